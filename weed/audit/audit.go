@@ -0,0 +1,204 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math/rand"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+)
+
+const (
+	// TypeAuth is a request rejected by the authentication or the authorization
+	TypeAuth = "auth"
+	// TypeAccess is a read or write of the objects or files
+	TypeAccess = "access"
+	// TypeAdmin is a change to the buckets or the configuration
+	TypeAdmin = "admin"
+
+	ResultAllowed = "allowed"
+	ResultDenied  = "denied"
+	ResultFailed  = "failed"
+
+	// BufferSize is how many events wait to be sent before new ones are dropped
+	BufferSize = 10000
+	// batchSize is the most events sent to the output at once
+	batchSize     = 100
+	flushInterval = time.Second
+)
+
+// Event is one audited request. It never carries the credentials, the query string or the full client ip.
+type Event struct {
+	Time      time.Time `json:"time"`
+	Service   string    `json:"service"`
+	Type      string    `json:"type"`
+	Result    string    `json:"result"`
+	RequestId string    `json:"requestId,omitempty"`
+	RemoteIp  string    `json:"remoteIp,omitempty"`
+	Identity  string    `json:"identity,omitempty"`
+	AccessKey string    `json:"accessKey,omitempty"`
+	AuthType  string    `json:"authType,omitempty"`
+	Action    string    `json:"action,omitempty"`
+	Method    string    `json:"method,omitempty"`
+	Bucket    string    `json:"bucket,omitempty"`
+	Path      string    `json:"path,omitempty"`
+	Status    int       `json:"status,omitempty"`
+	ErrorCode string    `json:"errorCode,omitempty"`
+	Duration  string    `json:"duration,omitempty"`
+}
+
+func (e *Event) Json() []byte {
+	data, _ := json.Marshal(e)
+	return data
+}
+
+type Option struct {
+	// Output is where the events go, see NewOutput
+	Output string
+	// SampleRate is the fraction of the allowed access events to keep.
+	// The denied and failed requests and the admin changes are always kept.
+	SampleRate float64
+	// HashPaths replaces the object keys and file paths with their hashes
+	HashPaths bool
+}
+
+// Logger sends the events to the output in the background.
+// Logging never blocks the request: when the output falls behind, the new events are dropped.
+type Logger struct {
+	service string
+	option  Option
+	output  Output
+	events  chan *Event
+	dropped int64
+}
+
+// NewLogger returns nil, which logs nothing, if there is no output configured.
+func NewLogger(service string, option Option) (*Logger, error) {
+	if option.Output == "" {
+		return nil, nil
+	}
+	output, err := NewOutput(option.Output)
+	if err != nil {
+		return nil, err
+	}
+	l := newLogger(service, option, output)
+	go l.loop()
+	glog.V(0).Infof("%s audit log to %s, sampling %v of the allowed access", service, output, option.SampleRate)
+	return l, nil
+}
+
+func newLogger(service string, option Option, output Output) *Logger {
+	return &Logger{
+		service: service,
+		option:  option,
+		output:  output,
+		events:  make(chan *Event, BufferSize),
+	}
+}
+
+// Log redacts and queues the event, unless it is sampled out or the buffer is full.
+func (l *Logger) Log(e *Event) {
+	if l == nil {
+		return
+	}
+	if e.Type == TypeAccess && e.Result == ResultAllowed && !sampled(l.option.SampleRate) {
+		return
+	}
+	e.Service = l.service
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	l.redact(e)
+	select {
+	case l.events <- e:
+	default:
+		stats.AuditEventCounter.WithLabelValues(l.service, "dropped").Inc()
+		if atomic.AddInt64(&l.dropped, 1)%1000 == 1 {
+			glog.Warningf("%s audit log to %s falls behind, dropped %d events", l.service, l.output, atomic.LoadInt64(&l.dropped))
+		}
+	}
+}
+
+func sampled(rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	return rate > 0 && rand.Float64() < rate
+}
+
+func (l *Logger) redact(e *Event) {
+	e.RemoteIp = MaskIp(e.RemoteIp)
+	e.AccessKey = MaskAccessKey(e.AccessKey)
+	if l.option.HashPaths && e.Path != "" {
+		e.Path = HashPath(e.Path)
+	}
+}
+
+func (l *Logger) loop() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	var batch []*Event
+	for {
+		select {
+		case e := <-l.events:
+			batch = append(batch, e)
+			if len(batch) < batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		l.send(batch)
+		batch = nil
+	}
+}
+
+func (l *Logger) send(batch []*Event) {
+	if err := l.output.Write(batch); err != nil {
+		glog.Warningf("%s audit log to %s: %v", l.service, l.output, err)
+		stats.AuditEventCounter.WithLabelValues(l.service, "failed").Add(float64(len(batch)))
+		return
+	}
+	stats.AuditEventCounter.WithLabelValues(l.service, "sent").Add(float64(len(batch)))
+}
+
+// MaskIp keeps the network of the client, the /24 of ipv4 or the /48 of ipv6.
+func MaskIp(remoteAddr string) string {
+	if remoteAddr == "" {
+		return ""
+	}
+	host := remoteAddr
+	if h, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		host = h
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return ""
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// MaskAccessKey keeps the first 4 characters, enough to tell the keys apart.
+func MaskAccessKey(accessKey string) string {
+	if len(accessKey) <= 4 {
+		return accessKey
+	}
+	return accessKey[:4] + strings.Repeat("*", len(accessKey)-4)
+}
+
+// HashPath replaces the path with its hash, so the same file is still correlated across events.
+func HashPath(path string) string {
+	h := sha256.Sum256([]byte(path))
+	return "sha256:" + hex.EncodeToString(h[:16])
+}
@@ -0,0 +1,180 @@
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const outputTimeout = 10 * time.Second
+
+// Output writes a batch of events to the SIEM.
+type Output interface {
+	Write(events []*Event) error
+	String() string
+}
+
+// NewOutput creates the output from its url:
+//
+//	file:///var/log/seaweedfs_audit.log   appends json lines to the file
+//	syslog://host:514                     sends RFC 5424 messages over udp
+//	syslog+tcp://host:514                 sends RFC 5424 messages over tcp, octet counted
+//	https://siem.example.com/ingest       posts newline delimited json
+func NewOutput(outputUrl string) (Output, error) {
+	u, err := url.Parse(outputUrl)
+	if err != nil {
+		return nil, fmt.Errorf("parse audit output %s: %v", outputUrl, err)
+	}
+	switch u.Scheme {
+	case "file":
+		if u.Path == "" {
+			return nil, fmt.Errorf("audit output %s has no file path", outputUrl)
+		}
+		return newFileOutput(u.Path)
+	case "syslog":
+		return newSyslogOutput("udp", u.Host), nil
+	case "syslog+tcp":
+		return newSyslogOutput("tcp", u.Host), nil
+	case "http", "https":
+		return &httpOutput{url: outputUrl, client: &http.Client{Timeout: outputTimeout}}, nil
+	}
+	return nil, fmt.Errorf("unknown audit output %s, expecting file://, syslog://, syslog+tcp://, http:// or https://", outputUrl)
+}
+
+type fileOutput struct {
+	path string
+	file *os.File
+}
+
+func newFileOutput(path string) (*fileOutput, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return nil, err
+	}
+	return &fileOutput{path: path, file: file}, nil
+}
+
+func (o *fileOutput) Write(events []*Event) error {
+	var buf bytes.Buffer
+	for _, e := range events {
+		buf.Write(e.Json())
+		buf.WriteByte('\n')
+	}
+	_, err := o.file.Write(buf.Bytes())
+	return err
+}
+
+func (o *fileOutput) String() string {
+	return "file://" + o.path
+}
+
+type syslogOutput struct {
+	network  string
+	address  string
+	hostName string
+	conn     net.Conn
+	sync.Mutex
+}
+
+func newSyslogOutput(network, address string) *syslogOutput {
+	hostName, _ := os.Hostname()
+	if hostName == "" {
+		hostName = "-"
+	}
+	return &syslogOutput{network: network, address: address, hostName: hostName}
+}
+
+const (
+	syslogFacilityLocal0   = 16
+	syslogSeverityWarning  = 4
+	syslogSeverityInfo     = 6
+	syslogAppName          = "seaweedfs"
+	syslogStructuredData   = "-"
+	syslogMessageIdUnknown = "-"
+)
+
+// format is a RFC 5424 message, with the event as json.
+func (o *syslogOutput) format(e *Event) []byte {
+	severity := syslogSeverityInfo
+	if e.Result != ResultAllowed {
+		severity = syslogSeverityWarning
+	}
+	msgId := e.Type
+	if msgId == "" {
+		msgId = syslogMessageIdUnknown
+	}
+	return []byte(fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		syslogFacilityLocal0*8+severity, e.Time.UTC().Format(time.RFC3339Nano), o.hostName, syslogAppName,
+		os.Getpid(), msgId, syslogStructuredData, e.Json()))
+}
+
+func (o *syslogOutput) Write(events []*Event) error {
+	o.Lock()
+	defer o.Unlock()
+	if o.conn == nil {
+		conn, err := net.DialTimeout(o.network, o.address, outputTimeout)
+		if err != nil {
+			return err
+		}
+		o.conn = conn
+	}
+	o.conn.SetWriteDeadline(time.Now().Add(outputTimeout))
+	for _, e := range events {
+		message := o.format(e)
+		if o.network == "tcp" {
+			// octet counting framing of RFC 6587
+			message = append([]byte(fmt.Sprintf("%d ", len(message))), message...)
+		}
+		if _, err := o.conn.Write(message); err != nil {
+			o.conn.Close()
+			o.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *syslogOutput) String() string {
+	if o.network == "tcp" {
+		return "syslog+tcp://" + o.address
+	}
+	return "syslog://" + o.address
+}
+
+type httpOutput struct {
+	url    string
+	client *http.Client
+}
+
+func (o *httpOutput) Write(events []*Event) error {
+	var buf bytes.Buffer
+	for _, e := range events {
+		buf.Write(e.Json())
+		buf.WriteByte('\n')
+	}
+	resp, err := o.client.Post(o.url, "application/x-ndjson", &buf)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("post to %s: %s", o.url, resp.Status)
+	}
+	return nil
+}
+
+// String hides the credentials the url may carry.
+func (o *httpOutput) String() string {
+	if u, err := url.Parse(o.url); err == nil {
+		return u.Redacted()
+	}
+	return strings.SplitN(o.url, "?", 2)[0]
+}
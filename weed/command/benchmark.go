@@ -99,8 +99,10 @@ var cmdBenchmark = &Command{
   before starting the benchmark command:
     http://localhost:9333/vol/grow?collection=benchmark&count=5
 
-  After benchmarking, you can clean up the written data by deleting the benchmark collection
-    http://localhost:9333/col/delete?collection=benchmark
+  After benchmarking, you can clean up the written data by deleting the benchmark collection.
+  Get a force token with a dry run first, since the collection is not empty:
+    http://localhost:9333/col/delete?collection=benchmark&dryRun=true
+    http://localhost:9333/col/delete?collection=benchmark&forceToken=<forceToken>
 
   `,
 }
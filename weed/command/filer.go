@@ -9,17 +9,20 @@ import (
 	"strings"
 	"time"
 
-	"google.golang.org/grpc/reflection"
-
+	"github.com/seaweedfs/seaweedfs/weed/audit"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
 	weed_server "github.com/seaweedfs/seaweedfs/weed/server"
 	stats_collect "github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/chunk_cache"
 )
 
+const filerChunkCacheMemoryEntries = 256
+
 var (
 	f               FilerOptions
 	filerStartS3    *bool
@@ -56,11 +59,31 @@ type FilerOptions struct {
 	localSocket             *string
 	showUIDirectoryDelete   *bool
 	downloadMaxMBps         *int
+	cacheDir                *string
+	cacheCapacityMB         *int
+	cacheSmallChunkSizeKB   *int
+	cacheMediumChunkSizeKB  *int
+	cacheMinReads           *int
+	checksumSha256          *bool
+	verifyChecksumOnRead    *bool
+	encryptedRangeReads     *bool
+	auditOutput             *string
+	auditSampleRate         *float64
+	auditHashPaths          *bool
+	slowLogThresholds       *string
+	metaVerifyMinutes       *int
+	metaVerifyApply         *bool
+	packLimitKB             *int
+	packContainerMB         *int
+	packWaitMs              *int
+	readOnly                *bool
+	archiveMaxSizeMB        *int
+	archiveMaxFiles         *int
 }
 
 func init() {
 	cmdFiler.Run = runFiler // break init cycle
-	f.mastersString = cmdFiler.Flag.String("master", "localhost:9333", "comma-separated master servers")
+	f.mastersString = cmdFiler.Flag.String("master", "localhost:9333", "comma-separated master servers, or dns:<host>:<port> and srv:<name> re-resolved periodically")
 	f.filerGroup = cmdFiler.Flag.String("filerGroup", "", "share metadata with other filers in the same filerGroup")
 	f.collection = cmdFiler.Flag.String("collection", "", "all data will be stored in this default collection")
 	f.ip = cmdFiler.Flag.String("ip", util.DetectedHostAddress(), "filer server http listen ip address")
@@ -85,16 +108,42 @@ func init() {
 	f.localSocket = cmdFiler.Flag.String("localSocket", "", "default to /tmp/seaweedfs-filer-<port>.sock")
 	f.showUIDirectoryDelete = cmdFiler.Flag.Bool("ui.deleteDir", true, "enable filer UI show delete directory button")
 	f.downloadMaxMBps = cmdFiler.Flag.Int("downloadMaxMBps", 0, "download max speed for each download request, in MB per second")
+	f.cacheDir = cmdFiler.Flag.String("cacheDir", "", "local directory to cache the chunks of files read through the filer, empty to disable")
+	f.cacheCapacityMB = cmdFiler.Flag.Int("cacheCapacityMB", 1024, "local chunk cache disk capacity in MB")
+	f.cacheSmallChunkSizeKB = cmdFiler.Flag.Int("cacheSmallChunkSizeKB", 1024, "chunks up to this size are cached in memory and in the small chunk cache tier")
+	f.cacheMediumChunkSizeKB = cmdFiler.Flag.Int("cacheMediumChunkSizeKB", 4096, "chunks up to this size are cached in the medium chunk cache tier, larger ones in the large tier")
+	f.cacheMinReads = cmdFiler.Flag.Int("cacheMinReads", 2, "cache a chunk only after it is read this many times recently")
+	f.checksumSha256 = cmdFiler.Flag.Bool("checksumSha256", false, "compute the sha256 of the files written over http, returned as the X-Amz-Checksum-Sha256 header")
+	f.verifyChecksumOnRead = cmdFiler.Flag.Bool("verifyChecksumOnRead", false, "verify the whole file reads against the saved sha256 or md5, aborting the response on a mismatch")
+	f.encryptedRangeReads = cmdFiler.Flag.Bool("encryptedRangeReads", false, "range reads of encrypted chunks only fetch and decrypt the part, which is not authenticated by the tag of the whole chunk")
+	f.auditOutput = cmdFiler.Flag.String("audit.output", "", "send the audit events to file:///path, syslog://host:514, syslog+tcp://host:514 or an http(s) url, empty to disable")
+	f.auditSampleRate = cmdFiler.Flag.Float64("audit.sampleRate", 1, "the fraction of the successful file access to audit, the failed and denied ones and the admin changes are always audited")
+	f.auditHashPaths = cmdFiler.Flag.Bool("audit.hashPaths", false, "audit the hashes of the file paths instead of the paths")
+	f.slowLogThresholds = cmdFiler.Flag.String("slowLog.thresholds", "", "log the requests slower than the thresholds as json, e.g. \"default=1s,GET=200ms,POST=2s\" per http method, empty to disable")
+	f.metaVerifyMinutes = cmdFiler.Flag.Int("metaVerify.intervalMinutes", 0, "verify the filer store reflects the meta log events of every interval, and log the divergences, 0 to disable")
+	f.metaVerifyApply = cmdFiler.Flag.Bool("metaVerify.apply", false, "apply the last meta log events of the diverged paths to the filer store again")
+	f.packLimitKB = cmdFiler.Flag.Int("pack.limitKB", 0, "pack the files up to this size and not saved in the filer store into shared container chunks, 0 to disable")
+	f.packContainerMB = cmdFiler.Flag.Int("pack.containerMB", 4, "the size of the container chunks the small files are packed into")
+	f.packWaitMs = cmdFiler.Flag.Int("pack.waitMs", 20, "upload a container chunk at most this long after the first file packed into it")
+	f.readOnly = cmdFiler.Flag.Bool("readOnly", false, "reject the changes with 503 and keep serving the reads, e.g. during a maintenance window, toggled at runtime by filer.readonly in weed shell")
+	f.archiveMaxSizeMB = cmdFiler.Flag.Int("archive.maxSizeMB", 1024, "the max size of the files in a directory downloaded as an archive with ?archive=tar or zip, 0 to disable")
+	f.archiveMaxFiles = cmdFiler.Flag.Int("archive.maxFiles", 10000, "the max number of files and sub directories in a directory downloaded as an archive")
 
 	// start s3 on filer
 	filerStartS3 = cmdFiler.Flag.Bool("s3", false, "whether to start S3 gateway")
 	filerS3Options.port = cmdFiler.Flag.Int("s3.port", 8333, "s3 server http listen port")
 	filerS3Options.portGrpc = cmdFiler.Flag.Int("s3.port.grpc", 0, "s3 server grpc listen port")
-	filerS3Options.domainName = cmdFiler.Flag.String("s3.domainName", "", "suffix of the host name in comma separated list, {bucket}.{domainName}")
+	filerS3Options.domainName = cmdFiler.Flag.String("s3.domainName", "", "suffixes of the host names of the virtual-hosted-style requests {bucket}.{domainName} in comma separated list, any port, e.g. with a wildcard certificate *.{domainName}")
 	filerS3Options.dataCenter = cmdFiler.Flag.String("s3.dataCenter", "", "prefer to read and write to volumes in this data center")
 	filerS3Options.config = cmdFiler.Flag.String("s3.config", "", "path to the config file")
 	filerS3Options.allowEmptyFolder = cmdFiler.Flag.Bool("s3.allowEmptyFolder", true, "allow empty folders")
 	filerS3Options.allowDeleteBucketNotEmpty = cmdFiler.Flag.Bool("s3.allowDeleteBucketNotEmpty", true, "allow recursive deleting all entries along with bucket")
+	filerS3Options.multipartMinPartSizeMB = cmdFiler.Flag.Int("s3.multipart.minPartSizeMB", 5, "the minimum size of the multipart upload parts except the last one")
+	filerS3Options.storageClasses = cmdFiler.Flag.String("s3.storageClasses", "", "comma separated storage classes and their disk types, e.g. STANDARD=ssd,STANDARD_IA=hdd. Other storage classes are rejected if set.")
+	filerS3Options.auditOutput = cmdFiler.Flag.String("s3.audit.output", "", "send the audit events to file:///path, syslog://host:514, syslog+tcp://host:514 or an http(s) url, empty to disable")
+	filerS3Options.auditSampleRate = cmdFiler.Flag.Float64("s3.audit.sampleRate", 1, "the fraction of the successful object access to audit, the failed and denied ones and the admin changes are always audited")
+	filerS3Options.auditHashPaths = cmdFiler.Flag.Bool("s3.audit.hashPaths", false, "audit the hashes of the object keys instead of the keys")
+	filerS3Options.slowLogThresholds = cmdFiler.Flag.String("s3.slowLog.thresholds", "", "log the requests slower than the thresholds as json, e.g. \"default=1s,GetObject=200ms,PutObject=2s\" per s3 action, empty to disable")
 
 	// start iam on filer
 	filerStartIam = cmdFiler.Flag.Bool("iam", false, "whether to start IAM service")
@@ -143,6 +192,9 @@ func runFiler(cmd *Command, args []string) bool {
 	}
 
 	util.LoadConfiguration("security", false)
+	if err := security.LoadHttpsClient(util.GetViper()); err != nil {
+		glog.Fatalf("https client: %v", err)
+	}
 
 	go stats_collect.StartMetricsServer(*f.metricsHttpPort)
 
@@ -215,11 +267,41 @@ func (fo *FilerOptions) startFiler() {
 		ConcurrentUploadLimit: int64(*fo.concurrentUploadLimitMB) * 1024 * 1024,
 		ShowUIDirectoryDelete: *fo.showUIDirectoryDelete,
 		DownloadMaxBytesPs:    int64(*fo.downloadMaxMBps) * 1024 * 1024,
+		ChecksumSha256:        *fo.checksumSha256,
+		VerifyChecksumOnRead:  *fo.verifyChecksumOnRead,
+		EncryptedRangeReads:   *fo.encryptedRangeReads,
+		Audit: audit.Option{
+			Output:     *fo.auditOutput,
+			SampleRate: *fo.auditSampleRate,
+			HashPaths:  *fo.auditHashPaths,
+		},
+		SlowLogThresholds:  *fo.slowLogThresholds,
+		MetaVerifyInterval: time.Duration(*fo.metaVerifyMinutes) * time.Minute,
+		MetaVerifyApply:    *fo.metaVerifyApply,
+		PackSmallFileLimit: int64(*fo.packLimitKB) * 1024,
+		PackContainerSize:  int64(*fo.packContainerMB) * 1024 * 1024,
+		PackWait:           time.Duration(*fo.packWaitMs) * time.Millisecond,
+		ReadOnly:           *fo.readOnly,
+		ArchiveMaxSize:     int64(*fo.archiveMaxSizeMB) * 1024 * 1024,
+		ArchiveMaxFiles:    *fo.archiveMaxFiles,
+		ChunkCacheOption: &chunk_cache.TieredChunkCacheOption{
+			MemoryEntries:        filerChunkCacheMemoryEntries,
+			Dir:                  util.ResolvePath(*fo.cacheDir),
+			DiskSize:             int64(*fo.cacheCapacityMB) * 1024 * 1024,
+			SmallChunkSizeLimit:  int64(*fo.cacheSmallChunkSizeKB) * 1024,
+			MediumChunkSizeLimit: int64(*fo.cacheMediumChunkSizeKB) * 1024,
+			MinReadsToAdmit:      *fo.cacheMinReads,
+		},
 	})
 	if nfs_err != nil {
 		glog.Fatalf("Filer startup error: %v", nfs_err)
 	}
 
+	tlsConfig, err := security.LoadHttpsServerTLS(util.GetViper(), "filer")
+	if err != nil {
+		glog.Fatalf("Filer https: %v", err)
+	}
+
 	if *fo.publicPort != 0 {
 		publicListeningAddress := util.JoinHostPort(*fo.bindIp, *fo.publicPort)
 		glog.V(0).Infoln("Start Seaweed filer server", util.Version(), "public at", publicListeningAddress)
@@ -227,14 +309,15 @@ func (fo *FilerOptions) startFiler() {
 		if e != nil {
 			glog.Fatalf("Filer server public listener error on port %d:%v", *fo.publicPort, e)
 		}
+		publicHttpS := &http.Server{Handler: publicVolumeMux, TLSConfig: tlsConfig}
 		go func() {
-			if e := http.Serve(publicListener, publicVolumeMux); e != nil {
+			if e := security.ServeHttp(publicHttpS, publicListener, tlsConfig != nil); e != nil {
 				glog.Fatalf("Volume server fail to serve public: %v", e)
 			}
 		}()
 		if localPublicListener != nil {
 			go func() {
-				if e := http.Serve(localPublicListener, publicVolumeMux); e != nil {
+				if e := security.ServeHttp(publicHttpS, localPublicListener, tlsConfig != nil); e != nil {
 					glog.Errorf("Volume server fail to serve public: %v", e)
 				}
 			}()
@@ -258,13 +341,16 @@ func (fo *FilerOptions) startFiler() {
 	}
 	grpcS := rpc.NewGrpcServer()
 	filer_pb.RegisterSeaweedFilerServer(grpcS, fs)
-	reflection.Register(grpcS)
+	rpc.RegisterReflection(grpcS)
 	if grpcLocalL != nil {
 		go grpcS.Serve(grpcLocalL)
 	}
 	go grpcS.Serve(grpcL)
 
 	httpS := &http.Server{Handler: defaultMux}
+	// the local unix socket stays on plain http for the s3 gateway in the same process
+	socketHttpS := &http.Server{Handler: defaultMux}
+	httpS.TLSConfig = tlsConfig
 
 	localSocket := *fo.localSocket
 	if localSocket == "" {
@@ -279,17 +365,17 @@ func (fo *FilerOptions) startFiler() {
 		if err != nil {
 			glog.Fatalf("Failed to listen on %s: %v", localSocket, err)
 		}
-		httpS.Serve(filerSocketListener)
+		socketHttpS.Serve(filerSocketListener)
 	}()
 
 	if filerLocalListener != nil {
 		go func() {
-			if err := httpS.Serve(filerLocalListener); err != nil {
+			if err := security.ServeHttp(httpS, filerLocalListener, tlsConfig != nil); err != nil {
 				glog.Errorf("Filer Fail to serve: %v", e)
 			}
 		}()
 	}
-	if err := httpS.Serve(filerListener); err != nil {
+	if err := security.ServeHttp(httpS, filerListener, tlsConfig != nil); err != nil {
 		glog.Fatalf("Filer Fail to serve: %v", e)
 	}
 
@@ -2,14 +2,18 @@ package command
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/replication"
+	"github.com/seaweedfs/seaweedfs/weed/replication/sink"
 	"github.com/seaweedfs/seaweedfs/weed/replication/source"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
@@ -21,6 +25,9 @@ type FilerBackupOptions struct {
 	debug           *bool
 	proxyByFiler    *bool
 	timeAgo         *time.Duration
+	verify          *bool
+	verifyReport    *string
+	repair          *bool
 }
 
 var (
@@ -35,6 +42,9 @@ func init() {
 	filerBackupOptions.proxyByFiler = cmdFilerBackup.Flag.Bool("filerProxy", false, "read and write file chunks by filer instead of volume servers")
 	filerBackupOptions.debug = cmdFilerBackup.Flag.Bool("debug", false, "debug mode to print out received files")
 	filerBackupOptions.timeAgo = cmdFilerBackup.Flag.Duration("timeAgo", 0, "start time before now. \"300ms\", \"1.5h\" or \"2h45m\". Valid time units are \"ns\", \"us\" (or \"µs\"), \"ms\", \"s\", \"m\", \"h\"")
+	filerBackupOptions.verify = cmdFilerBackup.Flag.Bool("verify", false, "read back each backed up file from the destination, and compare the size and md5")
+	filerBackupOptions.verifyReport = cmdFilerBackup.Flag.String("verifyReport", "", "append the files diverged from the source to this file, one json line each")
+	filerBackupOptions.repair = cmdFilerBackup.Flag.Bool("repair", false, "copy the diverged files again, implies -verify")
 }
 
 var cmdFilerBackup = &Command{
@@ -48,6 +58,10 @@ var cmdFilerBackup = &Command{
 	If restarted and "-timeAgo" is not set, the synchronization will resume from the previous checkpoints, persisted every minute.
 	A fresh sync will start from the earliest metadata logs. To reset the checkpoints, just set "-timeAgo" to a high value.
 
+	With "-verify", each file is read back from the destination after it is written, and compared with the source
+	by size, and by md5 when both sides know it. The diverged files are logged, and appended to "-verifyReport".
+	With "-repair", the diverged files are copied again.
+
 `,
 }
 
@@ -118,11 +132,50 @@ func doFilerBackup(grpcDialOption grpc.DialOption, backupOption *FilerBackupOpti
 
 	processEventFn := genProcessFunction(sourcePath, targetPath, excludePaths, dataSink, debug)
 
+	var verifier *replication.BackupVerifier
+	if *backupOption.verify || *backupOption.repair {
+		var err error
+		if verifier, err = replication.NewBackupVerifier(dataSink, *backupOption.repair, *backupOption.verifyReport); err != nil {
+			return err
+		}
+		processEventFn = genVerifyFunction(processEventFn, verifier, sourcePath, targetPath, excludePaths, dataSink)
+	}
+
 	processEventFnWithOffset := rpc.AddOffsetFunc(processEventFn, 3*time.Second, func(counter int64, lastTsNs int64) error {
 		glog.V(0).Infof("backup %s progressed to %v %0.2f/sec", sourceFiler, time.Unix(0, lastTsNs), float64(counter)/float64(3))
+		if verifier != nil {
+			glog.V(0).Infof("backup %s %v", sourceFiler, verifier)
+		}
 		return setOffset(grpcDialOption, sourceFiler, BackupKeyPrefix, int32(sinkId), lastTsNs)
 	})
 
 	return rpc.FollowMetadata(sourceFiler, grpcDialOption, "backup_"+dataSink.GetName(), clientId, clientEpoch, sourcePath, nil, startFrom.UnixNano(), 0, 0, processEventFnWithOffset, rpc.TrivialOnError)
 
 }
+
+// genVerifyFunction verifies the file written to the sink after each event is processed
+func genVerifyFunction(processEventFn func(resp *filer_pb.SubscribeMetadataResponse) error, verifier *replication.BackupVerifier,
+	sourcePath string, targetPath string, excludePaths []string, dataSink sink.ReplicationSink) func(resp *filer_pb.SubscribeMetadataResponse) error {
+	return func(resp *filer_pb.SubscribeMetadataResponse) error {
+		message := resp.EventNotification
+		if message.NewEntry == nil || message.NewEntry.IsDirectory || !strings.HasPrefix(resp.Directory, sourcePath) {
+			return processEventFn(resp)
+		}
+		for _, excludePath := range excludePaths {
+			if strings.HasPrefix(resp.Directory, excludePath) {
+				return processEventFn(resp)
+			}
+		}
+		sourceNewKey := util.FullPath(message.NewParentPath).Child(message.NewEntry.Name)
+		if !strings.HasPrefix(string(sourceNewKey), sourcePath) {
+			return processEventFn(resp)
+		}
+		// the key is built before processing, which changes the new parent path of updates
+		key := buildKey(dataSink, message, targetPath, sourceNewKey, sourcePath)
+
+		if err := processEventFn(resp); err != nil {
+			return err
+		}
+		return verifier.Verify(key, message.NewEntry, message.Signatures)
+	}
+}
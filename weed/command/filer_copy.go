@@ -1,7 +1,10 @@
 package command
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,6 +13,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
@@ -31,23 +35,35 @@ var (
 )
 
 type CopyOptions struct {
-	include           *string
-	replication       *string
-	collection        *string
-	ttl               *string
-	diskType          *string
-	maxMB             *int
-	masterClient      *wdclient.MasterClient
+	include          *string
+	replication      *string
+	collection       *string
+	ttl              *string
+	diskType         *string
+	maxMB            *int
+	masterClient     *wdclient.MasterClient
 	concurrentFiles  *int
 	concurrentChunks *int
-	grpcDialOption    grpc.DialOption
-	masters           []string
-	cipher            bool
-	ttlSec            int32
-	checkSize         *bool
-	verbose           *bool
+	grpcDialOption   grpc.DialOption
+	masters          []string
+	cipher           bool
+	ttlSec           int32
+	checkSize        *bool
+	checkHash        *bool
+	verbose          *bool
+	exclude          *string
+	skipHidden       *bool
+	excludePatterns  []string
+	errorFile        *string
+	report           copyReport
 }
 
+// the exit codes of filer.copy, besides 2 for the configuration errors like the other commands
+const (
+	copyExitSuccess        = 0
+	copyExitPartialFailure = 1 // some files failed to copy
+)
+
 func init() {
 	cmdFilerCopy.Run = runCopy // break init cycle
 	cmdFilerCopy.IsDebug = cmdFilerCopy.Flag.Bool("debug", false, "verbose debug information")
@@ -60,7 +76,11 @@ func init() {
 	copy.concurrentFiles = cmdFilerCopy.Flag.Int("c", 8, "concurrent file copy goroutines")
 	copy.concurrentChunks = cmdFilerCopy.Flag.Int("concurrentChunks", 8, "concurrent chunk copy goroutines for each file")
 	copy.checkSize = cmdFilerCopy.Flag.Bool("check.size", false, "copy when the target file size is different from the source file")
+	copy.checkHash = cmdFilerCopy.Flag.Bool("check.hash", false, "copy when the target file sha256 is different from the source file, ignoring the mtime")
 	copy.verbose = cmdFilerCopy.Flag.Bool("verbose", false, "print out details during copying")
+	copy.exclude = cmdFilerCopy.Flag.String("exclude", "", "comma separated patterns of files and folders to skip, e.g., .git,node_modules,*.tmp")
+	copy.skipHidden = cmdFilerCopy.Flag.Bool("skipHidden", false, "skip files and folders whose names start with a dot")
+	copy.errorFile = cmdFilerCopy.Flag.String("errorFile", "", "write the files failed to copy and their errors to this file, one per line")
 }
 
 var cmdFilerCopy = &Command{
@@ -73,9 +93,18 @@ var cmdFilerCopy = &Command{
   If copying a whole folder recursively:
   All files under the folder and sub folders will be copied.
   Optional parameter "-include" allows you to specify the file name patterns.
+  Optional parameter "-exclude" skips the files and sub folders matching any of the name patterns,
+  and "-skipHidden" skips the files and sub folders whose names start with a dot.
 
   If "maxMB" is set to a positive number, files larger than it would be split into chunks.
 
+  The sha256 of each file is saved in the target entry. With "-check.hash", the files with the same
+  sha256 as their target entries are skipped, so copying a large folder again only uploads the changes.
+
+  The last line of the output is a JSON summary of the copied, skipped and failed files.
+  Optional parameter "-errorFile" lists the failed files with their errors.
+  The exit code is 0 if all files are copied, 1 if some files failed, and 2 for configuration errors.
+
 `,
 }
 
@@ -103,6 +132,10 @@ func runCopy(cmd *Command, args []string) bool {
 		fmt.Printf("read from filer %s: %v\n", filerAddress, err)
 		return false
 	}
+	if len(masters) == 0 {
+		fmt.Printf("no master resolved from filer %s\n", filerAddress)
+		return false
+	}
 	if strings.HasPrefix(urlPath, dirBuckets+"/") {
 		restPath := urlPath[len(dirBuckets)+1:]
 		if strings.Index(restPath, "/") > 0 {
@@ -134,10 +167,16 @@ func runCopy(cmd *Command, args []string) bool {
 	}
 	copy.ttlSec = int32(ttl.Minutes()) * 60
 
+	if copy.excludePatterns, err = parseExcludePatterns(*copy.exclude); err != nil {
+		fmt.Printf("parsing exclude %s: %v\n", *copy.exclude, err)
+		return false
+	}
+
 	if *cmdFilerCopy.IsDebug {
 		grace.SetupProfiling("filer.copy.cpu.pprof", "filer.copy.mem.pprof")
 	}
 
+	startTime := time.Now()
 	fileCopyTaskChan := make(chan FileCopyTask, *copy.concurrentFiles)
 
 	go func() {
@@ -157,24 +196,97 @@ func runCopy(cmd *Command, args []string) bool {
 				options:      &copy,
 				filerAddress: filerAddress,
 			}
-			if err := worker.copyFiles(fileCopyTaskChan); err != nil {
-				fmt.Fprintf(os.Stderr, "copy file error: %v\n", err)
-				return
-			}
+			worker.copyFiles(fileCopyTaskChan)
 		}()
 	}
 	waitGroup.Wait()
 
+	summary := copy.report.summary(time.Since(startTime))
+	if *copy.errorFile != "" {
+		if err := copy.report.writeFailures(*copy.errorFile); err != nil {
+			fmt.Fprintf(os.Stderr, "write %s: %v\n", *copy.errorFile, err)
+		}
+	}
+	summaryJson, _ := json.Marshal(summary)
+	fmt.Printf("%s\n", summaryJson)
+
+	if summary.FilesFailed > 0 {
+		os.Exit(copyExitPartialFailure)
+	}
+	os.Exit(copyExitSuccess)
 	return true
 }
 
+// CopySummary is printed as JSON after filer.copy finishes
+type CopySummary struct {
+	FilesCopied     int64   `json:"filesCopied"`
+	FilesSkipped    int64   `json:"filesSkipped"`
+	FilesFailed     int64   `json:"filesFailed"`
+	BytesCopied     int64   `json:"bytesCopied"`
+	DurationSeconds float64 `json:"durationSeconds"`
+}
+
+type copyFailure struct {
+	path string
+	err  error
+}
+
+// copyReport counts the files handled by the concurrent workers
+type copyReport struct {
+	filesCopied  int64
+	filesSkipped int64
+	bytesCopied  int64
+	failures     []copyFailure
+	failuresLock sync.Mutex
+}
+
+func (r *copyReport) addCopied(size int64) {
+	atomic.AddInt64(&r.filesCopied, 1)
+	atomic.AddInt64(&r.bytesCopied, size)
+}
+
+func (r *copyReport) addSkipped() {
+	atomic.AddInt64(&r.filesSkipped, 1)
+}
+
+func (r *copyReport) addFailure(path string, err error) {
+	fmt.Fprintf(os.Stderr, "copy %s: %v\n", path, err)
+	r.failuresLock.Lock()
+	r.failures = append(r.failures, copyFailure{path: path, err: err})
+	r.failuresLock.Unlock()
+}
+
+func (r *copyReport) summary(duration time.Duration) CopySummary {
+	r.failuresLock.Lock()
+	defer r.failuresLock.Unlock()
+	return CopySummary{
+		FilesCopied:     atomic.LoadInt64(&r.filesCopied),
+		FilesSkipped:    atomic.LoadInt64(&r.filesSkipped),
+		FilesFailed:     int64(len(r.failures)),
+		BytesCopied:     atomic.LoadInt64(&r.bytesCopied),
+		DurationSeconds: duration.Seconds(),
+	}
+}
+
+func (r *copyReport) writeFailures(fileName string) error {
+	r.failuresLock.Lock()
+	defer r.failuresLock.Unlock()
+	var buf strings.Builder
+	for _, failure := range r.failures {
+		fmt.Fprintf(&buf, "%s\t%v\n", failure.path, strings.TrimSpace(failure.err.Error()))
+	}
+	return os.WriteFile(fileName, []byte(buf.String()), 0644)
+}
+
 func readFilerConfiguration(grpcDialOption grpc.DialOption, filerGrpcAddress rpc.ServerAddress) (masters []string, collection, replication string, dirBuckets string, maxMB uint32, cipher bool, err error) {
 	err = rpc.WithGrpcFilerClient(false, filerGrpcAddress, grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
 		resp, err := client.GetFilerConfiguration(context.Background(), &filer_pb.GetFilerConfigurationRequest{})
 		if err != nil {
 			return fmt.Errorf("get filer %s configuration: %v", filerGrpcAddress, err)
 		}
-		masters, collection, replication, maxMB = resp.Masters, resp.Collection, resp.Replication, resp.MaxMb
+		// older filers return the masters given as dns names unresolved
+		masters = rpc.ToAddressStrings(rpc.ResolveServerAddresses(rpc.FromAddressStrings(resp.Masters)))
+		collection, replication, maxMB = resp.Collection, resp.Replication, resp.MaxMb
 		dirBuckets = resp.DirBuckets
 		cipher = resp.Cipher
 		return nil
@@ -186,7 +298,7 @@ func genFileCopyTask(fileOrDir string, destPath string, fileCopyTaskChan chan Fi
 
 	fi, err := os.Stat(fileOrDir)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: read file %s: %v\n", fileOrDir, err)
+		copy.report.addFailure(fileOrDir, fmt.Errorf("read file: %v", err))
 		return nil
 	}
 
@@ -209,6 +321,12 @@ func genFileCopyTask(fileOrDir string, destPath string, fileCopyTaskChan chan Fi
 	if mode.IsDir() {
 		files, _ := os.ReadDir(fileOrDir)
 		for _, subFileOrDir := range files {
+			if isExcludedFromCopy(subFileOrDir.Name(), copy.excludePatterns, *copy.skipHidden) {
+				if *copy.verbose {
+					fmt.Printf("skipping excluded %s/%s\n", fileOrDir, subFileOrDir.Name())
+				}
+				continue
+			}
 			cleanedDestDirectory := destPath + fi.Name()
 			if err = genFileCopyTask(fileOrDir+"/"+subFileOrDir.Name(), cleanedDestDirectory+"/", fileCopyTaskChan); err != nil {
 				return err
@@ -219,18 +337,46 @@ func genFileCopyTask(fileOrDir string, destPath string, fileCopyTaskChan chan Fi
 	return nil
 }
 
+func parseExcludePatterns(exclude string) (patterns []string, err error) {
+	for _, pattern := range strings.Split(exclude, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err = filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("pattern %s: %v", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return
+}
+
+// isExcludedFromCopy checks the name of a file or folder under the copied folders.
+// The files and folders listed on the command line are always copied.
+func isExcludedFromCopy(name string, excludePatterns []string, skipHidden bool) bool {
+	if skipHidden && strings.HasPrefix(name, ".") {
+		return true
+	}
+	for _, pattern := range excludePatterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
 type FileCopyWorker struct {
 	options      *CopyOptions
 	filerAddress rpc.ServerAddress
 }
 
-func (worker *FileCopyWorker) copyFiles(fileCopyTaskChan chan FileCopyTask) error {
+// copyFiles copies each file, and keeps copying the other files after a failure.
+func (worker *FileCopyWorker) copyFiles(fileCopyTaskChan chan FileCopyTask) {
 	for task := range fileCopyTaskChan {
 		if err := worker.doEachCopy(task); err != nil {
-			return err
+			worker.options.report.addFailure(task.sourceLocation, err)
 		}
 	}
-	return nil
 }
 
 type FileCopyTask struct {
@@ -240,17 +386,13 @@ type FileCopyTask struct {
 	fileMode           os.FileMode
 	uid                uint32
 	gid                uint32
+	sha256             []byte
 }
 
 func (worker *FileCopyWorker) doEachCopy(task FileCopyTask) error {
 
 	f, err := os.Open(task.sourceLocation)
 	if err != nil {
-		fmt.Printf("Failed to open file %s: %v\n", task.sourceLocation, err)
-		if _, ok := err.(*os.PathError); ok {
-			fmt.Printf("skipping %s\n", task.sourceLocation)
-			return nil
-		}
 		return err
 	}
 	defer f.Close()
@@ -262,12 +404,21 @@ func (worker *FileCopyWorker) doEachCopy(task FileCopyTask) error {
 		}
 	}
 
+	if task.fileMode.IsRegular() {
+		if task.sha256, err = sha256OfFile(f); err != nil {
+			return fmt.Errorf("hash file: %v", err)
+		}
+	}
+
 	if shouldCopy, err := worker.checkExistingFileFirst(task, f); err != nil {
 		return fmt.Errorf("check existing file: %v", err)
 	} else if !shouldCopy {
 		if *worker.options.verbose {
 			fmt.Printf("skipping copied file: %v\n", f.Name())
 		}
+		if !task.fileMode.IsDir() {
+			worker.options.report.addSkipped()
+		}
 		return nil
 	}
 
@@ -279,17 +430,21 @@ func (worker *FileCopyWorker) doEachCopy(task FileCopyTask) error {
 	}
 
 	if chunkCount == 1 {
-		return worker.uploadFileAsOne(task, f)
+		err = worker.uploadFileAsOne(task, f)
+	} else {
+		err = worker.uploadFileInChunks(task, f, chunkCount, chunkSize)
 	}
-
-	return worker.uploadFileInChunks(task, f, chunkCount, chunkSize)
+	if err == nil && !task.fileMode.IsDir() {
+		worker.options.report.addCopied(task.fileSize)
+	}
+	return err
 }
 
 func (worker *FileCopyWorker) checkExistingFileFirst(task FileCopyTask, f *os.File) (shouldCopy bool, err error) {
 
 	shouldCopy = true
 
-	if !*worker.options.checkSize {
+	if !*worker.options.checkSize && !*worker.options.checkHash {
 		return
 	}
 
@@ -312,7 +467,10 @@ func (worker *FileCopyWorker) checkExistingFileFirst(task FileCopyTask, f *os.Fi
 			return nil
 		}
 
-		if fileStat.Size() == int64(filer.FileSize(resp.Entry)) {
+		if *worker.options.checkHash {
+			// the same content, even if the mtime differs
+			shouldCopy = len(task.sha256) == 0 || !bytes.Equal(task.sha256, resp.Entry.Attributes.GetSha256())
+		} else if fileStat.Size() == int64(filer.FileSize(resp.Entry)) {
 			shouldCopy = false
 		}
 
@@ -346,6 +504,7 @@ func (worker *FileCopyWorker) uploadFileAsOne(task FileCopyTask, f *os.File) err
 				TtlSec:      worker.options.ttlSec,
 				DiskType:    *worker.options.diskType,
 				Path:        task.destinationUrlPath,
+				MaxSize:     operation.UploadMaxSize(int64(len(data))),
 			},
 			&operation.UploadOption{
 				Filename:          fileName,
@@ -379,6 +538,7 @@ func (worker *FileCopyWorker) uploadFileAsOne(task FileCopyTask, f *os.File) err
 					FileMode: uint32(task.fileMode),
 					Mime:     mimeType,
 					TtlSec:   worker.options.ttlSec,
+					Sha256:   task.sha256,
 				},
 				Chunks: chunks,
 			},
@@ -425,6 +585,7 @@ func (worker *FileCopyWorker) uploadFileInChunks(task FileCopyTask, f *os.File,
 					TtlSec:      worker.options.ttlSec,
 					DiskType:    *worker.options.diskType,
 					Path:        task.destinationUrlPath + fileName,
+					MaxSize:     operation.UploadMaxSize(chunkSize),
 				},
 				&operation.UploadOption{
 					Filename:          fileName + "-" + strconv.FormatInt(i+1, 10),
@@ -490,6 +651,7 @@ func (worker *FileCopyWorker) uploadFileInChunks(task FileCopyTask, f *os.File,
 					FileMode: uint32(task.fileMode),
 					Mime:     mimeType,
 					TtlSec:   worker.options.ttlSec,
+					Sha256:   task.sha256,
 				},
 				Chunks: manifestedChunks,
 			},
@@ -508,6 +670,18 @@ func (worker *FileCopyWorker) uploadFileInChunks(task FileCopyTask, f *os.File,
 	return nil
 }
 
+// sha256OfFile hashes the whole file, and rewinds it for the upload
+func sha256OfFile(f *os.File) ([]byte, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
 func detectMimeType(f *os.File) string {
 	head := make([]byte, 512)
 	f.Seek(0, io.SeekStart)
@@ -0,0 +1,47 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenFileCopyTaskExcludes(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.tmp", ".hidden", ".git/config", "node_modules/x.js", "src/c.txt", "src/d.tmp"} {
+		assert.Nil(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+	}
+
+	copied := func(exclude string, skipHidden bool) (names []string) {
+		var err error
+		copy.excludePatterns, err = parseExcludePatterns(exclude)
+		assert.Nil(t, err)
+		*copy.skipHidden = skipHidden
+		defer func() {
+			copy.excludePatterns, *copy.skipHidden = nil, false
+		}()
+
+		taskChan := make(chan FileCopyTask, 100)
+		assert.Nil(t, genFileCopyTask(dir, "/dest/", taskChan))
+		close(taskChan)
+		for task := range taskChan {
+			if !task.fileMode.IsDir() {
+				relative, _ := filepath.Rel(dir, task.sourceLocation)
+				names = append(names, relative)
+			}
+		}
+		sort.Strings(names)
+		return
+	}
+
+	assert.Equal(t, []string{".git/config", ".hidden", "a.txt", "b.tmp", "node_modules/x.js", "src/c.txt", "src/d.tmp"}, copied("", false))
+	assert.Equal(t, []string{"a.txt", "b.tmp", "node_modules/x.js", "src/c.txt", "src/d.tmp"}, copied("", true))
+	assert.Equal(t, []string{".hidden", "a.txt", "src/c.txt"}, copied("*.tmp, .git,node_modules", false))
+
+	_, err := parseExcludePatterns("[a")
+	assert.NotNil(t, err)
+}
@@ -1,8 +1,6 @@
 package command
 
 import (
-	"context"
-	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -52,7 +50,6 @@ type SyncOptions struct {
 }
 
 const (
-	SyncKeyPrefix         = "sync."
 	DefaultConcurrencyLimit = 32
 )
 
@@ -137,7 +134,7 @@ func runFilerSynchronize(cmd *Command, args []string) bool {
 	go func() {
 		// a->b
 		// set synchronization start timestamp to offset
-		initOffsetError := initOffsetFromTsMs(grpcDialOption, filerB, aFilerSignature, *syncOptions.bFromTsMs, getSignaturePrefixByPath(*syncOptions.aPath))
+		initOffsetError := initOffsetFromTsMs(grpcDialOption, filerB, aFilerSignature, *syncOptions.bFromTsMs, replication.SyncSignaturePrefixByPath(*syncOptions.aPath))
 		if initOffsetError != nil {
 			glog.Errorf("init offset from timestamp %d error from %s to %s: %v", *syncOptions.bFromTsMs, *syncOptions.filerA, *syncOptions.filerB, initOffsetError)
 			os.Exit(2)
@@ -173,7 +170,7 @@ func runFilerSynchronize(cmd *Command, args []string) bool {
 	if !*syncOptions.isActivePassive {
 		// b->a
 		// set synchronization start timestamp to offset
-		initOffsetError := initOffsetFromTsMs(grpcDialOption, filerA, bFilerSignature, *syncOptions.aFromTsMs, getSignaturePrefixByPath(*syncOptions.bPath))
+		initOffsetError := initOffsetFromTsMs(grpcDialOption, filerA, bFilerSignature, *syncOptions.aFromTsMs, replication.SyncSignaturePrefixByPath(*syncOptions.bPath))
 		if initOffsetError != nil {
 			glog.Errorf("init offset from timestamp %d error from %s to %s: %v", *syncOptions.aFromTsMs, *syncOptions.filerB, *syncOptions.filerA, initOffsetError)
 			os.Exit(2)
@@ -234,13 +231,26 @@ func doSubscribeFilerMetaChanges(clientId int32, clientEpoch int32, grpcDialOpti
 
 	// if first time, start from now
 	// if has previously synced, resume from that point of time
-	sourceFilerOffsetTsNs, err := getOffset(grpcDialOption, targetFiler, getSignaturePrefixByPath(sourcePath), sourceFilerSignature)
+	sourceFilerOffsetTsNs, err := getOffset(grpcDialOption, targetFiler, replication.SyncSignaturePrefixByPath(sourcePath), sourceFilerSignature)
 	if err != nil {
 		return err
 	}
 
 	glog.V(0).Infof("start sync %s(%d) => %s(%d) from %v(%d)", sourceFiler, sourceFilerSignature, targetFiler, targetFilerSignature, time.Unix(0, sourceFilerOffsetTsNs), sourceFilerOffsetTsNs)
 
+	// so the offset can be listed and changed by filer.sync.offset.* in weed shell
+	registerErr := rpc.WithFilerClient(false, targetFiler, grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		return replication.RegisterSyncOffset(client, &filer_pb.FilerSyncOffset{
+			SourceFiler:     string(sourceFiler),
+			SourceSignature: sourceFilerSignature,
+			SourcePath:      sourcePath,
+			TargetPath:      targetPath,
+		})
+	})
+	if registerErr != nil {
+		glog.Warningf("register sync offset of %s on %s: %v", sourceFiler, targetFiler, registerErr)
+	}
+
 	// create filer sink
 	filerSource := &source.FilerSource{}
 	filerSource.DoInitialize(sourceFiler.ToHttpAddress(), sourceFiler.ToGrpcAddress(), sourcePath, sourceReadChunkFromFiler)
@@ -282,7 +292,7 @@ func doSubscribeFilerMetaChanges(clientId int32, clientEpoch int32, grpcDialOpti
 		lastLogTsNs = now
 		// collect synchronous offset
 		statsCollect.FilerSyncOffsetGauge.WithLabelValues(sourceFiler.String(), targetFiler.String(), clientName, sourcePath).Set(float64(processor.processedTsWatermark))
-		return setOffset(grpcDialOption, targetFiler, getSignaturePrefixByPath(sourcePath), sourceFilerSignature, processor.processedTsWatermark)
+		return setOffset(grpcDialOption, targetFiler, replication.SyncSignaturePrefixByPath(sourcePath), sourceFilerSignature, processor.processedTsWatermark)
 	})
 
 	return rpc.FollowMetadata(sourceFiler, grpcDialOption, clientName, clientId, clientEpoch,
@@ -290,37 +300,11 @@ func doSubscribeFilerMetaChanges(clientId int32, clientEpoch int32, grpcDialOpti
 
 }
 
-// When each business is distinguished according to path, and offsets need to be maintained separately.
-func getSignaturePrefixByPath(path string) string {
-	// compatible historical version
-	if path == "/" {
-		return SyncKeyPrefix
-	} else {
-		return SyncKeyPrefix + path
-	}
-}
-
 func getOffset(grpcDialOption grpc.DialOption, filer rpc.ServerAddress, signaturePrefix string, signature int32) (lastOffsetTsNs int64, readErr error) {
 
-	readErr = rpc.WithFilerClient(false, filer, grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
-		syncKey := []byte(signaturePrefix + "____")
-		util.Uint32toBytes(syncKey[len(signaturePrefix):len(signaturePrefix)+4], uint32(signature))
-
-		resp, err := client.KvGet(context.Background(), &filer_pb.KvGetRequest{Key: syncKey})
-		if err != nil {
-			return err
-		}
-
-		if len(resp.Error) != 0 {
-			return errors.New(resp.Error)
-		}
-		if len(resp.Value) < 8 {
-			return nil
-		}
-
-		lastOffsetTsNs = int64(util.BytesToUint64(resp.Value))
-
-		return nil
+	readErr = rpc.WithFilerClient(false, filer, grpcDialOption, func(client filer_pb.SeaweedFilerClient) (err error) {
+		lastOffsetTsNs, err = replication.GetSyncOffset(client, replication.SyncOffsetKey(signaturePrefix, signature))
+		return err
 	})
 
 	return
@@ -329,29 +313,8 @@ func getOffset(grpcDialOption grpc.DialOption, filer rpc.ServerAddress, signatur
 
 func setOffset(grpcDialOption grpc.DialOption, filer rpc.ServerAddress, signaturePrefix string, signature int32, offsetTsNs int64) error {
 	return rpc.WithFilerClient(false, filer, grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
-
-		syncKey := []byte(signaturePrefix + "____")
-		util.Uint32toBytes(syncKey[len(signaturePrefix):len(signaturePrefix)+4], uint32(signature))
-
-		valueBuf := make([]byte, 8)
-		util.Uint64toBytes(valueBuf, uint64(offsetTsNs))
-
-		resp, err := client.KvPut(context.Background(), &filer_pb.KvPutRequest{
-			Key:   syncKey,
-			Value: valueBuf,
-		})
-		if err != nil {
-			return err
-		}
-
-		if len(resp.Error) != 0 {
-			return errors.New(resp.Error)
-		}
-
-		return nil
-
+		return replication.SetSyncOffset(client, replication.SyncOffsetKey(signaturePrefix, signature), offsetTsNs)
 	})
-
 }
 
 func genProcessFunction(sourcePath string, targetPath string, excludePaths []string, dataSink sink.ReplicationSink, debug bool) func(resp *filer_pb.SubscribeMetadataResponse) error {
@@ -413,7 +376,7 @@ func genProcessFunction(sourcePath string, targetPath string, excludePaths []str
 				if !dataSink.IsIncremental() {
 					oldKey := util.Join(targetPath, string(sourceOldKey)[len(sourcePath):])
 					message.NewParentPath = util.Join(targetPath, message.NewParentPath[len(sourcePath):])
-					foundExisting, err := dataSink.UpdateEntry(string(oldKey), message.OldEntry, message.NewParentPath, message.NewEntry, message.DeleteChunks, message.Signatures)
+					foundExisting, err := sink.UpdateEntry(dataSink, string(oldKey), message)
 					if foundExisting {
 						return err
 					}
@@ -7,6 +7,9 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/storage"
@@ -22,20 +25,42 @@ func init() {
 }
 
 var cmdFix = &Command{
-	UsageLine: "fix [-volumeId=234] [-collection=bigData] /tmp",
+	UsageLine: "fix [-volumeId=234] [-collection=bigData] [-concurrency=4] /tmp",
 	Short:     "run weed tool fix on files or whole folders to recreate index file(s) if corrupted",
 	Long: `Fix runs the SeaweedFS fix command on dat files or whole folders to re-create the index .idx file.
+
+  All volumes found in the folders are repaired, -concurrency of them at a time.
+  With -verifyCrc, the needle data is read and checked while scanning,
+  and needles with mismatched CRC are left out of the index.
+  A summary of repaired and unrepairable needles is printed at the end.
   `,
 }
 
 var (
 	fixVolumeCollection = cmdFix.Flag.String("collection", "", "an optional volume collection name, if specified only it will be processed")
 	fixVolumeId         = cmdFix.Flag.Int64("volumeId", 0, "an optional volume id, if not 0 (default) only it will be processed")
+	fixConcurrency      = cmdFix.Flag.Int("concurrency", 1, "number of volumes to repair at the same time")
+	fixVerifyCrc        = cmdFix.Flag.Bool("verifyCrc", true, "read needle data and verify the CRC while scanning")
+	fixProgress         = cmdFix.Flag.Duration("progressInterval", 10*time.Second, "interval to print the scanning progress, 0 to disable")
 )
 
 type VolumeFileScanner4Fix struct {
-	version needle.Version
-	nm      *needle_map.MemDb
+	version      needle.Version
+	nm           *needle_map.MemDb
+	verifyCrc    bool
+	result       *fixVolumeResult
+	datSize      int64
+	lastProgress time.Time
+}
+
+type fixVolumeResult struct {
+	baseFileName   string
+	liveCount      int64
+	deletedCount   int64
+	crcErrorCount  int64
+	scannedBytes   int64
+	err            error
+	processingTime time.Duration
 }
 
 func (scanner *VolumeFileScanner4Fix) VisitSuperBlock(superBlock super_block.SuperBlock) error {
@@ -44,22 +69,62 @@ func (scanner *VolumeFileScanner4Fix) VisitSuperBlock(superBlock super_block.Sup
 
 }
 func (scanner *VolumeFileScanner4Fix) ReadNeedleBody() bool {
-	return false
+	return scanner.verifyCrc
 }
 
 func (scanner *VolumeFileScanner4Fix) VisitNeedle(n *needle.Needle, offset int64, needleHeader, needleBody []byte) error {
 	glog.V(2).Infof("key %d offset %d size %d disk_size %d compressed %v", n.Id, offset, n.Size, n.DiskSize(scanner.version), n.IsCompressed())
+	scanner.reportProgress(offset + n.DiskSize(scanner.version))
 	if n.Size.IsValid() {
+		if scanner.verifyCrc && !isNeedleChecksumValid(n, needleBody) {
+			glog.V(0).Infof("%s: skipping needle %d at offset %d with CRC error", scanner.result.baseFileName, n.Id, offset)
+			scanner.result.crcErrorCount++
+			return nil
+		}
 		pe := scanner.nm.Set(n.Id, types.ToOffset(offset), n.Size)
 		glog.V(2).Infof("saved %d with error %v", n.Size, pe)
+		scanner.result.liveCount++
 	} else {
 		glog.V(2).Infof("skipping deleted file ...")
+		scanner.result.deletedCount++
 		return scanner.nm.Delete(n.Id)
 	}
 	return nil
 }
 
+func (scanner *VolumeFileScanner4Fix) reportProgress(scannedBytes int64) {
+	scanner.result.scannedBytes = scannedBytes
+	if *fixProgress <= 0 || time.Since(scanner.lastProgress) < *fixProgress {
+		return
+	}
+	scanner.lastProgress = time.Now()
+	if scanner.datSize > 0 {
+		fmt.Printf("%s: scanned %.1f%% (%d/%d bytes)\n", scanner.result.baseFileName, float64(scannedBytes)*100/float64(scanner.datSize), scannedBytes, scanner.datSize)
+	}
+}
+
+func isNeedleChecksumValid(n *needle.Needle, needleBody []byte) bool {
+	if len(needleBody) < int(n.Size)+needle.NeedleChecksumSize {
+		return false
+	}
+	if n.Size == 0 {
+		return true
+	}
+	checksum := util.BytesToUint32(needleBody[n.Size : int(n.Size)+needle.NeedleChecksumSize])
+	newChecksum := n.GetChecksumAlgorithm().Checksum(n.Data)
+	// the crc.Value() function is to be deprecated. this double checking is for backward compatible.
+	return checksum == newChecksum.Value() || checksum == uint32(newChecksum)
+}
+
+type fixVolumeTask struct {
+	basePath     string
+	baseFileName string
+	collection   string
+	volumeId     int64
+}
+
 func runFix(cmd *Command, args []string) bool {
+	var tasks []fixVolumeTask
 	for _, arg := range args {
 		basePath, f := path.Split(util.ResolvePath(arg))
 		if util.FolderExists(arg) {
@@ -107,13 +172,73 @@ func runFix(cmd *Command, args []string) bool {
 			if *fixVolumeId != 0 && *fixVolumeId != volumeId {
 				continue
 			}
-			doFixOneVolume(basePath, baseFileName, collection, volumeId)
+			tasks = append(tasks, fixVolumeTask{
+				basePath:     basePath,
+				baseFileName: baseFileName,
+				collection:   collection,
+				volumeId:     volumeId,
+			})
 		}
 	}
+
+	if *fixConcurrency < 1 {
+		*fixConcurrency = 1
+	}
+
+	results := make([]*fixVolumeResult, len(tasks))
+	var wg sync.WaitGroup
+	var finishedCount int32
+	executor := util.NewLimitedConcurrentExecutor(*fixConcurrency)
+	for i, task := range tasks {
+		wg.Add(1)
+		i, task := i, task
+		executor.Execute(func() {
+			defer wg.Done()
+			results[i] = doFixOneVolume(task.basePath, task.baseFileName, task.collection, task.volumeId)
+			finished := atomic.AddInt32(&finishedCount, 1)
+			if results[i].err != nil {
+				fmt.Printf("[%d/%d] %s failed: %v\n", finished, len(tasks), task.baseFileName, results[i].err)
+			} else {
+				fmt.Printf("[%d/%d] %s repaired in %v\n", finished, len(tasks), task.baseFileName, results[i].processingTime)
+			}
+		})
+	}
+	wg.Wait()
+
+	printFixSummary(results)
 	return true
 }
 
-func doFixOneVolume(basepath string, baseFileName string, collection string, volumeId int64) {
+func printFixSummary(results []*fixVolumeResult) {
+	var totalLive, totalDeleted, totalCrcErrors int64
+	var failedCount int
+	fmt.Printf("\n%-32s %12s %12s %12s  %s\n", "volume", "needles", "deleted", "crcErrors", "status")
+	for _, r := range results {
+		status := "ok"
+		if r.err != nil {
+			status = r.err.Error()
+			failedCount++
+		} else if r.crcErrorCount > 0 {
+			status = "partially repaired"
+		}
+		fmt.Printf("%-32s %12d %12d %12d  %s\n", r.baseFileName, r.liveCount, r.deletedCount, r.crcErrorCount, status)
+		totalLive += r.liveCount
+		totalDeleted += r.deletedCount
+		totalCrcErrors += r.crcErrorCount
+	}
+	fmt.Printf("\nvolumes:%d failed:%d repaired needles:%d deleted needles:%d unrepairable needles:%d\n",
+		len(results), failedCount, totalLive, totalDeleted, totalCrcErrors)
+}
+
+func doFixOneVolume(basepath string, baseFileName string, collection string, volumeId int64) (result *fixVolumeResult) {
+
+	result = &fixVolumeResult{
+		baseFileName: baseFileName,
+	}
+	startTime := time.Now()
+	defer func() {
+		result.processingTime = time.Since(startTime)
+	}()
 
 	indexFileName := path.Join(basepath, baseFileName+".idx")
 
@@ -122,15 +247,24 @@ func doFixOneVolume(basepath string, baseFileName string, collection string, vol
 
 	vid := needle.VolumeId(volumeId)
 	scanner := &VolumeFileScanner4Fix{
-		nm: nm,
+		nm:           nm,
+		verifyCrc:    *fixVerifyCrc,
+		result:       result,
+		lastProgress: startTime,
+	}
+	if stat, err := os.Stat(path.Join(basepath, baseFileName+".dat")); err == nil {
+		scanner.datSize = stat.Size()
 	}
 
 	if err := storage.ScanVolumeFile(basepath, collection, vid, storage.NeedleMapInMemory, scanner); err != nil {
-		glog.Fatalf("scan .dat File: %v", err)
+		result.err = fmt.Errorf("scan .dat File: %v", err)
+		return
 	}
 
 	if err := nm.SaveToIdx(indexFileName); err != nil {
 		os.Remove(indexFileName)
-		glog.Fatalf("save to .idx File: %v", err)
+		result.err = fmt.Errorf("save to .idx File: %v", err)
+		return
 	}
+	return
 }
@@ -0,0 +1,51 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/backend"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+func TestFixXxhashVolume(t *testing.T) {
+	dir := t.TempDir()
+	superBlock := &super_block.SuperBlock{
+		Version:          needle.CurrentVersion,
+		ReplicaPlacement: &super_block.ReplicaPlacement{},
+		Ttl:              needle.EMPTY_TTL,
+		Extra:            &master_pb.SuperBlockExtra{ChecksumAlgorithm: uint32(needle.ChecksumXxhash)},
+	}
+	f, err := os.Create(filepath.Join(dir, "1.dat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.Write(superBlock.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	datFile := backend.NewDiskFile(f)
+	for i := 1; i <= 4; i++ {
+		n := &needle.Needle{Id: types.Uint64ToNeedleId(uint64(i)), Data: []byte("needle data")}
+		n.SetChecksumAlgorithm(needle.ChecksumXxhash)
+		if i == 4 {
+			// corrupted, the checksum does not match the algorithm of the flags
+			n.Checksum = needle.ChecksumCrc32c.Checksum(n.Data)
+		}
+		if _, _, _, err = n.Append(datFile, needle.CurrentVersion); err != nil {
+			t.Fatal(err)
+		}
+	}
+	datFile.Close()
+
+	result := doFixOneVolume(dir, "1", "", 1)
+	if result.err != nil {
+		t.Fatalf("fix: %v", result.err)
+	}
+	if result.liveCount != 3 || result.crcErrorCount != 1 {
+		t.Errorf("fixed %d needles with %d crc errors, expected 3 and 1", result.liveCount, result.crcErrorCount)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/iamapi"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
@@ -32,7 +33,7 @@ type IamOptions struct {
 func init() {
 	cmdIam.Run = runIam // break init cycle
 	iamStandaloneOptions.filer = cmdIam.Flag.String("filer", "localhost:8888", "filer server address")
-	iamStandaloneOptions.masters = cmdIam.Flag.String("master", "localhost:9333", "comma-separated master servers")
+	iamStandaloneOptions.masters = cmdIam.Flag.String("master", "localhost:9333", "comma-separated master servers, or dns:<host>:<port> and srv:<name> re-resolved periodically")
 	iamStandaloneOptions.ip = cmdIam.Flag.String("ip", util.DetectedHostAddress(), "iam server http listen ip address")
 	iamStandaloneOptions.port = cmdIam.Flag.Int("port", 8111, "iam server http listen port")
 }
@@ -44,6 +45,10 @@ var cmdIam = &Command{
 }
 
 func runIam(cmd *Command, args []string) bool {
+	util.LoadConfiguration("security", false)
+	if err := security.LoadHttpsClient(util.GetViper()); err != nil {
+		glog.Fatalf("https client: %v", err)
+	}
 	return iamStandaloneOptions.startIamServer()
 }
 
@@ -82,7 +87,11 @@ func (iamopt *IamOptions) startIamServer() bool {
 		glog.Fatalf("IAM API Server startup error: %v", iamApiServer_err)
 	}
 
-	httpS := &http.Server{Handler: router}
+	tlsConfig, err := security.LoadHttpsServerTLS(util.GetViper(), "iam")
+	if err != nil {
+		glog.Fatalf("IAM API Server https: %v", err)
+	}
+	httpS := &http.Server{Handler: router, TLSConfig: tlsConfig}
 
 	listenAddress := fmt.Sprintf(":%d", *iamopt.port)
 	iamApiListener, iamApiLocalListener, err := util.NewIpAndLocalListeners(*iamopt.ip, *iamopt.port, time.Duration(10)*time.Second)
@@ -93,12 +102,12 @@ func (iamopt *IamOptions) startIamServer() bool {
 	glog.V(0).Infof("Start Seaweed IAM API Server %s at http port %d", util.Version(), *iamopt.port)
 	if iamApiLocalListener != nil {
 		go func() {
-			if err = httpS.Serve(iamApiLocalListener); err != nil {
+			if err = security.ServeHttp(httpS, iamApiLocalListener, tlsConfig != nil); err != nil {
 				glog.Errorf("IAM API Server Fail to serve: %v", err)
 			}
 		}()
 	}
-	if err = httpS.Serve(iamApiListener); err != nil {
+	if err = security.ServeHttp(httpS, iamApiListener, tlsConfig != nil); err != nil {
 		glog.Fatalf("IAM API Server Fail to serve: %v", err)
 	}
 
@@ -10,7 +10,6 @@ import (
 	"github.com/gorilla/mux"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/reflection"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
@@ -18,6 +17,7 @@ import (
 	weed_server "github.com/seaweedfs/seaweedfs/weed/server"
 	stats_collect "github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/storage/backend"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 	"github.com/seaweedfs/seaweedfs/weed/util/grace"
 )
@@ -35,6 +35,7 @@ type MasterOptions struct {
 	peers             *string
 	volumeSizeLimitMB *uint
 	volumePreallocate *bool
+	volumeChecksum    *string
 	// pulseSeconds       *int
 	defaultReplication *string
 	garbageThreshold   *float64
@@ -59,6 +60,7 @@ func init() {
 	m.peers = cmdMaster.Flag.String("peers", "", "all master nodes in comma separated ip:port list, example: 127.0.0.1:9093,127.0.0.1:9094,127.0.0.1:9095")
 	m.volumeSizeLimitMB = cmdMaster.Flag.Uint("volumeSizeLimitMB", 30*1000, "Master stops directing writes to oversized volumes.")
 	m.volumePreallocate = cmdMaster.Flag.Bool("volumePreallocate", false, "Preallocate disk space for volumes.")
+	m.volumeChecksum = cmdMaster.Flag.String("volumeChecksum", "crc32c", "checksum of the needles in the new volumes, crc32c or xxhash. xxhash is faster on the CPUs without CRC32 instructions.")
 	// m.pulseSeconds = cmdMaster.Flag.Int("pulseSeconds", 5, "number of seconds between heartbeats")
 	m.defaultReplication = cmdMaster.Flag.String("defaultReplication", "", "Default replication type if not specified.")
 	m.garbageThreshold = cmdMaster.Flag.Float64("garbageThreshold", 0.3, "threshold to vacuum and reclaim spaces")
@@ -87,6 +89,7 @@ var (
 )
 
 func runMaster(cmd *Command, args []string) bool {
+	util.LoadConfiguration("security", false)
 	util.LoadConfiguration("master", false)
 
 	grace.SetupProfiling(*masterCpuProfile, *masterMemProfile)
@@ -120,6 +123,10 @@ func startMaster(masterOption MasterOptions, masterWhiteList []string) {
 		*masterOption.ipBind = *masterOption.ip
 	}
 
+	if _, err := needle.ParseChecksumAlgorithm(*masterOption.volumeChecksum); err != nil {
+		glog.Fatalf("volumeChecksum: %v", err)
+	}
+
 	myMasterAddress, peers := checkPeers(*masterOption.ip, *masterOption.port, *masterOption.portGrpc, *masterOption.peers)
 
 	masterPeers := make(map[string]rpc.ServerAddress)
@@ -170,7 +177,7 @@ func startMaster(masterOption MasterOptions, masterWhiteList []string) {
 
 	raftServer.TransportManager.Register(grpcS)
 
-	reflection.Register(grpcS)
+	rpc.RegisterReflection(grpcS)
 	glog.V(0).Infof("Start Seaweed Master %s grpc server at %s:%d", util.Version(), *masterOption.ipBind, grpcPort)
 	if grpcLocalL != nil {
 		go grpcS.Serve(grpcLocalL)
@@ -218,6 +225,7 @@ func (m *MasterOptions) toMasterOption(whiteList []string) *weed_server.MasterOp
 		MetaFolder:        *m.metaFolder,
 		VolumeSizeLimitMB: uint32(*m.volumeSizeLimitMB),
 		VolumePreallocate: *m.volumePreallocate,
+		VolumeChecksum:    *m.volumeChecksum,
 		// PulseSeconds:            *m.pulseSeconds,
 		DefaultReplicaPlacement: *m.defaultReplication,
 		GarbageThreshold:        *m.garbageThreshold,
@@ -10,7 +10,6 @@ import (
 	"github.com/gorilla/mux"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/reflection"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
@@ -34,6 +33,7 @@ func init() {
 	mf.metaFolder = aws.String("")
 	mf.volumeSizeLimitMB = nil
 	mf.volumePreallocate = nil
+	mf.volumeChecksum = aws.String("")
 	mf.defaultReplication = nil
 	mf.garbageThreshold = aws.Float64(0.1)
 	mf.whiteList = nil
@@ -132,7 +132,7 @@ func startMasterFollower(masterOptions MasterOptions) {
 	}
 	grpcS := rpc.NewGrpcServer()
 	master_pb.RegisterSeaweedServer(grpcS, ms)
-	reflection.Register(grpcS)
+	rpc.RegisterReflection(grpcS)
 	glog.V(0).Infof("Start Seaweed Master %s grpc server at %s:%d", util.Version(), *masterOptions.ip, grpcPort)
 	if grpcLocalL != nil {
 		go grpcS.Serve(grpcLocalL)
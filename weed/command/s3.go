@@ -9,12 +9,15 @@ import (
 	"github.com/gorilla/mux"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/reflection"
 
+	"github.com/seaweedfs/seaweedfs/weed/audit"
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/iamapi"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/s3api"
+	"github.com/seaweedfs/seaweedfs/weed/security"
 	stats_collect "github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
@@ -25,6 +28,7 @@ var (
 
 type S3Options struct {
 	filer                     *string
+	filerReadRoundRobin       *bool
 	bindIp                    *string
 	port                      *int
 	portGrpc                  *int
@@ -35,21 +39,34 @@ type S3Options struct {
 	allowDeleteBucketNotEmpty *bool
 	localFilerSocket          *string
 	dataCenter                *string
+	multipartMinPartSizeMB    *int
+	storageClasses            *string
+	auditOutput               *string
+	auditSampleRate           *float64
+	auditHashPaths            *bool
+	slowLogThresholds         *string
 }
 
 func init() {
 	cmdS3.Run = runS3 // break init cycle
-	s3StandaloneOptions.filer = cmdS3.Flag.String("filer", "localhost:8888", "filer server address")
+	s3StandaloneOptions.filer = cmdS3.Flag.String("filer", "localhost:8888", "comma-separated filer server addresses, failing over to the next one if the current filer is unavailable")
+	s3StandaloneOptions.filerReadRoundRobin = cmdS3.Flag.Bool("filer.readRoundRobin", false, "spread object reads over all healthy filers")
 	s3StandaloneOptions.bindIp = cmdS3.Flag.String("ip.bind", "", "ip address to bind to. Default to localhost.")
 	s3StandaloneOptions.port = cmdS3.Flag.Int("port", 8333, "s3 server http listen port")
 	s3StandaloneOptions.portGrpc = cmdS3.Flag.Int("port.grpc", 0, "s3 server grpc listen port")
-	s3StandaloneOptions.domainName = cmdS3.Flag.String("domainName", "", "suffix of the host name in comma separated list, {bucket}.{domainName}")
+	s3StandaloneOptions.domainName = cmdS3.Flag.String("domainName", "", "suffixes of the host names of the virtual-hosted-style requests {bucket}.{domainName} in comma separated list, any port, e.g. with a wildcard certificate *.{domainName}")
 	s3StandaloneOptions.dataCenter = cmdS3.Flag.String("dataCenter", "", "prefer to read and write to volumes in this data center")
 	s3StandaloneOptions.config = cmdS3.Flag.String("config", "", "path to the config file")
 	s3StandaloneOptions.metricsHttpPort = cmdS3.Flag.Int("metricsPort", 0, "Prometheus metrics listen port")
 	s3StandaloneOptions.allowEmptyFolder = cmdS3.Flag.Bool("allowEmptyFolder", true, "allow empty folders")
 	s3StandaloneOptions.allowDeleteBucketNotEmpty = cmdS3.Flag.Bool("allowDeleteBucketNotEmpty", true, "allow recursive deleting all entries along with bucket")
 	s3StandaloneOptions.localFilerSocket = cmdS3.Flag.String("localFilerSocket", "", "local filer socket path")
+	s3StandaloneOptions.multipartMinPartSizeMB = cmdS3.Flag.Int("multipart.minPartSizeMB", 5, "the minimum size of the multipart upload parts except the last one")
+	s3StandaloneOptions.storageClasses = cmdS3.Flag.String("storageClasses", "", "comma separated storage classes and their disk types, e.g. STANDARD=ssd,STANDARD_IA=hdd. Other storage classes are rejected if set.")
+	s3StandaloneOptions.auditOutput = cmdS3.Flag.String("audit.output", "", "send the audit events to file:///path, syslog://host:514, syslog+tcp://host:514 or an http(s) url, empty to disable")
+	s3StandaloneOptions.auditSampleRate = cmdS3.Flag.Float64("audit.sampleRate", 1, "the fraction of the successful object access to audit, the failed and denied ones and the admin changes are always audited")
+	s3StandaloneOptions.auditHashPaths = cmdS3.Flag.Bool("audit.hashPaths", false, "audit the hashes of the object keys instead of the keys")
+	s3StandaloneOptions.slowLogThresholds = cmdS3.Flag.String("slowLog.thresholds", "", "log the requests slower than the thresholds as json, e.g. \"default=1s,GetObject=200ms,PutObject=2s\" per s3 action, empty to disable")
 }
 
 var cmdS3 = &Command{
@@ -129,12 +146,18 @@ var cmdS3 = &Command{
   ]
 }
 
+	The bucket inventory reports are generated in the CSV format only.
+	The inventory configurations asking for the Parquet or ORC format are rejected as unsupported.
+
 `,
 }
 
 func runS3(cmd *Command, args []string) bool {
 
 	util.LoadConfiguration("security", false)
+	if err := security.LoadHttpsClient(util.GetViper()); err != nil {
+		glog.Fatalf("https client: %v", err)
+	}
 
 	go stats_collect.StartMetricsServer(*s3StandaloneOptions.metricsHttpPort)
 
@@ -144,7 +167,10 @@ func runS3(cmd *Command, args []string) bool {
 
 func (s3opt *S3Options) startS3Server() bool {
 
-	filerAddress := rpc.ServerAddress(*s3opt.filer)
+	filerAddresses := rpc.ServerAddresses(*s3opt.filer).ToAddresses()
+	if len(filerAddresses) == 0 {
+		glog.Fatalf("missing filer address")
+	}
 
 	filerBucketsPath := "/buckets"
 
@@ -155,10 +181,10 @@ func (s3opt *S3Options) startS3Server() bool {
 	var metricsIntervalSec int
 
 	for {
-		err := rpc.WithGrpcFilerClient(false, filerAddress, grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		err := rpc.WithOneOfGrpcFilerClients(false, filerAddresses, grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
 			resp, err := client.GetFilerConfiguration(context.Background(), &filer_pb.GetFilerConfigurationRequest{})
 			if err != nil {
-				return fmt.Errorf("get filer %s configuration: %v", filerAddress, err)
+				return fmt.Errorf("get filer configuration: %v", err)
 			}
 			filerBucketsPath = resp.DirBuckets
 			metricsAddress, metricsIntervalSec = resp.MetricsAddress, int(resp.MetricsIntervalSec)
@@ -166,10 +192,10 @@ func (s3opt *S3Options) startS3Server() bool {
 			return nil
 		})
 		if err != nil {
-			glog.V(0).Infof("wait to connect to filer %s grpc address %s", *s3opt.filer, filerAddress.ToGrpcAddress())
+			glog.V(0).Infof("wait to connect to filers %s: %v", *s3opt.filer, err)
 			time.Sleep(time.Second)
 		} else {
-			glog.V(0).Infof("connected to filer %s grpc address %s", *s3opt.filer, filerAddress.ToGrpcAddress())
+			glog.V(0).Infof("connected to filers %s", *s3opt.filer)
 			break
 		}
 	}
@@ -181,8 +207,19 @@ func (s3opt *S3Options) startS3Server() bool {
 	if s3opt.localFilerSocket != nil {
 		localFilerSocket = *s3opt.localFilerSocket
 	}
+	var filerReadRoundRobin bool
+	if s3opt.filerReadRoundRobin != nil {
+		filerReadRoundRobin = *s3opt.filerReadRoundRobin
+	}
+	identityLoader, err := newS3IdentityLoader(filerAddresses[0], grpcDialOption)
+	if err != nil {
+		glog.Fatalf("S3 API Server iam credential store: %v", err)
+	}
+
 	s3ApiServer, s3ApiServer_err := s3api.NewS3ApiServer(router, &s3api.S3ApiServerOption{
-		Filer:                     filerAddress,
+		Filer:                     filerAddresses[0],
+		Filers:                    filerAddresses,
+		FilerReadRoundRobin:       filerReadRoundRobin,
 		Port:                      *s3opt.port,
 		Config:                    *s3opt.config,
 		DomainName:                *s3opt.domainName,
@@ -192,12 +229,25 @@ func (s3opt *S3Options) startS3Server() bool {
 		AllowDeleteBucketNotEmpty: *s3opt.allowDeleteBucketNotEmpty,
 		LocalFilerSocket:          localFilerSocket,
 		DataCenter:                *s3opt.dataCenter,
+		MultipartMinPartSize:      int64(*s3opt.multipartMinPartSizeMB) * 1024 * 1024,
+		StorageClasses:            *s3opt.storageClasses,
+		Audit: audit.Option{
+			Output:     *s3opt.auditOutput,
+			SampleRate: *s3opt.auditSampleRate,
+			HashPaths:  *s3opt.auditHashPaths,
+		},
+		SlowLogThresholds: *s3opt.slowLogThresholds,
+		IdentityLoader:    identityLoader,
 	})
 	if s3ApiServer_err != nil {
 		glog.Fatalf("S3 API Server startup error: %v", s3ApiServer_err)
 	}
 
-	httpS := &http.Server{Handler: router}
+	tlsConfig, err := security.LoadHttpsServerTLS(util.GetViper(), "s3")
+	if err != nil {
+		glog.Fatalf("S3 API Server https: %v", err)
+	}
+	httpS := &http.Server{Handler: router, TLSConfig: tlsConfig}
 
 	if *s3opt.portGrpc == 0 {
 		*s3opt.portGrpc = 10000 + *s3opt.port
@@ -220,7 +270,7 @@ func (s3opt *S3Options) startS3Server() bool {
 	}
 	grpcS := rpc.NewGrpcServer()
 	rpc.RegisterS3Server(grpcS, s3ApiServer)
-	reflection.Register(grpcS)
+	rpc.RegisterReflection(grpcS)
 	if grpcLocalL != nil {
 		go grpcS.Serve(grpcLocalL)
 	}
@@ -229,15 +279,34 @@ func (s3opt *S3Options) startS3Server() bool {
 	glog.V(0).Infof("Start Seaweed S3 API Server %s at http port %d", util.Version(), *s3opt.port)
 	if s3ApiLocalListener != nil {
 		go func() {
-			if err = httpS.Serve(s3ApiLocalListener); err != nil {
+			if err = security.ServeHttp(httpS, s3ApiLocalListener, tlsConfig != nil); err != nil {
 				glog.Fatalf("S3 API Server Fail to serve: %v", err)
 			}
 		}()
 	}
-	if err = httpS.Serve(s3ApiListener); err != nil {
+	if err = security.ServeHttp(httpS, s3ApiListener, tlsConfig != nil); err != nil {
 		glog.Fatalf("S3 API Server Fail to serve: %v", err)
 	}
 
 	return true
 
 }
+
+// newS3IdentityLoader loads the identities from the iam credential store configured in security.toml,
+// if it is not the filer, so the secrets are never copied into the filer.
+func newS3IdentityLoader(filerAddress rpc.ServerAddress, grpcDialOption grpc.DialOption) (func() ([]byte, error), error) {
+	v := util.GetViper()
+	if name := v.GetString("iam.credential.store"); name == "" || name == "filer" {
+		return nil, nil
+	}
+	store, err := iamapi.NewCredentialStore(v, &iamapi.IamServerOption{
+		Filer:          filerAddress,
+		GrpcDialOption: grpcDialOption,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return func() ([]byte, error) {
+		return store.Load(filer.IamIdentityFile)
+	}, nil
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/security"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 	"github.com/seaweedfs/seaweedfs/weed/util/grace"
 )
@@ -87,6 +88,7 @@ func init() {
 	masterOptions.peers = cmdServer.Flag.String("master.peers", "", "all master nodes in comma separated ip:masterPort list")
 	masterOptions.volumeSizeLimitMB = cmdServer.Flag.Uint("master.volumeSizeLimitMB", 30*1000, "Master stops directing writes to oversized volumes.")
 	masterOptions.volumePreallocate = cmdServer.Flag.Bool("master.volumePreallocate", false, "Preallocate disk space for volumes.")
+	masterOptions.volumeChecksum = cmdServer.Flag.String("master.volumeChecksum", "crc32c", "checksum of the needles in the new volumes, crc32c or xxhash. xxhash is faster on the CPUs without CRC32 instructions.")
 	masterOptions.defaultReplication = cmdServer.Flag.String("master.defaultReplication", "", "Default replication type if not specified.")
 	masterOptions.garbageThreshold = cmdServer.Flag.Float64("garbageThreshold", 0.3, "threshold to vacuum and reclaim spaces")
 	masterOptions.metricsAddress = cmdServer.Flag.String("metrics.address", "", "Prometheus gateway address")
@@ -110,6 +112,26 @@ func init() {
 	filerOptions.localSocket = cmdServer.Flag.String("filer.localSocket", "", "default to /tmp/seaweedfs-filer-<port>.sock")
 	filerOptions.showUIDirectoryDelete = cmdServer.Flag.Bool("filer.ui.deleteDir", true, "enable filer UI show delete directory button")
 	filerOptions.downloadMaxMBps = cmdServer.Flag.Int("filer.downloadMaxMBps", 0, "download max speed for each download request, in MB per second")
+	filerOptions.cacheDir = cmdServer.Flag.String("filer.cacheDir", "", "local directory to cache the chunks of files read through the filer, empty to disable")
+	filerOptions.cacheCapacityMB = cmdServer.Flag.Int("filer.cacheCapacityMB", 1024, "local chunk cache disk capacity in MB")
+	filerOptions.cacheSmallChunkSizeKB = cmdServer.Flag.Int("filer.cacheSmallChunkSizeKB", 1024, "chunks up to this size are cached in memory and in the small chunk cache tier")
+	filerOptions.cacheMediumChunkSizeKB = cmdServer.Flag.Int("filer.cacheMediumChunkSizeKB", 4096, "chunks up to this size are cached in the medium chunk cache tier, larger ones in the large tier")
+	filerOptions.cacheMinReads = cmdServer.Flag.Int("filer.cacheMinReads", 2, "cache a chunk only after it is read this many times recently")
+	filerOptions.checksumSha256 = cmdServer.Flag.Bool("filer.checksumSha256", false, "compute the sha256 of the files written over http, returned as the X-Amz-Checksum-Sha256 header")
+	filerOptions.verifyChecksumOnRead = cmdServer.Flag.Bool("filer.verifyChecksumOnRead", false, "verify the whole file reads against the saved sha256 or md5, aborting the response on a mismatch")
+	filerOptions.encryptedRangeReads = cmdServer.Flag.Bool("filer.encryptedRangeReads", false, "range reads of encrypted chunks only fetch and decrypt the part, which is not authenticated by the tag of the whole chunk")
+	filerOptions.auditOutput = cmdServer.Flag.String("filer.audit.output", "", "send the audit events to file:///path, syslog://host:514, syslog+tcp://host:514 or an http(s) url, empty to disable")
+	filerOptions.auditSampleRate = cmdServer.Flag.Float64("filer.audit.sampleRate", 1, "the fraction of the successful file access to audit, the failed and denied ones and the admin changes are always audited")
+	filerOptions.auditHashPaths = cmdServer.Flag.Bool("filer.audit.hashPaths", false, "audit the hashes of the file paths instead of the paths")
+	filerOptions.slowLogThresholds = cmdServer.Flag.String("filer.slowLog.thresholds", "", "log the requests slower than the thresholds as json, e.g. \"default=1s,GET=200ms,POST=2s\" per http method, empty to disable")
+	filerOptions.metaVerifyMinutes = cmdServer.Flag.Int("filer.metaVerify.intervalMinutes", 0, "verify the filer store reflects the meta log events of every interval, and log the divergences, 0 to disable")
+	filerOptions.metaVerifyApply = cmdServer.Flag.Bool("filer.metaVerify.apply", false, "apply the last meta log events of the diverged paths to the filer store again")
+	filerOptions.packLimitKB = cmdServer.Flag.Int("filer.pack.limitKB", 0, "pack the files up to this size and not saved in the filer store into shared container chunks, 0 to disable")
+	filerOptions.packContainerMB = cmdServer.Flag.Int("filer.pack.containerMB", 4, "the size of the container chunks the small files are packed into")
+	filerOptions.packWaitMs = cmdServer.Flag.Int("filer.pack.waitMs", 20, "upload a container chunk at most this long after the first file packed into it")
+	filerOptions.readOnly = cmdServer.Flag.Bool("filer.readOnly", false, "reject the changes with 503 and keep serving the reads, e.g. during a maintenance window, toggled at runtime by filer.readonly in weed shell")
+	filerOptions.archiveMaxSizeMB = cmdServer.Flag.Int("filer.archive.maxSizeMB", 1024, "the max size of the files in a directory downloaded as an archive with ?archive=tar or zip, 0 to disable")
+	filerOptions.archiveMaxFiles = cmdServer.Flag.Int("filer.archive.maxFiles", 10000, "the max number of files and sub directories in a directory downloaded as an archive")
 
 	serverOptions.v.port = cmdServer.Flag.Int("volume.port", 8080, "volume server http listen port")
 	serverOptions.v.portGrpc = cmdServer.Flag.Int("volume.port.grpc", 0, "volume server grpc listen port")
@@ -126,15 +148,33 @@ func init() {
 	serverOptions.v.pprof = cmdServer.Flag.Bool("volume.pprof", false, "enable pprof http handlers. precludes --memprofile and --cpuprofile")
 	serverOptions.v.idxFolder = cmdServer.Flag.String("volume.dir.idx", "", "directory to store .idx files")
 	serverOptions.v.inflightUploadDataTimeout = cmdServer.Flag.Duration("volume.inflightUploadDataTimeout", 60*time.Second, "inflight upload data wait timeout of volume servers")
+	serverOptions.v.inflightUploadQueueLength = cmdServer.Flag.Int("volume.inflightUploadQueueLength", 256, "the uploads waiting beyond concurrentUploadLimitMB, more are rejected with 503 at once")
 	serverOptions.v.hasSlowRead = cmdServer.Flag.Bool("volume.hasSlowRead", false, "<experimental> if true, this prevents slow reads from blocking other requests, but large file read P99 latency will increase.")
 	serverOptions.v.readBufferSizeMB = cmdServer.Flag.Int("volume.readBufferSizeMB", 4, "<experimental> larger values can optimize query performance but will increase some memory usage,Use with hasSlowRead normally")
+	serverOptions.v.diskSmart = cmdServer.Flag.Bool("volume.disk.smart", false, "report the SMART health of the disks to the master, requires smartctl")
+	serverOptions.v.diskSpillover = cmdServer.Flag.Bool("volume.disk.spillover", false, "when a write runs out of disk space, move the volume to another directory of the same disk type with free space")
+	serverOptions.v.readRepair = cmdServer.Flag.Bool("volume.readRepair", false, "when a read fails the CRC check, serve the file from another replica and overwrite the local corrupted copy")
+	serverOptions.v.peerRepair = cmdServer.Flag.Bool("volume.peerRepair", false, "when the master finds a replica missing files or deletions of its peers, pull them directly from the peers")
+	serverOptions.v.loadConcurrency = cmdServer.Flag.Int("volume.load.concurrency", 0, "the number of volumes loaded at the same time in each directory on startup, 0 to choose by the cpu count")
+	serverOptions.v.loadTimeout = cmdServer.Flag.Duration("volume.load.timeout", 0, "quarantine a volume not loaded in this time on startup, reporting it to the master, 0 to wait forever")
+	serverOptions.v.slowLogThresholds = cmdServer.Flag.String("volume.slowLog.thresholds", "", "log the requests slower than the thresholds as json, e.g. \"default=1s,GET=200ms,POST=2s\" per http method, empty to disable")
+	serverOptions.v.preallocate = cmdServer.Flag.String("volume.dir.preallocate", "false", "preallocate the .dat files of new volumes to the volume size limit to reduce fragmentation, true|false[,true|false]...")
+	serverOptions.v.directIO = cmdServer.Flag.String("volume.dir.directIO", "false", "read with O_DIRECT during compaction and erasure coding to keep the page cache for hot data, true|false[,true|false]...")
 
 	s3Options.port = cmdServer.Flag.Int("s3.port", 8333, "s3 server http listen port")
+	s3Options.filer = cmdServer.Flag.String("s3.filer", "", "comma-separated filer addresses for s3 to fail over to. Default to the local filer only.")
+	s3Options.filerReadRoundRobin = cmdServer.Flag.Bool("s3.filer.readRoundRobin", false, "spread s3 object reads over all healthy filers")
 	s3Options.portGrpc = cmdServer.Flag.Int("s3.port.grpc", 0, "s3 server grpc listen port")
-	s3Options.domainName = cmdServer.Flag.String("s3.domainName", "", "suffix of the host name in comma separated list, {bucket}.{domainName}")
+	s3Options.domainName = cmdServer.Flag.String("s3.domainName", "", "suffixes of the host names of the virtual-hosted-style requests {bucket}.{domainName} in comma separated list, any port, e.g. with a wildcard certificate *.{domainName}")
 	s3Options.config = cmdServer.Flag.String("s3.config", "", "path to the config file")
 	s3Options.allowEmptyFolder = cmdServer.Flag.Bool("s3.allowEmptyFolder", true, "allow empty folders")
 	s3Options.allowDeleteBucketNotEmpty = cmdServer.Flag.Bool("s3.allowDeleteBucketNotEmpty", true, "allow recursive deleting all entries along with bucket")
+	s3Options.multipartMinPartSizeMB = cmdServer.Flag.Int("s3.multipart.minPartSizeMB", 5, "the minimum size of the multipart upload parts except the last one")
+	s3Options.storageClasses = cmdServer.Flag.String("s3.storageClasses", "", "comma separated storage classes and their disk types, e.g. STANDARD=ssd,STANDARD_IA=hdd. Other storage classes are rejected if set.")
+	s3Options.auditOutput = cmdServer.Flag.String("s3.audit.output", "", "send the audit events to file:///path, syslog://host:514, syslog+tcp://host:514 or an http(s) url, empty to disable")
+	s3Options.auditSampleRate = cmdServer.Flag.Float64("s3.audit.sampleRate", 1, "the fraction of the successful object access to audit, the failed and denied ones and the admin changes are always audited")
+	s3Options.auditHashPaths = cmdServer.Flag.Bool("s3.audit.hashPaths", false, "audit the hashes of the object keys instead of the keys")
+	s3Options.slowLogThresholds = cmdServer.Flag.String("s3.slowLog.thresholds", "", "log the requests slower than the thresholds as json, e.g. \"default=1s,GetObject=200ms,PutObject=2s\" per s3 action, empty to disable")
 
 	iamOptions.port = cmdServer.Flag.Int("iam.port", 8111, "iam server http listen port")
 }
@@ -146,6 +186,9 @@ func runServer(cmd *Command, args []string) bool {
 	}
 
 	util.LoadConfiguration("security", false)
+	if err := security.LoadHttpsClient(util.GetViper()); err != nil {
+		glog.Fatalf("https client: %v", err)
+	}
 	util.LoadConfiguration("master", false)
 
 	grace.SetupProfiling(*serverOptions.cpuprofile, *serverOptions.memprofile)
@@ -195,7 +238,9 @@ func runServer(cmd *Command, args []string) bool {
 	masterOptions.disableHttp = serverDisableHttp
 
 	filerAddress := string(rpc.NewServerAddress(*serverIp, *filerOptions.port, *filerOptions.portGrpc))
-	s3Options.filer = &filerAddress
+	if *s3Options.filer == "" {
+		s3Options.filer = &filerAddress
+	}
 	iamOptions.filer = &filerAddress
 
 	go stats_collect.StartMetricsServer(*serverMetricsHttpPort)
@@ -2,11 +2,14 @@ package command
 
 import (
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
+	"github.com/seaweedfs/seaweedfs/weed/security"
 	"github.com/seaweedfs/seaweedfs/weed/shell"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
@@ -15,6 +18,7 @@ var (
 	shellOptions      shell.ShellOptions
 	shellInitialFiler *string
 	shellCluster      *string
+	shellJson         *bool
 )
 
 func init() {
@@ -23,6 +27,7 @@ func init() {
 	shellOptions.FilerGroup = cmdShell.Flag.String("filerGroup", "", "filerGroup for the filers")
 	shellInitialFiler = cmdShell.Flag.String("filer", "", "filer host and port, e.g. localhost:8888")
 	shellCluster = cmdShell.Flag.String("cluster", "", "cluster defined in shell.toml")
+	shellJson = cmdShell.Flag.Bool("json", false, "print the output of each command as one line of json, with its status, warnings, text output and the data of the commands supporting it")
 }
 
 var cmdShell = &Command{
@@ -32,11 +37,21 @@ var cmdShell = &Command{
 
 	Generate shell.toml via "weed scaffold -config=shell"
 
+	With -json, the commands are read without a prompt and each command prints one line of json:
+	{"command":"volume.list","status":"ok","warnings":[...],"output":"...","data":{...}}
+	The status is ok or error with the error message. The data is the structured result of
+	volume.list, volume.fsck and volume.fix.replication, which leave the text output empty or short.
+
   `,
 }
 
 func runShell(command *Command, args []string) bool {
 	shellOptions.GrpcDialOption = grpc.WithTransportCredentials(insecure.NewCredentials())
+	util.LoadConfiguration("security", false)
+	if err := security.LoadHttpsClient(util.GetViper()); err != nil {
+		fmt.Fprintf(os.Stderr, "https client: %v\n", err)
+		return false
+	}
 
 	if *shellOptions.Masters == "" {
 		util.LoadConfiguration("shell", false)
@@ -50,12 +65,17 @@ func runShell(command *Command, args []string) bool {
 		} else {
 			*shellOptions.Masters = v.GetString("cluster." + cluster + ".master")
 			*shellInitialFiler = v.GetString("cluster." + cluster + ".filer")
-			fmt.Printf("master: %s filer: %s\n", *shellOptions.Masters, *shellInitialFiler)
+			var banner io.Writer = os.Stdout
+			if *shellJson {
+				banner = os.Stderr
+			}
+			fmt.Fprintf(banner, "master: %s filer: %s\n", *shellOptions.Masters, *shellInitialFiler)
 		}
 	}
 
 	shellOptions.FilerAddress = rpc.ServerAddress(*shellInitialFiler)
 	shellOptions.Directory = "/"
+	shellOptions.Json = *shellJson
 
 	shell.RunShell(shellOptions)
 
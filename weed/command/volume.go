@@ -12,7 +12,6 @@ import (
 
 	"github.com/facebookgo/httpdown"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/reflection"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
@@ -59,8 +58,18 @@ type VolumeServerOptions struct {
 	metricsHttpPort           *int
 	// pulseSeconds          *int
 	inflightUploadDataTimeout *time.Duration
+	inflightUploadQueueLength *int
 	hasSlowRead               *bool
 	readBufferSizeMB          *int
+	diskSmart                 *bool
+	diskSpillover             *bool
+	readRepair                *bool
+	peerRepair                *bool
+	loadConcurrency           *int
+	loadTimeout               *time.Duration
+	slowLogThresholds         *string
+	preallocate               *string
+	directIO                  *string
 }
 
 func init() {
@@ -71,7 +80,7 @@ func init() {
 	v.ip = cmdVolume.Flag.String("ip", util.DetectedHostAddress(), "ip or server name, also used as identifier")
 	v.publicUrl = cmdVolume.Flag.String("publicUrl", "", "Publicly accessible address")
 	v.bindIp = cmdVolume.Flag.String("ip.bind", "", "ip address to bind to. If empty, default to same as -ip option.")
-	v.mastersString = cmdVolume.Flag.String("mserver", "localhost:9333", "comma-separated master servers")
+	v.mastersString = cmdVolume.Flag.String("mserver", "localhost:9333", "comma-separated master servers, or dns:<host>:<port> and srv:<name> re-resolved periodically")
 	v.preStopSeconds = cmdVolume.Flag.Int("preStopSeconds", 10, "number of seconds between stop send heartbeats and stop volume server")
 	// v.pulseSeconds = cmdVolume.Flag.Int("pulseSeconds", 5, "number of seconds between heartbeats, must be smaller than or equal to the master's setting")
 	v.idleConnectionTimeout = cmdVolume.Flag.Int("idleTimeout", 30, "connection idle seconds")
@@ -90,8 +99,18 @@ func init() {
 	v.metricsHttpPort = cmdVolume.Flag.Int("metricsPort", 0, "Prometheus metrics listen port")
 	v.idxFolder = cmdVolume.Flag.String("dir.idx", "", "directory to store .idx files")
 	v.inflightUploadDataTimeout = cmdVolume.Flag.Duration("inflightUploadDataTimeout", 60*time.Second, "inflight upload data wait timeout of volume servers")
+	v.inflightUploadQueueLength = cmdVolume.Flag.Int("inflightUploadQueueLength", 256, "the uploads waiting beyond concurrentUploadLimitMB, more are rejected with 503 at once")
 	v.hasSlowRead = cmdVolume.Flag.Bool("hasSlowRead", false, "<experimental> if true, this prevents slow reads from blocking other requests, but large file read P99 latency will increase.")
 	v.readBufferSizeMB = cmdVolume.Flag.Int("readBufferSizeMB", 4, "<experimental> larger values can optimize query performance but will increase some memory usage,Use with hasSlowRead normally.")
+	v.diskSmart = cmdVolume.Flag.Bool("disk.smart", false, "report the SMART health of the disks to the master, requires smartctl")
+	v.diskSpillover = cmdVolume.Flag.Bool("disk.spillover", false, "when a write runs out of disk space, move the volume to another directory of the same disk type with free space")
+	v.readRepair = cmdVolume.Flag.Bool("readRepair", false, "when a read fails the CRC check, serve the file from another replica and overwrite the local corrupted copy")
+	v.peerRepair = cmdVolume.Flag.Bool("peerRepair", false, "when the master finds a replica missing files or deletions of its peers, pull them directly from the peers")
+	v.loadConcurrency = cmdVolume.Flag.Int("load.concurrency", 0, "the number of volumes loaded at the same time in each directory on startup, 0 to choose by the cpu count")
+	v.loadTimeout = cmdVolume.Flag.Duration("load.timeout", 0, "quarantine a volume not loaded in this time on startup, reporting it to the master, 0 to wait forever")
+	v.slowLogThresholds = cmdVolume.Flag.String("slowLog.thresholds", "", "log the requests slower than the thresholds as json, e.g. \"default=1s,GET=200ms,POST=2s\" per http method, empty to disable")
+	v.preallocate = cmdVolume.Flag.String("dir.preallocate", "false", "preallocate the .dat files of new volumes to the volume size limit to reduce fragmentation, true|false[,true|false]...")
+	v.directIO = cmdVolume.Flag.String("dir.directIO", "false", "read with O_DIRECT during compaction and erasure coding to keep the page cache for hot data, true|false[,true|false]...")
 }
 
 var cmdVolume = &Command{
@@ -181,6 +200,9 @@ func (v VolumeServerOptions) startVolumeServer(volumeFolders, maxVolumeCounts, v
 		glog.Fatalf("%d directories by -dir, but only %d disk types is set by -disk", len(v.folders), len(diskTypes))
 	}
 
+	preallocates := parseDirBools(v.folders, *v.preallocate, "-dir.preallocate")
+	directIOs := parseDirBools(v.folders, *v.directIO, "-dir.directIO")
+
 	// security related white list configuration
 	v.whiteList = util.StringSplit(volumeWhiteListOption, ",")
 
@@ -216,6 +238,11 @@ func (v VolumeServerOptions) startVolumeServer(volumeFolders, maxVolumeCounts, v
 		volumeMux.HandleFunc("/debug/pprof/trace", httppprof.Trace)
 	}
 
+	slowLog, err := stats_collect.NewSlowRequestLog("volume", *v.slowLogThresholds)
+	if err != nil {
+		glog.Fatalf("volume slow log: %v", err)
+	}
+
 	volumeNeedleMapKind := storage.NeedleMapInMemory
 	switch *v.indexType {
 	case "leveldb":
@@ -229,6 +256,7 @@ func (v VolumeServerOptions) startVolumeServer(volumeFolders, maxVolumeCounts, v
 	volumeServer := weed_server.NewVolumeServer(volumeMux, publicVolumeMux,
 		*v.ip, *v.port, *v.portGrpc, *v.publicUrl,
 		v.folders, v.folderMaxLimits, minFreeSpaces, diskTypes,
+		preallocates, directIOs,
 		*v.idxFolder,
 		volumeNeedleMapKind,
 		v.masters, 5, *v.dataCenter, *v.rack,
@@ -239,8 +267,18 @@ func (v VolumeServerOptions) startVolumeServer(volumeFolders, maxVolumeCounts, v
 		int64(*v.concurrentUploadLimitMB)*1024*1024,
 		int64(*v.concurrentDownloadLimitMB)*1024*1024,
 		*v.inflightUploadDataTimeout,
+		*v.inflightUploadQueueLength,
 		*v.hasSlowRead,
 		*v.readBufferSizeMB,
+		*v.diskSmart,
+		*v.diskSpillover,
+		*v.readRepair,
+		*v.peerRepair,
+		storage.VolumeLoadOption{
+			Concurrency: *v.loadConcurrency,
+			Timeout:     *v.loadTimeout,
+		},
+		slowLog,
 	)
 	// starting grpc server
 	grpcS := v.startGrpcService(volumeServer)
@@ -315,7 +353,7 @@ func (v VolumeServerOptions) startGrpcService(vs volume_server_pb.VolumeServerSe
 	}
 	grpcS := rpc.NewGrpcServer()
 	volume_server_pb.RegisterVolumeServerServer(grpcS, vs)
-	reflection.Register(grpcS)
+	rpc.RegisterReflection(grpcS)
 	go func() {
 		if err := grpcS.Serve(grpcL); err != nil {
 			glog.Fatalf("start gRPC service failed, %s", err)
@@ -365,3 +403,23 @@ func (v VolumeServerOptions) startClusterHttpService(handler http.Handler) httpd
 	}()
 	return clusterHttpServer
 }
+
+// parseDirBools parses a comma separated option for each directory, one value applies to all directories
+func parseDirBools(folders []string, option string, name string) (values []bool) {
+	for _, s := range strings.Split(option, ",") {
+		value, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			glog.Fatalf("%s %s: %v", name, option, err)
+		}
+		values = append(values, value)
+	}
+	if len(values) == 1 && len(folders) > 1 {
+		for i := 0; i < len(folders)-1; i++ {
+			values = append(values, values[0])
+		}
+	}
+	if len(folders) != len(values) {
+		glog.Fatalf("%d directories by -dir, but only %d values are set by %s", len(folders), len(values), name)
+	}
+	return
+}
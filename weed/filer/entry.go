@@ -17,6 +17,7 @@ type Attr struct {
 	Mime     string      // MIME type
 	TtlSec   int32       // TTL in seconds
 	Md5      []byte
+	Sha256   []byte // of the whole content, if enabled on the filer
 	FileSize uint64
 }
 
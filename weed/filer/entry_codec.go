@@ -41,6 +41,7 @@ func EntryAttributeToPb(entry *Entry) *filer_pb.Attributes {
 		Mime:     entry.Mime,
 		TtlSec:   entry.Attr.TtlSec,
 		Md5:      entry.Attr.Md5,
+		Sha256:   entry.Attr.Sha256,
 		FileSize: entry.Attr.FileSize,
 	}
 }
@@ -61,6 +62,7 @@ func PbToEntryAttribute(attr *filer_pb.Attributes) Attr {
 	t.Mime = attr.Mime
 	t.TtlSec = attr.TtlSec
 	t.Md5 = attr.Md5
+	t.Sha256 = attr.Sha256
 	t.FileSize = attr.FileSize
 
 	return t
@@ -20,7 +20,8 @@ import (
 )
 
 const (
-	ManifestBatch = 10000
+	ManifestBatch              = 10000
+	ManifestResolveConcurrency = 8
 )
 
 var bytesBufferPool = sync.Pool{
@@ -50,31 +51,62 @@ func SeparateManifestChunks(chunks []*filer_pb.FileChunk) (manifestChunks, nonMa
 }
 
 func ResolveChunkManifest(lookupFileIdFn wdclient.LookupFileIdFunctionType, chunks []*filer_pb.FileChunk, startOffset, stopOffset int64) (dataChunks, manifestChunks []*filer_pb.FileChunk, manifestResolveErr error) {
-	// TODO maybe parallel this
-	for _, chunk := range chunks {
 
+	// only the manifest chunks overlapping with the range are fetched, concurrently
+	type resolved struct {
+		dataChunks, manifestChunks []*filer_pb.FileChunk
+		err                        error
+	}
+	var overlapped []*filer_pb.FileChunk
+	for _, chunk := range chunks {
 		if max(chunk.Offset, startOffset) >= min(chunk.Offset+int64(chunk.Size), stopOffset) {
 			continue
 		}
+		overlapped = append(overlapped, chunk)
+	}
+
+	results := make([]*resolved, len(overlapped))
+	var wg sync.WaitGroup
+	limiter := make(chan struct{}, ManifestResolveConcurrency)
+	for i, chunk := range overlapped {
+		if !chunk.IsChunkManifest {
+			continue
+		}
+		wg.Add(1)
+		limiter <- struct{}{}
+		go func(i int, chunk *filer_pb.FileChunk) {
+			defer func() {
+				<-limiter
+				wg.Done()
+			}()
+			result := &resolved{}
+			results[i] = result
+			resolvedChunks, err := ResolveOneChunkManifest(lookupFileIdFn, chunk)
+			if err != nil {
+				result.err = err
+				return
+			}
+			// recursive
+			result.dataChunks, result.manifestChunks, result.err = ResolveChunkManifest(lookupFileIdFn, resolvedChunks, startOffset, stopOffset)
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	for i, chunk := range overlapped {
 
 		if !chunk.IsChunkManifest {
 			dataChunks = append(dataChunks, chunk)
 			continue
 		}
 
-		resolvedChunks, err := ResolveOneChunkManifest(lookupFileIdFn, chunk)
-		if err != nil {
-			return dataChunks, nil, err
+		result := results[i]
+		if result.err != nil {
+			return dataChunks, nil, result.err
 		}
 
 		manifestChunks = append(manifestChunks, chunk)
-		// recursive
-		subDataChunks, subManifestChunks, subErr := ResolveChunkManifest(lookupFileIdFn, resolvedChunks, startOffset, stopOffset)
-		if subErr != nil {
-			return dataChunks, nil, subErr
-		}
-		dataChunks = append(dataChunks, subDataChunks...)
-		manifestChunks = append(manifestChunks, subManifestChunks...)
+		dataChunks = append(dataChunks, result.dataChunks...)
+		manifestChunks = append(manifestChunks, result.manifestChunks...)
 	}
 	return
 }
@@ -215,6 +247,11 @@ func MaybeManifestize(saveFunc SaveDataAsChunkFunctionType, inputChunks []*filer
 	return doMaybeManifestize(saveFunc, inputChunks, ManifestBatch, mergeIntoManifest)
 }
 
+// MaybeManifestizeBatch merges each batch of data chunks into one manifest chunk, keeping the existing manifest chunks.
+func MaybeManifestizeBatch(saveFunc SaveDataAsChunkFunctionType, inputChunks []*filer_pb.FileChunk, batch int) (chunks []*filer_pb.FileChunk, err error) {
+	return doMaybeManifestize(saveFunc, inputChunks, batch, mergeIntoManifest)
+}
+
 func doMaybeManifestize(saveFunc SaveDataAsChunkFunctionType, inputChunks []*filer_pb.FileChunk, mergeFactor int, mergefn func(saveFunc SaveDataAsChunkFunctionType, dataChunks []*filer_pb.FileChunk) (manifestChunk *filer_pb.FileChunk, err error)) (chunks []*filer_pb.FileChunk, err error) {
 
 	var dataChunks []*filer_pb.FileChunk
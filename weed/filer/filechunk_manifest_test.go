@@ -3,9 +3,14 @@ package filer
 import (
 	"bytes"
 	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 )
@@ -111,3 +116,46 @@ func mockMerge(saveFunc SaveDataAsChunkFunctionType, dataChunks []*filer_pb.File
 
 	return
 }
+
+func TestResolveChunkManifestInRange(t *testing.T) {
+	manifests := map[string][]*filer_pb.FileChunk{
+		"m1": {{FileId: "1", Offset: 0, Size: 100}, {FileId: "2", Offset: 100, Size: 100}},
+		"m2": {{FileId: "3", Offset: 200, Size: 100}, {FileId: "4", Offset: 300, Size: 100}},
+	}
+	var fetchedLock sync.Mutex
+	fetched := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fileId := strings.TrimPrefix(r.URL.Path, "/")
+		fetchedLock.Lock()
+		fetched[fileId]++
+		fetchedLock.Unlock()
+		data, _ := proto.Marshal(&filer_pb.FileChunkManifest{Chunks: manifests[fileId]})
+		w.Write(data)
+	}))
+	defer server.Close()
+	lookupFn := func(fileId string) ([]string, error) {
+		return []string{server.URL + "/" + fileId}, nil
+	}
+
+	chunks := []*filer_pb.FileChunk{
+		{FileId: "m1", Offset: 0, Size: 200, IsChunkManifest: true},
+		{FileId: "m2", Offset: 200, Size: 200, IsChunkManifest: true},
+	}
+
+	dataChunks, manifestChunks, err := ResolveChunkManifest(lookupFn, chunks, 250, 260)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(dataChunks))
+	assert.Equal(t, "3", dataChunks[0].FileId)
+	assert.Equal(t, 1, len(manifestChunks))
+	assert.Equal(t, 0, fetched["m1"], "manifest outside of the range should not be fetched")
+	assert.Equal(t, 1, fetched["m2"])
+
+	dataChunks, manifestChunks, err = ResolveChunkManifest(lookupFn, chunks, 0, math.MaxInt64)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(manifestChunks))
+	var fileIds []string
+	for _, chunk := range dataChunks {
+		fileIds = append(fileIds, chunk.FileId)
+	}
+	assert.Equal(t, []string{"1", "2", "3", "4"}, fileIds)
+}
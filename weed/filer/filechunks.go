@@ -98,10 +98,10 @@ func DoMinusChunks(as, bs []*filer_pb.FileChunk) (delta []*filer_pb.FileChunk) {
 
 	fileIds := make(map[string]bool)
 	for _, interval := range bs {
-		fileIds[interval.GetFileIdString()] = true
+		fileIds[chunkIdentity(interval)] = true
 	}
 	for _, chunk := range as {
-		if _, found := fileIds[chunk.GetFileIdString()]; !found {
+		if _, found := fileIds[chunkIdentity(chunk)]; !found {
 			delta = append(delta, chunk)
 		}
 	}
@@ -127,6 +127,22 @@ func DoMinusChunksBySourceFileId(as, bs []*filer_pb.FileChunk) (delta []*filer_p
 	return
 }
 
+// chunkIdentity tells the chunks apart, also the packed small files sharing one container chunk
+func chunkIdentity(chunk *filer_pb.FileChunk) string {
+	if chunk.IsPacked() {
+		return fmt.Sprintf("%s@%d", chunk.GetFileIdString(), chunk.ContainerOffset)
+	}
+	return chunk.GetFileIdString()
+}
+
+// storedChunkSize is the size of the data stored under the file id of the chunk, the whole container for a packed small file
+func storedChunkSize(chunk *filer_pb.FileChunk) uint64 {
+	if chunk.IsPacked() {
+		return chunk.ContainerSize
+	}
+	return chunk.Size
+}
+
 type ChunkView struct {
 	FileId      string
 	Offset      int64
@@ -192,7 +208,7 @@ func logPrintf(name string, visibles []VisibleInterval) {
 
 func MergeIntoVisibles(visibles []VisibleInterval, chunk *filer_pb.FileChunk) (newVisibles []VisibleInterval) {
 
-	newV := newVisibleInterval(chunk.Offset, chunk.Offset+int64(chunk.Size), chunk.GetFileIdString(), chunk.Mtime, 0, chunk.Size, chunk.CipherKey, chunk.IsCompressed)
+	newV := newVisibleInterval(chunk.Offset, chunk.Offset+int64(chunk.Size), chunk.GetFileIdString(), chunk.Mtime, chunk.ContainerOffset, storedChunkSize(chunk), chunk.CipherKey, chunk.IsCompressed)
 
 	length := len(visibles)
 	if length == 0 {
@@ -99,8 +99,8 @@ func addToVisibles(visibles []VisibleInterval, prevX int64, startPoint *Point, p
 			stop:         point.x,
 			fileId:       chunk.GetFileIdString(),
 			modifiedTime: chunk.Mtime,
-			chunkOffset:  prevX - chunk.Offset,
-			chunkSize:    chunk.Size,
+			chunkOffset:  prevX - chunk.Offset + chunk.ContainerOffset,
+			chunkSize:    storedChunkSize(chunk),
 			cipherKey:    chunk.CipherKey,
 			isGzipped:    chunk.IsCompressed,
 		})
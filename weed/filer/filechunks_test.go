@@ -537,3 +537,34 @@ func TestViewFromVisibleIntervals3(t *testing.T) {
 	}
 
 }
+
+func TestViewFromPackedChunks(t *testing.T) {
+	chunks := []*filer_pb.FileChunk{
+		{FileId: "3,01", Offset: 0, Size: 100, Mtime: 1, ContainerOffset: 4000, ContainerSize: 8192},
+		{FileId: "4,02", Offset: 60, Size: 20, Mtime: 2},
+	}
+
+	views := ViewFromChunks(nil, chunks, 10, 80)
+
+	assert.Equal(t, 3, len(views))
+	assert.Equal(t, &ChunkView{FileId: "3,01", Offset: 4010, Size: 50, LogicOffset: 10, ChunkSize: 8192}, views[0])
+	assert.Equal(t, &ChunkView{FileId: "4,02", Offset: 0, Size: 20, LogicOffset: 60, ChunkSize: 20}, views[1])
+	assert.Equal(t, &ChunkView{FileId: "3,01", Offset: 4080, Size: 10, LogicOffset: 80, ChunkSize: 8192}, views[2])
+	assert.False(t, views[0].IsFullChunk())
+}
+
+func TestMinusPackedChunks(t *testing.T) {
+	// the packed small files share the file id of the container
+	oldChunks := []*filer_pb.FileChunk{
+		{FileId: "3,01", Size: 100, ContainerOffset: 0, ContainerSize: 8192},
+		{FileId: "3,01", Size: 100, ContainerOffset: 100, ContainerSize: 8192},
+	}
+	newChunks := []*filer_pb.FileChunk{
+		{FileId: "3,01", Size: 100, ContainerOffset: 100, ContainerSize: 8192},
+	}
+
+	delta := DoMinusChunks(oldChunks, newChunks)
+
+	assert.Equal(t, 1, len(delta))
+	assert.Equal(t, int64(0), delta[0].ContainerOffset)
+}
@@ -1,11 +1,13 @@
 package filer
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc"
@@ -15,6 +17,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 	"github.com/seaweedfs/seaweedfs/weed/util/log_buffer"
 	"github.com/seaweedfs/seaweedfs/weed/wdclient"
@@ -36,7 +39,7 @@ type Filer struct {
 	UniqueFilerEpoch    int32
 	Store               VirtualFilerStore
 	MasterClient        *wdclient.MasterClient
-	fileIdDeletionQueue *util.UnboundedQueue
+	fileIdDeletionQueue *DeletionQueue
 	GrpcDialOption      grpc.DialOption
 	DirBucketsPath      string
 	Cipher              bool
@@ -47,22 +50,37 @@ type Filer struct {
 	Signature           int32
 	FilerConf           *FilerConf
 	RemoteStorage       *FilerRemoteStorage
+	RemoteCacheStats    *RemoteCacheStats
+	Deduper             *ChunkDeduper
+	PackedContainers    *PackedContainers
+	AdvisoryLocks       *AdvisoryLockManager
+	DirChangeSeqs       *DirChangeSeqs
+	metaEventLock       sync.Mutex
+	lastMetaEventTsNs   int64
+	// serializes the conditional writes to the same entry
+	entryLocks util.StripedLock
+	// signs the requests to the peer filers
+	SigningKey security.SigningKey
 }
 
 func NewFiler(masters map[string]rpc.ServerAddress, grpcDialOption grpc.DialOption, filerHost rpc.ServerAddress,
 	filerGroup string, collection string, replication string, dataCenter string, notifyFn func()) *Filer {
 	f := &Filer{
 		MasterClient:        wdclient.NewMasterClient(grpcDialOption, filerGroup, cluster.FilerType, filerHost, dataCenter, "", masters),
-		fileIdDeletionQueue: util.NewUnboundedQueue(),
+		fileIdDeletionQueue: NewDeletionQueue(string(filerHost)),
 		GrpcDialOption:      grpcDialOption,
 		FilerConf:           NewFilerConf(),
 		RemoteStorage:       NewFilerRemoteStorage(),
+		RemoteCacheStats:    NewRemoteCacheStats(),
 		UniqueFilerId:       util.RandomInt32(),
+		DirChangeSeqs:       NewDirChangeSeqs(DirChangeSeqsLimit),
 	}
 	if f.UniqueFilerId < 0 {
 		f.UniqueFilerId = -f.UniqueFilerId
 	}
 
+	f.AdvisoryLocks = NewAdvisoryLockManager(f)
+
 	f.LocalMetaLogBuffer = log_buffer.NewLogBuffer("local", LogFlushInterval, f.logFlushFunc, notifyFn)
 	f.metaLogCollection = collection
 	f.metaLogReplication = replication
@@ -110,8 +128,14 @@ func (f *Filer) ListExistingPeerUpdates() (existingNodes []*master_pb.ClusterNod
 
 func (f *Filer) SetStore(store FilerStore) (isFresh bool) {
 	f.Store = NewFilerStoreWrapper(store)
+	f.Deduper = NewChunkDeduper(f.Store)
+	f.PackedContainers = NewPackedContainers(f.Store)
 
-	return f.setOrLoadFilerStoreSignature(store)
+	isFresh = f.setOrLoadFilerStoreSignature(store)
+	if err := f.fileIdDeletionQueue.Load(f.Store); err != nil {
+		glog.Errorf("%v", err)
+	}
+	return isFresh
 }
 
 func (f *Filer) setOrLoadFilerStoreSignature(store FilerStore) (isFresh bool) {
@@ -159,12 +183,48 @@ func (f *Filer) RollbackTransaction(ctx context.Context) error {
 }
 
 func (f *Filer) CreateEntry(ctx context.Context, entry *Entry, o_excl bool, isFromOtherCluster bool, signatures []int32, skipCreateParentDir bool) error {
+	return f.createEntry(ctx, entry, o_excl, nil, isFromOtherCluster, signatures, skipCreateParentDir)
+}
+
+// CreateEntryWithCondition creates or overwrites the entry only if the existing file matches the condition.
+// The condition is checked and the entry saved under the entry lock and in one store transaction.
+func (f *Filer) CreateEntryWithCondition(ctx context.Context, entry *Entry, condition *WriteCondition, isFromOtherCluster bool, signatures []int32, skipCreateParentDir bool) (err error) {
+	if !condition.IsSet() {
+		return f.createEntry(ctx, entry, false, nil, isFromOtherCluster, signatures, skipCreateParentDir)
+	}
+
+	unlock := f.entryLocks.Lock(string(entry.FullPath))
+	defer unlock()
+
+	txCtx, err := f.BeginTransaction(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction for %s: %v", entry.FullPath, err)
+	}
+	if err = f.createEntry(txCtx, entry, false, condition, isFromOtherCluster, signatures, skipCreateParentDir); err != nil {
+		if rollbackErr := f.RollbackTransaction(txCtx); rollbackErr != nil {
+			glog.Errorf("rollback transaction for %s: %v", entry.FullPath, rollbackErr)
+		}
+		return err
+	}
+	return f.CommitTransaction(txCtx)
+}
+
+func (f *Filer) createEntry(ctx context.Context, entry *Entry, o_excl bool, condition *WriteCondition, isFromOtherCluster bool, signatures []int32, skipCreateParentDir bool) error {
 
 	if string(entry.FullPath) == "/" {
 		return nil
 	}
 
-	oldEntry, _ := f.FindEntry(ctx, entry.FullPath)
+	oldEntry, findErr := f.FindEntry(ctx, entry.FullPath)
+	if condition.IsSet() {
+		if findErr != nil && findErr != filer_pb.ErrNotFound {
+			return fmt.Errorf("find %s: %v", entry.FullPath, findErr)
+		}
+		if err := condition.Check(oldEntry); err != nil {
+			glog.V(3).Infof("conditional write %s: %v", entry.FullPath, err)
+			return err
+		}
+	}
 
 	/*
 		if !hasWritePermission(lastDirectoryEntry, entry) {
@@ -176,6 +236,11 @@ func (f *Filer) CreateEntry(ctx context.Context, entry *Entry, o_excl bool, isFr
 
 	if oldEntry == nil {
 
+		if err := f.checkUploadPolicy(nil, entry); err != nil {
+			glog.V(1).Infof("create entry %s: %v", entry.FullPath, err)
+			return err
+		}
+
 		if !skipCreateParentDir {
 			dirParts := strings.Split(string(entry.FullPath), "/")
 			if err := f.ensureParentDirectoryEntry(ctx, entry, dirParts, len(dirParts)-1, isFromOtherCluster); err != nil {
@@ -196,13 +261,16 @@ func (f *Filer) CreateEntry(ctx context.Context, entry *Entry, o_excl bool, isFr
 		glog.V(4).Infof("UpdateEntry %s: old entry: %v", entry.FullPath, oldEntry.Name())
 		if err := f.UpdateEntry(ctx, oldEntry, entry); err != nil {
 			glog.Errorf("update entry %s: %v", entry.FullPath, err)
-			return fmt.Errorf("update entry %s: %v", entry.FullPath, err)
+			return fmt.Errorf("update entry %s: %w", entry.FullPath, err)
 		}
 	}
 
 	f.NotifyUpdateEvent(ctx, oldEntry, entry, true, isFromOtherCluster, signatures)
 
-	f.deleteChunksIfNotNew(oldEntry, entry)
+	// the chunks of a replaced hard link are still used by its other links
+	if oldEntry == nil || len(oldEntry.HardLinkId) == 0 || bytes.Equal(oldEntry.HardLinkId, entry.HardLinkId) || oldEntry.HardLinkCounter <= 1 {
+		f.deleteChunksIfNotNew(oldEntry, entry)
+	}
 
 	glog.V(4).Infof("CreateEntry %s: created", entry.FullPath)
 
@@ -274,6 +342,14 @@ func (f *Filer) UpdateEntry(ctx context.Context, oldEntry, entry *Entry) (err er
 			glog.Errorf("existing %s is a file", oldEntry.FullPath)
 			return fmt.Errorf("existing %s is a file", oldEntry.FullPath)
 		}
+		if err = checkObjectLockUpdate(oldEntry, entry, isGovernanceBypassed(ctx), time.Now()); err != nil {
+			glog.V(2).Infof("update entry: %v", err)
+			return err
+		}
+	}
+	if err = f.checkUploadPolicy(oldEntry, entry); err != nil {
+		glog.V(1).Infof("update entry %s: %v", entry.FullPath, err)
+		return err
 	}
 	return f.Store.UpdateEntry(ctx, entry)
 }
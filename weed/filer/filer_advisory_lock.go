@@ -0,0 +1,216 @@
+package filer
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	AdvisoryLockKeyPrefix       = "__advisory_lock__"
+	DefaultAdvisoryLockLeaseSec = 30
+)
+
+// AdvisoryLockManager keeps the POSIX advisory locks, i.e. flock() and fcntl() locks, of the mount clients,
+// so locks taken on different mounts of the same file see each other.
+// The locks of one file are saved in the filer store kv, and are changed by compare and swap, so the filers
+// sharing the store see each other's locks. A lock expires unless its owner renews the lease,
+// which releases the locks of a disconnected client.
+type AdvisoryLockManager struct {
+	filer *Filer
+}
+
+func NewAdvisoryLockManager(f *Filer) *AdvisoryLockManager {
+	return &AdvisoryLockManager{
+		filer: f,
+	}
+}
+
+// Acquire takes the lock if there is no conflicting lock from other owners, otherwise returns the conflicting lock.
+// An owner's existing locks in the range are replaced, so a shared lock can be upgraded or downgraded.
+func (m *AdvisoryLockManager) Acquire(ctx context.Context, p util.FullPath, lock *filer_pb.AdvisoryLock, lease time.Duration, testOnly bool) (conflict *filer_pb.AdvisoryLock, err error) {
+	err = m.updateLocks(ctx, advisoryLockKey(p), func(locks []*filer_pb.AdvisoryLock, now int64) ([]*filer_pb.AdvisoryLock, bool) {
+		if conflict = findConflictingLock(locks, lock); conflict != nil || testOnly {
+			return locks, false
+		}
+		acquired := proto.Clone(lock).(*filer_pb.AdvisoryLock)
+		acquired.ExpireAtNs = now + int64(lease)
+		return append(removeLockRange(locks, lock.Owner, lock.Start, lock.End), acquired), true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return conflict, nil
+}
+
+// Release unlocks the range of the owner's locks on the path.
+func (m *AdvisoryLockManager) Release(ctx context.Context, p util.FullPath, owner string, start, end int64) error {
+	return m.updateLocks(ctx, advisoryLockKey(p), func(locks []*filer_pb.AdvisoryLock, now int64) ([]*filer_pb.AdvisoryLock, bool) {
+		return removeLockRange(locks, owner, start, end), true
+	})
+}
+
+// Renew extends the leases of all locks of the owners with the prefix, or releases them all.
+// The locked paths are listed from the store, so the locks taken before a filer restart, or on other filers,
+// are renewed too, and the expired locks of vanished owners are removed on the way.
+func (m *AdvisoryLockManager) Renew(ctx context.Context, ownerPrefix string, lease time.Duration, release bool) (count int, err error) {
+	keys, err := m.listLockKeys(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, key := range keys {
+		var renewed int
+		err = m.updateLocks(ctx, key, func(locks []*filer_pb.AdvisoryLock, now int64) (remaining []*filer_pb.AdvisoryLock, changed bool) {
+			renewed = 0
+			for _, lock := range locks {
+				if !strings.HasPrefix(lock.Owner, ownerPrefix) {
+					remaining = append(remaining, lock)
+					continue
+				}
+				changed = true
+				if release {
+					continue
+				}
+				lock.ExpireAtNs = now + int64(lease)
+				remaining = append(remaining, lock)
+				renewed++
+			}
+			return
+		})
+		if err != nil {
+			return
+		}
+		count += renewed
+	}
+	return
+}
+
+// updateLocks changes the unexpired locks of the key, and saves them if changed or if some locks expired.
+// The change is retried if the locks are changed by others meanwhile.
+func (m *AdvisoryLockManager) updateLocks(ctx context.Context, key []byte, updateFn func(locks []*filer_pb.AdvisoryLock, now int64) (updated []*filer_pb.AdvisoryLock, changed bool)) error {
+	for {
+		oldValue, err := m.filer.Store.KvGet(ctx, key)
+		if err == ErrKvNotFound {
+			oldValue, err = nil, nil
+		}
+		if err != nil {
+			return err
+		}
+		now := time.Now().UnixNano()
+		locks, hasExpired, err := decodeLocks(oldValue, now)
+		if err != nil {
+			return err
+		}
+		locks, changed := updateFn(locks, now)
+		if !changed && !hasExpired {
+			return nil
+		}
+		var newValue []byte
+		if len(locks) > 0 {
+			if newValue, err = proto.Marshal(&filer_pb.AdvisoryLocks{Locks: locks}); err != nil {
+				return err
+			}
+		}
+		if oldValue == nil && newValue == nil {
+			return nil
+		}
+		swapped, err := m.filer.Store.KvCompareAndSwap(ctx, key, oldValue, newValue)
+		if err != nil || swapped {
+			return err
+		}
+	}
+}
+
+func (m *AdvisoryLockManager) listLockKeys(ctx context.Context) (keys [][]byte, err error) {
+	var startKey []byte
+	for {
+		var count int64
+		err = m.filer.Store.KvList(ctx, []byte(AdvisoryLockKeyPrefix), startKey, PaginationSize, func(key []byte, value []byte) bool {
+			count++
+			startKey = append([]byte(nil), key...)
+			keys = append(keys, startKey)
+			return true
+		})
+		if err != nil || count < PaginationSize {
+			return
+		}
+	}
+}
+
+// decodeLocks reads the unexpired locks
+func decodeLocks(value []byte, now int64) (locks []*filer_pb.AdvisoryLock, hasExpired bool, err error) {
+	if value == nil {
+		return nil, false, nil
+	}
+	saved := &filer_pb.AdvisoryLocks{}
+	if err = proto.Unmarshal(value, saved); err != nil {
+		return nil, false, err
+	}
+	for _, lock := range saved.Locks {
+		if lock.ExpireAtNs > now {
+			locks = append(locks, lock)
+		} else {
+			hasExpired = true
+		}
+	}
+	return locks, hasExpired, nil
+}
+
+func advisoryLockKey(p util.FullPath) []byte {
+	return []byte(AdvisoryLockKeyPrefix + string(p))
+}
+
+func findConflictingLock(locks []*filer_pb.AdvisoryLock, lock *filer_pb.AdvisoryLock) *filer_pb.AdvisoryLock {
+	for _, existing := range locks {
+		if existing.Owner == lock.Owner {
+			continue
+		}
+		if !existing.IsExclusive && !lock.IsExclusive {
+			continue
+		}
+		if isLockRangeOverlapping(existing.Start, lockRangeEnd(existing.End), lock.Start, lockRangeEnd(lock.End)) {
+			return existing
+		}
+	}
+	return nil
+}
+
+// removeLockRange unlocks [start, end) of the owner's locks, splitting a lock if the range is in its middle
+func removeLockRange(locks []*filer_pb.AdvisoryLock, owner string, start, end int64) (remaining []*filer_pb.AdvisoryLock) {
+	end = lockRangeEnd(end)
+	for _, lock := range locks {
+		lockEnd := lockRangeEnd(lock.End)
+		if lock.Owner != owner || !isLockRangeOverlapping(lock.Start, lockEnd, start, end) {
+			remaining = append(remaining, lock)
+			continue
+		}
+		if lock.Start < start {
+			head := proto.Clone(lock).(*filer_pb.AdvisoryLock)
+			head.End = start
+			remaining = append(remaining, head)
+		}
+		if end < lockEnd {
+			tail := proto.Clone(lock).(*filer_pb.AdvisoryLock)
+			tail.Start = end
+			remaining = append(remaining, tail)
+		}
+	}
+	return
+}
+
+func isLockRangeOverlapping(start1, end1, start2, end2 int64) bool {
+	return start1 < end2 && start2 < end1
+}
+
+// lockRangeEnd treats a non-positive end as the end of file
+func lockRangeEnd(end int64) int64 {
+	if end <= 0 {
+		return 1<<63 - 1
+	}
+	return end
+}
@@ -0,0 +1,136 @@
+package filer
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+// lockedKvStore is a filer store with only a listable kv, without its own compare and swap
+type lockedKvStore struct {
+	FilerStore
+	kv map[string][]byte
+	sync.Mutex
+}
+
+func (s *lockedKvStore) KvPut(ctx context.Context, key []byte, value []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	s.kv[string(key)] = value
+	return nil
+}
+
+func (s *lockedKvStore) KvGet(ctx context.Context, key []byte) ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+	value, found := s.kv[string(key)]
+	if !found {
+		return nil, ErrKvNotFound
+	}
+	return value, nil
+}
+
+func (s *lockedKvStore) KvDelete(ctx context.Context, key []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.kv, string(key))
+	return nil
+}
+
+func (s *lockedKvStore) KvList(ctx context.Context, prefix []byte, startKey []byte, limit int64, eachFunc KvEachFunc) error {
+	s.Lock()
+	var keys []string
+	for key := range s.kv {
+		if bytes.HasPrefix([]byte(key), prefix) && key > string(startKey) {
+			keys = append(keys, key)
+		}
+	}
+	s.Unlock()
+	sort.Strings(keys)
+	for _, key := range keys {
+		if limit <= 0 {
+			break
+		}
+		limit--
+		value, err := s.KvGet(ctx, []byte(key))
+		if err != nil {
+			continue
+		}
+		if !eachFunc([]byte(key), value) {
+			break
+		}
+	}
+	return nil
+}
+
+func TestAdvisoryLocksAcrossFilersAndRestart(t *testing.T) {
+	store := NewFilerStoreWrapper(&lockedKvStore{kv: make(map[string][]byte)})
+	ctx := context.Background()
+	filer1 := NewAdvisoryLockManager(&Filer{Store: store})
+	filer2 := NewAdvisoryLockManager(&Filer{Store: store})
+
+	conflict, err := filer1.Acquire(ctx, "/dir/a.txt", &filer_pb.AdvisoryLock{Owner: "mount1:1", IsExclusive: true}, time.Minute, false)
+	assert.Nil(t, err)
+	assert.Nil(t, conflict)
+
+	conflict, err = filer2.Acquire(ctx, "/dir/a.txt", &filer_pb.AdvisoryLock{Owner: "mount2:1"}, time.Minute, false)
+	assert.Nil(t, err)
+	if assert.NotNil(t, conflict) {
+		assert.Equal(t, "mount1:1", conflict.Owner)
+	}
+
+	// a vanished client's lock expires, and is removed when the locks are renewed
+	_, err = filer2.Acquire(ctx, "/dir/b.txt", &filer_pb.AdvisoryLock{Owner: "mount3:1"}, time.Nanosecond, false)
+	assert.Nil(t, err)
+	time.Sleep(time.Millisecond)
+
+	// a restarted filer renews the locks saved before
+	restarted := NewAdvisoryLockManager(&Filer{Store: store})
+	count, err := restarted.Renew(ctx, "mount1:", time.Minute, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	_, err = store.KvGet(ctx, advisoryLockKey("/dir/b.txt"))
+	assert.Equal(t, ErrKvNotFound, err)
+
+	count, err = restarted.Renew(ctx, "mount1:", time.Minute, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+	conflict, err = filer2.Acquire(ctx, "/dir/a.txt", &filer_pb.AdvisoryLock{Owner: "mount2:1", IsExclusive: true}, time.Minute, false)
+	assert.Nil(t, err)
+	assert.Nil(t, conflict)
+}
+
+func TestAdvisoryLockAcquiredByOneFiler(t *testing.T) {
+	store := NewFilerStoreWrapper(&lockedKvStore{kv: make(map[string][]byte)})
+
+	var wg sync.WaitGroup
+	acquired := make(chan string, 8)
+	for i := 0; i < 8; i++ {
+		owner := string(rune('a' + i))
+		m := NewAdvisoryLockManager(&Filer{Store: store})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conflict, err := m.Acquire(context.Background(), "/file", &filer_pb.AdvisoryLock{Owner: owner, IsExclusive: true}, time.Minute, false)
+			assert.Nil(t, err)
+			if conflict == nil {
+				acquired <- owner
+			}
+		}()
+	}
+	wg.Wait()
+	close(acquired)
+
+	var owners []string
+	for owner := range acquired {
+		owners = append(owners, owner)
+	}
+	assert.Len(t, owners, 1)
+}
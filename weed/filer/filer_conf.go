@@ -25,6 +25,8 @@ const (
 	IamConfigDirectory    = "/etc/iam"
 	IamIdentityFile       = "identity.json"
 	IamPoliciesFile       = "policies.json"
+	// changed when the identities in an iam credential store other than the filer are changed
+	IamIdentityVersionFile = "identity.version"
 )
 
 type FilerConf struct {
@@ -169,6 +171,27 @@ func mergePathConf(a, b *filer_pb.FilerConf_PathConf) {
 	a.DataCenter = util.Nvl(b.DataCenter, a.DataCenter)
 	a.Rack = util.Nvl(b.Rack, a.Rack)
 	a.DataNode = util.Nvl(b.DataNode, a.DataNode)
+	a.Dedup = b.Dedup || a.Dedup
+	if len(b.UidMap) > 0 {
+		a.UidMap = b.UidMap
+	}
+	if len(b.GidMap) > 0 {
+		a.GidMap = b.GidMap
+	}
+	a.SquashRoot = b.SquashRoot || a.SquashRoot
+	if b.MaxFileSize > 0 {
+		a.MaxFileSize = b.MaxFileSize
+	}
+	if len(b.AllowedMimeTypes) > 0 {
+		a.AllowedMimeTypes = b.AllowedMimeTypes
+	}
+	if len(b.BlockedMimeTypes) > 0 {
+		a.BlockedMimeTypes = b.BlockedMimeTypes
+	}
+	a.FileNamePattern = util.Nvl(b.FileNamePattern, a.FileNamePattern)
+	if b.FilerAckCount > 0 {
+		a.FilerAckCount = b.FilerAckCount
+	}
 }
 
 func (fc *FilerConf) ToProto() *filer_pb.FilerConf {
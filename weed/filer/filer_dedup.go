@@ -0,0 +1,202 @@
+package filer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// The dedup index lives in the filer store kv space:
+//
+//	dedup.idx:<collection>:<sha256 of chunk content> => FileChunk
+//	dedup.ref:<file id>                              => 4 bytes refcount + dedup.idx key
+//
+// A chunk referenced by the refcount table is only deleted from the volume
+// servers after the last entry referencing it is gone.
+// The changes of one content hash are serialized by the striped lock of its index key.
+const (
+	dedupInUseKey    = "dedup.in.use"
+	dedupIndexPrefix = "dedup.idx:"
+	dedupRefPrefix   = "dedup.ref:"
+)
+
+type ChunkDeduper struct {
+	store      VirtualFilerStore
+	inUse      int32
+	inUseLock  sync.Mutex
+	indexLocks *util.StripedLock
+}
+
+func NewChunkDeduper(store VirtualFilerStore) *ChunkDeduper {
+	d := &ChunkDeduper{
+		store:      store,
+		indexLocks: util.NewStripedLock(),
+	}
+	if value, err := store.KvGet(context.Background(), []byte(dedupInUseKey)); err == nil && len(value) > 0 {
+		d.inUse = 1
+	}
+	return d
+}
+
+func ChunkContentHash(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+func dedupIndexKey(collection, contentHash string) []byte {
+	return []byte(dedupIndexPrefix + collection + ":" + contentHash)
+}
+
+func dedupRefKey(fileId string) []byte {
+	return []byte(dedupRefPrefix + fileId)
+}
+
+// FindChunk returns an already stored chunk with the same content, with its reference counter increased.
+// If the chunk is not saved in an entry afterwards, the reference must be released with Filer.DeleteChunks.
+func (d *ChunkDeduper) FindChunk(ctx context.Context, collection, contentHash string, offset int64) (*filer_pb.FileChunk, bool) {
+	indexKey := dedupIndexKey(collection, contentHash)
+	unlock := d.indexLocks.Lock(string(indexKey))
+	defer unlock()
+
+	value, err := d.store.KvGet(ctx, indexKey)
+	if err != nil || len(value) == 0 {
+		return nil, false
+	}
+	chunk := &filer_pb.FileChunk{}
+	if err = proto.Unmarshal(value, chunk); err != nil {
+		glog.Errorf("decode dedup index %s: %v", indexKey, err)
+		return nil, false
+	}
+
+	refKey := dedupRefKey(chunk.GetFileIdString())
+	refValue, err := d.store.KvGet(ctx, refKey)
+	if err != nil || len(refValue) < 4 {
+		// the chunk is already deleted, drop the stale index
+		if err == ErrKvNotFound || err == nil {
+			if err = d.store.KvDelete(ctx, indexKey); err != nil {
+				glog.Errorf("delete stale dedup index %s: %v", indexKey, err)
+			}
+		}
+		return nil, false
+	}
+	refCount := util.BytesToUint32(refValue[:4]) + 1
+	util.Uint32toBytes(refValue[:4], refCount)
+	if err = d.store.KvPut(ctx, refKey, refValue); err != nil {
+		glog.Errorf("increase dedup ref %s: %v", refKey, err)
+		return nil, false
+	}
+
+	chunk.Offset = offset
+	chunk.Mtime = time.Now().UnixNano()
+	glog.V(4).Infof("dedup chunk %s refs:%d", chunk.GetFileIdString(), refCount)
+	return chunk, true
+}
+
+// RecordChunk registers a newly uploaded chunk so that later uploads with the same content can reuse it.
+func (d *ChunkDeduper) RecordChunk(ctx context.Context, collection, contentHash string, chunk *filer_pb.FileChunk) error {
+	if err := d.markInUse(ctx); err != nil {
+		return err
+	}
+
+	indexKey := dedupIndexKey(collection, contentHash)
+	unlock := d.indexLocks.Lock(string(indexKey))
+	defer unlock()
+
+	indexed := proto.Clone(chunk).(*filer_pb.FileChunk)
+	indexed.Offset = 0
+	value, err := proto.Marshal(indexed)
+	if err != nil {
+		return fmt.Errorf("encode dedup chunk %s: %v", chunk.GetFileIdString(), err)
+	}
+
+	refValue := make([]byte, 4+len(indexKey))
+	util.Uint32toBytes(refValue[:4], 1)
+	copy(refValue[4:], indexKey)
+	if err = d.store.KvPut(ctx, dedupRefKey(chunk.GetFileIdString()), refValue); err != nil {
+		return fmt.Errorf("set dedup ref %s: %v", chunk.GetFileIdString(), err)
+	}
+
+	return d.store.KvPut(ctx, indexKey, value)
+}
+
+func (d *ChunkDeduper) markInUse(ctx context.Context) error {
+	if atomic.LoadInt32(&d.inUse) == 1 {
+		return nil
+	}
+	d.inUseLock.Lock()
+	defer d.inUseLock.Unlock()
+	if atomic.LoadInt32(&d.inUse) == 1 {
+		return nil
+	}
+	if err := d.store.KvPut(ctx, []byte(dedupInUseKey), []byte{1}); err != nil {
+		return fmt.Errorf("mark dedup in use: %v", err)
+	}
+	atomic.StoreInt32(&d.inUse, 1)
+	return nil
+}
+
+// FilterReferencedFileIds releases one reference of each file id,
+// and returns only the file ids that are no longer referenced.
+func (d *ChunkDeduper) FilterReferencedFileIds(fileIds []string) (toDelete []string) {
+	if d == nil || atomic.LoadInt32(&d.inUse) == 0 {
+		return fileIds
+	}
+
+	ctx := context.Background()
+	for _, fileId := range fileIds {
+		if d.releaseReference(ctx, fileId) {
+			toDelete = append(toDelete, fileId)
+		}
+	}
+	return
+}
+
+// releaseReference decreases the refcount of the file id, and tells whether the chunk is no longer referenced.
+func (d *ChunkDeduper) releaseReference(ctx context.Context, fileId string) (isLast bool) {
+	refKey := dedupRefKey(fileId)
+	refValue, err := d.store.KvGet(ctx, refKey)
+	if err != nil || len(refValue) < 4 {
+		return true
+	}
+
+	// the index key of a file id never changes, lock it and read the refcount again
+	indexKey := refValue[4:]
+	unlock := d.indexLocks.Lock(string(indexKey))
+	defer unlock()
+	if refValue, err = d.store.KvGet(ctx, refKey); err != nil || len(refValue) < 4 {
+		return true
+	}
+
+	refCount := util.BytesToUint32(refValue[:4])
+	if refCount > 1 {
+		util.Uint32toBytes(refValue[:4], refCount-1)
+		if err = d.store.KvPut(ctx, refKey, refValue); err != nil {
+			glog.Errorf("decrease dedup ref %s: %v", refKey, err)
+		}
+		return false
+	}
+
+	// last reference, remove the index if it still points to this file id
+	if value, err := d.store.KvGet(ctx, indexKey); err == nil && len(value) > 0 {
+		chunk := &filer_pb.FileChunk{}
+		if err = proto.Unmarshal(value, chunk); err == nil && chunk.GetFileIdString() == fileId {
+			if err = d.store.KvDelete(ctx, indexKey); err != nil {
+				glog.Errorf("delete dedup index %s: %v", indexKey, err)
+			}
+		}
+	}
+	if err = d.store.KvDelete(ctx, refKey); err != nil {
+		glog.Errorf("delete dedup ref %s: %v", refKey, err)
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package filer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+func TestChunkDeduperReleasesIndex(t *testing.T) {
+	store := &kvFilerStore{kv: memoryKvStore{}}
+	d := NewChunkDeduper(store)
+	ctx := context.Background()
+	contentHash := ChunkContentHash([]byte("hello"))
+
+	assert.Nil(t, d.RecordChunk(ctx, "c", contentHash, &filer_pb.FileChunk{FileId: "3,01", Size: 5}))
+	chunk, found := d.FindChunk(ctx, "c", contentHash, 10)
+	assert.True(t, found)
+	assert.Equal(t, int64(10), chunk.Offset)
+
+	assert.Nil(t, d.FilterReferencedFileIds([]string{"3,01"}))
+	assert.Equal(t, []string{"3,01"}, d.FilterReferencedFileIds([]string{"3,01"}))
+	_, err := store.KvGet(ctx, dedupIndexKey("c", contentHash))
+	assert.Equal(t, ErrKvNotFound, err)
+	_, found = d.FindChunk(ctx, "c", contentHash, 0)
+	assert.False(t, found)
+
+	// the index of a chunk deleted without the refcount is dropped on lookup
+	assert.Nil(t, d.RecordChunk(ctx, "c", contentHash, &filer_pb.FileChunk{FileId: "4,02", Size: 5}))
+	assert.Nil(t, store.KvDelete(ctx, dedupRefKey("4,02")))
+	_, found = d.FindChunk(ctx, "c", contentHash, 0)
+	assert.False(t, found)
+	_, err = store.KvGet(ctx, dedupIndexKey("c", contentHash))
+	assert.Equal(t, ErrKvNotFound, err)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
@@ -14,7 +15,7 @@ const (
 )
 
 type OnChunksFunc func([]*filer_pb.FileChunk) error
-type OnHardLinkIdsFunc func([]HardLinkId) error
+type OnHardLinksFunc func(hardLinks []*Entry, shouldDeleteChunks bool) error
 
 func (f *Filer) DeleteEntryMetaAndData(ctx context.Context, p util.FullPath, isRecursive, ignoreRecursiveError, shouldDeleteChunks, isFromOtherCluster bool, signatures []int32) (err error) {
 	if p == "/" {
@@ -25,20 +26,20 @@ func (f *Filer) DeleteEntryMetaAndData(ctx context.Context, p util.FullPath, isR
 	if findErr != nil {
 		return findErr
 	}
+	if err = f.CheckObjectLockForDelete(ctx, entry, isGovernanceBypassed(ctx)); err != nil {
+		glog.V(2).Infof("delete entry: %v", err)
+		return err
+	}
 	isDeleteCollection := f.isBucket(entry)
 	if entry.IsDirectory() {
 		// delete the folder children, not including the folder itself
 		err = f.doBatchDeleteFolderMetaAndData(ctx, entry, isRecursive, ignoreRecursiveError, shouldDeleteChunks && !isDeleteCollection, isDeleteCollection, isFromOtherCluster, signatures, func(chunks []*filer_pb.FileChunk) error {
-			if shouldDeleteChunks && !isDeleteCollection {
-				f.DirectDeleteChunks(chunks)
-			}
+			f.DirectDeleteChunks(chunks)
 			return nil
-		}, func(hardLinkIds []HardLinkId) error {
+		}, func(hardLinks []*Entry, shouldDeleteChunks bool) error {
 			// A case not handled:
 			// what if the chunk is in a different collection?
-			if shouldDeleteChunks {
-				f.maybeDeleteHardLinks(hardLinkIds)
-			}
+			f.maybeDeleteHardLinks(hardLinks, shouldDeleteChunks)
 			return nil
 		})
 		if err != nil {
@@ -47,7 +48,8 @@ func (f *Filer) DeleteEntryMetaAndData(ctx context.Context, p util.FullPath, isR
 		}
 	}
 
-	if shouldDeleteChunks && !isDeleteCollection {
+	// the chunks of a hard link are deleted with its last link
+	if shouldDeleteChunks && !isDeleteCollection && len(entry.HardLinkId) == 0 {
 		f.DirectDeleteChunks(entry.Chunks)
 	}
 
@@ -65,7 +67,7 @@ func (f *Filer) DeleteEntryMetaAndData(ctx context.Context, p util.FullPath, isR
 	return nil
 }
 
-func (f *Filer) doBatchDeleteFolderMetaAndData(ctx context.Context, entry *Entry, isRecursive, ignoreRecursiveError, shouldDeleteChunks, isDeletingBucket, isFromOtherCluster bool, signatures []int32, onChunksFn OnChunksFunc, onHardLinkIdsFn OnHardLinkIdsFunc) (err error) {
+func (f *Filer) doBatchDeleteFolderMetaAndData(ctx context.Context, entry *Entry, isRecursive, ignoreRecursiveError, shouldDeleteChunks, isDeletingBucket, isFromOtherCluster bool, signatures []int32, onChunksFn OnChunksFunc, onHardLinksFn OnHardLinksFunc) (err error) {
 
 	lastFileName := ""
 	includeLastFile := false
@@ -85,14 +87,18 @@ func (f *Filer) doBatchDeleteFolderMetaAndData(ctx context.Context, entry *Entry
 			for _, sub := range entries {
 				lastFileName = sub.Name()
 				if sub.IsDirectory() {
+					// the chunks of a nested bucket are dropped with its collection
 					subIsDeletingBucket := f.isBucket(sub)
-					err = f.doBatchDeleteFolderMetaAndData(ctx, sub, isRecursive, ignoreRecursiveError, shouldDeleteChunks, subIsDeletingBucket, false, nil, onChunksFn, onHardLinkIdsFn)
+					err = f.doBatchDeleteFolderMetaAndData(ctx, sub, isRecursive, ignoreRecursiveError, shouldDeleteChunks && !subIsDeletingBucket, subIsDeletingBucket, false, nil, onChunksFn, onHardLinksFn)
+					if err == nil && subIsDeletingBucket && shouldDeleteChunks {
+						f.doDeleteCollection(sub.Name())
+					}
 				} else {
 					f.NotifyUpdateEvent(ctx, sub, nil, shouldDeleteChunks, isFromOtherCluster, nil)
 					if len(sub.HardLinkId) != 0 {
 						// hard link chunk data are deleted separately
-						err = onHardLinkIdsFn([]HardLinkId{sub.HardLinkId})
-					} else {
+						err = onHardLinksFn([]*Entry{sub}, shouldDeleteChunks)
+					} else if shouldDeleteChunks {
 						err = onChunksFn(sub.Chunks)
 					}
 				}
@@ -122,9 +128,13 @@ func (f *Filer) doDeleteEntryMetaAndData(ctx context.Context, entry *Entry, shou
 
 	glog.V(3).Infof("deleting entry %v, delete chunks: %v", entry.FullPath, shouldDeleteChunks)
 
-	if storeDeletionErr := f.Store.DeleteOneEntry(ctx, entry); storeDeletionErr != nil {
+	isLastHardLink, storeDeletionErr := f.Store.DeleteOneEntryAndHardLink(ctx, entry)
+	if storeDeletionErr != nil {
 		return fmt.Errorf("filer store delete: %v", storeDeletionErr)
 	}
+	if isLastHardLink && shouldDeleteChunks {
+		f.DirectDeleteChunks(entry.Chunks)
+	}
 	if !entry.IsDirectory() {
 		f.NotifyUpdateEvent(ctx, entry, nil, shouldDeleteChunks, isFromOtherCluster, signatures)
 	}
@@ -135,9 +145,7 @@ func (f *Filer) doDeleteEntryMetaAndData(ctx context.Context, entry *Entry, shou
 func (f *Filer) doDeleteCollection(collectionName string) (err error) {
 
 	return f.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
-		_, err := client.CollectionDelete(context.Background(), &master_pb.CollectionDeleteRequest{
-			Name: collectionName,
-		})
+		err := operation.DeleteCollection(client, collectionName)
 		if err != nil {
 			glog.Infof("delete collection %s: %v", collectionName, err)
 		}
@@ -146,10 +154,15 @@ func (f *Filer) doDeleteCollection(collectionName string) (err error) {
 
 }
 
-func (f *Filer) maybeDeleteHardLinks(hardLinkIds []HardLinkId) {
-	for _, hardLinkId := range hardLinkIds {
-		if err := f.Store.DeleteHardLink(context.Background(), hardLinkId); err != nil {
-			glog.Errorf("delete hard link id %d : %v", hardLinkId, err)
+func (f *Filer) maybeDeleteHardLinks(hardLinks []*Entry, shouldDeleteChunks bool) {
+	for _, hardLink := range hardLinks {
+		isLastLink, err := f.Store.DeleteHardLink(context.Background(), hardLink.HardLinkId)
+		if err != nil {
+			glog.Errorf("delete hard link id %d : %v", hardLink.HardLinkId, err)
+			continue
+		}
+		if isLastLink && shouldDeleteChunks {
+			f.DirectDeleteChunks(hardLink.Chunks)
 		}
 	}
 }
@@ -0,0 +1,206 @@
+package filer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	RecursiveDeletePageSize       = 10 * PaginationSize
+	RecursiveDeleteChunkBatchSize = 10000
+)
+
+type RecursiveDeleteProgress struct {
+	Directory         util.FullPath
+	DeletedEntryCount uint64
+	DeletedChunkCount uint64
+}
+
+type OnRecursiveDeleteProgressFunc func(progress *RecursiveDeleteProgress) error
+
+// recursiveDeletion walks a folder tree in large pages, and collects the chunks of many files
+// to delete them in batches, instead of one volume server round trip per file.
+type recursiveDeletion struct {
+	f                    *Filer
+	pageSize             int
+	shouldDeleteChunks   bool
+	ignoreRecursiveError bool
+	isFromOtherCluster   bool
+	fileIds              []string
+	progress             RecursiveDeleteProgress
+	onProgressFn         OnRecursiveDeleteProgressFunc
+}
+
+// DeleteEntryRecursively deletes the entry and, for a folder, everything under it.
+// The progress is reported after each page of entries.
+func (f *Filer) DeleteEntryRecursively(ctx context.Context, p util.FullPath, ignoreRecursiveError, shouldDeleteChunks, isFromOtherCluster bool, signatures []int32, pageSize int, onProgressFn OnRecursiveDeleteProgressFunc) (err error) {
+	if p == "/" {
+		return nil
+	}
+
+	entry, findErr := f.FindEntry(ctx, p)
+	if findErr != nil {
+		return findErr
+	}
+	if err = f.CheckObjectLockForDelete(ctx, entry, isGovernanceBypassed(ctx)); err != nil {
+		glog.V(2).Infof("delete entry: %v", err)
+		return err
+	}
+	if pageSize <= 0 {
+		pageSize = RecursiveDeletePageSize
+	}
+
+	isDeleteCollection := f.isBucket(entry)
+	d := &recursiveDeletion{
+		f:                    f,
+		pageSize:             pageSize,
+		shouldDeleteChunks:   shouldDeleteChunks && !isDeleteCollection,
+		ignoreRecursiveError: ignoreRecursiveError,
+		isFromOtherCluster:   isFromOtherCluster,
+		onProgressFn:         onProgressFn,
+	}
+
+	if entry.IsDirectory() {
+		if isDeleteCollection && f.Store.CanDropWholeBucket() {
+			err = d.deleteFolderChildren(ctx, entry, d.shouldDeleteChunks, signatures)
+		} else {
+			err = d.deleteFolder(ctx, entry, d.shouldDeleteChunks, signatures)
+		}
+		if err != nil {
+			glog.V(0).Infof("delete directory %s: %v", p, err)
+			return fmt.Errorf("delete directory %s: %v", p, err)
+		}
+	}
+
+	// the chunks of a hard link are deleted with its last link
+	if d.shouldDeleteChunks && len(entry.HardLinkId) == 0 {
+		d.fileIds = append(d.fileIds, f.resolveChunkFileIds(entry.Chunks)...)
+	}
+	d.flushChunks()
+
+	if err = f.doDeleteEntryMetaAndData(ctx, entry, d.shouldDeleteChunks, isFromOtherCluster, signatures); err != nil {
+		return fmt.Errorf("delete file %s: %v", p, err)
+	}
+	d.progress.DeletedEntryCount++
+	d.progress.Directory = entry.FullPath
+	if err = d.reportProgress(); err != nil {
+		return err
+	}
+
+	if isDeleteCollection {
+		f.doDeleteCollection(entry.Name())
+	}
+
+	return nil
+}
+
+func (d *recursiveDeletion) deleteFolder(ctx context.Context, entry *Entry, shouldDeleteChunks bool, signatures []int32) (err error) {
+	lastFileName := ""
+	for {
+		entries, _, listErr := d.f.ListDirectoryEntries(ctx, entry.FullPath, lastFileName, false, int64(d.pageSize), "", "", "")
+		if listErr != nil {
+			glog.Errorf("list folder %s: %v", entry.FullPath, listErr)
+			return fmt.Errorf("list folder %s: %v", entry.FullPath, listErr)
+		}
+
+		var hardLinks []*Entry
+		for _, sub := range entries {
+			lastFileName = sub.Name()
+			if sub.IsDirectory() {
+				if err = d.deleteSubFolder(ctx, sub, shouldDeleteChunks); err != nil {
+					if !d.ignoreRecursiveError {
+						return err
+					}
+					glog.V(0).Infof("delete directory %s: %v", sub.FullPath, err)
+					continue
+				}
+				d.progress.DeletedEntryCount++
+				continue
+			}
+			d.f.NotifyUpdateEvent(ctx, sub, nil, shouldDeleteChunks, d.isFromOtherCluster, nil)
+			if len(sub.HardLinkId) != 0 {
+				// hard link chunk data are deleted separately
+				hardLinks = append(hardLinks, sub)
+			} else if shouldDeleteChunks {
+				d.fileIds = append(d.fileIds, d.f.resolveChunkFileIds(sub.Chunks)...)
+			}
+			d.progress.DeletedEntryCount++
+		}
+		d.collectHardLinkChunks(ctx, hardLinks, shouldDeleteChunks)
+
+		if len(d.fileIds) >= RecursiveDeleteChunkBatchSize {
+			d.flushChunks()
+		}
+		d.progress.Directory = entry.FullPath
+		if err = d.reportProgress(); err != nil {
+			return err
+		}
+
+		if len(entries) < d.pageSize {
+			break
+		}
+	}
+
+	return d.deleteFolderChildren(ctx, entry, shouldDeleteChunks, signatures)
+}
+
+// deleteSubFolder deletes a sub folder. A nested bucket keeps its chunks, which are dropped with its collection.
+func (d *recursiveDeletion) deleteSubFolder(ctx context.Context, sub *Entry, shouldDeleteChunks bool) (err error) {
+	if !d.f.isBucket(sub) {
+		return d.deleteFolder(ctx, sub, shouldDeleteChunks, nil)
+	}
+	if d.f.Store.CanDropWholeBucket() {
+		err = d.deleteFolderChildren(ctx, sub, false, nil)
+	} else {
+		err = d.deleteFolder(ctx, sub, false, nil)
+	}
+	if err == nil && shouldDeleteChunks {
+		d.f.doDeleteCollection(sub.Name())
+	}
+	return
+}
+
+func (d *recursiveDeletion) deleteFolderChildren(ctx context.Context, entry *Entry, shouldDeleteChunks bool, signatures []int32) error {
+	glog.V(3).Infof("deleting directory %v delete chunks: %v", entry.FullPath, shouldDeleteChunks)
+
+	if storeDeletionErr := d.f.Store.DeleteFolderChildren(ctx, entry.FullPath); storeDeletionErr != nil {
+		return fmt.Errorf("filer store delete: %v", storeDeletionErr)
+	}
+
+	d.f.NotifyUpdateEvent(ctx, entry, nil, shouldDeleteChunks, d.isFromOtherCluster, signatures)
+
+	return nil
+}
+
+func (d *recursiveDeletion) collectHardLinkChunks(ctx context.Context, hardLinks []*Entry, shouldDeleteChunks bool) {
+	for _, hardLink := range hardLinks {
+		isLastLink, err := d.f.Store.DeleteHardLink(ctx, hardLink.HardLinkId)
+		if err != nil {
+			glog.Errorf("delete hard link id %d : %v", hardLink.HardLinkId, err)
+			continue
+		}
+		if isLastLink && shouldDeleteChunks {
+			d.fileIds = append(d.fileIds, d.f.resolveChunkFileIds(hardLink.Chunks)...)
+		}
+	}
+}
+
+func (d *recursiveDeletion) flushChunks() {
+	if len(d.fileIds) == 0 {
+		return
+	}
+	d.f.doDeleteFileIds(d.fileIds)
+	d.progress.DeletedChunkCount += uint64(len(d.fileIds))
+	d.fileIds = nil
+}
+
+func (d *recursiveDeletion) reportProgress() error {
+	if d.onProgressFn == nil {
+		return nil
+	}
+	progress := d.progress
+	return d.onProgressFn(&progress)
+}
@@ -41,7 +41,16 @@ func (f *Filer) loopProcessingDeletion() {
 	var deletionCount int
 	for {
 		deletionCount = 0
-		f.fileIdDeletionQueue.Consume(func(fileIds []string) {
+		f.fileIdDeletionQueue.Consume(func(fileIds []string, isResumed bool) {
+			if isResumed {
+				// skip the file ids deleted before the restart
+				if existing, err := operation.FilterExistingFileIds(f.GrpcDialOption, fileIds, lookupFunc); err != nil {
+					glog.V(0).Infof("check resumed deletion of %d file ids: %v", len(fileIds), err)
+				} else {
+					glog.V(1).Infof("resume deleting %d of %d file ids", len(existing), len(fileIds))
+					fileIds = existing
+				}
+			}
 			for len(fileIds) > 0 {
 				var toDeleteFileIds []string
 				if len(fileIds) > DeletionBatchSize {
@@ -74,6 +83,7 @@ func (f *Filer) doDeleteFileIds(fileIds []string) {
 	lookupFunc := LookupByMasterClientFn(f.MasterClient)
 	DeletionBatchSize := 100000 // roughly 20 bytes cost per file id.
 
+	fileIds = f.releaseReferencedFileIds(fileIds)
 	for len(fileIds) > 0 {
 		var toDeleteFileIds []string
 		if len(fileIds) > DeletionBatchSize {
@@ -94,7 +104,11 @@ func (f *Filer) doDeleteFileIds(fileIds []string) {
 }
 
 func (f *Filer) DirectDeleteChunks(chunks []*filer_pb.FileChunk) {
-	var fileIdsToDelete []string
+	f.doDeleteFileIds(f.resolveChunkFileIds(chunks))
+}
+
+// resolveChunkFileIds lists the file ids of the chunks, including the data chunks of chunk manifests
+func (f *Filer) resolveChunkFileIds(chunks []*filer_pb.FileChunk) (fileIdsToDelete []string) {
 	for _, chunk := range chunks {
 		if !chunk.IsChunkManifest {
 			fileIdsToDelete = append(fileIdsToDelete, chunk.GetFileIdString())
@@ -109,31 +123,27 @@ func (f *Filer) DirectDeleteChunks(chunks []*filer_pb.FileChunk) {
 		}
 		fileIdsToDelete = append(fileIdsToDelete, chunk.GetFileIdString())
 	}
+	return
+}
 
-	f.doDeleteFileIds(fileIdsToDelete)
+// releaseReferencedFileIds releases one reference of the deduplicated and the packed file ids,
+// and returns the file ids no longer referenced. The references are released before the file ids
+// are queued, so resuming the persisted deletion queue after a restart does not release them again.
+func (f *Filer) releaseReferencedFileIds(fileIds []string) []string {
+	fileIds = f.Deduper.FilterReferencedFileIds(fileIds)
+	return f.PackedContainers.FilterReferencedFileIds(fileIds)
 }
 
 func (f *Filer) DeleteChunks(chunks []*filer_pb.FileChunk) {
-	for _, chunk := range chunks {
-		if !chunk.IsChunkManifest {
-			f.fileIdDeletionQueue.EnQueue(chunk.GetFileIdString())
-			continue
-		}
-		dataChunks, manifestResolveErr := ResolveOneChunkManifest(f.MasterClient.LookupFileId, chunk)
-		if manifestResolveErr != nil {
-			glog.V(0).Infof("failed to resolve manifest %s: %v", chunk.FileId, manifestResolveErr)
-		}
-		for _, dChunk := range dataChunks {
-			f.fileIdDeletionQueue.EnQueue(dChunk.GetFileIdString())
-		}
-		f.fileIdDeletionQueue.EnQueue(chunk.GetFileIdString())
-	}
+	f.fileIdDeletionQueue.EnQueue(f.releaseReferencedFileIds(f.resolveChunkFileIds(chunks))...)
 }
 
 func (f *Filer) DeleteChunksNotRecursive(chunks []*filer_pb.FileChunk) {
+	var fileIds []string
 	for _, chunk := range chunks {
-		f.fileIdDeletionQueue.EnQueue(chunk.GetFileIdString())
+		fileIds = append(fileIds, chunk.GetFileIdString())
 	}
+	f.fileIdDeletionQueue.EnQueue(f.releaseReferencedFileIds(fileIds)...)
 }
 
 func (f *Filer) deleteChunksIfNotNew(oldEntry, newEntry *Entry) {
@@ -156,10 +166,10 @@ func (f *Filer) deleteChunksIfNotNew(oldEntry, newEntry *Entry) {
 		return
 	}
 	for _, newChunk := range newDataChunks {
-		newChunkIds[newChunk.GetFileIdString()] = true
+		newChunkIds[chunkIdentity(newChunk)] = true
 	}
 	for _, newChunk := range newManifestChunks {
-		newChunkIds[newChunk.GetFileIdString()] = true
+		newChunkIds[chunkIdentity(newChunk)] = true
 	}
 
 	oldDataChunks, oldManifestChunks, err := ResolveChunkManifest(f.MasterClient.GetLookupFileIdFunction(),
@@ -170,12 +180,12 @@ func (f *Filer) deleteChunksIfNotNew(oldEntry, newEntry *Entry) {
 		return
 	}
 	for _, oldChunk := range oldDataChunks {
-		if _, found := newChunkIds[oldChunk.GetFileIdString()]; !found {
+		if _, found := newChunkIds[chunkIdentity(oldChunk)]; !found {
 			toDelete = append(toDelete, oldChunk)
 		}
 	}
 	for _, oldChunk := range oldManifestChunks {
-		if _, found := newChunkIds[oldChunk.GetFileIdString()]; !found {
+		if _, found := newChunkIds[chunkIdentity(oldChunk)]; !found {
 			toDelete = append(toDelete, oldChunk)
 		}
 	}
@@ -0,0 +1,194 @@
+package filer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+)
+
+type deletionQueueKvStore interface {
+	KvPut(ctx context.Context, key []byte, value []byte) (err error)
+	KvGet(ctx context.Context, key []byte) (value []byte, err error)
+	KvDelete(ctx context.Context, key []byte) (err error)
+}
+
+// deletionSegment is the file ids of one deletion, kept in the filer store until they are deleted.
+type deletionSegment struct {
+	seq         uint64
+	isPersisted bool
+	isResumed   bool
+	fileIds     []string
+}
+
+// DeletionQueue holds the file ids to delete from the volume servers.
+// Each batch of file ids is written to the filer store kv, under a key prefix of this filer address,
+// so the pending deletions are resumed after a restart instead of becoming orphans.
+// The head is the first batch not deleted yet, and the tail is the next batch to write.
+type DeletionQueue struct {
+	sync.Mutex
+	store       deletionQueueKvStore
+	keyPrefix   string
+	head        uint64
+	tail        uint64
+	segments    []*deletionSegment
+	fileIdCount int
+}
+
+func NewDeletionQueue(filerHost string) *DeletionQueue {
+	return &DeletionQueue{
+		keyPrefix: "filer.deletion." + filerHost + ".",
+	}
+}
+
+func (q *DeletionQueue) headKey() []byte {
+	return []byte(q.keyPrefix + "head")
+}
+
+func (q *DeletionQueue) tailKey() []byte {
+	return []byte(q.keyPrefix + "tail")
+}
+
+func (q *DeletionQueue) segmentKey(seq uint64) []byte {
+	return []byte(fmt.Sprintf("%s%d", q.keyPrefix, seq))
+}
+
+func (q *DeletionQueue) readSeq(key []byte) (uint64, error) {
+	value, err := q.store.KvGet(context.Background(), key)
+	if err == ErrKvNotFound || err == nil && len(value) == 0 {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(string(value), 10, 64)
+}
+
+func (q *DeletionQueue) writeSeq(key []byte, seq uint64) error {
+	return q.store.KvPut(context.Background(), key, []byte(strconv.FormatUint(seq, 10)))
+}
+
+// Load starts persisting the queue in the store, and queues the deletions left over by the last run.
+// A store without kv support keeps the queue in memory only.
+func (q *DeletionQueue) Load(store deletionQueueKvStore) error {
+	q.Lock()
+	defer q.Unlock()
+
+	q.store = store
+	head, err := q.readSeq(q.headKey())
+	if err == nil {
+		q.tail, err = q.readSeq(q.tailKey())
+	}
+	if err != nil {
+		q.store = nil
+		if err == ErrKvNotImplemented {
+			return nil
+		}
+		return fmt.Errorf("load deletion queue: %v", err)
+	}
+	q.head = head
+
+	var loaded []*deletionSegment
+	for seq := head; seq < q.tail; seq++ {
+		value, err := q.store.KvGet(context.Background(), q.segmentKey(seq))
+		if err == ErrKvNotFound || err == nil && len(value) == 0 {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("load deletion queue segment %d: %v", seq, err)
+		}
+		fileIds := strings.Split(string(value), "\n")
+		loaded = append(loaded, &deletionSegment{seq: seq, isPersisted: true, isResumed: true, fileIds: fileIds})
+		q.fileIdCount += len(fileIds)
+	}
+	if len(loaded) > 0 {
+		glog.V(0).Infof("resume deleting %d batches of file ids", len(loaded))
+	}
+	q.segments = append(loaded, q.segments...)
+	stats.FilerDeletionBacklogGauge.Set(float64(q.fileIdCount))
+	return nil
+}
+
+func (q *DeletionQueue) EnQueue(fileIds ...string) {
+	if len(fileIds) == 0 {
+		return
+	}
+	q.Lock()
+	defer q.Unlock()
+
+	segment := &deletionSegment{fileIds: fileIds}
+	if q.store != nil {
+		segment.seq = q.tail
+		err := q.store.KvPut(context.Background(), q.segmentKey(segment.seq), []byte(strings.Join(fileIds, "\n")))
+		if err == nil {
+			err = q.writeSeq(q.tailKey(), q.tail+1)
+		}
+		if err != nil {
+			glog.Warningf("persist deletion of %d file ids: %v", len(fileIds), err)
+		} else {
+			segment.isPersisted = true
+			q.tail++
+		}
+	}
+	q.segments = append(q.segments, segment)
+	q.fileIdCount += len(fileIds)
+	stats.FilerDeletionBacklogGauge.Set(float64(q.fileIdCount))
+}
+
+// Consume passes all queued file ids to fn, and removes them from the store after fn returns.
+// The file ids left over by the last run are passed separately with isResumed set,
+// since some of them may have been deleted already.
+func (q *DeletionQueue) Consume(fn func(fileIds []string, isResumed bool)) {
+	q.Lock()
+	segments := q.segments
+	q.segments = nil
+	q.Unlock()
+
+	if len(segments) == 0 {
+		return
+	}
+	var resumedFileIds, fileIds []string
+	for _, segment := range segments {
+		if segment.isResumed {
+			resumedFileIds = append(resumedFileIds, segment.fileIds...)
+		} else {
+			fileIds = append(fileIds, segment.fileIds...)
+		}
+	}
+
+	if len(resumedFileIds) > 0 {
+		fn(resumedFileIds, true)
+	}
+	if len(fileIds) > 0 {
+		fn(fileIds, false)
+	}
+
+	q.Lock()
+	defer q.Unlock()
+	q.fileIdCount -= len(resumedFileIds) + len(fileIds)
+	stats.FilerDeletionBacklogGauge.Set(float64(q.fileIdCount))
+	if q.store == nil {
+		return
+	}
+	head := q.head
+	for _, segment := range segments {
+		if !segment.isPersisted {
+			continue
+		}
+		if err := q.store.KvDelete(context.Background(), q.segmentKey(segment.seq)); err != nil {
+			glog.Warningf("delete deletion queue segment %d: %v", segment.seq, err)
+		}
+		head = segment.seq + 1
+	}
+	if head != q.head {
+		if err := q.writeSeq(q.headKey(), head); err != nil {
+			glog.Warningf("update deletion queue head to %d: %v", head, err)
+			return
+		}
+		q.head = head
+	}
+}
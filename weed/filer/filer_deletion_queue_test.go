@@ -0,0 +1,69 @@
+package filer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryKvStore map[string][]byte
+
+func (m memoryKvStore) KvPut(ctx context.Context, key []byte, value []byte) error {
+	m[string(key)] = value
+	return nil
+}
+
+func (m memoryKvStore) KvGet(ctx context.Context, key []byte) ([]byte, error) {
+	value, found := m[string(key)]
+	if !found {
+		return nil, ErrKvNotFound
+	}
+	return value, nil
+}
+
+func (m memoryKvStore) KvDelete(ctx context.Context, key []byte) error {
+	delete(m, string(key))
+	return nil
+}
+
+func TestDeletionQueueResumesAfterRestart(t *testing.T) {
+	store := memoryKvStore{}
+
+	q := NewDeletionQueue("localhost:8888")
+	assert.Nil(t, q.Load(store))
+	q.EnQueue("1,01", "1,02")
+	q.EnQueue("2,03")
+
+	var deleted []string
+	q.Consume(func(fileIds []string, isResumed bool) {
+		assert.False(t, isResumed)
+		deleted = append(deleted, fileIds...)
+	})
+	assert.Equal(t, []string{"1,01", "1,02", "2,03"}, deleted)
+
+	q.EnQueue("3,04")
+	q.EnQueue("3,05", "3,06")
+
+	// the filer restarts before deleting them
+	restarted := NewDeletionQueue("localhost:8888")
+	assert.Nil(t, restarted.Load(store))
+	deleted = nil
+	restarted.Consume(func(fileIds []string, isResumed bool) {
+		assert.True(t, isResumed)
+		deleted = append(deleted, fileIds...)
+	})
+	assert.Equal(t, []string{"3,04", "3,05", "3,06"}, deleted)
+
+	// another filer sharing the store has its own queue
+	other := NewDeletionQueue("localhost:8889")
+	assert.Nil(t, other.Load(store))
+	other.Consume(func(fileIds []string, isResumed bool) {
+		t.Errorf("unexpected file ids %v", fileIds)
+	})
+
+	assert.Equal(t, memoryKvStore{
+		"filer.deletion.localhost:8888.head": []byte("4"),
+		"filer.deletion.localhost:8888.tail": []byte("4"),
+	}, store)
+}
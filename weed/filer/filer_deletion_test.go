@@ -0,0 +1,117 @@
+package filer
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+// kvFilerStore only implements the kv of a filer store
+type kvFilerStore struct {
+	VirtualFilerStore
+	kv memoryKvStore
+	sync.Mutex
+}
+
+func (s *kvFilerStore) KvPut(ctx context.Context, key []byte, value []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.kv.KvPut(ctx, key, value)
+}
+
+func (s *kvFilerStore) KvGet(ctx context.Context, key []byte) ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.kv.KvGet(ctx, key)
+}
+
+func (s *kvFilerStore) KvDelete(ctx context.Context, key []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.kv.KvDelete(ctx, key)
+}
+
+func (s *kvFilerStore) KvCompareAndSwap(ctx context.Context, key []byte, oldValue []byte, newValue []byte) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+	value, found := s.kv[string(key)]
+	if found != (oldValue != nil) || !bytes.Equal(value, oldValue) {
+		return false, nil
+	}
+	if newValue == nil {
+		return true, s.kv.KvDelete(ctx, key)
+	}
+	return true, s.kv.KvPut(ctx, key, newValue)
+}
+
+func TestDeletePackedChunksOnceAfterRestart(t *testing.T) {
+	store := &kvFilerStore{kv: memoryKvStore{}}
+	newFiler := func() *Filer {
+		f := &Filer{
+			fileIdDeletionQueue: NewDeletionQueue("localhost:8888"),
+			Deduper:             NewChunkDeduper(store),
+			PackedContainers:    NewPackedContainers(store),
+		}
+		assert.Nil(t, f.fileIdDeletionQueue.Load(store))
+		return f
+	}
+	consume := func(f *Filer) (fileIds []string) {
+		f.fileIdDeletionQueue.Consume(func(ids []string, isResumed bool) {
+			fileIds = append(fileIds, ids...)
+		})
+		return
+	}
+
+	f := newFiler()
+	assert.Nil(t, f.PackedContainers.RecordContainer(context.Background(), "3,01", 3))
+	packed := []*filer_pb.FileChunk{{FileId: "3,01", Size: 10, ContainerSize: 30}}
+
+	// the container is kept while other packed files reference it
+	f.DeleteChunks(packed)
+	f.DeleteChunks([]*filer_pb.FileChunk{{FileId: "4,02"}})
+
+	// the filer restarts before deleting the queued file ids, which releases no more references
+	f = newFiler()
+	assert.Equal(t, []string{"4,02"}, consume(f))
+	f = newFiler()
+	assert.Nil(t, consume(f))
+
+	f.DeleteChunksNotRecursive(packed)
+	assert.Nil(t, consume(f))
+	f.DeleteChunks(packed)
+	f = newFiler()
+	assert.Equal(t, []string{"3,01"}, consume(f))
+	_, err := store.KvGet(context.Background(), packRefKey("3,01"))
+	assert.Equal(t, ErrKvNotFound, err)
+}
+
+func TestReleasePackedContainerAcrossFilers(t *testing.T) {
+	store := &kvFilerStore{kv: memoryKvStore{}}
+	assert.Nil(t, NewPackedContainers(store).RecordContainer(context.Background(), "3,01", 50))
+
+	// the filers sharing the store release the packed files concurrently
+	containers := []*PackedContainers{NewPackedContainers(store), NewPackedContainers(store)}
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var toDelete []string
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(c *PackedContainers) {
+			defer wg.Done()
+			fileIds := c.FilterReferencedFileIds([]string{"3,01"})
+			lock.Lock()
+			toDelete = append(toDelete, fileIds...)
+			lock.Unlock()
+		}(containers[i%2])
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"3,01"}, toDelete)
+	_, err := store.KvGet(context.Background(), packRefKey("3,01"))
+	assert.Equal(t, ErrKvNotFound, err)
+}
@@ -0,0 +1,78 @@
+package filer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// DirChangeSeqsLimit is the number of directories to keep the change sequence numbers for
+const DirChangeSeqsLimit = 100000
+
+// DirChangeSeqs keeps a change sequence number per directory, increased when its children are created, deleted or renamed,
+// so the clients can validate their cached listings with one lookup instead of listing the directory again.
+// The numbers are kept in memory, and are only comparable on the same filer.
+// The directories not changed since the filer started, or forgotten when there are too many, return the base number,
+// which only goes up.
+type DirChangeSeqs struct {
+	sync.Mutex
+	lastSeq uint64
+	baseSeq uint64
+	seqs    map[util.FullPath]uint64
+	limit   int
+}
+
+func NewDirChangeSeqs(limit int) *DirChangeSeqs {
+	// start after the numbers given out before the filer restarted
+	startSeq := uint64(time.Now().UnixNano())
+	return &DirChangeSeqs{
+		lastSeq: startSeq,
+		baseSeq: startSeq,
+		seqs:    make(map[util.FullPath]uint64),
+		limit:   limit,
+	}
+}
+
+// Changed increases the change sequence numbers of the directories.
+func (d *DirChangeSeqs) Changed(dirs ...util.FullPath) {
+	d.Lock()
+	defer d.Unlock()
+	for _, dir := range dirs {
+		if _, found := d.seqs[dir]; !found && len(d.seqs) >= d.limit {
+			// forget all directories, which still see no change if not changed since
+			d.seqs = make(map[util.FullPath]uint64)
+			d.baseSeq = d.lastSeq
+		}
+		d.lastSeq++
+		d.seqs[dir] = d.lastSeq
+	}
+}
+
+// Get returns the change sequence number of the directory.
+func (d *DirChangeSeqs) Get(dir util.FullPath) uint64 {
+	d.Lock()
+	defer d.Unlock()
+	if seq, found := d.seqs[dir]; found {
+		return seq
+	}
+	return d.baseSeq
+}
+
+// updateDirChangeSeqs increases the change sequence numbers of the parent directories
+// if the event creates, deletes or renames an entry.
+func (f *Filer) updateDirChangeSeqs(directory string, eventNotification *filer_pb.EventNotification) {
+	oldEntry, newEntry := eventNotification.OldEntry, eventNotification.NewEntry
+	switch {
+	case oldEntry == nil && newEntry == nil:
+	case oldEntry == nil:
+		f.DirChangeSeqs.Changed(util.FullPath(eventNotification.NewParentPath))
+	case newEntry == nil:
+		f.DirChangeSeqs.Changed(util.FullPath(directory))
+	case directory != eventNotification.NewParentPath:
+		f.DirChangeSeqs.Changed(util.FullPath(directory), util.FullPath(eventNotification.NewParentPath))
+	case oldEntry.Name != newEntry.Name:
+		f.DirChangeSeqs.Changed(util.FullPath(directory))
+	}
+}
@@ -0,0 +1,59 @@
+package filer
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+func TestDirChangeSeqs(t *testing.T) {
+	d := NewDirChangeSeqs(2)
+
+	base := d.Get("/a")
+	d.Changed("/a")
+	a1 := d.Get("/a")
+	if a1 <= base {
+		t.Fatalf("changed /a: %d not after %d", a1, base)
+	}
+	if d.Get("/b") != base {
+		t.Errorf("unchanged /b: %d, want %d", d.Get("/b"), base)
+	}
+
+	d.Changed("/b")
+	// forgets /a and /b, which must not go back
+	d.Changed("/c")
+	if a := d.Get("/a"); a < a1 {
+		t.Errorf("forgotten /a went back from %d to %d", a1, a)
+	}
+	if c := d.Get("/c"); c <= d.Get("/b") {
+		t.Errorf("changed /c: %d not after /b %d", c, d.Get("/b"))
+	}
+}
+
+func TestUpdateDirChangeSeqs(t *testing.T) {
+	f := &Filer{DirChangeSeqs: NewDirChangeSeqs(DirChangeSeqsLimit)}
+	seqs := func() (a, b uint64) {
+		return f.DirChangeSeqs.Get("/a"), f.DirChangeSeqs.Get("/b")
+	}
+
+	tests := []struct {
+		name               string
+		directory          string
+		event              *filer_pb.EventNotification
+		changedA, changedB bool
+	}{
+		{"create", "/a", &filer_pb.EventNotification{NewEntry: &filer_pb.Entry{Name: "x"}, NewParentPath: "/a"}, true, false},
+		{"update", "/a", &filer_pb.EventNotification{OldEntry: &filer_pb.Entry{Name: "x"}, NewEntry: &filer_pb.Entry{Name: "x"}, NewParentPath: "/a"}, false, false},
+		{"rename", "/a", &filer_pb.EventNotification{OldEntry: &filer_pb.Entry{Name: "x"}, NewEntry: &filer_pb.Entry{Name: "y"}, NewParentPath: "/a"}, true, false},
+		{"move", "/a", &filer_pb.EventNotification{OldEntry: &filer_pb.Entry{Name: "y"}, NewEntry: &filer_pb.Entry{Name: "y"}, NewParentPath: "/b"}, true, true},
+		{"delete", "/b", &filer_pb.EventNotification{OldEntry: &filer_pb.Entry{Name: "y"}}, false, true},
+	}
+	for _, tt := range tests {
+		a, b := seqs()
+		f.updateDirChangeSeqs(tt.directory, tt.event)
+		newA, newB := seqs()
+		if (newA != a) != tt.changedA || (newB != b) != tt.changedB {
+			t.Errorf("%s: /a %d => %d, /b %d => %d", tt.name, a, newA, b, newB)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package filer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+// NobodyId is the uid and gid the root user of the clients is squashed to.
+const NobodyId = 65534
+
+type idRange struct {
+	clientStart uint32
+	filerStart  uint32
+	count       uint32
+}
+
+// IdMapping translates the uid and gid of the clients to the ones saved in the filer, and back.
+// The ids not covered by any range are kept as they are.
+type IdMapping struct {
+	uidRanges  []idRange
+	gidRanges  []idRange
+	squashRoot bool
+}
+
+// ParseIdRanges parses the ranges in the format of clientStart:filerStart:count.
+func ParseIdRanges(specs []string) (ranges []idRange, err error) {
+	for _, spec := range specs {
+		parts := strings.Split(spec, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("id range %s should be clientStart:filerStart:count", spec)
+		}
+		var values [3]uint32
+		for i, part := range parts {
+			v, parseErr := strconv.ParseUint(part, 10, 32)
+			if parseErr != nil {
+				return nil, fmt.Errorf("id range %s: %v", spec, parseErr)
+			}
+			values[i] = uint32(v)
+		}
+		r := idRange{clientStart: values[0], filerStart: values[1], count: values[2]}
+		if r.count == 0 || uint64(r.clientStart)+uint64(r.count) > 1<<32 || uint64(r.filerStart)+uint64(r.count) > 1<<32 {
+			return nil, fmt.Errorf("id range %s is out of bounds", spec)
+		}
+		ranges = append(ranges, r)
+	}
+	return
+}
+
+// NewIdMapping creates the mapping of the path configuration, or nil if there is nothing to map.
+func NewIdMapping(pathConf *filer_pb.FilerConf_PathConf) (*IdMapping, error) {
+	if len(pathConf.UidMap) == 0 && len(pathConf.GidMap) == 0 && !pathConf.SquashRoot {
+		return nil, nil
+	}
+	uidRanges, err := ParseIdRanges(pathConf.UidMap)
+	if err != nil {
+		return nil, fmt.Errorf("uid map: %v", err)
+	}
+	gidRanges, err := ParseIdRanges(pathConf.GidMap)
+	if err != nil {
+		return nil, fmt.Errorf("gid map: %v", err)
+	}
+	return &IdMapping{
+		uidRanges:  uidRanges,
+		gidRanges:  gidRanges,
+		squashRoot: pathConf.SquashRoot,
+	}, nil
+}
+
+// ToFiler translates the ids of the client to the ids saved in the filer.
+func (m *IdMapping) ToFiler(uid, gid uint32) (uint32, uint32) {
+	if m == nil {
+		return uid, gid
+	}
+	if m.squashRoot {
+		if uid == 0 {
+			uid = NobodyId
+		}
+		if gid == 0 {
+			gid = NobodyId
+		}
+	}
+	return mapId(m.uidRanges, uid, true), mapId(m.gidRanges, gid, true)
+}
+
+// ToClient translates the ids saved in the filer to the ids of the client.
+func (m *IdMapping) ToClient(uid, gid uint32) (uint32, uint32) {
+	if m == nil {
+		return uid, gid
+	}
+	return mapId(m.uidRanges, uid, false), mapId(m.gidRanges, gid, false)
+}
+
+func mapId(ranges []idRange, id uint32, toFiler bool) uint32 {
+	for _, r := range ranges {
+		from, to := r.clientStart, r.filerStart
+		if !toFiler {
+			from, to = to, from
+		}
+		if from <= id && id-from < r.count {
+			return to + (id - from)
+		}
+	}
+	return id
+}
+
+// IdMappingOf finds the uid and gid mapping of the path.
+func (fc *FilerConf) IdMappingOf(path string) (*IdMapping, error) {
+	return NewIdMapping(fc.MatchStorageRule(path))
+}
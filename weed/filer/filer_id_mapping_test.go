@@ -0,0 +1,70 @@
+package filer
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdMapping(t *testing.T) {
+
+	fc := NewFilerConf()
+	fc.doLoadConf(&filer_pb.FilerConf{Locations: []*filer_pb.FilerConf_PathConf{
+		{
+			LocationPrefix: "/shared/",
+			UidMap:         []string{"1000:101000:1000"},
+			GidMap:         []string{"100:200100:10"},
+			SquashRoot:     true,
+		},
+		{
+			LocationPrefix: "/shared/team/",
+			UidMap:         []string{"1000:201000:1000"},
+		},
+	}})
+
+	m, err := fc.IdMappingOf("/shared/a.txt")
+	assert.Nil(t, err)
+	uid, gid := m.ToFiler(1001, 105)
+	assert.Equal(t, uint32(101001), uid)
+	assert.Equal(t, uint32(200105), gid)
+	uid, gid = m.ToClient(uid, gid)
+	assert.Equal(t, uint32(1001), uid)
+	assert.Equal(t, uint32(105), gid)
+
+	// not mapped ids are kept
+	uid, gid = m.ToFiler(2000, 110)
+	assert.Equal(t, uint32(2000), uid)
+	assert.Equal(t, uint32(110), gid)
+
+	// root is squashed
+	uid, gid = m.ToFiler(0, 0)
+	assert.Equal(t, uint32(NobodyId), uid)
+	assert.Equal(t, uint32(NobodyId), gid)
+
+	// the longer prefix overrides the uid map, and inherits the others
+	m, err = fc.IdMappingOf("/shared/team/b.txt")
+	assert.Nil(t, err)
+	uid, gid = m.ToFiler(1999, 100)
+	assert.Equal(t, uint32(201999), uid)
+	assert.Equal(t, uint32(200100), gid)
+
+	m, err = fc.IdMappingOf("/other/c.txt")
+	assert.Nil(t, err)
+	assert.Nil(t, m)
+	uid, gid = m.ToFiler(0, 0)
+	assert.Equal(t, uint32(0), uid)
+	assert.Equal(t, uint32(0), gid)
+}
+
+func TestParseIdRanges(t *testing.T) {
+	_, err := ParseIdRanges([]string{"1000:2000"})
+	assert.NotNil(t, err)
+	_, err = ParseIdRanges([]string{"1000:2000:0"})
+	assert.NotNil(t, err)
+	_, err = ParseIdRanges([]string{"1000:4294967295:2"})
+	assert.NotNil(t, err)
+	ranges, err := ParseIdRanges([]string{"0:100000:65536"})
+	assert.Nil(t, err)
+	assert.Equal(t, []idRange{{clientStart: 0, filerStart: 100000, count: 65536}}, ranges)
+}
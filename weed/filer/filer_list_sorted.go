@@ -0,0 +1,161 @@
+package filer
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	SortByName = ""
+	// SortByMtime lists the most recently modified entries first
+	SortByMtime = "mtime"
+	// SortBySize lists the largest entries first
+	SortBySize = "size"
+)
+
+func ValidateSortBy(sortBy string) error {
+	switch sortBy {
+	case SortByName, SortByMtime, SortBySize:
+		return nil
+	}
+	return fmt.Errorf("unknown sort by %s, expecting %s or %s", sortBy, SortByMtime, SortBySize)
+}
+
+// ListDirectorySortedEntries lists up to limit entries, the newest or largest first.
+// Unlike the listing by name, it can not be paginated.
+func (f *Filer) ListDirectorySortedEntries(ctx context.Context, p util.FullPath, prefix string, sortBy string, limit int64, eachEntryFunc ListEachEntryFunc) error {
+	if err := ValidateSortBy(sortBy); err != nil {
+		return err
+	}
+	if strings.HasSuffix(string(p), "/") && len(p) > 1 {
+		p = p[0 : len(p)-1]
+	}
+
+	// the expired entries are deleted while listing, so listing again fills up to the limit
+	for {
+		var entries []*Entry
+		var expiredCount int64
+		err := f.Store.ListDirectorySortedEntries(ctx, p, prefix, sortBy, limit, func(entry *Entry) bool {
+			if entry.TtlSec > 0 && entry.Crtime.Add(time.Duration(entry.TtlSec)*time.Second).Before(time.Now()) {
+				f.Store.DeleteOneEntry(ctx, entry)
+				expiredCount++
+				return true
+			}
+			entries = append(entries, entry)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if expiredCount > 0 {
+			continue
+		}
+		for _, entry := range entries {
+			if !eachEntryFunc(entry) {
+				break
+			}
+		}
+		return nil
+	}
+}
+
+func (fsw *FilerStoreWrapper) ListDirectorySortedEntries(ctx context.Context, dirPath util.FullPath, prefix string, sortBy string, limit int64, eachEntryFunc ListEachEntryFunc) error {
+	actualStore := fsw.getActualStore(dirPath + "/")
+	stats.FilerStoreCounter.WithLabelValues(actualStore.GetName(), "sortedList").Inc()
+	start := time.Now()
+	defer func() {
+		stats.FilerStoreHistogram.WithLabelValues(actualStore.GetName(), "sortedList").Observe(time.Since(start).Seconds())
+	}()
+
+	if sortedListable, ok := actualStore.(SortedListable); ok {
+		err := sortedListable.ListDirectorySortedEntries(ctx, dirPath, prefix, sortBy, limit, func(entry *Entry) bool {
+			fsw.maybeReadHardLink(ctx, entry)
+			filer_pb.AfterEntryDeserialization(entry.Chunks)
+			return eachEntryFunc(entry)
+		})
+		if err != ErrUnsupportedSortedListing {
+			return err
+		}
+	}
+
+	// scan the directory, keeping only the top entries
+	top := &topEntries{less: entryRanksBefore(sortBy)}
+	lastFileName, includeLastFile := "", false
+	for {
+		var count int
+		var err error
+		lastFileName, err = fsw.ListDirectoryPrefixedEntries(ctx, dirPath, lastFileName, includeLastFile, PaginationSize, prefix, func(entry *Entry) bool {
+			count++
+			top.add(entry, limit)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if count < PaginationSize {
+			break
+		}
+	}
+
+	sort.Slice(top.entries, func(i, j int) bool {
+		return top.less(top.entries[i], top.entries[j])
+	})
+	for _, entry := range top.entries {
+		if !eachEntryFunc(entry) {
+			break
+		}
+	}
+	return nil
+}
+
+// entryRanksBefore orders the newest or largest first, then by name.
+func entryRanksBefore(sortBy string) func(a, b *Entry) bool {
+	return func(a, b *Entry) bool {
+		switch sortBy {
+		case SortByMtime:
+			if !a.Mtime.Equal(b.Mtime) {
+				return a.Mtime.After(b.Mtime)
+			}
+		case SortBySize:
+			if a.Size() != b.Size() {
+				return a.Size() > b.Size()
+			}
+		}
+		return a.Name() < b.Name()
+	}
+}
+
+// topEntries is a heap with the lowest ranked entry on top, to be replaced by a better one when full.
+type topEntries struct {
+	entries []*Entry
+	less    func(a, b *Entry) bool
+}
+
+func (t *topEntries) Len() int           { return len(t.entries) }
+func (t *topEntries) Less(i, j int) bool { return t.less(t.entries[j], t.entries[i]) }
+func (t *topEntries) Swap(i, j int)      { t.entries[i], t.entries[j] = t.entries[j], t.entries[i] }
+func (t *topEntries) Push(x interface{}) { t.entries = append(t.entries, x.(*Entry)) }
+func (t *topEntries) Pop() interface{} {
+	last := t.entries[len(t.entries)-1]
+	t.entries = t.entries[:len(t.entries)-1]
+	return last
+}
+
+func (t *topEntries) add(entry *Entry, limit int64) {
+	if int64(len(t.entries)) < limit {
+		heap.Push(t, entry)
+		return
+	}
+	if limit > 0 && t.less(entry, t.entries[0]) {
+		t.entries[0] = entry
+		heap.Fix(t, 0)
+	}
+}
@@ -0,0 +1,208 @@
+package filer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	MetaMissing    = "missing"    // the entry created by the last event is not in the store
+	MetaStale      = "stale"      // the entry in the store differs from the last event
+	MetaUnexpected = "unexpected" // the entry deleted by the last event is still in the store
+	MetaUnlogged   = "unlogged"   // the entry changed in the store has no event
+)
+
+type metaPathEvent struct {
+	directory string
+	tsNs      int64
+	// nil if the path is deleted
+	entry *filer_pb.Entry
+}
+
+// MetaVerifier checks the filer store reflects the last meta log event of each path,
+// which finds the store corruptions and the missed replications.
+type MetaVerifier struct {
+	pathPrefix string
+	lastEvents map[util.FullPath]*metaPathEvent
+	EventCount int64
+}
+
+func NewMetaVerifier(pathPrefix string) *MetaVerifier {
+	return &MetaVerifier{
+		pathPrefix: pathPrefix,
+		lastEvents: make(map[util.FullPath]*metaPathEvent),
+	}
+}
+
+func (v *MetaVerifier) PathCount() int {
+	return len(v.lastEvents)
+}
+
+// AddEvent keeps the event if it is the last one of its old or new path.
+// The events can be added out of order, e.g. from the logs of different filers.
+func (v *MetaVerifier) AddEvent(event *filer_pb.SubscribeMetadataResponse) {
+	message := event.EventNotification
+	if message == nil {
+		return
+	}
+	v.EventCount++
+	var newPath util.FullPath
+	if message.NewEntry != nil {
+		newParentPath := message.NewParentPath
+		if newParentPath == "" {
+			newParentPath = event.Directory
+		}
+		newPath = util.NewFullPath(newParentPath, message.NewEntry.Name)
+		v.setLastEvent(newPath, &metaPathEvent{directory: newParentPath, tsNs: event.TsNs, entry: message.NewEntry})
+	}
+	if message.OldEntry != nil {
+		if oldPath := util.NewFullPath(event.Directory, message.OldEntry.Name); oldPath != newPath {
+			v.setLastEvent(oldPath, &metaPathEvent{directory: event.Directory, tsNs: event.TsNs})
+		}
+	}
+}
+
+func (v *MetaVerifier) setLastEvent(p util.FullPath, pathEvent *metaPathEvent) {
+	if !strings.HasPrefix(string(p), v.pathPrefix) {
+		return
+	}
+	if last, found := v.lastEvents[p]; found && last.tsNs > pathEvent.tsNs {
+		return
+	}
+	v.lastEvents[p] = pathEvent
+}
+
+// Verify compares the store with the last event of each path.
+func (v *MetaVerifier) Verify(ctx context.Context, store FilerStore) (divergences []*filer_pb.MetaDivergence, err error) {
+	for p, pathEvent := range v.lastEvents {
+		entry, findErr := store.FindEntry(ctx, p)
+		if findErr != nil && findErr != filer_pb.ErrNotFound {
+			return divergences, findErr
+		}
+		var kind string
+		switch {
+		case pathEvent.entry == nil && entry != nil:
+			kind = MetaUnexpected
+		case pathEvent.entry != nil && entry == nil:
+			kind = MetaMissing
+		case pathEvent.entry != nil && !isSameMetaEntry(pathEvent.entry, entry):
+			kind = MetaStale
+		default:
+			continue
+		}
+		divergences = append(divergences, &filer_pb.MetaDivergence{
+			Path: string(p),
+			Kind: kind,
+			TsNs: pathEvent.tsNs,
+		})
+	}
+	return
+}
+
+// VerifySample checks up to sampleSize entries under the path prefix, that the ones changed in (sinceNs, untilNs) have an event.
+func (v *MetaVerifier) VerifySample(ctx context.Context, store FilerStore, sinceNs, untilNs int64, sampleSize int) (sampled int, divergences []*filer_pb.MetaDivergence, err error) {
+	// the mtime is in seconds, and the event is logged after the store is updated
+	sinceSec, untilSec := sinceNs/int64(time.Second)+1, untilNs/int64(time.Second)-1
+
+	dir, _ := util.FullPath(v.pathPrefix).DirAndName()
+	if strings.HasSuffix(v.pathPrefix, "/") {
+		dir = strings.TrimSuffix(v.pathPrefix, "/")
+	}
+	if dir == "" {
+		dir = "/"
+	}
+	dirs := []util.FullPath{util.FullPath(dir)}
+	for len(dirs) > 0 && sampled < sampleSize {
+		dirPath := dirs[0]
+		dirs = dirs[1:]
+		if strings.HasPrefix(string(dirPath), SystemLogDir) {
+			continue
+		}
+		_, err = store.ListDirectoryEntries(ctx, dirPath, "", false, int64(sampleSize-sampled), func(entry *Entry) bool {
+			if !strings.HasPrefix(string(entry.FullPath), v.pathPrefix) && !strings.HasPrefix(v.pathPrefix, string(entry.FullPath)) {
+				return true
+			}
+			if entry.IsDirectory() {
+				dirs = append(dirs, entry.FullPath)
+			}
+			if !strings.HasPrefix(string(entry.FullPath), v.pathPrefix) {
+				return true
+			}
+			sampled++
+			if mtime := entry.Attr.Mtime.Unix(); mtime <= sinceSec || mtime >= untilSec {
+				return true
+			}
+			if _, found := v.lastEvents[entry.FullPath]; !found {
+				divergences = append(divergences, &filer_pb.MetaDivergence{
+					Path: string(entry.FullPath),
+					Kind: MetaUnlogged,
+					TsNs: entry.Attr.Mtime.UnixNano(),
+				})
+			}
+			return true
+		})
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ExcludeChanged removes the divergences of the paths with an event in other, e.g. changed during the verification.
+func (v *MetaVerifier) ExcludeChanged(divergences []*filer_pb.MetaDivergence, other *MetaVerifier) (remaining []*filer_pb.MetaDivergence) {
+	for _, divergence := range divergences {
+		if _, found := other.lastEvents[util.FullPath(divergence.Path)]; !found {
+			remaining = append(remaining, divergence)
+		}
+	}
+	return
+}
+
+// Apply writes the last event of the diverged path to the store again, without logging another event.
+// The directories are not deleted, which needs their children deleted first.
+func (v *MetaVerifier) Apply(ctx context.Context, store FilerStore, divergence *filer_pb.MetaDivergence) error {
+	pathEvent, found := v.lastEvents[util.FullPath(divergence.Path)]
+	if !found {
+		return filer_pb.ErrNotFound
+	}
+	if pathEvent.entry != nil {
+		return store.InsertEntry(ctx, FromPbEntry(pathEvent.directory, pathEvent.entry))
+	}
+	entry, err := store.FindEntry(ctx, util.FullPath(divergence.Path))
+	if err != nil {
+		return err
+	}
+	if entry.IsDirectory() {
+		return ErrVerifyDirectoryNotApplied
+	}
+	return store.DeleteEntry(ctx, entry.FullPath)
+}
+
+var ErrVerifyDirectoryNotApplied = errors.New("skip deleting the directory, which may have children")
+
+// isSameMetaEntry compares the type, the mtime, the size and the chunks
+func isSameMetaEntry(expected *filer_pb.Entry, actual *Entry) bool {
+	if expected.IsDirectory != actual.IsDirectory() {
+		return false
+	}
+	if expected.Attributes != nil && expected.Attributes.Mtime != actual.Attr.Mtime.Unix() {
+		return false
+	}
+	if expected.IsDirectory {
+		return true
+	}
+	if FileSize(expected) != actual.Size() || len(expected.Chunks) != len(actual.Chunks) {
+		return false
+	}
+	for i, chunk := range expected.Chunks {
+		if chunk.GetFileIdString() != actual.Chunks[i].GetFileIdString() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package filer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// verifyTestStore keeps the entries in a map, only for the methods used by the MetaVerifier
+type verifyTestStore struct {
+	FilerStore
+	entries map[util.FullPath]*Entry
+}
+
+func (s *verifyTestStore) FindEntry(ctx context.Context, p util.FullPath) (*Entry, error) {
+	if entry, found := s.entries[p]; found {
+		return entry, nil
+	}
+	return nil, filer_pb.ErrNotFound
+}
+
+func (s *verifyTestStore) InsertEntry(ctx context.Context, entry *Entry) error {
+	s.entries[entry.FullPath] = entry
+	return nil
+}
+
+func (s *verifyTestStore) DeleteEntry(ctx context.Context, p util.FullPath) error {
+	delete(s.entries, p)
+	return nil
+}
+
+func TestMetaVerifier(t *testing.T) {
+	file := func(name string, mtime int64, fileIds ...string) *filer_pb.Entry {
+		entry := &filer_pb.Entry{Name: name, Attributes: &filer_pb.Attributes{Mtime: mtime}}
+		for _, fileId := range fileIds {
+			entry.Chunks = append(entry.Chunks, &filer_pb.FileChunk{FileId: fileId, Size: 10})
+		}
+		return entry
+	}
+	event := func(tsNs int64, dir string, oldEntry, newEntry *filer_pb.Entry, newParentPath string) *filer_pb.SubscribeMetadataResponse {
+		return &filer_pb.SubscribeMetadataResponse{
+			Directory: dir,
+			TsNs:      tsNs,
+			EventNotification: &filer_pb.EventNotification{
+				OldEntry:      oldEntry,
+				NewEntry:      newEntry,
+				NewParentPath: newParentPath,
+			},
+		}
+	}
+
+	store := &verifyTestStore{entries: make(map[util.FullPath]*Entry)}
+	store.InsertEntry(context.Background(), FromPbEntry("/d", file("ok", 1, "1,01")))
+	store.InsertEntry(context.Background(), FromPbEntry("/d", file("stale", 1, "1,02")))
+	store.InsertEntry(context.Background(), FromPbEntry("/d", file("deleted", 1, "1,03")))
+	store.InsertEntry(context.Background(), FromPbEntry("/e", file("renamed", 1, "1,04")))
+
+	v := NewMetaVerifier("/d")
+	// added out of order
+	v.AddEvent(event(3, "/d", file("ok", 1, "1,00"), file("ok", 1, "1,01"), "/d"))
+	v.AddEvent(event(2, "/d", nil, file("ok", 1, "1,00"), "/d"))
+	v.AddEvent(event(4, "/d", file("stale", 1, "1,02"), file("stale", 2, "1,05"), "/d"))
+	v.AddEvent(event(5, "/d", file("deleted", 1, "1,03"), nil, ""))
+	v.AddEvent(event(6, "/d", nil, file("missing", 1, "1,06"), "/d"))
+	v.AddEvent(event(7, "/d", file("renamed", 1, "1,04"), file("renamed", 1, "1,04"), "/e"))
+
+	divergences, err := v.Verify(context.Background(), store)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	kinds := make(map[string]string)
+	for _, divergence := range divergences {
+		kinds[divergence.Path] = divergence.Kind
+	}
+	expected := map[string]string{
+		"/d/stale":   MetaStale,
+		"/d/deleted": MetaUnexpected,
+		"/d/missing": MetaMissing,
+	}
+	if len(kinds) != len(expected) {
+		t.Errorf("divergences %v, expected %v", kinds, expected)
+	}
+	for p, kind := range expected {
+		if kinds[p] != kind {
+			t.Errorf("%s: %s, expected %s", p, kinds[p], kind)
+		}
+	}
+
+	changed := NewMetaVerifier("/d")
+	changed.AddEvent(event(8, "/d", nil, file("missing", 2, "1,07"), "/d"))
+	divergences = v.ExcludeChanged(divergences, changed)
+	if len(divergences) != 2 {
+		t.Errorf("divergences %+v after excluding the changed", divergences)
+	}
+
+	for _, divergence := range divergences {
+		if err = v.Apply(context.Background(), store, divergence); err != nil {
+			t.Errorf("apply %s: %v", divergence.Path, err)
+		}
+	}
+	if divergences, _ = v.Verify(context.Background(), store); len(divergences) != 1 || divergences[0].Path != "/d/missing" {
+		t.Errorf("divergences %+v after applying", divergences)
+	}
+}
@@ -53,6 +53,9 @@ func (f *Filer) NotifyUpdateEvent(ctx context.Context, oldEntry, newEntry *Entry
 		IsFromOtherCluster: isFromOtherCluster,
 		Signatures:         signatures,
 	}
+	setChunkChanges(eventNotification)
+	directory, _ := util.FullPath(fullpath).DirAndName()
+	f.updateDirChangeSeqs(directory, eventNotification)
 
 	if notification.Queue != nil {
 		glog.V(3).Infof("notifying entry update %v", fullpath)
@@ -66,15 +69,37 @@ func (f *Filer) NotifyUpdateEvent(ctx context.Context, oldEntry, newEntry *Entry
 
 }
 
+// setChunkChanges sets the chunks the update deleted and added, and the content hash,
+// for the files without chunk manifests, which need a lookup to compare.
+func setChunkChanges(eventNotification *filer_pb.EventNotification) {
+	oldEntry, newEntry := eventNotification.OldEntry, eventNotification.NewEntry
+	if oldEntry == nil || newEntry == nil || oldEntry.IsDirectory || newEntry.IsDirectory {
+		return
+	}
+	if HasChunkManifest(oldEntry.Chunks) || HasChunkManifest(newEntry.Chunks) {
+		return
+	}
+	eventNotification.DeletedChunks = DoMinusChunks(oldEntry.Chunks, newEntry.Chunks)
+	eventNotification.NewChunks = DoMinusChunks(newEntry.Chunks, oldEntry.Chunks)
+	eventNotification.ContentHash = ETag(newEntry)
+}
+
 func (f *Filer) logMetaEvent(ctx context.Context, fullpath string, eventNotification *filer_pb.EventNotification) {
 
 	dir, _ := util.FullPath(fullpath).DirAndName()
 
+	// the events are logged in the order of their time, so the peers acknowledging one event have applied all earlier ones
+	f.metaEventLock.Lock()
+	defer f.metaEventLock.Unlock()
+
 	event := &filer_pb.SubscribeMetadataResponse{
 		Directory:         dir,
 		EventNotification: eventNotification,
 		TsNs:              time.Now().UnixNano(),
 	}
+	if event.TsNs <= f.lastMetaEventTsNs {
+		event.TsNs = f.lastMetaEventTsNs + 1
+	}
 	data, err := proto.Marshal(event)
 	if err != nil {
 		glog.Errorf("failed to marshal filer_pb.SubscribeMetadataResponse %+v: %v", event, err)
@@ -82,6 +107,8 @@ func (f *Filer) logMetaEvent(ctx context.Context, fullpath string, eventNotifica
 	}
 
 	f.LocalMetaLogBuffer.AddToBuffer([]byte(dir), data, event.TsNs)
+	f.lastMetaEventTsNs = event.TsNs
+	recordMetaEventTsNs(ctx, event.TsNs)
 
 }
 
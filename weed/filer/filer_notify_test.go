@@ -51,3 +51,32 @@ func TestProtoMarshal(t *testing.T) {
 	println(string(text))
 
 }
+
+func TestSetChunkChanges(t *testing.T) {
+	unchanged := &filer_pb.FileChunk{FileId: "1,01", Size: 10}
+	replaced := &filer_pb.FileChunk{FileId: "1,02", Offset: 10, Size: 10}
+	appended := &filer_pb.FileChunk{FileId: "1,03", Offset: 10, Size: 20}
+
+	notification := &filer_pb.EventNotification{
+		OldEntry: &filer_pb.Entry{Name: "a", Chunks: []*filer_pb.FileChunk{unchanged, replaced}},
+		NewEntry: &filer_pb.Entry{Name: "b", Chunks: []*filer_pb.FileChunk{unchanged, appended},
+			Attributes: &filer_pb.Attributes{Md5: []byte{0x12, 0x34}}},
+	}
+	setChunkChanges(notification)
+
+	if len(notification.DeletedChunks) != 1 || notification.DeletedChunks[0].FileId != replaced.FileId {
+		t.Errorf("deleted chunks: %v", notification.DeletedChunks)
+	}
+	if len(notification.NewChunks) != 1 || notification.NewChunks[0].FileId != appended.FileId {
+		t.Errorf("new chunks: %v", notification.NewChunks)
+	}
+	if notification.ContentHash != "1234" {
+		t.Errorf("content hash: %s", notification.ContentHash)
+	}
+
+	created := &filer_pb.EventNotification{NewEntry: &filer_pb.Entry{Name: "c", Chunks: []*filer_pb.FileChunk{unchanged}}}
+	setChunkChanges(created)
+	if created.ContentHash != "" || len(created.NewChunks) != 0 {
+		t.Errorf("chunk changes of a creation: %v", created)
+	}
+}
@@ -0,0 +1,189 @@
+package filer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	ObjectLockModeGovernance = "GOVERNANCE"
+	ObjectLockModeCompliance = "COMPLIANCE"
+	ObjectLockLegalHoldOn    = "ON"
+)
+
+// ErrObjectLocked refuses to delete or overwrite an object under the S3 object lock
+var ErrObjectLocked = errors.New("object is locked")
+
+type governanceBypassKey struct{}
+
+// WithGovernanceBypass lets the deletes and updates with the context bypass the governance mode retention.
+func WithGovernanceBypass(ctx context.Context, bypass bool) context.Context {
+	if !bypass {
+		return ctx
+	}
+	return context.WithValue(ctx, governanceBypassKey{}, true)
+}
+
+func isGovernanceBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(governanceBypassKey{}).(bool)
+	return bypass
+}
+
+// ObjectRetention reads the retention kept in the extended attributes of the object, with an empty mode if not set
+func ObjectRetention(extended map[string][]byte) (mode string, retainUntil time.Time) {
+	mode = string(extended[s3_constants.AmzObjectLockMode])
+	if mode == "" {
+		return
+	}
+	retainUntil, _ = time.Parse(time.RFC3339, string(extended[s3_constants.AmzObjectLockRetainUntilDate]))
+	return
+}
+
+func IsObjectLegalHoldOn(extended map[string][]byte) bool {
+	return string(extended[s3_constants.AmzObjectLockLegalHold]) == ObjectLockLegalHoldOn
+}
+
+// IsObjectLocked checks the object can not be deleted or overwritten, because of the legal hold, or the retention
+// in compliance mode, or in governance mode unless bypassed.
+func IsObjectLocked(extended map[string][]byte, bypassGovernance bool, now time.Time) bool {
+	if IsObjectLegalHoldOn(extended) {
+		return true
+	}
+	mode, retainUntil := ObjectRetention(extended)
+	if mode == "" || !now.Before(retainUntil) {
+		return false
+	}
+	return mode == ObjectLockModeCompliance || !bypassGovernance
+}
+
+// HasObjectLock tells whether the object lock is enabled on the bucket entry
+func HasObjectLock(extended map[string][]byte) bool {
+	_, found := extended[s3_constants.X_SeaweedFS_Object_Lock_Config]
+	return found
+}
+
+// checkObjectLockUpdate allows to change the attributes of a locked object, but not its content,
+// and to extend its retention, but not to shorten or remove it unless bypassing the governance mode.
+// The object lock of a bucket can not be disabled.
+func checkObjectLockUpdate(oldEntry, entry *Entry, bypassGovernance bool, now time.Time) error {
+	if oldEntry == nil {
+		return nil
+	}
+	if oldEntry.IsDirectory() {
+		if HasObjectLock(oldEntry.Extended) && !HasObjectLock(entry.Extended) {
+			return fmt.Errorf("%w: can not disable the object lock of %s", ErrObjectLocked, oldEntry.FullPath)
+		}
+		return nil
+	}
+	if !IsObjectLocked(oldEntry.Extended, bypassGovernance, now) {
+		return nil
+	}
+	if !isSameContent(oldEntry, entry) {
+		return fmt.Errorf("%w: can not overwrite %s", ErrObjectLocked, oldEntry.FullPath)
+	}
+	mode, retainUntil := ObjectRetention(oldEntry.Extended)
+	if mode == "" || !now.Before(retainUntil) || mode == ObjectLockModeGovernance && bypassGovernance {
+		return nil
+	}
+	newMode, newRetainUntil := ObjectRetention(entry.Extended)
+	if newMode == "" || newRetainUntil.Before(retainUntil) || mode == ObjectLockModeCompliance && newMode != ObjectLockModeCompliance {
+		return fmt.Errorf("%w: can not shorten the retention of %s", ErrObjectLocked, oldEntry.FullPath)
+	}
+	return nil
+}
+
+func isSameContent(a, b *Entry) bool {
+	if !bytes.Equal(a.Content, b.Content) || len(a.Chunks) != len(b.Chunks) || a.FileSize != b.FileSize {
+		return false
+	}
+	fileIds := make(map[string]struct{}, len(a.Chunks))
+	for _, chunk := range a.Chunks {
+		fileIds[chunk.GetFileIdString()] = struct{}{}
+	}
+	for _, chunk := range b.Chunks {
+		if _, found := fileIds[chunk.GetFileIdString()]; !found {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckObjectLockForDelete refuses to delete a locked object, or a directory with locked objects.
+// Only the directories in the buckets with object lock, or containing the buckets, are searched.
+func (f *Filer) CheckObjectLockForDelete(ctx context.Context, entry *Entry, bypassGovernance bool) error {
+	now := time.Now()
+	if !entry.IsDirectory() {
+		if IsObjectLocked(entry.Extended, bypassGovernance, now) {
+			return fmt.Errorf("%w: %s", ErrObjectLocked, entry.FullPath)
+		}
+		return nil
+	}
+	inObjectLockBucket := f.isInObjectLockBucket(ctx, entry)
+	if !inObjectLockBucket && !util.FullPath(f.DirBucketsPath).IsUnder(entry.FullPath) {
+		return nil
+	}
+	lockedPath, err := f.findLockedObject(ctx, entry.FullPath, inObjectLockBucket, bypassGovernance, now)
+	if err != nil {
+		return fmt.Errorf("find locked objects in %s: %v", entry.FullPath, err)
+	}
+	if lockedPath != "" {
+		return fmt.Errorf("%w: %s", ErrObjectLocked, lockedPath)
+	}
+	return nil
+}
+
+// isInObjectLockBucket checks whether the directory is a bucket with object lock, or is in one
+func (f *Filer) isInObjectLockBucket(ctx context.Context, dir *Entry) bool {
+	if f.isBucket(dir) {
+		return HasObjectLock(dir.Extended)
+	}
+	bucketsPrefix := f.DirBucketsPath + "/"
+	if !strings.HasPrefix(string(dir.FullPath), bucketsPrefix) {
+		return false
+	}
+	bucket := strings.SplitN(strings.TrimPrefix(string(dir.FullPath), bucketsPrefix), "/", 2)[0]
+	bucketEntry, err := f.FindEntry(ctx, util.NewFullPath(f.DirBucketsPath, bucket))
+	if err != nil {
+		return false
+	}
+	return HasObjectLock(bucketEntry.Extended)
+}
+
+func (f *Filer) findLockedObject(ctx context.Context, dir util.FullPath, inObjectLockBucket, bypassGovernance bool, now time.Time) (lockedPath util.FullPath, err error) {
+	lastFileName := ""
+	for {
+		entries, hasMore, listErr := f.ListDirectoryEntries(ctx, dir, lastFileName, false, PaginationSize, "", "", "")
+		if listErr != nil {
+			return "", listErr
+		}
+		for _, sub := range entries {
+			lastFileName = sub.Name()
+			if !sub.IsDirectory() {
+				if inObjectLockBucket && IsObjectLocked(sub.Extended, bypassGovernance, now) {
+					return sub.FullPath, nil
+				}
+				continue
+			}
+			subInObjectLockBucket := inObjectLockBucket
+			if f.isBucket(sub) {
+				subInObjectLockBucket = HasObjectLock(sub.Extended)
+			}
+			if !subInObjectLockBucket && !util.FullPath(f.DirBucketsPath).IsUnder(sub.FullPath) {
+				continue
+			}
+			if lockedPath, err = f.findLockedObject(ctx, sub.FullPath, subInObjectLockBucket, bypassGovernance, now); err != nil || lockedPath != "" {
+				return
+			}
+		}
+		if !hasMore {
+			return "", nil
+		}
+	}
+}
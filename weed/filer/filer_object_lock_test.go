@@ -0,0 +1,90 @@
+package filer
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+)
+
+func objectLockExtended(mode string, retainUntil time.Time, legalHold bool) map[string][]byte {
+	extended := make(map[string][]byte)
+	if mode != "" {
+		extended[s3_constants.AmzObjectLockMode] = []byte(mode)
+		extended[s3_constants.AmzObjectLockRetainUntilDate] = []byte(retainUntil.UTC().Format(time.RFC3339))
+	}
+	if legalHold {
+		extended[s3_constants.AmzObjectLockLegalHold] = []byte(ObjectLockLegalHoldOn)
+	}
+	return extended
+}
+
+func TestIsObjectLocked(t *testing.T) {
+	now := time.Now()
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+
+	cases := []struct {
+		name     string
+		extended map[string][]byte
+		bypass   bool
+		expected bool
+	}{
+		{"no lock", objectLockExtended("", time.Time{}, false), false, false},
+		{"legal hold", objectLockExtended("", time.Time{}, true), true, true},
+		{"compliance", objectLockExtended(ObjectLockModeCompliance, later, false), true, true},
+		{"expired compliance", objectLockExtended(ObjectLockModeCompliance, earlier, false), false, false},
+		{"governance", objectLockExtended(ObjectLockModeGovernance, later, false), false, true},
+		{"bypassed governance", objectLockExtended(ObjectLockModeGovernance, later, false), true, false},
+		{"bypassed governance with legal hold", objectLockExtended(ObjectLockModeGovernance, later, true), true, true},
+	}
+	for _, c := range cases {
+		if actual := IsObjectLocked(c.extended, c.bypass, now); actual != c.expected {
+			t.Errorf("%s: expected %v, actual %v", c.name, c.expected, actual)
+		}
+	}
+}
+
+func TestCheckObjectLockUpdate(t *testing.T) {
+	now := time.Now()
+	later, muchLater := now.Add(time.Hour), now.Add(2*time.Hour)
+	chunks := []*filer_pb.FileChunk{{FileId: "3,01637037d6", Size: 10}}
+	otherChunks := []*filer_pb.FileChunk{{FileId: "4,02637037d6", Size: 10}}
+	file := func(extended map[string][]byte, chunks []*filer_pb.FileChunk) *Entry {
+		return &Entry{FullPath: "/buckets/b/a.txt", Attr: Attr{FileSize: 10}, Chunks: chunks, Extended: extended}
+	}
+	bucket := func(extended map[string][]byte) *Entry {
+		return &Entry{FullPath: "/buckets/b", Attr: Attr{Mode: os.ModeDir | 0755}, Extended: extended}
+	}
+	lockConfig := map[string][]byte{s3_constants.X_SeaweedFS_Object_Lock_Config: []byte("<ObjectLockConfiguration/>")}
+
+	cases := []struct {
+		name     string
+		oldEntry *Entry
+		entry    *Entry
+		bypass   bool
+		locked   bool
+	}{
+		{"new file", nil, file(nil, chunks), false, false},
+		{"overwrite unlocked", file(nil, chunks), file(nil, otherChunks), false, false},
+		{"overwrite compliance", file(objectLockExtended(ObjectLockModeCompliance, later, false), chunks), file(nil, otherChunks), true, true},
+		{"overwrite legal hold", file(objectLockExtended("", time.Time{}, true), chunks), file(nil, otherChunks), true, true},
+		{"overwrite bypassed governance", file(objectLockExtended(ObjectLockModeGovernance, later, false), chunks), file(nil, otherChunks), true, false},
+		{"remove legal hold", file(objectLockExtended("", time.Time{}, true), chunks), file(nil, chunks), false, false},
+		{"extend compliance", file(objectLockExtended(ObjectLockModeCompliance, later, false), chunks), file(objectLockExtended(ObjectLockModeCompliance, muchLater, false), chunks), false, false},
+		{"shorten compliance", file(objectLockExtended(ObjectLockModeCompliance, muchLater, false), chunks), file(objectLockExtended(ObjectLockModeCompliance, later, false), chunks), true, true},
+		{"compliance to governance", file(objectLockExtended(ObjectLockModeCompliance, later, false), chunks), file(objectLockExtended(ObjectLockModeGovernance, muchLater, false), chunks), true, true},
+		{"remove governance", file(objectLockExtended(ObjectLockModeGovernance, later, false), chunks), file(nil, chunks), false, true},
+		{"remove bypassed governance", file(objectLockExtended(ObjectLockModeGovernance, later, false), chunks), file(nil, chunks), true, false},
+		{"keep bucket lock", bucket(lockConfig), bucket(lockConfig), false, false},
+		{"disable bucket lock", bucket(lockConfig), bucket(nil), true, true},
+	}
+	for _, c := range cases {
+		err := checkObjectLockUpdate(c.oldEntry, c.entry, c.bypass, now)
+		if locked := errors.Is(err, ErrObjectLocked); locked != c.locked {
+			t.Errorf("%s: expected locked %v, actual %v", c.name, c.locked, err)
+		}
+	}
+}
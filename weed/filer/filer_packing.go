@@ -0,0 +1,110 @@
+package filer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// The small files packed into one container chunk are counted in the filer store kv space:
+//
+//	pack.ref:<container file id> => 4 bytes count of the packed files
+//
+// A container chunk is only deleted from the volume servers after the last file packed into it is gone.
+const (
+	packInUseKey  = "pack.in.use"
+	packRefPrefix = "pack.ref:"
+)
+
+type PackedContainers struct {
+	store VirtualFilerStore
+	inUse int32
+	sync.Mutex
+}
+
+func NewPackedContainers(store VirtualFilerStore) *PackedContainers {
+	c := &PackedContainers{
+		store: store,
+	}
+	if value, err := store.KvGet(context.Background(), []byte(packInUseKey)); err == nil && len(value) > 0 {
+		c.inUse = 1
+	}
+	return c
+}
+
+func packRefKey(fileId string) []byte {
+	return []byte(packRefPrefix + fileId)
+}
+
+// RecordContainer registers a newly uploaded container chunk with the count of the files packed into it.
+func (c *PackedContainers) RecordContainer(ctx context.Context, fileId string, fileCount int) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if atomic.LoadInt32(&c.inUse) == 0 {
+		if err := c.store.KvPut(ctx, []byte(packInUseKey), []byte{1}); err != nil {
+			return fmt.Errorf("mark packing in use: %v", err)
+		}
+		atomic.StoreInt32(&c.inUse, 1)
+	}
+
+	refValue := make([]byte, 4)
+	util.Uint32toBytes(refValue, uint32(fileCount))
+	if err := c.store.KvPut(ctx, packRefKey(fileId), refValue); err != nil {
+		return fmt.Errorf("set pack ref %s: %v", fileId, err)
+	}
+	return nil
+}
+
+// FilterReferencedFileIds releases one packed file of each container file id,
+// and returns the other file ids and the containers no longer referenced.
+func (c *PackedContainers) FilterReferencedFileIds(fileIds []string) (toDelete []string) {
+	if c == nil || atomic.LoadInt32(&c.inUse) == 0 {
+		return fileIds
+	}
+
+	ctx := context.Background()
+	for _, fileId := range fileIds {
+		isPacked, isLastRef, err := c.releaseRef(ctx, fileId)
+		if err != nil {
+			glog.Errorf("release pack ref %s: %v", fileId, err)
+			continue
+		}
+		if !isPacked || isLastRef {
+			toDelete = append(toDelete, fileId)
+		}
+	}
+	return
+}
+
+// releaseRef decreases the count of the files packed into the container, deleting the count with the last file.
+// The count is compared and swapped in the store, so the filers sharing the store release each file only once.
+func (c *PackedContainers) releaseRef(ctx context.Context, fileId string) (isPacked, isLastRef bool, err error) {
+	refKey := packRefKey(fileId)
+	for {
+		refValue, getErr := c.store.KvGet(ctx, refKey)
+		if getErr == ErrKvNotFound || getErr == nil && len(refValue) < 4 {
+			return false, false, nil
+		}
+		if getErr != nil {
+			return false, false, getErr
+		}
+		var newValue []byte
+		refCount := util.BytesToUint32(refValue[:4])
+		if refCount > 1 {
+			newValue = make([]byte, 4)
+			util.Uint32toBytes(newValue, refCount-1)
+		}
+		swapped, swapErr := c.store.KvCompareAndSwap(ctx, refKey, refValue, newValue)
+		if swapErr != nil {
+			return true, false, swapErr
+		}
+		if swapped {
+			return true, newValue == nil, nil
+		}
+	}
+}
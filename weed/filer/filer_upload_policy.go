@@ -0,0 +1,142 @@
+package filer
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+var (
+	ErrFileTooLarge       = errors.New("file too large")
+	ErrMimeTypeNotAllowed = errors.New("mime type not allowed")
+	ErrFileNameNotAllowed = errors.New("file name not allowed")
+)
+
+// UploadPolicy limits the files written under a path: the max size, the allowed or blocked mime types, and the file name pattern.
+type UploadPolicy struct {
+	maxFileSize      int64
+	allowedMimeTypes []string
+	blockedMimeTypes []string
+	fileNamePattern  *regexp.Regexp
+}
+
+// NewUploadPolicy creates the upload policy of the path configuration, or nil if there is nothing to limit.
+func NewUploadPolicy(pathConf *filer_pb.FilerConf_PathConf) (*UploadPolicy, error) {
+	if pathConf.MaxFileSize <= 0 && len(pathConf.AllowedMimeTypes) == 0 && len(pathConf.BlockedMimeTypes) == 0 && pathConf.FileNamePattern == "" {
+		return nil, nil
+	}
+	p := &UploadPolicy{
+		maxFileSize:      pathConf.MaxFileSize,
+		allowedMimeTypes: pathConf.AllowedMimeTypes,
+		blockedMimeTypes: pathConf.BlockedMimeTypes,
+	}
+	if pathConf.FileNamePattern != "" {
+		pattern, err := regexp.Compile(pathConf.FileNamePattern)
+		if err != nil {
+			return nil, fmt.Errorf("file name pattern %s: %v", pathConf.FileNamePattern, err)
+		}
+		p.fileNamePattern = pattern
+	}
+	return p, nil
+}
+
+// Check returns an error wrapping ErrFileTooLarge, ErrMimeTypeNotAllowed or ErrFileNameNotAllowed if the file is not allowed.
+// The size is not checked if negative, e.g. unknown before the upload.
+func (p *UploadPolicy) Check(fileName, mimeType string, fileSize int64) error {
+	if p == nil {
+		return nil
+	}
+	if err := p.CheckSize(fileSize); err != nil {
+		return err
+	}
+	if p.fileNamePattern != nil && !p.fileNamePattern.MatchString(fileName) {
+		return fmt.Errorf("%w: %s does not match %s", ErrFileNameNotAllowed, fileName, p.fileNamePattern)
+	}
+	mimeType = normalizeMimeType(mimeType)
+	if len(p.allowedMimeTypes) > 0 && !matchMimeTypes(p.allowedMimeTypes, mimeType) {
+		return fmt.Errorf("%w: %s", ErrMimeTypeNotAllowed, mimeType)
+	}
+	if matchMimeTypes(p.blockedMimeTypes, mimeType) {
+		return fmt.Errorf("%w: %s", ErrMimeTypeNotAllowed, mimeType)
+	}
+	return nil
+}
+
+// CheckSize only checks the max size, e.g. for a part of the file.
+func (p *UploadPolicy) CheckSize(fileSize int64) error {
+	if p == nil {
+		return nil
+	}
+	if p.maxFileSize > 0 && fileSize > p.maxFileSize {
+		return fmt.Errorf("%w: %d bytes is more than %d bytes", ErrFileTooLarge, fileSize, p.maxFileSize)
+	}
+	return nil
+}
+
+// normalizeMimeType drops the parameters and the case, and treats the unknown type as application/octet-stream
+func normalizeMimeType(mimeType string) string {
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		mimeType = mediaType
+	}
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	return util.Nvl(mimeType, "application/octet-stream")
+}
+
+// matchMimeTypes matches the exact types, or the whole top level type like "image/*"
+func matchMimeTypes(patterns []string, mimeType string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == mimeType || pattern == "*/*" {
+			return true
+		}
+		if strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// UploadPolicyOf finds the upload policy of the path.
+func (fc *FilerConf) UploadPolicyOf(path string) (*UploadPolicy, error) {
+	return NewUploadPolicy(fc.MatchStorageRule(path))
+}
+
+// CheckUploadPolicy checks a file against the upload policy of its path.
+// The parts of the S3 multipart uploads are only limited by the size, and the whole object is checked on completion.
+// The system files of the filer are not limited.
+func (f *Filer) CheckUploadPolicy(fullpath util.FullPath, mimeType string, fileSize int64) error {
+	if strings.HasPrefix(string(fullpath), DirectoryEtcRoot) || strings.HasPrefix(string(fullpath), SystemLogDir+"/") {
+		return nil
+	}
+	p, err := f.FilerConf.UploadPolicyOf(string(fullpath))
+	if err != nil {
+		glog.Errorf("upload policy of %s: %v", fullpath, err)
+		return nil
+	}
+	if p == nil {
+		return nil
+	}
+	if strings.HasPrefix(string(fullpath), f.DirBucketsPath+"/") && strings.Contains(string(fullpath), "/"+s3_constants.MultipartUploadsFolder+"/") {
+		return p.CheckSize(fileSize)
+	}
+	return p.Check(fullpath.Name(), mimeType, fileSize)
+}
+
+// checkUploadPolicy checks the created or changed file when saving the entry, whichever way it is written or renamed.
+// Only changing the attributes of an existing file is always allowed, e.g. of a file saved before the policy.
+func (f *Filer) checkUploadPolicy(oldEntry, entry *Entry) error {
+	if entry.IsDirectory() {
+		return nil
+	}
+	if oldEntry != nil && oldEntry.FullPath == entry.FullPath && oldEntry.Mime == entry.Mime && isSameContent(oldEntry, entry) {
+		return nil
+	}
+	return f.CheckUploadPolicy(entry.FullPath, entry.Mime, int64(entry.Size()))
+}
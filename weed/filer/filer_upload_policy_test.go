@@ -0,0 +1,88 @@
+package filer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadPolicy(t *testing.T) {
+
+	fc := NewFilerConf()
+	fc.doLoadConf(&filer_pb.FilerConf{Locations: []*filer_pb.FilerConf_PathConf{
+		{
+			LocationPrefix:   "/buckets/public/",
+			MaxFileSize:      10 << 30,
+			BlockedMimeTypes: []string{"application/x-msdownload"},
+			FileNamePattern:  `^[\w.-]+$`,
+		},
+		{
+			LocationPrefix:   "/buckets/public/images/",
+			MaxFileSize:      10 << 20,
+			AllowedMimeTypes: []string{"image/*"},
+		},
+	}})
+
+	p, err := fc.UploadPolicyOf("/buckets/public/a.txt")
+	assert.Nil(t, err)
+	assert.Nil(t, p.Check("a.txt", "text/plain; charset=utf-8", 1024))
+	assert.Nil(t, p.Check("a.txt", "", -1), "unknown size is not checked")
+	assert.True(t, errors.Is(p.Check("a.txt", "text/plain", 11<<30), ErrFileTooLarge))
+	assert.True(t, errors.Is(p.Check("a b.txt", "text/plain", 1024), ErrFileNameNotAllowed))
+	assert.True(t, errors.Is(p.Check("a.exe", "Application/X-MSDownload", 1024), ErrMimeTypeNotAllowed))
+
+	// the deeper location overrides the size, and inherits the others
+	p, err = fc.UploadPolicyOf("/buckets/public/images/b.png")
+	assert.Nil(t, err)
+	assert.Nil(t, p.Check("b.png", "image/png", 1024))
+	assert.True(t, errors.Is(p.Check("b.png", "image/png", 11<<20), ErrFileTooLarge))
+	assert.True(t, errors.Is(p.Check("b.txt", "text/plain", 1024), ErrMimeTypeNotAllowed))
+	assert.True(t, errors.Is(p.Check("b.bin", "", 1024), ErrMimeTypeNotAllowed), "unknown type is application/octet-stream")
+	assert.True(t, errors.Is(p.Check("b c.png", "image/png", 1024), ErrFileNameNotAllowed))
+
+	// only the size of the parts
+	assert.Nil(t, p.CheckSize(1024))
+	assert.True(t, errors.Is(p.CheckSize(11<<20), ErrFileTooLarge))
+
+	// nothing to limit
+	p, err = fc.UploadPolicyOf("/other/c.txt")
+	assert.Nil(t, err)
+	assert.Nil(t, p)
+	assert.Nil(t, p.Check("c d.exe", "application/x-msdownload", 100<<30))
+
+	_, err = NewUploadPolicy(&filer_pb.FilerConf_PathConf{FileNamePattern: "("})
+	assert.NotNil(t, err)
+}
+
+func TestFilerCheckUploadPolicy(t *testing.T) {
+
+	f := &Filer{FilerConf: NewFilerConf(), DirBucketsPath: "/buckets"}
+	f.FilerConf.doLoadConf(&filer_pb.FilerConf{Locations: []*filer_pb.FilerConf_PathConf{
+		{
+			LocationPrefix:   "/",
+			MaxFileSize:      1024,
+			AllowedMimeTypes: []string{"image/*"},
+		},
+	}})
+	file := func(path, mime string, size uint64, fileId string) *Entry {
+		return &Entry{FullPath: util.FullPath(path), Attr: Attr{Mime: mime, FileSize: size},
+			Chunks: []*filer_pb.FileChunk{{FileId: fileId, Size: size}}}
+	}
+
+	assert.Nil(t, f.checkUploadPolicy(nil, file("/dir/a.png", "image/png", 100, "3,01")))
+	assert.True(t, errors.Is(f.checkUploadPolicy(nil, file("/dir/a.txt", "text/plain", 100, "3,01")), ErrMimeTypeNotAllowed))
+
+	// an existing file saved before the policy
+	old := file("/dir/a.txt", "text/plain", 2048, "3,01")
+	assert.Nil(t, f.checkUploadPolicy(old, file("/dir/a.txt", "text/plain", 2048, "3,01")), "only the attributes are changed")
+	assert.True(t, errors.Is(f.checkUploadPolicy(old, file("/dir/a.txt", "text/plain", 2048, "3,02")), ErrFileTooLarge), "new content")
+	assert.True(t, errors.Is(f.checkUploadPolicy(old, file("/dir/a.txt", "image/png", 2048, "3,01")), ErrFileTooLarge), "new mime type")
+
+	// the parts of the multipart uploads, and the system files
+	assert.Nil(t, f.checkUploadPolicy(nil, file("/buckets/b/.uploads/123/0001.part", "", 100, "3,01")))
+	assert.True(t, errors.Is(f.checkUploadPolicy(nil, file("/buckets/b/.uploads/123/0001.part", "", 2048, "3,01")), ErrFileTooLarge))
+	assert.Nil(t, f.checkUploadPolicy(nil, file("/etc/seaweedfs/filer.conf", "", 2048, "3,01")))
+}
@@ -0,0 +1,65 @@
+package filer
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+var (
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrPreconditionNotFound is a failed If-Match without an existing file
+	ErrPreconditionNotFound = errors.New("precondition failed: file not found")
+)
+
+// WriteCondition is the If-Match and If-None-Match of a conditional write.
+// It is checked against the existing file when saving the entry, so only one of the concurrent conditional writes succeeds.
+type WriteCondition struct {
+	// the etags of the file to overwrite, or "*" for any existing file
+	IfMatch string
+	// "*" to only create a new file, or the etags the existing file must not have
+	IfNoneMatch string
+}
+
+func (c *WriteCondition) IsSet() bool {
+	return c != nil && (c.IfMatch != "" || c.IfNoneMatch != "")
+}
+
+// Check compares the condition with the existing entry, nil if not found. A directory counts as no file.
+func (c *WriteCondition) Check(existing *Entry) error {
+	if !c.IsSet() {
+		return nil
+	}
+	exists := existing != nil && !existing.IsDirectory()
+	var etag string
+	if exists {
+		etag = ETagEntry(existing)
+	}
+	if c.IfMatch != "" {
+		if !exists {
+			return ErrPreconditionNotFound
+		}
+		if !EtagMatches(c.IfMatch, etag) {
+			return ErrPreconditionFailed
+		}
+	}
+	if c.IfNoneMatch != "" && exists && EtagMatches(c.IfNoneMatch, etag) {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+// EtagMatches checks the etag against the comma separated etags of an If-Match or If-None-Match header.
+func EtagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if util.CanonicalizeETag(strings.TrimPrefix(candidate, "W/")) == util.CanonicalizeETag(etag) {
+			return true
+		}
+	}
+	return false
+}
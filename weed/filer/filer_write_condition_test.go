@@ -0,0 +1,55 @@
+package filer
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEtagMatches(t *testing.T) {
+	etag := "d41d8cd98f00b204e9800998ecf8427e"
+	cases := []struct {
+		header   string
+		expected bool
+	}{
+		{"*", true},
+		{`"d41d8cd98f00b204e9800998ecf8427e"`, true},
+		{"d41d8cd98f00b204e9800998ecf8427e", true},
+		{`W/"d41d8cd98f00b204e9800998ecf8427e"`, true},
+		{`"abc", "d41d8cd98f00b204e9800998ecf8427e"`, true},
+		{`"abc"`, false},
+	}
+	for _, c := range cases {
+		if actual := EtagMatches(c.header, etag); actual != c.expected {
+			t.Errorf("EtagMatches(%s): expected %v, actual %v", c.header, c.expected, actual)
+		}
+	}
+}
+
+func TestWriteConditionCheck(t *testing.T) {
+	existing := &Entry{FullPath: "/dir/a.txt", Attr: Attr{Md5: []byte{0xab, 0xcd}}}
+	directory := &Entry{FullPath: "/dir/a", Attr: Attr{Mode: os.ModeDir | 0755}}
+
+	tests := []struct {
+		name      string
+		condition *WriteCondition
+		existing  *Entry
+		expected  error
+	}{
+		{name: "no conditions", existing: existing},
+		{name: "create new file", condition: &WriteCondition{IfNoneMatch: "*"}},
+		{name: "create existing file", condition: &WriteCondition{IfNoneMatch: "*"}, existing: existing, expected: ErrPreconditionFailed},
+		{name: "create over directory", condition: &WriteCondition{IfNoneMatch: "*"}, existing: directory},
+		{name: "overwrite matching etag", condition: &WriteCondition{IfMatch: `"abcd"`}, existing: existing},
+		{name: "overwrite one of the etags", condition: &WriteCondition{IfMatch: `"1234", "abcd"`}, existing: existing},
+		{name: "overwrite any", condition: &WriteCondition{IfMatch: "*"}, existing: existing},
+		{name: "overwrite changed file", condition: &WriteCondition{IfMatch: `"1234"`}, existing: existing, expected: ErrPreconditionFailed},
+		{name: "overwrite missing file", condition: &WriteCondition{IfMatch: `"abcd"`}, expected: ErrPreconditionNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.condition.Check(tt.existing))
+		})
+	}
+}
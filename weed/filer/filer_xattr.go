@@ -0,0 +1,114 @@
+package filer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	// XattrPrefix is the prefix of the extended attributes in entry.Extended, the same as set by weed mount
+	XattrPrefix = "xattr-"
+
+	// the limits of linux for one attribute, and of the filer for all attributes of one entry
+	MaxXattrNameSize  = 255
+	MaxXattrValueSize = 64 * 1024
+	MaxXattrTotalSize = 256 * 1024
+)
+
+var (
+	ErrXattrNotFound = errors.New("extended attribute not found")
+	ErrXattrReserved = errors.New("extended attribute name is reserved")
+	ErrXattrTooLarge = errors.New("extended attribute too large")
+)
+
+// the name spaces only the kernel or root can set in linux
+var reservedXattrPrefixes = []string{"security.", "system.", "trusted."}
+
+// Xattrs returns the extended attributes of the entry, by the names without the prefix.
+func (entry *Entry) Xattrs() map[string][]byte {
+	xattrs := make(map[string][]byte)
+	for k, v := range entry.Extended {
+		if strings.HasPrefix(k, XattrPrefix) {
+			xattrs[k[len(XattrPrefix):]] = v
+		}
+	}
+	return xattrs
+}
+
+func (entry *Entry) GetXattr(name string) ([]byte, error) {
+	value, found := entry.Extended[XattrPrefix+name]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrXattrNotFound, name)
+	}
+	return value, nil
+}
+
+// SetXattr adds or replaces the extended attribute, checking the reserved names and the size limits.
+func (entry *Entry) SetXattr(name string, value []byte) error {
+	if err := checkXattrName(name); err != nil {
+		return err
+	}
+	if len(value) > MaxXattrValueSize {
+		return fmt.Errorf("%w: value of %s is %d bytes, more than %d", ErrXattrTooLarge, name, len(value), MaxXattrValueSize)
+	}
+	totalSize := len(name) + len(value)
+	for k, v := range entry.Xattrs() {
+		if k != name {
+			totalSize += len(k) + len(v)
+		}
+	}
+	if totalSize > MaxXattrTotalSize {
+		return fmt.Errorf("%w: all attributes are %d bytes, more than %d", ErrXattrTooLarge, totalSize, MaxXattrTotalSize)
+	}
+	if entry.Extended == nil {
+		entry.Extended = make(map[string][]byte)
+	}
+	entry.Extended[XattrPrefix+name] = value
+	return nil
+}
+
+func (entry *Entry) RemoveXattr(name string) error {
+	if err := checkXattrName(name); err != nil {
+		return err
+	}
+	if _, found := entry.Extended[XattrPrefix+name]; !found {
+		return fmt.Errorf("%w: %s", ErrXattrNotFound, name)
+	}
+	delete(entry.Extended, XattrPrefix+name)
+	return nil
+}
+
+// UpdateXattrs changes the extended attributes of the entry with fn and saves it, under the entry lock,
+// so the concurrent changes to the attributes of the same entry are not lost.
+func (f *Filer) UpdateXattrs(ctx context.Context, fullpath util.FullPath, fn func(entry *Entry) error) error {
+	unlock := f.entryLocks.Lock(string(fullpath))
+	defer unlock()
+
+	entry, err := f.FindEntry(ctx, fullpath)
+	if err != nil {
+		return fmt.Errorf("find %s: %w", fullpath, err)
+	}
+	if err = fn(entry); err != nil {
+		return err
+	}
+	return f.CreateEntry(ctx, entry, false, false, nil, false)
+}
+
+func checkXattrName(name string) error {
+	if name == "" {
+		return errors.New("empty extended attribute name")
+	}
+	if len(name) > MaxXattrNameSize {
+		return fmt.Errorf("%w: name is %d bytes, more than %d", ErrXattrTooLarge, len(name), MaxXattrNameSize)
+	}
+	for _, prefix := range reservedXattrPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return fmt.Errorf("%w: %s", ErrXattrReserved, name)
+		}
+	}
+	return nil
+}
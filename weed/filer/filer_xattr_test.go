@@ -0,0 +1,39 @@
+package filer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntryXattr(t *testing.T) {
+	entry := &Entry{FullPath: "/a/b.txt"}
+	entry.Extended = map[string][]byte{"Seaweed-Tag": []byte("t")}
+
+	assert.Nil(t, entry.SetXattr("user.color", []byte("blue")))
+	value, err := entry.GetXattr("user.color")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("blue"), value)
+	assert.Equal(t, []byte("blue"), entry.Extended[XattrPrefix+"user.color"], "the same key as weed mount")
+	assert.Equal(t, map[string][]byte{"user.color": []byte("blue")}, entry.Xattrs(), "other extended keys are not attributes")
+
+	assert.True(t, errors.Is(entry.SetXattr("security.selinux", []byte("x")), ErrXattrReserved))
+	assert.True(t, errors.Is(entry.SetXattr(string(bytes.Repeat([]byte("n"), MaxXattrNameSize+1)), nil), ErrXattrTooLarge))
+	assert.True(t, errors.Is(entry.SetXattr("user.big", make([]byte, MaxXattrValueSize+1)), ErrXattrTooLarge))
+	assert.NotNil(t, entry.SetXattr("", []byte("x")))
+
+	// the total size of all attributes, replacing one counts only the new value
+	for i := 0; i < MaxXattrTotalSize/MaxXattrValueSize-1; i++ {
+		assert.Nil(t, entry.SetXattr(string(rune('a'+i)), make([]byte, MaxXattrValueSize)))
+	}
+	assert.Nil(t, entry.SetXattr("a", make([]byte, MaxXattrValueSize)))
+	assert.True(t, errors.Is(entry.SetXattr("z", make([]byte, MaxXattrValueSize)), ErrXattrTooLarge))
+
+	assert.Nil(t, entry.RemoveXattr("user.color"))
+	_, err = entry.GetXattr("user.color")
+	assert.True(t, errors.Is(err, ErrXattrNotFound))
+	assert.True(t, errors.Is(entry.RemoveXattr("user.color"), ErrXattrNotFound))
+	assert.Equal(t, []byte("t"), entry.Extended["Seaweed-Tag"])
+}
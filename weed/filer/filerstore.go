@@ -12,12 +12,16 @@ const CountEntryChunksForGzip = 50
 var (
 	ErrUnsupportedListDirectoryPrefixed      = errors.New("unsupported directory prefix listing")
 	ErrUnsupportedSuperLargeDirectoryListing = errors.New("unsupported super large directory listing")
+	ErrUnsupportedSortedListing              = errors.New("unsupported sorted directory listing")
+	ErrUnsupportedKvListing                  = errors.New("unsupported kv listing")
 	ErrKvNotImplemented                      = errors.New("kv not implemented yet")
 	ErrKvNotFound                            = errors.New("kv: not found")
 )
 
 type ListEachEntryFunc func(entry *Entry) bool
 
+type KvEachFunc func(key []byte, value []byte) bool
+
 type FilerStore interface {
 	// GetName gets the name to locate the configuration in filer.toml file
 	GetName() string
@@ -49,6 +53,23 @@ type BucketAware interface {
 	CanDropWholeBucket() bool
 }
 
+// SortedListable is implemented by the stores able to list the newest or largest entries without a full scan.
+// ErrUnsupportedSortedListing falls back to scanning the directory.
+type SortedListable interface {
+	ListDirectorySortedEntries(ctx context.Context, dirPath util.FullPath, prefix string, sortBy string, limit int64, eachEntryFunc ListEachEntryFunc) error
+}
+
+// KvListable is implemented by the stores able to list the kv entries by key, after the startKey.
+type KvListable interface {
+	KvList(ctx context.Context, prefix []byte, startKey []byte, limit int64, eachFunc KvEachFunc) error
+}
+
+// KvCompareAndSwappable is implemented by the stores able to update a kv entry atomically, for the filers sharing the store.
+// A nil oldValue expects the key to be absent, and a nil newValue deletes the key.
+type KvCompareAndSwappable interface {
+	KvCompareAndSwap(ctx context.Context, key []byte, oldValue []byte, newValue []byte) (swapped bool, err error)
+}
+
 type Debuggable interface {
 	Debug(writer io.Writer)
 }
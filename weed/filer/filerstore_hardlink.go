@@ -14,13 +14,6 @@ func (fsw *FilerStoreWrapper) handleUpdateToHardLinks(ctx context.Context, entry
 		return nil
 	}
 
-	if len(entry.HardLinkId) > 0 {
-		// handle hard links
-		if err := fsw.setHardLink(ctx, entry); err != nil {
-			return fmt.Errorf("setHardLink %d: %v", entry.HardLinkId, err)
-		}
-	}
-
 	// check what is existing entry
 	// glog.V(4).Infof("handleUpdateToHardLinks FindEntry %s", entry.FullPath)
 	actualStore := fsw.getActualStore(entry.FullPath)
@@ -28,23 +21,51 @@ func (fsw *FilerStoreWrapper) handleUpdateToHardLinks(ctx context.Context, entry
 	if err != nil && err != filer_pb.ErrNotFound {
 		return fmt.Errorf("update existing entry %s: %v", entry.FullPath, err)
 	}
+	isSameHardLink := err == nil && len(existingEntry.HardLinkId) != 0 && bytes.Equal(existingEntry.HardLinkId, entry.HardLinkId)
+
+	if len(entry.HardLinkId) > 0 {
+		// handle hard links
+		if err := fsw.setHardLink(ctx, entry, !isSameHardLink); err != nil {
+			return fmt.Errorf("setHardLink %d: %v", entry.HardLinkId, err)
+		}
+	}
 
 	// remove old hard link
-	if err == nil && len(existingEntry.HardLinkId) != 0 && bytes.Compare(existingEntry.HardLinkId, entry.HardLinkId) != 0 {
+	if err == nil && len(existingEntry.HardLinkId) != 0 && !isSameHardLink {
 		glog.V(4).Infof("handleUpdateToHardLinks DeleteHardLink %s", entry.FullPath)
-		if err = fsw.DeleteHardLink(ctx, existingEntry.HardLinkId); err != nil {
+		if _, err = fsw.DeleteHardLink(ctx, existingEntry.HardLinkId); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (fsw *FilerStoreWrapper) setHardLink(ctx context.Context, entry *Entry) error {
+// setHardLink saves the shared meta data of a hard link.
+// The link counter is maintained here instead of trusting the client:
+// it is increased by one for a new link to the hard link id, and kept for an update of an existing link.
+func (fsw *FilerStoreWrapper) setHardLink(ctx context.Context, entry *Entry, isNewLink bool) error {
 	if len(entry.HardLinkId) == 0 {
 		return nil
 	}
 	key := entry.HardLinkId
 
+	value, err := fsw.KvGet(ctx, key)
+	switch {
+	case err == ErrKvNotFound:
+		entry.HardLinkCounter = 1
+	case err != nil:
+		return err
+	default:
+		existing := &Entry{}
+		if err = existing.DecodeAttributesAndChunks(value); err != nil {
+			return err
+		}
+		entry.HardLinkCounter = existing.HardLinkCounter
+		if isNewLink {
+			entry.HardLinkCounter++
+		}
+	}
+
 	newBlob, encodeErr := entry.EncodeAttributesAndChunks()
 	if encodeErr != nil {
 		return encodeErr
@@ -77,33 +98,35 @@ func (fsw *FilerStoreWrapper) maybeReadHardLink(ctx context.Context, entry *Entr
 	return nil
 }
 
-func (fsw *FilerStoreWrapper) DeleteHardLink(ctx context.Context, hardLinkId HardLinkId) error {
+// DeleteHardLink decreases the link counter, and removes the shared meta data with the last link.
+// isLastLink tells the caller the shared chunks are not referenced any more.
+func (fsw *FilerStoreWrapper) DeleteHardLink(ctx context.Context, hardLinkId HardLinkId) (isLastLink bool, err error) {
 	key := hardLinkId
 	value, err := fsw.KvGet(ctx, key)
 	if err == ErrKvNotFound {
-		return nil
+		return false, nil
 	}
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	entry := &Entry{}
 	if err = entry.DecodeAttributesAndChunks(value); err != nil {
-		return err
+		return false, err
 	}
 
 	entry.HardLinkCounter--
 	if entry.HardLinkCounter <= 0 {
 		glog.V(4).Infof("DeleteHardLink KvDelete %v", key)
-		return fsw.KvDelete(ctx, key)
+		return true, fsw.KvDelete(ctx, key)
 	}
 
 	newBlob, encodeErr := entry.EncodeAttributesAndChunks()
 	if encodeErr != nil {
-		return encodeErr
+		return false, encodeErr
 	}
 
 	glog.V(4).Infof("DeleteHardLink KvPut %v", key)
-	return fsw.KvPut(ctx, key, newBlob)
+	return false, fsw.KvPut(ctx, key, newBlob)
 
 }
@@ -0,0 +1,77 @@
+package filer
+
+import (
+	"bytes"
+	"context"
+)
+
+// KvNamespaces are the key prefixes of the kv entries kept by the filer, filer.sync, filer.remote.sync and iam.
+// The hard link entries are keyed by random ids, and are not listed.
+var KvNamespaces = []string{
+	"sync.",
+	"remote.sync.",
+	"iam.",
+	"filer.",
+	"dedup.",
+	MetaOffsetPrefix,
+	AdvisoryLockKeyPrefix,
+	"metaBackup",
+}
+
+func (fsw *FilerStoreWrapper) KvList(ctx context.Context, prefix []byte, startKey []byte, limit int64, eachFunc KvEachFunc) error {
+	kvListable, ok := fsw.getDefaultStore().(KvListable)
+	if !ok {
+		return ErrUnsupportedKvListing
+	}
+	return kvListable.KvList(ctx, prefix, startKey, limit, eachFunc)
+}
+
+// KvUsage counts the keys and the value bytes under the prefix.
+func KvUsage(ctx context.Context, store VirtualFilerStore, prefix []byte) (keyCount, valueBytes int64, err error) {
+	var startKey []byte
+	for {
+		var count int64
+		err = store.KvList(ctx, prefix, startKey, PaginationSize, func(key []byte, value []byte) bool {
+			count++
+			valueBytes += int64(len(value))
+			startKey = append(startKey[:0], key...)
+			return true
+		})
+		if err != nil {
+			return
+		}
+		keyCount += count
+		if count < PaginationSize {
+			return
+		}
+	}
+}
+
+// KvCompareAndSwap replaces the value of the key only if it is still the oldValue.
+// The stores without an atomic compare and swap, e.g. leveldb, are local to one filer, and are serialized here.
+func (fsw *FilerStoreWrapper) KvCompareAndSwap(ctx context.Context, key []byte, oldValue []byte, newValue []byte) (swapped bool, err error) {
+	store := fsw.getDefaultStore()
+	if swappable, ok := store.(KvCompareAndSwappable); ok {
+		return swappable.KvCompareAndSwap(ctx, key, oldValue, newValue)
+	}
+
+	fsw.kvSwapLock.Lock()
+	defer fsw.kvSwapLock.Unlock()
+
+	value, err := store.KvGet(ctx, key)
+	if err == ErrKvNotFound {
+		value, err = nil, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if (value == nil) != (oldValue == nil) || !bytes.Equal(value, oldValue) {
+		return false, nil
+	}
+	if newValue == nil {
+		err = store.KvDelete(ctx, key)
+	} else {
+		err = store.KvPut(ctx, key, newValue)
+	}
+	return err == nil, err
+}
@@ -131,6 +131,21 @@ func (t *FilerStorePathTranslator) ListDirectoryPrefixedEntries(ctx context.Cont
 	})
 }
 
+func (t *FilerStorePathTranslator) ListDirectorySortedEntries(ctx context.Context, dirPath util.FullPath, prefix string, sortBy string, limit int64, eachEntryFunc ListEachEntryFunc) error {
+
+	sortedListable, ok := t.actualStore.(SortedListable)
+	if !ok {
+		return ErrUnsupportedSortedListing
+	}
+
+	newFullPath := t.translatePath(dirPath)
+
+	return sortedListable.ListDirectorySortedEntries(ctx, newFullPath, prefix, sortBy, limit, func(entry *Entry) bool {
+		entry.FullPath = dirPath[:len(t.storeRoot)-1] + entry.FullPath
+		return eachEntryFunc(entry)
+	})
+}
+
 func (t *FilerStorePathTranslator) BeginTransaction(ctx context.Context) (context.Context, error) {
 	return t.actualStore.BeginTransaction(ctx)
 }
@@ -7,6 +7,7 @@ import (
 	"io"
 	"math"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
@@ -21,9 +22,13 @@ var (
 
 type VirtualFilerStore interface {
 	FilerStore
-	DeleteHardLink(ctx context.Context, hardLinkId HardLinkId) error
+	DeleteHardLink(ctx context.Context, hardLinkId HardLinkId) (isLastLink bool, err error)
 	DeleteOneEntry(ctx context.Context, entry *Entry) error
+	DeleteOneEntryAndHardLink(ctx context.Context, entry *Entry) (isLastHardLink bool, err error)
 	AddPathSpecificStore(path string, storeId string, store FilerStore)
+	ListDirectorySortedEntries(ctx context.Context, dirPath util.FullPath, prefix string, sortBy string, limit int64, eachEntryFunc ListEachEntryFunc) error
+	KvList(ctx context.Context, prefix []byte, startKey []byte, limit int64, eachFunc KvEachFunc) error
+	KvCompareAndSwap(ctx context.Context, key []byte, oldValue []byte, newValue []byte) (swapped bool, err error)
 	OnBucketCreation(bucket string)
 	OnBucketDeletion(bucket string)
 	CanDropWholeBucket() bool
@@ -33,6 +38,8 @@ type FilerStoreWrapper struct {
 	defaultStore   FilerStore
 	pathToStore    ptrie.Trie
 	storeIdToStore map[string]FilerStore
+	// serializes the compare and swap of the stores only used by this filer
+	kvSwapLock sync.Mutex
 }
 
 func NewFilerStoreWrapper(store FilerStore) *FilerStoreWrapper {
@@ -187,7 +194,7 @@ func (fsw *FilerStoreWrapper) DeleteEntry(ctx context.Context, fp util.FullPath)
 	if len(existingEntry.HardLinkId) != 0 {
 		// remove hard link
 		glog.V(4).Infof("DeleteHardLink %s", existingEntry.FullPath)
-		if err = fsw.DeleteHardLink(ctx, existingEntry.HardLinkId); err != nil {
+		if _, err = fsw.DeleteHardLink(ctx, existingEntry.HardLinkId); err != nil {
 			return err
 		}
 	}
@@ -197,6 +204,12 @@ func (fsw *FilerStoreWrapper) DeleteEntry(ctx context.Context, fp util.FullPath)
 }
 
 func (fsw *FilerStoreWrapper) DeleteOneEntry(ctx context.Context, existingEntry *Entry) (err error) {
+	_, err = fsw.DeleteOneEntryAndHardLink(ctx, existingEntry)
+	return
+}
+
+// DeleteOneEntryAndHardLink also reports whether the entry was the last link of its hard link.
+func (fsw *FilerStoreWrapper) DeleteOneEntryAndHardLink(ctx context.Context, existingEntry *Entry) (isLastHardLink bool, err error) {
 	actualStore := fsw.getActualStore(existingEntry.FullPath)
 	stats.FilerStoreCounter.WithLabelValues(actualStore.GetName(), "delete").Inc()
 	start := time.Now()
@@ -207,13 +220,13 @@ func (fsw *FilerStoreWrapper) DeleteOneEntry(ctx context.Context, existingEntry
 	if len(existingEntry.HardLinkId) != 0 {
 		// remove hard link
 		glog.V(4).Infof("DeleteHardLink %s", existingEntry.FullPath)
-		if err = fsw.DeleteHardLink(ctx, existingEntry.HardLinkId); err != nil {
-			return err
+		if isLastHardLink, err = fsw.DeleteHardLink(ctx, existingEntry.HardLinkId); err != nil {
+			return false, err
 		}
 	}
 
 	// glog.V(4).Infof("DeleteOneEntry %s", existingEntry.FullPath)
-	return actualStore.DeleteEntry(ctx, existingEntry.FullPath)
+	return isLastHardLink, actualStore.DeleteEntry(ctx, existingEntry.FullPath)
 }
 
 func (fsw *FilerStoreWrapper) DeleteFolderChildren(ctx context.Context, fp util.FullPath) (err error) {
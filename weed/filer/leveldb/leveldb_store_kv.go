@@ -1,10 +1,14 @@
 package leveldb
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	leveldb_util "github.com/syndtr/goleveldb/leveldb/util"
 )
 
 func (store *LevelDBStore) KvPut(ctx context.Context, key []byte, value []byte) (err error) {
@@ -43,3 +47,39 @@ func (store *LevelDBStore) KvDelete(ctx context.Context, key []byte) (err error)
 
 	return nil
 }
+
+// KvList lists the kv entries by key. The keys of the file entries start with "/", and are skipped.
+func (store *LevelDBStore) KvList(ctx context.Context, prefix []byte, startKey []byte, limit int64, eachFunc filer.KvEachFunc) (err error) {
+
+	iter := store.db.NewIterator(leveldb_util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+
+	for ok := seekAfter(iter, startKey); ok && limit > 0; ok = iter.Next() {
+		key := iter.Key()
+		if len(key) > 0 && key[0] == '/' {
+			continue
+		}
+		limit--
+		if !eachFunc(key, iter.Value()) {
+			break
+		}
+	}
+
+	if err = iter.Error(); err != nil {
+		return fmt.Errorf("kv list: %v", err)
+	}
+	return nil
+}
+
+func seekAfter(iter iterator.Iterator, startKey []byte) bool {
+	if len(startKey) == 0 {
+		return iter.First()
+	}
+	if !iter.Seek(startKey) {
+		return false
+	}
+	if bytes.Equal(iter.Key(), startKey) {
+		return iter.Next()
+	}
+	return true
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
@@ -86,6 +87,75 @@ func TestEmptyRoot(t *testing.T) {
 
 }
 
+func TestChunkDedup(t *testing.T) {
+	testFiler := filer.NewFiler(nil, nil, "", "", "", "", "", nil)
+	dir := t.TempDir()
+	store := &LevelDBStore{}
+	store.initialize(dir)
+	testFiler.SetStore(store)
+
+	ctx := context.Background()
+	contentHash := filer.ChunkContentHash([]byte("some content"))
+
+	if _, found := testFiler.Deduper.FindChunk(ctx, "c1", contentHash, 0); found {
+		t.Errorf("unexpected dedup chunk before recording")
+	}
+
+	chunk := &filer_pb.FileChunk{
+		FileId: "3,01637037d6",
+		Size:   12,
+	}
+	if err := testFiler.Deduper.RecordChunk(ctx, "c1", contentHash, chunk); err != nil {
+		t.Fatalf("record chunk: %v", err)
+	}
+
+	if _, found := testFiler.Deduper.FindChunk(ctx, "c2", contentHash, 0); found {
+		t.Errorf("dedup should not cross collections")
+	}
+	found, ok := testFiler.Deduper.FindChunk(ctx, "c1", contentHash, 1024)
+	if !ok || found.FileId != chunk.FileId || found.Offset != 1024 {
+		t.Fatalf("dedup chunk: %+v", found)
+	}
+
+	if toDelete := testFiler.Deduper.FilterReferencedFileIds([]string{chunk.FileId}); len(toDelete) != 0 {
+		t.Errorf("chunk still referenced, but to delete %v", toDelete)
+	}
+	if toDelete := testFiler.Deduper.FilterReferencedFileIds([]string{chunk.FileId, "4,0163703aaa"}); len(toDelete) != 2 {
+		t.Errorf("chunk no longer referenced, but to delete %v", toDelete)
+	}
+	if _, found := testFiler.Deduper.FindChunk(ctx, "c1", contentHash, 0); found {
+		t.Errorf("unexpected dedup chunk after deletion")
+	}
+}
+
+func TestPackedContainers(t *testing.T) {
+	testFiler := filer.NewFiler(nil, nil, "", "", "", "", "", nil)
+	dir := t.TempDir()
+	store := &LevelDBStore{}
+	store.initialize(dir)
+	testFiler.SetStore(store)
+
+	ctx := context.Background()
+	container := "3,01637037d6"
+
+	if err := testFiler.PackedContainers.RecordContainer(ctx, container, 2); err != nil {
+		t.Fatalf("record container: %v", err)
+	}
+
+	if toDelete := testFiler.PackedContainers.FilterReferencedFileIds([]string{container, "4,0163703aaa"}); len(toDelete) != 1 || toDelete[0] != "4,0163703aaa" {
+		t.Errorf("container still referenced, but to delete %v", toDelete)
+	}
+
+	// the packing in use is remembered across restarts
+	reloaded := filer.NewPackedContainers(testFiler.Store)
+	if toDelete := reloaded.FilterReferencedFileIds([]string{container}); len(toDelete) != 1 {
+		t.Errorf("container no longer referenced, but to delete %v", toDelete)
+	}
+	if toDelete := reloaded.FilterReferencedFileIds([]string{container}); len(toDelete) != 1 {
+		t.Errorf("unrecorded file id should be deleted, but to delete %v", toDelete)
+	}
+}
+
 func BenchmarkInsertEntry(b *testing.B) {
 	testFiler := filer.NewFiler(nil, nil, "", "", "", "", "", nil)
 	dir := b.TempDir()
@@ -109,3 +179,221 @@ func BenchmarkInsertEntry(b *testing.B) {
 		store.InsertEntry(ctx, entry)
 	}
 }
+
+func TestHardLinkCounter(t *testing.T) {
+	testFiler := filer.NewFiler(nil, nil, "", "", "", "", "", nil)
+	dir := t.TempDir()
+	store := &LevelDBStore{}
+	store.initialize(dir)
+	testFiler.SetStore(store)
+
+	ctx := context.Background()
+	hardLinkId := filer.NewHardLinkId()
+
+	newLink := func(p string, counter int32) *filer.Entry {
+		return &filer.Entry{
+			FullPath:        util.FullPath(p),
+			Attr:            filer.Attr{Mode: 0644},
+			HardLinkId:      hardLinkId,
+			HardLinkCounter: counter, // the filer maintains the counter, regardless of the client
+		}
+	}
+	linkCounter := func(p string) int32 {
+		entry, err := testFiler.FindEntry(ctx, util.FullPath(p))
+		if err != nil {
+			t.Fatalf("find %s: %v", p, err)
+		}
+		return entry.HardLinkCounter
+	}
+
+	for _, p := range []string{"/backup/1/a", "/backup/2/a", "/backup/3/a"} {
+		if err := testFiler.CreateEntry(ctx, newLink(p, 5), false, false, nil, false); err != nil {
+			t.Fatalf("create %s: %v", p, err)
+		}
+	}
+	if c := linkCounter("/backup/1/a"); c != 3 {
+		t.Errorf("expected 3 links, got %d", c)
+	}
+
+	// updating an existing link keeps the counter
+	if err := testFiler.CreateEntry(ctx, newLink("/backup/2/a", 1), false, false, nil, false); err != nil {
+		t.Fatalf("update link: %v", err)
+	}
+	if c := linkCounter("/backup/3/a"); c != 3 {
+		t.Errorf("expected 3 links after update, got %d", c)
+	}
+
+	if err := testFiler.DeleteEntryMetaAndData(ctx, "/backup/1/a", false, false, false, false, nil); err != nil {
+		t.Fatalf("delete link: %v", err)
+	}
+	if c := linkCounter("/backup/2/a"); c != 2 {
+		t.Errorf("expected 2 links after delete, got %d", c)
+	}
+
+	// replacing a link with a plain file removes the link
+	if err := testFiler.CreateEntry(ctx, &filer.Entry{FullPath: "/backup/2/a", Attr: filer.Attr{Mode: 0644}}, false, false, nil, false); err != nil {
+		t.Fatalf("replace link: %v", err)
+	}
+	if c := linkCounter("/backup/3/a"); c != 1 {
+		t.Errorf("expected 1 link after replace, got %d", c)
+	}
+
+	isLastLink, err := testFiler.Store.DeleteOneEntryAndHardLink(ctx, &filer.Entry{FullPath: "/backup/3/a", HardLinkId: hardLinkId})
+	if err != nil || !isLastLink {
+		t.Errorf("delete the last link: %v %v", isLastLink, err)
+	}
+	if _, err = testFiler.Store.KvGet(ctx, hardLinkId); err != filer.ErrKvNotFound {
+		t.Errorf("hard link meta data should be removed: %v", err)
+	}
+}
+
+func TestAdvisoryLocks(t *testing.T) {
+	testFiler := filer.NewFiler(nil, nil, "", "", "", "", "", nil)
+	dir := t.TempDir()
+	store := &LevelDBStore{}
+	store.initialize(dir)
+	testFiler.SetStore(store)
+
+	ctx := context.Background()
+	locks := testFiler.AdvisoryLocks
+	p := util.FullPath("/mail/inbox.sqlite")
+
+	acquire := func(owner string, exclusive bool, start, end int64) *filer_pb.AdvisoryLock {
+		conflict, err := locks.Acquire(ctx, p, &filer_pb.AdvisoryLock{Owner: owner, IsExclusive: exclusive, Start: start, End: end}, time.Minute, false)
+		if err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		return conflict
+	}
+
+	if acquire("mount1:1", false, 0, 0) != nil || acquire("mount2:1", false, 0, 100) != nil {
+		t.Errorf("shared locks should not conflict")
+	}
+	if conflict := acquire("mount2:1", true, 0, 10); conflict == nil || conflict.Owner != "mount1:1" {
+		t.Errorf("exclusive lock should conflict with the shared lock of mount1: %v", conflict)
+	}
+
+	// mount1 unlocks the middle of its lock
+	if err := locks.Release(ctx, p, "mount1:1", 0, 50); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if conflict := acquire("mount2:1", true, 0, 50); conflict != nil {
+		t.Errorf("the range is released: %v", conflict)
+	}
+	if conflict := acquire("mount3:1", false, 60, 70); conflict != nil {
+		t.Errorf("shared lock beyond the exclusive range: %v", conflict)
+	}
+
+	// releasing all locks of a disconnected client
+	if _, err := locks.Renew(ctx, "mount2:", time.Minute, true); err != nil {
+		t.Fatalf("release all: %v", err)
+	}
+	if conflict := acquire("mount3:1", true, 0, 10); conflict != nil {
+		t.Errorf("locks of mount2 should be released: %v", conflict)
+	}
+
+	// expired locks are ignored
+	if _, err := locks.Acquire(ctx, "/expired", &filer_pb.AdvisoryLock{Owner: "mount4:1", IsExclusive: true}, -time.Second, false); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	conflict, _ := locks.Acquire(ctx, "/expired", &filer_pb.AdvisoryLock{Owner: "mount5:1", IsExclusive: true}, time.Minute, false)
+	if conflict != nil {
+		t.Errorf("expired lock should not conflict: %v", conflict)
+	}
+}
+
+func TestDeleteEntryRecursively(t *testing.T) {
+	testFiler := filer.NewFiler(nil, nil, "", "", "", "", "", nil)
+	dir := t.TempDir()
+	store := &LevelDBStore{}
+	store.initialize(dir)
+	testFiler.SetStore(store)
+
+	ctx := context.Background()
+
+	for _, p := range []string{"/home/a/f1", "/home/a/f2", "/home/a/f3", "/home/a/b/f4", "/home/a/b/c/f5", "/home/keep"} {
+		entry := &filer.Entry{
+			FullPath: util.FullPath(p),
+			Attr:     filer.Attr{Mode: 0644},
+		}
+		if err := testFiler.CreateEntry(ctx, entry, false, false, nil, false); err != nil {
+			t.Fatalf("create entry %v: %v", p, err)
+		}
+	}
+
+	var reports int
+	var last filer.RecursiveDeleteProgress
+	err := testFiler.DeleteEntryRecursively(ctx, "/home/a", false, true, false, nil, 2, func(progress *filer.RecursiveDeleteProgress) error {
+		reports++
+		last = *progress
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("delete recursively: %v", err)
+	}
+
+	// f1 f2 f3 f4 f5 b c and a itself
+	if last.DeletedEntryCount != 8 {
+		t.Errorf("deleted entry count %d, expected 8", last.DeletedEntryCount)
+	}
+	if reports < 4 {
+		t.Errorf("expected progress of each page, got %d reports", reports)
+	}
+	for _, p := range []string{"/home/a", "/home/a/f1", "/home/a/b/c/f5"} {
+		if _, err := testFiler.FindEntry(ctx, util.FullPath(p)); err != filer_pb.ErrNotFound {
+			t.Errorf("%s should be deleted: %v", p, err)
+		}
+	}
+	if _, err := testFiler.FindEntry(ctx, "/home/keep"); err != nil {
+		t.Errorf("/home/keep should be kept: %v", err)
+	}
+}
+
+func TestListDirectorySortedEntries(t *testing.T) {
+	testFiler := filer.NewFiler(nil, nil, "", "", "", "", "", nil)
+	dir := t.TempDir()
+	store := &LevelDBStore{}
+	store.initialize(dir)
+	testFiler.SetStore(store)
+
+	ctx := context.Background()
+	now := time.Now()
+	for i, size := range []uint64{30, 10, 50, 20, 40} {
+		entry := &filer.Entry{
+			FullPath: util.NewFullPath("/recent", fmt.Sprintf("file%d", i)),
+			Attr: filer.Attr{
+				Mode:     0644,
+				Mtime:    now.Add(time.Duration(i) * time.Minute),
+				Crtime:   now,
+				FileSize: size,
+			},
+		}
+		if err := testFiler.CreateEntry(ctx, entry, false, false, nil, false); err != nil {
+			t.Fatalf("create entry %v: %v", entry.FullPath, err)
+		}
+	}
+
+	listNames := func(sortBy string, limit int64) (names []string) {
+		err := testFiler.ListDirectorySortedEntries(ctx, "/recent", "", sortBy, limit, func(entry *filer.Entry) bool {
+			names = append(names, entry.Name())
+			return true
+		})
+		if err != nil {
+			t.Fatalf("list sorted by %s: %v", sortBy, err)
+		}
+		return
+	}
+
+	if names := fmt.Sprint(listNames(filer.SortByMtime, 3)); names != "[file4 file3 file2]" {
+		t.Errorf("sorted by mtime: %v", names)
+	}
+	if names := fmt.Sprint(listNames(filer.SortBySize, 2)); names != "[file2 file4]" {
+		t.Errorf("sorted by size: %v", names)
+	}
+	if names := fmt.Sprint(listNames(filer.SortBySize, 10)); names != "[file2 file4 file0 file3 file1]" {
+		t.Errorf("sorted by size: %v", names)
+	}
+	if err := testFiler.ListDirectorySortedEntries(ctx, "/recent", "", "name", 10, nil); err == nil {
+		t.Errorf("expecting error for unknown sort")
+	}
+}
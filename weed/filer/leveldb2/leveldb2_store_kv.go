@@ -1,11 +1,15 @@
 package leveldb
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	leveldb_util "github.com/syndtr/goleveldb/leveldb/util"
 )
 
 func (store *LevelDB2Store) KvPut(ctx context.Context, key []byte, value []byte) (err error) {
@@ -54,3 +58,57 @@ func (store *LevelDB2Store) KvDelete(ctx context.Context, key []byte) (err error
 func bucketKvKey(key []byte, dbCount int) (partitionId int) {
 	return int(key[len(key)-1]) % dbCount
 }
+
+// KvList lists the kv entries by key, merging the partitions.
+// The keys of the file entries start with the hash of the directory, and may be listed with an empty prefix.
+func (store *LevelDB2Store) KvList(ctx context.Context, prefix []byte, startKey []byte, limit int64, eachFunc filer.KvEachFunc) (err error) {
+
+	var keys, values [][]byte
+	for partitionId, db := range store.dbs {
+		iter := db.NewIterator(leveldb_util.BytesPrefix(prefix), nil)
+		count := int64(0)
+		for ok := seekAfter(iter, startKey); ok && count < limit; ok = iter.Next() {
+			keys = append(keys, append([]byte(nil), iter.Key()...))
+			values = append(values, append([]byte(nil), iter.Value()...))
+			count++
+		}
+		err = iter.Error()
+		iter.Release()
+		if err != nil {
+			return fmt.Errorf("kv bucket %d list: %v", partitionId, err)
+		}
+	}
+
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return bytes.Compare(keys[order[i]], keys[order[j]]) < 0
+	})
+
+	for _, i := range order {
+		if limit <= 0 {
+			break
+		}
+		limit--
+		if !eachFunc(keys[i], values[i]) {
+			break
+		}
+	}
+
+	return nil
+}
+
+func seekAfter(iter iterator.Iterator, startKey []byte) bool {
+	if len(startKey) == 0 {
+		return iter.First()
+	}
+	if !iter.Seek(startKey) {
+		return false
+	}
+	if bytes.Equal(iter.Key(), startKey) {
+		return iter.Next()
+	}
+	return true
+}
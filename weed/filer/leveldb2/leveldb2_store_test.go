@@ -2,6 +2,7 @@ package leveldb
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
@@ -82,3 +83,45 @@ func TestEmptyRoot(t *testing.T) {
 	}
 
 }
+
+func TestKvList(t *testing.T) {
+	testFiler := filer.NewFiler(nil, nil, "", "", "", "", "", nil)
+	dir := t.TempDir()
+	store := &LevelDB2Store{}
+	store.initialize(dir, 4)
+	testFiler.SetStore(store)
+
+	ctx := context.Background()
+
+	for _, key := range []string{"sync.a", "sync.b", "sync.c", "sync.d", "sync.e", "iam.x", "syncx"} {
+		if err := testFiler.Store.KvPut(ctx, []byte(key), []byte("value:"+key)); err != nil {
+			t.Fatalf("kv put %s: %v", key, err)
+		}
+	}
+
+	listPage := func(startKey string, limit int64) (keys []string) {
+		err := testFiler.Store.KvList(ctx, []byte("sync."), []byte(startKey), limit, func(key []byte, value []byte) bool {
+			if string(value) != "value:"+string(key) {
+				t.Errorf("kv list %s: unexpected value %s", key, value)
+			}
+			keys = append(keys, string(key))
+			return true
+		})
+		if err != nil {
+			t.Fatalf("kv list: %v", err)
+		}
+		return
+	}
+
+	if keys := listPage("", 3); strings.Join(keys, ",") != "sync.a,sync.b,sync.c" {
+		t.Errorf("kv list first page: %v", keys)
+	}
+	if keys := listPage("sync.c", 3); strings.Join(keys, ",") != "sync.d,sync.e" {
+		t.Errorf("kv list second page: %v", keys)
+	}
+
+	keyCount, valueBytes, err := filer.KvUsage(ctx, testFiler.Store, []byte("sync."))
+	if err != nil || keyCount != 5 || valueBytes != 5*int64(len("value:sync.a")) {
+		t.Errorf("kv usage: %d keys %d bytes, %v", keyCount, valueBytes, err)
+	}
+}
@@ -32,6 +32,10 @@ type MetaAggregator struct {
 	// notifying clients
 	ListenersLock sync.Mutex
 	ListenersCond *sync.Cond
+	// the local meta events applied by the peers
+	peerAcks     map[rpc.ServerAddress]int64
+	peerAcksLock sync.Mutex
+	peerAcksCond *sync.Cond
 }
 
 // MetaAggregator only aggregates data "on the fly". The logs are not re-persisted to disk.
@@ -42,8 +46,10 @@ func NewMetaAggregator(filer *Filer, self rpc.ServerAddress, grpcDialOption grpc
 		self:           self,
 		grpcDialOption: grpcDialOption,
 		peerStatues:    make(map[rpc.ServerAddress]int),
+		peerAcks:       make(map[rpc.ServerAddress]int64),
 	}
 	t.ListenersCond = sync.NewCond(&t.ListenersLock)
+	t.peerAcksCond = sync.NewCond(&t.peerAcksLock)
 	t.MetaLogBuffer = log_buffer.NewLogBuffer("aggr", LogFlushInterval, nil, func() {
 		t.ListenersCond.Broadcast()
 	})
@@ -191,6 +197,14 @@ func (ma *MetaAggregator) doSubscribeToOneFiler(f *Filer, self rpc.ServerAddress
 		return nil
 	}
 
+	// acknowledge the applied events to the other filers, for their writes waiting for the peers
+	var ackChan chan int64
+	if peer != self {
+		ackChan = make(chan int64, 1)
+		defer close(ackChan)
+		go ma.loopAckToPeer(peer, ackChan)
+	}
+
 	glog.V(0).Infof("subscribing remote %s meta change: %v, clientId:%d", peer, time.Unix(0, lastTsNs), ma.filer.UniqueFilerId)
 	err = rpc.WithFilerClient(true, peer, ma.grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -222,6 +236,15 @@ func (ma *MetaAggregator) doSubscribeToOneFiler(f *Filer, self rpc.ServerAddress
 
 			f.onMetadataChangeEvent(resp)
 			lastTsNs = resp.TsNs
+
+			// only the latest one is worth sending
+			if ackChan != nil {
+				select {
+				case <-ackChan:
+				default:
+				}
+				ackChan <- resp.TsNs
+			}
 		}
 	})
 	return lastTsNs, err
@@ -0,0 +1,100 @@
+package filer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+)
+
+// MetaAckTimeout limits how long a write waits for the other filers to apply its meta event.
+const MetaAckTimeout = 10 * time.Second
+
+var ErrFilerAckTimeout = errors.New("not applied by enough filers")
+
+type metaEventTsNsKey struct{}
+
+// WithMetaEventTsNs lets the changes with the context record the time of their latest meta event, read by MetaEventTsNs.
+func WithMetaEventTsNs(ctx context.Context) context.Context {
+	return context.WithValue(ctx, metaEventTsNsKey{}, new(int64))
+}
+
+// MetaEventTsNs is the time of the latest meta event of the changes with the context, or 0 if none.
+func MetaEventTsNs(ctx context.Context) int64 {
+	if tsNs, ok := ctx.Value(metaEventTsNsKey{}).(*int64); ok {
+		return atomic.LoadInt64(tsNs)
+	}
+	return 0
+}
+
+func recordMetaEventTsNs(ctx context.Context, eventTsNs int64) {
+	if tsNs, ok := ctx.Value(metaEventTsNsKey{}).(*int64); ok {
+		atomic.StoreInt64(tsNs, eventTsNs)
+	}
+}
+
+// OnPeerAck records that the peer filer has applied the local meta events until tsNs.
+func (ma *MetaAggregator) OnPeerAck(peer rpc.ServerAddress, tsNs int64) {
+	ma.peerAcksLock.Lock()
+	defer ma.peerAcksLock.Unlock()
+	if tsNs > ma.peerAcks[peer] {
+		ma.peerAcks[peer] = tsNs
+		ma.peerAcksCond.Broadcast()
+	}
+}
+
+// WaitForPeerAcks waits until at least count other active filers have applied the local meta events until tsNs.
+func (ma *MetaAggregator) WaitForPeerAcks(tsNs int64, count int, timeout time.Duration) (acked int, err error) {
+	timer := time.AfterFunc(timeout, func() {
+		ma.peerAcksLock.Lock()
+		ma.peerAcksCond.Broadcast()
+		ma.peerAcksLock.Unlock()
+	})
+	defer timer.Stop()
+
+	deadline := time.Now().Add(timeout)
+	ma.peerAcksLock.Lock()
+	defer ma.peerAcksLock.Unlock()
+	for {
+		acked = 0
+		for peer, ackedTsNs := range ma.peerAcks {
+			if ackedTsNs >= tsNs && peer != ma.self && ma.isActive(peer) {
+				acked++
+			}
+		}
+		if acked >= count {
+			return acked, nil
+		}
+		if !time.Now().Before(deadline) {
+			return acked, fmt.Errorf("%w: %d of %d filers in %v", ErrFilerAckTimeout, acked, count, timeout)
+		}
+		ma.peerAcksCond.Wait()
+	}
+}
+
+// loopAckToPeer sends the time of the latest applied event to the peer it is subscribed to, until ackChan is closed.
+// The acks are signed with the filer jwt key, if configured.
+func (ma *MetaAggregator) loopAckToPeer(peer rpc.ServerAddress, ackChan chan int64) {
+	var signingKey security.SigningKey
+	if ma.filer != nil {
+		signingKey = ma.filer.SigningKey
+	}
+	for tsNs := range ackChan {
+		err := rpc.WithFilerClient(false, peer, ma.grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+			_, err := client.AckLocalMetadata(security.WithFilerJwt(context.Background(), signingKey), &filer_pb.AckLocalMetadataRequest{
+				Filer: string(ma.self),
+				TsNs:  tsNs,
+			})
+			return err
+		})
+		if err != nil {
+			glog.V(1).Infof("ack meta events until %d to %s: %v", tsNs, peer, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package filer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util/log_buffer"
+)
+
+func TestWaitForPeerAcks(t *testing.T) {
+	ma := NewMetaAggregator(nil, "filer0:8888", nil)
+	ma.setActive("filer1:8888", true)
+	ma.setActive("filer2:8888", true)
+
+	// not active peers are not counted
+	ma.OnPeerAck("filer3:8888", 100)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		ma.OnPeerAck("filer1:8888", 99)
+		ma.OnPeerAck("filer1:8888", 101)
+		ma.OnPeerAck("filer1:8888", 50)
+	}()
+	acked, err := ma.WaitForPeerAcks(100, 1, time.Second)
+	if err != nil || acked != 1 {
+		t.Fatalf("wait for 1 ack: %d %v", acked, err)
+	}
+
+	acked, err = ma.WaitForPeerAcks(100, 2, 50*time.Millisecond)
+	if !errors.Is(err, ErrFilerAckTimeout) || acked != 1 {
+		t.Fatalf("expected timeout with 1 ack: %d %v", acked, err)
+	}
+
+	ma.OnPeerAck("filer2:8888", 200)
+	if acked, err = ma.WaitForPeerAcks(100, 2, time.Second); err != nil || acked != 2 {
+		t.Fatalf("wait for 2 acks: %d %v", acked, err)
+	}
+}
+
+func TestMetaEventTsNs(t *testing.T) {
+	f := &Filer{LocalMetaLogBuffer: log_buffer.NewLogBuffer("local", time.Minute, nil, func() {})}
+	defer f.LocalMetaLogBuffer.Shutdown()
+
+	if tsNs := MetaEventTsNs(context.Background()); tsNs != 0 {
+		t.Fatalf("expected no event time without WithMetaEventTsNs, actual %d", tsNs)
+	}
+
+	// another change logged later must not be waited for
+	ctx := WithMetaEventTsNs(context.Background())
+	f.logMetaEvent(ctx, "/dir/a.txt", &filer_pb.EventNotification{})
+	f.logMetaEvent(context.Background(), "/dir/b.txt", &filer_pb.EventNotification{})
+	tsNs := MetaEventTsNs(ctx)
+	if tsNs == 0 || tsNs >= f.lastMetaEventTsNs {
+		t.Fatalf("expected the time of the first event, actual %d, latest %d", tsNs, f.lastMetaEventTsNs)
+	}
+}
@@ -2,6 +2,9 @@ package filer
 
 import (
 	"context"
+	"fmt"
+	"io"
+
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/remote_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
@@ -34,3 +37,29 @@ func CacheRemoteObjectToLocalCluster(filerClient filer_pb.FilerClient, remoteCon
 		return err
 	})
 }
+
+// the size of each read from the remote storage when streaming a remote only entry
+const remoteReadSize = 4 * 1024 * 1024
+
+// StreamRemoteContent copies the first size bytes of an entry only in the remote storage, without caching it in the local cluster.
+func (f *Filer) StreamRemoteContent(writer io.Writer, entry *Entry, size int64) error {
+	mountDir, remoteMountedLocation := f.RemoteStorage.FindMountDirectory(entry.FullPath)
+	if mountDir == "" {
+		return fmt.Errorf("%s is not mounted", entry.FullPath)
+	}
+	client, _, found := f.RemoteStorage.GetRemoteStorageClient(remoteMountedLocation.Name)
+	if !found {
+		return fmt.Errorf("remote storage %s of %s not found", remoteMountedLocation.Name, entry.FullPath)
+	}
+	remoteLocation := MapFullPathToRemoteStorageLocation(mountDir, remoteMountedLocation, entry.FullPath)
+	for offset := int64(0); offset < size; offset += remoteReadSize {
+		data, err := client.ReadFile(remoteLocation, offset, min(remoteReadSize, size-offset))
+		if err != nil {
+			return fmt.Errorf("read remote %s [%d, %d): %v", entry.FullPath, offset, min(offset+remoteReadSize, size), err)
+		}
+		if _, err = writer.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
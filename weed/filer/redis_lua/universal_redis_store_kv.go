@@ -3,6 +3,9 @@ package redis_lua
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
@@ -40,3 +43,109 @@ func (store *UniversalRedisLuaStore) KvDelete(ctx context.Context, key []byte) (
 
 	return nil
 }
+
+// kvCompareAndSwapScript sets or deletes KEYS[1] if its value is ARGV[2], or if it is absent when ARGV[1] is "1".
+// ARGV[3] is "1" to delete the key, otherwise the key is set to ARGV[4].
+var kvCompareAndSwapScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if ARGV[1] == '1' then
+	if current then return 0 end
+elseif current ~= ARGV[2] then
+	return 0
+end
+if ARGV[3] == '1' then
+	redis.call('DEL', KEYS[1])
+else
+	redis.call('SET', KEYS[1], ARGV[4])
+end
+return 1
+`)
+
+func (store *UniversalRedisLuaStore) KvCompareAndSwap(ctx context.Context, key []byte, oldValue []byte, newValue []byte) (swapped bool, err error) {
+
+	swapped, err = kvCompareAndSwapScript.Run(ctx, store.Client, []string{string(key)},
+		kvScriptFlag(oldValue == nil), oldValue, kvScriptFlag(newValue == nil), newValue).Bool()
+
+	if err != nil {
+		return false, fmt.Errorf("kv compare and swap: %v", err)
+	}
+
+	return swapped, nil
+}
+
+func kvScriptFlag(isNil bool) string {
+	if isNil {
+		return "1"
+	}
+	return "0"
+}
+
+// KvList lists the kv entries by key. Redis keeps the keys unordered, so the keys under the prefix are
+// scanned and sorted for each page. The keys of the file entries start with "/", and are skipped.
+func (store *UniversalRedisLuaStore) KvList(ctx context.Context, prefix []byte, startKey []byte, limit int64, eachFunc filer.KvEachFunc) (err error) {
+
+	keys, err := scanKvKeys(ctx, store.Client, string(prefix))
+	if err != nil {
+		return fmt.Errorf("kv list: %v", err)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if limit <= 0 {
+			break
+		}
+		if key <= string(startKey) || strings.HasPrefix(key, "/") {
+			continue
+		}
+		value, getErr := store.Client.Get(ctx, key).Bytes()
+		if getErr == redis.Nil {
+			// deleted after the scan
+			continue
+		}
+		if getErr != nil {
+			return fmt.Errorf("kv list %s: %v", key, getErr)
+		}
+		limit--
+		if !eachFunc([]byte(key), value) {
+			break
+		}
+	}
+
+	return nil
+}
+
+// scanKvKeys scans the keys with the prefix, on every master of a redis cluster.
+func scanKvKeys(ctx context.Context, client redis.UniversalClient, prefix string) (keys []string, err error) {
+	match := escapeScanPattern(prefix) + "*"
+	var keysLock sync.Mutex
+	scan := func(ctx context.Context, c redis.Cmdable) error {
+		iter := c.Scan(ctx, 0, match, 1024).Iterator()
+		for iter.Next(ctx) {
+			keysLock.Lock()
+			keys = append(keys, iter.Val())
+			keysLock.Unlock()
+		}
+		return iter.Err()
+	}
+	if clusterClient, ok := client.(*redis.ClusterClient); ok {
+		err = clusterClient.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+			return scan(ctx, master)
+		})
+		return
+	}
+	err = scan(ctx, client)
+	return
+}
+
+// escapeScanPattern escapes the glob characters of the SCAN MATCH pattern.
+func escapeScanPattern(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
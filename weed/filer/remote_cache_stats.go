@@ -0,0 +1,105 @@
+package filer
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// the directories with cache misses tracked per mount, to bound the memory
+const maxTrackedMissedDirectories = 10000
+
+type remoteMountCacheCounter struct {
+	hitCount   uint64
+	missCount  uint64
+	hitBytes   uint64
+	missBytes  uint64
+	missedDirs map[util.FullPath]*filer_pb.RemoteMountCacheStatistics_MissedDirectory
+}
+
+// RemoteCacheStats counts the reads of mounted remote storage served from the local cluster, the hits,
+// and the reads fetching the content from the remote storage, the misses.
+// The directories with the most misses are the hot prefixes worth warming up.
+type RemoteCacheStats struct {
+	mounts map[util.FullPath]*remoteMountCacheCounter
+	since  time.Time
+	sync.Mutex
+}
+
+func NewRemoteCacheStats() *RemoteCacheStats {
+	return &RemoteCacheStats{
+		mounts: make(map[util.FullPath]*remoteMountCacheCounter),
+		since:  time.Now(),
+	}
+}
+
+func (s *RemoteCacheStats) RecordRead(mountDir util.FullPath, dir util.FullPath, size int64, isHit bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	counter, found := s.mounts[mountDir]
+	if !found {
+		counter = &remoteMountCacheCounter{
+			missedDirs: make(map[util.FullPath]*filer_pb.RemoteMountCacheStatistics_MissedDirectory),
+		}
+		s.mounts[mountDir] = counter
+	}
+	if isHit {
+		counter.hitCount++
+		counter.hitBytes += uint64(size)
+		return
+	}
+	counter.missCount++
+	counter.missBytes += uint64(size)
+	missedDir, found := counter.missedDirs[dir]
+	if !found {
+		if len(counter.missedDirs) >= maxTrackedMissedDirectories {
+			return
+		}
+		missedDir = &filer_pb.RemoteMountCacheStatistics_MissedDirectory{Directory: string(dir)}
+		counter.missedDirs[dir] = missedDir
+	}
+	missedDir.MissCount++
+	missedDir.MissBytes += uint64(size)
+}
+
+// Collect returns the statistics of each mount, with up to topMissedDirectories directories having the most misses.
+func (s *RemoteCacheStats) Collect(topMissedDirectories int) (mounts []*filer_pb.RemoteMountCacheStatistics, since time.Time) {
+	s.Lock()
+	defer s.Unlock()
+
+	for mountDir, counter := range s.mounts {
+		mount := &filer_pb.RemoteMountCacheStatistics{
+			Directory: string(mountDir),
+			HitCount:  counter.hitCount,
+			MissCount: counter.missCount,
+			HitBytes:  counter.hitBytes,
+			MissBytes: counter.missBytes,
+		}
+		for _, missedDir := range counter.missedDirs {
+			mount.MissedDirectories = append(mount.MissedDirectories, &filer_pb.RemoteMountCacheStatistics_MissedDirectory{
+				Directory: missedDir.Directory,
+				MissCount: missedDir.MissCount,
+				MissBytes: missedDir.MissBytes,
+			})
+		}
+		sort.Slice(mount.MissedDirectories, func(i, j int) bool {
+			a, b := mount.MissedDirectories[i], mount.MissedDirectories[j]
+			if a.MissCount != b.MissCount {
+				return a.MissCount > b.MissCount
+			}
+			return a.Directory < b.Directory
+		})
+		if len(mount.MissedDirectories) > topMissedDirectories {
+			mount.MissedDirectories = mount.MissedDirectories[:topMissedDirectories]
+		}
+		mounts = append(mounts, mount)
+	}
+	sort.Slice(mounts, func(i, j int) bool {
+		return mounts[i].Directory < mounts[j].Directory
+	})
+	return mounts, s.since
+}
@@ -0,0 +1,29 @@
+package filer
+
+import (
+	"testing"
+)
+
+func TestRemoteCacheStats(t *testing.T) {
+	s := NewRemoteCacheStats()
+	s.RecordRead("/mnt/a", "/mnt/a/x", 100, true)
+	s.RecordRead("/mnt/a", "/mnt/a/x", 10, false)
+	s.RecordRead("/mnt/a", "/mnt/a/y", 20, false)
+	s.RecordRead("/mnt/a", "/mnt/a/y", 30, false)
+	s.RecordRead("/mnt/b", "/mnt/b", 5, false)
+
+	mounts, _ := s.Collect(1)
+	if len(mounts) != 2 {
+		t.Fatalf("expected 2 mounts, got %d", len(mounts))
+	}
+	a := mounts[0]
+	if a.Directory != "/mnt/a" || a.HitCount != 1 || a.HitBytes != 100 || a.MissCount != 3 || a.MissBytes != 60 {
+		t.Errorf("unexpected statistics %+v", a)
+	}
+	if len(a.MissedDirectories) != 1 || a.MissedDirectories[0].Directory != "/mnt/a/y" || a.MissedDirectories[0].MissBytes != 50 {
+		t.Errorf("expected /mnt/a/y with the most misses, got %+v", a.MissedDirectories)
+	}
+	if mounts[1].Directory != "/mnt/b" || mounts[1].MissCount != 1 {
+		t.Errorf("unexpected statistics %+v", mounts[1])
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/chunk_cache"
 	"github.com/seaweedfs/seaweedfs/weed/wdclient"
 )
 
@@ -68,74 +69,146 @@ func NewFileReader(filerClient filer_pb.FilerClient, entry *filer_pb.Entry) io.R
 }
 
 func StreamContent(masterClient wdclient.HasLookupFileIdFunction, writer io.Writer, chunks []*filer_pb.FileChunk, offset int64, size int64) error {
-	return StreamContentWithThrottler(masterClient, writer, chunks, offset, size, 0)
+	return StreamContentWithThrottler(masterClient, writer, chunks, offset, size, 0, nil)
 }
 
-func StreamContentWithThrottler(masterClient wdclient.HasLookupFileIdFunction, writer io.Writer, chunks []*filer_pb.FileChunk, offset int64, size int64, downloadMaxBytesPs int64) error {
+// StreamContentWithThrottler writes the file content in [offset, offset+size) to the writer.
+// With a chunk cache, whole chunks are fetched and cached, and cached chunks skip the volume servers.
+func StreamContentWithThrottler(masterClient wdclient.HasLookupFileIdFunction, writer io.Writer, chunks []*filer_pb.FileChunk, offset int64, size int64, downloadMaxBytesPs int64, chunkCache chunk_cache.ChunkCache) error {
+	streamFn, err := PrepareStreamContentWithThrottler(masterClient, chunks, offset, size, downloadMaxBytesPs, chunkCache)
+	if err != nil {
+		return err
+	}
+	return streamFn(writer)
+}
+
+// DoStreamContent writes the prepared content to the writer.
+type DoStreamContent func(writer io.Writer) error
+
+// PrepareStreamContentWithThrottler resolves the chunks in [offset, offset+size) and looks up their locations,
+// so these errors are returned before any content is written.
+func PrepareStreamContentWithThrottler(masterClient wdclient.HasLookupFileIdFunction, chunks []*filer_pb.FileChunk, offset int64, size int64, downloadMaxBytesPs int64, chunkCache chunk_cache.ChunkCache) (DoStreamContent, error) {
 
 	glog.V(4).Infof("start to stream content for chunks: %d", len(chunks))
-	chunkViews := ViewFromChunks(masterClient.GetLookupFileIdFunction(), chunks, offset, size)
+	visibles, err := NonOverlappingVisibleIntervals(masterClient.GetLookupFileIdFunction(), chunks, offset, offset+size)
+	if err != nil {
+		return nil, fmt.Errorf("resolve chunks in [%d,%d): %v", offset, offset+size, err)
+	}
+	chunkViews := ViewFromVisibleIntervals(visibles, offset, size)
 
 	fileId2Url := make(map[string][]string)
-
 	for _, chunkView := range chunkViews {
-		var urlStrings []string
-		var err error
-		for _, backoff := range getLookupFileIdBackoffSchedule {
-			urlStrings, err = masterClient.GetLookupFileIdFunction()(chunkView.FileId)
-			if err == nil && len(urlStrings) > 0 {
-				break
-			}
-			glog.V(4).Infof("waiting for chunk: %s", chunkView.FileId)
-			time.Sleep(backoff)
+		if _, found := fileId2Url[chunkView.FileId]; found {
+			continue
 		}
+		urlStrings, err := lookupFileIdWithBackoff(masterClient, chunkView.FileId)
 		if err != nil {
-			glog.V(1).Infof("operation LookupFileId %s failed, err: %v", chunkView.FileId, err)
-			return err
-		} else if len(urlStrings) == 0 {
-			errUrlNotFound := fmt.Errorf("operation LookupFileId %s failed, err: urls not found", chunkView.FileId)
-			glog.Error(errUrlNotFound)
-			return errUrlNotFound
+			return nil, err
 		}
 		fileId2Url[chunkView.FileId] = urlStrings
 	}
 
-	downloadThrottler := util.NewWriteThrottler(downloadMaxBytesPs)
-	remaining := size
-	for _, chunkView := range chunkViews {
-		if offset < chunkView.LogicOffset {
-			gap := chunkView.LogicOffset - offset
-			remaining -= gap
-			glog.V(4).Infof("zero [%d,%d)", offset, chunkView.LogicOffset)
-			err := writeZero(writer, gap)
+	return func(writer io.Writer) error {
+		downloadThrottler := util.NewWriteThrottler(downloadMaxBytesPs)
+		offset, remaining := offset, size
+		for _, chunkView := range chunkViews {
+			if offset < chunkView.LogicOffset {
+				gap := chunkView.LogicOffset - offset
+				remaining -= gap
+				glog.V(4).Infof("zero [%d,%d)", offset, chunkView.LogicOffset)
+				err := writeZero(writer, gap)
+				if err != nil {
+					return fmt.Errorf("write zero [%d,%d)", offset, chunkView.LogicOffset)
+				}
+				offset = chunkView.LogicOffset
+			}
+			if chunkCache != nil {
+				data := make([]byte, chunkView.Size)
+				if n, _ := chunkCache.ReadChunkAt(data, chunkView.FileId, uint64(chunkView.Offset)); n >= len(data) {
+					if _, err := writer.Write(data); err != nil {
+						return fmt.Errorf("write cached chunk %s: %v", chunkView.FileId, err)
+					}
+					offset += int64(chunkView.Size)
+					remaining -= int64(chunkView.Size)
+					stats.FilerRequestCounter.WithLabelValues("chunkCacheHit").Inc()
+					continue
+				}
+			}
+			urlStrings := fileId2Url[chunkView.FileId]
+			start := time.Now()
+			var err error
+			if isChunkAdmitted(chunkCache, chunkView.FileId) {
+				err = fetchAndCacheChunk(writer, chunkCache, urlStrings, chunkView)
+			} else {
+				err = retriedStreamFetchChunkData(writer, urlStrings, chunkView.CipherKey, chunkView.IsGzipped, chunkView.IsFullChunk(), chunkView.Offset, int(chunkView.Size))
+			}
+			offset += int64(chunkView.Size)
+			remaining -= int64(chunkView.Size)
+			stats.FilerRequestHistogram.WithLabelValues("chunkDownload").Observe(time.Since(start).Seconds())
 			if err != nil {
-				return fmt.Errorf("write zero [%d,%d)", offset, chunkView.LogicOffset)
+				stats.FilerRequestCounter.WithLabelValues("chunkDownloadError").Inc()
+				return fmt.Errorf("read chunk: %v", err)
 			}
-			offset = chunkView.LogicOffset
+			stats.FilerRequestCounter.WithLabelValues("chunkDownload").Inc()
+			downloadThrottler.MaybeSlowdown(int64(chunkView.Size))
 		}
-		urlStrings := fileId2Url[chunkView.FileId]
-		start := time.Now()
-		err := retriedStreamFetchChunkData(writer, urlStrings, chunkView.CipherKey, chunkView.IsGzipped, chunkView.IsFullChunk(), chunkView.Offset, int(chunkView.Size))
-		offset += int64(chunkView.Size)
-		remaining -= int64(chunkView.Size)
-		stats.FilerRequestHistogram.WithLabelValues("chunkDownload").Observe(time.Since(start).Seconds())
-		if err != nil {
-			stats.FilerRequestCounter.WithLabelValues("chunkDownloadError").Inc()
-			return fmt.Errorf("read chunk: %v", err)
+		if remaining > 0 {
+			glog.V(4).Infof("zero [%d,%d)", offset, offset+remaining)
+			err := writeZero(writer, remaining)
+			if err != nil {
+				return fmt.Errorf("write zero [%d,%d)", offset, offset+remaining)
+			}
 		}
-		stats.FilerRequestCounter.WithLabelValues("chunkDownload").Inc()
-		downloadThrottler.MaybeSlowdown(int64(chunkView.Size))
+
+		return nil
+	}, nil
+
+}
+
+// isChunkAdmitted tells whether the chunk would be cached, otherwise it is streamed through without buffering
+func isChunkAdmitted(chunkCache chunk_cache.ChunkCache, fileId string) bool {
+	if chunkCache == nil {
+		return false
 	}
-	if remaining > 0 {
-		glog.V(4).Infof("zero [%d,%d)", offset, offset+remaining)
-		err := writeZero(writer, remaining)
-		if err != nil {
-			return fmt.Errorf("write zero [%d,%d)", offset, offset+remaining)
-		}
+	if checker, ok := chunkCache.(chunk_cache.AdmissionChecker); ok {
+		return checker.IsAdmitted(fileId)
 	}
+	return true
+}
 
-	return nil
+// fetchAndCacheChunk reads the whole chunk, so it can be cached for later reads of any part of it
+func fetchAndCacheChunk(writer io.Writer, chunkCache chunk_cache.ChunkCache, urlStrings []string, chunkView *ChunkView) error {
+	data := make([]byte, chunkView.ChunkSize)
+	n, err := retriedFetchChunkData(data, urlStrings, chunkView.CipherKey, chunkView.IsGzipped, true, 0)
+	if err != nil {
+		return err
+	}
+	if n < int(chunkView.Offset)+int(chunkView.Size) {
+		return fmt.Errorf("chunk %s has %d bytes, expected %d", chunkView.FileId, n, chunkView.Offset+int64(chunkView.Size))
+	}
+	chunkCache.SetChunk(chunkView.FileId, data[:n])
+	_, err = writer.Write(data[chunkView.Offset : chunkView.Offset+int64(chunkView.Size)])
+	return err
+}
 
+func lookupFileIdWithBackoff(masterClient wdclient.HasLookupFileIdFunction, fileId string) (urlStrings []string, err error) {
+	for _, backoff := range getLookupFileIdBackoffSchedule {
+		urlStrings, err = masterClient.GetLookupFileIdFunction()(fileId)
+		if err == nil && len(urlStrings) > 0 {
+			break
+		}
+		glog.V(4).Infof("waiting for chunk: %s", fileId)
+		time.Sleep(backoff)
+	}
+	if err != nil {
+		glog.V(1).Infof("operation LookupFileId %s failed, err: %v", fileId, err)
+		return nil, err
+	} else if len(urlStrings) == 0 {
+		errUrlNotFound := fmt.Errorf("operation LookupFileId %s failed, err: urls not found", fileId)
+		glog.Error(errUrlNotFound)
+		return nil, errUrlNotFound
+	}
+	return urlStrings, nil
 }
 
 // ----------------  ReadAllReader ----------------------------------
@@ -67,6 +67,11 @@
 //		"glob" pattern and N is a V level. For instance,
 //			-vmodule=gopher*=3
 //		sets the V level to 3 in all Go files whose names begin "gopher".
+//		A pattern with a slash is matched with the last directories of the file,
+//		e.g. filer/*=3 sets the V level to 3 in all Go files of the filer directory.
+//	-vcomponent=""
+//		A comma-separated list of component=N, setting the V level of all files
+//		of the master, volume, filer or s3 components, e.g. -vcomponent=filer=3.
 //
 package glog
 
@@ -250,7 +255,11 @@ type modulePat struct {
 
 // match reports whether the file matches the pattern. It uses a string
 // comparison if the pattern contains no metacharacters.
-func (m *modulePat) match(file string) bool {
+// A pattern with slashes is matched with as many last elements of the path.
+func (m *modulePat) match(file, path string) bool {
+	if strings.Contains(m.pattern, "/") {
+		file = lastPathElements(path, strings.Count(m.pattern, "/")+1)
+	}
 	if m.literal {
 		return file == m.pattern
 	}
@@ -258,6 +267,15 @@ func (m *modulePat) match(file string) bool {
 	return match
 }
 
+// lastPathElements returns the last n elements of the slash separated path.
+func lastPathElements(path string, n int) string {
+	i := len(path)
+	for ; n > 0 && i >= 0; n-- {
+		i = strings.LastIndex(path[:i], "/")
+	}
+	return path[i+1:]
+}
+
 func (m *moduleSpec) String() string {
 	// Lock because the type is not atomic. TODO: clean this up.
 	logging.mu.Lock()
@@ -401,6 +419,7 @@ func init() {
 	flag.Var(&logging.verbosity, "v", "log levels [0|1|2|3|4], default to 0")
 	flag.Var(&logging.stderrThreshold, "stderrthreshold", "logs at or above this threshold go to stderr")
 	flag.Var(&logging.vmodule, "vmodule", "comma-separated list of pattern=N settings for file-filtered logging")
+	flag.Var(&logging.vcomponent, "vcomponent", "comma-separated list of component=N settings for the logging of "+strings.Join(Components(), ", "))
 	flag.Var(&logging.traceLocation, "log_backtrace_at", "when logging hits line file:N, emit a stack trace")
 
 	// Default stderrThreshold is ERROR.
@@ -455,7 +474,8 @@ type loggingT struct {
 	verbosity Level      // V logging level, the value of the -v flag/
 
 	// added by seaweedfs
-	exited bool
+	exited     bool
+	vcomponent componentSpec // The state of the -vcomponent flag, checked after -vmodule.
 }
 
 // buffer holds a byte Buffer for reuse. The zero value is ready for use.
@@ -483,7 +503,7 @@ func (l *loggingT) setVState(verbosity Level, filter []modulePat, setFilter bool
 
 	// Things are consistent now, so enable filtering and verbosity.
 	// They are enabled in order opposite to that in V.
-	atomic.StoreInt32(&logging.filterLength, int32(len(filter)))
+	atomic.StoreInt32(&logging.filterLength, int32(len(logging.vmodule.filter)+len(logging.vcomponent.filter)))
 	logging.verbosity.set(verbosity)
 }
 
@@ -961,15 +981,22 @@ func (lb logBridge) Write(b []byte) (n int, err error) {
 func (l *loggingT) setV(pc uintptr) Level {
 	fn := runtime.FuncForPC(pc)
 	file, _ := fn.FileLine(pc)
-	// The file is something like /a/b/c/d.go. We want just the d.
+	// The file is something like /a/b/c/d.go. We want just the d, or the /a/b/c/d for the patterns with slashes.
 	if strings.HasSuffix(file, ".go") {
 		file = file[:len(file)-3]
 	}
+	path := file
 	if slash := strings.LastIndex(file, "/"); slash >= 0 {
 		file = file[slash+1:]
 	}
 	for _, filter := range l.vmodule.filter {
-		if filter.match(file) {
+		if filter.match(file, path) {
+			l.vmap[pc] = filter.level
+			return filter.level
+		}
+	}
+	for _, filter := range l.vcomponent.filter {
+		if filter.match(file, path) {
 			l.vmap[pc] = filter.level
 			return filter.level
 		}
@@ -0,0 +1,189 @@
+package glog
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// componentPatterns are the source files of each component of the weed server,
+// as the -vmodule patterns matched with the last directories of the file.
+var componentPatterns = map[string][]string{
+	"master": {"server/master_*", "topology/*", "sequence/*", "cluster/*"},
+	"volume": {"server/volume_*", "storage/*", "storage/*/*", "storage/*/*/*"},
+	"filer":  {"server/filer_*", "filer/*", "filer/*/*", "filer/*/*/*"},
+	"s3":     {"s3api/*", "s3api/*/*"},
+}
+
+// componentSpec represents the setting of the -vcomponent flag.
+// The component levels only raise the verbosity above -v, and -vmodule takes precedence.
+type componentSpec struct {
+	levels map[string]Level
+	filter []modulePat
+}
+
+// Components returns the names of the components accepted by -vcomponent.
+func Components() []string {
+	var names []string
+	for name := range componentPatterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *componentSpec) String() string {
+	logging.mu.Lock()
+	defer logging.mu.Unlock()
+	return c.string()
+}
+
+func (c *componentSpec) string() string {
+	var b bytes.Buffer
+	for _, name := range Components() {
+		if level, found := c.levels[name]; found {
+			if b.Len() > 0 {
+				b.WriteRune(',')
+			}
+			fmt.Fprintf(&b, "%s=%d", name, level)
+		}
+	}
+	return b.String()
+}
+
+// Get is part of the flag.Getter interface.
+func (c *componentSpec) Get() interface{} {
+	return nil
+}
+
+// Syntax: -vcomponent=filer=3,volume=1
+func (c *componentSpec) Set(value string) error {
+	levels, err := parseComponentLevels(value)
+	if err != nil {
+		return err
+	}
+	logging.mu.Lock()
+	defer logging.mu.Unlock()
+	c.setLevels(levels)
+	return nil
+}
+
+// setLevels replaces the component levels. l.mu must be held.
+func (c *componentSpec) setLevels(levels map[string]Level) {
+	var filter []modulePat
+	for _, name := range Components() {
+		if level := levels[name]; level > 0 {
+			for _, pattern := range componentPatterns[name] {
+				filter = append(filter, modulePat{pattern, isLiteral(pattern), level})
+			}
+		} else {
+			delete(levels, name)
+		}
+	}
+
+	c.levels = levels
+	c.filter = filter
+	// the filters are only read with l.mu held, so wipe the pc->Level map and update the filter length
+	logging.vmap = make(map[uintptr]Level)
+	logging.setVState(logging.verbosity.get(), logging.vmodule.filter, false)
+}
+
+func parseComponentLevels(value string) (map[string]Level, error) {
+	levels := make(map[string]Level)
+	for _, pat := range strings.Split(value, ",") {
+		if len(pat) == 0 {
+			continue
+		}
+		name, level, err := parseLevel(pat)
+		if err != nil {
+			return nil, err
+		}
+		if _, found := componentPatterns[name]; !found {
+			return nil, fmt.Errorf("unknown component %q, expect one of %s", name, strings.Join(Components(), ", "))
+		}
+		levels[name] = level
+	}
+	return levels, nil
+}
+
+func parseLevel(pat string) (string, Level, error) {
+	nameLevel := strings.Split(pat, "=")
+	if len(nameLevel) != 2 || len(nameLevel[0]) == 0 || len(nameLevel[1]) == 0 {
+		return "", 0, fmt.Errorf("syntax error in %q: expect name=N", pat)
+	}
+	v, err := strconv.Atoi(nameLevel[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("syntax error in %q: %v", pat, err)
+	}
+	if v < 0 {
+		return "", 0, fmt.Errorf("negative level in %q", pat)
+	}
+	return nameLevel[0], Level(v), nil
+}
+
+// SetLevels changes the log levels at runtime, with a comma-separated list of name=N,
+// where the name is v for the -v level or a component of -vcomponent, e.g. "v=1,filer=3".
+// The components not listed keep their levels, and level 0 turns the component level off.
+func SetLevels(spec string) error {
+	verbosity := -1
+	components := make(map[string]Level)
+	for _, pat := range strings.Split(spec, ",") {
+		if len(pat) == 0 {
+			continue
+		}
+		name, level, err := parseLevel(pat)
+		if err != nil {
+			return err
+		}
+		if name == "v" {
+			verbosity = int(level)
+			continue
+		}
+		if _, found := componentPatterns[name]; !found {
+			return fmt.Errorf("unknown component %q, expect v or one of %s", name, strings.Join(Components(), ", "))
+		}
+		components[name] = level
+	}
+
+	logging.mu.Lock()
+	defer logging.mu.Unlock()
+	if len(components) > 0 {
+		levels := make(map[string]Level)
+		for name, level := range logging.vcomponent.levels {
+			levels[name] = level
+		}
+		for name, level := range components {
+			levels[name] = level
+		}
+		logging.vcomponent.setLevels(levels)
+	}
+	if verbosity >= 0 {
+		logging.setVState(Level(verbosity), logging.vmodule.filter, false)
+	}
+	return nil
+}
+
+// ChangeLevels changes the log levels by SetLevels unless the spec is empty, and returns the current levels.
+// The servers running in one process share the log levels.
+func ChangeLevels(spec string) (levels string, err error) {
+	if spec != "" {
+		if err = SetLevels(spec); err != nil {
+			return "", err
+		}
+		V(0).Infof("log levels are changed to %s", Levels())
+	}
+	return Levels(), nil
+}
+
+// Levels returns the current log levels in the format of SetLevels.
+func Levels() string {
+	logging.mu.Lock()
+	defer logging.mu.Unlock()
+	levels := "v=" + logging.verbosity.String()
+	if components := logging.vcomponent.string(); components != "" {
+		levels += "," + components
+	}
+	return levels
+}
@@ -306,6 +306,12 @@ var vGlobs = map[string]bool{
 	"m*=2":         false,
 	"??_*=2":       false,
 	"?[abc]?_*t=2": false,
+	// These use the last directories of the file.
+	"glog/glog_test=2": true,
+	"glog/*=2":         true,
+	"*/glog/*=2":       true,
+	"filer/*=2":        false,
+	"glog/*/*=2":       false,
 }
 
 // Test that vmodule globbing works as advertised.
@@ -326,6 +332,42 @@ func TestVmoduleGlob(t *testing.T) {
 	}
 }
 
+// Test that a component level enables a log in the files of the component.
+func TestVcomponent(t *testing.T) {
+	setFlags()
+	defer logging.swap(logging.newBuffers())
+	componentPatterns["test"] = []string{"glog/glog_test"}
+	defer delete(componentPatterns, "test")
+	defer SetLevels("v=0,test=0")
+
+	if err := SetLevels("test=2,v=1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := Levels(); got != "v=1,test=2" {
+		t.Errorf("Levels: got %q", got)
+	}
+	if !V(2) || V(3) {
+		t.Error("V not enabled for 2 only")
+	}
+
+	if err := SetLevels("test=0"); err != nil {
+		t.Fatal(err)
+	}
+	if got := Levels(); got != "v=1" {
+		t.Errorf("Levels: got %q", got)
+	}
+	if V(2) {
+		t.Error("V enabled for 2 after the component level is off")
+	}
+
+	if err := SetLevels("nosuchcomponent=2"); err == nil {
+		t.Error("unknown component accepted")
+	}
+	if err := SetLevels("v=x"); err == nil {
+		t.Error("bad level accepted")
+	}
+}
+
 func TestRollover(t *testing.T) {
 	setFlags()
 	var err error
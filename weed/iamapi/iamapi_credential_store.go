@@ -0,0 +1,332 @@
+package iamapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/wdclient"
+)
+
+// CredentialStore persists the serialized iam identities and policies.
+// Load returns nil data if nothing is saved yet.
+type CredentialStore interface {
+	GetName() string
+	Load(name string) (data []byte, err error)
+	Save(name string, data []byte) error
+}
+
+func NewCredentialStore(config util.Configuration, option *IamServerOption, masterClient *wdclient.MasterClient) (store CredentialStore, err error) {
+	config.SetDefault("iam.credential.store", "filer")
+	config.SetDefault("iam.credential.vault.mount", "secret")
+	config.SetDefault("iam.credential.vault.path", "seaweedfs/iam")
+
+	switch name := config.GetString("iam.credential.store"); name {
+	case "filer":
+		store = &FilerCredentialStore{option: option, masterClient: masterClient}
+	case "filer_kv":
+		store = &FilerKvCredentialStore{option: option}
+	case "file":
+		store, err = NewFileCredentialStore(config.GetString("iam.credential.file.dir"), config.GetString("iam.credential.file.key"))
+	case "vault":
+		store, err = NewVaultCredentialStore(
+			config.GetString("iam.credential.vault.address"),
+			config.GetString("iam.credential.vault.token"),
+			config.GetString("iam.credential.vault.mount"),
+			config.GetString("iam.credential.vault.path"))
+	default:
+		err = fmt.Errorf("unknown iam credential store %s", name)
+	}
+	if err == nil {
+		glog.V(0).Infof("iam credential store: %s", store.GetName())
+	}
+	return
+}
+
+// FilerCredentialStore keeps the files in the filer /etc/iam directory, which the s3 gateways read directly.
+type FilerCredentialStore struct {
+	option       *IamServerOption
+	masterClient *wdclient.MasterClient
+}
+
+func (store *FilerCredentialStore) GetName() string {
+	return "filer"
+}
+
+func (store *FilerCredentialStore) Load(name string) (data []byte, err error) {
+	var buf bytes.Buffer
+	err = rpc.WithGrpcFilerClient(false, store.option.Filer, store.option.GrpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		return filer.ReadEntry(store.masterClient, client, filer.IamConfigDirectory, name, &buf)
+	})
+	if err == filer_pb.ErrNotFound {
+		return nil, nil
+	}
+	return buf.Bytes(), err
+}
+
+func (store *FilerCredentialStore) Save(name string, data []byte) error {
+	return saveIamFileInsideFiler(store.option, name, data)
+}
+
+func saveIamFileInsideFiler(option *IamServerOption, name string, data []byte) error {
+	return rpc.WithGrpcFilerClient(false, option.Filer, option.GrpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		return util.Retry("saveIamFile", func() error {
+			return filer.SaveInsideFiler(client, filer.IamConfigDirectory, name, data)
+		})
+	})
+}
+
+// FilerKvCredentialStore keeps the files in the filer store key value space.
+type FilerKvCredentialStore struct {
+	option *IamServerOption
+}
+
+func (store *FilerKvCredentialStore) GetName() string {
+	return "filer_kv"
+}
+
+func (store *FilerKvCredentialStore) Load(name string) (data []byte, err error) {
+	err = rpc.WithGrpcFilerClient(false, store.option.Filer, store.option.GrpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.KvGet(context.Background(), &filer_pb.KvGetRequest{
+			Key: []byte("iam." + name),
+		})
+		if err != nil {
+			return err
+		}
+		if resp.Error != "" {
+			return fmt.Errorf("kv get iam.%s: %v", name, resp.Error)
+		}
+		data = resp.Value
+		return nil
+	})
+	return
+}
+
+func (store *FilerKvCredentialStore) Save(name string, data []byte) error {
+	return rpc.WithGrpcFilerClient(false, store.option.Filer, store.option.GrpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.KvPut(context.Background(), &filer_pb.KvPutRequest{
+			Key:   []byte("iam." + name),
+			Value: data,
+		})
+		if err != nil {
+			return err
+		}
+		if resp.Error != "" {
+			return fmt.Errorf("kv put iam.%s: %v", name, resp.Error)
+		}
+		return nil
+	})
+}
+
+// FileCredentialStore keeps the files in a local directory, encrypted if a key is configured.
+type FileCredentialStore struct {
+	dir       string
+	cipherKey util.CipherKey
+	sync.Mutex
+}
+
+func NewFileCredentialStore(dir string, base64Key string) (*FileCredentialStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("iam.credential.file.dir is not configured")
+	}
+	store := &FileCredentialStore{
+		dir: util.ResolvePath(dir),
+	}
+	if base64Key != "" {
+		key, err := base64.StdEncoding.DecodeString(base64Key)
+		if err != nil {
+			return nil, fmt.Errorf("decode iam.credential.file.key: %v", err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("iam.credential.file.key should be 32 bytes, but is %d bytes", len(key))
+		}
+		store.cipherKey = key
+	}
+	if err := os.MkdirAll(store.dir, 0700); err != nil {
+		return nil, fmt.Errorf("create %s: %v", store.dir, err)
+	}
+	return store, nil
+}
+
+func (store *FileCredentialStore) GetName() string {
+	return "file"
+}
+
+func (store *FileCredentialStore) Load(name string) (data []byte, err error) {
+	store.Lock()
+	defer store.Unlock()
+
+	data, err = os.ReadFile(filepath.Join(store.dir, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil || len(store.cipherKey) == 0 {
+		return
+	}
+	return util.Decrypt(data, store.cipherKey)
+}
+
+func (store *FileCredentialStore) Save(name string, data []byte) (err error) {
+	store.Lock()
+	defer store.Unlock()
+
+	if len(store.cipherKey) > 0 {
+		if data, err = util.Encrypt(data, store.cipherKey); err != nil {
+			return err
+		}
+	}
+	target := filepath.Join(store.dir, name)
+	if err = os.WriteFile(target+".tmp", data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(target+".tmp", target)
+}
+
+// VaultCredentialStore keeps the files in a HashiCorp Vault kv version 2 secrets engine.
+type VaultCredentialStore struct {
+	address string
+	token   string
+	mount   string
+	path    string
+	client  *http.Client
+}
+
+func NewVaultCredentialStore(address, token, mount, path string) (*VaultCredentialStore, error) {
+	if address == "" || token == "" {
+		return nil, fmt.Errorf("iam.credential.vault.address and iam.credential.vault.token are required")
+	}
+	return &VaultCredentialStore{
+		address: strings.TrimSuffix(address, "/"),
+		token:   token,
+		mount:   strings.Trim(mount, "/"),
+		path:    strings.Trim(path, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (store *VaultCredentialStore) GetName() string {
+	return "vault"
+}
+
+type vaultSecret struct {
+	Data struct {
+		Data map[string]string `json:"data"`
+	} `json:"data"`
+}
+
+func (store *VaultCredentialStore) secretUrl(name string) string {
+	return fmt.Sprintf("%s/v1/%s/data/%s/%s", store.address, store.mount, store.path, name)
+}
+
+func (store *VaultCredentialStore) Load(name string) (data []byte, err error) {
+	req, err := http.NewRequest(http.MethodGet, store.secretUrl(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", store.token)
+	resp, err := store.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("read vault secret %s: %v", name, err)
+	}
+	defer util.CloseResponse(resp)
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("read vault secret %s: %s", name, resp.Status)
+	}
+	secret := &vaultSecret{}
+	if err = json.NewDecoder(resp.Body).Decode(secret); err != nil {
+		return nil, fmt.Errorf("decode vault secret %s: %v", name, err)
+	}
+	return base64.StdEncoding.DecodeString(secret.Data.Data["value"])
+}
+
+func (store *VaultCredentialStore) Save(name string, data []byte) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"data": map[string]string{
+			"value": base64.StdEncoding.EncodeToString(data),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, store.secretUrl(name), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", store.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := store.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("write vault secret %s: %v", name, err)
+	}
+	defer util.CloseResponse(resp)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		message, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("write vault secret %s: %s %s", name, resp.Status, message)
+	}
+	return nil
+}
+
+// cachedCredentialStore keeps the loaded files for a while, and drops them on save.
+type cachedCredentialStore struct {
+	CredentialStore
+	ttl     time.Duration
+	entries map[string]*cachedCredential
+	sync.Mutex
+}
+
+type cachedCredential struct {
+	data     []byte
+	loadedAt time.Time
+}
+
+func newCachedCredentialStore(store CredentialStore, ttl time.Duration) *cachedCredentialStore {
+	return &cachedCredentialStore{
+		CredentialStore: store,
+		ttl:             ttl,
+		entries:         make(map[string]*cachedCredential),
+	}
+}
+
+func (store *cachedCredentialStore) Load(name string) ([]byte, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	if entry, found := store.entries[name]; found && time.Since(entry.loadedAt) < store.ttl {
+		return entry.data, nil
+	}
+	data, err := store.CredentialStore.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	store.entries[name] = &cachedCredential{data: data, loadedAt: time.Now()}
+	return data, nil
+}
+
+func (store *cachedCredentialStore) Save(name string, data []byte) error {
+	store.Lock()
+	defer store.Unlock()
+
+	delete(store.entries, name)
+	if err := store.CredentialStore.Save(name, data); err != nil {
+		return err
+	}
+	store.entries[name] = &cachedCredential{data: data, loadedAt: time.Now()}
+	return nil
+}
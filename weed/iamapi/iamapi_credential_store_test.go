@@ -0,0 +1,95 @@
+package iamapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestFileCredentialStore(t *testing.T) {
+	dir := t.TempDir()
+	key := base64.StdEncoding.EncodeToString(util.GenCipherKey())
+	store, err := NewFileCredentialStore(dir, key)
+	assert.Nil(t, err)
+
+	data, err := store.Load(filer.IamIdentityFile)
+	assert.Nil(t, err)
+	assert.Nil(t, data)
+
+	assert.Nil(t, store.Save(filer.IamIdentityFile, []byte(`{"identities":[]}`)))
+	onDisk, _ := os.ReadFile(filepath.Join(dir, filer.IamIdentityFile))
+	assert.NotContains(t, string(onDisk), "identities")
+
+	data, err = store.Load(filer.IamIdentityFile)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"identities":[]}`, string(data))
+
+	_, err = NewFileCredentialStore(dir, base64.StdEncoding.EncodeToString([]byte("short")))
+	assert.NotNil(t, err)
+}
+
+func TestVaultCredentialStore(t *testing.T) {
+	secrets := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			secrets[r.URL.Path] = string(body)
+		case http.MethodGet:
+			secret, found := secrets[r.URL.Path]
+			if !found {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			io.WriteString(w, `{"data":`+secret+`}`)
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewVaultCredentialStore(server.URL, "token", "secret", "/seaweedfs/iam/")
+	assert.Nil(t, err)
+
+	data, err := store.Load(filer.IamPoliciesFile)
+	assert.Nil(t, err)
+	assert.Nil(t, data)
+
+	policies, _ := json.Marshal(Policies{Policies: map[string]PolicyDocument{}})
+	assert.Nil(t, store.Save(filer.IamPoliciesFile, policies))
+	_, found := secrets["/v1/secret/data/seaweedfs/iam/"+filer.IamPoliciesFile]
+	assert.True(t, found)
+
+	data, err = store.Load(filer.IamPoliciesFile)
+	assert.Nil(t, err)
+	assert.Equal(t, policies, data)
+}
+
+func TestCachedCredentialStore(t *testing.T) {
+	fileStore, err := NewFileCredentialStore(t.TempDir(), "")
+	assert.Nil(t, err)
+	store := newCachedCredentialStore(fileStore, time.Hour)
+
+	assert.Nil(t, store.Save(filer.IamIdentityFile, []byte("v1")))
+	// changes made behind the cache are not visible until the ttl expires
+	assert.Nil(t, fileStore.Save(filer.IamIdentityFile, []byte("v2")))
+	data, _ := store.Load(filer.IamIdentityFile)
+	assert.Equal(t, "v1", string(data))
+
+	store.ttl = 0
+	data, _ = store.Load(filer.IamIdentityFile)
+	assert.Equal(t, "v2", string(data))
+}
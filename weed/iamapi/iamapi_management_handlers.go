@@ -21,14 +21,15 @@ import (
 )
 
 const (
-	charsetUpper           = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	charset                = charsetUpper + "abcdefghijklmnopqrstuvwxyz/"
-	policyDocumentVersion  = "2012-10-17"
-	StatementActionAdmin   = "*"
-	StatementActionWrite   = "Put*"
-	StatementActionRead    = "Get*"
-	StatementActionList    = "List*"
-	StatementActionTagging = "Tagging*"
+	charsetUpper             = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	charset                  = charsetUpper + "abcdefghijklmnopqrstuvwxyz/"
+	policyDocumentVersion    = "2012-10-17"
+	StatementActionAdmin     = "*"
+	StatementActionWrite     = "Put*"
+	StatementActionRead      = "Get*"
+	StatementActionList      = "List*"
+	StatementActionTagging   = "Tagging*"
+	StatementActionRetention = "PutObjectRetention"
 )
 
 var (
@@ -50,6 +51,8 @@ func MapToStatementAction(action string) string {
 		return s3_constants.ACTION_LIST
 	case StatementActionTagging:
 		return s3_constants.ACTION_TAGGING
+	case StatementActionRetention:
+		return s3_constants.ACTION_RETENTION
 	default:
 		return ""
 	}
@@ -67,6 +70,8 @@ func MapToIdentitiesAction(action string) string {
 		return StatementActionList
 	case s3_constants.ACTION_TAGGING:
 		return StatementActionTagging
+	case s3_constants.ACTION_RETENTION:
+		return StatementActionRetention
 	default:
 		return ""
 	}
@@ -7,13 +7,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"google.golang.org/grpc"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
-	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/s3api"
 	. "github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
@@ -31,6 +31,7 @@ type IamS3ApiConfig interface {
 type IamS3ApiConfigure struct {
 	option       *IamServerOption
 	masterClient *wdclient.MasterClient
+	store        CredentialStore
 }
 
 type IamServerOption struct {
@@ -48,11 +49,25 @@ type IamApiServer struct {
 var s3ApiConfigure IamS3ApiConfig
 
 func NewIamApiServer(router *mux.Router, option *IamServerOption) (iamApiServer *IamApiServer, err error) {
-	s3ApiConfigure = IamS3ApiConfigure{
+	masterClient := wdclient.NewMasterClient(option.GrpcDialOption, "", "iam", "", "", "", option.Masters)
+	v := util.GetViper()
+	store, err := NewCredentialStore(v, option, masterClient)
+	if err != nil {
+		return nil, err
+	}
+	v.SetDefault("iam.credential.cache_ttl_seconds", 5)
+	configure := IamS3ApiConfigure{
 		option:       option,
-		masterClient: wdclient.NewMasterClient(option.GrpcDialOption, "", "iam", "", "", "", option.Masters),
+		masterClient: masterClient,
+		store:        newCachedCredentialStore(store, time.Duration(v.GetInt("iam.credential.cache_ttl_seconds"))*time.Second),
 	}
+	s3ApiConfigure = configure
 	s3Option := s3api.S3ApiServerOption{Filer: option.Filer}
+	if store.GetName() != "filer" {
+		s3Option.IdentityLoader = func() ([]byte, error) {
+			return configure.store.Load(filer.IamIdentityFile)
+		}
+	}
 	iamApiServer = &IamApiServer{
 		s3ApiConfig: s3ApiConfigure,
 		iam:         s3api.NewIdentityAccessManagement(&s3Option),
@@ -76,18 +91,12 @@ func (iama *IamApiServer) registerRouter(router *mux.Router) {
 }
 
 func (iam IamS3ApiConfigure) GetS3ApiConfiguration(s3cfg *rpc.IAMConfiguration) (err error) {
-	var buf bytes.Buffer
-	err = rpc.WithGrpcFilerClient(false, iam.option.Filer, iam.option.GrpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
-		if err = filer.ReadEntry(iam.masterClient, client, filer.IamConfigDirectory, filer.IamIdentityFile, &buf); err != nil {
-			return err
-		}
-		return nil
-	})
+	data, err := iam.store.Load(filer.IamIdentityFile)
 	if err != nil {
 		return err
 	}
-	if buf.Len() > 0 {
-		if err = filer.ParseS3ConfigurationFromBytes(buf.Bytes(), s3cfg); err != nil {
+	if len(data) > 0 {
+		if err = filer.ParseS3ConfigurationFromBytes(data, s3cfg); err != nil {
 			return err
 		}
 	}
@@ -99,33 +108,39 @@ func (iam IamS3ApiConfigure) PutS3ApiConfiguration(s3cfg *rpc.IAMConfiguration)
 	if err := filer.ProtoToText(&buf, s3cfg); err != nil {
 		return fmt.Errorf("ProtoToText: %s", err)
 	}
-	return rpc.WithGrpcFilerClient(false, iam.option.Filer, iam.option.GrpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
-		err = util.Retry("saveIamIdentity", func() error {
-			return filer.SaveInsideFiler(client, filer.IamConfigDirectory, filer.IamIdentityFile, buf.Bytes())
-		})
-		if err != nil {
-			return err
-		}
+	if err = iam.store.Save(filer.IamIdentityFile, buf.Bytes()); err != nil {
+		return err
+	}
+	return iam.notifyS3Gateways()
+}
+
+// notifyS3Gateways changes the identity version entry in the filer, where all s3 gateways subscribe to the changes.
+// The entry has no secrets, the s3 gateways load the identities from the same credential store.
+// It is a no-op if the identities are stored in the filer, whose changes are seen directly.
+func (iam IamS3ApiConfigure) notifyS3Gateways() error {
+	if iam.store.GetName() == "filer" {
 		return nil
-	})
+	}
+	if err := saveIamFileInsideFiler(iam.option, filer.IamIdentityVersionFile, identityVersion(iam.store.GetName(), time.Now())); err != nil {
+		return fmt.Errorf("notify s3 gateways: %v", err)
+	}
+	return nil
+}
+
+func identityVersion(storeName string, updatedAt time.Time) []byte {
+	return []byte(fmt.Sprintf("{\"store\":%q,\"updatedAtNs\":%d}\n", storeName, updatedAt.UnixNano()))
 }
 
 func (iam IamS3ApiConfigure) GetPolicies(policies *Policies) (err error) {
-	var buf bytes.Buffer
-	err = rpc.WithGrpcFilerClient(false, iam.option.Filer, iam.option.GrpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
-		if err = filer.ReadEntry(iam.masterClient, client, filer.IamConfigDirectory, filer.IamPoliciesFile, &buf); err != nil {
-			return err
-		}
-		return nil
-	})
-	if err != nil && err != filer_pb.ErrNotFound {
+	data, err := iam.store.Load(filer.IamPoliciesFile)
+	if err != nil {
 		return err
 	}
-	if err == filer_pb.ErrNotFound || buf.Len() == 0 {
+	if len(data) == 0 {
 		policies.Policies = make(map[string]PolicyDocument)
 		return nil
 	}
-	if err := json.Unmarshal(buf.Bytes(), policies); err != nil {
+	if err := json.Unmarshal(data, policies); err != nil {
 		return err
 	}
 	return nil
@@ -136,10 +151,5 @@ func (iam IamS3ApiConfigure) PutPolicies(policies *Policies) (err error) {
 	if b, err = json.Marshal(policies); err != nil {
 		return err
 	}
-	return rpc.WithGrpcFilerClient(false, iam.option.Filer, iam.option.GrpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
-		if err := filer.SaveInsideFiler(client, filer.IamConfigDirectory, filer.IamPoliciesFile, b); err != nil {
-			return err
-		}
-		return nil
-	})
+	return iam.store.Save(filer.IamPoliciesFile, b)
 }
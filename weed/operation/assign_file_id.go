@@ -22,6 +22,7 @@ type VolumeAssignRequest struct {
 	Rack                string
 	DataNode            string
 	WritableVolumeCount uint32
+	MaxSize             int64
 }
 
 type AssignResult struct {
@@ -60,6 +61,7 @@ func Assign(masterFn GetMasterFn, grpcDialOption grpc.DialOption, primaryRequest
 				Rack:                request.Rack,
 				DataNode:            request.DataNode,
 				WritableVolumeCount: request.WritableVolumeCount,
+				MaxSize:             request.MaxSize,
 			}
 			resp, grpcErr := masterClient.Assign(context.Background(), req)
 			if grpcErr != nil {
@@ -138,12 +140,18 @@ type StorageOption struct {
 	TtlSeconds        int32
 	Fsync             bool
 	VolumeGrowthCount uint32
+	Dedup             bool
 }
 
 func (so *StorageOption) TtlString() string {
 	return needle.SecondsToTTL(so.TtlSeconds)
 }
 
+// UploadMaxSize is the max bytes to upload the data of the size, allowing for the gzip and cipher overhead
+func UploadMaxSize(dataSize int64) int64 {
+	return dataSize + dataSize/64 + 1024
+}
+
 func (so *StorageOption) ToAssignRequests(count int) (ar *VolumeAssignRequest, altRequest *VolumeAssignRequest) {
 	ar = &VolumeAssignRequest{
 		Count:               uint64(count),
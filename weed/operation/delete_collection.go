@@ -0,0 +1,25 @@
+package operation
+
+import (
+	"context"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+)
+
+// DeleteCollection deletes a collection with all its data.
+// The master only deletes a non-empty collection with the force token from a dry run,
+// so this is for callers which already decided to drop all the data, e.g. when deleting a bucket.
+func DeleteCollection(client master_pb.SeaweedClient, collection string) error {
+	dryRunResp, err := client.CollectionDelete(context.Background(), &master_pb.CollectionDeleteRequest{
+		Name:   collection,
+		DryRun: true,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.CollectionDelete(context.Background(), &master_pb.CollectionDeleteRequest{
+		Name:       collection,
+		ForceToken: dryRunResp.ForceToken,
+	})
+	return err
+}
@@ -10,6 +10,7 @@ import (
 
 	"google.golang.org/grpc"
 
+	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
 )
@@ -157,3 +158,85 @@ func DeleteFilesAtOneVolumeServer(volumeServer rpc.ServerAddress, grpcDialOption
 	return
 
 }
+
+// FilterExistingFileIds drops the file ids confirmed missing by all replicas, checking the volume servers in batches.
+// The file ids of unknown volumes, or not checked on some replica, are kept.
+func FilterExistingFileIds(grpcDialOption grpc.DialOption, fileIds []string, lookupFunc func(vid []string) (map[string]*LookupResult, error)) (existing []string, err error) {
+
+	vid_to_fileIds := make(map[string][]string)
+	var vids []string
+	for _, fileId := range fileIds {
+		vid, _, parseErr := ParseFileId(fileId)
+		if parseErr != nil {
+			continue
+		}
+		if _, ok := vid_to_fileIds[vid]; !ok {
+			vids = append(vids, vid)
+		}
+		vid_to_fileIds[vid] = append(vid_to_fileIds[vid], fileId)
+	}
+
+	lookupResults, err := lookupFunc(vids)
+	if err != nil {
+		return nil, err
+	}
+
+	server_to_fileIds := make(map[rpc.ServerAddress][]string)
+	replicaCounts := make(map[string]int)
+	for vid, result := range lookupResults {
+		for _, location := range result.Locations {
+			serverAddress := location.ServerAddress()
+			server_to_fileIds[serverAddress] = append(server_to_fileIds[serverAddress], vid_to_fileIds[vid]...)
+			for _, fileId := range vid_to_fileIds[vid] {
+				replicaCounts[fileId]++
+			}
+		}
+	}
+
+	var lock sync.Mutex
+	missingCounts := make(map[string]int)
+	var wg sync.WaitGroup
+	for server, fidList := range server_to_fileIds {
+		wg.Add(1)
+		go func(server rpc.ServerAddress, fidList []string) {
+			defer wg.Done()
+			results, checkErr := NeedleExistsAtOneVolumeServer(server, grpcDialOption, fidList)
+			if checkErr != nil {
+				glog.V(1).Infof("check %d file ids on %s: %v", len(fidList), server, checkErr)
+				return
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			for _, result := range results {
+				if !result.Exists && result.Error == "" {
+					missingCounts[result.FileId]++
+				}
+			}
+		}(server, fidList)
+	}
+	wg.Wait()
+
+	for _, fileId := range fileIds {
+		if replicas := replicaCounts[fileId]; replicas == 0 || missingCounts[fileId] < replicas {
+			existing = append(existing, fileId)
+		}
+	}
+	return existing, nil
+}
+
+// NeedleExistsAtOneVolumeServer checks a list of files in the indexes of one volume server via gRpc
+func NeedleExistsAtOneVolumeServer(volumeServer rpc.ServerAddress, grpcDialOption grpc.DialOption, fileIds []string) (ret []*volume_server_pb.NeedleExistsResult, err error) {
+
+	err = WithVolumeServerClient(false, volumeServer, grpcDialOption, func(volumeServerClient volume_server_pb.VolumeServerClient) error {
+		resp, err := volumeServerClient.BatchNeedleExists(context.Background(), &volume_server_pb.BatchNeedleExistsRequest{
+			FileIds: fileIds,
+		})
+		if err != nil {
+			return err
+		}
+		ret = resp.Results
+		return nil
+	})
+
+	return
+}
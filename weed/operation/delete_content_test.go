@@ -0,0 +1,21 @@
+package operation
+
+import (
+	"testing"
+)
+
+func TestFilterExistingFileIdsKeepsUnknownVolumes(t *testing.T) {
+	lookupFunc := func(vids []string) (map[string]*LookupResult, error) {
+		return map[string]*LookupResult{
+			"4": {VolumeOrFileId: "4", Error: "volume not found"},
+		}, nil
+	}
+	fileIds := []string{"3,01637037d6", "4,02637037d6"}
+	existing, err := FilterExistingFileIds(nil, fileIds, lookupFunc)
+	if err != nil {
+		t.Fatalf("filter: %v", err)
+	}
+	if len(existing) != len(fileIds) {
+		t.Errorf("expected the file ids of unknown volumes kept, got %v", existing)
+	}
+}
@@ -68,14 +68,16 @@ var (
 )
 
 func init() {
-	HttpClient = &http.Client{Transport: &http.Transport{
+	transport := &http.Transport{
 		DialContext: (&net.Dialer{
 			Timeout:   10 * time.Second,
 			KeepAlive: 10 * time.Second,
 		}).DialContext,
 		MaxIdleConns:        1024,
 		MaxIdleConnsPerHost: 1024,
-	}}
+	}
+	util.AddHttpsTransport(transport)
+	HttpClient = &http.Client{Transport: transport}
 }
 
 // UploadWithRetry will retry both assigning volume request and uploading content
@@ -69,6 +69,8 @@ type Bucket struct {
 }
 
 type RemoteStorageClient interface {
+	// CheckLocation verifies the credentials, and that the location can be listed
+	CheckLocation(loc *remote_pb.RemoteStorageLocation) error
 	Traverse(loc *remote_pb.RemoteStorageLocation, visitFn VisitFunc) error
 	ReadFile(loc *remote_pb.RemoteStorageLocation, offset int64, size int64) (data []byte, err error)
 	WriteDirectory(loc *remote_pb.RemoteStorageLocation, entry *filer_pb.Entry) (err error)
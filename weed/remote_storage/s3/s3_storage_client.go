@@ -68,6 +68,22 @@ type s3RemoteStorageClient struct {
 
 var _ = remote_storage.RemoteStorageClient(&s3RemoteStorageClient{supportTagging: true})
 
+func (s *s3RemoteStorageClient) CheckLocation(loc *remote_pb.RemoteStorageLocation) error {
+	if _, err := s.conn.HeadBucket(&s3.HeadBucketInput{
+		Bucket: aws.String(loc.Bucket),
+	}); err != nil {
+		return fmt.Errorf("access bucket %s: %v", loc.Bucket, err)
+	}
+	if _, err := s.conn.ListObjectsV2(&s3.ListObjectsV2Input{
+		Bucket:  aws.String(loc.Bucket),
+		Prefix:  aws.String(loc.Path[1:]),
+		MaxKeys: aws.Int64(1),
+	}); err != nil {
+		return fmt.Errorf("list %s%s: %v", loc.Bucket, loc.Path, err)
+	}
+	return nil
+}
+
 func (s *s3RemoteStorageClient) Traverse(remote *remote_pb.RemoteStorageLocation, visitFn remote_storage.VisitFunc) (err error) {
 
 	pathKey := remote.Path[1:]
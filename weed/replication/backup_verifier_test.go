@@ -0,0 +1,61 @@
+package replication
+
+import (
+	"crypto/md5"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/replication/sink/localsink"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+func TestBackupVerifierRepair(t *testing.T) {
+	dir := t.TempDir()
+	dataSink := &localsink.LocalSink{Dir: dir}
+	reportFile := filepath.Join(dir, "report.jsonl")
+	verifier, err := NewBackupVerifier(dataSink, true, reportFile)
+	if err != nil {
+		t.Fatalf("new verifier: %v", err)
+	}
+
+	content := []byte("hello world")
+	hash := md5.Sum(content)
+	entry := &filer_pb.Entry{
+		Name:       "a.txt",
+		Content:    content,
+		Attributes: &filer_pb.Attributes{FileSize: uint64(len(content)), FileMode: 0644, Md5: hash[:]},
+	}
+	key := filepath.Join(dir, "a.txt")
+
+	if err = dataSink.CreateEntry(key, entry, nil); err != nil {
+		t.Fatalf("create entry: %v", err)
+	}
+	if err = verifier.Verify(key, entry, nil); err != nil || verifier.DivergedCount != 0 {
+		t.Fatalf("verify a good replica: %v, %v", err, verifier)
+	}
+
+	// same size, different content
+	if err = os.WriteFile(key, []byte("hello WORLD"), 0644); err != nil {
+		t.Fatalf("corrupt replica: %v", err)
+	}
+	if err = verifier.Verify(key, entry, nil); err != nil {
+		t.Fatalf("verify a corrupted replica: %v", err)
+	}
+	if verifier.DivergedCount != 1 || verifier.RepairedCount != 1 {
+		t.Errorf("unexpected counts: %v", verifier)
+	}
+	if data, _ := os.ReadFile(key); string(data) != string(content) {
+		t.Errorf("replica is not repaired: %q", data)
+	}
+	if report, _ := os.ReadFile(reportFile); len(report) == 0 {
+		t.Errorf("empty verification report")
+	}
+
+	// missing replica
+	os.Remove(key)
+	divergence, err := verifier.compare(key, entry)
+	if err != nil || divergence == nil || divergence.Reason != "missing" {
+		t.Errorf("unexpected divergence %+v: %v", divergence, err)
+	}
+}
@@ -77,7 +77,10 @@ func (r *Replicator) Replicate(ctx context.Context, key string, message *filer_p
 		return nil
 	}
 
-	foundExisting, err := r.sink.UpdateEntry(key, message.OldEntry, message.NewParentPath, message.NewEntry, message.DeleteChunks, message.Signatures)
+	if strings.HasPrefix(message.NewParentPath, r.source.Dir) {
+		message.NewParentPath = util.Join(r.sink.GetSinkToDirectory(), dateKey, message.NewParentPath[len(r.source.Dir):])
+	}
+	foundExisting, err := sink.UpdateEntry(r.sink, key, message)
 	if foundExisting {
 		glog.V(4).Infof("updated %v", key)
 		return err
@@ -2,10 +2,13 @@ package filersink
 
 import (
 	"fmt"
+	"io"
+	"math"
 	"sync"
 
 	"google.golang.org/grpc"
 
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
@@ -38,6 +41,81 @@ func (fs *FilerSink) replicateChunks(sourceChunks []*filer_pb.FileChunk, path st
 	return
 }
 
+// replicateChangedChunks returns the target chunks of the source chunks, replicating only the source chunks without
+// a replicated target chunk yet, and the existing target chunks not replicated from any of the source chunks.
+// The chunk manifests are resolved, so the unchanged data chunks of large files are kept,
+// and the target filer makes its own manifests.
+func (fs *FilerSink) replicateChangedChunks(existingChunks, sourceChunks []*filer_pb.FileChunk, path string) (chunks, unmatchedChunks []*filer_pb.FileChunk, err error) {
+
+	sourceDataChunks, _, err := filer.ResolveChunkManifest(fs.filerSource.LookupFileId, sourceChunks, 0, math.MaxInt64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolve source chunks: %v", err)
+	}
+	existingDataChunks, _, err := filer.ResolveChunkManifest(filer.LookupFn(fs), existingChunks, 0, math.MaxInt64)
+	if err != nil {
+		// replicate all the chunks again
+		glog.Warningf("resolve existing chunks of %s: %v", path, err)
+		existingDataChunks = nil
+	}
+
+	chunks, unmatchedChunks = matchReplicatedChunks(existingDataChunks, sourceDataChunks)
+
+	var toReplicate []*filer_pb.FileChunk
+	for i, chunk := range chunks {
+		if chunk == nil {
+			toReplicate = append(toReplicate, sourceDataChunks[i])
+		}
+	}
+	replicatedChunks, err := fs.replicateChunks(toReplicate, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	glog.V(2).Infof("replicate %s: %d chunks replicated, %d chunks kept", path, len(replicatedChunks), len(chunks)-len(replicatedChunks))
+
+	for i := range chunks {
+		if chunks[i] == nil {
+			chunks[i], replicatedChunks = replicatedChunks[0], replicatedChunks[1:]
+		}
+	}
+	return chunks, unmatchedChunks, nil
+}
+
+// matchReplicatedChunks finds the target chunk replicated from each source chunk, or nil if not replicated yet,
+// and the target chunks not replicated from any source chunk.
+func matchReplicatedChunks(targetChunks, sourceChunks []*filer_pb.FileChunk) (matched, unmatched []*filer_pb.FileChunk) {
+
+	type chunkKey struct {
+		fileId string
+		offset int64
+		size   uint64
+	}
+
+	replicated := make(map[chunkKey]*filer_pb.FileChunk)
+	for _, chunk := range targetChunks {
+		if chunk.SourceFileId != "" {
+			replicated[chunkKey{chunk.SourceFileId, chunk.Offset, chunk.Size}] = chunk
+		}
+	}
+
+	matched = make([]*filer_pb.FileChunk, len(sourceChunks))
+	for i, sourceChunk := range sourceChunks {
+		key := chunkKey{sourceChunk.GetFileIdString(), sourceChunk.Offset, sourceChunk.Size}
+		if chunk, found := replicated[key]; found {
+			// the modified time decides which of the overlapping chunks is visible, the same as in the source
+			chunk.Mtime = sourceChunk.Mtime
+			matched[i] = chunk
+			delete(replicated, key)
+		}
+	}
+
+	for _, chunk := range targetChunks {
+		if _, found := replicated[chunkKey{chunk.SourceFileId, chunk.Offset, chunk.Size}]; found || chunk.SourceFileId == "" {
+			unmatched = append(unmatched, chunk)
+		}
+	}
+	return
+}
+
 func (fs *FilerSink) replicateOneChunk(sourceChunk *filer_pb.FileChunk, path string) (*filer_pb.FileChunk, error) {
 
 	fileId, err := fs.fetchAndWrite(sourceChunk, path)
@@ -65,6 +143,15 @@ func (fs *FilerSink) fetchAndWrite(sourceChunk *filer_pb.FileChunk, path string)
 	}
 	defer util.CloseResponse(resp)
 
+	var reader io.Reader = resp.Body
+	if sourceChunk.IsPacked() {
+		// only copy the small file out of its container chunk
+		if _, err = io.CopyN(io.Discard, resp.Body, sourceChunk.ContainerOffset); err != nil {
+			return "", fmt.Errorf("skip to %d in container %s: %v", sourceChunk.ContainerOffset, sourceChunk.GetFileIdString(), err)
+		}
+		reader = io.LimitReader(resp.Body, int64(sourceChunk.Size))
+	}
+
 	fileId, uploadResult, err, _ := operation.UploadWithRetry(
 		fs,
 		&filer_pb.AssignVolumeRequest{
@@ -75,23 +162,24 @@ func (fs *FilerSink) fetchAndWrite(sourceChunk *filer_pb.FileChunk, path string)
 			DataCenter:  fs.dataCenter,
 			DiskType:    fs.diskType,
 			Path:        path,
+			MaxSize:     operation.UploadMaxSize(int64(sourceChunk.Size)),
 		},
 		&operation.UploadOption{
 			Filename:          filename,
 			Cipher:            false,
-			IsInputCompressed: "gzip" == header.Get("Content-Encoding"),
+			IsInputCompressed: "gzip" == header.Get("Content-Encoding") && !sourceChunk.IsPacked(),
 			MimeType:          header.Get("Content-Type"),
 			PairMap:           nil,
 		},
 		func(host, fileId string) string {
 			fileUrl := fmt.Sprintf("http://%s/%s", host, fileId)
 			if fs.writeChunkByFiler {
-				fileUrl = fmt.Sprintf("http://%s/?proxyChunkId=%s", fs.address, fileId)
+				fileUrl = fmt.Sprintf("%s://%s/?proxyChunkId=%s", util.HttpScheme(), fs.address, fileId)
 			}
 			glog.V(4).Infof("replicating %s to %s header:%+v", filename, fileUrl, header)
 			return fileUrl
 		},
-		resp.Body,
+		reader,
 	)
 
 	if err != nil {
@@ -0,0 +1,53 @@
+package filersink
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+func TestMatchReplicatedChunks(t *testing.T) {
+	targetChunks := []*filer_pb.FileChunk{
+		{FileId: "7,01", SourceFileId: "3,01", Offset: 0, Size: 100, Mtime: 1},
+		{FileId: "7,02", SourceFileId: "3,02", Offset: 100, Size: 100, Mtime: 2},
+		{FileId: "7,03", SourceFileId: "3,03", Offset: 200, Size: 50, Mtime: 3},  // rewritten in the source
+		{FileId: "7,04", SourceFileId: "3,04", Offset: 300, Size: 100, Mtime: 4}, // deleted in the source
+		{FileId: "7,05", Offset: 400, Size: 100, Mtime: 5},                       // written on the target
+	}
+	sourceChunks := []*filer_pb.FileChunk{
+		{FileId: "3,01", Offset: 0, Size: 100, Mtime: 1},
+		{FileId: "3,02", Offset: 100, Size: 100, Mtime: 2},
+		{FileId: "3,03", Offset: 200, Size: 100, Mtime: 6}, // appended to
+		{FileId: "3,06", Offset: 300, Size: 100, Mtime: 7},
+	}
+
+	matched, unmatched := matchReplicatedChunks(targetChunks, sourceChunks)
+
+	assert.Equal(t, 4, len(matched))
+	assert.Equal(t, "7,01", matched[0].FileId)
+	assert.Equal(t, "7,02", matched[1].FileId)
+	assert.Nil(t, matched[2])
+	assert.Nil(t, matched[3])
+
+	var unmatchedFileIds []string
+	for _, chunk := range unmatched {
+		unmatchedFileIds = append(unmatchedFileIds, chunk.FileId)
+	}
+	assert.Equal(t, []string{"7,03", "7,04", "7,05"}, unmatchedFileIds)
+}
+
+func TestMatchReplicatedChunksFollowsSourceMtime(t *testing.T) {
+	targetChunks := []*filer_pb.FileChunk{
+		{FileId: "7,01", SourceFileId: "3,01", Offset: 0, Size: 100, Mtime: 1},
+	}
+	sourceChunks := []*filer_pb.FileChunk{
+		{FileId: "3,01", Offset: 0, Size: 100, Mtime: 9},
+	}
+
+	matched, unmatched := matchReplicatedChunks(targetChunks, sourceChunks)
+
+	assert.Empty(t, unmatched)
+	assert.Equal(t, int64(9), matched[0].Mtime)
+}
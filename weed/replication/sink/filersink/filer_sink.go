@@ -2,8 +2,9 @@ package filersink
 
 import (
 	"context"
+	"crypto/md5"
 	"fmt"
-	"math"
+	"io"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -15,7 +16,6 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
-	"github.com/seaweedfs/seaweedfs/weed/wdclient"
 )
 
 type FilerSink struct {
@@ -110,14 +110,16 @@ func (fs *FilerSink) CreateEntry(key string, entry *filer_pb.Entry, signatures [
 			Name:      name,
 		}
 		glog.V(1).Infof("lookup: %v", lookupRequest)
+		var existingChunks []*filer_pb.FileChunk
 		if resp, err := filer_pb.LookupEntry(client, lookupRequest); err == nil {
 			if filer.ETag(resp.Entry) == filer.ETag(entry) {
 				glog.V(3).Infof("already replicated %s", key)
 				return nil
 			}
+			existingChunks = resp.Entry.Chunks
 		}
 
-		replicatedChunks, err := fs.replicateChunks(entry.Chunks, key)
+		replicatedChunks, _, err := fs.replicateChangedChunks(existingChunks, entry.Chunks, key)
 
 		if err != nil {
 			// only warning here since the source chunk may have been deleted already
@@ -191,30 +193,20 @@ func (fs *FilerSink) UpdateEntry(key string, oldEntry *filer_pb.Entry, newParent
 		// this usually happens when retrying the replication
 		glog.V(3).Infof("already replicated %s", key)
 	} else {
-		// find out what changed
-		deletedChunks, newChunks, err := compareChunks(filer.LookupFn(fs), oldEntry, newEntry)
+		// replicate only the chunks changed in the source, comparing with the existing entry
+		// instead of the old entry of the event, so the missed or repeated events do not matter
+		chunks, unmatchedChunks, err := fs.replicateChangedChunks(existingEntry.Chunks, newEntry.Chunks, key)
 		if err != nil {
-			return true, fmt.Errorf("replicate %s compare chunks error: %v", key, err)
+			return true, fmt.Errorf("replicate %s chunks error: %v", key, err)
 		}
 
-		// delete the chunks that are deleted from the source
-		if deleteIncludeChunks {
-			// remove the deleted chunks. Actual data deletion happens in filer UpdateEntry FindUnusedFileChunks
-			existingEntry.Chunks = filer.DoMinusChunksBySourceFileId(existingEntry.Chunks, deletedChunks)
+		// keep the chunks no longer in the source unless their data is deleted there too.
+		// The actual data deletion of the dropped chunks happens in filer UpdateEntry.
+		if !deleteIncludeChunks {
+			chunks = append(unmatchedChunks, chunks...)
 		}
-
-		// replicate the chunks that are new in the source
-		replicatedChunks, err := fs.replicateChunks(newChunks, key)
-		if err != nil {
-			return true, fmt.Errorf("replicate %s chunks error: %v", key, err)
-		}
-		existingEntry.Chunks = append(existingEntry.Chunks, replicatedChunks...)
-		existingEntry.Attributes = newEntry.Attributes
-		existingEntry.Extended = newEntry.Extended
-		existingEntry.HardLinkId = newEntry.HardLinkId
-		existingEntry.HardLinkCounter = newEntry.HardLinkCounter
-		existingEntry.Content = newEntry.Content
-		existingEntry.RemoteEntry = newEntry.RemoteEntry
+		existingEntry.Chunks = chunks
+		copyEntryMetadata(existingEntry, newEntry)
 	}
 
 	// save updated meta data
@@ -235,21 +227,123 @@ func (fs *FilerSink) UpdateEntry(key string, oldEntry *filer_pb.Entry, newParent
 	})
 
 }
-func compareChunks(lookupFileIdFn wdclient.LookupFileIdFunctionType, oldEntry, newEntry *filer_pb.Entry) (deletedChunks, newChunks []*filer_pb.FileChunk, err error) {
-	aData, aMeta, aErr := filer.ResolveChunkManifest(lookupFileIdFn, oldEntry.Chunks, 0, math.MaxInt64)
-	if aErr != nil {
-		return nil, nil, aErr
+
+// UpdateEntryChunks renames the entry on the target filer if it is renamed, and replicates only the new chunks of the event.
+// A retried event finds the entry renamed, or its content replicated, already.
+func (fs *FilerSink) UpdateEntryChunks(key string, message *filer_pb.EventNotification) (foundExistingEntry bool, err error) {
+
+	newEntry := message.NewEntry
+	newKey := util.NewFullPath(message.NewParentPath, newEntry.Name)
+	isRenamed := string(newKey) != key
+
+	existingEntry, err := fs.lookupEntry(key)
+	if err == filer_pb.ErrNotFound && isRenamed {
+		if renamedEntry, lookupErr := fs.lookupEntry(string(newKey)); lookupErr == nil && filer.ETag(renamedEntry) == message.ContentHash {
+			glog.V(3).Infof("already replicated %s => %s", key, newKey)
+			return true, nil
+		}
 	}
-	bData, bMeta, bErr := filer.ResolveChunkManifest(lookupFileIdFn, newEntry.Chunks, 0, math.MaxInt64)
-	if bErr != nil {
-		return nil, nil, bErr
+	if err != nil {
+		return false, fmt.Errorf("lookup %s: %v", key, err)
 	}
 
-	deletedChunks = append(deletedChunks, filer.DoMinusChunks(aData, bData)...)
-	deletedChunks = append(deletedChunks, filer.DoMinusChunks(aMeta, bMeta)...)
+	if isRenamed {
+		oldDir, oldName := util.FullPath(key).DirAndName()
+		err = fs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+			_, renameErr := client.AtomicRenameEntry(context.Background(), &filer_pb.AtomicRenameEntryRequest{
+				OldDirectory: oldDir,
+				OldName:      oldName,
+				NewDirectory: message.NewParentPath,
+				NewName:      newEntry.Name,
+				Signatures:   message.Signatures,
+			})
+			return renameErr
+		})
+		if err != nil {
+			return true, fmt.Errorf("rename %s => %s: %v", key, newKey, err)
+		}
+		existingEntry.Name = newEntry.Name
+	}
 
-	newChunks = append(newChunks, filer.DoMinusChunks(bData, aData)...)
-	newChunks = append(newChunks, filer.DoMinusChunks(bMeta, aMeta)...)
+	if existingEntry.Attributes.Mtime > newEntry.Attributes.Mtime {
+		// skip if already changed
+		glog.V(2).Infof("late updates %s", newKey)
+	} else if filer.ETag(existingEntry) == message.ContentHash {
+		// the content is not changed, or already replicated
+		glog.V(3).Infof("already replicated %s", newKey)
+		copyEntryMetadata(existingEntry, newEntry)
+	} else {
+		if message.DeleteChunks {
+			existingEntry.Chunks = filer.DoMinusChunksBySourceFileId(existingEntry.Chunks, message.DeletedChunks)
+		}
+		replicatedChunks, err := fs.replicateChunks(message.NewChunks, string(newKey))
+		if err != nil {
+			return true, fmt.Errorf("replicate %s chunks error: %v", newKey, err)
+		}
+		existingEntry.Chunks = append(existingEntry.Chunks, replicatedChunks...)
+		copyEntryMetadata(existingEntry, newEntry)
+	}
 
+	return true, fs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		request := &filer_pb.UpdateEntryRequest{
+			Directory:          message.NewParentPath,
+			Entry:              existingEntry,
+			IsFromOtherCluster: true,
+			Signatures:         message.Signatures,
+		}
+		if _, err := client.UpdateEntry(context.Background(), request); err != nil {
+			return fmt.Errorf("update existingEntry %s: %v", newKey, err)
+		}
+		return nil
+	})
+}
+
+func (fs *FilerSink) lookupEntry(key string) (entry *filer_pb.Entry, err error) {
+	dir, name := util.FullPath(key).DirAndName()
+	err = fs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, lookupErr := filer_pb.LookupEntry(client, &filer_pb.LookupDirectoryEntryRequest{
+			Directory: dir,
+			Name:      name,
+		})
+		if lookupErr != nil {
+			return lookupErr
+		}
+		entry = resp.Entry
+		return nil
+	})
 	return
 }
+
+func copyEntryMetadata(existingEntry, newEntry *filer_pb.Entry) {
+	existingEntry.Attributes = newEntry.Attributes
+	existingEntry.Extended = newEntry.Extended
+	existingEntry.HardLinkId = newEntry.HardLinkId
+	existingEntry.HardLinkCounter = newEntry.HardLinkCounter
+	existingEntry.Content = newEntry.Content
+	existingEntry.RemoteEntry = newEntry.RemoteEntry
+}
+
+// GetEntryInfo reads back the target content to hash it, since the attributes and the chunk etags
+// of the target entry are copied from the source.
+func (fs *FilerSink) GetEntryInfo(key string) (info *sink.EntryInfo, err error) {
+	entry, err := fs.lookupEntry(key)
+	if err == filer_pb.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	info = &sink.EntryInfo{
+		Size: int64(filer.FileSize(entry)),
+	}
+	if entry.RemoteEntry != nil && len(entry.Chunks) == 0 {
+		// the content is only in the remote storage
+		return info, nil
+	}
+	hash := md5.New()
+	if _, err = io.Copy(hash, filer.NewFileReader(fs, entry)); err != nil {
+		return nil, fmt.Errorf("read %s: %v", key, err)
+	}
+	info.Md5 = hash.Sum(nil)
+	return info, nil
+}
@@ -1,6 +1,8 @@
 package localsink
 
 import (
+	"crypto/md5"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -156,3 +158,24 @@ func (localsink *LocalSink) UpdateEntry(key string, oldEntry *filer_pb.Entry, ne
 	err = localsink.CreateEntry(key, newEntry, signatures)
 	return
 }
+
+func (localsink *LocalSink) GetEntryInfo(key string) (*sink.EntryInfo, error) {
+	f, err := os.Open(key)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hash := md5.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return nil, err
+	}
+	return &sink.EntryInfo{
+		Size: size,
+		Md5:  hash.Sum(nil),
+	}, nil
+}
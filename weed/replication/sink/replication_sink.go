@@ -1,8 +1,8 @@
 package sink
 
 import (
-	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/replication/source"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
@@ -17,6 +17,32 @@ type ReplicationSink interface {
 	IsIncremental() bool
 }
 
+// EntryInfo is what a sink has stored for an entry.
+type EntryInfo struct {
+	Size int64
+	Md5  []byte // empty if not known
+}
+
+// VerifiableSink reads back the entries it has written, to verify the replica.
+// The entry info is nil if the entry is not found.
+type VerifiableSink interface {
+	GetEntryInfo(key string) (*EntryInfo, error)
+}
+
+// ChunkChangeSink updates an entry, renamed or not, with the chunk changes of the event,
+// replicating only the new chunks, and skipping the content already replicated.
+type ChunkChangeSink interface {
+	UpdateEntryChunks(key string, message *filer_pb.EventNotification) (foundExistingEntry bool, err error)
+}
+
+// UpdateEntry updates the entry at the key with the event, using its chunk changes if the sink supports them.
+func UpdateEntry(dataSink ReplicationSink, key string, message *filer_pb.EventNotification) (foundExistingEntry bool, err error) {
+	if chunkChangeSink, ok := dataSink.(ChunkChangeSink); ok && message.ContentHash != "" {
+		return chunkChangeSink.UpdateEntryChunks(key, message)
+	}
+	return dataSink.UpdateEntry(key, message.OldEntry, message.NewParentPath, message.NewEntry, message.DeleteChunks, message.Signatures)
+}
+
 var (
 	Sinks []ReplicationSink
 )
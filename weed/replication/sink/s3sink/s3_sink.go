@@ -1,8 +1,10 @@
 package S3Sink
 
 import (
+	"encoding/hex"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -12,9 +14,9 @@ import (
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
-	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/replication/sink"
 	"github.com/seaweedfs/seaweedfs/weed/replication/source"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
@@ -169,6 +171,31 @@ func (s3sink *S3Sink) UpdateEntry(key string, oldEntry *filer_pb.Entry, newParen
 	return true, s3sink.CreateEntry(key, newEntry, signatures)
 }
 
+func (s3sink *S3Sink) GetEntryInfo(key string) (*sink.EntryInfo, error) {
+	key = cleanKey(key)
+
+	result, err := s3sink.conn.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s3sink.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && (awsErr.Code() == "NotFound" || awsErr.Code() == s3.ErrCodeNoSuchKey) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	info := &sink.EntryInfo{
+		Size: aws.Int64Value(result.ContentLength),
+	}
+	// the etag of a multipart upload is not the md5 of the content
+	etag := strings.Trim(aws.StringValue(result.ETag), "\"")
+	if md5, decodeErr := hex.DecodeString(etag); decodeErr == nil && len(md5) == 16 {
+		info.Md5 = md5
+	}
+	return info, nil
+}
+
 func cleanKey(key string) string {
 	if strings.HasPrefix(key, "/") {
 		key = key[1:]
@@ -94,7 +94,7 @@ func (fs *FilerSource) LookupFileId(part string) (fileUrls []string, err error)
 			}
 		}
 	} else {
-		fileUrls = append(fileUrls, fmt.Sprintf("http://%s/?proxyChunkId=%s", fs.address, part))
+		fileUrls = append(fileUrls, fmt.Sprintf("%s://%s/?proxyChunkId=%s", util.HttpScheme(), fs.address, part))
 	}
 
 	return
@@ -103,7 +103,7 @@ func (fs *FilerSource) LookupFileId(part string) (fileUrls []string, err error)
 func (fs *FilerSource) ReadPart(fileId string) (filename string, header http.Header, resp *http.Response, err error) {
 
 	if fs.proxyByFiler {
-		return util.DownloadFile("http://"+fs.address+"/?proxyChunkId="+fileId, "")
+		return util.DownloadFile(util.HttpScheme()+"://"+fs.address+"/?proxyChunkId="+fileId, "")
 	}
 
 	fileUrls, err := fs.LookupFileId(fileId)
@@ -0,0 +1,129 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	SyncKeyPrefix = "sync."
+	// SyncOffsetsKey lists the filer.sync offsets kept on a target filer
+	SyncOffsetsKey = "sync.offsets"
+)
+
+// SyncSignaturePrefixByPath separates the offsets of the filer.sync processes syncing different paths.
+func SyncSignaturePrefixByPath(path string) string {
+	// compatible historical version
+	if path == "/" {
+		return SyncKeyPrefix
+	}
+	return SyncKeyPrefix + path
+}
+
+// SyncOffsetKey is the KV key of the offset of the source filer signature.
+func SyncOffsetKey(signaturePrefix string, signature int32) []byte {
+	syncKey := []byte(signaturePrefix + "____")
+	util.Uint32toBytes(syncKey[len(signaturePrefix):len(signaturePrefix)+4], uint32(signature))
+	return syncKey
+}
+
+// GetSyncOffset reads the offset in ns, and 0 if there is no offset.
+func GetSyncOffset(client filer_pb.SeaweedFilerClient, syncKey []byte) (offsetTsNs int64, err error) {
+	resp, err := client.KvGet(context.Background(), &filer_pb.KvGetRequest{Key: syncKey})
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Error) != 0 {
+		return 0, errors.New(resp.Error)
+	}
+	if len(resp.Value) < 8 {
+		return 0, nil
+	}
+	return int64(util.BytesToUint64(resp.Value)), nil
+}
+
+func SetSyncOffset(client filer_pb.SeaweedFilerClient, syncKey []byte, offsetTsNs int64) error {
+	valueBuf := make([]byte, 8)
+	util.Uint64toBytes(valueBuf, uint64(offsetTsNs))
+	return kvPut(client, syncKey, valueBuf)
+}
+
+func DeleteSyncOffset(client filer_pb.SeaweedFilerClient, syncKey []byte) error {
+	return kvPut(client, syncKey, nil)
+}
+
+// ListSyncOffsets lists the filer.sync offsets registered on the filer.
+func ListSyncOffsets(client filer_pb.SeaweedFilerClient) ([]*filer_pb.FilerSyncOffset, error) {
+	resp, err := client.KvGet(context.Background(), &filer_pb.KvGetRequest{Key: []byte(SyncOffsetsKey)})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Error) != 0 {
+		return nil, errors.New(resp.Error)
+	}
+	offsets := &filer_pb.FilerSyncOffsets{}
+	if err = proto.Unmarshal(resp.Value, offsets); err != nil {
+		return nil, err
+	}
+	return offsets.Offsets, nil
+}
+
+// RegisterSyncOffset adds or replaces the offset of the same source signature and path.
+func RegisterSyncOffset(client filer_pb.SeaweedFilerClient, offset *filer_pb.FilerSyncOffset) error {
+	offsets, err := ListSyncOffsets(client)
+	if err != nil {
+		return err
+	}
+	offset.RegisteredAtNs = time.Now().UnixNano()
+	offsets = append(removeSyncOffset(offsets, offset.SourceSignature, offset.SourcePath), offset)
+	return saveSyncOffsets(client, offsets)
+}
+
+func UnregisterSyncOffset(client filer_pb.SeaweedFilerClient, signature int32, sourcePath string) error {
+	offsets, err := ListSyncOffsets(client)
+	if err != nil {
+		return err
+	}
+	return saveSyncOffsets(client, removeSyncOffset(offsets, signature, sourcePath))
+}
+
+func removeSyncOffset(offsets []*filer_pb.FilerSyncOffset, signature int32, sourcePath string) (kept []*filer_pb.FilerSyncOffset) {
+	for _, offset := range offsets {
+		if offset.SourceSignature == signature && offset.SourcePath == sourcePath {
+			continue
+		}
+		kept = append(kept, offset)
+	}
+	return
+}
+
+func saveSyncOffsets(client filer_pb.SeaweedFilerClient, offsets []*filer_pb.FilerSyncOffset) error {
+	if len(offsets) == 0 {
+		return kvPut(client, []byte(SyncOffsetsKey), nil)
+	}
+	value, err := proto.Marshal(&filer_pb.FilerSyncOffsets{Offsets: offsets})
+	if err != nil {
+		return err
+	}
+	return kvPut(client, []byte(SyncOffsetsKey), value)
+}
+
+func kvPut(client filer_pb.SeaweedFilerClient, key, value []byte) error {
+	resp, err := client.KvPut(context.Background(), &filer_pb.KvPutRequest{
+		Key:   key,
+		Value: value,
+	})
+	if err != nil {
+		return err
+	}
+	if len(resp.Error) != 0 {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package replication
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+func TestSyncOffsetKey(t *testing.T) {
+	// keep the keys written by earlier filer.sync versions
+	if key := SyncOffsetKey(SyncSignaturePrefixByPath("/"), 0x01020304); !bytes.Equal(key, []byte("sync.\x01\x02\x03\x04")) {
+		t.Errorf("unexpected key %q", key)
+	}
+	if key := SyncOffsetKey(SyncSignaturePrefixByPath("/buckets"), 1); !bytes.Equal(key, []byte("sync./buckets\x00\x00\x00\x01")) {
+		t.Errorf("unexpected key %q", key)
+	}
+}
+
+func TestRemoveSyncOffset(t *testing.T) {
+	offsets := []*filer_pb.FilerSyncOffset{
+		{SourceSignature: 1, SourcePath: "/"},
+		{SourceSignature: 1, SourcePath: "/buckets"},
+		{SourceSignature: 2, SourcePath: "/"},
+	}
+	kept := removeSyncOffset(offsets, 1, "/")
+	if len(kept) != 2 || kept[0].SourcePath != "/buckets" || kept[1].SourceSignature != 2 {
+		t.Errorf("unexpected offsets %+v", kept)
+	}
+	if kept = removeSyncOffset(offsets, 3, "/"); len(kept) != 3 {
+		t.Errorf("unexpected offsets %+v", kept)
+	}
+}
@@ -242,6 +242,11 @@ func DoMkdir(client SeaweedFilerClient, parentDirectoryPath string, dirName stri
 }
 
 func MkFile(filerClient FilerClient, parentDirectoryPath string, fileName string, chunks []*FileChunk, fn func(entry *Entry)) error {
+	return MkFileWithCondition(filerClient, parentDirectoryPath, fileName, chunks, "", "", fn)
+}
+
+// MkFileWithCondition creates the file only if the existing file matches the If-Match and If-None-Match etags, when set.
+func MkFileWithCondition(filerClient FilerClient, parentDirectoryPath string, fileName string, chunks []*FileChunk, ifMatch, ifNoneMatch string, fn func(entry *Entry)) error {
 	return filerClient.WithFilerClient(false, func(client SeaweedFilerClient) error {
 
 		entry := &Entry{
@@ -262,8 +267,10 @@ func MkFile(filerClient FilerClient, parentDirectoryPath string, fileName string
 		}
 
 		request := &CreateEntryRequest{
-			Directory: parentDirectoryPath,
-			Entry:     entry,
+			Directory:   parentDirectoryPath,
+			Entry:       entry,
+			IfMatch:     ifMatch,
+			IfNoneMatch: ifNoneMatch,
 		}
 
 		glog.V(1).Infof("create file: %s/%s", parentDirectoryPath, fileName)
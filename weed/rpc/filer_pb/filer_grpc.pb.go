@@ -28,6 +28,7 @@ type SeaweedFilerClient interface {
 	UpdateEntry(ctx context.Context, in *UpdateEntryRequest, opts ...grpc.CallOption) (*UpdateEntryResponse, error)
 	AppendToEntry(ctx context.Context, in *AppendToEntryRequest, opts ...grpc.CallOption) (*AppendToEntryResponse, error)
 	DeleteEntry(ctx context.Context, in *DeleteEntryRequest, opts ...grpc.CallOption) (*DeleteEntryResponse, error)
+	StreamDeleteEntry(ctx context.Context, in *StreamDeleteEntryRequest, opts ...grpc.CallOption) (SeaweedFiler_StreamDeleteEntryClient, error)
 	AtomicRenameEntry(ctx context.Context, in *AtomicRenameEntryRequest, opts ...grpc.CallOption) (*AtomicRenameEntryResponse, error)
 	StreamRenameEntry(ctx context.Context, in *StreamRenameEntryRequest, opts ...grpc.CallOption) (SeaweedFiler_StreamRenameEntryClient, error)
 	AssignVolume(ctx context.Context, in *AssignVolumeRequest, opts ...grpc.CallOption) (*AssignVolumeResponse, error)
@@ -36,12 +37,24 @@ type SeaweedFilerClient interface {
 	DeleteCollection(ctx context.Context, in *DeleteCollectionRequest, opts ...grpc.CallOption) (*DeleteCollectionResponse, error)
 	Statistics(ctx context.Context, in *StatisticsRequest, opts ...grpc.CallOption) (*StatisticsResponse, error)
 	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error)
+	SetReadOnly(ctx context.Context, in *SetReadOnlyRequest, opts ...grpc.CallOption) (*SetReadOnlyResponse, error)
 	GetFilerConfiguration(ctx context.Context, in *GetFilerConfigurationRequest, opts ...grpc.CallOption) (*GetFilerConfigurationResponse, error)
 	SubscribeMetadata(ctx context.Context, in *SubscribeMetadataRequest, opts ...grpc.CallOption) (SeaweedFiler_SubscribeMetadataClient, error)
 	SubscribeLocalMetadata(ctx context.Context, in *SubscribeMetadataRequest, opts ...grpc.CallOption) (SeaweedFiler_SubscribeLocalMetadataClient, error)
+	AckLocalMetadata(ctx context.Context, in *AckLocalMetadataRequest, opts ...grpc.CallOption) (*AckLocalMetadataResponse, error)
 	KvGet(ctx context.Context, in *KvGetRequest, opts ...grpc.CallOption) (*KvGetResponse, error)
 	KvPut(ctx context.Context, in *KvPutRequest, opts ...grpc.CallOption) (*KvPutResponse, error)
+	KvList(ctx context.Context, in *KvListRequest, opts ...grpc.CallOption) (*KvListResponse, error)
+	KvDelete(ctx context.Context, in *KvDeleteRequest, opts ...grpc.CallOption) (*KvDeleteResponse, error)
+	KvUsage(ctx context.Context, in *KvUsageRequest, opts ...grpc.CallOption) (*KvUsageResponse, error)
+	VerifyMetadata(ctx context.Context, in *VerifyMetadataRequest, opts ...grpc.CallOption) (*VerifyMetadataResponse, error)
 	CacheRemoteObjectToLocalCluster(ctx context.Context, in *CacheRemoteObjectToLocalClusterRequest, opts ...grpc.CallOption) (*CacheRemoteObjectToLocalClusterResponse, error)
+	RemoteCacheStatistics(ctx context.Context, in *RemoteCacheStatisticsRequest, opts ...grpc.CallOption) (*RemoteCacheStatisticsResponse, error)
+	AcquireAdvisoryLock(ctx context.Context, in *AcquireAdvisoryLockRequest, opts ...grpc.CallOption) (*AcquireAdvisoryLockResponse, error)
+	ReleaseAdvisoryLock(ctx context.Context, in *ReleaseAdvisoryLockRequest, opts ...grpc.CallOption) (*ReleaseAdvisoryLockResponse, error)
+	RenewAdvisoryLocks(ctx context.Context, in *RenewAdvisoryLocksRequest, opts ...grpc.CallOption) (*RenewAdvisoryLocksResponse, error)
+	SlowRequests(ctx context.Context, in *SlowRequestsRequest, opts ...grpc.CallOption) (*SlowRequestsResponse, error)
 }
 
 type seaweedFilerClient struct {
@@ -129,6 +142,38 @@ func (c *seaweedFilerClient) DeleteEntry(ctx context.Context, in *DeleteEntryReq
 	return out, nil
 }
 
+func (c *seaweedFilerClient) StreamDeleteEntry(ctx context.Context, in *StreamDeleteEntryRequest, opts ...grpc.CallOption) (SeaweedFiler_StreamDeleteEntryClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SeaweedFiler_ServiceDesc.Streams[1], "/filer_pb.SeaweedFiler/StreamDeleteEntry", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &seaweedFilerStreamDeleteEntryClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SeaweedFiler_StreamDeleteEntryClient interface {
+	Recv() (*StreamDeleteEntryResponse, error)
+	grpc.ClientStream
+}
+
+type seaweedFilerStreamDeleteEntryClient struct {
+	grpc.ClientStream
+}
+
+func (x *seaweedFilerStreamDeleteEntryClient) Recv() (*StreamDeleteEntryResponse, error) {
+	m := new(StreamDeleteEntryResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *seaweedFilerClient) AtomicRenameEntry(ctx context.Context, in *AtomicRenameEntryRequest, opts ...grpc.CallOption) (*AtomicRenameEntryResponse, error) {
 	out := new(AtomicRenameEntryResponse)
 	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/AtomicRenameEntry", in, out, opts...)
@@ -139,7 +184,7 @@ func (c *seaweedFilerClient) AtomicRenameEntry(ctx context.Context, in *AtomicRe
 }
 
 func (c *seaweedFilerClient) StreamRenameEntry(ctx context.Context, in *StreamRenameEntryRequest, opts ...grpc.CallOption) (SeaweedFiler_StreamRenameEntryClient, error) {
-	stream, err := c.cc.NewStream(ctx, &SeaweedFiler_ServiceDesc.Streams[1], "/filer_pb.SeaweedFiler/StreamRenameEntry", opts...)
+	stream, err := c.cc.NewStream(ctx, &SeaweedFiler_ServiceDesc.Streams[2], "/filer_pb.SeaweedFiler/StreamRenameEntry", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -224,6 +269,24 @@ func (c *seaweedFilerClient) Ping(ctx context.Context, in *PingRequest, opts ...
 	return out, nil
 }
 
+func (c *seaweedFilerClient) SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error) {
+	out := new(SetLogLevelResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/SetLogLevel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) SetReadOnly(ctx context.Context, in *SetReadOnlyRequest, opts ...grpc.CallOption) (*SetReadOnlyResponse, error) {
+	out := new(SetReadOnlyResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/SetReadOnly", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *seaweedFilerClient) GetFilerConfiguration(ctx context.Context, in *GetFilerConfigurationRequest, opts ...grpc.CallOption) (*GetFilerConfigurationResponse, error) {
 	out := new(GetFilerConfigurationResponse)
 	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/GetFilerConfiguration", in, out, opts...)
@@ -234,7 +297,7 @@ func (c *seaweedFilerClient) GetFilerConfiguration(ctx context.Context, in *GetF
 }
 
 func (c *seaweedFilerClient) SubscribeMetadata(ctx context.Context, in *SubscribeMetadataRequest, opts ...grpc.CallOption) (SeaweedFiler_SubscribeMetadataClient, error) {
-	stream, err := c.cc.NewStream(ctx, &SeaweedFiler_ServiceDesc.Streams[2], "/filer_pb.SeaweedFiler/SubscribeMetadata", opts...)
+	stream, err := c.cc.NewStream(ctx, &SeaweedFiler_ServiceDesc.Streams[3], "/filer_pb.SeaweedFiler/SubscribeMetadata", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -266,7 +329,7 @@ func (x *seaweedFilerSubscribeMetadataClient) Recv() (*SubscribeMetadataResponse
 }
 
 func (c *seaweedFilerClient) SubscribeLocalMetadata(ctx context.Context, in *SubscribeMetadataRequest, opts ...grpc.CallOption) (SeaweedFiler_SubscribeLocalMetadataClient, error) {
-	stream, err := c.cc.NewStream(ctx, &SeaweedFiler_ServiceDesc.Streams[3], "/filer_pb.SeaweedFiler/SubscribeLocalMetadata", opts...)
+	stream, err := c.cc.NewStream(ctx, &SeaweedFiler_ServiceDesc.Streams[4], "/filer_pb.SeaweedFiler/SubscribeLocalMetadata", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -297,6 +360,15 @@ func (x *seaweedFilerSubscribeLocalMetadataClient) Recv() (*SubscribeMetadataRes
 	return m, nil
 }
 
+func (c *seaweedFilerClient) AckLocalMetadata(ctx context.Context, in *AckLocalMetadataRequest, opts ...grpc.CallOption) (*AckLocalMetadataResponse, error) {
+	out := new(AckLocalMetadataResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/AckLocalMetadata", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *seaweedFilerClient) KvGet(ctx context.Context, in *KvGetRequest, opts ...grpc.CallOption) (*KvGetResponse, error) {
 	out := new(KvGetResponse)
 	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/KvGet", in, out, opts...)
@@ -315,6 +387,42 @@ func (c *seaweedFilerClient) KvPut(ctx context.Context, in *KvPutRequest, opts .
 	return out, nil
 }
 
+func (c *seaweedFilerClient) KvList(ctx context.Context, in *KvListRequest, opts ...grpc.CallOption) (*KvListResponse, error) {
+	out := new(KvListResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/KvList", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) KvDelete(ctx context.Context, in *KvDeleteRequest, opts ...grpc.CallOption) (*KvDeleteResponse, error) {
+	out := new(KvDeleteResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/KvDelete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) KvUsage(ctx context.Context, in *KvUsageRequest, opts ...grpc.CallOption) (*KvUsageResponse, error) {
+	out := new(KvUsageResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/KvUsage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) VerifyMetadata(ctx context.Context, in *VerifyMetadataRequest, opts ...grpc.CallOption) (*VerifyMetadataResponse, error) {
+	out := new(VerifyMetadataResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/VerifyMetadata", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *seaweedFilerClient) CacheRemoteObjectToLocalCluster(ctx context.Context, in *CacheRemoteObjectToLocalClusterRequest, opts ...grpc.CallOption) (*CacheRemoteObjectToLocalClusterResponse, error) {
 	out := new(CacheRemoteObjectToLocalClusterResponse)
 	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/CacheRemoteObjectToLocalCluster", in, out, opts...)
@@ -324,6 +432,51 @@ func (c *seaweedFilerClient) CacheRemoteObjectToLocalCluster(ctx context.Context
 	return out, nil
 }
 
+func (c *seaweedFilerClient) RemoteCacheStatistics(ctx context.Context, in *RemoteCacheStatisticsRequest, opts ...grpc.CallOption) (*RemoteCacheStatisticsResponse, error) {
+	out := new(RemoteCacheStatisticsResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/RemoteCacheStatistics", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) AcquireAdvisoryLock(ctx context.Context, in *AcquireAdvisoryLockRequest, opts ...grpc.CallOption) (*AcquireAdvisoryLockResponse, error) {
+	out := new(AcquireAdvisoryLockResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/AcquireAdvisoryLock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) ReleaseAdvisoryLock(ctx context.Context, in *ReleaseAdvisoryLockRequest, opts ...grpc.CallOption) (*ReleaseAdvisoryLockResponse, error) {
+	out := new(ReleaseAdvisoryLockResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/ReleaseAdvisoryLock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) RenewAdvisoryLocks(ctx context.Context, in *RenewAdvisoryLocksRequest, opts ...grpc.CallOption) (*RenewAdvisoryLocksResponse, error) {
+	out := new(RenewAdvisoryLocksResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/RenewAdvisoryLocks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) SlowRequests(ctx context.Context, in *SlowRequestsRequest, opts ...grpc.CallOption) (*SlowRequestsResponse, error) {
+	out := new(SlowRequestsResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/SlowRequests", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SeaweedFilerServer is the server API for SeaweedFiler service.
 // All implementations must embed UnimplementedSeaweedFilerServer
 // for forward compatibility
@@ -334,6 +487,7 @@ type SeaweedFilerServer interface {
 	UpdateEntry(context.Context, *UpdateEntryRequest) (*UpdateEntryResponse, error)
 	AppendToEntry(context.Context, *AppendToEntryRequest) (*AppendToEntryResponse, error)
 	DeleteEntry(context.Context, *DeleteEntryRequest) (*DeleteEntryResponse, error)
+	StreamDeleteEntry(*StreamDeleteEntryRequest, SeaweedFiler_StreamDeleteEntryServer) error
 	AtomicRenameEntry(context.Context, *AtomicRenameEntryRequest) (*AtomicRenameEntryResponse, error)
 	StreamRenameEntry(*StreamRenameEntryRequest, SeaweedFiler_StreamRenameEntryServer) error
 	AssignVolume(context.Context, *AssignVolumeRequest) (*AssignVolumeResponse, error)
@@ -342,12 +496,24 @@ type SeaweedFilerServer interface {
 	DeleteCollection(context.Context, *DeleteCollectionRequest) (*DeleteCollectionResponse, error)
 	Statistics(context.Context, *StatisticsRequest) (*StatisticsResponse, error)
 	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error)
+	SetReadOnly(context.Context, *SetReadOnlyRequest) (*SetReadOnlyResponse, error)
 	GetFilerConfiguration(context.Context, *GetFilerConfigurationRequest) (*GetFilerConfigurationResponse, error)
 	SubscribeMetadata(*SubscribeMetadataRequest, SeaweedFiler_SubscribeMetadataServer) error
 	SubscribeLocalMetadata(*SubscribeMetadataRequest, SeaweedFiler_SubscribeLocalMetadataServer) error
+	AckLocalMetadata(context.Context, *AckLocalMetadataRequest) (*AckLocalMetadataResponse, error)
 	KvGet(context.Context, *KvGetRequest) (*KvGetResponse, error)
 	KvPut(context.Context, *KvPutRequest) (*KvPutResponse, error)
+	KvList(context.Context, *KvListRequest) (*KvListResponse, error)
+	KvDelete(context.Context, *KvDeleteRequest) (*KvDeleteResponse, error)
+	KvUsage(context.Context, *KvUsageRequest) (*KvUsageResponse, error)
+	VerifyMetadata(context.Context, *VerifyMetadataRequest) (*VerifyMetadataResponse, error)
 	CacheRemoteObjectToLocalCluster(context.Context, *CacheRemoteObjectToLocalClusterRequest) (*CacheRemoteObjectToLocalClusterResponse, error)
+	RemoteCacheStatistics(context.Context, *RemoteCacheStatisticsRequest) (*RemoteCacheStatisticsResponse, error)
+	AcquireAdvisoryLock(context.Context, *AcquireAdvisoryLockRequest) (*AcquireAdvisoryLockResponse, error)
+	ReleaseAdvisoryLock(context.Context, *ReleaseAdvisoryLockRequest) (*ReleaseAdvisoryLockResponse, error)
+	RenewAdvisoryLocks(context.Context, *RenewAdvisoryLocksRequest) (*RenewAdvisoryLocksResponse, error)
+	SlowRequests(context.Context, *SlowRequestsRequest) (*SlowRequestsResponse, error)
 	mustEmbedUnimplementedSeaweedFilerServer()
 }
 
@@ -373,6 +539,9 @@ func (UnimplementedSeaweedFilerServer) AppendToEntry(context.Context, *AppendToE
 func (UnimplementedSeaweedFilerServer) DeleteEntry(context.Context, *DeleteEntryRequest) (*DeleteEntryResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteEntry not implemented")
 }
+func (UnimplementedSeaweedFilerServer) StreamDeleteEntry(*StreamDeleteEntryRequest, SeaweedFiler_StreamDeleteEntryServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamDeleteEntry not implemented")
+}
 func (UnimplementedSeaweedFilerServer) AtomicRenameEntry(context.Context, *AtomicRenameEntryRequest) (*AtomicRenameEntryResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AtomicRenameEntry not implemented")
 }
@@ -397,6 +566,12 @@ func (UnimplementedSeaweedFilerServer) Statistics(context.Context, *StatisticsRe
 func (UnimplementedSeaweedFilerServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
 }
+func (UnimplementedSeaweedFilerServer) SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
+}
+func (UnimplementedSeaweedFilerServer) SetReadOnly(context.Context, *SetReadOnlyRequest) (*SetReadOnlyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetReadOnly not implemented")
+}
 func (UnimplementedSeaweedFilerServer) GetFilerConfiguration(context.Context, *GetFilerConfigurationRequest) (*GetFilerConfigurationResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetFilerConfiguration not implemented")
 }
@@ -406,15 +581,45 @@ func (UnimplementedSeaweedFilerServer) SubscribeMetadata(*SubscribeMetadataReque
 func (UnimplementedSeaweedFilerServer) SubscribeLocalMetadata(*SubscribeMetadataRequest, SeaweedFiler_SubscribeLocalMetadataServer) error {
 	return status.Errorf(codes.Unimplemented, "method SubscribeLocalMetadata not implemented")
 }
+func (UnimplementedSeaweedFilerServer) AckLocalMetadata(context.Context, *AckLocalMetadataRequest) (*AckLocalMetadataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AckLocalMetadata not implemented")
+}
 func (UnimplementedSeaweedFilerServer) KvGet(context.Context, *KvGetRequest) (*KvGetResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method KvGet not implemented")
 }
 func (UnimplementedSeaweedFilerServer) KvPut(context.Context, *KvPutRequest) (*KvPutResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method KvPut not implemented")
 }
+func (UnimplementedSeaweedFilerServer) KvList(context.Context, *KvListRequest) (*KvListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KvList not implemented")
+}
+func (UnimplementedSeaweedFilerServer) KvDelete(context.Context, *KvDeleteRequest) (*KvDeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KvDelete not implemented")
+}
+func (UnimplementedSeaweedFilerServer) KvUsage(context.Context, *KvUsageRequest) (*KvUsageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KvUsage not implemented")
+}
+func (UnimplementedSeaweedFilerServer) VerifyMetadata(context.Context, *VerifyMetadataRequest) (*VerifyMetadataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyMetadata not implemented")
+}
 func (UnimplementedSeaweedFilerServer) CacheRemoteObjectToLocalCluster(context.Context, *CacheRemoteObjectToLocalClusterRequest) (*CacheRemoteObjectToLocalClusterResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CacheRemoteObjectToLocalCluster not implemented")
 }
+func (UnimplementedSeaweedFilerServer) RemoteCacheStatistics(context.Context, *RemoteCacheStatisticsRequest) (*RemoteCacheStatisticsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoteCacheStatistics not implemented")
+}
+func (UnimplementedSeaweedFilerServer) AcquireAdvisoryLock(context.Context, *AcquireAdvisoryLockRequest) (*AcquireAdvisoryLockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcquireAdvisoryLock not implemented")
+}
+func (UnimplementedSeaweedFilerServer) ReleaseAdvisoryLock(context.Context, *ReleaseAdvisoryLockRequest) (*ReleaseAdvisoryLockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseAdvisoryLock not implemented")
+}
+func (UnimplementedSeaweedFilerServer) RenewAdvisoryLocks(context.Context, *RenewAdvisoryLocksRequest) (*RenewAdvisoryLocksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenewAdvisoryLocks not implemented")
+}
+func (UnimplementedSeaweedFilerServer) SlowRequests(context.Context, *SlowRequestsRequest) (*SlowRequestsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SlowRequests not implemented")
+}
 func (UnimplementedSeaweedFilerServer) mustEmbedUnimplementedSeaweedFilerServer() {}
 
 // UnsafeSeaweedFilerServer may be embedded to opt out of forward compatibility for this service.
@@ -539,6 +744,27 @@ func _SeaweedFiler_DeleteEntry_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SeaweedFiler_StreamDeleteEntry_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamDeleteEntryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SeaweedFilerServer).StreamDeleteEntry(m, &seaweedFilerStreamDeleteEntryServer{stream})
+}
+
+type SeaweedFiler_StreamDeleteEntryServer interface {
+	Send(*StreamDeleteEntryResponse) error
+	grpc.ServerStream
+}
+
+type seaweedFilerStreamDeleteEntryServer struct {
+	grpc.ServerStream
+}
+
+func (x *seaweedFilerStreamDeleteEntryServer) Send(m *StreamDeleteEntryResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _SeaweedFiler_AtomicRenameEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(AtomicRenameEntryRequest)
 	if err := dec(in); err != nil {
@@ -686,6 +912,42 @@ func _SeaweedFiler_Ping_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SeaweedFiler_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLogLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).SetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/SetLogLevel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).SetLogLevel(ctx, req.(*SetLogLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_SetReadOnly_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetReadOnlyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).SetReadOnly(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/SetReadOnly",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).SetReadOnly(ctx, req.(*SetReadOnlyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _SeaweedFiler_GetFilerConfiguration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetFilerConfigurationRequest)
 	if err := dec(in); err != nil {
@@ -746,6 +1008,24 @@ func (x *seaweedFilerSubscribeLocalMetadataServer) Send(m *SubscribeMetadataResp
 	return x.ServerStream.SendMsg(m)
 }
 
+func _SeaweedFiler_AckLocalMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AckLocalMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).AckLocalMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/AckLocalMetadata",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).AckLocalMetadata(ctx, req.(*AckLocalMetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _SeaweedFiler_KvGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(KvGetRequest)
 	if err := dec(in); err != nil {
@@ -782,6 +1062,78 @@ func _SeaweedFiler_KvPut_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SeaweedFiler_KvList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KvListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).KvList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/KvList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).KvList(ctx, req.(*KvListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_KvDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KvDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).KvDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/KvDelete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).KvDelete(ctx, req.(*KvDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_KvUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KvUsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).KvUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/KvUsage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).KvUsage(ctx, req.(*KvUsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_VerifyMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).VerifyMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/VerifyMetadata",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).VerifyMetadata(ctx, req.(*VerifyMetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _SeaweedFiler_CacheRemoteObjectToLocalCluster_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CacheRemoteObjectToLocalClusterRequest)
 	if err := dec(in); err != nil {
@@ -800,6 +1152,96 @@ func _SeaweedFiler_CacheRemoteObjectToLocalCluster_Handler(srv interface{}, ctx
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SeaweedFiler_RemoteCacheStatistics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoteCacheStatisticsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).RemoteCacheStatistics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/RemoteCacheStatistics",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).RemoteCacheStatistics(ctx, req.(*RemoteCacheStatisticsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_AcquireAdvisoryLock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcquireAdvisoryLockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).AcquireAdvisoryLock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/AcquireAdvisoryLock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).AcquireAdvisoryLock(ctx, req.(*AcquireAdvisoryLockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_ReleaseAdvisoryLock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseAdvisoryLockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).ReleaseAdvisoryLock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/ReleaseAdvisoryLock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).ReleaseAdvisoryLock(ctx, req.(*ReleaseAdvisoryLockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_RenewAdvisoryLocks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenewAdvisoryLocksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).RenewAdvisoryLocks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/RenewAdvisoryLocks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).RenewAdvisoryLocks(ctx, req.(*RenewAdvisoryLocksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_SlowRequests_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SlowRequestsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).SlowRequests(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/SlowRequests",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).SlowRequests(ctx, req.(*SlowRequestsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // SeaweedFiler_ServiceDesc is the grpc.ServiceDesc for SeaweedFiler service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -855,10 +1297,22 @@ var SeaweedFiler_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Ping",
 			Handler:    _SeaweedFiler_Ping_Handler,
 		},
+		{
+			MethodName: "SetLogLevel",
+			Handler:    _SeaweedFiler_SetLogLevel_Handler,
+		},
+		{
+			MethodName: "SetReadOnly",
+			Handler:    _SeaweedFiler_SetReadOnly_Handler,
+		},
 		{
 			MethodName: "GetFilerConfiguration",
 			Handler:    _SeaweedFiler_GetFilerConfiguration_Handler,
 		},
+		{
+			MethodName: "AckLocalMetadata",
+			Handler:    _SeaweedFiler_AckLocalMetadata_Handler,
+		},
 		{
 			MethodName: "KvGet",
 			Handler:    _SeaweedFiler_KvGet_Handler,
@@ -867,10 +1321,46 @@ var SeaweedFiler_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "KvPut",
 			Handler:    _SeaweedFiler_KvPut_Handler,
 		},
+		{
+			MethodName: "KvList",
+			Handler:    _SeaweedFiler_KvList_Handler,
+		},
+		{
+			MethodName: "KvDelete",
+			Handler:    _SeaweedFiler_KvDelete_Handler,
+		},
+		{
+			MethodName: "KvUsage",
+			Handler:    _SeaweedFiler_KvUsage_Handler,
+		},
+		{
+			MethodName: "VerifyMetadata",
+			Handler:    _SeaweedFiler_VerifyMetadata_Handler,
+		},
 		{
 			MethodName: "CacheRemoteObjectToLocalCluster",
 			Handler:    _SeaweedFiler_CacheRemoteObjectToLocalCluster_Handler,
 		},
+		{
+			MethodName: "RemoteCacheStatistics",
+			Handler:    _SeaweedFiler_RemoteCacheStatistics_Handler,
+		},
+		{
+			MethodName: "AcquireAdvisoryLock",
+			Handler:    _SeaweedFiler_AcquireAdvisoryLock_Handler,
+		},
+		{
+			MethodName: "ReleaseAdvisoryLock",
+			Handler:    _SeaweedFiler_ReleaseAdvisoryLock_Handler,
+		},
+		{
+			MethodName: "RenewAdvisoryLocks",
+			Handler:    _SeaweedFiler_RenewAdvisoryLocks_Handler,
+		},
+		{
+			MethodName: "SlowRequests",
+			Handler:    _SeaweedFiler_SlowRequests_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -878,6 +1368,11 @@ var SeaweedFiler_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _SeaweedFiler_ListEntries_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "StreamDeleteEntry",
+			Handler:       _SeaweedFiler_StreamDeleteEntry_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "StreamRenameEntry",
 			Handler:       _SeaweedFiler_StreamRenameEntry_Handler,
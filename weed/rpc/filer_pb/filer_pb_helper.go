@@ -56,6 +56,12 @@ func (c *FileChunk) GetFileIdString() string {
 	return ""
 }
 
+// IsPacked tells whether the chunk is a small file packed with others into a shared container chunk,
+// the data at [ContainerOffset, ContainerOffset+Size) of the container.
+func (c *FileChunk) IsPacked() bool {
+	return c.ContainerSize > 0
+}
+
 func BeforeEntrySerialization(chunks []*FileChunk) {
 
 	for _, chunk := range chunks {
@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const maxRetryBackoff = 30 * time.Second
+
+// clientPolicy is the deadline and retry policy of the unary calls to one kind of server.
+type clientPolicy struct {
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+}
+
+var (
+	// the client policies by the grpc package of the services, loaded from security.toml on the first call
+	clientPolicies     map[string]*clientPolicy
+	clientPoliciesOnce sync.Once
+)
+
+// clientPolicyServices maps the grpc packages to the [grpc.client.*] sections of security.toml.
+var clientPolicyServices = map[string]string{
+	"master_pb":        "master",
+	"volume_server_pb": "volume",
+	"filer_pb":         "filer",
+}
+
+type policyConfiguration interface {
+	IsSet(key string) bool
+	GetInt(key string) int
+}
+
+// loadClientPolicies reads [grpc.client] as the defaults of all services, overridden by [grpc.client.<service>].
+func loadClientPolicies(config policyConfiguration) map[string]*clientPolicy {
+	get := func(service, name string, defaultValue int) int {
+		if key := "grpc.client." + service + "." + name; config.IsSet(key) {
+			return config.GetInt(key)
+		}
+		if key := "grpc.client." + name; config.IsSet(key) {
+			return config.GetInt(key)
+		}
+		return defaultValue
+	}
+	policies := make(map[string]*clientPolicy)
+	for pkg, service := range clientPolicyServices {
+		policy := &clientPolicy{
+			timeout: time.Duration(get(service, "timeout_seconds", 0)) * time.Second,
+			retries: get(service, "retries", 0),
+			backoff: time.Duration(get(service, "backoff_milliseconds", 500)) * time.Millisecond,
+		}
+		if policy.timeout > 0 || policy.retries > 0 {
+			glog.V(1).Infof("grpc %s client timeout %v retries %d backoff %v", service, policy.timeout, policy.retries, policy.backoff)
+		}
+		policies[pkg] = policy
+	}
+	return policies
+}
+
+// policyOf finds the policy by the full method name, e.g. /master_pb.Seaweed/Assign.
+func policyOf(policies map[string]*clientPolicy, method string) *clientPolicy {
+	pkg := strings.TrimPrefix(method, "/")
+	if dot := strings.Index(pkg, "."); dot > 0 {
+		pkg = pkg[:dot]
+	}
+	return policies[pkg]
+}
+
+// unaryClientPolicyInterceptor gives the unary calls without a deadline the configured default deadline,
+// and retries the calls failing because the server is unavailable, with exponential backoff.
+func unaryClientPolicyInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	clientPoliciesOnce.Do(func() {
+		clientPolicies = loadClientPolicies(util.GetViper())
+	})
+	return invokeWithPolicy(policyOf(clientPolicies, method), ctx, method, req, reply, cc, invoker, opts...)
+}
+
+func invokeWithPolicy(policy *clientPolicy, ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+	if policy == nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	backoff := policy.backoff
+	for attempt := 0; ; attempt++ {
+		err = invokeWithTimeout(policy.timeout, ctx, method, req, reply, cc, invoker, opts...)
+		if err == nil || attempt >= policy.retries || status.Code(err) != codes.Unavailable {
+			return err
+		}
+		glog.V(1).Infof("retry %s in %v: %v", method, backoff, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+func invokeWithTimeout(timeout time.Duration, ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if _, hasDeadline := ctx.Deadline(); timeout > 0 && !hasDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,53 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLoadClientPolicies(t *testing.T) {
+	v := viper.New()
+	v.Set("grpc.client.timeout_seconds", 10)
+	v.Set("grpc.client.retries", 2)
+	v.Set("grpc.client.volume.timeout_seconds", 60)
+
+	policies := loadClientPolicies(v)
+	master := policyOf(policies, "/master_pb.Seaweed/Assign")
+	assert.Equal(t, 10*time.Second, master.timeout)
+	assert.Equal(t, 2, master.retries)
+	assert.Equal(t, 500*time.Millisecond, master.backoff)
+	volume := policyOf(policies, "/volume_server_pb.VolumeServer/VacuumVolumeCheck")
+	assert.Equal(t, 60*time.Second, volume.timeout)
+	assert.Nil(t, policyOf(policies, "/iam_pb.SeaweedIdentityAccessManagement/GetConfiguration"))
+}
+
+func TestInvokeWithPolicy(t *testing.T) {
+	policy := &clientPolicy{timeout: time.Minute, retries: 2, backoff: time.Millisecond}
+
+	calls := 0
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline)
+		return status.Error(codes.Unavailable, "connection refused")
+	}
+	err := invokeWithPolicy(policy, context.Background(), "/filer_pb.SeaweedFiler/Ping", nil, nil, nil, invoker)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	invoker = func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		return status.Error(codes.NotFound, "not found")
+	}
+	err = invokeWithPolicy(policy, context.Background(), "/filer_pb.SeaweedFiler/LookupDirectoryEntry", nil, nil, nil, invoker)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, 1, calls)
+}
@@ -12,6 +12,7 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
@@ -80,7 +81,9 @@ func GrpcDial(ctx context.Context, address string, waitForReady bool, opts ...gr
 			Time:                30 * time.Second, // client ping server if no activity for this long
 			Timeout:             20 * time.Second,
 			PermitWithoutStream: true,
-		}))
+		}),
+		grpc.WithChainUnaryInterceptor(unaryClientPolicyInterceptor),
+	)
 	for _, opt := range opts {
 		if opt != nil {
 			options = append(options, opt)
@@ -261,3 +264,11 @@ func WithOneOfGrpcFilerClients(streamingMode bool, filerAddresses []ServerAddres
 
 	return err
 }
+
+// RegisterReflection lets tools like grpcurl list the services, unless grpc.disable_reflection is set in security.toml.
+func RegisterReflection(grpcS *grpc.Server) {
+	if util.GetViper().GetBool("grpc.disable_reflection") {
+		return
+	}
+	reflection.Register(grpcS)
+}
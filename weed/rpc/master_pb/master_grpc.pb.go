@@ -32,15 +32,23 @@ type SeaweedClient interface {
 	VolumeList(ctx context.Context, in *VolumeListRequest, opts ...grpc.CallOption) (*VolumeListResponse, error)
 	LookupEcVolume(ctx context.Context, in *LookupEcVolumeRequest, opts ...grpc.CallOption) (*LookupEcVolumeResponse, error)
 	VacuumVolume(ctx context.Context, in *VacuumVolumeRequest, opts ...grpc.CallOption) (*VacuumVolumeResponse, error)
+	StreamVacuumVolume(ctx context.Context, in *VacuumVolumeRequest, opts ...grpc.CallOption) (Seaweed_StreamVacuumVolumeClient, error)
 	VolumeMarkReadonly(ctx context.Context, in *VolumeMarkReadonlyRequest, opts ...grpc.CallOption) (*VolumeMarkReadonlyResponse, error)
 	GetMasterConfiguration(ctx context.Context, in *GetMasterConfigurationRequest, opts ...grpc.CallOption) (*GetMasterConfigurationResponse, error)
 	ListClusterNodes(ctx context.Context, in *ListClusterNodesRequest, opts ...grpc.CallOption) (*ListClusterNodesResponse, error)
 	LeaseAdminToken(ctx context.Context, in *LeaseAdminTokenRequest, opts ...grpc.CallOption) (*LeaseAdminTokenResponse, error)
 	ReleaseAdminToken(ctx context.Context, in *ReleaseAdminTokenRequest, opts ...grpc.CallOption) (*ReleaseAdminTokenResponse, error)
 	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error)
 	RaftListClusterServers(ctx context.Context, in *RaftListClusterServersRequest, opts ...grpc.CallOption) (*RaftListClusterServersResponse, error)
 	RaftAddServer(ctx context.Context, in *RaftAddServerRequest, opts ...grpc.CallOption) (*RaftAddServerResponse, error)
 	RaftRemoveServer(ctx context.Context, in *RaftRemoveServerRequest, opts ...grpc.CallOption) (*RaftRemoveServerResponse, error)
+	GetVolumeIdRanges(ctx context.Context, in *GetVolumeIdRangesRequest, opts ...grpc.CallOption) (*GetVolumeIdRangesResponse, error)
+	SetVolumeIdRanges(ctx context.Context, in *SetVolumeIdRangesRequest, opts ...grpc.CallOption) (*SetVolumeIdRangesResponse, error)
+	GetReplicationTargets(ctx context.Context, in *GetReplicationTargetsRequest, opts ...grpc.CallOption) (*GetReplicationTargetsResponse, error)
+	SetReplicationTarget(ctx context.Context, in *SetReplicationTargetRequest, opts ...grpc.CallOption) (*SetReplicationTargetResponse, error)
+	ExportClusterSnapshot(ctx context.Context, in *ExportClusterSnapshotRequest, opts ...grpc.CallOption) (*ExportClusterSnapshotResponse, error)
+	RestoreClusterSnapshot(ctx context.Context, in *RestoreClusterSnapshotRequest, opts ...grpc.CallOption) (*RestoreClusterSnapshotResponse, error)
 }
 
 type seaweedClient struct {
@@ -185,6 +193,38 @@ func (c *seaweedClient) VacuumVolume(ctx context.Context, in *VacuumVolumeReques
 	return out, nil
 }
 
+func (c *seaweedClient) StreamVacuumVolume(ctx context.Context, in *VacuumVolumeRequest, opts ...grpc.CallOption) (Seaweed_StreamVacuumVolumeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Seaweed_ServiceDesc.Streams[2], "/master_pb.Seaweed/StreamVacuumVolume", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &seaweedStreamVacuumVolumeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Seaweed_StreamVacuumVolumeClient interface {
+	Recv() (*VacuumVolumeProgress, error)
+	grpc.ClientStream
+}
+
+type seaweedStreamVacuumVolumeClient struct {
+	grpc.ClientStream
+}
+
+func (x *seaweedStreamVacuumVolumeClient) Recv() (*VacuumVolumeProgress, error) {
+	m := new(VacuumVolumeProgress)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *seaweedClient) VolumeMarkReadonly(ctx context.Context, in *VolumeMarkReadonlyRequest, opts ...grpc.CallOption) (*VolumeMarkReadonlyResponse, error) {
 	out := new(VolumeMarkReadonlyResponse)
 	err := c.cc.Invoke(ctx, "/master_pb.Seaweed/VolumeMarkReadonly", in, out, opts...)
@@ -239,6 +279,15 @@ func (c *seaweedClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.
 	return out, nil
 }
 
+func (c *seaweedClient) SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error) {
+	out := new(SetLogLevelResponse)
+	err := c.cc.Invoke(ctx, "/master_pb.Seaweed/SetLogLevel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *seaweedClient) RaftListClusterServers(ctx context.Context, in *RaftListClusterServersRequest, opts ...grpc.CallOption) (*RaftListClusterServersResponse, error) {
 	out := new(RaftListClusterServersResponse)
 	err := c.cc.Invoke(ctx, "/master_pb.Seaweed/RaftListClusterServers", in, out, opts...)
@@ -266,6 +315,60 @@ func (c *seaweedClient) RaftRemoveServer(ctx context.Context, in *RaftRemoveServ
 	return out, nil
 }
 
+func (c *seaweedClient) GetVolumeIdRanges(ctx context.Context, in *GetVolumeIdRangesRequest, opts ...grpc.CallOption) (*GetVolumeIdRangesResponse, error) {
+	out := new(GetVolumeIdRangesResponse)
+	err := c.cc.Invoke(ctx, "/master_pb.Seaweed/GetVolumeIdRanges", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedClient) SetVolumeIdRanges(ctx context.Context, in *SetVolumeIdRangesRequest, opts ...grpc.CallOption) (*SetVolumeIdRangesResponse, error) {
+	out := new(SetVolumeIdRangesResponse)
+	err := c.cc.Invoke(ctx, "/master_pb.Seaweed/SetVolumeIdRanges", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedClient) GetReplicationTargets(ctx context.Context, in *GetReplicationTargetsRequest, opts ...grpc.CallOption) (*GetReplicationTargetsResponse, error) {
+	out := new(GetReplicationTargetsResponse)
+	err := c.cc.Invoke(ctx, "/master_pb.Seaweed/GetReplicationTargets", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedClient) SetReplicationTarget(ctx context.Context, in *SetReplicationTargetRequest, opts ...grpc.CallOption) (*SetReplicationTargetResponse, error) {
+	out := new(SetReplicationTargetResponse)
+	err := c.cc.Invoke(ctx, "/master_pb.Seaweed/SetReplicationTarget", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedClient) ExportClusterSnapshot(ctx context.Context, in *ExportClusterSnapshotRequest, opts ...grpc.CallOption) (*ExportClusterSnapshotResponse, error) {
+	out := new(ExportClusterSnapshotResponse)
+	err := c.cc.Invoke(ctx, "/master_pb.Seaweed/ExportClusterSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedClient) RestoreClusterSnapshot(ctx context.Context, in *RestoreClusterSnapshotRequest, opts ...grpc.CallOption) (*RestoreClusterSnapshotResponse, error) {
+	out := new(RestoreClusterSnapshotResponse)
+	err := c.cc.Invoke(ctx, "/master_pb.Seaweed/RestoreClusterSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SeaweedServer is the server API for Seaweed service.
 // All implementations must embed UnimplementedSeaweedServer
 // for forward compatibility
@@ -280,15 +383,23 @@ type SeaweedServer interface {
 	VolumeList(context.Context, *VolumeListRequest) (*VolumeListResponse, error)
 	LookupEcVolume(context.Context, *LookupEcVolumeRequest) (*LookupEcVolumeResponse, error)
 	VacuumVolume(context.Context, *VacuumVolumeRequest) (*VacuumVolumeResponse, error)
+	StreamVacuumVolume(*VacuumVolumeRequest, Seaweed_StreamVacuumVolumeServer) error
 	VolumeMarkReadonly(context.Context, *VolumeMarkReadonlyRequest) (*VolumeMarkReadonlyResponse, error)
 	GetMasterConfiguration(context.Context, *GetMasterConfigurationRequest) (*GetMasterConfigurationResponse, error)
 	ListClusterNodes(context.Context, *ListClusterNodesRequest) (*ListClusterNodesResponse, error)
 	LeaseAdminToken(context.Context, *LeaseAdminTokenRequest) (*LeaseAdminTokenResponse, error)
 	ReleaseAdminToken(context.Context, *ReleaseAdminTokenRequest) (*ReleaseAdminTokenResponse, error)
 	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error)
 	RaftListClusterServers(context.Context, *RaftListClusterServersRequest) (*RaftListClusterServersResponse, error)
 	RaftAddServer(context.Context, *RaftAddServerRequest) (*RaftAddServerResponse, error)
 	RaftRemoveServer(context.Context, *RaftRemoveServerRequest) (*RaftRemoveServerResponse, error)
+	GetVolumeIdRanges(context.Context, *GetVolumeIdRangesRequest) (*GetVolumeIdRangesResponse, error)
+	SetVolumeIdRanges(context.Context, *SetVolumeIdRangesRequest) (*SetVolumeIdRangesResponse, error)
+	GetReplicationTargets(context.Context, *GetReplicationTargetsRequest) (*GetReplicationTargetsResponse, error)
+	SetReplicationTarget(context.Context, *SetReplicationTargetRequest) (*SetReplicationTargetResponse, error)
+	ExportClusterSnapshot(context.Context, *ExportClusterSnapshotRequest) (*ExportClusterSnapshotResponse, error)
+	RestoreClusterSnapshot(context.Context, *RestoreClusterSnapshotRequest) (*RestoreClusterSnapshotResponse, error)
 	mustEmbedUnimplementedSeaweedServer()
 }
 
@@ -326,6 +437,9 @@ func (UnimplementedSeaweedServer) LookupEcVolume(context.Context, *LookupEcVolum
 func (UnimplementedSeaweedServer) VacuumVolume(context.Context, *VacuumVolumeRequest) (*VacuumVolumeResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method VacuumVolume not implemented")
 }
+func (UnimplementedSeaweedServer) StreamVacuumVolume(*VacuumVolumeRequest, Seaweed_StreamVacuumVolumeServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamVacuumVolume not implemented")
+}
 func (UnimplementedSeaweedServer) VolumeMarkReadonly(context.Context, *VolumeMarkReadonlyRequest) (*VolumeMarkReadonlyResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method VolumeMarkReadonly not implemented")
 }
@@ -344,6 +458,9 @@ func (UnimplementedSeaweedServer) ReleaseAdminToken(context.Context, *ReleaseAdm
 func (UnimplementedSeaweedServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
 }
+func (UnimplementedSeaweedServer) SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
+}
 func (UnimplementedSeaweedServer) RaftListClusterServers(context.Context, *RaftListClusterServersRequest) (*RaftListClusterServersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RaftListClusterServers not implemented")
 }
@@ -353,6 +470,24 @@ func (UnimplementedSeaweedServer) RaftAddServer(context.Context, *RaftAddServerR
 func (UnimplementedSeaweedServer) RaftRemoveServer(context.Context, *RaftRemoveServerRequest) (*RaftRemoveServerResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RaftRemoveServer not implemented")
 }
+func (UnimplementedSeaweedServer) GetVolumeIdRanges(context.Context, *GetVolumeIdRangesRequest) (*GetVolumeIdRangesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVolumeIdRanges not implemented")
+}
+func (UnimplementedSeaweedServer) SetVolumeIdRanges(context.Context, *SetVolumeIdRangesRequest) (*SetVolumeIdRangesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetVolumeIdRanges not implemented")
+}
+func (UnimplementedSeaweedServer) GetReplicationTargets(context.Context, *GetReplicationTargetsRequest) (*GetReplicationTargetsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReplicationTargets not implemented")
+}
+func (UnimplementedSeaweedServer) SetReplicationTarget(context.Context, *SetReplicationTargetRequest) (*SetReplicationTargetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetReplicationTarget not implemented")
+}
+func (UnimplementedSeaweedServer) ExportClusterSnapshot(context.Context, *ExportClusterSnapshotRequest) (*ExportClusterSnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportClusterSnapshot not implemented")
+}
+func (UnimplementedSeaweedServer) RestoreClusterSnapshot(context.Context, *RestoreClusterSnapshotRequest) (*RestoreClusterSnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreClusterSnapshot not implemented")
+}
 func (UnimplementedSeaweedServer) mustEmbedUnimplementedSeaweedServer() {}
 
 // UnsafeSeaweedServer may be embedded to opt out of forward compatibility for this service.
@@ -562,6 +697,27 @@ func _Seaweed_VacuumVolume_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Seaweed_StreamVacuumVolume_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(VacuumVolumeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SeaweedServer).StreamVacuumVolume(m, &seaweedStreamVacuumVolumeServer{stream})
+}
+
+type Seaweed_StreamVacuumVolumeServer interface {
+	Send(*VacuumVolumeProgress) error
+	grpc.ServerStream
+}
+
+type seaweedStreamVacuumVolumeServer struct {
+	grpc.ServerStream
+}
+
+func (x *seaweedStreamVacuumVolumeServer) Send(m *VacuumVolumeProgress) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Seaweed_VolumeMarkReadonly_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(VolumeMarkReadonlyRequest)
 	if err := dec(in); err != nil {
@@ -670,6 +826,24 @@ func _Seaweed_Ping_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Seaweed_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLogLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedServer).SetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/master_pb.Seaweed/SetLogLevel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedServer).SetLogLevel(ctx, req.(*SetLogLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Seaweed_RaftListClusterServers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(RaftListClusterServersRequest)
 	if err := dec(in); err != nil {
@@ -724,6 +898,114 @@ func _Seaweed_RaftRemoveServer_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Seaweed_GetVolumeIdRanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVolumeIdRangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedServer).GetVolumeIdRanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/master_pb.Seaweed/GetVolumeIdRanges",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedServer).GetVolumeIdRanges(ctx, req.(*GetVolumeIdRangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Seaweed_SetVolumeIdRanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetVolumeIdRangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedServer).SetVolumeIdRanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/master_pb.Seaweed/SetVolumeIdRanges",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedServer).SetVolumeIdRanges(ctx, req.(*SetVolumeIdRangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Seaweed_GetReplicationTargets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReplicationTargetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedServer).GetReplicationTargets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/master_pb.Seaweed/GetReplicationTargets",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedServer).GetReplicationTargets(ctx, req.(*GetReplicationTargetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Seaweed_SetReplicationTarget_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetReplicationTargetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedServer).SetReplicationTarget(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/master_pb.Seaweed/SetReplicationTarget",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedServer).SetReplicationTarget(ctx, req.(*SetReplicationTargetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Seaweed_ExportClusterSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportClusterSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedServer).ExportClusterSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/master_pb.Seaweed/ExportClusterSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedServer).ExportClusterSnapshot(ctx, req.(*ExportClusterSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Seaweed_RestoreClusterSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreClusterSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedServer).RestoreClusterSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/master_pb.Seaweed/RestoreClusterSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedServer).RestoreClusterSnapshot(ctx, req.(*RestoreClusterSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Seaweed_ServiceDesc is the grpc.ServiceDesc for Seaweed service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -787,6 +1069,10 @@ var Seaweed_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Ping",
 			Handler:    _Seaweed_Ping_Handler,
 		},
+		{
+			MethodName: "SetLogLevel",
+			Handler:    _Seaweed_SetLogLevel_Handler,
+		},
 		{
 			MethodName: "RaftListClusterServers",
 			Handler:    _Seaweed_RaftListClusterServers_Handler,
@@ -799,6 +1085,30 @@ var Seaweed_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RaftRemoveServer",
 			Handler:    _Seaweed_RaftRemoveServer_Handler,
 		},
+		{
+			MethodName: "GetVolumeIdRanges",
+			Handler:    _Seaweed_GetVolumeIdRanges_Handler,
+		},
+		{
+			MethodName: "SetVolumeIdRanges",
+			Handler:    _Seaweed_SetVolumeIdRanges_Handler,
+		},
+		{
+			MethodName: "GetReplicationTargets",
+			Handler:    _Seaweed_GetReplicationTargets_Handler,
+		},
+		{
+			MethodName: "SetReplicationTarget",
+			Handler:    _Seaweed_SetReplicationTarget_Handler,
+		},
+		{
+			MethodName: "ExportClusterSnapshot",
+			Handler:    _Seaweed_ExportClusterSnapshot_Handler,
+		},
+		{
+			MethodName: "RestoreClusterSnapshot",
+			Handler:    _Seaweed_RestoreClusterSnapshot_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -813,6 +1123,11 @@ var Seaweed_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "StreamVacuumVolume",
+			Handler:       _Seaweed_StreamVacuumVolume_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "master.proto",
 }
@@ -23,6 +23,8 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type S3Client interface {
 	Configure(ctx context.Context, in *S3ConfigureRequest, opts ...grpc.CallOption) (*S3ConfigureResponse, error)
+	SlowRequests(ctx context.Context, in *SlowRequestsRequest, opts ...grpc.CallOption) (*SlowRequestsResponse, error)
+	SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error)
 }
 
 type s3Client struct {
@@ -42,11 +44,31 @@ func (c *s3Client) Configure(ctx context.Context, in *S3ConfigureRequest, opts .
 	return out, nil
 }
 
+func (c *s3Client) SlowRequests(ctx context.Context, in *SlowRequestsRequest, opts ...grpc.CallOption) (*SlowRequestsResponse, error) {
+	out := new(SlowRequestsResponse)
+	err := c.cc.Invoke(ctx, "/weed.s3.S3/SlowRequests", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *s3Client) SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error) {
+	out := new(SetLogLevelResponse)
+	err := c.cc.Invoke(ctx, "/weed.s3.S3/SetLogLevel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // S3Server is the server API for S3 service.
 // All implementations must embed UnimplementedS3Server
 // for forward compatibility
 type S3Server interface {
 	Configure(context.Context, *S3ConfigureRequest) (*S3ConfigureResponse, error)
+	SlowRequests(context.Context, *SlowRequestsRequest) (*SlowRequestsResponse, error)
+	SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error)
 	mustEmbedUnimplementedS3Server()
 }
 
@@ -57,6 +79,12 @@ type UnimplementedS3Server struct {
 func (UnimplementedS3Server) Configure(context.Context, *S3ConfigureRequest) (*S3ConfigureResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Configure not implemented")
 }
+func (UnimplementedS3Server) SlowRequests(context.Context, *SlowRequestsRequest) (*SlowRequestsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SlowRequests not implemented")
+}
+func (UnimplementedS3Server) SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
+}
 func (UnimplementedS3Server) mustEmbedUnimplementedS3Server() {}
 
 // UnsafeS3Server may be embedded to opt out of forward compatibility for this service.
@@ -88,6 +116,42 @@ func _S3_Configure_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _S3_SlowRequests_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SlowRequestsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(S3Server).SlowRequests(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/weed.s3.S3/SlowRequests",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(S3Server).SlowRequests(ctx, req.(*SlowRequestsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _S3_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLogLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(S3Server).SetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/weed.s3.S3/SetLogLevel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(S3Server).SetLogLevel(ctx, req.(*SetLogLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // S3_ServiceDesc is the grpc.ServiceDesc for S3 service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -99,6 +163,14 @@ var S3_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Configure",
 			Handler:    _S3_Configure_Handler,
 		},
+		{
+			MethodName: "SlowRequests",
+			Handler:    _S3_SlowRequests_Handler,
+		},
+		{
+			MethodName: "SetLogLevel",
+			Handler:    _S3_SetLogLevel_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "s3.proto",
@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+const (
+	// dns:<host>:<port> is resolved to all the ips of the host
+	dnsAddressPrefix = "dns:"
+	// srv:<name> is resolved to the targets of the SRV record
+	srvAddressPrefix = "srv:"
+)
+
+var (
+	lookupHost = net.LookupHost
+	lookupSRV  = net.LookupSRV
+)
+
+// IsDnsName tells whether the address is a dns name re-resolved to the actual server addresses.
+func (sa ServerAddress) IsDnsName() bool {
+	return strings.HasPrefix(string(sa), dnsAddressPrefix) || strings.HasPrefix(string(sa), srvAddressPrefix)
+}
+
+// HasDnsName tells whether any of the addresses needs to be resolved.
+func HasDnsName(addresses map[string]ServerAddress) bool {
+	for _, address := range addresses {
+		if address.IsDnsName() {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveServerAddresses resolves the dns names to the server addresses, keeping the other addresses.
+// The dns names failing to resolve are skipped.
+func ResolveServerAddresses(addresses []ServerAddress) (resolved []ServerAddress) {
+	for _, address := range addresses {
+		resolved = append(resolved, address.resolve()...)
+	}
+	return
+}
+
+// ResolveServerAddressMap resolves the dns names to the server addresses, keeping the other addresses.
+func ResolveServerAddressMap(addresses map[string]ServerAddress) (resolved map[string]ServerAddress) {
+	resolved = make(map[string]ServerAddress)
+	for _, address := range addresses {
+		for _, r := range address.resolve() {
+			resolved[string(r)] = r
+		}
+	}
+	return
+}
+
+func (sa ServerAddress) resolve() (addresses []ServerAddress) {
+	switch {
+	case strings.HasPrefix(string(sa), dnsAddressPrefix):
+		hostPort := strings.TrimPrefix(string(sa), dnsAddressPrefix)
+		sepIndex := strings.LastIndex(hostPort, ":")
+		if sepIndex < 0 {
+			glog.Errorf("dns address %s should be dns:<host>:<port>", sa)
+			return nil
+		}
+		// the port may come with the grpc port, as <port>.<grpcPort>
+		host, ports := hostPort[:sepIndex], hostPort[sepIndex+1:]
+		ips, err := lookupHost(host)
+		if err != nil {
+			glog.Errorf("resolve %s: %v", sa, err)
+			return nil
+		}
+		for _, ip := range ips {
+			addresses = append(addresses, ServerAddress(net.JoinHostPort(ip, ports)))
+		}
+	case strings.HasPrefix(string(sa), srvAddressPrefix):
+		_, records, err := lookupSRV("", "", strings.TrimPrefix(string(sa), srvAddressPrefix))
+		if err != nil {
+			glog.Errorf("resolve %s: %v", sa, err)
+			return nil
+		}
+		for _, record := range records {
+			addresses = append(addresses, ServerAddress(net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port)))))
+		}
+	default:
+		addresses = append(addresses, sa)
+	}
+	return
+}
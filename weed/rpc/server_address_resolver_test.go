@@ -0,0 +1,34 @@
+package rpc
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveServerAddresses(t *testing.T) {
+	lookupHost = func(host string) ([]string, error) {
+		if host == "master.svc" {
+			return []string{"10.0.0.1", "fd00::2"}, nil
+		}
+		return nil, fmt.Errorf("no such host %s", host)
+	}
+	lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		return "", []*net.SRV{{Target: "master-0.master.svc.", Port: 9333}}, nil
+	}
+	defer func() {
+		lookupHost, lookupSRV = net.LookupHost, net.LookupSRV
+	}()
+
+	assert.Equal(t, []ServerAddress{"10.0.0.1:9333", "[fd00::2]:9333", "localhost:9334"},
+		ResolveServerAddresses([]ServerAddress{"dns:master.svc:9333", "localhost:9334", "dns:missing.svc:9333"}))
+	assert.Equal(t, []ServerAddress{"10.0.0.1:9333.19334"},
+		ResolveServerAddresses([]ServerAddress{"dns:master.svc:9333.19334"})[:1])
+	assert.Equal(t, map[string]ServerAddress{"master-0.master.svc:9333": "master-0.master.svc:9333"},
+		ResolveServerAddressMap(map[string]ServerAddress{"srv:_master._tcp.master.svc": "srv:_master._tcp.master.svc"}))
+
+	assert.True(t, HasDnsName(map[string]ServerAddress{"a": "localhost:9333", "b": "dns:master.svc:9333"}))
+	assert.False(t, HasDnsName(map[string]ServerAddress{"a": "localhost:9333"}))
+}
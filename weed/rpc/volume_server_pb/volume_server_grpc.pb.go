@@ -24,6 +24,7 @@ const _ = grpc.SupportPackageIsVersion7
 type VolumeServerClient interface {
 	//Experts only: takes multiple fid parameters. This function does not propagate deletes to replicas.
 	BatchDelete(ctx context.Context, in *BatchDeleteRequest, opts ...grpc.CallOption) (*BatchDeleteResponse, error)
+	BatchNeedleExists(ctx context.Context, in *BatchNeedleExistsRequest, opts ...grpc.CallOption) (*BatchNeedleExistsResponse, error)
 	VacuumVolumeCheck(ctx context.Context, in *VacuumVolumeCheckRequest, opts ...grpc.CallOption) (*VacuumVolumeCheckResponse, error)
 	VacuumVolumeCompact(ctx context.Context, in *VacuumVolumeCompactRequest, opts ...grpc.CallOption) (VolumeServer_VacuumVolumeCompactClient, error)
 	VacuumVolumeCommit(ctx context.Context, in *VacuumVolumeCommitRequest, opts ...grpc.CallOption) (*VacuumVolumeCommitResponse, error)
@@ -64,12 +65,16 @@ type VolumeServerClient interface {
 	VolumeTierMoveDatFromRemote(ctx context.Context, in *VolumeTierMoveDatFromRemoteRequest, opts ...grpc.CallOption) (VolumeServer_VolumeTierMoveDatFromRemoteClient, error)
 	VolumeServerStatus(ctx context.Context, in *VolumeServerStatusRequest, opts ...grpc.CallOption) (*VolumeServerStatusResponse, error)
 	VolumeServerLeave(ctx context.Context, in *VolumeServerLeaveRequest, opts ...grpc.CallOption) (*VolumeServerLeaveResponse, error)
+	VolumeServerMarkReadonly(ctx context.Context, in *VolumeServerMarkReadonlyRequest, opts ...grpc.CallOption) (*VolumeServerMarkReadonlyResponse, error)
+	VolumeServerStats(ctx context.Context, in *VolumeServerStatsRequest, opts ...grpc.CallOption) (*VolumeServerStatsResponse, error)
+	SlowRequests(ctx context.Context, in *SlowRequestsRequest, opts ...grpc.CallOption) (*SlowRequestsResponse, error)
 	// remote storage
 	FetchAndWriteNeedle(ctx context.Context, in *FetchAndWriteNeedleRequest, opts ...grpc.CallOption) (*FetchAndWriteNeedleResponse, error)
 	// <experimental> query
 	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (VolumeServer_QueryClient, error)
 	VolumeNeedleStatus(ctx context.Context, in *VolumeNeedleStatusRequest, opts ...grpc.CallOption) (*VolumeNeedleStatusResponse, error)
 	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error)
 }
 
 type volumeServerClient struct {
@@ -89,6 +94,15 @@ func (c *volumeServerClient) BatchDelete(ctx context.Context, in *BatchDeleteReq
 	return out, nil
 }
 
+func (c *volumeServerClient) BatchNeedleExists(ctx context.Context, in *BatchNeedleExistsRequest, opts ...grpc.CallOption) (*BatchNeedleExistsResponse, error) {
+	out := new(BatchNeedleExistsResponse)
+	err := c.cc.Invoke(ctx, "/volume_server_pb.VolumeServer/BatchNeedleExists", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *volumeServerClient) VacuumVolumeCheck(ctx context.Context, in *VacuumVolumeCheckRequest, opts ...grpc.CallOption) (*VacuumVolumeCheckResponse, error) {
 	out := new(VacuumVolumeCheckResponse)
 	err := c.cc.Invoke(ctx, "/volume_server_pb.VolumeServer/VacuumVolumeCheck", in, out, opts...)
@@ -629,6 +643,33 @@ func (c *volumeServerClient) VolumeServerLeave(ctx context.Context, in *VolumeSe
 	return out, nil
 }
 
+func (c *volumeServerClient) VolumeServerMarkReadonly(ctx context.Context, in *VolumeServerMarkReadonlyRequest, opts ...grpc.CallOption) (*VolumeServerMarkReadonlyResponse, error) {
+	out := new(VolumeServerMarkReadonlyResponse)
+	err := c.cc.Invoke(ctx, "/volume_server_pb.VolumeServer/VolumeServerMarkReadonly", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *volumeServerClient) VolumeServerStats(ctx context.Context, in *VolumeServerStatsRequest, opts ...grpc.CallOption) (*VolumeServerStatsResponse, error) {
+	out := new(VolumeServerStatsResponse)
+	err := c.cc.Invoke(ctx, "/volume_server_pb.VolumeServer/VolumeServerStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *volumeServerClient) SlowRequests(ctx context.Context, in *SlowRequestsRequest, opts ...grpc.CallOption) (*SlowRequestsResponse, error) {
+	out := new(SlowRequestsResponse)
+	err := c.cc.Invoke(ctx, "/volume_server_pb.VolumeServer/SlowRequests", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *volumeServerClient) FetchAndWriteNeedle(ctx context.Context, in *FetchAndWriteNeedleRequest, opts ...grpc.CallOption) (*FetchAndWriteNeedleResponse, error) {
 	out := new(FetchAndWriteNeedleResponse)
 	err := c.cc.Invoke(ctx, "/volume_server_pb.VolumeServer/FetchAndWriteNeedle", in, out, opts...)
@@ -688,12 +729,22 @@ func (c *volumeServerClient) Ping(ctx context.Context, in *PingRequest, opts ...
 	return out, nil
 }
 
+func (c *volumeServerClient) SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error) {
+	out := new(SetLogLevelResponse)
+	err := c.cc.Invoke(ctx, "/volume_server_pb.VolumeServer/SetLogLevel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // VolumeServerServer is the server API for VolumeServer service.
 // All implementations must embed UnimplementedVolumeServerServer
 // for forward compatibility
 type VolumeServerServer interface {
 	//Experts only: takes multiple fid parameters. This function does not propagate deletes to replicas.
 	BatchDelete(context.Context, *BatchDeleteRequest) (*BatchDeleteResponse, error)
+	BatchNeedleExists(context.Context, *BatchNeedleExistsRequest) (*BatchNeedleExistsResponse, error)
 	VacuumVolumeCheck(context.Context, *VacuumVolumeCheckRequest) (*VacuumVolumeCheckResponse, error)
 	VacuumVolumeCompact(*VacuumVolumeCompactRequest, VolumeServer_VacuumVolumeCompactServer) error
 	VacuumVolumeCommit(context.Context, *VacuumVolumeCommitRequest) (*VacuumVolumeCommitResponse, error)
@@ -734,12 +785,16 @@ type VolumeServerServer interface {
 	VolumeTierMoveDatFromRemote(*VolumeTierMoveDatFromRemoteRequest, VolumeServer_VolumeTierMoveDatFromRemoteServer) error
 	VolumeServerStatus(context.Context, *VolumeServerStatusRequest) (*VolumeServerStatusResponse, error)
 	VolumeServerLeave(context.Context, *VolumeServerLeaveRequest) (*VolumeServerLeaveResponse, error)
+	VolumeServerMarkReadonly(context.Context, *VolumeServerMarkReadonlyRequest) (*VolumeServerMarkReadonlyResponse, error)
+	VolumeServerStats(context.Context, *VolumeServerStatsRequest) (*VolumeServerStatsResponse, error)
+	SlowRequests(context.Context, *SlowRequestsRequest) (*SlowRequestsResponse, error)
 	// remote storage
 	FetchAndWriteNeedle(context.Context, *FetchAndWriteNeedleRequest) (*FetchAndWriteNeedleResponse, error)
 	// <experimental> query
 	Query(*QueryRequest, VolumeServer_QueryServer) error
 	VolumeNeedleStatus(context.Context, *VolumeNeedleStatusRequest) (*VolumeNeedleStatusResponse, error)
 	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error)
 	mustEmbedUnimplementedVolumeServerServer()
 }
 
@@ -750,6 +805,9 @@ type UnimplementedVolumeServerServer struct {
 func (UnimplementedVolumeServerServer) BatchDelete(context.Context, *BatchDeleteRequest) (*BatchDeleteResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BatchDelete not implemented")
 }
+func (UnimplementedVolumeServerServer) BatchNeedleExists(context.Context, *BatchNeedleExistsRequest) (*BatchNeedleExistsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchNeedleExists not implemented")
+}
 func (UnimplementedVolumeServerServer) VacuumVolumeCheck(context.Context, *VacuumVolumeCheckRequest) (*VacuumVolumeCheckResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method VacuumVolumeCheck not implemented")
 }
@@ -861,6 +919,15 @@ func (UnimplementedVolumeServerServer) VolumeServerStatus(context.Context, *Volu
 func (UnimplementedVolumeServerServer) VolumeServerLeave(context.Context, *VolumeServerLeaveRequest) (*VolumeServerLeaveResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method VolumeServerLeave not implemented")
 }
+func (UnimplementedVolumeServerServer) VolumeServerMarkReadonly(context.Context, *VolumeServerMarkReadonlyRequest) (*VolumeServerMarkReadonlyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VolumeServerMarkReadonly not implemented")
+}
+func (UnimplementedVolumeServerServer) VolumeServerStats(context.Context, *VolumeServerStatsRequest) (*VolumeServerStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VolumeServerStats not implemented")
+}
+func (UnimplementedVolumeServerServer) SlowRequests(context.Context, *SlowRequestsRequest) (*SlowRequestsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SlowRequests not implemented")
+}
 func (UnimplementedVolumeServerServer) FetchAndWriteNeedle(context.Context, *FetchAndWriteNeedleRequest) (*FetchAndWriteNeedleResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method FetchAndWriteNeedle not implemented")
 }
@@ -873,6 +940,9 @@ func (UnimplementedVolumeServerServer) VolumeNeedleStatus(context.Context, *Volu
 func (UnimplementedVolumeServerServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
 }
+func (UnimplementedVolumeServerServer) SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
+}
 func (UnimplementedVolumeServerServer) mustEmbedUnimplementedVolumeServerServer() {}
 
 // UnsafeVolumeServerServer may be embedded to opt out of forward compatibility for this service.
@@ -904,6 +974,24 @@ func _VolumeServer_BatchDelete_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VolumeServer_BatchNeedleExists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchNeedleExistsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VolumeServerServer).BatchNeedleExists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/volume_server_pb.VolumeServer/BatchNeedleExists",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VolumeServerServer).BatchNeedleExists(ctx, req.(*BatchNeedleExistsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _VolumeServer_VacuumVolumeCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(VacuumVolumeCheckRequest)
 	if err := dec(in); err != nil {
@@ -1597,6 +1685,60 @@ func _VolumeServer_VolumeServerLeave_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VolumeServer_VolumeServerMarkReadonly_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VolumeServerMarkReadonlyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VolumeServerServer).VolumeServerMarkReadonly(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/volume_server_pb.VolumeServer/VolumeServerMarkReadonly",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VolumeServerServer).VolumeServerMarkReadonly(ctx, req.(*VolumeServerMarkReadonlyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VolumeServer_VolumeServerStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VolumeServerStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VolumeServerServer).VolumeServerStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/volume_server_pb.VolumeServer/VolumeServerStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VolumeServerServer).VolumeServerStats(ctx, req.(*VolumeServerStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VolumeServer_SlowRequests_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SlowRequestsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VolumeServerServer).SlowRequests(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/volume_server_pb.VolumeServer/SlowRequests",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VolumeServerServer).SlowRequests(ctx, req.(*SlowRequestsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _VolumeServer_FetchAndWriteNeedle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(FetchAndWriteNeedleRequest)
 	if err := dec(in); err != nil {
@@ -1672,6 +1814,24 @@ func _VolumeServer_Ping_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VolumeServer_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLogLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VolumeServerServer).SetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/volume_server_pb.VolumeServer/SetLogLevel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VolumeServerServer).SetLogLevel(ctx, req.(*SetLogLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // VolumeServer_ServiceDesc is the grpc.ServiceDesc for VolumeServer service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -1683,6 +1843,10 @@ var VolumeServer_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "BatchDelete",
 			Handler:    _VolumeServer_BatchDelete_Handler,
 		},
+		{
+			MethodName: "BatchNeedleExists",
+			Handler:    _VolumeServer_BatchNeedleExists_Handler,
+		},
 		{
 			MethodName: "VacuumVolumeCheck",
 			Handler:    _VolumeServer_VacuumVolumeCheck_Handler,
@@ -1795,6 +1959,18 @@ var VolumeServer_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "VolumeServerLeave",
 			Handler:    _VolumeServer_VolumeServerLeave_Handler,
 		},
+		{
+			MethodName: "VolumeServerMarkReadonly",
+			Handler:    _VolumeServer_VolumeServerMarkReadonly_Handler,
+		},
+		{
+			MethodName: "VolumeServerStats",
+			Handler:    _VolumeServer_VolumeServerStats_Handler,
+		},
+		{
+			MethodName: "SlowRequests",
+			Handler:    _VolumeServer_SlowRequests_Handler,
+		},
 		{
 			MethodName: "FetchAndWriteNeedle",
 			Handler:    _VolumeServer_FetchAndWriteNeedle_Handler,
@@ -1807,6 +1983,10 @@ var VolumeServer_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Ping",
 			Handler:    _VolumeServer_Ping_Handler,
 		},
+		{
+			MethodName: "SetLogLevel",
+			Handler:    _VolumeServer_SetLogLevel_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -26,7 +26,7 @@ type IdentityAccessManagement struct {
 
 	identities    []*Identity
 	isAuthEnabled bool
-	domain        string
+	domainNames   []string
 }
 
 type Identity struct {
@@ -67,12 +67,16 @@ func (action Action) getPermission() Permission {
 
 func NewIdentityAccessManagement(option *S3ApiServerOption) *IdentityAccessManagement {
 	iam := &IdentityAccessManagement{
-		domain: option.DomainName,
+		domainNames: parseDomainNames(option.DomainName),
 	}
 	if option.Config != "" {
 		if err := iam.loadS3ApiConfigurationFromFile(option.Config); err != nil {
 			glog.Fatalf("fail to load config file %s: %v", option.Config, err)
 		}
+	} else if option.IdentityLoader != nil {
+		if err := iam.loadS3ApiConfigurationFromLoader(option.IdentityLoader); err != nil {
+			glog.Warningf("fail to load config: %v", err)
+		}
 	} else {
 		if err := iam.loadS3ApiConfigurationFromFiler(option); err != nil {
 			glog.Warningf("fail to load config: %v", err)
@@ -81,9 +85,17 @@ func NewIdentityAccessManagement(option *S3ApiServerOption) *IdentityAccessManag
 	return iam
 }
 
+func (iam *IdentityAccessManagement) loadS3ApiConfigurationFromLoader(identityLoader func() ([]byte, error)) error {
+	content, err := identityLoader()
+	if err != nil {
+		return fmt.Errorf("load S3 config from the iam credential store: %v", err)
+	}
+	return iam.LoadS3ApiConfigurationFromBytes(content)
+}
+
 func (iam *IdentityAccessManagement) loadS3ApiConfigurationFromFiler(option *S3ApiServerOption) (err error) {
 	var content []byte
-	err = rpc.WithFilerClient(false, option.Filer, option.GrpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+	err = rpc.WithOneOfGrpcFilerClients(false, option.filerAddresses(), option.GrpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
 		content, err = filer.ReadInsideFiler(client, filer.IamConfigDirectory, filer.IamIdentityFile)
 		return err
 	})
@@ -186,19 +198,27 @@ func (iam *IdentityAccessManagement) Auth(f http.HandlerFunc, action Action) htt
 			return
 		}
 
+		// the identity headers are only set after the authentication, never taken from the client
+		r.Header.Del(s3_constants.AmzIdentityId)
+		r.Header.Del(s3_constants.AmzIsAdmin)
+
 		identity, errCode := iam.authRequest(r, action)
 		if errCode == s3err.ErrNone {
 			if identity != nil && identity.Name != "" {
+				s3err.RecordAuthResult(r, identity.Name, false)
 				r.Header.Set(s3_constants.AmzIdentityId, identity.Name)
 				if identity.isAdmin() {
 					r.Header.Set(s3_constants.AmzIsAdmin, "true")
-				} else if _, ok := r.Header[s3_constants.AmzIsAdmin]; ok {
-					r.Header.Del(s3_constants.AmzIsAdmin)
 				}
 			}
 			f(w, r)
 			return
 		}
+		var identityName string
+		if identity != nil {
+			identityName = identity.Name
+		}
+		s3err.RecordAuthResult(r, identityName, true)
 		s3err.WriteErrorResponse(w, r, errCode)
 	}
 }
@@ -28,6 +28,7 @@ func (s3a *S3ApiServer) subscribeMetaEvents(clientName string, prefix string, la
 
 		_ = s3a.onIamConfigUpdate(dir, fileName, content)
 		_ = s3a.onCircuitBreakerConfigUpdate(dir, fileName, content)
+		_ = s3a.onFilerConfUpdate(dir, fileName)
 
 		return nil
 	}
@@ -43,8 +44,22 @@ func (s3a *S3ApiServer) subscribeMetaEvents(clientName string, prefix string, la
 }
 
 //reload iam config
+//with an iam credential store other than the filer, only its version entry is in the filer
 func (s3a *S3ApiServer) onIamConfigUpdate(dir, filename string, content []byte) error {
-	if dir == filer.IamConfigDirectory && filename == filer.IamIdentityFile {
+	if dir != filer.IamConfigDirectory {
+		return nil
+	}
+	if s3a.option.IdentityLoader != nil {
+		if filename == filer.IamIdentityVersionFile {
+			if err := s3a.iam.loadS3ApiConfigurationFromLoader(s3a.option.IdentityLoader); err != nil {
+				glog.Warningf("reload iam identities after %s/%s: %v", dir, filename, err)
+				return err
+			}
+			glog.V(0).Infof("reloaded iam identities after %s/%s", dir, filename)
+		}
+		return nil
+	}
+	if filename == filer.IamIdentityFile {
 		if err := s3a.iam.LoadS3ApiConfigurationFromBytes(content); err != nil {
 			return err
 		}
@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/protobuf/encoding/protojson"
 
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
 	. "github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 )
@@ -126,3 +127,32 @@ func TestCanDo(t *testing.T) {
 	assert.Equal(t, true, ident5.canDo(ACTION_WRITE, "special_bucket", "/a/b/c/d.txt"))
 
 }
+
+func TestIamConfigUpdateFromCredentialStore(t *testing.T) {
+	identities := func(accessKey string) []byte {
+		return []byte(`{"identities":[{"name":"u","credentials":[{"accessKey":"` + accessKey + `","secretKey":"s"}],"actions":["Read"]}]}`)
+	}
+	stored := identities("fromStore1")
+	s3a := &S3ApiServer{
+		option: &S3ApiServerOption{IdentityLoader: func() ([]byte, error) {
+			return stored, nil
+		}},
+		iam: &IdentityAccessManagement{},
+	}
+
+	// the identities copied into the filer are ignored
+	assert.Nil(t, s3a.onIamConfigUpdate(filer.IamConfigDirectory, filer.IamIdentityFile, identities("fromFiler")))
+	_, _, found := s3a.iam.lookupByAccessKey("fromFiler")
+	assert.False(t, found)
+
+	assert.Nil(t, s3a.onIamConfigUpdate(filer.IamConfigDirectory, filer.IamIdentityVersionFile, []byte(`{"store":"vault"}`)))
+	_, _, found = s3a.iam.lookupByAccessKey("fromStore1")
+	assert.True(t, found)
+
+	stored = identities("fromStore2")
+	assert.Nil(t, s3a.onIamConfigUpdate(filer.IamConfigDirectory, filer.IamIdentityVersionFile, nil))
+	_, _, found = s3a.iam.lookupByAccessKey("fromStore1")
+	assert.False(t, found)
+	_, _, found = s3a.iam.lookupByAccessKey("fromStore2")
+	assert.True(t, found)
+}
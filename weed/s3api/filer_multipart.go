@@ -1,11 +1,12 @@
 package s3api
 
 import (
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
-	"golang.org/x/exp/slices"
 	"math"
 	"path/filepath"
 	"sort"
@@ -15,10 +16,12 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 )
 
 type InitiateMultipartUploadResult struct {
@@ -64,14 +67,23 @@ type CompleteMultipartUploadResult struct {
 	s3.CompleteMultipartUploadOutput
 }
 
-func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploadInput, parts *CompleteMultipartUpload) (output *CompleteMultipartUploadResult, code s3err.ErrorCode) {
+func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploadInput, parts *CompleteMultipartUpload, conditions writeConditions) (output *CompleteMultipartUploadResult, code s3err.ErrorCode) {
 
 	glog.V(2).Infof("completeMultipartUpload input %v", input)
 
 	completedParts := parts.Parts
-	slices.SortFunc(completedParts, func(a, b CompletedPart) bool {
-		return a.PartNumber < b.PartNumber
-	})
+	if len(completedParts) == 0 {
+		return nil, s3err.ErrMalformedXML
+	}
+	for i := 1; i < len(completedParts); i++ {
+		if completedParts[i].PartNumber <= completedParts[i-1].PartNumber {
+			return nil, s3err.ErrInvalidPartOrder
+		}
+	}
+
+	// no part of the upload is written while it is completed
+	unlock := s3a.uploadLocks.Lock(*input.UploadId)
+	defer unlock()
 
 	uploadDirectory := s3a.genUploadsFolder(*input.Bucket) + "/" + *input.UploadId
 
@@ -91,6 +103,7 @@ func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploa
 
 	var finalParts []*filer_pb.FileChunk
 	var offset int64
+	var completedPartsInfo []*MultipartPartInfo
 
 	for _, entry := range entries {
 		if strings.HasSuffix(entry.Name, ".part") && !entry.IsDirectory {
@@ -103,21 +116,24 @@ func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploa
 				glog.Errorf("completeMultipartUpload %s ETag mismatch chunk: %s part: %s", entry.Name, entryETag, partETag)
 				return nil, s3err.ErrInvalidPart
 			}
-			for _, chunk := range entry.Chunks {
-				p := &filer_pb.FileChunk{
-					FileId:    chunk.GetFileIdString(),
-					Offset:    offset,
-					Size:      chunk.Size,
-					Mtime:     chunk.Mtime,
-					CipherKey: chunk.CipherKey,
-					ETag:      chunk.ETag,
-				}
-				finalParts = append(finalParts, p)
-				offset += int64(chunk.Size)
-			}
+			var partChunks []*filer_pb.FileChunk
+			partChunks, offset = appendPartChunks(entry, offset)
+			finalParts = append(finalParts, partChunks...)
+			partNumber, _ := strconv.Atoi(entry.Name[:4])
+			completedPartsInfo = append(completedPartsInfo, newMultipartPartInfo(partNumber, entry))
 		}
 	}
-	
+	if errCode := s3a.checkCompletedParts(completedParts, completedPartsInfo); errCode != s3err.ErrNone {
+		glog.V(1).Infof("completeMultipartUpload %s %s: %s", *input.Bucket, *input.UploadId, s3err.GetAPIError(errCode).Code)
+		return nil, errCode
+	}
+	partsInfo := newMultipartPartsInfo(completedPartsInfo)
+
+	if errCode := s3a.checkUploadPolicy(*input.Bucket, "/"+strings.TrimPrefix(*input.Key, "/"), mime, offset); errCode != s3err.ErrNone {
+		glog.V(1).Infof("completeMultipartUpload %s %s: %s", *input.Bucket, *input.UploadId, s3err.GetAPIError(errCode).Code)
+		return nil, errCode
+	}
+
 	entryName := filepath.Base(*input.Key)
 	dirName := filepath.Dir(*input.Key)
 	if dirName == "." {
@@ -133,7 +149,7 @@ func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploa
 		dirName = dirName[:len(dirName)-1]
 	}
 
-	err = s3a.mkFile(dirName, entryName, finalParts, func(entry *filer_pb.Entry) {
+	err = filer_pb.MkFileWithCondition(s3a, dirName, entryName, finalParts, conditions.ifMatch, conditions.ifNoneMatch, func(entry *filer_pb.Entry) {
 		if entry.Extended == nil {
 			entry.Extended = make(map[string][]byte)
 		}
@@ -148,21 +164,40 @@ func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploa
 			entry.Attributes.Mime = mime
 		}
 		entry.Attributes.FileSize = uint64(offset)
+		for _, header := range s3_constants.AmzChecksumHeaders {
+			delete(entry.Extended, header)
+		}
+		if partsInfo.ChecksumHeader != "" {
+			entry.Extended[partsInfo.ChecksumHeader] = []byte(partsInfo.compositeChecksum())
+		}
+		if data, marshalErr := json.Marshal(partsInfo); marshalErr == nil {
+			entry.Extended[s3_constants.X_SeaweedFS_Multipart_Parts] = data
+		}
 	})
 
 	if err != nil {
+		if code = conditionalWriteErrorCode(err.Error()); code != s3err.ErrNone {
+			glog.V(2).Infof("completeMultipartUpload %s/%s: %v", dirName, entryName, err)
+			return nil, code
+		}
+		if code = objectLockErrorCode(err.Error()); code != s3err.ErrNone {
+			glog.V(2).Infof("completeMultipartUpload %s/%s: %v", dirName, entryName, err)
+			return nil, code
+		}
 		glog.Errorf("completeMultipartUpload %s/%s error: %v", dirName, entryName, err)
 		return nil, s3err.ErrInternalError
 	}
 
 	output = &CompleteMultipartUploadResult{
 		CompleteMultipartUploadOutput: s3.CompleteMultipartUploadOutput{
-			Location: aws.String(fmt.Sprintf("http://%s%s/%s", s3a.option.Filer.ToHttpAddress(), urlPathEscape(dirName), urlPathEscape(entryName))),
-			Bucket:   input.Bucket,
-			ETag:     aws.String("\"" + filer.ETagChunks(finalParts) + "\""),
-			Key:      objectKey(input.Key),
+			Bucket: input.Bucket,
+			ETag:   aws.String("\"" + filer.ETagChunks(finalParts) + "\""),
+			Key:    objectKey(input.Key),
 		},
 	}
+	if partsInfo.ChecksumHeader != "" {
+		setChecksumField(&output.CompleteMultipartUploadOutput, partsInfo.ChecksumHeader, partsInfo.compositeChecksum())
+	}
 
 	if err = s3a.rm(s3a.genUploadsFolder(*input.Bucket), *input.UploadId, false, true); err != nil {
 		glog.V(1).Infof("completeMultipartUpload cleanup %s upload %s: %v", *input.Bucket, *input.UploadId, err)
@@ -171,6 +206,32 @@ func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploa
 	return
 }
 
+// checkCompletedParts checks all the listed parts are uploaded, and all but the last are not smaller than the minimum part size.
+func (s3a *S3ApiServer) checkCompletedParts(completedParts []CompletedPart, uploadedParts []*MultipartPartInfo) s3err.ErrorCode {
+	if len(uploadedParts) != len(completedParts) {
+		return s3err.ErrInvalidPart
+	}
+	for _, part := range uploadedParts[:len(uploadedParts)-1] {
+		if part.Size < s3a.option.MultipartMinPartSize {
+			glog.V(1).Infof("part %d of %d bytes is smaller than %d bytes", part.PartNumber, part.Size, s3a.option.MultipartMinPartSize)
+			return s3err.ErrEntityTooSmall
+		}
+	}
+	return s3err.ErrNone
+}
+
+// appendPartChunks copies the chunks of a part one after another from the offset, and returns the offset after them.
+// The other fields are kept, e.g. the container offset and size of a packed chunk.
+func appendPartChunks(entry *filer_pb.Entry, offset int64) (chunks []*filer_pb.FileChunk, nextOffset int64) {
+	for _, chunk := range entry.Chunks {
+		c := proto.Clone(chunk).(*filer_pb.FileChunk)
+		c.Offset = offset
+		chunks = append(chunks, c)
+		offset += int64(chunk.Size)
+	}
+	return chunks, offset
+}
+
 func findByPartNumber(fileName string, parts []CompletedPart) (etag string, found bool) {
 	partNumber, formatErr := strconv.Atoi(fileName[:4])
 	if formatErr != nil {
@@ -200,6 +261,9 @@ func (s3a *S3ApiServer) abortMultipartUpload(input *s3.AbortMultipartUploadInput
 
 	glog.V(2).Infof("abortMultipartUpload input %v", input)
 
+	unlock := s3a.uploadLocks.Lock(*input.UploadId)
+	defer unlock()
+
 	exists, err := s3a.exists(s3a.genUploadsFolder(*input.Bucket), *input.UploadId, true)
 	if err != nil {
 		glog.V(1).Infof("bucket %s abort upload %s: %v", *input.Bucket, *input.UploadId, err)
@@ -265,8 +329,9 @@ func (s3a *S3ApiServer) listMultipartUploads(input *s3.ListMultipartUploadsInput
 				continue
 			}
 			output.Upload = append(output.Upload, &s3.MultipartUpload{
-				Key:      objectKey(aws.String(key)),
-				UploadId: aws.String(entry.Name),
+				Key:          objectKey(aws.String(key)),
+				UploadId:     aws.String(entry.Name),
+				StorageClass: aws.String(storageClassOf(entry)),
 			})
 			uploadsCount += 1
 		}
@@ -306,7 +371,11 @@ func (s3a *S3ApiServer) listObjectParts(input *s3.ListPartsInput) (output *ListP
 		UploadId:         input.UploadId,
 		MaxParts:         input.MaxParts,         // the maximum number of parts to return.
 		PartNumberMarker: input.PartNumberMarker, // the part number starts after this, exclusive
-		StorageClass:     aws.String("STANDARD"),
+		StorageClass:     aws.String(defaultStorageClass),
+	}
+
+	if uploadEntry, err := s3a.getEntry(s3a.genUploadsFolder(*input.Bucket), *input.UploadId); err == nil && uploadEntry != nil {
+		output.StorageClass = aws.String(storageClassOf(uploadEntry))
 	}
 
 	entries, isLast, err := s3a.list(s3a.genUploadsFolder(*input.Bucket)+"/"+*input.UploadId, "", fmt.Sprintf("%04d.part", *input.PartNumberMarker), false, uint32(*input.MaxParts))
@@ -342,3 +411,83 @@ func (s3a *S3ApiServer) listObjectParts(input *s3.ListPartsInput) (output *ListP
 
 	return
 }
+
+// MultipartPartInfo is kept with the completed object, to report its parts in GetObjectAttributes.
+type MultipartPartInfo struct {
+	PartNumber int    `json:"partNumber"`
+	Size       int64  `json:"size"`
+	Checksum   string `json:"checksum,omitempty"`
+}
+
+type MultipartPartsInfo struct {
+	ChecksumHeader string               `json:"checksumHeader,omitempty"`
+	Parts          []*MultipartPartInfo `json:"parts"`
+}
+
+func newMultipartPartInfo(partNumber int, entry *filer_pb.Entry) *MultipartPartInfo {
+	info := &MultipartPartInfo{
+		PartNumber: partNumber,
+		Size:       int64(filer.FileSize(entry)),
+	}
+	for _, header := range s3_constants.AmzChecksumHeaders {
+		if checksum, found := entry.Extended[header]; found {
+			info.Checksum = header + ":" + string(checksum)
+			break
+		}
+	}
+	return info
+}
+
+// newMultipartPartsInfo only keeps the part checksums if all parts use the same checksum algorithm.
+func newMultipartPartsInfo(parts []*MultipartPartInfo) *MultipartPartsInfo {
+	partsInfo := &MultipartPartsInfo{
+		Parts: parts,
+	}
+	var checksumHeader string
+	for i, part := range parts {
+		header, _, _ := strings.Cut(part.Checksum, ":")
+		if i == 0 {
+			checksumHeader = header
+		} else if header != checksumHeader {
+			checksumHeader = ""
+		}
+	}
+	for _, part := range parts {
+		if _, checksum, found := strings.Cut(part.Checksum, ":"); found && checksumHeader != "" {
+			part.Checksum = checksum
+		} else {
+			part.Checksum = ""
+		}
+	}
+	partsInfo.ChecksumHeader = checksumHeader
+	return partsInfo
+}
+
+// compositeChecksum is the checksum of the concatenated part checksums, followed by the number of parts.
+func (partsInfo *MultipartPartsInfo) compositeChecksum() string {
+	h := s3_constants.NewAmzChecksumHash(partsInfo.ChecksumHeader)
+	if h == nil {
+		return ""
+	}
+	for _, part := range partsInfo.Parts {
+		checksum, err := base64.StdEncoding.DecodeString(part.Checksum)
+		if err != nil {
+			return ""
+		}
+		h.Write(checksum)
+	}
+	return fmt.Sprintf("%s-%d", base64.StdEncoding.EncodeToString(h.Sum(nil)), len(partsInfo.Parts))
+}
+
+func setChecksumField(output *s3.CompleteMultipartUploadOutput, header, checksum string) {
+	switch header {
+	case s3_constants.AmzChecksumCRC32:
+		output.ChecksumCRC32 = aws.String(checksum)
+	case s3_constants.AmzChecksumCRC32C:
+		output.ChecksumCRC32C = aws.String(checksum)
+	case s3_constants.AmzChecksumSHA1:
+		output.ChecksumSHA1 = aws.String(checksum)
+	case s3_constants.AmzChecksumSHA256:
+		output.ChecksumSHA256 = aws.String(checksum)
+	}
+}
@@ -3,6 +3,7 @@ package s3api
 import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -135,3 +136,54 @@ func Test_findByPartNumber(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckCompletedParts(t *testing.T) {
+	s3a := &S3ApiServer{option: &S3ApiServerOption{MultipartMinPartSize: 5 * 1024 * 1024}}
+	completedParts := []CompletedPart{{PartNumber: 1}, {PartNumber: 2}}
+
+	assert.Equal(t, s3err.ErrNone, s3a.checkCompletedParts(completedParts, []*MultipartPartInfo{
+		{PartNumber: 1, Size: 5 * 1024 * 1024},
+		{PartNumber: 2, Size: 1},
+	}))
+	assert.Equal(t, s3err.ErrEntityTooSmall, s3a.checkCompletedParts(completedParts, []*MultipartPartInfo{
+		{PartNumber: 1, Size: 1024},
+		{PartNumber: 2, Size: 5 * 1024 * 1024},
+	}))
+	assert.Equal(t, s3err.ErrInvalidPart, s3a.checkCompletedParts(completedParts, []*MultipartPartInfo{
+		{PartNumber: 2, Size: 1},
+	}))
+}
+
+func TestAppendPartChunks(t *testing.T) {
+	// a small part packed into a container chunk with other files
+	packedPart := &filer_pb.Entry{
+		Name: "0002.part",
+		Chunks: []*filer_pb.FileChunk{
+			{FileId: "3,01637037d6", Offset: 0, Size: 100, ContainerOffset: 4096, ContainerSize: 8192, IsCompressed: true},
+		},
+	}
+	part := &filer_pb.Entry{
+		Name: "0001.part",
+		Chunks: []*filer_pb.FileChunk{
+			{FileId: "4,0163", Offset: 0, Size: 200},
+			{FileId: "4,0164", Offset: 200, Size: 50},
+		},
+	}
+
+	chunks, offset := appendPartChunks(part, 0)
+	assert.Equal(t, int64(250), offset)
+	packedChunks, offset := appendPartChunks(packedPart, offset)
+	assert.Equal(t, int64(350), offset)
+	chunks = append(chunks, packedChunks...)
+
+	assert.Equal(t, 3, len(chunks))
+	assert.Equal(t, int64(200), chunks[1].Offset)
+	packed := chunks[2]
+	assert.Equal(t, "3,01637037d6", packed.GetFileIdString())
+	assert.Equal(t, int64(250), packed.Offset)
+	assert.Equal(t, int64(4096), packed.ContainerOffset)
+	assert.Equal(t, uint64(8192), packed.ContainerSize)
+	assert.True(t, packed.IsCompressed)
+	// the part entry is not changed
+	assert.Equal(t, int64(0), packedPart.Chunks[0].Offset)
+}
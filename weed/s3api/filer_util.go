@@ -3,12 +3,17 @@ package s3api
 import (
 	"context"
 	"fmt"
+	"github.com/google/uuid"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 	"strings"
+	"time"
 )
 
+// filerLockLease is the lease of the filer locks taken by lockWithFiler, renewed until unlocked
+const filerLockLease = time.Minute
+
 func (s3a *S3ApiServer) mkdir(parentDirectoryPath string, dirName string, fn func(entry *filer_pb.Entry)) error {
 
 	return filer_pb.Mkdir(s3a, parentDirectoryPath, dirName, fn)
@@ -43,7 +48,7 @@ func (s3a *S3ApiServer) rm(parentDirectoryPath, entryName string, isDeleteData,
 
 	return s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
 
-		err := doDeleteEntry(client, parentDirectoryPath, entryName, isDeleteData, isRecursive)
+		err := doDeleteEntry(client, parentDirectoryPath, entryName, isDeleteData, isRecursive, false)
 		if err != nil {
 			return err
 		}
@@ -53,13 +58,14 @@ func (s3a *S3ApiServer) rm(parentDirectoryPath, entryName string, isDeleteData,
 
 }
 
-func doDeleteEntry(client filer_pb.SeaweedFilerClient, parentDirectoryPath string, entryName string, isDeleteData bool, isRecursive bool) error {
+func doDeleteEntry(client filer_pb.SeaweedFilerClient, parentDirectoryPath string, entryName string, isDeleteData bool, isRecursive bool, bypassGovernance bool) error {
 	request := &filer_pb.DeleteEntryRequest{
-		Directory:            parentDirectoryPath,
-		Name:                 entryName,
-		IsDeleteData:         isDeleteData,
-		IsRecursive:          isRecursive,
-		IgnoreRecursiveError: true,
+		Directory:                 parentDirectoryPath,
+		Name:                      entryName,
+		IsDeleteData:              isDeleteData,
+		IsRecursive:               isRecursive,
+		IgnoreRecursiveError:      true,
+		BypassGovernanceRetention: bypassGovernance,
 	}
 
 	glog.V(1).Infof("delete entry %v/%v: %v", parentDirectoryPath, entryName, request)
@@ -91,6 +97,65 @@ func (s3a *S3ApiServer) getEntry(parentDirectoryPath, entryName string) (entry *
 	return filer_pb.GetEntry(s3a, fullPath)
 }
 
+// lockWithFiler takes an exclusive filer advisory lock on the path, and keeps renewing it until unlocked,
+// so the background work is done by only one of the S3 gateways. isLocked is false if the lock is held by another gateway.
+func (s3a *S3ApiServer) lockWithFiler(lockPath, purpose string) (unlock func(), isLocked bool, err error) {
+	lock := &filer_pb.AdvisoryLock{
+		Owner:       purpose + "." + uuid.New().String(),
+		IsExclusive: true,
+	}
+	acquire := func() (bool, error) {
+		var isAcquired bool
+		err := s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+			resp, err := client.AcquireAdvisoryLock(context.Background(), &filer_pb.AcquireAdvisoryLockRequest{
+				Path:         lockPath,
+				Lock:         lock,
+				LeaseSeconds: int64(filerLockLease / time.Second),
+			})
+			if err != nil {
+				return err
+			}
+			isAcquired = resp.IsAcquired
+			return nil
+		})
+		return isAcquired, err
+	}
+	if isLocked, err = acquire(); err != nil || !isLocked {
+		return nil, isLocked, err
+	}
+
+	// acquiring the lock again by the same owner renews the lease, on whichever filer is current
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(filerLockLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if isAcquired, err := acquire(); err != nil || !isAcquired {
+					glog.Warningf("renew %s lock %s: acquired %v, %v", purpose, lockPath, isAcquired, err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		err := s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+			_, err := client.ReleaseAdvisoryLock(context.Background(), &filer_pb.ReleaseAdvisoryLockRequest{
+				Path:  lockPath,
+				Owner: lock.Owner,
+			})
+			return err
+		})
+		if err != nil {
+			glog.Warningf("release %s lock %s: %v", purpose, lockPath, err)
+		}
+	}, true, nil
+}
+
 func objectKey(key *string) *string {
 	if strings.HasPrefix(*key, "/") {
 		t := (*key)[1:]
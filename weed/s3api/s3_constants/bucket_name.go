@@ -0,0 +1,46 @@
+package s3_constants
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// VerifyBucketName checks the new bucket name against the S3 bucket naming rules,
+// so the bucket is also a valid DNS name in the virtual-hosted-style host {bucket}.{domainName}.
+func VerifyBucketName(name string) error {
+	if len(name) < 3 || len(name) > 63 {
+		return fmt.Errorf("bucket name must be 3 to 63 characters long")
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '.' || c == '-') {
+			return fmt.Errorf("bucket name can only contain lowercase letters, numbers, dots and hyphens")
+		}
+	}
+	if !isLetterOrNumber(name[0]) || !isLetterOrNumber(name[len(name)-1]) {
+		return fmt.Errorf("bucket name must begin and end with a letter or number")
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Errorf("bucket name must have no adjacent dots, and no hyphens next to the dots")
+		}
+	}
+	if net.ParseIP(name) != nil {
+		return fmt.Errorf("bucket name must not be formatted as an IP address")
+	}
+	for _, prefix := range []string{"xn--", "sthree-"} {
+		if strings.HasPrefix(name, prefix) {
+			return fmt.Errorf("bucket name must not start with %s", prefix)
+		}
+	}
+	for _, suffix := range []string{"-s3alias", "--ol-s3"} {
+		if strings.HasSuffix(name, suffix) {
+			return fmt.Errorf("bucket name must not end with %s", suffix)
+		}
+	}
+	return nil
+}
+
+func isLetterOrNumber(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,32 @@
+package s3_constants
+
+import "testing"
+
+func TestVerifyBucketName(t *testing.T) {
+	cases := []struct {
+		name  string
+		valid bool
+	}{
+		{"my-bucket", true},
+		{"my.bucket.2024", true},
+		{"abc", true},
+		{"ab", false},
+		{"a123456789012345678901234567890123456789012345678901234567890123", false},
+		{"My-Bucket", false},
+		{"my_bucket", false},
+		{"-bucket", false},
+		{"bucket-", false},
+		{"my..bucket", false},
+		{"my.-bucket", false},
+		{"192.168.5.4", false},
+		{"xn--bucket", false},
+		{"sthree-bucket", false},
+		{"bucket-s3alias", false},
+		{"bucket--ol-s3", false},
+	}
+	for _, c := range cases {
+		if err := VerifyBucketName(c.name); (err == nil) != c.valid {
+			t.Errorf("%s: expected valid %v, actual error %v", c.name, c.valid, err)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package s3_constants
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"hash"
+	"hash/crc32"
+)
+
+var AmzChecksumHeaders = []string{AmzChecksumCRC32, AmzChecksumCRC32C, AmzChecksumSHA1, AmzChecksumSHA256}
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// NewAmzChecksumHash returns the hash for one of the x-amz-checksum-* headers, or nil if the header is unknown.
+func NewAmzChecksumHash(header string) hash.Hash {
+	switch header {
+	case AmzChecksumCRC32:
+		return crc32.NewIEEE()
+	case AmzChecksumCRC32C:
+		return crc32.New(crc32cTable)
+	case AmzChecksumSHA1:
+		return sha1.New()
+	case AmzChecksumSHA256:
+		return sha256.New()
+	}
+	return nil
+}
@@ -37,7 +37,40 @@ const (
 	AmzObjectTaggingDirective = "X-Amz-Tagging-Directive"
 	AmzTagCount               = "x-amz-tagging-count"
 
+	// S3 object checksums
+	AmzChecksumCRC32  = "X-Amz-Checksum-Crc32"
+	AmzChecksumCRC32C = "X-Amz-Checksum-Crc32c"
+	AmzChecksumSHA1   = "X-Amz-Checksum-Sha1"
+	AmzChecksumSHA256 = "X-Amz-Checksum-Sha256"
+
+	// S3 object attributes
+	AmzObjectAttributes = "X-Amz-Object-Attributes"
+	AmzMaxParts         = "X-Amz-Max-Parts"
+	AmzPartNumberMarker = "X-Amz-Part-Number-Marker"
+
+	// S3 object lock, also kept in the extended attributes of the object
+	AmzObjectLockMode            = "X-Amz-Object-Lock-Mode"
+	AmzObjectLockRetainUntilDate = "X-Amz-Object-Lock-Retain-Until-Date"
+	AmzObjectLockLegalHold       = "X-Amz-Object-Lock-Legal-Hold"
+	AmzBypassGovernanceRetention = "X-Amz-Bypass-Governance-Retention"
+	AmzBucketObjectLockEnabled   = "X-Amz-Bucket-Object-Lock-Enabled"
+
+	// the object size of a streaming upload, without the chunk signatures
+	AmzDecodedContentLength = "X-Amz-Decoded-Content-Length"
+
 	X_SeaweedFS_Header_Directory_Key = "x-seaweedfs-is-directory-key"
+	// the part numbers, sizes and checksums of a completed multipart upload
+	X_SeaweedFS_Multipart_Parts = "X-Seaweedfs-Multipart-Parts"
+	// the bucket quota on the object count, and the usage percentage to warn at, kept in the bucket entry
+	X_SeaweedFS_Quota_Objects         = "X-Seaweedfs-Quota-Objects"
+	X_SeaweedFS_Quota_Warning_Percent = "X-Seaweedfs-Quota-Warning-Percent"
+	// the inventory configurations by id, and when each inventory was generated last, kept in the bucket entry
+	X_SeaweedFS_Inventory_Config_Prefix    = "X-Seaweedfs-Inventory-Config-"
+	X_SeaweedFS_Inventory_Generated_Prefix = "X-Seaweedfs-Inventory-Generated-"
+	// the lifecycle rules aborting the incomplete multipart uploads, kept in the bucket entry
+	X_SeaweedFS_Lifecycle_Abort_Multipart = "X-Seaweedfs-Lifecycle-Abort-Multipart"
+	// the object lock configuration, kept in the bucket entry if the object lock is enabled
+	X_SeaweedFS_Object_Lock_Config = "X-Seaweedfs-Object-Lock-Config"
 )
 
 // Non-Standard S3 HTTP request constants
@@ -0,0 +1,41 @@
+package s3_ui
+
+import (
+	_ "embed"
+	"html/template"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// BucketListing is one page of the objects and folders under a prefix of a bucket
+type BucketListing struct {
+	Bucket        string
+	Prefix        string
+	Breadcrumbs   []Link
+	Entries       []ListingEntry
+	FirstPageLink string // empty on the first page
+	NextPageLink  string // empty on the last page
+}
+
+type Link struct {
+	Name string
+	Link string
+}
+
+type ListingEntry struct {
+	Name         string
+	Link         string
+	IsFolder     bool
+	Size         uint64
+	LastModified time.Time
+}
+
+var funcMap = template.FuncMap{
+	"bytesToHumanReadable": util.BytesToHumanReadable,
+}
+
+//go:embed bucket.html
+var bucketHtml string
+
+var BucketListingTpl = template.Must(template.New("bucket").Funcs(funcMap).Parse(bucketHtml))
@@ -0,0 +1,77 @@
+package s3api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/audit"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+// auditRequest logs the authentication failures, the changes to the buckets, and the object access.
+func (s3a *S3ApiServer) auditRequest(r *http.Request, action string, status int, duration time.Duration, trace *s3err.RequestTrace) {
+	if s3a.auditLog == nil {
+		return
+	}
+	trace = trace.Snapshot()
+	bucket, object := s3_constants.GetBucketAndObject(r)
+
+	e := &audit.Event{
+		Time:      trace.Time,
+		RequestId: trace.RequestId,
+		RemoteIp:  r.RemoteAddr,
+		Identity:  trace.Identity,
+		AuthType:  r.Header.Get(s3_constants.AmzAuthType),
+		Action:    action,
+		Method:    r.Method,
+		Bucket:    bucket,
+		Status:    status,
+		ErrorCode: trace.ErrorCode,
+		Duration:  duration.String(),
+	}
+	if object != "/" {
+		e.Path = object
+	}
+
+	switch {
+	case trace.AuthDenied:
+		e.Type = audit.TypeAuth
+		e.AccessKey = requestAccessKey(r)
+	case object == "/" && (r.Method == http.MethodPut || r.Method == http.MethodDelete):
+		// creating or deleting the bucket, or changing its policy, tagging, and so on
+		e.Type = audit.TypeAdmin
+	default:
+		e.Type = audit.TypeAccess
+	}
+
+	switch {
+	case trace.AuthDenied || status == http.StatusForbidden:
+		e.Result = audit.ResultDenied
+	case status >= http.StatusBadRequest:
+		e.Result = audit.ResultFailed
+	default:
+		e.Result = audit.ResultAllowed
+	}
+
+	s3a.auditLog.Log(e)
+}
+
+// requestAccessKey finds the access key of the signed or presigned request, to audit failed authentications.
+func requestAccessKey(r *http.Request) string {
+	if credential := r.URL.Query().Get("X-Amz-Credential"); credential != "" {
+		return strings.SplitN(credential, "/", 2)[0]
+	}
+	if accessKey := r.URL.Query().Get("AWSAccessKeyId"); accessKey != "" {
+		return accessKey
+	}
+	authorization := r.Header.Get("Authorization")
+	if _, credential, found := strings.Cut(authorization, "Credential="); found {
+		return strings.SplitN(credential, "/", 2)[0]
+	}
+	if strings.HasPrefix(authorization, signV2Algorithm+" ") {
+		return strings.SplitN(strings.TrimPrefix(authorization, signV2Algorithm+" "), ":", 2)[0]
+	}
+	return ""
+}
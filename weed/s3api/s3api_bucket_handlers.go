@@ -11,7 +11,6 @@ import (
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
-	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 
@@ -51,12 +50,16 @@ func (s3a *S3ApiServer) ListBucketsHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	identityId := r.Header.Get(s3_constants.AmzIdentityId)
+	// the route is not authenticated by iam.Auth, so the identity header is not trusted here
+	var identityId string
+	if identity != nil {
+		identityId = identity.Name
+	}
 
 	var buckets []*s3.Bucket
 	for _, entry := range entries {
 		if entry.IsDirectory {
-			if identity != nil && !identity.canDo(s3_constants.ACTION_LIST, entry.Name, "") {
+			if !isBucketVisibleTo(identity, entry) {
 				continue
 			}
 			buckets = append(buckets, &s3.Bucket{
@@ -77,11 +80,35 @@ func (s3a *S3ApiServer) ListBucketsHandler(w http.ResponseWriter, r *http.Reques
 	writeSuccessResponseXML(w, r, response)
 }
 
+// isBucketVisibleTo checks the identity can list the bucket, and owns it unless it is an admin.
+// All buckets are visible without the identity, when the authentication is disabled.
+func isBucketVisibleTo(identity *Identity, entry *filer_pb.Entry) bool {
+	if identity == nil {
+		return true
+	}
+	if !identity.canDo(s3_constants.ACTION_LIST, entry.Name, "") {
+		return false
+	}
+	if identity.isAdmin() {
+		return true
+	}
+	if owner, found := entry.Extended[s3_constants.AmzIdentityId]; found && string(owner) != identity.Name {
+		return false
+	}
+	return true
+}
+
 func (s3a *S3ApiServer) PutBucketHandler(w http.ResponseWriter, r *http.Request) {
 
 	bucket, _ := s3_constants.GetBucketAndObject(r)
 	glog.V(3).Infof("PutBucketHandler %s", bucket)
 
+	if err := s3_constants.VerifyBucketName(bucket); err != nil {
+		glog.V(1).Infof("PutBucketHandler %s: %v", bucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidBucketName)
+		return
+	}
+
 	// avoid duplicated buckets
 	errCode := s3err.ErrNone
 	if err := s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
@@ -119,22 +146,37 @@ func (s3a *S3ApiServer) PutBucketHandler(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	// the object lock can only be enabled when creating the bucket
+	var objectLockConfig []byte
+	if r.Header.Get(s3_constants.AmzBucketObjectLockEnabled) == "true" {
+		objectLockConfig, _ = xml.Marshal(&ObjectLockConfiguration{ObjectLockEnabled: ObjectLockEnabled})
+	}
+
 	fn := func(entry *filer_pb.Entry) {
+		if entry.Extended == nil {
+			entry.Extended = make(map[string][]byte)
+		}
 		if identityId := r.Header.Get(s3_constants.AmzIdentityId); identityId != "" {
-			if entry.Extended == nil {
-				entry.Extended = make(map[string][]byte)
-			}
 			entry.Extended[s3_constants.AmzIdentityId] = []byte(identityId)
 		}
+		if objectLockConfig != nil {
+			entry.Extended[s3_constants.X_SeaweedFS_Object_Lock_Config] = objectLockConfig
+		}
 	}
 
 	// create the folder for bucket, but lazily create actual collection
 	if err := s3a.mkdir(s3a.option.BucketsPath, bucket, fn); err != nil {
 		glog.Errorf("PutBucketHandler mkdir: %v", err)
+		s3err.RecordInternalError(r, fmt.Errorf("create bucket %s: %w", bucket, err))
 		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
 		return
 	}
-	w.Header().Set("Location", "/"+bucket)
+	// the virtual-hosted-style requests get the url of the bucket, as S3 does
+	if _, _, isVirtualHost := bucketFromHost(r.Host, s3a.domainNames); isVirtualHost {
+		w.Header().Set("Location", s3a.objectUrl(r, bucket, ""))
+	} else {
+		w.Header().Set("Location", "/"+bucket)
+	}
 	writeSuccessResponseEmpty(w, r)
 }
 
@@ -178,6 +220,9 @@ func (s3a *S3ApiServer) DeleteBucketHandler(w http.ResponseWriter, r *http.Reque
 		s3ErrorCode := s3err.ErrInternalError
 		if err.Error() == s3err.GetAPIError(s3err.ErrBucketNotEmpty).Code {
 			s3ErrorCode = s3err.ErrBucketNotEmpty
+		} else if code := objectLockErrorCode(err.Error()); code != s3err.ErrNone {
+			// the filer refuses to delete the collection of a bucket with locked objects
+			s3ErrorCode = code
 		}
 		s3err.WriteErrorResponse(w, r, s3ErrorCode)
 		return
@@ -287,21 +332,21 @@ func (s3a *S3ApiServer) GetBucketLifecycleConfigurationHandler(w http.ResponseWr
 		s3err.WriteErrorResponse(w, r, err)
 		return
 	}
-	fc, err := filer.ReadFilerConf(s3a.option.Filer, s3a.option.GrpcDialOption, nil)
+	fc, err := filer.ReadFilerConf(s3a.filers.Current(), s3a.option.GrpcDialOption, nil)
 	if err != nil {
 		glog.Errorf("GetBucketLifecycleConfigurationHandler: %s", err)
+		s3err.RecordInternalError(r, fmt.Errorf("read filer configuration: %w", err))
 		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
 		return
 	}
-	ttls := fc.GetCollectionTtls(bucket)
-	if len(ttls) == 0 {
-		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchLifecycleConfiguration)
+	entry, err := s3a.getEntry(s3a.option.BucketsPath, bucket)
+	if err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchBucket)
 		return
 	}
 	response := Lifecycle{}
-	for prefix, internalTtl := range ttls {
-		ttl, _ := needle.ReadTTL(internalTtl)
-		days := int(ttl.Minutes() / 60 / 24)
+	for prefix, internalTtl := range fc.GetCollectionTtls(bucket) {
+		days := ttlDays(internalTtl)
 		if days == 0 {
 			continue
 		}
@@ -313,23 +358,94 @@ func (s3a *S3ApiServer) GetBucketLifecycleConfigurationHandler(w http.ResponseWr
 			Expiration: Expiration{Days: days, set: true},
 		})
 	}
+	response.Rules = append(response.Rules, readAbortMultipartRules(entry)...)
+	if len(response.Rules) == 0 {
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchLifecycleConfiguration)
+		return
+	}
 	writeSuccessResponseXML(w, r, response)
 }
 
 // PutBucketLifecycleConfigurationHandler Put Bucket Lifecycle configuration
 // https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketLifecycleConfiguration.html
+// Only the rules aborting the incomplete multipart uploads are supported, and replace the ones put before.
+// The expiration is configured as the ttl of the locations in the filer configuration,
+// the expiration rules are accepted only if they match it, as returned by GetBucketLifecycleConfigurationHandler.
 func (s3a *S3ApiServer) PutBucketLifecycleConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("PutBucketLifecycleConfigurationHandler %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
 
-	s3err.WriteErrorResponse(w, r, s3err.ErrNotImplemented)
+	lifecycle := &Lifecycle{}
+	if err := xmlDecoder(r.Body, lifecycle, r.ContentLength); err != nil {
+		glog.V(1).Infof("PutBucketLifecycleConfigurationHandler %s: %v", bucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+	fc, err := filer.ReadFilerConf(s3a.filers.Current(), s3a.option.GrpcDialOption, nil)
+	if err != nil {
+		glog.Errorf("PutBucketLifecycleConfigurationHandler: %s", err)
+		s3err.RecordInternalError(r, fmt.Errorf("read filer configuration: %w", err))
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+	if errCode := validateLifecycle(lifecycle, fc.GetCollectionTtls(bucket)); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	var lifecycleXml []byte
+	if rules := abortMultipartRules(lifecycle); len(rules) > 0 {
+		if lifecycleXml, err = xml.Marshal(&Lifecycle{Rules: rules}); err != nil {
+			s3err.RecordInternalError(r, fmt.Errorf("marshal lifecycle configuration: %w", err))
+			s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+			return
+		}
+	}
+	err = s3a.updateBucketExtended(bucket, func(extended map[string][]byte) error {
+		if lifecycleXml == nil {
+			delete(extended, s3_constants.X_SeaweedFS_Lifecycle_Abort_Multipart)
+			return nil
+		}
+		extended[s3_constants.X_SeaweedFS_Lifecycle_Abort_Multipart] = lifecycleXml
+		return nil
+	})
+	if err != nil {
+		glog.Errorf("PutBucketLifecycleConfigurationHandler %s: %v", bucket, err)
+		s3err.RecordInternalError(r, fmt.Errorf("save lifecycle configuration: %w", err))
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
 
+	writeSuccessResponseEmpty(w, r)
 }
 
 // DeleteBucketMetricsConfiguration Delete Bucket Lifecycle
 // https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketLifecycle.html
+// The rules aborting the incomplete multipart uploads are deleted, the ttl of the locations are kept.
 func (s3a *S3ApiServer) DeleteBucketLifecycleHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("DeleteBucketLifecycleHandler %s", bucket)
 
-	s3err.WriteEmptyResponse(w, r, http.StatusNoContent)
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+	err := s3a.updateBucketExtended(bucket, func(extended map[string][]byte) error {
+		delete(extended, s3_constants.X_SeaweedFS_Lifecycle_Abort_Multipart)
+		return nil
+	})
+	if err != nil {
+		glog.Errorf("DeleteBucketLifecycleHandler %s: %v", bucket, err)
+		s3err.RecordInternalError(r, fmt.Errorf("delete lifecycle configuration: %w", err))
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
 
+	s3err.WriteEmptyResponse(w, r, http.StatusNoContent)
 }
 
 // GetBucketLocationHandler Get bucket location
@@ -1,6 +1,8 @@
 package s3api
 
 import (
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 	"testing"
 	"time"
@@ -38,3 +40,40 @@ func TestListBucketsHandler(t *testing.T) {
 		t.Errorf("unexpected output: %s\nexpecting:%s", encoded, expected)
 	}
 }
+
+func TestIsBucketVisibleTo(t *testing.T) {
+	ownedBy := func(name, owner string) *filer_pb.Entry {
+		entry := &filer_pb.Entry{Name: name, IsDirectory: true}
+		if owner != "" {
+			entry.Extended = map[string][]byte{s3_constants.AmzIdentityId: []byte(owner)}
+		}
+		return entry
+	}
+	admin := &Identity{Name: "admin", Actions: []Action{"Admin"}}
+	tenant := &Identity{Name: "tenant", Actions: []Action{"List", "Read"}}
+	scoped := &Identity{Name: "scoped", Actions: []Action{"List:logs"}}
+
+	tests := []struct {
+		identity *Identity
+		entry    *filer_pb.Entry
+		visible  bool
+	}{
+		{nil, ownedBy("b1", "tenant"), true},
+		{admin, ownedBy("b1", "tenant"), true},
+		{tenant, ownedBy("b1", "tenant"), true},
+		{tenant, ownedBy("b2", "other"), false},
+		{tenant, ownedBy("b3", ""), true},
+		{scoped, ownedBy("logs", ""), true},
+		{scoped, ownedBy("data", ""), false},
+		{scoped, ownedBy("logs", "tenant"), false},
+	}
+	for _, tt := range tests {
+		var name string
+		if tt.identity != nil {
+			name = tt.identity.Name
+		}
+		if visible := isBucketVisibleTo(tt.identity, tt.entry); visible != tt.visible {
+			t.Errorf("bucket %s visible to %q: got %v, want %v", tt.entry.Name, name, visible, tt.visible)
+		}
+	}
+}
@@ -0,0 +1,563 @@
+package s3api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	inventoryCheckInterval = time.Hour
+	inventoryRowsPerFile   = 1000000
+	inventoryArnPrefix     = "arn:aws:s3:::"
+)
+
+// inventoryFields are the supported optional fields, in the column order of the reports.
+var inventoryFields = []string{"Size", "LastModifiedDate", "ETag", "StorageClass"}
+
+var inventoryFrequencies = map[string]time.Duration{
+	"Daily":  24 * time.Hour,
+	"Weekly": 7 * 24 * time.Hour,
+}
+
+// InventoryConfiguration is the S3 inventory configuration of a bucket.
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_InventoryConfiguration.html
+type InventoryConfiguration struct {
+	XMLName                xml.Name             `xml:"InventoryConfiguration"`
+	XMLNS                  string               `xml:"xmlns,attr,omitempty"`
+	Id                     string               `xml:"Id"`
+	IsEnabled              bool                 `xml:"IsEnabled"`
+	Destination            InventoryDestination `xml:"Destination"`
+	Filter                 *InventoryFilter     `xml:"Filter,omitempty"`
+	IncludedObjectVersions string               `xml:"IncludedObjectVersions"`
+	OptionalFields         []string             `xml:"OptionalFields>Field,omitempty"`
+	Schedule               InventorySchedule    `xml:"Schedule"`
+}
+
+type InventoryDestination struct {
+	S3BucketDestination InventoryS3BucketDestination `xml:"S3BucketDestination"`
+}
+
+type InventoryS3BucketDestination struct {
+	AccountId string `xml:"AccountId,omitempty"`
+	Bucket    string `xml:"Bucket"`
+	Format    string `xml:"Format"`
+	Prefix    string `xml:"Prefix,omitempty"`
+}
+
+type InventoryFilter struct {
+	Prefix string `xml:"Prefix"`
+}
+
+type InventorySchedule struct {
+	Frequency string `xml:"Frequency"`
+}
+
+type ListInventoryConfigurationsResult struct {
+	XMLName                 xml.Name                  `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListInventoryConfigurationsResult"`
+	InventoryConfigurations []*InventoryConfiguration `xml:"InventoryConfiguration"`
+	IsTruncated             bool                      `xml:"IsTruncated"`
+}
+
+// inventoryManifest is the manifest.json of a generated inventory, in the S3 inventory format.
+type inventoryManifest struct {
+	SourceBucket      string                  `json:"sourceBucket"`
+	DestinationBucket string                  `json:"destinationBucket"`
+	Version           string                  `json:"version"`
+	CreationTimestamp string                  `json:"creationTimestamp"`
+	FileFormat        string                  `json:"fileFormat"`
+	FileSchema        string                  `json:"fileSchema"`
+	Files             []inventoryManifestFile `json:"files"`
+}
+
+type inventoryManifestFile struct {
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	MD5Checksum string `json:"MD5checksum"`
+}
+
+func (c *InventoryConfiguration) destinationBucket() string {
+	return strings.TrimPrefix(c.Destination.S3BucketDestination.Bucket, inventoryArnPrefix)
+}
+
+func (c *InventoryConfiguration) filterPrefix() string {
+	if c.Filter == nil {
+		return ""
+	}
+	return c.Filter.Prefix
+}
+
+// validate checks the configuration can be generated, only CSV reports of the current objects are supported.
+// The Parquet and ORC formats are not supported.
+func (c *InventoryConfiguration) validate(id string) s3err.ErrorCode {
+	if c.Id == "" || c.Id != id || c.destinationBucket() == "" {
+		return s3err.ErrInvalidInventoryConfiguration
+	}
+	switch c.Destination.S3BucketDestination.Format {
+	case "CSV":
+	case "Parquet", "ORC":
+		return s3err.ErrUnsupportedInventoryFormat
+	default:
+		return s3err.ErrInvalidInventoryConfiguration
+	}
+	if c.IncludedObjectVersions != "Current" {
+		return s3err.ErrInvalidInventoryConfiguration
+	}
+	if _, found := inventoryFrequencies[c.Schedule.Frequency]; !found {
+		return s3err.ErrInvalidInventoryConfiguration
+	}
+	for _, field := range c.OptionalFields {
+		if !isInventoryField(field) {
+			return s3err.ErrInvalidInventoryConfiguration
+		}
+	}
+	return s3err.ErrNone
+}
+
+func isInventoryField(field string) bool {
+	for _, f := range inventoryFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// columns are the report columns, the bucket and key followed by the optional fields in a fixed order.
+func (c *InventoryConfiguration) columns() []string {
+	columns := []string{"Bucket", "Key"}
+	for _, field := range inventoryFields {
+		for _, f := range c.OptionalFields {
+			if f == field {
+				columns = append(columns, field)
+				break
+			}
+		}
+	}
+	return columns
+}
+
+func readInventoryConfigurations(entry *filer_pb.Entry) (configs []*InventoryConfiguration) {
+	for key, value := range entry.Extended {
+		if !strings.HasPrefix(key, s3_constants.X_SeaweedFS_Inventory_Config_Prefix) {
+			continue
+		}
+		config := &InventoryConfiguration{}
+		if err := xml.Unmarshal(value, config); err != nil {
+			glog.Warningf("bucket %s inventory configuration %s: %v", entry.Name, key, err)
+			continue
+		}
+		configs = append(configs, config)
+	}
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].Id < configs[j].Id
+	})
+	return
+}
+
+// updateBucketExtended changes the extended attributes of the bucket entry.
+func (s3a *S3ApiServer) updateBucketExtended(bucket string, fn func(extended map[string][]byte) error) error {
+	return s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := filer_pb.LookupEntry(client, &filer_pb.LookupDirectoryEntryRequest{
+			Directory: s3a.option.BucketsPath,
+			Name:      bucket,
+		})
+		if err != nil {
+			return err
+		}
+		if resp.Entry.Extended == nil {
+			resp.Entry.Extended = make(map[string][]byte)
+		}
+		if err = fn(resp.Entry.Extended); err != nil {
+			return err
+		}
+		return filer_pb.UpdateEntry(client, &filer_pb.UpdateEntryRequest{
+			Directory: s3a.option.BucketsPath,
+			Entry:     resp.Entry,
+		})
+	})
+}
+
+// PutBucketInventoryConfigurationHandler Put bucket inventory configuration
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketInventoryConfiguration.html
+func (s3a *S3ApiServer) PutBucketInventoryConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	id := r.URL.Query().Get("id")
+	glog.V(3).Infof("PutBucketInventoryConfigurationHandler %s %s", bucket, id)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	config := &InventoryConfiguration{}
+	if err := xmlDecoder(r.Body, config, r.ContentLength); err != nil {
+		glog.V(1).Infof("PutBucketInventoryConfigurationHandler %s %s: %v", bucket, id, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+	if errCode := config.validate(id); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	// the reports are written with the permissions of the requester
+	if errCode := s3a.checkBucket(r, config.destinationBucket()); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	config.XMLNS = ""
+	configXml, err := xml.Marshal(config)
+	if err != nil {
+		s3err.RecordInternalError(r, fmt.Errorf("marshal inventory configuration: %w", err))
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+	err = s3a.updateBucketExtended(bucket, func(extended map[string][]byte) error {
+		extended[s3_constants.X_SeaweedFS_Inventory_Config_Prefix+id] = configXml
+		return nil
+	})
+	if err != nil {
+		glog.Errorf("PutBucketInventoryConfigurationHandler %s %s: %v", bucket, id, err)
+		s3err.RecordInternalError(r, fmt.Errorf("save inventory configuration: %w", err))
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	writeSuccessResponseEmpty(w, r)
+}
+
+// GetBucketInventoryConfigurationHandler Get bucket inventory configuration
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketInventoryConfiguration.html
+func (s3a *S3ApiServer) GetBucketInventoryConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	id := r.URL.Query().Get("id")
+	glog.V(3).Infof("GetBucketInventoryConfigurationHandler %s %s", bucket, id)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+	entry, err := s3a.getEntry(s3a.option.BucketsPath, bucket)
+	if err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchBucket)
+		return
+	}
+	for _, config := range readInventoryConfigurations(entry) {
+		if config.Id == id {
+			config.XMLNS = "http://s3.amazonaws.com/doc/2006-03-01/"
+			writeSuccessResponseXML(w, r, config)
+			return
+		}
+	}
+	s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchConfiguration)
+}
+
+// ListBucketInventoryConfigurationsHandler List bucket inventory configurations
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListBucketInventoryConfigurations.html
+func (s3a *S3ApiServer) ListBucketInventoryConfigurationsHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("ListBucketInventoryConfigurationsHandler %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+	entry, err := s3a.getEntry(s3a.option.BucketsPath, bucket)
+	if err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchBucket)
+		return
+	}
+	writeSuccessResponseXML(w, r, ListInventoryConfigurationsResult{
+		InventoryConfigurations: readInventoryConfigurations(entry),
+	})
+}
+
+// DeleteBucketInventoryConfigurationHandler Delete bucket inventory configuration
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketInventoryConfiguration.html
+func (s3a *S3ApiServer) DeleteBucketInventoryConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	id := r.URL.Query().Get("id")
+	glog.V(3).Infof("DeleteBucketInventoryConfigurationHandler %s %s", bucket, id)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+	isFound := false
+	err := s3a.updateBucketExtended(bucket, func(extended map[string][]byte) error {
+		key := s3_constants.X_SeaweedFS_Inventory_Config_Prefix + id
+		_, isFound = extended[key]
+		delete(extended, key)
+		delete(extended, s3_constants.X_SeaweedFS_Inventory_Generated_Prefix+id)
+		return nil
+	})
+	if err != nil {
+		glog.Errorf("DeleteBucketInventoryConfigurationHandler %s %s: %v", bucket, id, err)
+		s3err.RecordInternalError(r, fmt.Errorf("delete inventory configuration: %w", err))
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+	if !isFound {
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchConfiguration)
+		return
+	}
+	s3err.WriteEmptyResponse(w, r, http.StatusNoContent)
+}
+
+func (s3a *S3ApiServer) loopGenerateInventories(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		if err := s3a.generateDueInventories(time.Now()); err != nil {
+			glog.V(0).Infof("generate bucket inventories: %v", err)
+		}
+	}
+}
+
+// generateDueInventories generates the enabled inventories not generated within their frequency.
+func (s3a *S3ApiServer) generateDueInventories(now time.Time) error {
+	entries, _, err := s3a.list(s3a.option.BucketsPath, "", "", false, math.MaxInt32)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDirectory {
+			continue
+		}
+		for _, config := range readInventoryConfigurations(entry) {
+			if !config.IsEnabled || !isInventoryDue(entry, config, now) {
+				continue
+			}
+			if err := s3a.generateInventoryWithLock(entry.Name, config, now); err != nil {
+				glog.Warningf("generate inventory %s of bucket %s: %v", config.Id, entry.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// generateInventoryWithLock generates and records one inventory while holding the filer lock of the bucket,
+// so an inventory is generated by only one of the S3 gateways.
+func (s3a *S3ApiServer) generateInventoryWithLock(bucket string, config *InventoryConfiguration, now time.Time) error {
+	unlock, isLocked, err := s3a.lockInventory(bucket)
+	if err != nil || !isLocked {
+		return err
+	}
+	defer unlock()
+
+	// another gateway may have generated it before the lock was taken
+	entry, err := s3a.getEntry(s3a.option.BucketsPath, bucket)
+	if err != nil {
+		return err
+	}
+	if !isInventoryDue(entry, config, now) {
+		return nil
+	}
+	if err = s3a.generateInventory(bucket, config, now); err != nil {
+		return err
+	}
+	err = s3a.updateBucketExtended(bucket, func(extended map[string][]byte) error {
+		extended[s3_constants.X_SeaweedFS_Inventory_Generated_Prefix+config.Id] = []byte(strconv.FormatInt(now.Unix(), 10))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("record: %v", err)
+	}
+	return nil
+}
+
+// lockInventory takes the filer lock of the bucket directory. isLocked is false if the lock is held by another gateway.
+func (s3a *S3ApiServer) lockInventory(bucket string) (unlock func(), isLocked bool, err error) {
+	return s3a.lockWithFiler(string(util.FullPath(s3a.option.BucketsPath).Child(bucket)), "s3.inventory")
+}
+
+func isInventoryDue(entry *filer_pb.Entry, config *InventoryConfiguration, now time.Time) bool {
+	generated, err := strconv.ParseInt(string(entry.Extended[s3_constants.X_SeaweedFS_Inventory_Generated_Prefix+config.Id]), 10, 64)
+	if err != nil {
+		return true
+	}
+	return now.Sub(time.Unix(generated, 0)) >= inventoryFrequencies[config.Schedule.Frequency]
+}
+
+// inventoryWriter writes the rows of the gzipped CSV data files, and uploads a data file when it is full.
+type inventoryWriter struct {
+	buffer    bytes.Buffer
+	gzWriter  *gzip.Writer
+	csvWriter *csv.Writer
+	rows      int
+	upload    func(data []byte) error
+}
+
+func newInventoryWriter(upload func(data []byte) error) *inventoryWriter {
+	iw := &inventoryWriter{upload: upload}
+	iw.gzWriter = gzip.NewWriter(&iw.buffer)
+	iw.csvWriter = csv.NewWriter(iw.gzWriter)
+	return iw
+}
+
+func (iw *inventoryWriter) write(row []string) error {
+	if err := iw.csvWriter.Write(row); err != nil {
+		return err
+	}
+	iw.rows++
+	if iw.rows >= inventoryRowsPerFile {
+		return iw.flush()
+	}
+	return nil
+}
+
+func (iw *inventoryWriter) flush() error {
+	if iw.rows == 0 {
+		return nil
+	}
+	iw.csvWriter.Flush()
+	if err := iw.csvWriter.Error(); err != nil {
+		return err
+	}
+	if err := iw.gzWriter.Close(); err != nil {
+		return err
+	}
+	if err := iw.upload(iw.buffer.Bytes()); err != nil {
+		return err
+	}
+	iw.buffer.Reset()
+	iw.gzWriter.Reset(&iw.buffer)
+	iw.rows = 0
+	return nil
+}
+
+func inventoryRow(bucket, key string, entry *filer_pb.Entry, columns []string) []string {
+	row := make([]string, 0, len(columns))
+	for _, column := range columns {
+		switch column {
+		case "Bucket":
+			row = append(row, bucket)
+		case "Key":
+			row = append(row, url.QueryEscape(key))
+		case "Size":
+			row = append(row, strconv.FormatUint(filer.FileSize(entry), 10))
+		case "LastModifiedDate":
+			row = append(row, time.Unix(entry.Attributes.Mtime, 0).UTC().Format("2006-01-02T15:04:05.000Z"))
+		case "ETag":
+			row = append(row, filer.ETag(entry))
+		case "StorageClass":
+			row = append(row, storageClassOf(entry))
+		}
+	}
+	return row
+}
+
+// generateInventory lists the current objects of the bucket into the destination,
+// with the data files and the manifest laid out as S3 inventory does.
+func (s3a *S3ApiServer) generateInventory(bucket string, config *InventoryConfiguration, now time.Time) error {
+	destinationBucket := config.destinationBucket()
+	destinationPrefix := strings.Trim(config.Destination.S3BucketDestination.Prefix, "/")
+	basePath := fmt.Sprintf("%s/%s", bucket, config.Id)
+	if destinationPrefix != "" {
+		basePath = destinationPrefix + "/" + basePath
+	}
+	columns := config.columns()
+	glog.V(0).Infof("generate inventory %s of bucket %s to %s/%s", config.Id, bucket, destinationBucket, basePath)
+
+	manifest := &inventoryManifest{
+		SourceBucket:      bucket,
+		DestinationBucket: inventoryArnPrefix + destinationBucket,
+		Version:           "2016-11-30",
+		CreationTimestamp: strconv.FormatInt(now.UnixMilli(), 10),
+		FileFormat:        "CSV",
+		FileSchema:        strings.Join(columns, ", "),
+		Files:             []inventoryManifestFile{},
+	}
+	iw := newInventoryWriter(func(data []byte) error {
+		key := fmt.Sprintf("%s/data/%s.csv.gz", basePath, uuid.New().String())
+		md5sum, err := s3a.putInventoryFile(destinationBucket, key, data)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, inventoryManifestFile{Key: key, Size: int64(len(data)), MD5Checksum: md5sum})
+		return nil
+	})
+
+	bucketDir := util.FullPath(s3a.option.BucketsPath).Child(bucket)
+	prefix := config.filterPrefix()
+	err := s3a.walkInventoryEntries(bucketDir, "", prefix, func(key string, entry *filer_pb.Entry) error {
+		return iw.write(inventoryRow(bucket, key, entry, columns))
+	})
+	if err == nil {
+		err = iw.flush()
+	}
+	if err != nil {
+		return err
+	}
+
+	manifestJson, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	manifestDir := fmt.Sprintf("%s/%s", basePath, now.UTC().Format("2006-01-02T15-04Z"))
+	md5sum, err := s3a.putInventoryFile(destinationBucket, manifestDir+"/manifest.json", manifestJson)
+	if err != nil {
+		return err
+	}
+	_, err = s3a.putInventoryFile(destinationBucket, manifestDir+"/manifest.checksum", []byte(md5sum))
+	return err
+}
+
+// walkInventoryEntries visits the objects under the directory in key order, skipping the pending multipart uploads.
+func (s3a *S3ApiServer) walkInventoryEntries(dir util.FullPath, keyPrefix, filterPrefix string, fn func(key string, entry *filer_pb.Entry) error) error {
+	return filer_pb.ReadDirAllEntries(s3a, dir, "", func(entry *filer_pb.Entry, isLast bool) error {
+		key := keyPrefix + entry.Name
+		if entry.IsDirectory {
+			if keyPrefix == "" && entry.Name == s3_constants.MultipartUploadsFolder {
+				return nil
+			}
+			// skip the directories out of the filter prefix
+			if !strings.HasPrefix(key+"/", filterPrefix) && !strings.HasPrefix(filterPrefix, key+"/") {
+				return nil
+			}
+			if entry.IsDirectoryKeyObject() && strings.HasPrefix(key+"/", filterPrefix) {
+				if err := fn(key+"/", entry); err != nil {
+					return err
+				}
+			}
+			return s3a.walkInventoryEntries(dir.Child(entry.Name), key+"/", filterPrefix, fn)
+		}
+		if !strings.HasPrefix(key, filterPrefix) {
+			return nil
+		}
+		return fn(key, entry)
+	})
+}
+
+// putInventoryFile writes a file to the destination bucket, and returns its md5 in hex.
+func (s3a *S3ApiServer) putInventoryFile(bucket, object string, data []byte) (string, error) {
+	uploadUrl := s3a.toFilerUrl(bucket, "/"+object)
+	r, err := http.NewRequest(http.MethodPut, uploadUrl, nil)
+	if err != nil {
+		return "", err
+	}
+	md5sum, errCode := s3a.putToFiler(r, uploadUrl, bytes.NewReader(data), "")
+	if errCode != s3err.ErrNone {
+		return "", fmt.Errorf("upload %s/%s: %s", bucket, object, s3err.GetAPIError(errCode).Code)
+	}
+	return md5sum, nil
+}
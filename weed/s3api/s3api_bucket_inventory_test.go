@@ -0,0 +1,66 @@
+package s3api
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+func newTestInventoryConfiguration() *InventoryConfiguration {
+	return &InventoryConfiguration{
+		Id:        "report",
+		IsEnabled: true,
+		Destination: InventoryDestination{S3BucketDestination: InventoryS3BucketDestination{
+			Bucket: "arn:aws:s3:::reports",
+			Format: "CSV",
+		}},
+		IncludedObjectVersions: "Current",
+		OptionalFields:         []string{"ETag", "Size"},
+		Schedule:               InventorySchedule{Frequency: "Daily"},
+	}
+}
+
+func TestInventoryConfigurationValidate(t *testing.T) {
+	config := newTestInventoryConfiguration()
+	assert.Equal(t, s3err.ErrNone, config.validate("report"))
+	assert.Equal(t, s3err.ErrInvalidInventoryConfiguration, config.validate("other"))
+	assert.Equal(t, "reports", config.destinationBucket())
+	assert.Equal(t, []string{"Bucket", "Key", "Size", "ETag"}, config.columns())
+
+	config.Destination.S3BucketDestination.Format = "Parquet"
+	assert.Equal(t, s3err.ErrUnsupportedInventoryFormat, config.validate("report"))
+	config.Destination.S3BucketDestination.Format = "ORC"
+	assert.Equal(t, s3err.ErrUnsupportedInventoryFormat, config.validate("report"))
+	config.Destination.S3BucketDestination.Format = "JSON"
+	assert.Equal(t, s3err.ErrInvalidInventoryConfiguration, config.validate("report"))
+
+	config = newTestInventoryConfiguration()
+	config.OptionalFields = append(config.OptionalFields, "ReplicationStatus")
+	assert.Equal(t, s3err.ErrInvalidInventoryConfiguration, config.validate("report"))
+}
+
+func TestIsInventoryDue(t *testing.T) {
+	config := newTestInventoryConfiguration()
+	now := time.Now()
+	entry := &filer_pb.Entry{Extended: map[string][]byte{}}
+	assert.True(t, isInventoryDue(entry, config, now))
+
+	entry.Extended[s3_constants.X_SeaweedFS_Inventory_Generated_Prefix+"report"] = []byte(strconv.FormatInt(now.Add(-time.Hour).Unix(), 10))
+	assert.False(t, isInventoryDue(entry, config, now))
+	assert.True(t, isInventoryDue(entry, config, now.Add(24*time.Hour)))
+}
+
+func TestInventoryRow(t *testing.T) {
+	entry := &filer_pb.Entry{
+		Attributes: &filer_pb.Attributes{Mtime: 1700000000, FileSize: 5},
+		Extended:   map[string][]byte{s3_constants.AmzStorageClass: []byte("STANDARD_IA")},
+	}
+	row := inventoryRow("bucket", "dir/a b.txt", entry, []string{"Bucket", "Key", "Size", "LastModifiedDate", "StorageClass"})
+	assert.Equal(t, []string{"bucket", "dir%2Fa+b.txt", "5", "2023-11-14T22:13:20.000Z", "STANDARD_IA"}, row)
+}
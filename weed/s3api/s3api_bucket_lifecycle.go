@@ -0,0 +1,220 @@
+package s3api
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const multipartUploadCheckInterval = 5 * time.Minute
+
+// validateLifecycle checks the rules to put. Only the rules aborting the incomplete multipart uploads are kept
+// in the bucket, the expiration is configured as the ttl of the locations in the filer configuration:
+// an expiration is only accepted if it is the ttl of the rule prefix, as returned when getting the lifecycle.
+func validateLifecycle(lifecycle *Lifecycle, ttls map[string]string) s3err.ErrorCode {
+	if len(lifecycle.Rules) == 0 {
+		return s3err.ErrMalformedXML
+	}
+	for _, rule := range lifecycle.Rules {
+		if rule.Status != Enabled && rule.Status != Disabled {
+			return s3err.ErrMalformedXML
+		}
+		if rule.Filter.Tag.Key != "" || len(rule.Filter.And.Tags) > 0 ||
+			rule.Filter.ObjectSizeGreaterThan != 0 || rule.Filter.ObjectSizeLessThan != 0 ||
+			rule.Filter.And.ObjectSizeGreaterThan != 0 || rule.Filter.And.ObjectSizeLessThan != 0 {
+			return s3err.ErrNotImplemented
+		}
+		if !rule.Expiration.Date.IsZero() ||
+			rule.Transition.Days != 0 || !rule.Transition.Date.IsZero() || rule.Transition.StorageClass != "" {
+			return s3err.ErrNotImplemented
+		}
+		if rule.Expiration.Days != 0 {
+			internalTtl, found := ttls[rule.prefix()]
+			if !found || rule.Status != Enabled || ttlDays(internalTtl) != rule.Expiration.Days {
+				return s3err.ErrNotImplemented
+			}
+			if rule.AbortIncompleteMultipartUpload.DaysAfterInitiation == 0 {
+				continue
+			}
+		}
+		if rule.AbortIncompleteMultipartUpload.DaysAfterInitiation <= 0 {
+			return s3err.ErrInvalidRequest
+		}
+	}
+	return s3err.ErrNone
+}
+
+// abortMultipartRules keeps the validated rules aborting the incomplete multipart uploads, without their expiration.
+func abortMultipartRules(lifecycle *Lifecycle) (rules []Rule) {
+	for _, rule := range lifecycle.Rules {
+		if rule.AbortIncompleteMultipartUpload.DaysAfterInitiation <= 0 {
+			continue
+		}
+		rule.Expiration = Expiration{}
+		rules = append(rules, rule)
+	}
+	return
+}
+
+// ttlDays is the expiration in days of the ttl of a location in the filer configuration, 0 if less than a day.
+func ttlDays(internalTtl string) int {
+	ttl, _ := needle.ReadTTL(internalTtl)
+	return int(ttl.Minutes() / 60 / 24)
+}
+
+// readAbortMultipartRules reads the lifecycle rules aborting the incomplete multipart uploads of the bucket.
+func readAbortMultipartRules(entry *filer_pb.Entry) []Rule {
+	data, found := entry.Extended[s3_constants.X_SeaweedFS_Lifecycle_Abort_Multipart]
+	if !found {
+		return nil
+	}
+	lifecycle := &Lifecycle{}
+	if err := xml.Unmarshal(data, lifecycle); err != nil {
+		glog.Warningf("bucket %s lifecycle: %v", entry.Name, err)
+		return nil
+	}
+	return lifecycle.Rules
+}
+
+// isUploadAborted tells whether an enabled rule aborts the upload of the key initiated at that time.
+func isUploadAborted(rules []Rule, key string, initiated, now time.Time) bool {
+	for _, rule := range rules {
+		days := rule.AbortIncompleteMultipartUpload.DaysAfterInitiation
+		if rule.Status != Enabled || days <= 0 || !strings.HasPrefix(key, rule.prefix()) {
+			continue
+		}
+		if now.Sub(initiated) >= time.Duration(days)*24*time.Hour {
+			return true
+		}
+	}
+	return false
+}
+
+func (s3a *S3ApiServer) loopCheckMultipartUploads(interval time.Duration) {
+	reportedBuckets := make(map[string]bool)
+	for {
+		time.Sleep(interval)
+		if err := s3a.checkMultipartUploadsWithLock(time.Now(), reportedBuckets); err != nil {
+			glog.V(0).Infof("check multipart uploads: %v", err)
+		}
+	}
+}
+
+// checkMultipartUploadsWithLock checks the multipart uploads while holding the filer lock of the buckets directory,
+// so the uploads are aborted and reported by only one of the S3 gateways.
+func (s3a *S3ApiServer) checkMultipartUploadsWithLock(now time.Time, reportedBuckets map[string]bool) error {
+	unlock, isLocked, err := s3a.lockWithFiler(s3a.option.BucketsPath, "s3.multipart")
+	if err != nil {
+		return err
+	}
+	if !isLocked {
+		// another gateway reports the uploads now
+		forgetMultipartUploadMetrics(reportedBuckets, nil)
+		return nil
+	}
+	defer unlock()
+	return s3a.checkMultipartUploads(now, reportedBuckets)
+}
+
+// checkMultipartUploads aborts the incomplete multipart uploads expired by the lifecycle rules of each bucket,
+// and reports the count and the oldest age of the uploads left in progress.
+func (s3a *S3ApiServer) checkMultipartUploads(now time.Time, reportedBuckets map[string]bool) error {
+	buckets := make(map[string]bool)
+	err := filer_pb.ReadDirAllEntries(s3a, util.FullPath(s3a.option.BucketsPath), "", func(entry *filer_pb.Entry, isLast bool) error {
+		if !entry.IsDirectory {
+			return nil
+		}
+		if err := s3a.checkBucketMultipartUploads(entry, now); err != nil {
+			glog.Warningf("check multipart uploads of bucket %s: %v", entry.Name, err)
+			return nil
+		}
+		buckets[entry.Name] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	forgetMultipartUploadMetrics(reportedBuckets, buckets)
+	for bucket := range buckets {
+		reportedBuckets[bucket] = true
+	}
+	return nil
+}
+
+func (s3a *S3ApiServer) checkBucketMultipartUploads(bucketEntry *filer_pb.Entry, now time.Time) error {
+	bucket := bucketEntry.Name
+	rules := readAbortMultipartRules(bucketEntry)
+	var count int
+	var oldest time.Time
+	var aborted []*filer_pb.Entry
+	err := filer_pb.ReadDirAllEntries(s3a, util.FullPath(s3a.genUploadsFolder(bucket)), "", func(upload *filer_pb.Entry, isLast bool) error {
+		if !upload.IsDirectory || upload.Attributes == nil {
+			return nil
+		}
+		initiated := time.Unix(upload.Attributes.Crtime, 0)
+		if isUploadAborted(rules, string(upload.Extended["key"]), initiated, now) {
+			aborted = append(aborted, upload)
+			return nil
+		}
+		count++
+		if oldest.IsZero() || initiated.Before(oldest) {
+			oldest = initiated
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// abort after listing, not to change the directory while paginating it
+	for _, upload := range aborted {
+		key := string(upload.Extended["key"])
+		initiated := time.Unix(upload.Attributes.Crtime, 0)
+		_, errCode := s3a.abortMultipartUpload(&s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(bucket),
+			Key:      aws.String(key),
+			UploadId: aws.String(upload.Name),
+		})
+		if errCode == s3err.ErrNone {
+			glog.V(1).Infof("bucket %s lifecycle aborted upload %s of %s initiated at %v", bucket, upload.Name, key, initiated)
+			stats.S3BucketMultipartUploadsAbortedCounter.WithLabelValues(bucket).Inc()
+			continue
+		}
+		glog.Warningf("bucket %s lifecycle abort upload %s of %s failed", bucket, upload.Name, key)
+		count++
+		if oldest.IsZero() || initiated.Before(oldest) {
+			oldest = initiated
+		}
+	}
+
+	var oldestAge float64
+	if !oldest.IsZero() {
+		oldestAge = now.Sub(oldest).Seconds()
+	}
+	stats.S3BucketMultipartUploadsGauge.WithLabelValues(bucket).Set(float64(count))
+	stats.S3BucketMultipartUploadOldestAgeGauge.WithLabelValues(bucket).Set(oldestAge)
+	return nil
+}
+
+// forgetMultipartUploadMetrics deletes the metrics of the reported buckets no longer in the buckets
+func forgetMultipartUploadMetrics(reportedBuckets map[string]bool, buckets map[string]bool) {
+	for bucket := range reportedBuckets {
+		if !buckets[bucket] {
+			stats.S3BucketMultipartUploadsGauge.DeleteLabelValues(bucket)
+			stats.S3BucketMultipartUploadOldestAgeGauge.DeleteLabelValues(bucket)
+			delete(reportedBuckets, bucket)
+		}
+	}
+}
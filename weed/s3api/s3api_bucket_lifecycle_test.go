@@ -0,0 +1,98 @@
+package s3api
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+func TestValidateLifecycle(t *testing.T) {
+	var lifecycle Lifecycle
+	assert.NoError(t, xml.Unmarshal([]byte(`<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Rule>
+    <ID>abort-tmp</ID>
+    <Filter><Prefix>tmp/</Prefix></Filter>
+    <Status>Enabled</Status>
+    <AbortIncompleteMultipartUpload><DaysAfterInitiation>7</DaysAfterInitiation></AbortIncompleteMultipartUpload>
+  </Rule>
+</LifecycleConfiguration>`), &lifecycle))
+	ttls := map[string]string{"tmp/": "30d"}
+	assert.Equal(t, s3err.ErrNone, validateLifecycle(&lifecycle, ttls))
+	assert.Equal(t, "tmp/", lifecycle.Rules[0].prefix())
+	assert.Equal(t, 7, lifecycle.Rules[0].AbortIncompleteMultipartUpload.DaysAfterInitiation)
+
+	// the expiration of the ttl, as returned when getting the lifecycle, is accepted but not kept
+	expiring := lifecycle
+	expiring.Rules = []Rule{lifecycle.Rules[0], {Status: Enabled, Prefix: Prefix{string: "tmp/", set: true}}}
+	expiring.Rules[0].Expiration.Days = 30
+	expiring.Rules[1].Expiration.Days = 30
+	assert.Equal(t, s3err.ErrNone, validateLifecycle(&expiring, ttls))
+	rules := abortMultipartRules(&expiring)
+	assert.Equal(t, 1, len(rules))
+	assert.Equal(t, 0, rules[0].Expiration.Days)
+	assert.Equal(t, 7, rules[0].AbortIncompleteMultipartUpload.DaysAfterInitiation)
+
+	expiring.Rules[1].Expiration.Days = 31
+	assert.Equal(t, s3err.ErrNotImplemented, validateLifecycle(&expiring, ttls))
+	assert.Equal(t, s3err.ErrNotImplemented, validateLifecycle(&expiring, nil))
+	expiring.Rules[1].Expiration = Expiration{Date: ExpirationDate{time.Now()}}
+	assert.Equal(t, s3err.ErrNotImplemented, validateLifecycle(&expiring, ttls))
+
+	noDays := lifecycle
+	noDays.Rules = []Rule{{Status: Enabled}}
+	assert.Equal(t, s3err.ErrInvalidRequest, validateLifecycle(&noDays, ttls))
+
+	assert.Equal(t, s3err.ErrMalformedXML, validateLifecycle(&Lifecycle{}, ttls))
+
+	for _, filter := range []string{
+		`<Tag><Key>k</Key><Value>v</Value></Tag>`,
+		`<And><Prefix>tmp/</Prefix><Tag><Key>k</Key><Value>v</Value></Tag></And>`,
+		`<ObjectSizeGreaterThan>1024</ObjectSizeGreaterThan>`,
+		`<And><Prefix>tmp/</Prefix><ObjectSizeLessThan>1024</ObjectSizeLessThan></And>`,
+	} {
+		var filtered Lifecycle
+		assert.NoError(t, xml.Unmarshal([]byte(`<LifecycleConfiguration><Rule><Filter>`+filter+`</Filter><Status>Enabled</Status>
+<AbortIncompleteMultipartUpload><DaysAfterInitiation>7</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule></LifecycleConfiguration>`), &filtered))
+		assert.Equal(t, s3err.ErrNotImplemented, validateLifecycle(&filtered, ttls), filter)
+	}
+}
+
+func TestReadAbortMultipartRules(t *testing.T) {
+	lifecycle := &Lifecycle{Rules: []Rule{{
+		Status:                         Enabled,
+		Prefix:                         Prefix{string: "logs/", set: true},
+		AbortIncompleteMultipartUpload: AbortIncompleteMultipartUpload{DaysAfterInitiation: 1},
+	}}}
+	data, err := xml.Marshal(lifecycle)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "<DaysAfterInitiation>1</DaysAfterInitiation>")
+	assert.NotContains(t, string(data), "<Expiration>")
+
+	entry := &filer_pb.Entry{Name: "bucket", Extended: map[string][]byte{s3_constants.X_SeaweedFS_Lifecycle_Abort_Multipart: data}}
+	rules := readAbortMultipartRules(entry)
+	if assert.Len(t, rules, 1) {
+		assert.Equal(t, "logs/", rules[0].prefix())
+	}
+	assert.Nil(t, readAbortMultipartRules(&filer_pb.Entry{}))
+}
+
+func TestIsUploadAborted(t *testing.T) {
+	now := time.Now()
+	rules := []Rule{
+		{Status: Enabled, Filter: Filter{Prefix: Prefix{string: "tmp/", set: true}}, AbortIncompleteMultipartUpload: AbortIncompleteMultipartUpload{DaysAfterInitiation: 1}},
+		{Status: Disabled, AbortIncompleteMultipartUpload: AbortIncompleteMultipartUpload{DaysAfterInitiation: 1}},
+		{Status: Enabled, AbortIncompleteMultipartUpload: AbortIncompleteMultipartUpload{DaysAfterInitiation: 7}},
+	}
+
+	assert.True(t, isUploadAborted(rules, "tmp/a", now.Add(-25*time.Hour), now))
+	assert.False(t, isUploadAborted(rules, "tmp/a", now.Add(-23*time.Hour), now))
+	assert.False(t, isUploadAborted(rules, "data/a", now.Add(-25*time.Hour), now), "the disabled rule is ignored")
+	assert.True(t, isUploadAborted(rules, "data/a", now.Add(-8*24*time.Hour), now))
+	assert.False(t, isUploadAborted(nil, "data/a", now.Add(-8*24*time.Hour), now))
+}
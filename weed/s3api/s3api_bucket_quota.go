@@ -0,0 +1,237 @@
+package s3api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const defaultQuotaWarningPercent = 90
+
+// bucketQuota is the quota of one bucket and its usage, as of the last refresh plus the writes since then.
+type bucketQuota struct {
+	maxBytes       int64
+	maxObjects     int64
+	warningPercent int64
+	usedBytes      int64
+	usedObjects    int64
+	isWarned       bool
+}
+
+// BucketQuotas enforces the byte and object count quotas of the buckets.
+// The usage is the live bytes and files of the bucket collection in the master topology, as of each refresh,
+// so it lags behind the writes a little. The files are the volume needles, so an object of many chunks
+// counts as many files, and the parts of the pending multipart uploads are counted too.
+type BucketQuotas struct {
+	sync.RWMutex
+	buckets map[string]*bucketQuota
+}
+
+func NewBucketQuotas() *BucketQuotas {
+	return &BucketQuotas{
+		buckets: make(map[string]*bucketQuota),
+	}
+}
+
+// newBucketQuota reads the quota of the bucket entry, or returns nil if the bucket has no enabled quota.
+func newBucketQuota(entry *filer_pb.Entry) *bucketQuota {
+	q := &bucketQuota{
+		maxBytes:       entry.Quota,
+		warningPercent: defaultQuotaWarningPercent,
+	}
+	if entry.Extended != nil {
+		if objects, err := strconv.ParseInt(string(entry.Extended[s3_constants.X_SeaweedFS_Quota_Objects]), 10, 64); err == nil {
+			q.maxObjects = objects
+		}
+		if percent, err := strconv.ParseInt(string(entry.Extended[s3_constants.X_SeaweedFS_Quota_Warning_Percent]), 10, 64); err == nil && percent > 0 {
+			q.warningPercent = percent
+		}
+	}
+	// a negative quota is disabled, and the object count quota follows the byte quota
+	if q.maxBytes < 0 {
+		return nil
+	}
+	if q.maxBytes == 0 && q.maxObjects <= 0 {
+		return nil
+	}
+	return q
+}
+
+func isQuotaExceeded(used, add, max int64) bool {
+	return max > 0 && (used >= max || used+add > max)
+}
+
+func (bq *BucketQuotas) hasObjectQuota(bucket string) bool {
+	bq.RLock()
+	defer bq.RUnlock()
+	q, found := bq.buckets[bucket]
+	return found && q.maxObjects > 0
+}
+
+func (bq *BucketQuotas) check(bucket string, addBytes, addObjects int64) s3err.ErrorCode {
+	bq.RLock()
+	defer bq.RUnlock()
+	q, found := bq.buckets[bucket]
+	if !found {
+		return s3err.ErrNone
+	}
+	if isQuotaExceeded(q.usedBytes, addBytes, q.maxBytes) || isQuotaExceeded(q.usedObjects, addObjects, q.maxObjects) {
+		return s3err.ErrQuotaExceeded
+	}
+	return s3err.ErrNone
+}
+
+// recordWrite counts a write until the next refresh, so a burst of writes can not overshoot the quota much.
+func (bq *BucketQuotas) recordWrite(bucket string, bytes, objects int64) {
+	bq.Lock()
+	defer bq.Unlock()
+	if q, found := bq.buckets[bucket]; found {
+		q.usedBytes += bytes
+		q.usedObjects += objects
+	}
+}
+
+// update replaces the quotas and usages, keeping whether a bucket has been warned about.
+func (bq *BucketQuotas) update(quotas map[string]*bucketQuota) {
+	bq.Lock()
+	defer bq.Unlock()
+	for bucket, q := range quotas {
+		if previous, found := bq.buckets[bucket]; found {
+			q.isWarned = previous.isWarned
+		}
+		q.warnIfNeeded(bucket)
+	}
+	for bucket := range bq.buckets {
+		if _, found := quotas[bucket]; !found {
+			stats.S3BucketQuotaUsageGauge.DeleteLabelValues(bucket, "bytes")
+			stats.S3BucketQuotaUsageGauge.DeleteLabelValues(bucket, "objects")
+		}
+	}
+	bq.buckets = quotas
+}
+
+func (q *bucketQuota) warnIfNeeded(bucket string) {
+	var usage float64
+	if q.maxBytes > 0 {
+		bytesUsage := float64(q.usedBytes) / float64(q.maxBytes)
+		stats.S3BucketQuotaUsageGauge.WithLabelValues(bucket, "bytes").Set(bytesUsage)
+		usage = math.Max(usage, bytesUsage)
+	}
+	if q.maxObjects > 0 {
+		objectsUsage := float64(q.usedObjects) / float64(q.maxObjects)
+		stats.S3BucketQuotaUsageGauge.WithLabelValues(bucket, "objects").Set(objectsUsage)
+		usage = math.Max(usage, objectsUsage)
+	}
+	isAboveWarning := usage*100 >= float64(q.warningPercent)
+	if isAboveWarning && !q.isWarned {
+		glog.Warningf("bucket %s uses %.1f%% of its quota: %d of %d bytes, %d of %d objects",
+			bucket, usage*100, q.usedBytes, q.maxBytes, q.usedObjects, q.maxObjects)
+	}
+	q.isWarned = isAboveWarning
+}
+
+func (s3a *S3ApiServer) checkBucketQuota(r *http.Request, bucket string, addBytes, addObjects int64) s3err.ErrorCode {
+	errCode := s3a.quotas.check(bucket, addBytes, addObjects)
+	if errCode == s3err.ErrQuotaExceeded {
+		glog.V(1).Infof("%s %s: bucket %s quota exceeded", r.Method, r.URL.Path, bucket)
+		stats.S3BucketQuotaExceededCounter.WithLabelValues(bucket).Inc()
+	}
+	return errCode
+}
+
+// requestContentLength is the object size of an upload, without the chunk signatures of a streaming upload.
+func requestContentLength(r *http.Request) int64 {
+	if decoded, err := strconv.ParseInt(r.Header.Get(s3_constants.AmzDecodedContentLength), 10, 64); err == nil {
+		return decoded
+	}
+	if r.ContentLength > 0 {
+		return r.ContentLength
+	}
+	return 0
+}
+
+func (s3a *S3ApiServer) loopRefreshBucketQuotas(interval time.Duration) {
+	for {
+		if err := s3a.refreshBucketQuotas(); err != nil {
+			glog.V(0).Infof("refresh bucket quotas: %v", err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+func (s3a *S3ApiServer) refreshBucketQuotas() error {
+	entries, _, err := s3a.list(s3a.option.BucketsPath, "", "", false, math.MaxInt32)
+	if err != nil {
+		return err
+	}
+	quotas := make(map[string]*bucketQuota)
+	for _, entry := range entries {
+		if !entry.IsDirectory {
+			continue
+		}
+		if q := newBucketQuota(entry); q != nil {
+			quotas[entry.Name] = q
+		}
+	}
+	if len(quotas) == 0 {
+		s3a.quotas.update(quotas)
+		return nil
+	}
+
+	usages, err := s3a.collectionUsages()
+	if err != nil {
+		return fmt.Errorf("collection usages: %v", err)
+	}
+	for bucket, q := range quotas {
+		if usage, found := usages[bucket]; found {
+			q.usedBytes = int64(usage.DataSize)
+			q.usedObjects = int64(usage.FileCount)
+		}
+	}
+
+	s3a.quotas.update(quotas)
+	return nil
+}
+
+// collectionUsages reads the live files and bytes of the collections, i.e. the buckets, from the master topology.
+func (s3a *S3ApiServer) collectionUsages() (usages map[string]*filer_pb.Collection, err error) {
+	err = s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, listErr := client.CollectionList(context.Background(), &filer_pb.CollectionListRequest{
+			IncludeNormalVolumes: true,
+			IncludeUsage:         true,
+		})
+		if listErr != nil {
+			return listErr
+		}
+		usages = make(map[string]*filer_pb.Collection)
+		for _, c := range resp.Collections {
+			usages[c.Name] = c
+		}
+		return nil
+	})
+	return
+}
+
+// newObjectCount is 0 when the object overwrites an existing one, which takes no more of the object count quota.
+// The object is only looked up for the buckets with an object count quota.
+func (s3a *S3ApiServer) newObjectCount(bucket, object string) int64 {
+	if !s3a.quotas.hasObjectQuota(bucket) {
+		return 1
+	}
+	dir, name := util.FullPath(fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, bucket, object)).DirAndName()
+	if entry, err := s3a.getEntry(dir, name); err == nil && entry != nil && !entry.IsDirectory {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,52 @@
+package s3api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+func TestNewBucketQuota(t *testing.T) {
+	assert.Nil(t, newBucketQuota(&filer_pb.Entry{}))
+	assert.Nil(t, newBucketQuota(&filer_pb.Entry{Quota: -1024}))
+
+	q := newBucketQuota(&filer_pb.Entry{
+		Extended: map[string][]byte{
+			s3_constants.X_SeaweedFS_Quota_Objects:         []byte("100"),
+			s3_constants.X_SeaweedFS_Quota_Warning_Percent: []byte("80"),
+		},
+	})
+	assert.Equal(t, int64(0), q.maxBytes)
+	assert.Equal(t, int64(100), q.maxObjects)
+	assert.Equal(t, int64(80), q.warningPercent)
+
+	q = newBucketQuota(&filer_pb.Entry{Quota: 1024})
+	assert.Equal(t, int64(1024), q.maxBytes)
+	assert.Equal(t, int64(defaultQuotaWarningPercent), q.warningPercent)
+}
+
+func TestBucketQuotasCheck(t *testing.T) {
+	bq := NewBucketQuotas()
+	bq.update(map[string]*bucketQuota{
+		"bytes":   {maxBytes: 1000, warningPercent: 90, usedBytes: 900},
+		"objects": {maxObjects: 10, warningPercent: 90, usedObjects: 9},
+	})
+
+	assert.Equal(t, s3err.ErrNone, bq.check("unlimited", 1<<40, 1))
+	assert.False(t, bq.hasObjectQuota("unlimited"))
+	assert.False(t, bq.hasObjectQuota("bytes"))
+	assert.True(t, bq.hasObjectQuota("objects"))
+
+	assert.Equal(t, s3err.ErrNone, bq.check("bytes", 100, 1))
+	assert.Equal(t, s3err.ErrQuotaExceeded, bq.check("bytes", 101, 1))
+
+	assert.Equal(t, s3err.ErrNone, bq.check("objects", 1<<20, 1))
+	bq.recordWrite("objects", 1<<20, 1)
+	assert.Equal(t, s3err.ErrQuotaExceeded, bq.check("objects", 0, 1))
+	// a full bucket takes no more writes, even of unknown size
+	assert.Equal(t, s3err.ErrQuotaExceeded, bq.check("objects", 0, 0))
+}
@@ -0,0 +1,165 @@
+package s3api
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+// FilerSelector chooses which filer the s3 gateway talks to.
+// Writes and grpc calls stick to the current filer until it fails,
+// while reads can optionally be spread over all healthy filers.
+type FilerSelector struct {
+	filers         []rpc.ServerAddress
+	healthy        []int32
+	current        int32
+	readCounter    uint32
+	readRoundRobin bool
+}
+
+func NewFilerSelector(filers []rpc.ServerAddress, readRoundRobin bool) *FilerSelector {
+	fs := &FilerSelector{
+		filers:         filers,
+		healthy:        make([]int32, len(filers)),
+		readRoundRobin: readRoundRobin,
+	}
+	for i := range fs.healthy {
+		fs.healthy[i] = 1
+	}
+	return fs
+}
+
+func (fs *FilerSelector) Filers() []rpc.ServerAddress {
+	return fs.filers
+}
+
+// Current returns the filer for writes and metadata operations.
+func (fs *FilerSelector) Current() rpc.ServerAddress {
+	return fs.filers[atomic.LoadInt32(&fs.current)]
+}
+
+// ForRead returns the filer to read object content from.
+func (fs *FilerSelector) ForRead() rpc.ServerAddress {
+	if !fs.readRoundRobin || len(fs.filers) == 1 {
+		return fs.Current()
+	}
+	start := int(atomic.AddUint32(&fs.readCounter, 1))
+	for i := 0; i < len(fs.filers); i++ {
+		index := (start + i) % len(fs.filers)
+		if atomic.LoadInt32(&fs.healthy[index]) == 1 {
+			return fs.filers[index]
+		}
+	}
+	return fs.Current()
+}
+
+// Next returns a healthy filer other than the given one, or false if there is none.
+func (fs *FilerSelector) Next(filer rpc.ServerAddress) (rpc.ServerAddress, bool) {
+	index := fs.indexOf(filer)
+	for i := 1; i < len(fs.filers); i++ {
+		next := (index + i) % len(fs.filers)
+		if atomic.LoadInt32(&fs.healthy[next]) == 1 {
+			return fs.filers[next], true
+		}
+	}
+	return "", false
+}
+
+// FindByHttpAddress maps a filer http address back to the filer address.
+func (fs *FilerSelector) FindByHttpAddress(httpAddress string) (rpc.ServerAddress, bool) {
+	for _, filer := range fs.filers {
+		if filer.ToHttpAddress() == httpAddress {
+			return filer, true
+		}
+	}
+	return "", false
+}
+
+// MarkUnhealthy takes the filer out of rotation, and moves away from it if it is the current filer.
+func (fs *FilerSelector) MarkUnhealthy(filer rpc.ServerAddress) {
+	index := fs.indexOf(filer)
+	if index < 0 {
+		return
+	}
+	if atomic.SwapInt32(&fs.healthy[index], 0) == 1 {
+		glog.Warningf("s3: filer %s is unavailable", filer)
+	}
+	if int(atomic.LoadInt32(&fs.current)) != index {
+		return
+	}
+	if next, found := fs.Next(filer); found {
+		if atomic.CompareAndSwapInt32(&fs.current, int32(index), int32(fs.indexOf(next))) {
+			glog.V(0).Infof("s3: fail over from filer %s to %s", filer, next)
+		}
+	}
+}
+
+func (fs *FilerSelector) markHealthy(filer rpc.ServerAddress) {
+	index := fs.indexOf(filer)
+	if index < 0 {
+		return
+	}
+	if atomic.SwapInt32(&fs.healthy[index], 1) == 0 {
+		glog.V(0).Infof("s3: filer %s is available again", filer)
+	}
+	// stick to the current filer unless it is down
+	current := atomic.LoadInt32(&fs.current)
+	if atomic.LoadInt32(&fs.healthy[current]) == 0 {
+		atomic.CompareAndSwapInt32(&fs.current, current, int32(index))
+	}
+}
+
+func (fs *FilerSelector) indexOf(filer rpc.ServerAddress) int {
+	for i, f := range fs.filers {
+		if f == filer {
+			return i
+		}
+	}
+	return -1
+}
+
+// LoopHealthCheck pings all filers periodically. It is a no-op with only one filer.
+func (fs *FilerSelector) LoopHealthCheck(grpcDialOption grpc.DialOption, interval time.Duration) {
+	if len(fs.filers) <= 1 {
+		return
+	}
+	for {
+		for _, filer := range fs.filers {
+			err := rpc.WithGrpcFilerClient(false, filer, grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				defer cancel()
+				_, pingErr := client.Ping(ctx, &filer_pb.PingRequest{})
+				return pingErr
+			})
+			if err != nil {
+				glog.V(1).Infof("s3: ping filer %s: %v", filer, err)
+				fs.MarkUnhealthy(filer)
+			} else {
+				fs.markHealthy(filer)
+			}
+		}
+		time.Sleep(interval)
+	}
+}
+
+func isFilerUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if status.Code(err) == codes.Unavailable {
+		return true
+	}
+	errString := err.Error()
+	return strings.Contains(errString, "Unavailable") ||
+		strings.Contains(errString, "connection refused") ||
+		strings.Contains(errString, "getOrCreateConnection")
+}
@@ -0,0 +1,36 @@
+package s3api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+)
+
+func TestFilerSelectorFailover(t *testing.T) {
+	filers := rpc.ServerAddresses("f1:8888,f2:8888,f3:8888").ToAddresses()
+	fs := NewFilerSelector(filers, true)
+
+	assert.Equal(t, filers[0], fs.Current())
+
+	fs.MarkUnhealthy(filers[0])
+	assert.Equal(t, filers[1], fs.Current())
+	for i := 0; i < 10; i++ {
+		assert.NotEqual(t, filers[0], fs.ForRead())
+	}
+
+	// a recovered filer does not take over from a healthy current filer
+	fs.markHealthy(filers[0])
+	assert.Equal(t, filers[1], fs.Current())
+
+	fs.MarkUnhealthy(filers[1])
+	fs.MarkUnhealthy(filers[2])
+	assert.Equal(t, filers[0], fs.Current())
+	_, found := fs.Next(filers[0])
+	assert.False(t, found)
+
+	filer, found := fs.FindByHttpAddress("f2:8888")
+	assert.True(t, found)
+	assert.Equal(t, filers[1], filer)
+}
@@ -14,12 +14,25 @@ import (
 
 var _ = filer_pb.FilerClient(&S3ApiServer{})
 
-func (s3a *S3ApiServer) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
+func (s3a *S3ApiServer) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) (err error) {
 
-	return rpc.WithGrpcClient(streamingMode, func(grpcConnection *grpc.ClientConn) error {
-		client := filer_pb.NewSeaweedFilerClient(grpcConnection)
-		return fn(client)
-	}, s3a.option.Filer.ToGrpcAddress(), false, s3a.option.GrpcDialOption)
+	filerAddress := s3a.filers.Current()
+	for i := 0; i < len(s3a.filers.Filers()); i++ {
+		err = rpc.WithGrpcClient(streamingMode, func(grpcConnection *grpc.ClientConn) error {
+			client := filer_pb.NewSeaweedFilerClient(grpcConnection)
+			return fn(client)
+		}, filerAddress.ToGrpcAddress(), false, s3a.option.GrpcDialOption)
+		if !isFilerUnavailable(err) {
+			return err
+		}
+		s3a.filers.MarkUnhealthy(filerAddress)
+		next, found := s3a.filers.Next(filerAddress)
+		if !found {
+			return err
+		}
+		filerAddress = next
+	}
+	return err
 
 }
 
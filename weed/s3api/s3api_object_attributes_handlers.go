@@ -0,0 +1,179 @@
+package s3api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const defaultMaxObjectAttributesParts = 1000
+
+type ObjectAttributesChecksum struct {
+	ChecksumCRC32  string `xml:"ChecksumCRC32,omitempty"`
+	ChecksumCRC32C string `xml:"ChecksumCRC32C,omitempty"`
+	ChecksumSHA1   string `xml:"ChecksumSHA1,omitempty"`
+	ChecksumSHA256 string `xml:"ChecksumSHA256,omitempty"`
+}
+
+func (c *ObjectAttributesChecksum) set(header, checksum string) {
+	switch header {
+	case s3_constants.AmzChecksumCRC32:
+		c.ChecksumCRC32 = checksum
+	case s3_constants.AmzChecksumCRC32C:
+		c.ChecksumCRC32C = checksum
+	case s3_constants.AmzChecksumSHA1:
+		c.ChecksumSHA1 = checksum
+	case s3_constants.AmzChecksumSHA256:
+		c.ChecksumSHA256 = checksum
+	}
+}
+
+type ObjectAttributesPart struct {
+	ObjectAttributesChecksum
+	PartNumber int   `xml:"PartNumber"`
+	Size       int64 `xml:"Size"`
+}
+
+type ObjectAttributesParts struct {
+	PartsCount           int                     `xml:"PartsCount"`
+	PartNumberMarker     int                     `xml:"PartNumberMarker"`
+	NextPartNumberMarker int                     `xml:"NextPartNumberMarker"`
+	MaxParts             int                     `xml:"MaxParts"`
+	IsTruncated          bool                    `xml:"IsTruncated"`
+	Parts                []*ObjectAttributesPart `xml:"Part"`
+}
+
+type GetObjectAttributesResponse struct {
+	XMLName      xml.Name                  `xml:"http://s3.amazonaws.com/doc/2006-03-01/ GetObjectAttributesResponse"`
+	ETag         string                    `xml:"ETag,omitempty"`
+	Checksum     *ObjectAttributesChecksum `xml:"Checksum,omitempty"`
+	ObjectParts  *ObjectAttributesParts    `xml:"ObjectParts,omitempty"`
+	StorageClass string                    `xml:"StorageClass,omitempty"`
+	ObjectSize   *int64                    `xml:"ObjectSize,omitempty"`
+}
+
+// GetObjectAttributesHandler - GET object attributes
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectAttributes.html
+func (s3a *S3ApiServer) GetObjectAttributesHandler(w http.ResponseWriter, r *http.Request) {
+
+	bucket, object := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("GetObjectAttributesHandler %s %s", bucket, object)
+
+	attributes := make(map[string]bool)
+	for _, values := range r.Header.Values(s3_constants.AmzObjectAttributes) {
+		for _, attribute := range strings.Split(values, ",") {
+			attributes[strings.TrimSpace(attribute)] = true
+		}
+	}
+	if len(attributes) == 0 {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidRequest)
+		return
+	}
+
+	maxParts := defaultMaxObjectAttributesParts
+	if value := r.Header.Get(s3_constants.AmzMaxParts); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			s3err.WriteErrorResponse(w, r, s3err.ErrInvalidMaxParts)
+			return
+		}
+		maxParts = parsed
+	}
+	partNumberMarker := 0
+	if value := r.Header.Get(s3_constants.AmzPartNumberMarker); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			s3err.WriteErrorResponse(w, r, s3err.ErrInvalidPartNumberMarker)
+			return
+		}
+		partNumberMarker = parsed
+	}
+
+	target := util.FullPath(fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, bucket, object))
+	dir, name := target.DirAndName()
+	entry, err := s3a.getEntry(dir, name)
+	if err != nil || entry == nil || entry.IsDirectory {
+		if err != nil && err != filer_pb.ErrNotFound {
+			glog.Errorf("GetObjectAttributesHandler %s: %v", r.URL, err)
+			s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+			return
+		}
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchKey)
+		return
+	}
+
+	response := buildObjectAttributes(entry, attributes, maxParts, partNumberMarker)
+
+	if entry.Attributes != nil {
+		w.Header().Set("Last-Modified", time.Unix(entry.Attributes.Mtime, 0).UTC().Format(http.TimeFormat))
+	}
+	writeSuccessResponseXML(w, r, response)
+}
+
+func buildObjectAttributes(entry *filer_pb.Entry, attributes map[string]bool, maxParts, partNumberMarker int) *GetObjectAttributesResponse {
+	response := &GetObjectAttributesResponse{}
+
+	if attributes["ETag"] {
+		response.ETag = filer.ETag(entry)
+	}
+	if attributes["ObjectSize"] {
+		size := int64(filer.FileSize(entry))
+		response.ObjectSize = &size
+	}
+	if attributes["StorageClass"] {
+		response.StorageClass = storageClassOf(entry)
+	}
+	if attributes["Checksum"] {
+		for _, header := range s3_constants.AmzChecksumHeaders {
+			if checksum, found := entry.Extended[header]; found {
+				response.Checksum = &ObjectAttributesChecksum{}
+				response.Checksum.set(header, string(checksum))
+				break
+			}
+		}
+	}
+
+	partsData, isMultipart := entry.Extended[s3_constants.X_SeaweedFS_Multipart_Parts]
+	if attributes["ObjectParts"] && isMultipart {
+		partsInfo := &MultipartPartsInfo{}
+		if err := json.Unmarshal(partsData, partsInfo); err != nil {
+			glog.Errorf("decode multipart parts of %s: %v", entry.Name, err)
+			return response
+		}
+		objectParts := &ObjectAttributesParts{
+			PartsCount:       len(partsInfo.Parts),
+			PartNumberMarker: partNumberMarker,
+			MaxParts:         maxParts,
+		}
+		for _, part := range partsInfo.Parts {
+			if part.PartNumber <= partNumberMarker {
+				continue
+			}
+			if len(objectParts.Parts) >= maxParts {
+				objectParts.IsTruncated = true
+				break
+			}
+			objectPart := &ObjectAttributesPart{
+				PartNumber: part.PartNumber,
+				Size:       part.Size,
+			}
+			objectPart.set(partsInfo.ChecksumHeader, part.Checksum)
+			objectParts.Parts = append(objectParts.Parts, objectPart)
+			objectParts.NextPartNumberMarker = part.PartNumber
+		}
+		response.ObjectParts = objectParts
+	}
+
+	return response
+}
@@ -0,0 +1,59 @@
+package s3api
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+)
+
+func TestObjectAttributesParts(t *testing.T) {
+	sum1 := sha256.Sum256([]byte("part1"))
+	sum2 := sha256.Sum256([]byte("part2"))
+	checksum1 := base64.StdEncoding.EncodeToString(sum1[:])
+	checksum2 := base64.StdEncoding.EncodeToString(sum2[:])
+
+	partsInfo := newMultipartPartsInfo([]*MultipartPartInfo{
+		{PartNumber: 1, Size: 5, Checksum: s3_constants.AmzChecksumSHA256 + ":" + checksum1},
+		{PartNumber: 2, Size: 5, Checksum: s3_constants.AmzChecksumSHA256 + ":" + checksum2},
+	})
+	assert.Equal(t, s3_constants.AmzChecksumSHA256, partsInfo.ChecksumHeader)
+	composite := sha256.Sum256(append(sum1[:], sum2[:]...))
+	assert.Equal(t, base64.StdEncoding.EncodeToString(composite[:])+"-2", partsInfo.compositeChecksum())
+
+	partsData, _ := json.Marshal(partsInfo)
+	entry := &filer_pb.Entry{
+		Name:       "object",
+		Attributes: &filer_pb.Attributes{FileSize: 10},
+		Extended: map[string][]byte{
+			s3_constants.AmzChecksumSHA256:           []byte(partsInfo.compositeChecksum()),
+			s3_constants.X_SeaweedFS_Multipart_Parts: partsData,
+		},
+	}
+
+	response := buildObjectAttributes(entry, map[string]bool{"ObjectSize": true, "Checksum": true, "ObjectParts": true, "StorageClass": true}, 1, 0)
+	assert.Equal(t, int64(10), *response.ObjectSize)
+	assert.Equal(t, "STANDARD", response.StorageClass)
+	assert.Equal(t, partsInfo.compositeChecksum(), response.Checksum.ChecksumSHA256)
+	assert.Equal(t, 2, response.ObjectParts.PartsCount)
+	assert.True(t, response.ObjectParts.IsTruncated)
+	assert.Equal(t, 1, response.ObjectParts.NextPartNumberMarker)
+	assert.Equal(t, checksum1, response.ObjectParts.Parts[0].ChecksumSHA256)
+
+	response = buildObjectAttributes(entry, map[string]bool{"ObjectParts": true}, 1000, 1)
+	assert.False(t, response.ObjectParts.IsTruncated)
+	assert.Equal(t, 2, response.ObjectParts.Parts[0].PartNumber)
+
+	// mixed checksum algorithms are not combined
+	partsInfo = newMultipartPartsInfo([]*MultipartPartInfo{
+		{PartNumber: 1, Size: 5, Checksum: s3_constants.AmzChecksumSHA256 + ":" + checksum1},
+		{PartNumber: 2, Size: 5},
+	})
+	assert.Equal(t, "", partsInfo.ChecksumHeader)
+	assert.Equal(t, "", partsInfo.Parts[0].Checksum)
+}
@@ -0,0 +1,41 @@
+package s3api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+// writeConditions are the If-None-Match and If-Match headers of PutObject and CompleteMultipartUpload
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/conditional-writes.html
+// They are checked by the filer when saving the object entry, so concurrent conditional writes through any gateway
+// do not both succeed.
+type writeConditions struct {
+	// only "*", to create the object if the key does not exist
+	ifNoneMatch string
+	// the etags of the object to overwrite, or "*" for any existing object
+	ifMatch string
+}
+
+func getWriteConditions(r *http.Request) (conditions writeConditions, code s3err.ErrorCode) {
+	conditions.ifNoneMatch = strings.TrimSpace(r.Header.Get("If-None-Match"))
+	if conditions.ifNoneMatch != "" && conditions.ifNoneMatch != "*" {
+		return conditions, s3err.ErrNotImplemented
+	}
+	conditions.ifMatch = strings.TrimSpace(r.Header.Get("If-Match"))
+	return conditions, s3err.ErrNone
+}
+
+// conditionalWriteErrorCode maps the failed condition reported by the filer, or returns ErrNone for other errors.
+func conditionalWriteErrorCode(errString string) s3err.ErrorCode {
+	switch {
+	case strings.Contains(errString, filer.ErrPreconditionNotFound.Error()):
+		return s3err.ErrNoSuchKey
+	case strings.Contains(errString, filer.ErrPreconditionFailed.Error()):
+		return s3err.ErrPreconditionFailed
+	default:
+		return s3err.ErrNone
+	}
+}
@@ -0,0 +1,31 @@
+package s3api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+func TestWriteConditions(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodPut, "http://localhost/bucket/a.txt", nil)
+	r.Header.Set("If-None-Match", "*")
+	r.Header.Set("If-Match", `"abcd"`)
+	conditions, code := getWriteConditions(r)
+	assert.Equal(t, s3err.ErrNone, code)
+	assert.Equal(t, writeConditions{ifNoneMatch: "*", ifMatch: `"abcd"`}, conditions)
+
+	r, _ = http.NewRequest(http.MethodPut, "http://localhost/bucket/a.txt", nil)
+	r.Header.Set("If-None-Match", `"abcd"`)
+	_, code = getWriteConditions(r)
+	assert.Equal(t, s3err.ErrNotImplemented, code, "only If-None-Match: * is supported")
+}
+
+func TestConditionalWriteErrorCode(t *testing.T) {
+	assert.Equal(t, s3err.ErrPreconditionFailed, filerErrorToS3Error("precondition failed"))
+	assert.Equal(t, s3err.ErrNoSuchKey, filerErrorToS3Error("precondition failed: file not found"))
+	assert.Equal(t, s3err.ErrPreconditionFailed, conditionalWriteErrorCode("create file /buckets/b/a.txt: CreateEntry : precondition failed"))
+	assert.Equal(t, s3err.ErrNone, conditionalWriteErrorCode("create file /buckets/b/a.txt: connection refused"))
+}
@@ -2,6 +2,7 @@ package s3api
 
 import (
 	"fmt"
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
@@ -18,8 +19,14 @@ import (
 const (
 	DirectiveCopy    = "COPY"
 	DirectiveReplace = "REPLACE"
+
+	// same as AWS: the user metadata names, without the x-amz-meta- prefix, plus the values
+	maxUserMetadataSize = 2 * 1024
 )
 
+// the system metadata kept in the extended attributes, and copied unless the metadata directive is REPLACE
+var copiedSystemMetadataHeaders = []string{"Cache-Control", "Content-Disposition", "Expires"}
+
 func (s3a *S3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	dstBucket, dstObject := s3_constants.GetBucketAndObject(r)
@@ -35,6 +42,11 @@ func (s3a *S3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 
 	glog.V(3).Infof("CopyObjectHandler %s %s => %s %s", srcBucket, srcObject, dstBucket, dstObject)
 
+	if errCode := validateUserMetadata(r.Header); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
 	replaceMeta, replaceTagging := replaceDirective(r.Header)
 
 	if (srcBucket == dstBucket && srcObject == dstObject || cpSrcPath == "") && (replaceMeta || replaceTagging) {
@@ -51,6 +63,9 @@ func (s3a *S3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 			s3err.WriteErrorResponse(w, r, s3err.ErrInvalidTag)
 			return
 		}
+		if contentType := r.Header.Get("Content-Type"); replaceMeta && contentType != "" {
+			entry.Attributes.Mime = contentType
+		}
 		err = s3a.touch(dir, name, entry)
 		if err != nil {
 			s3err.WriteErrorResponse(w, r, s3err.ErrInvalidCopySource)
@@ -70,7 +85,8 @@ func (s3a *S3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 	}
 	srcPath := util.FullPath(fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, srcBucket, srcObject))
 	dir, name := srcPath.DirAndName()
-	if entry, err := s3a.getEntry(dir, name); err != nil || entry.IsDirectory {
+	srcEntry, err := s3a.getEntry(dir, name)
+	if err != nil || srcEntry.IsDirectory {
 		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidCopySource)
 		return
 	}
@@ -80,10 +96,25 @@ func (s3a *S3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	dstUrl := fmt.Sprintf("http://%s%s/%s%s",
-		s3a.option.Filer.ToHttpAddress(), s3a.option.BucketsPath, dstBucket, urlPathEscape(dstObject))
-	srcUrl := fmt.Sprintf("http://%s%s/%s%s",
-		s3a.option.Filer.ToHttpAddress(), s3a.option.BucketsPath, srcBucket, urlPathEscape(srcObject))
+	if errCode := s3a.checkObjectLock(r, dstBucket, dstObject); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	if errCode := s3a.checkObjectLockHeaders(r, dstBucket); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	objectSize, objectCount := int64(filer.FileSize(srcEntry)), s3a.newObjectCount(dstBucket, dstObject)
+	if errCode := s3a.checkBucketQuota(r, dstBucket, objectSize, objectCount); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	dstUrl := fmt.Sprintf("%s://%s%s/%s%s", util.HttpScheme(),
+		s3a.filers.Current().ToHttpAddress(), s3a.option.BucketsPath, dstBucket, urlPathEscape(dstObject))
+	srcUrl := fmt.Sprintf("%s://%s%s/%s%s", util.HttpScheme(),
+		s3a.filers.Current().ToHttpAddress(), s3a.option.BucketsPath, srcBucket, urlPathEscape(srcObject))
 
 	_, _, resp, err := util.DownloadFile(srcUrl, s3a.maybeGetFilerJwtAuthorizationToken(false))
 	if err != nil {
@@ -97,6 +128,11 @@ func (s3a *S3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidCopySource)
 		return
 	}
+	dstUrl, errCode := s3a.withStorageClass(dstUrl, r.Header.Get(s3_constants.AmzStorageClass))
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
 	glog.V(2).Infof("copy from %s to %s", srcUrl, dstUrl)
 	destination := fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, dstBucket, dstObject)
 	etag, errCode := s3a.putToFiler(r, dstUrl, resp.Body, destination)
@@ -105,6 +141,7 @@ func (s3a *S3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 		s3err.WriteErrorResponse(w, r, errCode)
 		return
 	}
+	s3a.quotas.recordWrite(dstBucket, objectSize, objectCount)
 
 	setEtag(w, etag)
 
@@ -169,10 +206,22 @@ func (s3a *S3ApiServer) CopyObjectPartHandler(w http.ResponseWriter, r *http.Req
 
 	rangeHeader := r.Header.Get("x-amz-copy-source-range")
 
-	dstUrl := fmt.Sprintf("http://%s%s/%s/%04d.part",
-		s3a.option.Filer.ToHttpAddress(), s3a.genUploadsFolder(dstBucket), uploadID, partID)
-	srcUrl := fmt.Sprintf("http://%s%s/%s%s",
-		s3a.option.Filer.ToHttpAddress(), s3a.option.BucketsPath, srcBucket, urlPathEscape(srcObject))
+	unlock := s3a.uploadLocks.RLock(uploadID)
+	defer unlock()
+	uploadEntry, errCode := s3a.getUpload(dstBucket, uploadID)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	dstUrl, errCode := s3a.withStorageClass(fmt.Sprintf("%s://%s%s/%s/%04d.part", util.HttpScheme(),
+		s3a.filers.Current().ToHttpAddress(), s3a.genUploadsFolder(dstBucket), uploadID, partID), storageClassOf(uploadEntry))
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	srcUrl := fmt.Sprintf("%s://%s%s/%s%s", util.HttpScheme(),
+		s3a.filers.Current().ToHttpAddress(), s3a.option.BucketsPath, srcBucket, urlPathEscape(srcObject))
 
 	resp, dataReader, err := util.ReadUrlAsReaderCloser(srcUrl, s3a.maybeGetFilerJwtAuthorizationToken(false), rangeHeader)
 	if err != nil {
@@ -202,6 +251,27 @@ func (s3a *S3ApiServer) CopyObjectPartHandler(w http.ResponseWriter, r *http.Req
 
 }
 
+func isUserMetadata(header string) bool {
+	return len(header) > len(s3_constants.AmzUserMetaPrefix) && strings.EqualFold(header[:len(s3_constants.AmzUserMetaPrefix)], s3_constants.AmzUserMetaPrefix)
+}
+
+// validateUserMetadata checks the metadata directive of a copy, and the size of the user metadata.
+func validateUserMetadata(reqHeader http.Header) s3err.ErrorCode {
+	if directive := reqHeader.Get(s3_constants.AmzUserMetaDirective); directive != "" && directive != DirectiveCopy && directive != DirectiveReplace {
+		return s3err.ErrInvalidMetadataDirective
+	}
+	size := 0
+	for header, values := range reqHeader {
+		if isUserMetadata(header) {
+			size += len(header) - len(s3_constants.AmzUserMetaPrefix) + len(strings.Join(values, ","))
+		}
+	}
+	if size > maxUserMetadataSize {
+		return s3err.ErrMetadataTooLarge
+	}
+	return s3err.ErrNone
+}
+
 func replaceDirective(reqHeader http.Header) (replaceMeta, replaceTagging bool) {
 	return reqHeader.Get(s3_constants.AmzUserMetaDirective) == DirectiveReplace, reqHeader.Get(s3_constants.AmzObjectTaggingDirective) == DirectiveReplace
 }
@@ -215,15 +285,21 @@ func processMetadata(reqHeader, existing http.Header, replaceMeta, replaceTaggin
 
 	if !replaceMeta {
 		for header, _ := range reqHeader {
-			if strings.HasPrefix(header, s3_constants.AmzUserMetaPrefix) {
+			if isUserMetadata(header) {
 				delete(reqHeader, header)
 			}
 		}
 		for k, v := range existing {
-			if strings.HasPrefix(k, s3_constants.AmzUserMetaPrefix) {
+			if isUserMetadata(k) {
 				reqHeader[k] = v
 			}
 		}
+		for _, header := range append([]string{"Content-Type"}, copiedSystemMetadataHeaders...) {
+			reqHeader.Del(header)
+			if v := existing.Get(header); v != "" {
+				reqHeader.Set(header, v)
+			}
+		}
 	}
 
 	if !replaceTagging {
@@ -261,8 +337,11 @@ func processMetadata(reqHeader, existing http.Header, replaceMeta, replaceTaggin
 func processMetadataBytes(reqHeader http.Header, existing map[string][]byte, replaceMeta, replaceTagging bool) (metadata map[string][]byte, err error) {
 	metadata = make(map[string][]byte)
 
-	if sc := existing[s3_constants.AmzStorageClass]; len(sc) > 0 {
-		metadata[s3_constants.AmzStorageClass] = sc
+	// keep the other extended attributes, e.g. the checksums and the multipart layout
+	for k, v := range existing {
+		if !isUserMetadata(k) && !strings.HasPrefix(k, s3_constants.AmzObjectTagging) {
+			metadata[k] = v
+		}
 	}
 	if sc := reqHeader.Get(s3_constants.AmzStorageClass); len(sc) > 0 {
 		metadata[s3_constants.AmzStorageClass] = []byte(sc)
@@ -270,15 +349,19 @@ func processMetadataBytes(reqHeader http.Header, existing map[string][]byte, rep
 
 	if replaceMeta {
 		for header, values := range reqHeader {
-			if strings.HasPrefix(header, s3_constants.AmzUserMetaPrefix) {
-				for _, value := range values {
-					metadata[header] = []byte(value)
-				}
+			if isUserMetadata(header) {
+				metadata[header] = []byte(strings.Join(values, ","))
+			}
+		}
+		for _, header := range copiedSystemMetadataHeaders {
+			delete(metadata, header)
+			if v := reqHeader.Get(header); v != "" {
+				metadata[header] = []byte(v)
 			}
 		}
 	} else {
 		for k, v := range existing {
-			if strings.HasPrefix(k, s3_constants.AmzUserMetaPrefix) {
+			if isUserMetadata(k) {
 				metadata[k] = v
 			}
 		}
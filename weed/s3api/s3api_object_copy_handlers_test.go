@@ -3,6 +3,7 @@ package s3api
 import (
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 	"net/http"
 	"reflect"
 	"sort"
@@ -52,6 +53,24 @@ var processMetadataTestCases = []struct {
 			"X-Amz-Tagging":      "A=B&a=b&type=existing",
 		},
 	},
+	{
+		203,
+		H{
+			"Content-Type":       "text/plain",
+			"X-Amz-Meta-My-Meta": "request",
+		},
+		H{
+			"Content-Type":        "image/png",
+			"Content-Disposition": "attachment",
+			"X-Amz-Meta-My-Meta":  "existing",
+		},
+		H{},
+		H{
+			"Content-Type":        "image/png",
+			"Content-Disposition": "attachment",
+			"X-Amz-Meta-My-Meta":  "existing",
+		},
+	},
 	{
 		202,
 		H{
@@ -345,6 +364,29 @@ var processMetadataBytesTestCases = []struct {
 		H{},
 		H{},
 	},
+
+	{
+		109,
+		H{
+			"User-Agent":                      "firefox",
+			"X-Amz-Meta-New-Meta":             "request",
+			"Cache-Control":                   "no-cache",
+			s3_constants.AmzUserMetaDirective: DirectiveReplace,
+		},
+		H{
+			"X-Amz-Meta-My-Meta":                     "existing",
+			"Cache-Control":                          "max-age=60",
+			"Expires":                                "Wed, 21 Oct 2015 07:28:00 GMT",
+			s3_constants.X_SeaweedFS_Multipart_Parts: "parts",
+			s3_constants.AmzStorageClass:             "STANDARD_IA",
+		},
+		H{
+			"X-Amz-Meta-New-Meta":                    "request",
+			"Cache-Control":                          "no-cache",
+			s3_constants.X_SeaweedFS_Multipart_Parts: "parts",
+			s3_constants.AmzStorageClass:             "STANDARD_IA",
+		},
+	},
 }
 
 func TestProcessMetadata(t *testing.T) {
@@ -436,3 +478,21 @@ func transferHeaderToH(data map[string][]string) H {
 	}
 	return m
 }
+
+func TestValidateUserMetadata(t *testing.T) {
+	header := http.Header{}
+	header.Add("X-Amz-Meta-Key", strings.Repeat("v", maxUserMetadataSize-len("key")))
+	if errCode := validateUserMetadata(header); errCode != s3err.ErrNone {
+		t.Errorf("expected metadata of %d bytes to be accepted, got %v", maxUserMetadataSize, errCode)
+	}
+	header.Add("X-Amz-Meta-Other", "v")
+	if errCode := validateUserMetadata(header); errCode != s3err.ErrMetadataTooLarge {
+		t.Errorf("expected metadata too large, got %v", errCode)
+	}
+
+	header = http.Header{}
+	header.Set(s3_constants.AmzUserMetaDirective, "MERGE")
+	if errCode := validateUserMetadata(header); errCode != s3err.ErrInvalidMetadataDirective {
+		t.Errorf("expected invalid metadata directive, got %v", errCode)
+	}
+}
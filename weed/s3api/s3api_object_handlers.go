@@ -23,6 +23,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	weed_server "github.com/seaweedfs/seaweedfs/weed/server"
 	"github.com/seaweedfs/seaweedfs/weed/util"
@@ -69,6 +70,28 @@ func (s3a *S3ApiServer) PutObjectHandler(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	if errCode := validateUserMetadata(r.Header); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	// the conditions are forwarded to the filer with the request headers
+	if _, errCode := getWriteConditions(r); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	if errCode := s3a.checkObjectLock(r, bucket, object); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	// the object lock headers are forwarded to the filer
+	if errCode := s3a.checkObjectLockHeaders(r, bucket); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
 	dataReader := r.Body
 	rAuthType := getRequestAuthType(r)
 	if s3a.iam.isEnabled() {
@@ -107,7 +130,22 @@ func (s3a *S3ApiServer) PutObjectHandler(w http.ResponseWriter, r *http.Request)
 			return
 		}
 	} else {
-		uploadUrl := s3a.toFilerUrl(bucket, object)
+		objectSize := requestContentLength(r)
+		if errCode := s3a.checkUploadPolicy(bucket, object, objectContentType, objectSize); errCode != s3err.ErrNone {
+			s3err.WriteErrorResponse(w, r, errCode)
+			return
+		}
+		objectCount := s3a.newObjectCount(bucket, object)
+		if errCode := s3a.checkBucketQuota(r, bucket, objectSize, objectCount); errCode != s3err.ErrNone {
+			s3err.WriteErrorResponse(w, r, errCode)
+			return
+		}
+
+		uploadUrl, errCode := s3a.withStorageClass(s3a.toFilerUrl(bucket, object), r.Header.Get(s3_constants.AmzStorageClass))
+		if errCode != s3err.ErrNone {
+			s3err.WriteErrorResponse(w, r, errCode)
+			return
+		}
 		if objectContentType == "" {
 			dataReader = mimeDetect(r, dataReader)
 		}
@@ -118,8 +156,10 @@ func (s3a *S3ApiServer) PutObjectHandler(w http.ResponseWriter, r *http.Request)
 			s3err.WriteErrorResponse(w, r, errCode)
 			return
 		}
+		s3a.quotas.recordWrite(bucket, objectSize, objectCount)
 
 		setEtag(w, etag)
+		setChecksumHeaders(w, r)
 	}
 
 	writeSuccessResponseEmpty(w, r)
@@ -154,9 +194,17 @@ func removeDuplicateSlashes(object string) string {
 }
 
 func (s3a *S3ApiServer) toFilerUrl(bucket, object string) string {
+	return s3a.toUrlOnFiler(s3a.filers.Current(), bucket, object)
+}
+
+func (s3a *S3ApiServer) toFilerReadUrl(bucket, object string) string {
+	return s3a.toUrlOnFiler(s3a.filers.ForRead(), bucket, object)
+}
+
+func (s3a *S3ApiServer) toUrlOnFiler(filerAddress rpc.ServerAddress, bucket, object string) string {
 	object = urlPathEscape(removeDuplicateSlashes(object))
-	destUrl := fmt.Sprintf("http://%s%s/%s%s",
-		s3a.option.Filer.ToHttpAddress(), s3a.option.BucketsPath, bucket, object)
+	destUrl := fmt.Sprintf("%s://%s%s/%s%s", util.HttpScheme(),
+		filerAddress.ToHttpAddress(), s3a.option.BucketsPath, bucket, object)
 	return destUrl
 }
 
@@ -170,7 +218,7 @@ func (s3a *S3ApiServer) GetObjectHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	destUrl := s3a.toFilerUrl(bucket, object)
+	destUrl := s3a.toFilerReadUrl(bucket, object)
 
 	s3a.proxyToFiler(w, r, destUrl, false, passThroughResponse)
 }
@@ -180,7 +228,7 @@ func (s3a *S3ApiServer) HeadObjectHandler(w http.ResponseWriter, r *http.Request
 	bucket, object := s3_constants.GetBucketAndObject(r)
 	glog.V(3).Infof("HeadObjectHandler %s %s", bucket, object)
 
-	destUrl := s3a.toFilerUrl(bucket, object)
+	destUrl := s3a.toFilerReadUrl(bucket, object)
 
 	s3a.proxyToFiler(w, r, destUrl, false, passThroughResponse)
 }
@@ -190,6 +238,11 @@ func (s3a *S3ApiServer) DeleteObjectHandler(w http.ResponseWriter, r *http.Reque
 	bucket, object := s3_constants.GetBucketAndObject(r)
 	glog.V(3).Infof("DeleteObjectHandler %s %s", bucket, object)
 
+	if errCode := s3a.checkObjectLock(r, bucket, object); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
 	destUrl := s3a.toFilerUrl(bucket, object)
 
 	s3a.proxyToFiler(w, r, destUrl, true, func(proxyResponse *http.Response, w http.ResponseWriter) (statusCode int) {
@@ -205,6 +258,8 @@ func (s3a *S3ApiServer) DeleteObjectHandler(w http.ResponseWriter, r *http.Reque
 // / ObjectIdentifier carries key name for the object to delete.
 type ObjectIdentifier struct {
 	ObjectName string `xml:"Key"`
+	// only the "null" version exists, the buckets are not versioned
+	VersionId string `xml:"VersionId,omitempty"`
 }
 
 // DeleteObjectsRequest - xml carrying the object key names which needs to be deleted.
@@ -217,9 +272,20 @@ type DeleteObjectsRequest struct {
 
 // DeleteError structure.
 type DeleteError struct {
-	Code    string
-	Message string
-	Key     string
+	Code      string
+	Message   string
+	Key       string
+	VersionId string `xml:"VersionId,omitempty"`
+}
+
+func newDeleteError(object ObjectIdentifier, errCode s3err.ErrorCode) DeleteError {
+	apiErr := s3err.GetAPIError(errCode)
+	return DeleteError{
+		Code:      apiErr.Code,
+		Message:   apiErr.Description,
+		Key:       object.ObjectName,
+		VersionId: object.VersionId,
+	}
 }
 
 // DeleteObjectsResponse container for multiple object deletes.
@@ -245,8 +311,20 @@ func (s3a *S3ApiServer) DeleteMultipleObjectsHandler(w http.ResponseWriter, r *h
 		return
 	}
 
+	contentMd5, err := validateContentMd5(r.Header)
+	if err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidDigest)
+		return
+	}
+	if len(contentMd5) > 0 {
+		if sum := md5.Sum(deleteXMLBytes); !bytes.Equal(sum[:], contentMd5) {
+			s3err.WriteErrorResponse(w, r, s3err.ErrBadDigest)
+			return
+		}
+	}
+
 	deleteObjects := &DeleteObjectsRequest{}
-	if err := xml.Unmarshal(deleteXMLBytes, deleteObjects); err != nil {
+	if err := xml.Unmarshal(deleteXMLBytes, deleteObjects); err != nil || len(deleteObjects.Objects) == 0 {
 		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
 		return
 	}
@@ -260,32 +338,44 @@ func (s3a *S3ApiServer) DeleteMultipleObjectsHandler(w http.ResponseWriter, r *h
 	var deleteErrors []DeleteError
 
 	directoriesWithDeletion := make(map[string]int)
+	bypassGovernance := s3a.canBypassGovernance(r)
 
-	s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+	err = s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
 
-		// delete file entries
+		// delete file entries, each key succeeds or fails on its own
 		for _, object := range deleteObjects.Objects {
-			lastSeparator := strings.LastIndex(object.ObjectName, "/")
-			parentDirectoryPath, entryName, isDeleteData, isRecursive := "", object.ObjectName, true, false
-			if lastSeparator > 0 && lastSeparator+1 < len(object.ObjectName) {
-				entryName = object.ObjectName[lastSeparator+1:]
-				parentDirectoryPath = "/" + object.ObjectName[:lastSeparator]
+			if object.ObjectName == "" {
+				deleteErrors = append(deleteErrors, newDeleteError(object, s3err.ErrUserKeyMustBeSpecified))
+				continue
+			}
+			if object.VersionId != "" && object.VersionId != "null" {
+				deleteErrors = append(deleteErrors, newDeleteError(object, s3err.ErrNoSuchVersion))
+				continue
+			}
+
+			// the directory objects are saved as directories without the trailing slash
+			objectPath := strings.TrimSuffix(object.ObjectName, "/")
+			lastSeparator := strings.LastIndex(objectPath, "/")
+			parentDirectoryPath, entryName, isDeleteData, isRecursive := "", objectPath, true, false
+			if lastSeparator > 0 && lastSeparator+1 < len(objectPath) {
+				entryName = objectPath[lastSeparator+1:]
+				parentDirectoryPath = "/" + objectPath[:lastSeparator]
 			}
 			parentDirectoryPath = fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, bucket, parentDirectoryPath)
 
-			err := doDeleteEntry(client, parentDirectoryPath, entryName, isDeleteData, isRecursive)
+			// the filer refuses to delete the locked objects
+			err := doDeleteEntry(client, parentDirectoryPath, entryName, isDeleteData, isRecursive, bypassGovernance)
 			if err == nil {
 				directoriesWithDeletion[parentDirectoryPath]++
 				deletedObjects = append(deletedObjects, object)
 			} else if strings.Contains(err.Error(), filer.MsgFailDelNonEmptyFolder) {
 				deletedObjects = append(deletedObjects, object)
+			} else if code := objectLockErrorCode(err.Error()); code != s3err.ErrNone {
+				deleteErrors = append(deleteErrors, newDeleteError(object, code))
 			} else {
+				glog.Errorf("DeleteMultipleObjectsHandler %s/%s: %v", bucket, object.ObjectName, err)
 				delete(directoriesWithDeletion, parentDirectoryPath)
-				deleteErrors = append(deleteErrors, DeleteError{
-					Code:    "",
-					Message: err.Error(),
-					Key:     object.ObjectName,
-				})
+				deleteErrors = append(deleteErrors, newDeleteError(object, s3err.ErrInternalError))
 			}
 		}
 
@@ -296,6 +386,11 @@ func (s3a *S3ApiServer) DeleteMultipleObjectsHandler(w http.ResponseWriter, r *h
 
 		return nil
 	})
+	if err != nil {
+		s3err.RecordInternalError(r, fmt.Errorf("delete objects in %s: %w", bucket, err))
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
 
 	deleteResp := DeleteObjectsResponse{}
 	if !deleteObjects.Quiet {
@@ -321,7 +416,7 @@ func (s3a *S3ApiServer) doDeleteEmptyDirectories(client filer_pb.SeaweedFilerCli
 		if parentDir == s3a.option.BucketsPath {
 			continue
 		}
-		if err := doDeleteEntry(client, parentDir, dirName, false, false); err != nil {
+		if err := doDeleteEntry(client, parentDir, dirName, false, false, false); err != nil {
 			glog.V(4).Infof("directory %s has %d deletion but still not empty: %v", dir, directoriesWithDeletion[dir], err)
 		} else {
 			newDirectoriesWithDeletion[parentDir]++
@@ -338,6 +433,7 @@ func (s3a *S3ApiServer) proxyToFiler(w http.ResponseWriter, r *http.Request, des
 
 	if err != nil {
 		glog.Errorf("NewRequest %s: %v", destUrl, err)
+		s3err.RecordInternalError(r, fmt.Errorf("new request %s: %w", destUrl, err))
 		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
 		return
 	}
@@ -351,14 +447,34 @@ func (s3a *S3ApiServer) proxyToFiler(w http.ResponseWriter, r *http.Request, des
 	for header, values := range r.Header {
 		proxyReq.Header[header] = values
 	}
+	if !s3a.canBypassGovernance(r) {
+		proxyReq.Header.Del(s3_constants.AmzBypassGovernanceRetention)
+	}
 
 	// ensure that the Authorization header is overriding any previous
 	// Authorization header which might be already present in proxyReq
 	s3a.maybeAddFilerJwtAuthorization(proxyReq, isWrite)
 	resp, postErr := s3a.client.Do(proxyReq)
 
+	// requests without a body can be retried on other filers
+	for postErr != nil {
+		failedFiler, found := s3a.filers.FindByHttpAddress(proxyReq.URL.Host)
+		if !found {
+			break
+		}
+		s3a.filers.MarkUnhealthy(failedFiler)
+		nextFiler, hasNext := s3a.filers.Next(failedFiler)
+		if !hasNext || (r.Method != "GET" && r.Method != "HEAD") {
+			break
+		}
+		glog.V(1).Infof("retry %s %s on filer %s: %v", r.Method, destUrl, nextFiler, postErr)
+		proxyReq.URL.Host = nextFiler.ToHttpAddress()
+		resp, postErr = s3a.client.Do(proxyReq)
+	}
+
 	if postErr != nil {
 		glog.Errorf("post to filer: %v", postErr)
+		s3err.RecordInternalError(r, fmt.Errorf("proxy to filer %s: %w", destUrl, postErr))
 		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
 		return
 	}
@@ -369,6 +485,11 @@ func (s3a *S3ApiServer) proxyToFiler(w http.ResponseWriter, r *http.Request, des
 		return
 	}
 
+	if resp.StatusCode == http.StatusForbidden && r.Method == "DELETE" {
+		s3err.WriteErrorResponse(w, r, s3err.ErrObjectLocked)
+		return
+	}
+
 	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
 		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidRange)
 		return
@@ -400,6 +521,11 @@ func (s3a *S3ApiServer) proxyToFiler(w http.ResponseWriter, r *http.Request, des
 
 func passThroughResponse(proxyResponse *http.Response, w http.ResponseWriter) (statusCode int) {
 	for k, v := range proxyResponse.Header {
+		if isUserMetadata(k) {
+			// the header names are canonicalized on the way, and the user metadata names
+			// are returned in lower case as AWS does, e.g. x-amz-meta-mykey
+			k = strings.ToLower(k)
+		}
 		w.Header()[k] = v
 	}
 	if proxyResponse.Header.Get("Content-Range") != "" && proxyResponse.StatusCode == 200 {
@@ -426,6 +552,7 @@ func (s3a *S3ApiServer) putToFiler(r *http.Request, uploadUrl string, dataReader
 
 	if err != nil {
 		glog.Errorf("NewRequest %s: %v", uploadUrl, err)
+		s3err.RecordInternalError(r, fmt.Errorf("new request %s: %w", uploadUrl, err))
 		return "", s3err.ErrInternalError
 	}
 
@@ -439,6 +566,9 @@ func (s3a *S3ApiServer) putToFiler(r *http.Request, uploadUrl string, dataReader
 			proxyReq.Header.Add(header, value)
 		}
 	}
+	if !s3a.canBypassGovernance(r) {
+		proxyReq.Header.Del(s3_constants.AmzBypassGovernanceRetention)
+	}
 	// ensure that the Authorization header is overriding any previous
 	// Authorization header which might be already present in proxyReq
 	s3a.maybeAddFilerJwtAuthorization(proxyReq, true)
@@ -446,6 +576,7 @@ func (s3a *S3ApiServer) putToFiler(r *http.Request, uploadUrl string, dataReader
 
 	if postErr != nil {
 		glog.Errorf("post to filer: %v", postErr)
+		s3err.RecordInternalError(r, fmt.Errorf("upload to filer %s: %w", uploadUrl, postErr))
 		return "", s3err.ErrInternalError
 	}
 	defer resp.Body.Close()
@@ -455,12 +586,14 @@ func (s3a *S3ApiServer) putToFiler(r *http.Request, uploadUrl string, dataReader
 	resp_body, ra_err := io.ReadAll(resp.Body)
 	if ra_err != nil {
 		glog.Errorf("upload to filer response read %d: %v", resp.StatusCode, ra_err)
+		s3err.RecordInternalError(r, fmt.Errorf("read upload response from %s: %w", uploadUrl, ra_err))
 		return etag, s3err.ErrInternalError
 	}
 	var ret weed_server.FilerPostResult
 	unmarshal_err := json.Unmarshal(resp_body, &ret)
 	if unmarshal_err != nil {
 		glog.Errorf("failing to read upload to %s : %v", uploadUrl, string(resp_body))
+		s3err.RecordInternalError(r, fmt.Errorf("parse upload response from %s: %w", uploadUrl, unmarshal_err))
 		return "", s3err.ErrInternalError
 	}
 	if ret.Error != "" {
@@ -481,12 +614,35 @@ func setEtag(w http.ResponseWriter, etag string) {
 	}
 }
 
+// setChecksumHeaders echoes the verified x-amz-checksum-* request headers
+func setChecksumHeaders(w http.ResponseWriter, r *http.Request) {
+	for _, header := range s3_constants.AmzChecksumHeaders {
+		if checksum := r.Header.Get(header); checksum != "" {
+			w.Header().Set(header, checksum)
+		}
+	}
+}
+
 func filerErrorToS3Error(errString string) s3err.ErrorCode {
+	if code := conditionalWriteErrorCode(errString); code != s3err.ErrNone {
+		return code
+	}
+	if code := objectLockErrorCode(errString); code != s3err.ErrNone {
+		return code
+	}
 	switch {
 	case strings.HasPrefix(errString, "existing ") && strings.HasSuffix(errString, "is a directory"):
 		return s3err.ErrExistingObjectIsDirectory
 	case strings.HasSuffix(errString, "is a file"):
 		return s3err.ErrExistingObjectIsFile
+	case errString == weed_server.ErrChecksumMismatch.Error():
+		return s3err.ErrBadDigest
+	case strings.HasPrefix(errString, filer.ErrFileTooLarge.Error()):
+		return s3err.ErrEntityTooLarge
+	case strings.HasPrefix(errString, filer.ErrMimeTypeNotAllowed.Error()):
+		return s3err.ErrContentTypeNotAllowed
+	case strings.HasPrefix(errString, filer.ErrFileNameNotAllowed.Error()):
+		return s3err.ErrObjectNameNotAllowed
 	default:
 		return s3err.ErrInternalError
 	}
@@ -1,8 +1,26 @@
 package s3api
 
 import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,3 +64,150 @@ func TestRemoveDuplicateSlashes(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteObjectsResponseVersionId(t *testing.T) {
+	deleteResp := DeleteObjectsResponse{
+		DeletedObjects: []ObjectIdentifier{{ObjectName: "a.txt"}, {ObjectName: "b.txt", VersionId: "null"}},
+		Errors:         []DeleteError{newDeleteError(ObjectIdentifier{ObjectName: "c.txt", VersionId: "v2"}, s3err.ErrNoSuchVersion)},
+	}
+	encoded, err := xml.Marshal(deleteResp)
+	assert.Nil(t, err)
+	assert.Equal(t, `<DeleteResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`+
+		`<Deleted><Key>a.txt</Key></Deleted>`+
+		`<Deleted><Key>b.txt</Key><VersionId>null</VersionId></Deleted>`+
+		`<Error><Code>NoSuchVersion</Code><Message>The specified version does not exist.</Message><Key>c.txt</Key><VersionId>v2</VersionId></Error>`+
+		`</DeleteResult>`, string(encoded))
+}
+
+func TestPassThroughResponseUserMetadataNames(t *testing.T) {
+	proxyResponse := &http.Response{
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"X-Amz-Meta-Mykey": []string{"value"},
+			"Content-Type":     []string{"text/plain"},
+		},
+		Body: io.NopCloser(strings.NewReader("data")),
+	}
+	w := httptest.NewRecorder()
+	passThroughResponse(proxyResponse, w)
+
+	assert.Equal(t, []string{"value"}, w.Header()["x-amz-meta-mykey"])
+	assert.Nil(t, w.Header()["X-Amz-Meta-Mykey"])
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, "data", w.Body.String())
+}
+
+// deleteObjectsFiler deletes the existing entries, refuses the locked ones and fails on the broken ones
+type deleteObjectsFiler struct {
+	filer_pb.UnimplementedSeaweedFilerServer
+	sync.Mutex
+	entries map[string]string
+}
+
+func (fs *deleteObjectsFiler) DeleteEntry(ctx context.Context, req *filer_pb.DeleteEntryRequest) (*filer_pb.DeleteEntryResponse, error) {
+	fs.Lock()
+	defer fs.Unlock()
+	fullpath := req.Directory + "/" + req.Name
+	switch fs.entries[fullpath] {
+	case "locked":
+		return &filer_pb.DeleteEntryResponse{Error: "object is locked: " + fullpath}, nil
+	case "broken":
+		return nil, fmt.Errorf("store failure")
+	case "":
+		return &filer_pb.DeleteEntryResponse{Error: "not found"}, nil
+	}
+	delete(fs.entries, fullpath)
+	return &filer_pb.DeleteEntryResponse{}, nil
+}
+
+func newDeleteObjectsServer(t *testing.T, entries map[string]string) *S3ApiServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	filer_pb.RegisterSeaweedFilerServer(grpcServer, &deleteObjectsFiler{entries: entries})
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	filerAddress := rpc.NewServerAddress("127.0.0.1", 1, listener.Addr().(*net.TCPAddr).Port)
+	return &S3ApiServer{
+		option: &S3ApiServerOption{
+			BucketsPath:    "/buckets",
+			GrpcDialOption: grpc.WithTransportCredentials(insecure.NewCredentials()),
+		},
+		iam:    &IdentityAccessManagement{},
+		filers: NewFilerSelector([]rpc.ServerAddress{filerAddress}, false),
+	}
+}
+
+func deleteObjects(s3a *S3ApiServer, body string, header http.Header) (*httptest.ResponseRecorder, DeleteObjectsResponse) {
+	r := httptest.NewRequest(http.MethodPost, "/b?delete", strings.NewReader(body))
+	r = mux.SetURLVars(r, map[string]string{"bucket": "b"})
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	s3a.DeleteMultipleObjectsHandler(w, r)
+	var deleteResp DeleteObjectsResponse
+	if w.Code == http.StatusOK {
+		xml.Unmarshal(w.Body.Bytes(), &deleteResp)
+	}
+	return w, deleteResp
+}
+
+func TestDeleteMultipleObjectsHandler(t *testing.T) {
+	body := `<Delete>` +
+		`<Object><Key>a.txt</Key></Object>` +
+		`<Object><Key>dir/b.txt</Key><VersionId>null</VersionId></Object>` +
+		`<Object><Key>locked.txt</Key></Object>` +
+		`<Object><Key>broken.txt</Key></Object>` +
+		`<Object><Key>c.txt</Key><VersionId>v2</VersionId></Object>` +
+		`<Object><Key></Key></Object>` +
+		`</Delete>`
+	newEntries := func() map[string]string {
+		return map[string]string{
+			"/buckets/b/a.txt":      "file",
+			"/buckets/b/dir/b.txt":  "file",
+			"/buckets/b/locked.txt": "locked",
+			"/buckets/b/broken.txt": "broken",
+		}
+	}
+	expectedErrors := []DeleteError{
+		newDeleteError(ObjectIdentifier{ObjectName: "locked.txt"}, s3err.ErrObjectLocked),
+		newDeleteError(ObjectIdentifier{ObjectName: "broken.txt"}, s3err.ErrInternalError),
+		newDeleteError(ObjectIdentifier{ObjectName: "c.txt", VersionId: "v2"}, s3err.ErrNoSuchVersion),
+		newDeleteError(ObjectIdentifier{}, s3err.ErrUserKeyMustBeSpecified),
+	}
+
+	// each key succeeds or fails on its own
+	w, deleteResp := deleteObjects(newDeleteObjectsServer(t, newEntries()), body, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []ObjectIdentifier{{ObjectName: "a.txt"}, {ObjectName: "dir/b.txt", VersionId: "null"}}, deleteResp.DeletedObjects)
+	assert.Equal(t, expectedErrors, deleteResp.Errors)
+
+	// the quiet mode only reports the errors
+	quietBody := strings.Replace(body, "<Delete>", "<Delete><Quiet>true</Quiet>", 1)
+	w, deleteResp = deleteObjects(newDeleteObjectsServer(t, newEntries()), quietBody, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, deleteResp.DeletedObjects)
+	assert.Equal(t, expectedErrors, deleteResp.Errors)
+
+	// the Content-MD5 must match the body
+	sum := md5.Sum([]byte(body))
+	w, _ = deleteObjects(newDeleteObjectsServer(t, newEntries()), body, http.Header{"Content-Md5": {base64.StdEncoding.EncodeToString(sum[:])}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	entries := newEntries()
+	w, _ = deleteObjects(newDeleteObjectsServer(t, entries), quietBody, http.Header{"Content-Md5": {base64.StdEncoding.EncodeToString(sum[:])}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "<Code>BadDigest</Code>")
+	assert.Contains(t, entries, "/buckets/b/a.txt")
+	w, _ = deleteObjects(newDeleteObjectsServer(t, newEntries()), body, http.Header{"Content-Md5": {"not base64"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "<Code>InvalidDigest</Code>")
+
+	// a request without keys is malformed
+	w, _ = deleteObjects(newDeleteObjectsServer(t, newEntries()), `<Delete></Delete>`, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "<Code>MalformedXML</Code>")
+}
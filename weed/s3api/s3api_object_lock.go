@@ -0,0 +1,496 @@
+package s3api
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	ObjectLockModeGovernance = filer.ObjectLockModeGovernance
+	ObjectLockModeCompliance = filer.ObjectLockModeCompliance
+	ObjectLockLegalHoldOn    = filer.ObjectLockLegalHoldOn
+	ObjectLockLegalHoldOff   = "OFF"
+	ObjectLockEnabled        = "Enabled"
+)
+
+// ObjectLockRetention is the body of PutObjectRetention and GetObjectRetention
+type ObjectLockRetention struct {
+	XMLName         xml.Name `xml:"Retention"`
+	Xmlns           string   `xml:"xmlns,attr"`
+	Mode            string   `xml:"Mode,omitempty"`
+	RetainUntilDate string   `xml:"RetainUntilDate,omitempty"`
+}
+
+// ObjectLockLegalHold is the body of PutObjectLegalHold and GetObjectLegalHold
+type ObjectLockLegalHold struct {
+	XMLName xml.Name `xml:"LegalHold"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Status  string   `xml:"Status"`
+}
+
+// ObjectLockConfiguration is the body of PutObjectLockConfiguration and GetObjectLockConfiguration
+type ObjectLockConfiguration struct {
+	XMLName           xml.Name        `xml:"ObjectLockConfiguration"`
+	Xmlns             string          `xml:"xmlns,attr"`
+	ObjectLockEnabled string          `xml:"ObjectLockEnabled,omitempty"`
+	Rule              *ObjectLockRule `xml:"Rule,omitempty"`
+}
+
+type ObjectLockRule struct {
+	DefaultRetention ObjectLockDefaultRetention `xml:"DefaultRetention"`
+}
+
+// ObjectLockDefaultRetention is the retention of the new objects without a retention, in either days or years
+type ObjectLockDefaultRetention struct {
+	Mode  string `xml:"Mode"`
+	Days  int    `xml:"Days,omitempty"`
+	Years int    `xml:"Years,omitempty"`
+}
+
+func (c *ObjectLockConfiguration) validate() s3err.ErrorCode {
+	if c.ObjectLockEnabled != ObjectLockEnabled {
+		return s3err.ErrMalformedXML
+	}
+	if c.Rule == nil {
+		return s3err.ErrNone
+	}
+	retention := c.Rule.DefaultRetention
+	if !isValidObjectLockMode(retention.Mode) {
+		return s3err.ErrMalformedXML
+	}
+	if retention.Days < 0 || retention.Years < 0 || (retention.Days > 0) == (retention.Years > 0) {
+		return s3err.ErrMalformedXML
+	}
+	return s3err.ErrNone
+}
+
+func isValidObjectLockMode(mode string) bool {
+	return mode == ObjectLockModeGovernance || mode == ObjectLockModeCompliance
+}
+
+// readObjectLockConfiguration reads the configuration kept in the bucket entry, nil if the object lock is not enabled
+func readObjectLockConfiguration(bucketEntry *filer_pb.Entry) (*ObjectLockConfiguration, error) {
+	data, found := bucketEntry.Extended[s3_constants.X_SeaweedFS_Object_Lock_Config]
+	if !found {
+		return nil, nil
+	}
+	config := &ObjectLockConfiguration{}
+	if err := xml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("unmarshal object lock configuration: %v", err)
+	}
+	return config, nil
+}
+
+// getObjectLockConfiguration reads the object lock configuration of the bucket, nil if the object lock is not enabled
+func (s3a *S3ApiServer) getObjectLockConfiguration(bucket string) (*ObjectLockConfiguration, s3err.ErrorCode) {
+	entry, err := s3a.getEntry(s3a.option.BucketsPath, bucket)
+	if err == filer_pb.ErrNotFound {
+		return nil, s3err.ErrNoSuchBucket
+	}
+	if err != nil {
+		glog.Errorf("get bucket %s: %v", bucket, err)
+		return nil, s3err.ErrInternalError
+	}
+	config, err := readObjectLockConfiguration(entry)
+	if err != nil {
+		glog.Errorf("bucket %s: %v", bucket, err)
+		return nil, s3err.ErrInternalError
+	}
+	return config, s3err.ErrNone
+}
+
+// checkObjectLockHeaders validates the x-amz-object-lock-* headers of a new object, saved by the filer with the object,
+// and sets the default retention of the bucket if the request has no retention.
+func (s3a *S3ApiServer) checkObjectLockHeaders(r *http.Request, bucket string) s3err.ErrorCode {
+	mode := r.Header.Get(s3_constants.AmzObjectLockMode)
+	retainUntilDate := r.Header.Get(s3_constants.AmzObjectLockRetainUntilDate)
+	legalHold := r.Header.Get(s3_constants.AmzObjectLockLegalHold)
+
+	config, errCode := s3a.getObjectLockConfiguration(bucket)
+	if errCode != s3err.ErrNone {
+		return errCode
+	}
+	if config == nil {
+		if mode != "" || retainUntilDate != "" || legalHold != "" {
+			return s3err.ErrMissingObjectLockConfiguration
+		}
+		return s3err.ErrNone
+	}
+
+	if legalHold != "" && legalHold != ObjectLockLegalHoldOn && legalHold != ObjectLockLegalHoldOff {
+		return s3err.ErrInvalidObjectLockHeaders
+	}
+	now := time.Now()
+	if mode == "" && retainUntilDate == "" {
+		if config.Rule != nil {
+			retention := config.Rule.DefaultRetention
+			r.Header.Set(s3_constants.AmzObjectLockMode, retention.Mode)
+			r.Header.Set(s3_constants.AmzObjectLockRetainUntilDate, now.AddDate(retention.Years, 0, retention.Days).UTC().Format(time.RFC3339))
+		}
+		return s3err.ErrNone
+	}
+	if !isValidObjectLockMode(mode) {
+		return s3err.ErrInvalidObjectLockHeaders
+	}
+	retainUntil, err := time.Parse(time.RFC3339, retainUntilDate)
+	if err != nil {
+		return s3err.ErrInvalidObjectLockHeaders
+	}
+	if !now.Before(retainUntil) {
+		return s3err.ErrInvalidRetentionDate
+	}
+	r.Header.Set(s3_constants.AmzObjectLockRetainUntilDate, retainUntil.UTC().Format(time.RFC3339))
+	return s3err.ErrNone
+}
+
+// objectLockErrorCode maps the filer refusing to change a locked object
+func objectLockErrorCode(errString string) s3err.ErrorCode {
+	if strings.Contains(errString, filer.ErrObjectLocked.Error()) {
+		return s3err.ErrObjectLocked
+	}
+	return s3err.ErrNone
+}
+
+// canBypassGovernance allows the admins to delete, overwrite, or shorten the retention of the objects in governance mode,
+// with the x-amz-bypass-governance-retention header.
+func (s3a *S3ApiServer) canBypassGovernance(r *http.Request) bool {
+	if !strings.EqualFold(r.Header.Get(s3_constants.AmzBypassGovernanceRetention), "true") {
+		return false
+	}
+	return !s3a.iam.isEnabled() || r.Header.Get(s3_constants.AmzIsAdmin) != ""
+}
+
+// checkObjectLock refuses to delete or overwrite a locked object.
+// It is checked before the write, so a lock set during the write does not stop it.
+func (s3a *S3ApiServer) checkObjectLock(r *http.Request, bucket, object string) s3err.ErrorCode {
+	target := util.FullPath(fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, bucket, object))
+	dir, name := target.DirAndName()
+	entry, err := s3a.getEntry(dir, name)
+	if err == filer_pb.ErrNotFound {
+		return s3err.ErrNone
+	}
+	if err != nil {
+		glog.Errorf("check object lock %s: %v", target, err)
+		return s3err.ErrInternalError
+	}
+	if !entry.IsDirectory && filer.IsObjectLocked(entry.Extended, s3a.canBypassGovernance(r), time.Now()) {
+		glog.V(2).Infof("object %s is locked", target)
+		return s3err.ErrObjectLocked
+	}
+	return s3err.ErrNone
+}
+
+// checkRetentionChange allows to extend the retention, and to reduce or remove it only in governance mode with the bypass.
+func checkRetentionChange(entry *filer_pb.Entry, retention *ObjectLockRetention, retainUntil time.Time, bypassGovernance bool, now time.Time) s3err.ErrorCode {
+	mode, currentRetainUntil := filer.ObjectRetention(entry.Extended)
+	if mode == "" || !now.Before(currentRetainUntil) {
+		return s3err.ErrNone
+	}
+	isReduced := retention.Mode == "" || retainUntil.Before(currentRetainUntil)
+	if mode == ObjectLockModeCompliance && (isReduced || retention.Mode != ObjectLockModeCompliance) {
+		return s3err.ErrObjectLockRetentionReduced
+	}
+	if mode == ObjectLockModeGovernance && isReduced && !bypassGovernance {
+		return s3err.ErrObjectLockRetentionReduced
+	}
+	return s3err.ErrNone
+}
+
+// updateObjectLock changes the object entry in a bucket with the object lock enabled,
+// serialized with the other object lock changes through this gateway. The filer checks the retention again.
+func (s3a *S3ApiServer) updateObjectLock(bucket, object string, bypassGovernance bool, fn func(entry *filer_pb.Entry) s3err.ErrorCode) s3err.ErrorCode {
+	config, errCode := s3a.getObjectLockConfiguration(bucket)
+	if errCode != s3err.ErrNone {
+		return errCode
+	}
+	if config == nil {
+		return s3err.ErrMissingObjectLockConfiguration
+	}
+
+	target := util.FullPath(fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, bucket, object))
+	unlock := s3a.objectLocks.Lock(string(target))
+	defer unlock()
+
+	dir, name := target.DirAndName()
+	entry, err := s3a.getEntry(dir, name)
+	if err == filer_pb.ErrNotFound || err == nil && (entry == nil || entry.IsDirectory) {
+		return s3err.ErrNoSuchKey
+	}
+	if err != nil {
+		glog.Errorf("update object lock %s: %v", target, err)
+		return s3err.ErrInternalError
+	}
+	if entry.Extended == nil {
+		entry.Extended = make(map[string][]byte)
+	}
+	if code := fn(entry); code != s3err.ErrNone {
+		return code
+	}
+	err = s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer_pb.UpdateEntry(client, &filer_pb.UpdateEntryRequest{
+			Directory:                 dir,
+			Entry:                     entry,
+			BypassGovernanceRetention: bypassGovernance,
+		})
+	})
+	if err != nil {
+		if objectLockErrorCode(err.Error()) != s3err.ErrNone {
+			return s3err.ErrObjectLockRetentionReduced
+		}
+		glog.Errorf("update object lock %s: %v", target, err)
+		return s3err.ErrInternalError
+	}
+	return s3err.ErrNone
+}
+
+// GetObjectRetentionHandler Get object Retention
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectRetention.html
+func (s3a *S3ApiServer) GetObjectRetentionHandler(w http.ResponseWriter, r *http.Request) {
+
+	bucket, object := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("GetObjectRetentionHandler %s %s", bucket, object)
+
+	target := util.FullPath(fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, bucket, object))
+	dir, name := target.DirAndName()
+	entry, err := s3a.getEntry(dir, name)
+	if err == filer_pb.ErrNotFound || err == nil && (entry == nil || entry.IsDirectory) {
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchKey)
+		return
+	}
+	if err != nil {
+		glog.Errorf("GetObjectRetentionHandler %s: %v", r.URL, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	mode, retainUntil := filer.ObjectRetention(entry.Extended)
+	if mode == "" {
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchObjectLockConfiguration)
+		return
+	}
+
+	writeSuccessResponseXML(w, r, &ObjectLockRetention{
+		Xmlns:           "http://s3.amazonaws.com/doc/2006-03-01/",
+		Mode:            mode,
+		RetainUntilDate: retainUntil.UTC().Format(time.RFC3339),
+	})
+}
+
+// PutObjectRetentionHandler Put object Retention
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectRetention.html
+func (s3a *S3ApiServer) PutObjectRetentionHandler(w http.ResponseWriter, r *http.Request) {
+
+	bucket, object := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("PutObjectRetentionHandler %s %s", bucket, object)
+
+	retention := &ObjectLockRetention{}
+	input, err := io.ReadAll(io.LimitReader(r.Body, r.ContentLength))
+	if err != nil {
+		glog.Errorf("PutObjectRetentionHandler read input %s: %v", r.URL, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+	if err = xml.Unmarshal(input, retention); err != nil {
+		glog.Errorf("PutObjectRetentionHandler Unmarshal %s: %v", r.URL, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+
+	// an empty retention removes the retention
+	var retainUntil time.Time
+	now := time.Now()
+	if retention.Mode != "" || retention.RetainUntilDate != "" {
+		if !isValidObjectLockMode(retention.Mode) {
+			s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+			return
+		}
+		if retainUntil, err = time.Parse(time.RFC3339, retention.RetainUntilDate); err != nil {
+			s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+			return
+		}
+		if !now.Before(retainUntil) {
+			s3err.WriteErrorResponse(w, r, s3err.ErrInvalidRetentionDate)
+			return
+		}
+	}
+
+	bypassGovernance := s3a.canBypassGovernance(r)
+	errCode := s3a.updateObjectLock(bucket, object, bypassGovernance, func(entry *filer_pb.Entry) s3err.ErrorCode {
+		if code := checkRetentionChange(entry, retention, retainUntil, bypassGovernance, now); code != s3err.ErrNone {
+			return code
+		}
+		if retention.Mode == "" {
+			delete(entry.Extended, s3_constants.AmzObjectLockMode)
+			delete(entry.Extended, s3_constants.AmzObjectLockRetainUntilDate)
+		} else {
+			entry.Extended[s3_constants.AmzObjectLockMode] = []byte(retention.Mode)
+			entry.Extended[s3_constants.AmzObjectLockRetainUntilDate] = []byte(retainUntil.UTC().Format(time.RFC3339))
+		}
+		return s3err.ErrNone
+	})
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	writeSuccessResponseEmpty(w, r)
+}
+
+// GetObjectLegalHoldHandler Get object Legal Hold
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectLegalHold.html
+func (s3a *S3ApiServer) GetObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+
+	bucket, object := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("GetObjectLegalHoldHandler %s %s", bucket, object)
+
+	target := util.FullPath(fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, bucket, object))
+	dir, name := target.DirAndName()
+	entry, err := s3a.getEntry(dir, name)
+	if err == filer_pb.ErrNotFound || err == nil && (entry == nil || entry.IsDirectory) {
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchKey)
+		return
+	}
+	if err != nil {
+		glog.Errorf("GetObjectLegalHoldHandler %s: %v", r.URL, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	legalHold := &ObjectLockLegalHold{
+		Xmlns:  "http://s3.amazonaws.com/doc/2006-03-01/",
+		Status: ObjectLockLegalHoldOff,
+	}
+	if filer.IsObjectLegalHoldOn(entry.Extended) {
+		legalHold.Status = ObjectLockLegalHoldOn
+	}
+	writeSuccessResponseXML(w, r, legalHold)
+}
+
+// PutObjectLegalHoldHandler Put object Legal Hold
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectLegalHold.html
+func (s3a *S3ApiServer) PutObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+
+	bucket, object := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("PutObjectLegalHoldHandler %s %s", bucket, object)
+
+	legalHold := &ObjectLockLegalHold{}
+	input, err := io.ReadAll(io.LimitReader(r.Body, r.ContentLength))
+	if err != nil {
+		glog.Errorf("PutObjectLegalHoldHandler read input %s: %v", r.URL, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+	if err = xml.Unmarshal(input, legalHold); err != nil {
+		glog.Errorf("PutObjectLegalHoldHandler Unmarshal %s: %v", r.URL, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+	if legalHold.Status != ObjectLockLegalHoldOn && legalHold.Status != ObjectLockLegalHoldOff {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+
+	errCode := s3a.updateObjectLock(bucket, object, false, func(entry *filer_pb.Entry) s3err.ErrorCode {
+		if legalHold.Status == ObjectLockLegalHoldOn {
+			entry.Extended[s3_constants.AmzObjectLockLegalHold] = []byte(ObjectLockLegalHoldOn)
+		} else {
+			delete(entry.Extended, s3_constants.AmzObjectLockLegalHold)
+		}
+		return s3err.ErrNone
+	})
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	writeSuccessResponseEmpty(w, r)
+}
+
+// PutObjectLockConfigurationHandler Put bucket object lock configuration
+// The object lock can only be enabled when creating the bucket, the configuration changes the default retention.
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectLockConfiguration.html
+func (s3a *S3ApiServer) PutObjectLockConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("PutObjectLockConfigurationHandler %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	config := &ObjectLockConfiguration{}
+	if err := xmlDecoder(r.Body, config, r.ContentLength); err != nil {
+		glog.V(1).Infof("PutObjectLockConfigurationHandler %s: %v", bucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+	if errCode := config.validate(); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	config.Xmlns = ""
+	data, err := xml.Marshal(config)
+	if err != nil {
+		glog.Errorf("PutObjectLockConfigurationHandler %s: %v", bucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	errCode := s3err.ErrNone
+	err = s3a.updateBucketExtended(bucket, func(extended map[string][]byte) error {
+		if !filer.HasObjectLock(extended) {
+			errCode = s3err.ErrInvalidBucketState
+			return nil
+		}
+		extended[s3_constants.X_SeaweedFS_Object_Lock_Config] = data
+		return nil
+	})
+	if err != nil {
+		glog.Errorf("PutObjectLockConfigurationHandler %s: %v", bucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	writeSuccessResponseEmpty(w, r)
+}
+
+// GetObjectLockConfigurationHandler Get bucket object lock configuration
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectLockConfiguration.html
+func (s3a *S3ApiServer) GetObjectLockConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("GetObjectLockConfigurationHandler %s", bucket)
+
+	if err := s3a.checkBucket(r, bucket); err != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, err)
+		return
+	}
+
+	config, errCode := s3a.getObjectLockConfiguration(bucket)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	if config == nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrObjectLockConfigurationNotFound)
+		return
+	}
+	config.Xmlns = "http://s3.amazonaws.com/doc/2006-03-01/"
+	writeSuccessResponseXML(w, r, config)
+}
@@ -0,0 +1,73 @@
+package s3api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+func lockedEntry(mode string, retainUntil time.Time, legalHold bool) *filer_pb.Entry {
+	entry := &filer_pb.Entry{Extended: make(map[string][]byte)}
+	if mode != "" {
+		entry.Extended[s3_constants.AmzObjectLockMode] = []byte(mode)
+		entry.Extended[s3_constants.AmzObjectLockRetainUntilDate] = []byte(retainUntil.UTC().Format(time.RFC3339))
+	}
+	if legalHold {
+		entry.Extended[s3_constants.AmzObjectLockLegalHold] = []byte(ObjectLockLegalHoldOn)
+	}
+	return entry
+}
+
+func TestCheckRetentionChange(t *testing.T) {
+	now := time.Now()
+	later, muchLater := now.Add(time.Hour), now.Add(2*time.Hour)
+
+	cases := []struct {
+		name        string
+		entry       *filer_pb.Entry
+		mode        string
+		retainUntil time.Time
+		bypass      bool
+		expected    s3err.ErrorCode
+	}{
+		{"set", lockedEntry("", time.Time{}, false), ObjectLockModeGovernance, later, false, s3err.ErrNone},
+		{"extend compliance", lockedEntry(ObjectLockModeCompliance, later, false), ObjectLockModeCompliance, muchLater, false, s3err.ErrNone},
+		{"reduce compliance", lockedEntry(ObjectLockModeCompliance, muchLater, false), ObjectLockModeCompliance, later, true, s3err.ErrObjectLockRetentionReduced},
+		{"compliance to governance", lockedEntry(ObjectLockModeCompliance, later, false), ObjectLockModeGovernance, muchLater, true, s3err.ErrObjectLockRetentionReduced},
+		{"remove compliance", lockedEntry(ObjectLockModeCompliance, later, false), "", time.Time{}, true, s3err.ErrObjectLockRetentionReduced},
+		{"governance to compliance", lockedEntry(ObjectLockModeGovernance, later, false), ObjectLockModeCompliance, later, false, s3err.ErrNone},
+		{"reduce governance", lockedEntry(ObjectLockModeGovernance, muchLater, false), ObjectLockModeGovernance, later, false, s3err.ErrObjectLockRetentionReduced},
+		{"reduce bypassed governance", lockedEntry(ObjectLockModeGovernance, muchLater, false), ObjectLockModeGovernance, later, true, s3err.ErrNone},
+		{"remove bypassed governance", lockedEntry(ObjectLockModeGovernance, later, false), "", time.Time{}, true, s3err.ErrNone},
+	}
+	for _, c := range cases {
+		retention := &ObjectLockRetention{Mode: c.mode}
+		if actual := checkRetentionChange(c.entry, retention, c.retainUntil, c.bypass, now); actual != c.expected {
+			t.Errorf("%s: expected %v, actual %v", c.name, c.expected, actual)
+		}
+	}
+}
+
+func TestObjectLockConfigurationValidate(t *testing.T) {
+	cases := []struct {
+		name     string
+		config   *ObjectLockConfiguration
+		expected s3err.ErrorCode
+	}{
+		{"enabled", &ObjectLockConfiguration{ObjectLockEnabled: ObjectLockEnabled}, s3err.ErrNone},
+		{"not enabled", &ObjectLockConfiguration{}, s3err.ErrMalformedXML},
+		{"days", &ObjectLockConfiguration{ObjectLockEnabled: ObjectLockEnabled, Rule: &ObjectLockRule{ObjectLockDefaultRetention{Mode: ObjectLockModeGovernance, Days: 1}}}, s3err.ErrNone},
+		{"years", &ObjectLockConfiguration{ObjectLockEnabled: ObjectLockEnabled, Rule: &ObjectLockRule{ObjectLockDefaultRetention{Mode: ObjectLockModeCompliance, Years: 1}}}, s3err.ErrNone},
+		{"days and years", &ObjectLockConfiguration{ObjectLockEnabled: ObjectLockEnabled, Rule: &ObjectLockRule{ObjectLockDefaultRetention{Mode: ObjectLockModeCompliance, Days: 1, Years: 1}}}, s3err.ErrMalformedXML},
+		{"no period", &ObjectLockConfiguration{ObjectLockEnabled: ObjectLockEnabled, Rule: &ObjectLockRule{ObjectLockDefaultRetention{Mode: ObjectLockModeCompliance}}}, s3err.ErrMalformedXML},
+		{"bad mode", &ObjectLockConfiguration{ObjectLockEnabled: ObjectLockEnabled, Rule: &ObjectLockRule{ObjectLockDefaultRetention{Mode: "FOREVER", Days: 1}}}, s3err.ErrMalformedXML},
+	}
+	for _, c := range cases {
+		if actual := c.config.validate(); actual != c.expected {
+			t.Errorf("%s: expected %v, actual %v", c.name, c.expected, actual)
+		}
+	}
+}
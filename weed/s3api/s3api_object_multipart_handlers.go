@@ -11,9 +11,11 @@ import (
 	"strings"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 	weed_server "github.com/seaweedfs/seaweedfs/weed/server"
+	"github.com/seaweedfs/seaweedfs/weed/util"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -30,12 +32,34 @@ const (
 func (s3a *S3ApiServer) NewMultipartUploadHandler(w http.ResponseWriter, r *http.Request) {
 	bucket, object := s3_constants.GetBucketAndObject(r)
 
+	if errCode := validateUserMetadata(r.Header); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	if _, errCode := s3a.diskTypeOf(r.Header.Get(s3_constants.AmzStorageClass)); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	// the size is only known on completion
+	if errCode := s3a.checkUploadPolicy(bucket, object, r.Header.Get("Content-Type"), -1); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
 	createMultipartUploadInput := &s3.CreateMultipartUploadInput{
 		Bucket:   aws.String(bucket),
 		Key:      objectKey(aws.String(object)),
 		Metadata: make(map[string]*string),
 	}
 
+	// the object lock headers are saved with the upload, and applied on completion
+	if errCode := s3a.checkObjectLockHeaders(r, bucket); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
 	metadata := weed_server.SaveAmzMetaData(r, nil, false)
 	for k, v := range metadata {
 		createMultipartUploadInput.Metadata[k] = aws.String(string(v))
@@ -78,11 +102,28 @@ func (s3a *S3ApiServer) CompleteMultipartUploadHandler(w http.ResponseWriter, r
 		return
 	}
 
+	objectCount := s3a.newObjectCount(bucket, object)
+	if errCode := s3a.checkBucketQuota(r, bucket, 0, objectCount); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	conditions, errCode := getWriteConditions(r)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	if errCode := s3a.checkObjectLock(r, bucket, object); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
 	response, errCode := s3a.completeMultipartUpload(&s3.CompleteMultipartUploadInput{
 		Bucket:   aws.String(bucket),
 		Key:      objectKey(aws.String(object)),
 		UploadId: aws.String(uploadID),
-	}, parts)
+	}, parts, conditions)
 
 	glog.V(2).Info("CompleteMultipartUploadHandler", string(s3err.EncodeXMLResponse(response)), errCode)
 
@@ -90,6 +131,8 @@ func (s3a *S3ApiServer) CompleteMultipartUploadHandler(w http.ResponseWriter, r
 		s3err.WriteErrorResponse(w, r, errCode)
 		return
 	}
+	s3a.quotas.recordWrite(bucket, 0, objectCount)
+	response.Location = aws.String(s3a.objectUrl(r, bucket, object))
 
 	writeSuccessResponseXML(w, r, response)
 
@@ -245,8 +288,27 @@ func (s3a *S3ApiServer) PutObjectPartHandler(w http.ResponseWriter, r *http.Requ
 
 	glog.V(2).Infof("PutObjectPartHandler %s %s %04d", bucket, uploadID, partID)
 
-	uploadUrl := fmt.Sprintf("http://%s%s/%s/%04d.part",
-		s3a.option.Filer.ToHttpAddress(), s3a.genUploadsFolder(bucket), uploadID, partID)
+	partSize := requestContentLength(r)
+	if errCode := s3a.checkBucketQuota(r, bucket, partSize, 0); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	// the part is not written while the upload is completed or aborted
+	unlock := s3a.uploadLocks.RLock(uploadID)
+	defer unlock()
+	uploadEntry, errCode := s3a.getUpload(bucket, uploadID)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	uploadUrl, errCode := s3a.withStorageClass(fmt.Sprintf("%s://%s%s/%s/%04d.part", util.HttpScheme(),
+		s3a.filers.Current().ToHttpAddress(), s3a.genUploadsFolder(bucket), uploadID, partID), storageClassOf(uploadEntry))
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
 
 	if partID == 1 && r.Header.Get("Content-Type") == "" {
 		dataReader = mimeDetect(r, dataReader)
@@ -258,13 +320,28 @@ func (s3a *S3ApiServer) PutObjectPartHandler(w http.ResponseWriter, r *http.Requ
 		s3err.WriteErrorResponse(w, r, errCode)
 		return
 	}
+	s3a.quotas.recordWrite(bucket, partSize, 0)
 
 	setEtag(w, etag)
+	setChecksumHeaders(w, r)
 
 	writeSuccessResponseEmpty(w, r)
 
 }
 
+// getUpload finds the upload not completed or aborted, not to write orphan parts.
+func (s3a *S3ApiServer) getUpload(bucket, uploadID string) (*filer_pb.Entry, s3err.ErrorCode) {
+	entry, err := s3a.getEntry(s3a.genUploadsFolder(bucket), uploadID)
+	if err == filer_pb.ErrNotFound || err == nil && (entry == nil || !entry.IsDirectory) {
+		return nil, s3err.ErrNoSuchUpload
+	}
+	if err != nil {
+		glog.V(1).Infof("bucket %s upload %s: %v", bucket, uploadID, err)
+		return nil, s3err.ErrInternalError
+	}
+	return entry, s3err.ErrNone
+}
+
 func (s3a *S3ApiServer) genUploadsFolder(bucket string) string {
 	return fmt.Sprintf("%s/%s/%s", s3a.option.BucketsPath, bucket, s3_constants.MultipartUploadsFolder)
 }
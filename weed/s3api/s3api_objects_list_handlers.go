@@ -1,6 +1,7 @@
 package s3api
 
 import (
+	"bytes"
 	"context"
 	"encoding/xml"
 	"fmt"
@@ -15,6 +16,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_ui"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 )
 
@@ -125,6 +127,117 @@ func (s3a *S3ApiServer) ListObjectsV1Handler(w http.ResponseWriter, r *http.Requ
 	writeSuccessResponseXML(w, r, response)
 }
 
+// the objects on one page of the html listing, unless set by max-keys
+const htmlListingPageSize = 100
+
+// ListObjectsHtmlHandler lists one folder of the bucket as a html page for the browsers, with the download links of the objects.
+// It takes the prefix, marker and max-keys of ListObjectsV1, and always lists by the "/" delimiter.
+func (s3a *S3ApiServer) ListObjectsHtmlHandler(w http.ResponseWriter, r *http.Request) {
+
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("ListObjectsHtmlHandler %s", bucket)
+
+	query := r.URL.Query()
+	originalPrefix, marker, _, maxKeys := getListObjectsV1Args(query)
+	if query.Get("max-keys") == "" {
+		maxKeys = htmlListingPageSize
+	}
+	if maxKeys <= 0 || maxKeys > maxObjectListSizeLimit {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidMaxKeys)
+		return
+	}
+
+	response, err := s3a.listFilerEntries(bucket, originalPrefix, maxKeys, marker, "/")
+
+	if err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	if len(response.Contents) == 0 {
+		if exists, existErr := s3a.exists(s3a.option.BucketsPath, bucket, true); existErr == nil && !exists {
+			s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchBucket)
+			return
+		}
+	}
+
+	// the bucket is in the host name for the virtual hosted style requests
+	bucketPath := "/" + bucket
+	if r.URL.Path == "/" {
+		bucketPath = ""
+	}
+
+	var page bytes.Buffer
+	if err = s3_ui.BucketListingTpl.Execute(&page, toBucketListing(bucketPath, query.Get("max-keys"), response)); err != nil {
+		glog.Errorf("render listing of bucket %s: %v", bucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+	s3err.WriteResponse(w, r, http.StatusOK, page.Bytes(), s3err.MimeHTML)
+}
+
+func toBucketListing(bucketPath string, maxKeys string, response ListBucketResult) *s3_ui.BucketListing {
+	folderLink := func(prefix, marker string) string {
+		query := url.Values{}
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		if maxKeys != "" {
+			query.Set("max-keys", maxKeys)
+		}
+		if len(query) == 0 {
+			return bucketPath + "/"
+		}
+		return bucketPath + "/?" + query.Encode()
+	}
+
+	listing := &s3_ui.BucketListing{
+		Bucket: response.Name,
+		Prefix: response.Prefix,
+		Breadcrumbs: []s3_ui.Link{
+			{Name: response.Name, Link: folderLink("", "")},
+		},
+	}
+	var folder string
+	for _, name := range strings.SplitAfter(response.Prefix, "/") {
+		if name == "" {
+			continue
+		}
+		folder += name
+		listing.Breadcrumbs = append(listing.Breadcrumbs, s3_ui.Link{
+			Name: strings.TrimSuffix(name, "/"),
+			Link: folderLink(folder, ""),
+		})
+	}
+
+	for _, prefix := range response.CommonPrefixes {
+		listing.Entries = append(listing.Entries, s3_ui.ListingEntry{
+			Name:     strings.TrimPrefix(prefix.Prefix, response.Prefix),
+			Link:     folderLink(prefix.Prefix, ""),
+			IsFolder: true,
+		})
+	}
+	for _, content := range response.Contents {
+		listing.Entries = append(listing.Entries, s3_ui.ListingEntry{
+			Name:         strings.TrimPrefix(content.Key, response.Prefix),
+			Link:         bucketPath + "/" + strings.ReplaceAll(url.PathEscape(content.Key), "%2F", "/"),
+			Size:         uint64(content.Size),
+			LastModified: content.LastModified,
+		})
+	}
+
+	if response.Marker != "" {
+		listing.FirstPageLink = folderLink(response.Prefix, "")
+	}
+	if response.IsTruncated && response.NextMarker != "" {
+		listing.NextPageLink = folderLink(response.Prefix, response.NextMarker)
+	}
+	return listing
+}
+
 func (s3a *S3ApiServer) listFilerEntries(bucket string, originalPrefix string, maxKeys int, originalMarker string, delimiter string) (response ListBucketResult, err error) {
 	// convert full path prefix into directory name and prefix for entry name
 	requestDir, prefix, marker := normalizePrefixMarker(originalPrefix, originalMarker)
@@ -168,10 +281,6 @@ func (s3a *S3ApiServer) listFilerEntries(bucket string, originalPrefix string, m
 					cursor.maxKeys--
 				}
 			} else {
-				storageClass := "STANDARD"
-				if v, ok := entry.Extended[s3_constants.AmzStorageClass]; ok {
-					storageClass = string(v)
-				}
 				contents = append(contents, ListEntry{
 					Key:          fmt.Sprintf("%s/%s", dir, entry.Name)[len(bucketPrefix):],
 					LastModified: time.Unix(entry.Attributes.Mtime, 0).UTC(),
@@ -181,7 +290,7 @@ func (s3a *S3ApiServer) listFilerEntries(bucket string, originalPrefix string, m
 						ID:          fmt.Sprintf("%x", entry.Attributes.Uid),
 						DisplayName: "",
 					},
-					StorageClass: StorageClass(storageClass),
+					StorageClass: StorageClass(storageClassOf(entry)),
 				})
 				cursor.maxKeys--
 			}
@@ -444,7 +553,7 @@ func (s3a *S3ApiServer) ensureDirectoryAllEmpty(filerClient filer_pb.SeaweedFile
 	}
 
 	glog.V(1).Infof("deleting empty folder %s", currentDir)
-	if err = doDeleteEntry(filerClient, parentDir, name, true, true); err != nil {
+	if err = doDeleteEntry(filerClient, parentDir, name, true, true, false); err != nil {
 		return
 	}
 
@@ -1,6 +1,8 @@
 package s3api
 
 import (
+	"bytes"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_ui"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -39,6 +41,48 @@ func TestListObjectsHandler(t *testing.T) {
 	}
 }
 
+func TestToBucketListing(t *testing.T) {
+	response := ListBucketResult{
+		Name:           "photos",
+		Prefix:         "2022/summer/",
+		Marker:         "2022/summer/a.jpg",
+		NextMarker:     "2022/summer/c d.jpg",
+		IsTruncated:    true,
+		CommonPrefixes: []PrefixEntry{{Prefix: "2022/summer/beach/"}},
+		Contents: []ListEntry{
+			{Key: "2022/summer/b.jpg", Size: 2048, LastModified: time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC)},
+			{Key: "2022/summer/c d.jpg", Size: 1},
+		},
+	}
+
+	listing := toBucketListing("/photos", "", response)
+	assert.Equal(t, []s3_ui.Link{
+		{Name: "photos", Link: "/photos/"},
+		{Name: "2022", Link: "/photos/?prefix=2022%2F"},
+		{Name: "summer", Link: "/photos/?prefix=2022%2Fsummer%2F"},
+	}, listing.Breadcrumbs)
+	assert.Equal(t, 3, len(listing.Entries))
+	assert.Equal(t, s3_ui.ListingEntry{Name: "beach/", Link: "/photos/?prefix=2022%2Fsummer%2Fbeach%2F", IsFolder: true}, listing.Entries[0])
+	assert.Equal(t, "b.jpg", listing.Entries[1].Name)
+	assert.Equal(t, "/photos/2022/summer/b.jpg", listing.Entries[1].Link)
+	assert.Equal(t, "/photos/2022/summer/c%20d.jpg", listing.Entries[2].Link)
+	assert.Equal(t, "/photos/?prefix=2022%2Fsummer%2F", listing.FirstPageLink)
+	assert.Equal(t, "/photos/?marker=2022%2Fsummer%2Fc+d.jpg&prefix=2022%2Fsummer%2F", listing.NextPageLink)
+
+	// virtual hosted style, on the last page
+	response.Marker, response.NextMarker, response.IsTruncated = "", "", false
+	listing = toBucketListing("", "10", response)
+	assert.Equal(t, "/?max-keys=10", listing.Breadcrumbs[0].Link)
+	assert.Equal(t, "/2022/summer/b.jpg", listing.Entries[1].Link)
+	assert.Empty(t, listing.FirstPageLink)
+	assert.Empty(t, listing.NextPageLink)
+
+	var page bytes.Buffer
+	assert.Nil(t, s3_ui.BucketListingTpl.Execute(&page, listing))
+	assert.Contains(t, page.String(), `<a href="/2022/summer/c%20d.jpg" download>c d.jpg</a>`)
+	assert.Contains(t, page.String(), "2.00 KiB")
+}
+
 func Test_normalizePrefixMarker(t *testing.T) {
 	type args struct {
 		prefix string
@@ -29,6 +29,19 @@ type Rule struct {
 	Prefix     Prefix     `xml:"Prefix,omitempty"`
 	Expiration Expiration `xml:"Expiration,omitempty"`
 	Transition Transition `xml:"Transition,omitempty"`
+
+	AbortIncompleteMultipartUpload AbortIncompleteMultipartUpload `xml:"AbortIncompleteMultipartUpload,omitempty"`
+}
+
+// prefix is the key prefix the rule applies to, from the filter or the deprecated rule prefix.
+func (r Rule) prefix() string {
+	if r.Filter.And.Prefix.set {
+		return r.Filter.And.Prefix.string
+	}
+	if r.Filter.Prefix.set {
+		return r.Filter.Prefix.string
+	}
+	return r.Prefix.string
 }
 
 // Filter - a filter for a lifecycle configuration Rule.
@@ -43,6 +56,9 @@ type Filter struct {
 
 	Tag    Tag
 	tagSet bool
+
+	ObjectSizeGreaterThan int64 `xml:"ObjectSizeGreaterThan,omitempty"`
+	ObjectSizeLessThan    int64 `xml:"ObjectSizeLessThan,omitempty"`
 }
 
 // Prefix holds the prefix xml tag in <Rule> and <Filter>
@@ -59,6 +75,16 @@ func (p Prefix) MarshalXML(e *xml.Encoder, startElement xml.StartElement) error
 	return e.EncodeElement(p.string, startElement)
 }
 
+// UnmarshalXML decodes Prefix field, and remembers it is set.
+func (p *Prefix) UnmarshalXML(d *xml.Decoder, startElement xml.StartElement) error {
+	var prefix string
+	if err := d.DecodeElement(&prefix, &startElement); err != nil {
+		return err
+	}
+	p.string, p.set = prefix, true
+	return nil
+}
+
 // MarshalXML encodes Filter field into an XML form.
 func (f Filter) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	if err := e.EncodeToken(start); err != nil {
@@ -72,9 +98,11 @@ func (f Filter) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 
 // And - a tag to combine a prefix and multiple tags for lifecycle configuration rule.
 type And struct {
-	XMLName xml.Name `xml:"And"`
-	Prefix  Prefix   `xml:"Prefix,omitempty"`
-	Tags    []Tag    `xml:"Tag,omitempty"`
+	XMLName               xml.Name `xml:"And"`
+	Prefix                Prefix   `xml:"Prefix,omitempty"`
+	Tags                  []Tag    `xml:"Tag,omitempty"`
+	ObjectSizeGreaterThan int64    `xml:"ObjectSizeGreaterThan,omitempty"`
+	ObjectSizeLessThan    int64    `xml:"ObjectSizeLessThan,omitempty"`
 }
 
 // Expiration - expiration actions for a rule in lifecycle configuration.
@@ -144,5 +172,20 @@ func (t Transition) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
 	return enc.EncodeElement(transitionWrapper(t), start)
 }
 
+// AbortIncompleteMultipartUpload - the days after the initiation to abort the multipart uploads not completed.
+type AbortIncompleteMultipartUpload struct {
+	XMLName             xml.Name `xml:"AbortIncompleteMultipartUpload"`
+	DaysAfterInitiation int      `xml:"DaysAfterInitiation,omitempty"`
+}
+
+// MarshalXML encodes AbortIncompleteMultipartUpload field into an XML form.
+func (a AbortIncompleteMultipartUpload) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	if a.DaysAfterInitiation == 0 {
+		return nil
+	}
+	type abortIncompleteMultipartUploadWrapper AbortIncompleteMultipartUpload
+	return enc.EncodeElement(abortIncompleteMultipartUploadWrapper(a), start)
+}
+
 // TransitionDays is a type alias to unmarshal Days in Transition
 type TransitionDays int
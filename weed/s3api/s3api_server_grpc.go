@@ -3,7 +3,9 @@ package s3api
 import (
 	"context"
 
+	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/security"
 )
 
 func (s3a *S3ApiServer) Configure(ctx context.Context, request *rpc.S3ConfigureRequest) (*rpc.S3ConfigureResponse, error) {
@@ -15,3 +17,29 @@ func (s3a *S3ApiServer) Configure(ctx context.Context, request *rpc.S3ConfigureR
 	return &rpc.S3ConfigureResponse{}, nil
 
 }
+
+func (s3a *S3ApiServer) SlowRequests(ctx context.Context, request *rpc.SlowRequestsRequest) (*rpc.SlowRequestsResponse, error) {
+
+	return &rpc.SlowRequestsResponse{
+		Requests: s3a.slowLog.RecentJson(int(request.Limit)),
+	}, nil
+
+}
+
+// SetLogLevel reads the log levels, or changes them with the master admin JWT.
+func (s3a *S3ApiServer) SetLogLevel(ctx context.Context, request *rpc.SetLogLevelRequest) (*rpc.SetLogLevelResponse, error) {
+
+	if request.Levels != "" {
+		if err := security.VerifyMasterAdminGrpc(ctx, s3a.adminSigningKey); err != nil {
+			return nil, err
+		}
+	}
+	levels, err := glog.ChangeLevels(request.Levels)
+	if err != nil {
+		return nil, err
+	}
+	return &rpc.SetLogLevelResponse{
+		Levels: levels,
+	}, nil
+
+}
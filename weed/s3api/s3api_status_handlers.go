@@ -1,11 +1,39 @@
 package s3api
 
 import (
-	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"encoding/json"
 	"net/http"
+	"strconv"
+
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 )
 
 func (s3a *S3ApiServer) StatusHandler(w http.ResponseWriter, r *http.Request) {
 	// write out the response code and content type header
 	s3err.WriteResponse(w, r, http.StatusOK, []byte{}, "")
 }
+
+// RequestTraceHandler looks up a recent request by ?id=<x-amz-request-id>,
+// or lists the recent requests by ?limit=100, optionally only the failed ones by &errors=true
+func (s3a *S3ApiServer) RequestTraceHandler(w http.ResponseWriter, r *http.Request) {
+	var response interface{}
+	if requestId := r.URL.Query().Get("id"); requestId != "" {
+		trace := s3err.RecentRequestTraces.Lookup(requestId)
+		if trace == nil {
+			http.Error(w, "request id "+requestId+" is not found in the recent requests", http.StatusNotFound)
+			return
+		}
+		response = trace
+	} else {
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit <= 0 {
+			limit = 100
+		}
+		onlyErrors, _ := strconv.ParseBool(r.URL.Query().Get("errors"))
+		response = s3err.RecentRequestTraces.Recent(limit, onlyErrors)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(response)
+}
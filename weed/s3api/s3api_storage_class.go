@@ -0,0 +1,63 @@
+package s3api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+const defaultStorageClass = "STANDARD"
+
+// parseStorageClasses parses the storage classes and their disk types, e.g. STANDARD=ssd,STANDARD_IA=hdd
+func parseStorageClasses(spec string) (diskTypes map[string]string, err error) {
+	diskTypes = make(map[string]string)
+	for _, pair := range strings.Split(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		storageClass, diskType, found := strings.Cut(pair, "=")
+		storageClass = strings.ToUpper(strings.TrimSpace(storageClass))
+		if !found || storageClass == "" {
+			return nil, fmt.Errorf("storage class %s should be <storage class>=<disk type>", pair)
+		}
+		diskTypes[storageClass] = strings.TrimSpace(diskType)
+	}
+	return diskTypes, nil
+}
+
+// diskTypeOf finds the disk type of the storage class.
+// Without configured storage classes, any storage class is accepted and written to the default disk type.
+func (s3a *S3ApiServer) diskTypeOf(storageClass string) (diskType string, errCode s3err.ErrorCode) {
+	if len(s3a.storageClassDiskTypes) == 0 {
+		return "", s3err.ErrNone
+	}
+	if storageClass == "" {
+		storageClass = defaultStorageClass
+	}
+	diskType, found := s3a.storageClassDiskTypes[storageClass]
+	if !found {
+		return "", s3err.ErrInvalidStorageClass
+	}
+	return diskType, s3err.ErrNone
+}
+
+// withStorageClass writes the upload to the disk type of the storage class.
+func (s3a *S3ApiServer) withStorageClass(uploadUrl string, storageClass string) (string, s3err.ErrorCode) {
+	diskType, errCode := s3a.diskTypeOf(storageClass)
+	if errCode != s3err.ErrNone || diskType == "" {
+		return uploadUrl, errCode
+	}
+	return uploadUrl + "?disk=" + url.QueryEscape(diskType), s3err.ErrNone
+}
+
+// storageClassOf returns the storage class saved in the entry, or STANDARD.
+func storageClassOf(entry *filer_pb.Entry) string {
+	if v, ok := entry.Extended[s3_constants.AmzStorageClass]; ok && len(v) > 0 {
+		return string(v)
+	}
+	return defaultStorageClass
+}
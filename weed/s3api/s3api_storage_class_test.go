@@ -0,0 +1,46 @@
+package s3api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+func TestStorageClassDiskTypes(t *testing.T) {
+	diskTypes, err := parseStorageClasses("standard=ssd, STANDARD_IA=hdd,GLACIER=")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"STANDARD": "ssd", "STANDARD_IA": "hdd", "GLACIER": ""}, diskTypes)
+
+	_, err = parseStorageClasses("STANDARD")
+	assert.NotNil(t, err)
+
+	s3a := &S3ApiServer{storageClassDiskTypes: diskTypes}
+	uploadUrl, errCode := s3a.withStorageClass("http://filer/buckets/b/o", "")
+	assert.Equal(t, s3err.ErrNone, errCode)
+	assert.Equal(t, "http://filer/buckets/b/o?disk=ssd", uploadUrl)
+	uploadUrl, errCode = s3a.withStorageClass("http://filer/buckets/b/o", "STANDARD_IA")
+	assert.Equal(t, s3err.ErrNone, errCode)
+	assert.Equal(t, "http://filer/buckets/b/o?disk=hdd", uploadUrl)
+	uploadUrl, errCode = s3a.withStorageClass("http://filer/buckets/b/o", "GLACIER")
+	assert.Equal(t, s3err.ErrNone, errCode)
+	assert.Equal(t, "http://filer/buckets/b/o", uploadUrl)
+	_, errCode = s3a.withStorageClass("http://filer/buckets/b/o", "ONEZONE_IA")
+	assert.Equal(t, s3err.ErrInvalidStorageClass, errCode)
+
+	// any storage class without the configuration
+	s3a = &S3ApiServer{}
+	uploadUrl, errCode = s3a.withStorageClass("http://filer/buckets/b/o", "ONEZONE_IA")
+	assert.Equal(t, s3err.ErrNone, errCode)
+	assert.Equal(t, "http://filer/buckets/b/o", uploadUrl)
+}
+
+func TestStorageClassOf(t *testing.T) {
+	assert.Equal(t, "STANDARD", storageClassOf(&filer_pb.Entry{}))
+	assert.Equal(t, "STANDARD_IA", storageClassOf(&filer_pb.Entry{Extended: map[string][]byte{
+		s3_constants.AmzStorageClass: []byte("STANDARD_IA"),
+	}}))
+}
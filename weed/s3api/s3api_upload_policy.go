@@ -0,0 +1,71 @@
+package s3api
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// loadFilerConf reads the filer configuration, to check the upload policies of the locations
+// before sending the content to the filer, which checks them again.
+func (s3a *S3ApiServer) loadFilerConf() error {
+	fc, err := filer.ReadFilerConf(s3a.filers.Current(), s3a.option.GrpcDialOption, nil)
+	if err != nil {
+		return err
+	}
+	s3a.filerConfLock.Lock()
+	s3a.filerConf = fc
+	s3a.filerConfLock.Unlock()
+	return nil
+}
+
+// reload filer config
+func (s3a *S3ApiServer) onFilerConfUpdate(dir, filename string) error {
+	if dir == filer.DirectoryEtcSeaweedFS && filename == filer.FilerConfName {
+		if err := s3a.loadFilerConf(); err != nil {
+			return err
+		}
+		glog.V(0).Infof("updated %s/%s", dir, filename)
+	}
+	return nil
+}
+
+// checkUploadPolicy checks the object against the upload policy of its location, the size is not checked if not positive.
+func (s3a *S3ApiServer) checkUploadPolicy(bucket, object, mimeType string, size int64) s3err.ErrorCode {
+	s3a.filerConfLock.RLock()
+	fc := s3a.filerConf
+	s3a.filerConfLock.RUnlock()
+	if fc == nil {
+		return s3err.ErrNone
+	}
+	fullPath := util.FullPath(fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, bucket, object))
+	p, err := fc.UploadPolicyOf(string(fullPath))
+	if err != nil {
+		glog.Errorf("upload policy of %s: %v", fullPath, err)
+		return s3err.ErrNone
+	}
+	if size <= 0 {
+		size = -1
+	}
+	if err = p.Check(fullPath.Name(), mimeType, size); err != nil {
+		glog.V(1).Infof("upload %s: %v", fullPath, err)
+		return uploadPolicyErrorCode(err)
+	}
+	return s3err.ErrNone
+}
+
+func uploadPolicyErrorCode(err error) s3err.ErrorCode {
+	switch {
+	case errors.Is(err, filer.ErrFileTooLarge):
+		return s3err.ErrEntityTooLarge
+	case errors.Is(err, filer.ErrMimeTypeNotAllowed):
+		return s3err.ErrContentTypeNotAllowed
+	case errors.Is(err, filer.ErrFileNameNotAllowed):
+		return s3err.ErrObjectNameNotAllowed
+	}
+	return s3err.ErrInternalError
+}
@@ -0,0 +1,68 @@
+package s3api
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// parseDomainNames splits the comma separated -domainName into the suffixes of the virtual-hosted-style hosts,
+// the longest first, so that b.s3.example.com is bucket b of s3.example.com rather than bucket b.s3 of example.com.
+func parseDomainNames(domainName string) (domainNames []string) {
+	for _, name := range strings.Split(domainName, ",") {
+		name = strings.Trim(strings.TrimSpace(name), ".")
+		if name != "" {
+			domainNames = append(domainNames, strings.ToLower(name))
+		}
+	}
+	sort.SliceStable(domainNames, func(i, j int) bool {
+		return len(domainNames[i]) > len(domainNames[j])
+	})
+	return
+}
+
+// bucketFromHost returns the bucket and the domain name of the virtual-hosted-style host {bucket}.{domainName},
+// with or without a port.
+func bucketFromHost(host string, domainNames []string) (bucket, domainName string, found bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	for _, domainName = range domainNames {
+		if bucket = strings.TrimSuffix(host, "."+domainName); bucket != host && bucket != "" {
+			return bucket, domainName, true
+		}
+	}
+	return "", "", false
+}
+
+// objectUrl returns the url of the object as the client addressed the gateway, http(s)://{bucket}.{domainName}/{object}
+// for the virtual-hosted-style requests, or http(s)://{host}/{bucket}/{object} otherwise.
+// Over https the buckets with dots fall back to the path style, since a wildcard certificate *.{domainName} does not cover them.
+func (s3a *S3ApiServer) objectUrl(r *http.Request, bucket, object string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	host := r.Host
+	if forwardedHost := r.Header.Get("X-Forwarded-Host"); forwardedHost != "" {
+		host = forwardedHost
+	}
+	object = urlPathEscape(strings.TrimPrefix(object, "/"))
+
+	hostBucket, domainName, isVirtualHost := bucketFromHost(host, s3a.domainNames)
+	if !isVirtualHost || hostBucket != bucket {
+		return scheme + "://" + host + "/" + bucket + "/" + object
+	}
+	if scheme == "https" && strings.Contains(bucket, ".") {
+		if _, port, err := net.SplitHostPort(host); err == nil {
+			domainName = net.JoinHostPort(domainName, port)
+		}
+		return scheme + "://" + domainName + "/" + bucket + "/" + object
+	}
+	return scheme + "://" + host + "/" + object
+}
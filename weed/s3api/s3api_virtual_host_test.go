@@ -0,0 +1,87 @@
+package s3api
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseDomainNames(t *testing.T) {
+	domainNames := parseDomainNames(" example.com, s3.example.com ,,.S3.other.io.")
+	expected := []string{"s3.example.com", "example.com", "s3.other.io"}
+	if !reflect.DeepEqual(domainNames, expected) {
+		t.Errorf("expected %v, actual %v", expected, domainNames)
+	}
+	if domainNames = parseDomainNames(""); len(domainNames) != 0 {
+		t.Errorf("expected no domain names, actual %v", domainNames)
+	}
+}
+
+func TestBucketFromHost(t *testing.T) {
+	domainNames := parseDomainNames("example.com,s3.example.com")
+	cases := []struct {
+		host       string
+		bucket     string
+		domainName string
+		found      bool
+	}{
+		{"b.s3.example.com", "b", "s3.example.com", true},
+		{"b.s3.example.com:8333", "b", "s3.example.com", true},
+		{"my.bucket.s3.example.com", "my.bucket", "s3.example.com", true},
+		{"b.example.com", "b", "example.com", true},
+		{"B.Example.com:443", "b", "example.com", true},
+		{"s3.example.com", "s3", "example.com", true},
+		{"example.com", "", "", false},
+		{"b.other.com", "", "", false},
+		{"localhost:8333", "", "", false},
+	}
+	for _, c := range cases {
+		bucket, domainName, found := bucketFromHost(c.host, domainNames)
+		if bucket != c.bucket || domainName != c.domainName || found != c.found {
+			t.Errorf("%s: expected %s %s %v, actual %s %s %v", c.host, c.bucket, c.domainName, c.found, bucket, domainName, found)
+		}
+	}
+	if got := getResource("/dir/key", "b.s3.example.com:8333", domainNames); got != "/b/dir/key" {
+		t.Errorf("unexpected resource %s", got)
+	}
+	if got := getResource("/b/dir/key", "localhost:8333", domainNames); got != "/b/dir/key" {
+		t.Errorf("unexpected resource %s", got)
+	}
+}
+
+func TestObjectUrl(t *testing.T) {
+	s3a := &S3ApiServer{domainNames: parseDomainNames("s3.example.com")}
+	cases := []struct {
+		host     string
+		https    bool
+		bucket   string
+		object   string
+		expected string
+	}{
+		{"localhost:8333", false, "b", "/dir/a b.txt", "http://localhost:8333/b/dir/a%20b.txt"},
+		{"b.s3.example.com:8333", false, "b", "/dir/a.txt", "http://b.s3.example.com:8333/dir/a.txt"},
+		{"b.s3.example.com", true, "b", "/a.txt", "https://b.s3.example.com/a.txt"},
+		{"my.bucket.s3.example.com", false, "my.bucket", "/a.txt", "http://my.bucket.s3.example.com/a.txt"},
+		{"my.bucket.s3.example.com:8443", true, "my.bucket", "/a.txt", "https://s3.example.com:8443/my.bucket/a.txt"},
+		{"b.s3.example.com", false, "b", "", "http://b.s3.example.com/"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("PUT", "/", nil)
+		r.Host = c.host
+		if c.https {
+			r.TLS = &tls.ConnectionState{}
+		}
+		if actual := s3a.objectUrl(r, c.bucket, c.object); actual != c.expected {
+			t.Errorf("%s %s%s: expected %s, actual %s", c.host, c.bucket, c.object, c.expected, actual)
+		}
+	}
+
+	r := httptest.NewRequest("PUT", "/", nil)
+	r.Host = "internal:8333"
+	r.Header.Set("X-Forwarded-Proto", "https")
+	r.Header.Set("X-Forwarded-Host", "b.s3.example.com")
+	if actual := s3a.objectUrl(r, "b", "/a.txt"); actual != "https://b.s3.example.com/a.txt" {
+		t.Errorf("forwarded: unexpected %s", actual)
+	}
+}
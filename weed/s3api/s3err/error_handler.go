@@ -3,11 +3,9 @@ package s3err
 import (
 	"bytes"
 	"encoding/xml"
-	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
@@ -18,6 +16,7 @@ type mimeType string
 const (
 	mimeNone mimeType = ""
 	MimeXML  mimeType = "application/xml"
+	MimeHTML mimeType = "text/html; charset=utf-8"
 )
 
 func WriteXMLResponse(w http.ResponseWriter, r *http.Request, statusCode int, response interface{}) {
@@ -37,20 +36,37 @@ func WriteErrorResponse(w http.ResponseWriter, r *http.Request, errorCode ErrorC
 	}
 
 	apiError := GetAPIError(errorCode)
-	errorResponse := getRESTErrorResponse(apiError, r.URL.Path, bucket, object)
+	if trace := GetRequestTrace(r); trace != nil {
+		trace.setErrorCode(apiError.Code)
+	}
+	requestId, hostId := ensureRequestId(w)
+	errorResponse := getRESTErrorResponse(apiError, r.URL.Path, bucket, object, requestId, hostId)
 	encodedErrorResponse := EncodeXMLResponse(errorResponse)
 	WriteResponse(w, r, apiError.HTTPStatusCode, encodedErrorResponse, MimeXML)
 }
 
-func getRESTErrorResponse(err APIError, resource string, bucket, object string) RESTErrorResponse {
+func getRESTErrorResponse(err APIError, resource string, bucket, object string, requestId, hostId string) RESTErrorResponse {
 	return RESTErrorResponse{
 		Code:       err.Code,
 		BucketName: bucket,
 		Key:        object,
 		Message:    err.Description,
 		Resource:   resource,
-		RequestID:  fmt.Sprintf("%d", time.Now().UnixNano()),
+		RequestID:  requestId,
+		HostID:     hostId,
+	}
+}
+
+// ensureRequestId uses the request id set when the request started, or sets a new one.
+func ensureRequestId(w http.ResponseWriter) (requestId, hostId string) {
+	requestId, hostId = w.Header().Get(RequestIdHeader), w.Header().Get(HostIdHeader)
+	if requestId == "" {
+		requestId = NewRequestId()
+		hostId = newHostId(requestId)
+		w.Header().Set(RequestIdHeader, requestId)
+		w.Header().Set(HostIdHeader, hostId)
 	}
+	return
 }
 
 // Encodes the response headers into XML format.
@@ -63,7 +79,7 @@ func EncodeXMLResponse(response interface{}) []byte {
 }
 
 func setCommonHeaders(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("x-amz-request-id", fmt.Sprintf("%d", time.Now().UnixNano()))
+	ensureRequestId(w)
 	w.Header().Set("Accept-Ranges", "bytes")
 	if r.Header.Get("Origin") != "" {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -0,0 +1,190 @@
+package s3err
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	RequestIdHeader = "x-amz-request-id"
+	HostIdHeader    = "x-amz-id-2"
+
+	// RecentRequestTraceCount is the number of recent requests kept to look up by request id
+	RecentRequestTraceCount = 10000
+)
+
+var hostName, _ = os.Hostname()
+
+type requestTraceContextKey struct{}
+
+// RequestTrace is what happened to one request, to look it up by the request id an SDK reports.
+type RequestTrace struct {
+	RequestId  string    `json:"requestId"`
+	HostId     string    `json:"hostId"`
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	Uri        string    `json:"uri"`
+	Status     int       `json:"status"`
+	Duration   string    `json:"duration,omitempty"`
+	ErrorCode  string    `json:"errorCode,omitempty"`
+	Errors     []string  `json:"errors,omitempty"`
+	Identity   string    `json:"identity,omitempty"`
+	AuthDenied bool      `json:"authDenied,omitempty"`
+	lock       sync.Mutex
+}
+
+// NewRequestId generates an id in the AWS format, 16 upper case hex characters.
+func NewRequestId() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return strings.ToUpper(hex.EncodeToString(b))
+}
+
+// newHostId identifies this server and the request, like the x-amz-id-2 of AWS.
+func newHostId(requestId string) string {
+	h := sha256.Sum256([]byte(hostName + "/" + requestId))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// StartRequestTrace sets the request id headers, and returns the request carrying its trace.
+func StartRequestTrace(w http.ResponseWriter, r *http.Request) (*http.Request, *RequestTrace) {
+	trace := &RequestTrace{
+		RequestId: NewRequestId(),
+		Time:      time.Now(),
+		Method:    r.Method,
+		Uri:       r.RequestURI,
+	}
+	trace.HostId = newHostId(trace.RequestId)
+	w.Header().Set(RequestIdHeader, trace.RequestId)
+	w.Header().Set(HostIdHeader, trace.HostId)
+	return r.WithContext(context.WithValue(r.Context(), requestTraceContextKey{}, trace)), trace
+}
+
+// GetRequestTrace returns the trace of the request, or nil if it is not traced.
+func GetRequestTrace(r *http.Request) *RequestTrace {
+	trace, _ := r.Context().Value(requestTraceContextKey{}).(*RequestTrace)
+	return trace
+}
+
+// RecordInternalError keeps the error and the errors it wraps with the request trace.
+func RecordInternalError(r *http.Request, err error) {
+	trace := GetRequestTrace(r)
+	if trace == nil || err == nil {
+		return
+	}
+	trace.lock.Lock()
+	defer trace.lock.Unlock()
+	for ; err != nil; err = errors.Unwrap(err) {
+		trace.Errors = append(trace.Errors, err.Error())
+	}
+}
+
+// RecordAuthResult keeps who sent the request, or that the request failed the authentication.
+func RecordAuthResult(r *http.Request, identity string, denied bool) {
+	trace := GetRequestTrace(r)
+	if trace == nil {
+		return
+	}
+	trace.lock.Lock()
+	defer trace.lock.Unlock()
+	trace.Identity = identity
+	trace.AuthDenied = denied
+}
+
+func (trace *RequestTrace) setErrorCode(code string) {
+	trace.lock.Lock()
+	defer trace.lock.Unlock()
+	trace.ErrorCode = code
+}
+
+// Finish records the result of the request, and keeps the trace with the recent ones.
+func (trace *RequestTrace) Finish(status int, duration time.Duration) {
+	trace.lock.Lock()
+	trace.Status = status
+	trace.Duration = duration.String()
+	trace.lock.Unlock()
+	RecentRequestTraces.add(trace)
+}
+
+// Snapshot copies the trace, to read it while the request may still record errors.
+func (trace *RequestTrace) Snapshot() *RequestTrace {
+	trace.lock.Lock()
+	defer trace.lock.Unlock()
+	return &RequestTrace{
+		RequestId:  trace.RequestId,
+		HostId:     trace.HostId,
+		Time:       trace.Time,
+		Method:     trace.Method,
+		Uri:        trace.Uri,
+		Status:     trace.Status,
+		Duration:   trace.Duration,
+		ErrorCode:  trace.ErrorCode,
+		Errors:     append([]string(nil), trace.Errors...),
+		Identity:   trace.Identity,
+		AuthDenied: trace.AuthDenied,
+	}
+}
+
+// RequestTraces is a ring of the most recent request traces.
+type RequestTraces struct {
+	traces []*RequestTrace
+	next   int
+	byId   map[string]*RequestTrace
+	sync.RWMutex
+}
+
+var RecentRequestTraces = NewRequestTraces(RecentRequestTraceCount)
+
+func NewRequestTraces(capacity int) *RequestTraces {
+	return &RequestTraces{
+		traces: make([]*RequestTrace, capacity),
+		byId:   make(map[string]*RequestTrace),
+	}
+}
+
+func (rt *RequestTraces) add(trace *RequestTrace) {
+	rt.Lock()
+	defer rt.Unlock()
+	if evicted := rt.traces[rt.next]; evicted != nil {
+		delete(rt.byId, evicted.RequestId)
+	}
+	rt.traces[rt.next] = trace
+	rt.byId[trace.RequestId] = trace
+	rt.next = (rt.next + 1) % len(rt.traces)
+}
+
+func (rt *RequestTraces) Lookup(requestId string) *RequestTrace {
+	rt.RLock()
+	defer rt.RUnlock()
+	if trace, found := rt.byId[requestId]; found {
+		return trace.Snapshot()
+	}
+	return nil
+}
+
+// Recent returns up to limit traces, the newest first, optionally only the failed ones.
+func (rt *RequestTraces) Recent(limit int, onlyErrors bool) (traces []*RequestTrace) {
+	rt.RLock()
+	defer rt.RUnlock()
+	for i := 1; i <= len(rt.traces) && len(traces) < limit; i++ {
+		trace := rt.traces[(rt.next-i+len(rt.traces))%len(rt.traces)]
+		if trace == nil {
+			break
+		}
+		trace = trace.Snapshot()
+		if onlyErrors && trace.Status < http.StatusBadRequest && len(trace.Errors) == 0 {
+			continue
+		}
+		traces = append(traces, trace)
+	}
+	return
+}
@@ -0,0 +1,55 @@
+package s3err
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestIdInErrorResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, trace := StartRequestTrace(w, httptest.NewRequest("GET", "/bucket/key", nil))
+	if len(trace.RequestId) != 16 || trace.HostId == "" {
+		t.Fatalf("unexpected request id %q host id %q", trace.RequestId, trace.HostId)
+	}
+
+	RecordInternalError(r, fmt.Errorf("upload to filer: %w", fmt.Errorf("connection refused")))
+	WriteErrorResponse(w, r, ErrInternalError)
+
+	if got := w.Header().Get(RequestIdHeader); got != trace.RequestId {
+		t.Errorf("request id header %q, want %q", got, trace.RequestId)
+	}
+	var errorResponse RESTErrorResponse
+	if err := xml.Unmarshal(w.Body.Bytes(), &errorResponse); err != nil {
+		t.Fatalf("unmarshal error response: %v", err)
+	}
+	if errorResponse.RequestID != trace.RequestId || errorResponse.HostID != trace.HostId {
+		t.Errorf("error response has request id %q host id %q", errorResponse.RequestID, errorResponse.HostID)
+	}
+	if trace.ErrorCode != "InternalError" || len(trace.Errors) != 2 || trace.Errors[1] != "connection refused" {
+		t.Errorf("unexpected trace %+v", trace)
+	}
+}
+
+func TestRequestTraces(t *testing.T) {
+	traces := NewRequestTraces(2)
+	for i, status := range []int{http.StatusOK, http.StatusNotFound, http.StatusOK} {
+		traces.add(&RequestTrace{RequestId: fmt.Sprintf("id%d", i), Status: status, Duration: time.Second.String()})
+	}
+
+	if traces.Lookup("id0") != nil {
+		t.Errorf("the oldest trace should be evicted")
+	}
+	if trace := traces.Lookup("id1"); trace == nil || trace.Status != http.StatusNotFound {
+		t.Errorf("unexpected trace %+v", trace)
+	}
+	if recent := traces.Recent(10, false); len(recent) != 2 || recent[0].RequestId != "id2" {
+		t.Errorf("unexpected recent traces %+v", recent)
+	}
+	if recent := traces.Recent(10, true); len(recent) != 1 || recent[0].RequestId != "id1" {
+		t.Errorf("unexpected recent failed traces %+v", recent)
+	}
+}
@@ -20,6 +20,7 @@ type RESTErrorResponse struct {
 	Message    string   `xml:"Message" json:"Message"`
 	Resource   string   `xml:"Resource" json:"Resource"`
 	RequestID  string   `xml:"RequestId" json:"RequestId"`
+	HostID     string   `xml:"HostId,omitempty" json:"HostId,omitempty"`
 	Key        string   `xml:"Key,omitempty" json:"Key,omitempty"`
 	BucketName string   `xml:"BucketName,omitempty" json:"BucketName,omitempty"`
 
@@ -54,21 +55,29 @@ const (
 	ErrNoSuchBucketPolicy
 	ErrNoSuchCORSConfiguration
 	ErrNoSuchLifecycleConfiguration
+	ErrNoSuchConfiguration
 	ErrNoSuchKey
 	ErrNoSuchUpload
 	ErrInvalidBucketName
 	ErrInvalidDigest
+	ErrBadDigest
 	ErrInvalidMaxKeys
 	ErrInvalidMaxUploads
 	ErrInvalidMaxParts
 	ErrInvalidMaxDeleteObjects
 	ErrInvalidPartNumberMarker
 	ErrInvalidPart
+	ErrInvalidPartOrder
+	ErrInvalidStorageClass
 	ErrInvalidRange
 	ErrInternalError
 	ErrInvalidCopyDest
 	ErrInvalidCopySource
 	ErrInvalidTag
+	ErrInvalidMetadataDirective
+	ErrMetadataTooLarge
+	ErrInvalidInventoryConfiguration
+	ErrUnsupportedInventoryFormat
 	ErrAuthHeaderEmpty
 	ErrSignatureVersionNotSupported
 	ErrMalformedPOSTRequest
@@ -107,6 +116,20 @@ const (
 
 	ErrTooManyRequest
 	ErrRequestBytesExceed
+	ErrQuotaExceeded
+
+	ErrObjectLocked
+	ErrInvalidRetentionDate
+	ErrObjectLockRetentionReduced
+	ErrNoSuchObjectLockConfiguration
+	ErrObjectLockConfigurationNotFound
+	ErrMissingObjectLockConfiguration
+	ErrInvalidBucketState
+	ErrInvalidObjectLockHeaders
+	ErrContentTypeNotAllowed
+	ErrObjectNameNotAllowed
+	ErrNoSuchVersion
+	ErrUserKeyMustBeSpecified
 )
 
 // error code to APIError structure, these fields carry respective
@@ -147,6 +170,11 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "The Content-Md5 you specified is not valid.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrBadDigest: {
+		Code:           "BadDigest",
+		Description:    "The Content-MD5 or checksum value you specified did not match what we received.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrInvalidMaxUploads: {
 		Code:           "InvalidArgument",
 		Description:    "Argument max-uploads must be an integer between 0 and 2147483647",
@@ -192,6 +220,11 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "The lifecycle configuration does not exist",
 		HTTPStatusCode: http.StatusNotFound,
 	},
+	ErrNoSuchConfiguration: {
+		Code:           "NoSuchConfiguration",
+		Description:    "The specified configuration does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
 	ErrNoSuchKey: {
 		Code:           "NoSuchKey",
 		Description:    "The specified key does not exist.",
@@ -213,6 +246,16 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "One or more of the specified parts could not be found.  The part may not have been uploaded, or the specified entity tag may not match the part's entity tag.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidPartOrder: {
+		Code:           "InvalidPartOrder",
+		Description:    "The list of parts was not in ascending order. The parts list must be specified in order by part number.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidStorageClass: {
+		Code:           "InvalidStorageClass",
+		Description:    "The storage class you specified is not valid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 
 	ErrInvalidCopyDest: {
 		Code:           "InvalidRequest",
@@ -229,6 +272,26 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "The Tag value you have provided is invalid",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidMetadataDirective: {
+		Code:           "InvalidArgument",
+		Description:    "Unknown metadata directive.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrMetadataTooLarge: {
+		Code:           "MetadataTooLarge",
+		Description:    "Your metadata headers exceed the maximum allowed metadata size.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidInventoryConfiguration: {
+		Code:           "InvalidArgument",
+		Description:    "The inventory configuration is invalid, only CSV reports of the current object versions are supported.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrUnsupportedInventoryFormat: {
+		Code:           "InvalidArgument",
+		Description:    "The inventory format is not supported, only the CSV format is supported.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrMalformedXML: {
 		Code:           "MalformedXML",
 		Description:    "The XML you provided was not well-formed or did not validate against our published schema.",
@@ -404,6 +467,71 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "Existing Object is a file.",
 		HTTPStatusCode: http.StatusConflict,
 	},
+	ErrQuotaExceeded: {
+		Code:           "QuotaExceeded",
+		Description:    "The bucket quota is exceeded.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrObjectLocked: {
+		Code:           "AccessDenied",
+		Description:    "Access Denied because object protected by object lock.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrInvalidRetentionDate: {
+		Code:           "InvalidArgument",
+		Description:    "The retain until date must be in the future.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrObjectLockRetentionReduced: {
+		Code:           "AccessDenied",
+		Description:    "The retention can not be reduced or removed in compliance mode, or in governance mode without bypassing the governance retention.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrNoSuchObjectLockConfiguration: {
+		Code:           "NoSuchObjectLockConfiguration",
+		Description:    "The specified object does not have a ObjectLock configuration",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrObjectLockConfigurationNotFound: {
+		Code:           "ObjectLockConfigurationNotFoundError",
+		Description:    "Object Lock configuration does not exist for this bucket",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrMissingObjectLockConfiguration: {
+		Code:           "InvalidRequest",
+		Description:    "Bucket is missing Object Lock Configuration",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidBucketState: {
+		Code:           "InvalidBucketState",
+		Description:    "Object Lock configuration cannot be enabled on existing buckets",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrInvalidObjectLockHeaders: {
+		Code:           "InvalidArgument",
+		Description:    "x-amz-object-lock-retain-until-date and x-amz-object-lock-mode must both be supplied, with a valid mode and a future date",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrContentTypeNotAllowed: {
+		Code:           "InvalidArgument",
+		Description:    "The content type of the object is not allowed in this location.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrObjectNameNotAllowed: {
+		Code:           "InvalidArgument",
+		Description:    "The object name is not allowed in this location.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchVersion: {
+		Code:           "NoSuchVersion",
+		Description:    "The specified version does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrUserKeyMustBeSpecified: {
+		Code:           "UserKeyMustBeSpecified",
+		Description:    "The delete request must specify a key.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrTooManyRequest: {
 		Code:           "ErrTooManyRequest",
 		Description:    "Too many simultaneous request count",
@@ -1,7 +1,9 @@
 package s3api
 
 import (
+	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 	stats_collect "github.com/seaweedfs/seaweedfs/weed/stats"
 	"net/http"
 	"strconv"
@@ -26,14 +28,28 @@ func (r *StatusRecorder) Flush() {
 	r.ResponseWriter.(http.Flusher).Flush()
 }
 
-func track(f http.HandlerFunc, action string) http.HandlerFunc {
+func (s3a *S3ApiServer) track(f http.HandlerFunc, action string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		bucket, _ := s3_constants.GetBucketAndObject(r)
 		w.Header().Set("Server", "SeaweedFS S3")
 		recorder := NewStatusResponseWriter(w)
 		start := time.Now()
+		r, trace := s3err.StartRequestTrace(recorder, r)
 		f(recorder, r)
-		stats_collect.S3RequestHistogram.WithLabelValues(action, bucket).Observe(time.Since(start).Seconds())
+		duration := time.Since(start)
+		trace.Finish(recorder.Status, duration)
+		s3a.auditRequest(r, action, recorder.Status, duration, trace)
+		glog.V(2).Infof("s3 access %s %s %s %d %v request id %s", r.RemoteAddr, r.Method, r.RequestURI, recorder.Status, duration, trace.RequestId)
+		stats_collect.S3RequestHistogram.WithLabelValues(action, bucket).Observe(duration.Seconds())
 		stats_collect.S3RequestCounter.WithLabelValues(action, strconv.Itoa(recorder.Status), bucket).Inc()
+		slowRequest := &stats_collect.SlowRequest{
+			Path:   r.URL.Path,
+			Peer:   r.RemoteAddr,
+			Status: recorder.Status,
+		}
+		if r.ContentLength > 0 {
+			slowRequest.Size = r.ContentLength
+		}
+		s3a.slowLog.Record(action, duration, slowRequest)
 	}
 }
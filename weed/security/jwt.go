@@ -1,22 +1,39 @@
 package security
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"google.golang.org/grpc/metadata"
+
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 )
 
 type EncodedJwt string
 type SigningKey []byte
 
+// Fingerprint identifies the key without revealing it, to compare the keys of different servers.
+func (k SigningKey) Fingerprint() string {
+	if len(k) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(k)
+	return hex.EncodeToString(sum[:8])
+}
+
 // SeaweedFileIdClaims is created by Master server(s) and consumed by Volume server(s),
 // restricting the access this JWT allows to only a single file.
 type SeaweedFileIdClaims struct {
 	Fid string `json:"fid"`
+	// the upload is limited to the collection, e.g. the bucket of the S3 gateway, and to the max size, if not empty
+	Collection string `json:"collection,omitempty"`
+	MaxSize    int64  `json:"maxSize,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -27,14 +44,26 @@ type SeaweedFilerClaims struct {
 	jwt.StandardClaims
 }
 
+// SeaweedMasterAdminClaims is created by the operator and consumed by Master server(s),
+// allowing the volume actions of the master UI, e.g. vacuum or mark a volume readonly.
+type SeaweedMasterAdminClaims struct {
+	jwt.StandardClaims
+}
+
 func GenJwtForVolumeServer(signingKey SigningKey, expiresAfterSec int, fileId string) EncodedJwt {
+	return GenJwtForVolumeServerUpload(signingKey, expiresAfterSec, fileId, "", 0)
+}
+
+// GenJwtForVolumeServerUpload creates a JSON-web-token to upload the file, only into the collection and up to maxSize bytes
+func GenJwtForVolumeServerUpload(signingKey SigningKey, expiresAfterSec int, fileId string, collection string, maxSize int64) EncodedJwt {
 	if len(signingKey) == 0 {
 		return ""
 	}
 
 	claims := SeaweedFileIdClaims{
-		fileId,
-		jwt.StandardClaims{},
+		Fid:        fileId,
+		Collection: collection,
+		MaxSize:    maxSize,
 	}
 	if expiresAfterSec > 0 {
 		claims.ExpiresAt = time.Now().Add(time.Second * time.Duration(expiresAfterSec)).Unix()
@@ -70,6 +99,98 @@ func GenJwtForFilerServer(signingKey SigningKey, expiresAfterSec int) EncodedJwt
 	return EncodedJwt(encoded)
 }
 
+// GenJwtForMasterAdmin creates a JSON-web-token for the volume actions of the master UI
+func GenJwtForMasterAdmin(signingKey SigningKey, expiresAfterSec int) EncodedJwt {
+	if len(signingKey) == 0 {
+		return ""
+	}
+
+	claims := SeaweedMasterAdminClaims{
+		jwt.StandardClaims{},
+	}
+	if expiresAfterSec > 0 {
+		claims.ExpiresAt = time.Now().Add(time.Second * time.Duration(expiresAfterSec)).Unix()
+	}
+	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	encoded, e := t.SignedString([]byte(signingKey))
+	if e != nil {
+		glog.V(0).Infof("Failed to sign claims %+v: %v", t.Claims, e)
+		return ""
+	}
+	return EncodedJwt(encoded)
+}
+
+// VerifyMasterAdminJwt checks the token is signed by the master admin signing key, and is not expired
+func VerifyMasterAdminJwt(signingKey SigningKey, tokenString EncodedJwt) error {
+	if tokenString == "" {
+		return ErrUnauthorized
+	}
+	token, err := DecodeJwt(signingKey, tokenString, &SeaweedMasterAdminClaims{})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+	if !token.Valid {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// WithMasterAdminJwt adds the master admin JWT to the metadata of the outgoing grpc requests, if the key is configured.
+func WithMasterAdminJwt(ctx context.Context, signingKey SigningKey) context.Context {
+	if len(signingKey) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+string(GenJwtForMasterAdmin(signingKey, 60)))
+}
+
+// VerifyMasterAdminGrpc checks the master admin JWT in the metadata of the grpc request.
+// The request is refused if the key is not configured.
+func VerifyMasterAdminGrpc(ctx context.Context, signingKey SigningKey) error {
+	if len(signingKey) == 0 {
+		return fmt.Errorf("%w: jwt.master_admin.key is not configured", ErrUnauthorized)
+	}
+	return VerifyMasterAdminJwt(signingKey, grpcBearerToken(ctx))
+}
+
+// WithFilerJwt adds the filer JWT to the metadata of the outgoing grpc requests, if the key is configured.
+func WithFilerJwt(ctx context.Context, signingKey SigningKey) context.Context {
+	if len(signingKey) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+string(GenJwtForFilerServer(signingKey, 60)))
+}
+
+// VerifyFilerGrpc checks the filer JWT in the metadata of the grpc request, e.g. from the peer filers.
+// Same as the filer http api, the request is allowed if the key is not configured.
+func VerifyFilerGrpc(ctx context.Context, signingKey SigningKey) error {
+	if len(signingKey) == 0 {
+		return nil
+	}
+	tokenStr := grpcBearerToken(ctx)
+	if tokenStr == "" {
+		return ErrUnauthorized
+	}
+	token, err := DecodeJwt(signingKey, tokenStr, &SeaweedFilerClaims{})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+	if !token.Valid {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+func grpcBearerToken(ctx context.Context) (tokenStr EncodedJwt) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, bearer := range md.Get("authorization") {
+			if len(bearer) > 7 && strings.ToUpper(bearer[0:6]) == "BEARER" {
+				tokenStr = EncodedJwt(bearer[7:])
+			}
+		}
+	}
+	return
+}
+
 func GetJwt(r *http.Request) EncodedJwt {
 
 	// Get token from query params
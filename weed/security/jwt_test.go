@@ -0,0 +1,86 @@
+package security
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGenJwtForVolumeServerUpload(t *testing.T) {
+	signingKey := SigningKey("secret")
+
+	encoded := GenJwtForVolumeServerUpload(signingKey, 10, "3,01637037d6", "bucket1", 4*1024*1024)
+	token, err := DecodeJwt(signingKey, encoded, &SeaweedFileIdClaims{})
+	if err != nil || !token.Valid {
+		t.Fatalf("decode jwt: %v", err)
+	}
+	claims := token.Claims.(*SeaweedFileIdClaims)
+	if claims.Fid != "3,01637037d6" || claims.Collection != "bucket1" || claims.MaxSize != 4*1024*1024 {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	if _, err = DecodeJwt(SigningKey("other"), encoded, &SeaweedFileIdClaims{}); err == nil {
+		t.Errorf("decoded jwt signed by another key")
+	}
+
+	// the tokens without the upload limits still decode
+	token, err = DecodeJwt(signingKey, GenJwtForVolumeServer(signingKey, 10, "3,01637037d6"), &SeaweedFileIdClaims{})
+	if err != nil {
+		t.Fatalf("decode jwt: %v", err)
+	}
+	if claims = token.Claims.(*SeaweedFileIdClaims); claims.Collection != "" || claims.MaxSize != 0 {
+		t.Errorf("unexpected upload limits %+v", claims)
+	}
+}
+
+func TestVerifyMasterAdminJwt(t *testing.T) {
+	signingKey := SigningKey("secret")
+
+	if err := VerifyMasterAdminJwt(signingKey, GenJwtForMasterAdmin(signingKey, 10)); err != nil {
+		t.Errorf("verify jwt: %v", err)
+	}
+	if err := VerifyMasterAdminJwt(signingKey, GenJwtForMasterAdmin(SigningKey("other"), 10)); err == nil {
+		t.Errorf("verified jwt signed by another key")
+	}
+	if err := VerifyMasterAdminJwt(signingKey, GenJwtForMasterAdmin(signingKey, -10)); err != nil {
+		t.Errorf("verify jwt without expiration: %v", err)
+	}
+	if err := VerifyMasterAdminJwt(signingKey, ""); err == nil {
+		t.Errorf("verified empty jwt")
+	}
+}
+
+func TestVerifyMasterAdminGrpc(t *testing.T) {
+	signingKey := SigningKey("secret")
+
+	// the outgoing metadata of the client is the incoming metadata of the server
+	md, _ := metadata.FromOutgoingContext(WithMasterAdminJwt(context.Background(), signingKey))
+	if err := VerifyMasterAdminGrpc(metadata.NewIncomingContext(context.Background(), md), signingKey); err != nil {
+		t.Errorf("verify grpc jwt: %v", err)
+	}
+	if err := VerifyMasterAdminGrpc(context.Background(), signingKey); err == nil {
+		t.Errorf("verified grpc request without jwt")
+	}
+	if err := VerifyMasterAdminGrpc(metadata.NewIncomingContext(context.Background(), md), nil); err == nil {
+		t.Errorf("verified grpc request without the key configured")
+	}
+}
+
+func TestVerifyFilerGrpc(t *testing.T) {
+	signingKey := SigningKey("secret")
+
+	md, _ := metadata.FromOutgoingContext(WithFilerJwt(context.Background(), signingKey))
+	if err := VerifyFilerGrpc(metadata.NewIncomingContext(context.Background(), md), signingKey); err != nil {
+		t.Errorf("verify grpc jwt: %v", err)
+	}
+	if err := VerifyFilerGrpc(context.Background(), signingKey); err == nil {
+		t.Errorf("verified grpc request without jwt")
+	}
+	if err := VerifyFilerGrpc(metadata.NewIncomingContext(context.Background(), md), SigningKey("other")); err == nil {
+		t.Errorf("verified grpc jwt of another key")
+	}
+	if err := VerifyFilerGrpc(context.Background(), nil); err != nil {
+		t.Errorf("refused grpc request without the key configured: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package security
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// LoadHttpsServerTLS reads the [https.<component>] section of security.toml.
+// The config is nil if no certificate is configured, and the server stays on plain http.
+// With a ca, only the clients with a certificate signed by the ca are accepted.
+func LoadHttpsServerTLS(config util.Configuration, component string) (*tls.Config, error) {
+	prefix := "https." + component + "."
+	certFile, keyFile, caFile := config.GetString(prefix+"cert"), config.GetString(prefix+"key"), config.GetString(prefix+"ca")
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load %s https certificate %s and key %s: %v", component, certFile, keyFile, err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	if caFile != "" {
+		caPem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read %s https ca %s: %v", component, caFile, err)
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caPem) {
+			return nil, fmt.Errorf("no certificate found in %s https ca %s", component, caFile)
+		}
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	glog.V(0).Infof("%s serves https with certificate %s, client certificates verified: %v", component, certFile, caFile != "")
+	return tlsConfig, nil
+}
+
+// LoadHttpsClient reads the [https.client] section of security.toml, so the internal http clients reach
+// the filers serving https. With a ca, the filer certificates are verified by the ca instead of the system roots,
+// and the client certificate is presented to the filers verifying the clients.
+func LoadHttpsClient(config util.Configuration) error {
+	if !config.GetBool("https.client.enabled") {
+		return nil
+	}
+	certFile, keyFile, caFile := config.GetString("https.client.cert"), config.GetString("https.client.key"), config.GetString("https.client.ca")
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if certFile != "" || keyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("load https client certificate %s and key %s: %v", certFile, keyFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	if caFile != "" {
+		caPem, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("read https client ca %s: %v", caFile, err)
+		}
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caPem) {
+			return fmt.Errorf("no certificate found in https client ca %s", caFile)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	util.UseHttpsClient(tlsConfig)
+	return nil
+}
+
+// ServeHttp serves https with the tls config of the server, which also enables HTTP/2, or else plain http.
+// The tls config is not checked, net/http sets an empty one on the server when serving plain http on another listener.
+func ServeHttp(server *http.Server, listener net.Listener, useTLS bool) error {
+	if useTLS {
+		return server.ServeTLS(listener, "", "")
+	}
+	return server.Serve(listener)
+}
@@ -0,0 +1,44 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestLoadHttpsServerTLS(t *testing.T) {
+	config := viper.New()
+
+	tlsConfig, err := LoadHttpsServerTLS(config, "filer")
+	if err != nil || tlsConfig != nil {
+		t.Errorf("expect plain http without a certificate, got %v %v", tlsConfig, err)
+	}
+
+	config.Set("https.filer.cert", "/not/found/cert.pem")
+	config.Set("https.filer.key", "/not/found/key.pem")
+	if _, err = LoadHttpsServerTLS(config, "filer"); err == nil {
+		t.Errorf("expect error for missing certificate files")
+	}
+}
+
+func TestLoadHttpsClient(t *testing.T) {
+	config := viper.New()
+
+	if err := LoadHttpsClient(config); err != nil || util.HttpScheme() != "http" {
+		t.Errorf("expect plain http when disabled, got %s %v", util.HttpScheme(), err)
+	}
+
+	config.Set("https.client.enabled", true)
+	config.Set("https.client.ca", "/not/found/ca.pem")
+	if err := LoadHttpsClient(config); err == nil {
+		t.Errorf("expect error for a missing ca file")
+	}
+
+	config.Set("https.client.ca", "")
+	defer util.UseHttpsClient(nil)
+	if err := LoadHttpsClient(config); err != nil || util.HttpScheme() != "https" {
+		t.Errorf("expect https when enabled, got %s %v", util.HttpScheme(), err)
+	}
+}
@@ -0,0 +1,154 @@
+package sequence
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+const (
+	DefaultEtcdSequenceKey = "/seaweedfs/master/sequence"
+
+	// the file ids reserved in etcd at a time, so most assignments do not wait for etcd
+	EtcdSequenceBatchSize = 10000
+
+	etcdTimeout = 5 * time.Second
+)
+
+// EtcdSequencer keeps the file id sequence in etcd, so the sequence survives wiping all masters.
+// The ids are reserved in etcd by batches and handed out from memory.
+// The unused ids of a batch are skipped after the master is replaced, never reused.
+type EtcdSequencer struct {
+	sequenceLock sync.Mutex
+	kv           clientv3.KV
+	key          string
+	currentSeq   uint64 // the next id to hand out
+	maxSeq       uint64 // the end of the reserved batch, exclusive
+}
+
+func NewEtcdSequencer(endpoints []string, key string) (*EtcdSequencer, error) {
+	if key == "" {
+		key = DefaultEtcdSequenceKey
+	}
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: etcdTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("connect to etcd %v: %v", endpoints, err)
+	}
+
+	m, err := newEtcdSequencer(client, key)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("etcd %v: %v", endpoints, err)
+	}
+	glog.V(0).Infof("use etcd seq id generator, etcd:%v key:%s from:%d", endpoints, key, m.currentSeq)
+
+	return m, nil
+}
+
+func newEtcdSequencer(kv clientv3.KV, key string) (*EtcdSequencer, error) {
+	m := &EtcdSequencer{kv: kv, key: key, currentSeq: 1}
+
+	// load the sequence, so Peek is right before any id is handed out
+	ctx, cancel := context.WithTimeout(context.Background(), etcdTimeout)
+	defer cancel()
+	resp, err := kv.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %v", key, err)
+	}
+	if len(resp.Kvs) > 0 {
+		stored, err := parseEtcdSequence(key, resp.Kvs[0].Value)
+		if err != nil {
+			return nil, err
+		}
+		m.currentSeq, m.maxSeq = stored, stored
+	}
+	return m, nil
+}
+
+func (m *EtcdSequencer) NextFileId(count uint64) (uint64, error) {
+	m.sequenceLock.Lock()
+	defer m.sequenceLock.Unlock()
+
+	if m.currentSeq+count > m.maxSeq {
+		if err := m.reserve(count); err != nil {
+			return 0, err
+		}
+	}
+	ret := m.currentSeq
+	m.currentSeq += count
+	return ret, nil
+}
+
+// reserve moves the sequence in etcd ahead by a batch, starting above both the stored and the seen ids.
+func (m *EtcdSequencer) reserve(count uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdTimeout)
+	defer cancel()
+
+	batchSize := uint64(EtcdSequenceBatchSize)
+	if count > batchSize {
+		batchSize = count
+	}
+
+	for {
+		resp, err := m.kv.Get(ctx, m.key)
+		if err != nil {
+			return fmt.Errorf("get %s from etcd: %v", m.key, err)
+		}
+
+		start := m.currentSeq
+		// only update the key if nobody else has changed it since read
+		cmp := clientv3.Compare(clientv3.CreateRevision(m.key), "=", 0)
+		if len(resp.Kvs) > 0 {
+			stored, err := parseEtcdSequence(m.key, resp.Kvs[0].Value)
+			if err != nil {
+				return err
+			}
+			if stored > start {
+				start = stored
+			}
+			cmp = clientv3.Compare(clientv3.ModRevision(m.key), "=", resp.Kvs[0].ModRevision)
+		}
+		end := start + batchSize
+
+		txnResp, err := m.kv.Txn(ctx).If(cmp).Then(clientv3.OpPut(m.key, strconv.FormatUint(end, 10))).Commit()
+		if err != nil {
+			return fmt.Errorf("reserve %s in etcd: %v", m.key, err)
+		}
+		if txnResp.Succeeded {
+			m.currentSeq, m.maxSeq = start, end
+			return nil
+		}
+		glog.V(0).Infof("sequence %s is changed in etcd by another master, retrying", m.key)
+	}
+}
+
+func (m *EtcdSequencer) SetMax(seenValue uint64) {
+	m.sequenceLock.Lock()
+	defer m.sequenceLock.Unlock()
+	if m.currentSeq <= seenValue {
+		m.currentSeq = seenValue + 1
+	}
+}
+
+func (m *EtcdSequencer) Peek() uint64 {
+	m.sequenceLock.Lock()
+	defer m.sequenceLock.Unlock()
+	return m.currentSeq
+}
+
+func parseEtcdSequence(key string, value []byte) (uint64, error) {
+	seq, err := strconv.ParseUint(string(value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s value %q from etcd: %v", key, value, err)
+	}
+	return seq, nil
+}
@@ -0,0 +1,127 @@
+package sequence
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fakeEtcdKv keeps one key, and only supports the get and the compare revision transactions
+type fakeEtcdKv struct {
+	clientv3.KV
+	sync.Mutex
+	value          []byte
+	createRevision int64
+	modRevision    int64
+	revision       int64
+}
+
+func (kv *fakeEtcdKv) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	kv.Lock()
+	defer kv.Unlock()
+	resp := &clientv3.GetResponse{}
+	if kv.value != nil {
+		resp.Kvs = []*mvccpb.KeyValue{{Key: []byte(key), Value: kv.value, CreateRevision: kv.createRevision, ModRevision: kv.modRevision}}
+	}
+	return resp, nil
+}
+
+func (kv *fakeEtcdKv) Txn(ctx context.Context) clientv3.Txn {
+	return &fakeEtcdTxn{kv: kv}
+}
+
+type fakeEtcdTxn struct {
+	kv   *fakeEtcdKv
+	cmps []clientv3.Cmp
+	ops  []clientv3.Op
+}
+
+func (t *fakeEtcdTxn) If(cs ...clientv3.Cmp) clientv3.Txn {
+	t.cmps = cs
+	return t
+}
+
+func (t *fakeEtcdTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	t.ops = ops
+	return t
+}
+
+func (t *fakeEtcdTxn) Else(ops ...clientv3.Op) clientv3.Txn {
+	return t
+}
+
+func (t *fakeEtcdTxn) Commit() (*clientv3.TxnResponse, error) {
+	kv := t.kv
+	kv.Lock()
+	defer kv.Unlock()
+	for _, cmp := range t.cmps {
+		var actual, expected int64
+		switch target := cmp.TargetUnion.(type) {
+		case *pb.Compare_CreateRevision:
+			actual, expected = kv.createRevision, target.CreateRevision
+		case *pb.Compare_ModRevision:
+			actual, expected = kv.modRevision, target.ModRevision
+		}
+		if cmp.Result != pb.Compare_EQUAL || actual != expected {
+			return &clientv3.TxnResponse{Succeeded: false}, nil
+		}
+	}
+	for _, op := range t.ops {
+		kv.revision++
+		if kv.value == nil {
+			kv.createRevision = kv.revision
+		}
+		kv.modRevision = kv.revision
+		kv.value = op.ValueBytes()
+	}
+	return &clientv3.TxnResponse{Succeeded: true}, nil
+}
+
+func TestEtcdSequencerAcrossMasters(t *testing.T) {
+	kv := &fakeEtcdKv{}
+	master1, err := newEtcdSequencer(kv, DefaultEtcdSequenceKey)
+	assert.Nil(t, err)
+	master2, err := newEtcdSequencer(kv, DefaultEtcdSequenceKey)
+	assert.Nil(t, err)
+
+	seen := make(map[uint64]bool)
+	var last uint64
+	assign := func(m *EtcdSequencer, count uint64) {
+		start, err := m.NextFileId(count)
+		assert.Nil(t, err)
+		for id := start; id < start+count; id++ {
+			assert.False(t, seen[id], "id %d is handed out twice", id)
+			seen[id] = true
+			if id > last {
+				last = id
+			}
+		}
+	}
+	for i := 0; i < 3; i++ {
+		assign(master1, 7)
+		assign(master2, 7)
+		assign(master1, EtcdSequenceBatchSize+1)
+	}
+
+	// all masters are wiped, the new master continues after every reserved id
+	master3, err := newEtcdSequencer(kv, DefaultEtcdSequenceKey)
+	assert.Nil(t, err)
+	assert.Greater(t, master3.Peek(), last)
+	start, err := master3.NextFileId(1)
+	assert.Nil(t, err)
+	assert.Greater(t, start, last)
+
+	// the ids seen in the volumes are never handed out again
+	master3.SetMax(start + 3*EtcdSequenceBatchSize)
+	next, err := master3.NextFileId(1)
+	assert.Nil(t, err)
+	assert.Equal(t, start+3*EtcdSequenceBatchSize+1, next)
+	master4, err := newEtcdSequencer(kv, DefaultEtcdSequenceKey)
+	assert.Nil(t, err)
+	assert.Greater(t, master4.Peek(), next)
+}
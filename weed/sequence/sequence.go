@@ -1,7 +1,7 @@
 package sequence
 
 type Sequencer interface {
-	NextFileId(count uint64) uint64
+	NextFileId(count uint64) (uint64, error)
 	SetMax(uint64)
 	Peek() uint64
 }
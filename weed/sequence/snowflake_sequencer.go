@@ -35,8 +35,8 @@ func hash(s string) uint32 {
 	return h.Sum32()
 }
 
-func (m *SnowflakeSequencer) NextFileId(count uint64) uint64 {
-	return uint64(m.node.Generate().Int64())
+func (m *SnowflakeSequencer) NextFileId(count uint64) (uint64, error) {
+	return uint64(m.node.Generate().Int64()), nil
 }
 
 // ignore setmax as we are snowflake
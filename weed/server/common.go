@@ -20,6 +20,7 @@ import (
 
 	"google.golang.org/grpc"
 
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/stats"
@@ -152,6 +153,7 @@ func submitForClientHandler(w http.ResponseWriter, r *http.Request, masterFn ope
 		Collection:  r.FormValue("collection"),
 		Ttl:         r.FormValue("ttl"),
 		DiskType:    r.FormValue("disk"),
+		MaxSize:     operation.UploadMaxSize(int64(len(pu.Data))),
 	}
 	assignResult, ae := operation.Assign(masterFn, grpcDialOption, ar)
 	if ae != nil {
@@ -258,18 +260,26 @@ func adjustHeaderContentDisposition(w http.ResponseWriter, r *http.Request, file
 	}
 }
 
-func processRangeRequest(r *http.Request, w http.ResponseWriter, totalSize int64, mimeType string, writeFn func(writer io.Writer, offset int64, size int64) error) error {
+// processRangeRequest writes the whole content or the requested ranges.
+// Each range is prepared before the response status is written, so the errors found by then are returned as http errors.
+func processRangeRequest(r *http.Request, w http.ResponseWriter, totalSize int64, mimeType string, prepareWriteFn func(offset int64, size int64) (filer.DoStreamContent, error)) error {
 	rangeReq := r.Header.Get("Range")
 	bufferedWriter := bufio.NewWriterSize(w, 128*1024)
 	defer bufferedWriter.Flush()
 
 	if rangeReq == "" {
-		w.Header().Set("Content-Length", strconv.FormatInt(totalSize, 10))
-		if err := writeFn(bufferedWriter, 0, totalSize); err != nil {
+		writeFn, err := prepareWriteFn(0, totalSize)
+		if err != nil {
 			glog.Errorf("processRangeRequest: %v", err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return fmt.Errorf("processRangeRequest: %v", err)
 		}
+		w.Header().Set("Content-Length", strconv.FormatInt(totalSize, 10))
+		if err = writeFn(bufferedWriter); err != nil {
+			glog.Errorf("processRangeRequest: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return fmt.Errorf("processRangeRequest: %w", err)
+		}
 		return nil
 	}
 
@@ -304,15 +314,21 @@ func processRangeRequest(r *http.Request, w http.ResponseWriter, totalSize int64
 		// A response to a request for a single range MUST NOT
 		// be sent using the multipart/byteranges media type."
 		ra := ranges[0]
+		writeFn, err := prepareWriteFn(ra.start, ra.length)
+		if err != nil {
+			glog.Errorf("processRangeRequest range[0]: %+v err: %v", w.Header(), err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return fmt.Errorf("processRangeRequest range[0]: %v", err)
+		}
 		w.Header().Set("Content-Length", strconv.FormatInt(ra.length, 10))
 		w.Header().Set("Content-Range", ra.contentRange(totalSize))
 
 		w.WriteHeader(http.StatusPartialContent)
-		err = writeFn(bufferedWriter, ra.start, ra.length)
+		err = writeFn(bufferedWriter)
 		if err != nil {
 			glog.Errorf("processRangeRequest range[0]: %+v err: %v", w.Header(), err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return fmt.Errorf("processRangeRequest range[0]: %v", err)
+			return fmt.Errorf("processRangeRequest range[0]: %w", err)
 		}
 		return nil
 	}
@@ -324,6 +340,14 @@ func processRangeRequest(r *http.Request, w http.ResponseWriter, totalSize int64
 			return fmt.Errorf("out of range: %v", err)
 		}
 	}
+	writeFns := make([]filer.DoStreamContent, len(ranges))
+	for i, ra := range ranges {
+		if writeFns[i], err = prepareWriteFn(ra.start, ra.length); err != nil {
+			glog.Errorf("processRangeRequest range[%d]: %+v err: %v", i, w.Header(), err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return fmt.Errorf("processRangeRequest range[%d]: %v", i, err)
+		}
+	}
 	sendSize := rangesMIMESize(ranges, mimeType, totalSize)
 	pr, pw := io.Pipe()
 	mw := multipart.NewWriter(pw)
@@ -331,13 +355,13 @@ func processRangeRequest(r *http.Request, w http.ResponseWriter, totalSize int64
 	sendContent := pr
 	defer pr.Close() // cause writing goroutine to fail and exit if CopyN doesn't finish.
 	go func() {
-		for _, ra := range ranges {
+		for i, ra := range ranges {
 			part, e := mw.CreatePart(ra.mimeHeader(mimeType, totalSize))
 			if e != nil {
 				pw.CloseWithError(e)
 				return
 			}
-			if e = writeFn(part, ra.start, ra.length); e != nil {
+			if e = writeFns[i](part); e != nil {
 				pw.CloseWithError(e)
 				return
 			}
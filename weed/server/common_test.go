@@ -1,8 +1,14 @@
 package weed_server
 
 import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 )
 
 func TestParseURL(t *testing.T) {
@@ -29,3 +35,27 @@ func TestParseURL(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessRangeRequestPrepareError(t *testing.T) {
+	prepareWriteFn := func(offset int64, size int64) (filer.DoStreamContent, error) {
+		if offset > 0 {
+			return nil, errors.New("chunk not found")
+		}
+		return func(writer io.Writer) error {
+			_, err := writer.Write([]byte("0123456789")[offset : offset+size])
+			return err
+		}, nil
+	}
+
+	for rangeHeader, expected := range map[string]int{"": http.StatusOK, "bytes=0-3": http.StatusPartialContent, "bytes=4-7": http.StatusInternalServerError, "bytes=0-1,4-5": http.StatusInternalServerError} {
+		r := httptest.NewRequest(http.MethodGet, "/file", nil)
+		if rangeHeader != "" {
+			r.Header.Set("Range", rangeHeader)
+		}
+		w := httptest.NewRecorder()
+		processRangeRequest(r, w, 10, "text/plain", prepareWriteFn)
+		if w.Code != expected {
+			t.Errorf("range %q: expected status %d, got %d", rangeHeader, expected, w.Code)
+		}
+	}
+}
@@ -30,9 +30,13 @@ func (fs *FilerServer) LookupDirectoryEntry(ctx context.Context, req *filer_pb.L
 		return nil, err
 	}
 
-	return &filer_pb.LookupDirectoryEntryResponse{
+	resp := &filer_pb.LookupDirectoryEntryResponse{
 		Entry: entry.ToProtoEntry(),
-	}, nil
+	}
+	if entry.IsDirectory() {
+		resp.DirChangeSeq = fs.filer.DirChangeSeqs.Get(entry.FullPath)
+	}
+	return resp, nil
 }
 
 func (fs *FilerServer) ListEntries(req *filer_pb.ListEntriesRequest, stream filer_pb.SeaweedFiler_ListEntriesServer) (err error) {
@@ -44,6 +48,22 @@ func (fs *FilerServer) ListEntries(req *filer_pb.ListEntriesRequest, stream file
 		limit = fs.option.DirListingLimit
 	}
 
+	if req.SortBy != filer.SortByName {
+		if req.StartFromFileName != "" {
+			return fmt.Errorf("list %s sorted by %s can not start from %s", req.Directory, req.SortBy, req.StartFromFileName)
+		}
+		listErr := fs.filer.ListDirectorySortedEntries(stream.Context(), util.FullPath(req.Directory), req.Prefix, req.SortBy, int64(limit), func(entry *filer.Entry) bool {
+			err = stream.Send(&filer_pb.ListEntriesResponse{
+				Entry: entry.ToProtoEntry(),
+			})
+			return err == nil
+		})
+		if listErr != nil {
+			return listErr
+		}
+		return err
+	}
+
 	paginationLimit := filer.PaginationSize
 	if limit < paginationLimit {
 		paginationLimit = limit
@@ -136,6 +156,10 @@ func (fs *FilerServer) lookupFileId(fileId string) (targetUrls []string, err err
 
 func (fs *FilerServer) CreateEntry(ctx context.Context, req *filer_pb.CreateEntryRequest) (resp *filer_pb.CreateEntryResponse, err error) {
 
+	if err := fs.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	glog.V(4).Infof("CreateEntry %v/%v", req.Directory, req.Entry.Name)
 
 	resp = &filer_pb.CreateEntryResponse{}
@@ -153,10 +177,21 @@ func (fs *FilerServer) CreateEntry(ctx context.Context, req *filer_pb.CreateEntr
 	newEntry.Chunks = chunks
 	newEntry.TtlSec = so.TtlSeconds
 
-	createErr := fs.filer.CreateEntry(ctx, newEntry, req.OExcl, req.IsFromOtherCluster, req.Signatures, req.SkipCheckParentDirectory)
+	ctx = filer.WithMetaEventTsNs(ctx)
+	condition := &filer.WriteCondition{IfMatch: req.IfMatch, IfNoneMatch: req.IfNoneMatch}
+	var createErr error
+	if condition.IsSet() {
+		createErr = fs.filer.CreateEntryWithCondition(ctx, newEntry, condition, req.IsFromOtherCluster, req.Signatures, req.SkipCheckParentDirectory)
+	} else {
+		createErr = fs.filer.CreateEntry(ctx, newEntry, req.OExcl, req.IsFromOtherCluster, req.Signatures, req.SkipCheckParentDirectory)
+	}
+	fs.auditGrpcChange(ctx, "CreateEntry", string(newEntry.FullPath), createErr)
 
 	if createErr == nil {
 		fs.filer.DeleteChunks(garbage)
+		if ackErr := fs.waitForFilerAcks(ctx, newEntry.FullPath); ackErr != nil {
+			resp.FilerAckWarning = ackErr.Error()
+		}
 	} else {
 		glog.V(3).Infof("CreateEntry %s: %v", filepath.Join(req.Directory, req.Entry.Name), createErr)
 		resp.Error = createErr.Error()
@@ -167,6 +202,10 @@ func (fs *FilerServer) CreateEntry(ctx context.Context, req *filer_pb.CreateEntr
 
 func (fs *FilerServer) UpdateEntry(ctx context.Context, req *filer_pb.UpdateEntryRequest) (*filer_pb.UpdateEntryResponse, error) {
 
+	if err := fs.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	glog.V(4).Infof("UpdateEntry %v", req)
 
 	fullpath := util.Join(req.Directory, req.Entry.Name)
@@ -187,16 +226,24 @@ func (fs *FilerServer) UpdateEntry(ctx context.Context, req *filer_pb.UpdateEntr
 		return &filer_pb.UpdateEntryResponse{}, err
 	}
 
-	if err = fs.filer.UpdateEntry(ctx, entry, newEntry); err == nil {
+	ctx = filer.WithMetaEventTsNs(ctx)
+	resp := &filer_pb.UpdateEntryResponse{}
+	err = fs.filer.UpdateEntry(filer.WithGovernanceBypass(ctx, req.BypassGovernanceRetention), entry, newEntry)
+	fs.auditGrpcChange(ctx, "UpdateEntry", fullpath, err)
+	if err == nil {
 		fs.filer.DeleteChunks(garbage)
 
 		fs.filer.NotifyUpdateEvent(ctx, entry, newEntry, true, req.IsFromOtherCluster, req.Signatures)
 
+		if ackErr := fs.waitForFilerAcks(ctx, newEntry.FullPath); ackErr != nil {
+			resp.FilerAckWarning = ackErr.Error()
+		}
+
 	} else {
 		glog.V(3).Infof("UpdateEntry %s: %v", filepath.Join(req.Directory, req.Entry.Name), err)
 	}
 
-	return &filer_pb.UpdateEntryResponse{}, err
+	return resp, err
 }
 
 func (fs *FilerServer) cleanupChunks(fullpath string, existingEntry *filer.Entry, newEntry *filer_pb.Entry) (chunks, garbage []*filer_pb.FileChunk, err error) {
@@ -239,9 +286,16 @@ func (fs *FilerServer) cleanupChunks(fullpath string, existingEntry *filer.Entry
 
 func (fs *FilerServer) AppendToEntry(ctx context.Context, req *filer_pb.AppendToEntryRequest) (*filer_pb.AppendToEntryResponse, error) {
 
+	if err := fs.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	glog.V(4).Infof("AppendToEntry %v", req)
 
 	fullpath := util.NewFullPath(req.Directory, req.EntryName)
+	unlock := fs.entryLocks.Lock(string(fullpath))
+	defer unlock()
+
 	var offset int64 = 0
 	entry, err := fs.filer.FindEntry(ctx, fullpath)
 	if err == filer_pb.ErrNotFound {
@@ -283,9 +337,14 @@ func (fs *FilerServer) AppendToEntry(ctx context.Context, req *filer_pb.AppendTo
 
 func (fs *FilerServer) DeleteEntry(ctx context.Context, req *filer_pb.DeleteEntryRequest) (resp *filer_pb.DeleteEntryResponse, err error) {
 
+	if err := fs.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	glog.V(4).Infof("DeleteEntry %v", req)
 
-	err = fs.filer.DeleteEntryMetaAndData(ctx, util.JoinPath(req.Directory, req.Name), req.IsRecursive, req.IgnoreRecursiveError, req.IsDeleteData, req.IsFromOtherCluster, req.Signatures)
+	err = fs.filer.DeleteEntryMetaAndData(filer.WithGovernanceBypass(ctx, req.BypassGovernanceRetention), util.JoinPath(req.Directory, req.Name), req.IsRecursive, req.IgnoreRecursiveError, req.IsDeleteData, req.IsFromOtherCluster, req.Signatures)
+	fs.auditGrpcChange(ctx, "DeleteEntry", string(util.JoinPath(req.Directory, req.Name)), err)
 	resp = &filer_pb.DeleteEntryResponse{}
 	if err != nil && err != filer_pb.ErrNotFound {
 		resp.Error = err.Error()
@@ -293,8 +352,33 @@ func (fs *FilerServer) DeleteEntry(ctx context.Context, req *filer_pb.DeleteEntr
 	return resp, nil
 }
 
+func (fs *FilerServer) StreamDeleteEntry(req *filer_pb.StreamDeleteEntryRequest, stream filer_pb.SeaweedFiler_StreamDeleteEntryServer) error {
+
+	if err := fs.checkWritable(); err != nil {
+		return err
+	}
+
+	glog.V(1).Infof("StreamDeleteEntry %v", req)
+
+	err := fs.filer.DeleteEntryRecursively(stream.Context(), util.JoinPath(req.Directory, req.Name), req.IgnoreRecursiveError, req.IsDeleteData, req.IsFromOtherCluster, req.Signatures, int(req.PageSize), func(progress *filer.RecursiveDeleteProgress) error {
+		return stream.Send(&filer_pb.StreamDeleteEntryResponse{
+			Directory:         string(progress.Directory),
+			DeletedEntryCount: progress.DeletedEntryCount,
+			DeletedChunkCount: progress.DeletedChunkCount,
+		})
+	})
+	if err == filer_pb.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
 func (fs *FilerServer) AssignVolume(ctx context.Context, req *filer_pb.AssignVolumeRequest) (resp *filer_pb.AssignVolumeResponse, err error) {
 
+	if err := fs.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	so, err := fs.detectStorageOption(req.Path, req.Collection, req.Replication, req.TtlSec, req.DiskType, req.DataCenter, req.Rack, req.DataNode)
 	if err != nil {
 		glog.V(3).Infof("AssignVolume: %v", err)
@@ -302,6 +386,10 @@ func (fs *FilerServer) AssignVolume(ctx context.Context, req *filer_pb.AssignVol
 	}
 
 	assignRequest, altRequest := so.ToAssignRequests(int(req.Count))
+	assignRequest.MaxSize = req.MaxSize
+	if altRequest != nil {
+		altRequest.MaxSize = req.MaxSize
+	}
 
 	assignResult, err := operation.Assign(fs.filer.GetMaster, fs.grpcDialOption, assignRequest, altRequest)
 	if err != nil {
@@ -336,12 +424,17 @@ func (fs *FilerServer) CollectionList(ctx context.Context, req *filer_pb.Collect
 		masterResp, err := client.CollectionList(context.Background(), &master_pb.CollectionListRequest{
 			IncludeNormalVolumes: req.IncludeNormalVolumes,
 			IncludeEcVolumes:     req.IncludeEcVolumes,
+			IncludeUsage:         req.IncludeUsage,
 		})
 		if err != nil {
 			return err
 		}
 		for _, c := range masterResp.Collections {
-			resp.Collections = append(resp.Collections, &filer_pb.Collection{Name: c.Name})
+			resp.Collections = append(resp.Collections, &filer_pb.Collection{
+				Name:      c.Name,
+				FileCount: c.FileCount,
+				DataSize:  c.DataSize,
+			})
 		}
 		return nil
 	})
@@ -351,13 +444,21 @@ func (fs *FilerServer) CollectionList(ctx context.Context, req *filer_pb.Collect
 
 func (fs *FilerServer) DeleteCollection(ctx context.Context, req *filer_pb.DeleteCollectionRequest) (resp *filer_pb.DeleteCollectionResponse, err error) {
 
+	if err := fs.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	glog.V(4).Infof("DeleteCollection %v", req)
 
+	// the collection of a bucket keeps the data of its locked objects
+	if bucketEntry, findErr := fs.filer.FindEntry(ctx, util.NewFullPath(fs.filer.DirBucketsPath, req.GetCollection())); findErr == nil {
+		if err = fs.filer.CheckObjectLockForDelete(ctx, bucketEntry, false); err != nil {
+			return nil, err
+		}
+	}
+
 	err = fs.filer.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
-		_, err := client.CollectionDelete(context.Background(), &master_pb.CollectionDeleteRequest{
-			Name: req.GetCollection(),
-		})
-		return err
+		return operation.DeleteCollection(client, req.GetCollection())
 	})
 
 	return &filer_pb.DeleteCollectionResponse{}, err
@@ -11,6 +11,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
@@ -82,26 +83,65 @@ func (fs *FilerServer) Ping(ctx context.Context, req *filer_pb.PingRequest) (res
 	return
 }
 
+// SetLogLevel reads the log levels, or changes them with the master admin JWT.
+func (fs *FilerServer) SetLogLevel(ctx context.Context, req *filer_pb.SetLogLevelRequest) (*filer_pb.SetLogLevelResponse, error) {
+	if req.Levels != "" {
+		if err := security.VerifyMasterAdminGrpc(ctx, fs.adminSigningKey); err != nil {
+			return nil, err
+		}
+	}
+	levels, err := glog.ChangeLevels(req.Levels)
+	if err != nil {
+		return nil, err
+	}
+	return &filer_pb.SetLogLevelResponse{
+		Levels: levels,
+	}, nil
+}
+
+// SetReadOnly switches the filer to reject or accept the changes, e.g. for a maintenance window.
+func (fs *FilerServer) SetReadOnly(ctx context.Context, req *filer_pb.SetReadOnlyRequest) (*filer_pb.SetReadOnlyResponse, error) {
+	if !req.GetOnly {
+		if err := security.VerifyMasterAdminGrpc(ctx, fs.adminSigningKey); err != nil {
+			return nil, err
+		}
+		fs.setReadOnly(req.ReadOnly)
+	}
+	return &filer_pb.SetReadOnlyResponse{
+		ReadOnly: fs.isReadOnly(),
+	}, nil
+}
+
 func (fs *FilerServer) GetFilerConfiguration(ctx context.Context, req *filer_pb.GetFilerConfigurationRequest) (resp *filer_pb.GetFilerConfigurationResponse, err error) {
 
 	clusterId, _ := fs.filer.Store.KvGet(context.Background(), []byte("clusterId"))
 
 	t := &filer_pb.GetFilerConfigurationResponse{
-		Masters:            rpc.ToAddressStringsFromMap(fs.option.Masters),
-		Collection:         fs.option.Collection,
-		Replication:        fs.option.DefaultReplication,
-		MaxMb:              uint32(fs.option.MaxMB),
-		DirBuckets:         fs.filer.DirBucketsPath,
-		Cipher:             fs.filer.Cipher,
-		Signature:          fs.filer.Signature,
-		MetricsAddress:     fs.metricsAddress,
-		MetricsIntervalSec: int32(fs.metricsIntervalSec),
-		Version:            util.Version(),
-		ClusterId:          string(clusterId),
-		FilerGroup:         fs.option.FilerGroup,
+		Masters:                       rpc.ToAddressStringsFromMap(rpc.ResolveServerAddressMap(fs.option.Masters)),
+		Collection:                    fs.option.Collection,
+		Replication:                   fs.option.DefaultReplication,
+		MaxMb:                         uint32(fs.option.MaxMB),
+		DirBuckets:                    fs.filer.DirBucketsPath,
+		Cipher:                        fs.filer.Cipher,
+		Signature:                     fs.filer.Signature,
+		MetricsAddress:                fs.metricsAddress,
+		MetricsIntervalSec:            int32(fs.metricsIntervalSec),
+		Version:                       util.Version(),
+		ClusterId:                     string(clusterId),
+		FilerGroup:                    fs.option.FilerGroup,
+		Store:                         fs.filer.Store.GetName(),
+		JwtFilerSigningKeyFingerprint: fs.filerGuard.SigningKey.Fingerprint(),
+		ReadOnly:                      fs.isReadOnly(),
 	}
 
 	glog.V(4).Infof("GetFilerConfiguration: %v", t)
 
 	return t, nil
 }
+
+func (fs *FilerServer) SlowRequests(ctx context.Context, req *filer_pb.SlowRequestsRequest) (*filer_pb.SlowRequestsResponse, error) {
+
+	return &filer_pb.SlowRequestsResponse{
+		Requests: fs.slowLog.RecentJson(int(req.Limit)),
+	}, nil
+}
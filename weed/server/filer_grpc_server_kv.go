@@ -26,10 +26,15 @@ func (fs *FilerServer) KvGet(ctx context.Context, req *filer_pb.KvGetRequest) (*
 // KvPut sets the key~value. if empty value, delete the kv entry
 func (fs *FilerServer) KvPut(ctx context.Context, req *filer_pb.KvPutRequest) (*filer_pb.KvPutResponse, error) {
 
+	if err := fs.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	if len(req.Value) == 0 {
 		if err := fs.filer.Store.KvDelete(ctx, req.Key); err != nil {
 			return &filer_pb.KvPutResponse{Error: err.Error()}, nil
 		}
+		return &filer_pb.KvPutResponse{}, nil
 	}
 
 	err := fs.filer.Store.KvPut(ctx, req.Key, req.Value)
@@ -40,3 +45,78 @@ func (fs *FilerServer) KvPut(ctx context.Context, req *filer_pb.KvPutRequest) (*
 	return &filer_pb.KvPutResponse{}, nil
 
 }
+
+// KvList lists the kv entries under the prefix, after the start key.
+func (fs *FilerServer) KvList(ctx context.Context, req *filer_pb.KvListRequest) (*filer_pb.KvListResponse, error) {
+
+	if len(req.Prefix) == 0 {
+		return &filer_pb.KvListResponse{Error: "kv list needs a key prefix"}, nil
+	}
+	limit := req.Limit
+	if limit <= 0 || limit > filer.PaginationSize {
+		limit = filer.PaginationSize
+	}
+
+	resp := &filer_pb.KvListResponse{}
+	err := fs.filer.Store.KvList(ctx, req.Prefix, req.StartKey, limit, func(key []byte, value []byte) bool {
+		kvEntry := &filer_pb.KvEntry{
+			Key:       append([]byte(nil), key...),
+			ValueSize: int64(len(value)),
+		}
+		if !req.KeysOnly {
+			kvEntry.Value = append([]byte(nil), value...)
+		}
+		resp.Entries = append(resp.Entries, kvEntry)
+		return true
+	})
+	if err != nil {
+		return &filer_pb.KvListResponse{Error: err.Error()}, nil
+	}
+
+	return resp, nil
+
+}
+
+func (fs *FilerServer) KvDelete(ctx context.Context, req *filer_pb.KvDeleteRequest) (*filer_pb.KvDeleteResponse, error) {
+
+	if err := fs.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	if err := fs.filer.Store.KvDelete(ctx, req.Key); err != nil {
+		return &filer_pb.KvDeleteResponse{Error: err.Error()}, nil
+	}
+
+	return &filer_pb.KvDeleteResponse{}, nil
+
+}
+
+// KvUsage counts the keys and value bytes of each prefix, or of the known namespaces if no prefixes are set.
+func (fs *FilerServer) KvUsage(ctx context.Context, req *filer_pb.KvUsageRequest) (*filer_pb.KvUsageResponse, error) {
+
+	prefixes := req.Prefixes
+	if len(prefixes) == 0 {
+		for _, namespace := range filer.KvNamespaces {
+			prefixes = append(prefixes, []byte(namespace))
+		}
+	}
+
+	resp := &filer_pb.KvUsageResponse{}
+	for _, prefix := range prefixes {
+		if len(prefix) == 0 {
+			return &filer_pb.KvUsageResponse{Error: "kv usage needs non empty prefixes"}, nil
+		}
+		keyCount, valueBytes, err := filer.KvUsage(ctx, fs.filer.Store, prefix)
+		if err != nil {
+			return &filer_pb.KvUsageResponse{Error: err.Error()}, nil
+		}
+		resp.Namespaces = append(resp.Namespaces, &filer_pb.KvNamespaceUsage{
+			Prefix:     prefix,
+			KeyCount:   keyCount,
+			ValueBytes: valueBytes,
+		})
+	}
+
+	return resp, nil
+
+}
@@ -0,0 +1,76 @@
+package weed_server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func (fs *FilerServer) AcquireAdvisoryLock(ctx context.Context, req *filer_pb.AcquireAdvisoryLockRequest) (*filer_pb.AcquireAdvisoryLockResponse, error) {
+
+	if req.Lock == nil || req.Lock.Owner == "" {
+		return nil, fmt.Errorf("missing lock owner")
+	}
+	if !req.TestOnly {
+		if err := fs.checkWritable(); err != nil {
+			return nil, err
+		}
+	}
+
+	conflict, err := fs.filer.AdvisoryLocks.Acquire(ctx, util.FullPath(req.Path), req.Lock, advisoryLockLease(req.LeaseSeconds), req.TestOnly)
+	if err != nil {
+		glog.Errorf("acquire advisory lock %s by %s: %v", req.Path, req.Lock.Owner, err)
+		return nil, err
+	}
+
+	return &filer_pb.AcquireAdvisoryLockResponse{
+		IsAcquired: conflict == nil && !req.TestOnly,
+		Conflict:   conflict,
+	}, nil
+}
+
+func (fs *FilerServer) ReleaseAdvisoryLock(ctx context.Context, req *filer_pb.ReleaseAdvisoryLockRequest) (*filer_pb.ReleaseAdvisoryLockResponse, error) {
+
+	if err := fs.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	if err := fs.filer.AdvisoryLocks.Release(ctx, util.FullPath(req.Path), req.Owner, req.Start, req.End); err != nil {
+		glog.Errorf("release advisory lock %s by %s: %v", req.Path, req.Owner, err)
+		return nil, err
+	}
+
+	return &filer_pb.ReleaseAdvisoryLockResponse{}, nil
+}
+
+// RenewAdvisoryLocks is called periodically by a client to keep its locks, and on unmount to release them.
+func (fs *FilerServer) RenewAdvisoryLocks(ctx context.Context, req *filer_pb.RenewAdvisoryLocksRequest) (*filer_pb.RenewAdvisoryLocksResponse, error) {
+
+	if req.OwnerPrefix == "" {
+		return nil, fmt.Errorf("missing lock owner prefix")
+	}
+	if err := fs.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	count, err := fs.filer.AdvisoryLocks.Renew(ctx, req.OwnerPrefix, advisoryLockLease(req.LeaseSeconds), req.Release)
+	if err != nil {
+		return nil, err
+	}
+
+	return &filer_pb.RenewAdvisoryLocksResponse{
+		LockCount: int32(count),
+	}, nil
+}
+
+func advisoryLockLease(leaseSeconds int64) time.Duration {
+	if leaseSeconds <= 0 {
+		leaseSeconds = filer.DefaultAdvisoryLockLeaseSec
+	}
+	return time.Duration(leaseSeconds) * time.Second
+}
@@ -0,0 +1,48 @@
+package weed_server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// AckLocalMetadata is called by the peer filers subscribed to the local meta events, after applying them.
+func (fs *FilerServer) AckLocalMetadata(ctx context.Context, req *filer_pb.AckLocalMetadataRequest) (*filer_pb.AckLocalMetadataResponse, error) {
+	if err := security.VerifyFilerGrpc(ctx, fs.filerGuard.SigningKey); err != nil {
+		return nil, err
+	}
+	if fs.filer.MetaAggregator != nil {
+		fs.filer.MetaAggregator.OnPeerAck(rpc.ServerAddress(req.Filer), req.TsNs)
+	}
+	return &filer_pb.AckLocalMetadataResponse{}, nil
+}
+
+// waitForFilerAcks waits for the other filers in the group to apply the meta event of the change with the context,
+// made with filer.WithMetaEventTsNs, if "filerAckCount" is configured for the path.
+// The change is already committed, so a timeout is only a warning to the client.
+func (fs *FilerServer) waitForFilerAcks(ctx context.Context, fullpath util.FullPath) error {
+	eventTsNs := filer.MetaEventTsNs(ctx)
+	if eventTsNs == 0 {
+		return nil
+	}
+	ackCount := fs.filer.FilerConf.MatchStorageRule(string(fullpath)).FilerAckCount
+	if ackCount == 0 {
+		return nil
+	}
+	if fs.filer.MetaAggregator == nil {
+		return fmt.Errorf("%w: no peer filers", filer.ErrFilerAckTimeout)
+	}
+	acked, err := fs.filer.MetaAggregator.WaitForPeerAcks(eventTsNs, int(ackCount), filer.MetaAckTimeout)
+	if err != nil {
+		glog.Warningf("write %s: %v", fullpath, err)
+		return err
+	}
+	glog.V(4).Infof("write %s applied by %d filers", fullpath, acked)
+	return nil
+}
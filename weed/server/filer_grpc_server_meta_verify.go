@@ -0,0 +1,140 @@
+package weed_server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util/log_buffer"
+)
+
+func (fs *FilerServer) VerifyMetadata(ctx context.Context, req *filer_pb.VerifyMetadataRequest) (*filer_pb.VerifyMetadataResponse, error) {
+
+	if req.Apply {
+		if err := fs.checkWritable(); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := fs.verifyMetadata(ctx, req.SinceNs, req.PathPrefix, int(req.SampleSize), req.Apply)
+	if err != nil {
+		return &filer_pb.VerifyMetadataResponse{Error: err.Error()}, nil
+	}
+	return resp, nil
+}
+
+// verifyMetadata replays the meta log events since sinceNs, and checks the filer store has the last state of each path.
+func (fs *FilerServer) verifyMetadata(ctx context.Context, sinceNs int64, pathPrefix string, sampleSize int, apply bool) (*filer_pb.VerifyMetadataResponse, error) {
+
+	untilNs := time.Now().UnixNano()
+	verifier := filer.NewMetaVerifier(pathPrefix)
+	if err := fs.readMetaEvents(sinceNs, untilNs, verifier.AddEvent); err != nil {
+		return nil, fmt.Errorf("read meta log: %v", err)
+	}
+
+	divergences, err := verifier.Verify(ctx, fs.filer.Store)
+	if err != nil {
+		return nil, fmt.Errorf("verify store: %v", err)
+	}
+	var sampled int
+	if sampleSize > 0 {
+		var unlogged []*filer_pb.MetaDivergence
+		sampled, unlogged, err = verifier.VerifySample(ctx, fs.filer.Store, sinceNs, untilNs, sampleSize)
+		if err != nil {
+			return nil, fmt.Errorf("sample store: %v", err)
+		}
+		divergences = append(divergences, unlogged...)
+	}
+
+	// the paths changed during the verification are not diverged
+	if len(divergences) > 0 {
+		changed := filer.NewMetaVerifier(pathPrefix)
+		if err = fs.readMetaEvents(untilNs, time.Now().UnixNano(), changed.AddEvent); err != nil {
+			return nil, fmt.Errorf("read meta log: %v", err)
+		}
+		divergences = verifier.ExcludeChanged(divergences, changed)
+	}
+
+	// the periodic verification only reports the divergences while the filer is read only
+	if apply && !fs.isReadOnly() {
+		for _, divergence := range divergences {
+			if divergence.Kind == filer.MetaUnlogged {
+				continue
+			}
+			if applyErr := verifier.Apply(ctx, fs.filer.Store, divergence); applyErr != nil {
+				divergence.Error = applyErr.Error()
+			} else {
+				divergence.Applied = true
+			}
+		}
+	}
+
+	return &filer_pb.VerifyMetadataResponse{
+		EventCount:   verifier.EventCount,
+		PathCount:    int64(verifier.PathCount()),
+		SampledCount: int64(sampled),
+		Divergences:  divergences,
+	}, nil
+}
+
+// readMetaEvents reads the persisted and then the in memory meta log events in (sinceNs, untilNs]
+func (fs *FilerServer) readMetaEvents(sinceNs, untilNs int64, eachEventFn func(event *filer_pb.SubscribeMetadataResponse)) error {
+
+	eachLogEntryFn := func(logEntry *filer_pb.LogEntry) error {
+		event := &filer_pb.SubscribeMetadataResponse{}
+		if err := proto.Unmarshal(logEntry.Data, event); err != nil {
+			return fmt.Errorf("unexpected unmarshal filer_pb.SubscribeMetadataResponse: %v", err)
+		}
+		eachEventFn(event)
+		return nil
+	}
+
+	logBuffer := fs.filer.LocalMetaLogBuffer
+	if fs.filer.MetaAggregator != nil {
+		logBuffer = fs.filer.MetaAggregator.MetaLogBuffer
+	}
+
+	lastReadTime := time.Unix(0, sinceNs)
+	for {
+		processedTsNs, _, err := fs.filer.ReadPersistedLogBuffer(lastReadTime, untilNs, eachLogEntryFn)
+		if err != nil {
+			return fmt.Errorf("reading from persisted logs: %v", err)
+		}
+		if processedTsNs != 0 {
+			lastReadTime = time.Unix(0, processedTsNs)
+		}
+
+		_, _, err = logBuffer.LoopProcessLogData("verifyMeta", lastReadTime, untilNs, func() bool {
+			return false
+		}, eachLogEntryFn)
+		if err == log_buffer.ResumeFromDiskError && processedTsNs != 0 {
+			// flushed to disk while reading
+			continue
+		}
+		return err
+	}
+}
+
+// loopVerifyMetadata verifies the meta log events of each interval, and logs the divergences
+func (fs *FilerServer) loopVerifyMetadata(interval time.Duration, apply bool) {
+	sinceNs := time.Now().UnixNano()
+	for {
+		time.Sleep(interval)
+		untilNs := time.Now().UnixNano()
+		resp, err := fs.verifyMetadata(context.Background(), sinceNs, "/", 0, apply)
+		if err != nil {
+			glog.Errorf("verify metadata since %v: %v", time.Unix(0, sinceNs), err)
+			continue
+		}
+		for _, divergence := range resp.Divergences {
+			glog.Warningf("verify metadata: %s %s at %v applied:%v %s", divergence.Kind, divergence.Path, time.Unix(0, divergence.TsNs), divergence.Applied, divergence.Error)
+		}
+		glog.V(1).Infof("verify metadata: %d events of %d paths since %v, %d divergences", resp.EventCount, resp.PathCount, time.Unix(0, sinceNs), len(resp.Divergences))
+		sinceNs = untilNs
+	}
+}
@@ -19,8 +19,20 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
+func (fs *FilerServer) RemoteCacheStatistics(ctx context.Context, req *filer_pb.RemoteCacheStatisticsRequest) (*filer_pb.RemoteCacheStatisticsResponse, error) {
+	mounts, since := fs.filer.RemoteCacheStats.Collect(int(req.TopMissedDirectories))
+	return &filer_pb.RemoteCacheStatisticsResponse{
+		Mounts:  mounts,
+		SinceNs: since.UnixNano(),
+	}, nil
+}
+
 func (fs *FilerServer) CacheRemoteObjectToLocalCluster(ctx context.Context, req *filer_pb.CacheRemoteObjectToLocalClusterRequest) (*filer_pb.CacheRemoteObjectToLocalClusterResponse, error) {
 
+	if err := fs.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	// load all mappings
 	mappingEntry, err := fs.filer.FindEntry(ctx, util.JoinPath(filer.DirectoryEtcRemote, filer.REMOTE_STORAGE_MOUNT_FILE))
 	if err != nil {
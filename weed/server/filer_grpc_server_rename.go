@@ -14,6 +14,10 @@ import (
 
 func (fs *FilerServer) AtomicRenameEntry(ctx context.Context, req *filer_pb.AtomicRenameEntryRequest) (*filer_pb.AtomicRenameEntryResponse, error) {
 
+	if err := fs.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	glog.V(1).Infof("AtomicRenameEntry %v", req)
 
 	oldParent := util.FullPath(filepath.ToSlash(req.OldDirectory))
@@ -33,6 +37,10 @@ func (fs *FilerServer) AtomicRenameEntry(ctx context.Context, req *filer_pb.Atom
 		fs.filer.RollbackTransaction(ctx)
 		return nil, fmt.Errorf("%s/%s not found: %v", req.OldDirectory, req.OldName, err)
 	}
+	if err = fs.filer.CheckObjectLockForDelete(ctx, oldEntry, false); err != nil {
+		fs.filer.RollbackTransaction(ctx)
+		return nil, err
+	}
 
 	moveErr := fs.moveEntry(ctx, nil, oldParent, oldEntry, newParent, req.NewName, req.Signatures)
 	if moveErr != nil {
@@ -50,6 +58,10 @@ func (fs *FilerServer) AtomicRenameEntry(ctx context.Context, req *filer_pb.Atom
 
 func (fs *FilerServer) StreamRenameEntry(req *filer_pb.StreamRenameEntryRequest, stream filer_pb.SeaweedFiler_StreamRenameEntryServer) (err error) {
 
+	if err := fs.checkWritable(); err != nil {
+		return err
+	}
+
 	glog.V(1).Infof("StreamRenameEntry %v", req)
 
 	oldParent := util.FullPath(filepath.ToSlash(req.OldDirectory))
@@ -71,6 +83,10 @@ func (fs *FilerServer) StreamRenameEntry(req *filer_pb.StreamRenameEntryRequest,
 		fs.filer.RollbackTransaction(ctx)
 		return fmt.Errorf("%s/%s not found: %v", req.OldDirectory, req.OldName, err)
 	}
+	if err = fs.filer.CheckObjectLockForDelete(ctx, oldEntry, false); err != nil {
+		fs.filer.RollbackTransaction(ctx)
+		return err
+	}
 
 	if oldEntry.IsDirectory() {
 		// follow https://pubs.opengroup.org/onlinepubs/000095399/functions/rename.html
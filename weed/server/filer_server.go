@@ -11,6 +11,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
+	"github.com/seaweedfs/seaweedfs/weed/audit"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	_ "github.com/seaweedfs/seaweedfs/weed/filer/leveldb"
 	_ "github.com/seaweedfs/seaweedfs/weed/filer/leveldb2"
@@ -28,6 +29,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/security"
 	"github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/chunk_cache"
 	"github.com/seaweedfs/seaweedfs/weed/util/grace"
 )
 
@@ -51,11 +53,27 @@ type FilerOption struct {
 	ConcurrentUploadLimit int64
 	ShowUIDirectoryDelete bool
 	DownloadMaxBytesPs    int64
+	ChunkCacheOption      *chunk_cache.TieredChunkCacheOption
+	ChecksumSha256        bool
+	VerifyChecksumOnRead  bool
+	EncryptedRangeReads   bool
+	Audit                 audit.Option
+	SlowLogThresholds     string
+	MetaVerifyInterval    time.Duration
+	MetaVerifyApply       bool
+	PackSmallFileLimit    int64
+	PackContainerSize     int64
+	PackWait              time.Duration
+	ReadOnly              bool
+	ArchiveMaxSize        int64
+	ArchiveMaxFiles       int
 }
 
 type FilerServer struct {
 	inFlightDataSize      int64
 	inFlightDataLimitCond *sync.Cond
+	// 1 while the filer rejects the changes, toggled at runtime by SetReadOnly
+	readOnly int32
 
 	filer_pb.UnimplementedSeaweedFilerServer
 	option         *FilerOption
@@ -63,6 +81,8 @@ type FilerServer struct {
 	filer          *filer.Filer
 	filerGuard     *security.Guard
 	grpcDialOption grpc.DialOption
+	// verifies the admin requests, e.g. changing the log levels
+	adminSigningKey security.SigningKey
 
 	// metrics read from the master
 	metricsAddress     string
@@ -75,6 +95,20 @@ type FilerServer struct {
 	// track known metadata listeners
 	knownListenersLock sync.Mutex
 	knownListeners     map[int32]int32
+
+	// serialize appends and conditional writes to the same entry
+	entryLocks *util.StripedLock
+	// serialize the requests of one resumable upload, held while streaming the request body
+	uploadLocks *util.KeyedLock
+
+	// caches the chunks of proxied reads, nil if disabled
+	chunkCache chunk_cache.ChunkCache
+	// logs the requests to the SIEM, nil if disabled
+	auditLog *audit.Logger
+	// logs the slow requests, nil if disabled
+	slowLog *stats.SlowRequestLog
+	// packs the small files into container chunks, nil if disabled
+	packer *smallFilePacker
 }
 
 func NewFilerServer(defaultMux, readonlyMux *http.ServeMux, option *FilerOption) (fs *FilerServer, err error) {
@@ -93,8 +127,16 @@ func NewFilerServer(defaultMux, readonlyMux *http.ServeMux, option *FilerOption)
 		grpcDialOption:        grpc.WithTransportCredentials(insecure.NewCredentials()),
 		knownListeners:        make(map[int32]int32),
 		inFlightDataLimitCond: sync.NewCond(new(sync.Mutex)),
+		entryLocks:            util.NewStripedLock(),
+		uploadLocks:           util.NewKeyedLock(),
 	}
 	fs.listenersCond = sync.NewCond(&fs.listenersLock)
+	if fs.auditLog, err = audit.NewLogger("filer", option.Audit); err != nil {
+		return nil, err
+	}
+	if fs.slowLog, err = stats.NewSlowRequestLog("filer", option.SlowLogThresholds); err != nil {
+		return nil, err
+	}
 
 	if len(option.Masters) == 0 {
 		glog.Fatal("master list is required!")
@@ -104,8 +146,20 @@ func NewFilerServer(defaultMux, readonlyMux *http.ServeMux, option *FilerOption)
 		fs.listenersCond.Broadcast()
 	})
 	fs.filer.Cipher = option.Cipher
+	if cacheOption := option.ChunkCacheOption; cacheOption != nil && cacheOption.Dir != "" && cacheOption.DiskSize > 0 {
+		if err := os.MkdirAll(cacheOption.Dir, 0755); err != nil {
+			glog.Fatalf("create chunk cache dir %s: %v", cacheOption.Dir, err)
+		}
+		chunkCache := chunk_cache.NewTieredChunkCacheWithOption(cacheOption)
+		grace.OnInterrupt(chunkCache.Shutdown)
+		fs.chunkCache = chunkCache
+		glog.V(0).Infof("cache chunks in %s, capacity %d MB", cacheOption.Dir, cacheOption.DiskSize/1024/1024)
+	}
 	// we do not support IP whitelist right now
 	fs.filerGuard = security.NewGuard([]string{}, signingKey, expiresAfterSec, readSigningKey, readExpiresAfterSec)
+	fs.adminSigningKey = security.SigningKey(v.GetString("jwt.master_admin.key"))
+	fs.filer.SigningKey = security.SigningKey(signingKey)
+	util.EncryptedRangeReads = option.EncryptedRangeReads
 
 	fs.checkWithMaster()
 
@@ -137,11 +191,11 @@ func NewFilerServer(defaultMux, readonlyMux *http.ServeMux, option *FilerOption)
 
 	handleStaticResources(defaultMux)
 	if !option.DisableHttp {
-		defaultMux.HandleFunc("/", fs.filerHandler)
+		defaultMux.HandleFunc("/", fs.withAudit(fs.filerHandler))
 	}
 	if defaultMux != readonlyMux {
 		handleStaticResources(readonlyMux)
-		readonlyMux.HandleFunc("/", fs.readonlyFilerHandler)
+		readonlyMux.HandleFunc("/", fs.withAudit(fs.readonlyFilerHandler))
 	}
 
 	existingNodes := fs.filer.ListExistingPeerUpdates()
@@ -158,6 +212,21 @@ func NewFilerServer(defaultMux, readonlyMux *http.ServeMux, option *FilerOption)
 
 	fs.filer.LoadRemoteStorageConfAndMapping()
 
+	go fs.loopDeleteExpiredResumableUploads()
+	if option.MetaVerifyInterval > 0 {
+		go fs.loopVerifyMetadata(option.MetaVerifyInterval, option.MetaVerifyApply)
+	}
+	if option.ReadOnly {
+		fs.setReadOnly(true)
+	}
+	if option.PackSmallFileLimit > 0 {
+		if option.Cipher {
+			glog.Warningf("small file packing is disabled with encryptVolumeData")
+		} else {
+			fs.packer = newSmallFilePacker(fs)
+		}
+	}
+
 	grace.OnInterrupt(func() {
 		fs.filer.Shutdown()
 	})
@@ -169,7 +238,7 @@ func (fs *FilerServer) checkWithMaster() {
 
 	isConnected := false
 	for !isConnected {
-		for _, master := range fs.option.Masters {
+		for _, master := range rpc.ResolveServerAddressMap(fs.option.Masters) {
 			readErr := operation.WithMasterServerClient(false, master, fs.grpcDialOption, func(masterClient master_pb.SeaweedClient) error {
 				resp, err := masterClient.GetMasterConfiguration(context.Background(), &master_pb.GetMasterConfigurationRequest{})
 				if err != nil {
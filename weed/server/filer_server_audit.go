@@ -0,0 +1,73 @@
+package weed_server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/peer"
+
+	"github.com/seaweedfs/seaweedfs/weed/audit"
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+)
+
+// withAudit logs the failed jwt checks, the changes to the configuration under /etc/, and the file access.
+func (fs *FilerServer) withAudit(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if fs.auditLog == nil || r.Method == http.MethodOptions {
+			handler(w, r)
+			return
+		}
+		sw := &statsResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		handler(sw, r)
+
+		isRead := r.Method == http.MethodGet || r.Method == http.MethodHead
+		e := &audit.Event{
+			Time:     start,
+			RemoteIp: r.RemoteAddr,
+			Method:   r.Method,
+			Path:     r.URL.Path,
+			Status:   sw.status,
+			Duration: time.Since(start).String(),
+		}
+		switch {
+		case sw.status == http.StatusUnauthorized:
+			e.Type = audit.TypeAuth
+		case !isRead && strings.HasPrefix(r.URL.Path, filer.DirectoryEtcRoot):
+			e.Type = audit.TypeAdmin
+		default:
+			e.Type = audit.TypeAccess
+		}
+		switch {
+		case sw.status == http.StatusUnauthorized || sw.status == http.StatusForbidden:
+			e.Result = audit.ResultDenied
+		case sw.status >= http.StatusBadRequest:
+			e.Result = audit.ResultFailed
+		default:
+			e.Result = audit.ResultAllowed
+		}
+		fs.auditLog.Log(e)
+	}
+}
+
+// auditGrpcChange logs the changes to the configuration under /etc/ made through grpc, e.g. by fs.configure or s3.configure.
+func (fs *FilerServer) auditGrpcChange(ctx context.Context, action, path string, err error) {
+	if fs.auditLog == nil || !strings.HasPrefix(path, filer.DirectoryEtcRoot) {
+		return
+	}
+	e := &audit.Event{
+		Type:   audit.TypeAdmin,
+		Action: action,
+		Path:   path,
+		Result: audit.ResultAllowed,
+	}
+	if pr, ok := peer.FromContext(ctx); ok {
+		e.RemoteIp = pr.Addr.String()
+	}
+	if err != nil {
+		e.Result = audit.ResultFailed
+	}
+	fs.auditLog.Log(e)
+}
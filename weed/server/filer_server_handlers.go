@@ -25,6 +25,11 @@ func (fs *FilerServer) filerHandler(w http.ResponseWriter, r *http.Request) {
 		writeJsonError(w, r, http.StatusUnauthorized, errors.New("wrong jwt"))
 		return
 	}
+	if !isReadHttpCall && fs.isReadOnly() {
+		w.Header().Set("Retry-After", "60")
+		writeJsonError(w, r, http.StatusServiceUnavailable, errFilerReadOnly)
+		return
+	}
 
 	// proxy to volume servers
 	var fileId string
@@ -35,11 +40,13 @@ func (fs *FilerServer) filerHandler(w http.ResponseWriter, r *http.Request) {
 		stats.FilerRequestCounter.WithLabelValues(stats.ChunkProxy).Inc()
 		fs.proxyToVolumeServer(w, r, fileId)
 		stats.FilerRequestHistogram.WithLabelValues(stats.ChunkProxy).Observe(time.Since(start).Seconds())
+		fs.slowLog.Record(stats.ChunkProxy, time.Since(start), &stats.SlowRequest{Fid: fileId, Peer: r.RemoteAddr})
 		return
 	}
 	stats.FilerRequestCounter.WithLabelValues(r.Method).Inc()
 	defer func() {
 		stats.FilerRequestHistogram.WithLabelValues(r.Method).Observe(time.Since(start).Seconds())
+		fs.recordSlowRequest(r, start)
 	}()
 
 	w.Header().Set("Server", "SeaweedFS Filer "+util.Version())
@@ -48,18 +55,33 @@ func (fs *FilerServer) filerHandler(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 	}
+	_, isXattr := r.URL.Query()["xattr"]
 	switch r.Method {
 	case "GET":
-		fs.GetOrHeadHandler(w, r)
+		if isXattr {
+			fs.GetXattrHandler(w, r)
+		} else {
+			fs.GetOrHeadHandler(w, r)
+		}
 	case "HEAD":
-		fs.GetOrHeadHandler(w, r)
+		if isResumableUpload(r) {
+			fs.resumableUploadHeadHandler(w, r)
+		} else if isXattr {
+			fs.GetXattrHandler(w, r)
+		} else {
+			fs.GetOrHeadHandler(w, r)
+		}
 	case "DELETE":
 		if _, ok := r.URL.Query()["tagging"]; ok {
 			fs.DeleteTaggingHandler(w, r)
+		} else if isXattr {
+			fs.DeleteXattrHandler(w, r)
+		} else if isResumableUpload(r) {
+			fs.resumableUploadDeleteHandler(w, r)
 		} else {
 			fs.DeleteHandler(w, r)
 		}
-	case "POST", "PUT":
+	case "POST", "PUT", "PATCH":
 		// wait until in flight data is less than the limit
 		contentLength := getContentLength(r)
 		fs.inFlightDataLimitCond.L.Lock()
@@ -79,9 +101,15 @@ func (fs *FilerServer) filerHandler(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "PUT" {
 			if _, ok := r.URL.Query()["tagging"]; ok {
 				fs.PutTaggingHandler(w, r)
+			} else if isXattr {
+				fs.PutXattrHandler(w, r)
 			} else {
 				fs.PostHandler(w, r, contentLength)
 			}
+		} else if r.Method == "PATCH" {
+			fs.resumableUploadPatchHandler(w, r)
+		} else if isResumableUpload(r) {
+			fs.resumableUploadCreateHandler(w, r)
 		} else { // method == "POST"
 			fs.PostHandler(w, r, contentLength)
 		}
@@ -94,6 +122,7 @@ func (fs *FilerServer) readonlyFilerHandler(w http.ResponseWriter, r *http.Reque
 	stats.FilerRequestCounter.WithLabelValues(r.Method).Inc()
 	defer func() {
 		stats.FilerRequestHistogram.WithLabelValues(r.Method).Observe(time.Since(start).Seconds())
+		fs.recordSlowRequest(r, start)
 	}()
 	// We handle OPTIONS first because it never should be authenticated
 	if r.Method == "OPTIONS" {
@@ -111,11 +140,14 @@ func (fs *FilerServer) readonlyFilerHandler(w http.ResponseWriter, r *http.Reque
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 	}
+	_, isXattr := r.URL.Query()["xattr"]
 	switch r.Method {
-	case "GET":
-		fs.GetOrHeadHandler(w, r)
-	case "HEAD":
-		fs.GetOrHeadHandler(w, r)
+	case "GET", "HEAD":
+		if isXattr {
+			fs.GetXattrHandler(w, r)
+		} else {
+			fs.GetOrHeadHandler(w, r)
+		}
 	}
 }
 
@@ -123,7 +155,10 @@ func OptionsHandler(w http.ResponseWriter, r *http.Request, isReadOnly bool) {
 	if isReadOnly {
 		w.Header().Add("Access-Control-Allow-Methods", "GET, OPTIONS")
 	} else {
-		w.Header().Add("Access-Control-Allow-Methods", "PUT, POST, GET, DELETE, OPTIONS")
+		w.Header().Add("Access-Control-Allow-Methods", "PUT, POST, PATCH, GET, DELETE, OPTIONS")
+		w.Header().Set("Tus-Resumable", TusResumableVersion)
+		w.Header().Set("Tus-Version", TusResumableVersion)
+		w.Header().Set("Tus-Extension", TusExtensions)
 	}
 	w.Header().Add("Access-Control-Allow-Headers", "*")
 }
@@ -165,3 +200,14 @@ func (fs *FilerServer) maybeCheckJwtAuthorization(r *http.Request, isWrite bool)
 		return true
 	}
 }
+
+func (fs *FilerServer) recordSlowRequest(r *http.Request, start time.Time) {
+	slowRequest := &stats.SlowRequest{
+		Path: r.URL.Path,
+		Peer: r.RemoteAddr,
+	}
+	if r.ContentLength > 0 {
+		slowRequest.Size = r.ContentLength
+	}
+	fs.slowLog.Record(r.Method, time.Since(start), slowRequest)
+}
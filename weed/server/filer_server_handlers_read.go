@@ -1,8 +1,12 @@
 package weed_server
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
 	"fmt"
+	"hash"
 	"io"
 	"math"
 	"mime"
@@ -90,6 +94,10 @@ func (fs *FilerServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 	entry, err := fs.filer.FindEntry(context.Background(), util.FullPath(path))
 	if err != nil {
 		if path == "/" {
+			if isArchiveRequest(r) {
+				fs.archiveDirectoryHandler(w, r, util.FullPath(path))
+				return
+			}
 			fs.listDirectoryHandler(w, r)
 			return
 		}
@@ -110,6 +118,10 @@ func (fs *FilerServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
+		if isArchiveRequest(r) {
+			fs.archiveDirectoryHandler(w, r, entry.FullPath)
+			return
+		}
 		if entry.Attr.Mime == "" {
 			fs.listDirectoryHandler(w, r)
 			return
@@ -133,7 +145,7 @@ func (fs *FilerServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 				writeJsonError(w, r, http.StatusInternalServerError, err)
 			}
 		}
-		writeJsonQuiet(w, r, http.StatusOK, entry)
+		writeJsonQuiet(w, r, http.StatusOK, fs.entryForClient(entry))
 		return
 	}
 
@@ -157,12 +169,14 @@ func (fs *FilerServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 
 	// print out the header from extended properties
 	for k, v := range entry.Extended {
-		if !strings.HasPrefix(k, "xattr-") {
+		if !strings.HasPrefix(k, "xattr-") && k != s3_constants.X_SeaweedFS_Multipart_Parts {
 			// "xattr-" prefix is set in filesys.XATTR_PREFIX
 			w.Header().Set(k, string(v))
 		}
 	}
 
+	setChunkEncodingHeaders(w, entry)
+
 	//Seaweed custom header are not visible to Vue or javascript
 	seaweedHeaders := []string{}
 	for header := range w.Header() {
@@ -185,6 +199,7 @@ func (fs *FilerServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	setEtag(w, etag)
+	setChecksumHeaders(w, r, entry)
 
 	filename := entry.Name()
 	adjustPassthroughHeaders(w, r, filename)
@@ -196,16 +211,24 @@ func (fs *FilerServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	processRangeRequest(r, w, totalSize, mimeType, func(writer io.Writer, offset int64, size int64) error {
+	processRangeRequest(r, w, totalSize, mimeType, func(offset int64, size int64) (filer.DoStreamContent, error) {
 		if offset+size <= int64(len(entry.Content)) {
-			_, err := writer.Write(entry.Content[offset : offset+size])
-			if err != nil {
-				stats.FilerRequestCounter.WithLabelValues(stats.ErrorWriteEntry).Inc()
-				glog.Errorf("failed to write entry content: %v", err)
-			}
-			return err
+			return fs.verifyingWriteFn(entry, offset, size, totalSize, func(writer io.Writer) error {
+				_, err := writer.Write(entry.Content[offset : offset+size])
+				if err != nil {
+					stats.FilerRequestCounter.WithLabelValues(stats.ErrorWriteEntry).Inc()
+					glog.Errorf("failed to write entry content: %v", err)
+				}
+				return err
+			}), nil
 		}
 		chunks := entry.Chunks
+		if entry.Remote != nil {
+			if mountDir, _ := fs.filer.RemoteStorage.FindMountDirectory(entry.FullPath); mountDir != "" {
+				dir, _ := entry.FullPath.DirAndName()
+				fs.filer.RemoteCacheStats.RecordRead(mountDir, util.FullPath(dir), size, !entry.IsInRemoteOnly())
+			}
+		}
 		if entry.IsInRemoteOnly() {
 			dir, name := entry.FullPath.DirAndName()
 			if resp, err := fs.CacheRemoteObjectToLocalCluster(context.Background(), &filer_pb.CacheRemoteObjectToLocalClusterRequest{
@@ -214,17 +237,109 @@ func (fs *FilerServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 			}); err != nil {
 				stats.FilerRequestCounter.WithLabelValues(stats.ErrorReadCache).Inc()
 				glog.Errorf("CacheRemoteObjectToLocalCluster %s: %v", entry.FullPath, err)
-				return fmt.Errorf("cache %s: %v", entry.FullPath, err)
+				return nil, fmt.Errorf("cache %s: %v", entry.FullPath, err)
 			} else {
 				chunks = resp.Entry.Chunks
 			}
 		}
 
-		err = filer.StreamContentWithThrottler(fs.filer.MasterClient, writer, chunks, offset, size, fs.option.DownloadMaxBytesPs)
+		streamFn, err := filer.PrepareStreamContentWithThrottler(fs.filer.MasterClient, chunks, offset, size, fs.option.DownloadMaxBytesPs, fs.chunkCache)
 		if err != nil {
 			stats.FilerRequestCounter.WithLabelValues(stats.ErrorReadStream).Inc()
-			glog.Errorf("failed to stream content %s: %v", r.URL, err)
+			glog.Errorf("failed to prepare stream content %s: %v", r.URL, err)
+			return nil, err
 		}
-		return err
+		return fs.verifyingWriteFn(entry, offset, size, totalSize, func(writer io.Writer) error {
+			err := streamFn(writer)
+			if err != nil {
+				stats.FilerRequestCounter.WithLabelValues(stats.ErrorReadStream).Inc()
+				glog.Errorf("failed to stream content %s: %v", r.URL, err)
+			}
+			return err
+		}), nil
 	})
 }
+
+// verifyingWriteFn verifies the checksums of the entry when the whole content is read.
+func (fs *FilerServer) verifyingWriteFn(entry *filer.Entry, offset, size, totalSize int64, writeFn filer.DoStreamContent) filer.DoStreamContent {
+	if !fs.option.VerifyChecksumOnRead || offset != 0 || size != totalSize {
+		return writeFn
+	}
+	return func(writer io.Writer) error {
+		verifyingWriter := newChecksumVerifyingWriter(writer, entry, size)
+		if verifyingWriter == nil {
+			return writeFn(writer)
+		}
+		defer func() {
+			if verifyingWriter.isMismatched {
+				stats.FilerRequestCounter.WithLabelValues(stats.ErrorReadChecksum).Inc()
+				glog.Errorf("read %s: checksum mismatch", entry.FullPath)
+				// cut the response short, without its last bytes, to fail the client
+				panic(http.ErrAbortHandler)
+			}
+		}()
+		return writeFn(verifyingWriter)
+	}
+}
+
+// the encodings of the stored chunks, while the content is always served decoded
+const (
+	chunkCompressionHeader = "Seaweed-Chunk-Compression"
+	chunkEncryptionHeader  = "Seaweed-Chunk-Encryption"
+)
+
+// setChunkEncodingHeaders tells whether the chunks of the entry, or some of them, are stored compressed or encrypted.
+// With -encryptedRangeReads, the range reads of the encrypted chunks only fetch and decrypt the part of each chunk.
+func setChunkEncodingHeaders(w http.ResponseWriter, entry *filer.Entry) {
+	var isCompressed, isEncrypted bool
+	for _, chunk := range entry.Chunks {
+		isCompressed = isCompressed || chunk.IsCompressed
+		isEncrypted = isEncrypted || len(chunk.CipherKey) > 0
+	}
+	if isCompressed {
+		w.Header().Set(chunkCompressionHeader, "gzip")
+	}
+	if isEncrypted {
+		w.Header().Set(chunkEncryptionHeader, "AES256-GCM")
+	}
+}
+
+// setChecksumHeaders returns the checksums of the whole content saved in the entry.
+func setChecksumHeaders(w http.ResponseWriter, r *http.Request, entry *filer.Entry) {
+	if len(entry.Attr.Md5) > 0 && r.Header.Get("Range") == "" {
+		w.Header().Set("Content-MD5", util.Base64Encode(entry.Attr.Md5))
+	}
+	if len(entry.Attr.Sha256) > 0 && w.Header().Get(s3_constants.AmzChecksumSHA256) == "" {
+		w.Header().Set(s3_constants.AmzChecksumSHA256, util.Base64Encode(entry.Attr.Sha256))
+	}
+}
+
+// checksumVerifyingWriter hashes the whole content as it is written,
+// and holds back the last write until the checksum is verified.
+type checksumVerifyingWriter struct {
+	writer       io.Writer
+	hash         hash.Hash
+	expected     []byte
+	remaining    int64
+	isMismatched bool
+}
+
+func newChecksumVerifyingWriter(writer io.Writer, entry *filer.Entry, size int64) *checksumVerifyingWriter {
+	if len(entry.Attr.Sha256) > 0 {
+		return &checksumVerifyingWriter{writer: writer, hash: sha256.New(), expected: entry.Attr.Sha256, remaining: size}
+	}
+	if len(entry.Attr.Md5) > 0 {
+		return &checksumVerifyingWriter{writer: writer, hash: md5.New(), expected: entry.Attr.Md5, remaining: size}
+	}
+	return nil
+}
+
+func (cw *checksumVerifyingWriter) Write(p []byte) (int, error) {
+	cw.hash.Write(p)
+	cw.remaining -= int64(len(p))
+	if cw.remaining <= 0 && !bytes.Equal(cw.hash.Sum(nil), cw.expected) {
+		cw.isMismatched = true
+		return 0, ErrChecksumMismatch
+	}
+	return cw.writer.Write(p)
+}
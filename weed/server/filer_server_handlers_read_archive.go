@@ -0,0 +1,299 @@
+package weed_server
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// the formats of GET /path/to/dir/?archive=
+const (
+	archiveTar = "tar"
+	archiveZip = "zip"
+)
+
+var errArchiveTooLarge = errors.New("the directory is too large to archive")
+
+type archiveEntry struct {
+	name  string // relative to the archived directory
+	entry *filer.Entry
+}
+
+// archiveFilter selects the files by name with the include and exclude wildcard patterns.
+// The sub directories are always walked.
+type archiveFilter struct {
+	includes []string
+	excludes []string
+}
+
+func newArchiveFilter(includes, excludes []string) (*archiveFilter, error) {
+	for _, pattern := range append(append([]string{}, includes...), excludes...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("pattern %q: %v", pattern, err)
+		}
+	}
+	return &archiveFilter{includes: includes, excludes: excludes}, nil
+}
+
+func (f *archiveFilter) matches(name string) bool {
+	for _, pattern := range f.excludes {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return false
+		}
+	}
+	if len(f.includes) == 0 {
+		return true
+	}
+	for _, pattern := range f.includes {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveDirectoryHandler streams the files under the directory as a tar or zip archive.
+// The files are selected by name with the "include" and "exclude" wildcard patterns, each can be repeated.
+// The directory is rejected with 413 when the files are over the archive size or count limits,
+// checked before streaming. The content of each file is streamed from its chunks, without buffering.
+func (fs *FilerServer) archiveDirectoryHandler(w http.ResponseWriter, r *http.Request, dir util.FullPath) {
+
+	stats.FilerRequestCounter.WithLabelValues(stats.DirArchive).Inc()
+
+	if fs.option.DisableDirListing || fs.option.ArchiveMaxSize <= 0 {
+		writeJsonError(w, r, http.StatusForbidden, errors.New("directory archive is disabled"))
+		return
+	}
+	query := r.URL.Query()
+	format := query.Get("archive")
+	if format != archiveTar && format != archiveZip {
+		writeJsonError(w, r, http.StatusBadRequest, fmt.Errorf("unsupported archive %q, use tar or zip", format))
+		return
+	}
+	filter, err := newArchiveFilter(query["include"], query["exclude"])
+	if err != nil {
+		writeJsonError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	entries, err := fs.collectArchiveEntries(r.Context(), dir, filter)
+	if err == errArchiveTooLarge {
+		writeJsonError(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("%v, the limits are %d bytes and %d files", err, fs.option.ArchiveMaxSize, fs.option.ArchiveMaxFiles))
+		return
+	}
+	if err != nil {
+		glog.Errorf("archive %s: %v", dir, err)
+		writeJsonError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	archiveName := dir.Name()
+	if archiveName == "" {
+		archiveName = "root"
+	}
+	if format == archiveZip {
+		w.Header().Set("Content-Type", "application/zip")
+	} else {
+		w.Header().Set("Content-Type", "application/x-tar")
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+fileNameEscaper.Replace(archiveName+"."+format)+`"`)
+	if r.Method == "HEAD" {
+		return
+	}
+
+	bufferedWriter := bufio.NewWriterSize(w, 128*1024)
+	if err = fs.writeArchive(bufferedWriter, format, entries); err == nil {
+		err = bufferedWriter.Flush()
+	}
+	if err != nil {
+		stats.FilerRequestCounter.WithLabelValues(stats.ErrorReadStream).Inc()
+		glog.Errorf("archive %s: %v", dir, err)
+		// cut the archive short to fail the client
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// collectArchiveEntries lists the directories and the matched files under the directory, depth first and sorted by name.
+func (fs *FilerServer) collectArchiveEntries(ctx context.Context, dir util.FullPath, filter *archiveFilter) (entries []*archiveEntry, err error) {
+	var totalSize int64
+	var totalCount int
+	var walk func(dir util.FullPath, prefix string) error
+	walk = func(dir util.FullPath, prefix string) error {
+		var subDirs []*archiveEntry
+		lastFileName, inclusive := "", false
+		for {
+			var listedCount int64
+			var limitErr error
+			lastFileName, err = fs.filer.StreamListDirectoryEntries(ctx, dir, lastFileName, inclusive, filer.PaginationSize, "", "", "", func(entry *filer.Entry) bool {
+				listedCount++
+				name := prefix + entry.Name()
+				if entry.IsDirectory() {
+					subDirs = append(subDirs, &archiveEntry{name: name, entry: entry})
+					// all directories count, so walking a tree of empty directories is limited too
+					totalCount++
+					if fs.option.ArchiveMaxFiles > 0 && totalCount > fs.option.ArchiveMaxFiles {
+						limitErr = errArchiveTooLarge
+						return false
+					}
+					return true
+				}
+				if !filter.matches(entry.Name()) {
+					return true
+				}
+				totalSize += int64(entry.Size())
+				totalCount++
+				entries = append(entries, &archiveEntry{name: name, entry: entry})
+				if totalSize > fs.option.ArchiveMaxSize || (fs.option.ArchiveMaxFiles > 0 && totalCount > fs.option.ArchiveMaxFiles) {
+					limitErr = errArchiveTooLarge
+					return false
+				}
+				return true
+			})
+			if limitErr != nil {
+				return limitErr
+			}
+			if err != nil {
+				return err
+			}
+			if listedCount < filer.PaginationSize {
+				break
+			}
+		}
+		for _, subDir := range subDirs {
+			// the directories are kept in the archive only without include patterns, to keep the empty ones
+			if len(filter.includes) == 0 {
+				entries = append(entries, subDir)
+			}
+			if err := walk(subDir.entry.FullPath, subDir.name+"/"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err = walk(dir, "")
+	return
+}
+
+func (fs *FilerServer) writeArchive(writer io.Writer, format string, entries []*archiveEntry) error {
+	var archive archiveWriter
+	if format == archiveZip {
+		archive = &zipArchiveWriter{zip.NewWriter(writer)}
+	} else {
+		archive = &tarArchiveWriter{tar.NewWriter(writer)}
+	}
+	for _, e := range entries {
+		contentWriter, err := archive.add(e.name, e.entry)
+		if err != nil {
+			return fmt.Errorf("add %s: %v", e.entry.FullPath, err)
+		}
+		if contentWriter == nil {
+			continue
+		}
+		if err = fs.writeEntryContent(contentWriter, e.entry); err != nil {
+			return fmt.Errorf("read %s: %v", e.entry.FullPath, err)
+		}
+	}
+	return archive.Close()
+}
+
+// writeEntryContent writes the whole content of the file, caching it from the remote storage if needed.
+func (fs *FilerServer) writeEntryContent(writer io.Writer, entry *filer.Entry) error {
+	size := int64(entry.Size())
+	if size <= int64(len(entry.Content)) {
+		_, err := writer.Write(entry.Content[:size])
+		return err
+	}
+	chunks := entry.Chunks
+	if entry.IsInRemoteOnly() && fs.isReadOnly() {
+		// caching the remote content changes the entry, refused while the filer is read only
+		return fs.filer.StreamRemoteContent(writer, entry, size)
+	}
+	if entry.IsInRemoteOnly() {
+		dir, name := entry.FullPath.DirAndName()
+		resp, err := fs.CacheRemoteObjectToLocalCluster(context.Background(), &filer_pb.CacheRemoteObjectToLocalClusterRequest{
+			Directory: dir,
+			Name:      name,
+		})
+		if err != nil {
+			stats.FilerRequestCounter.WithLabelValues(stats.ErrorReadCache).Inc()
+			return fmt.Errorf("cache %s: %v", entry.FullPath, err)
+		}
+		chunks = resp.Entry.Chunks
+	}
+	return filer.StreamContentWithThrottler(fs.filer.MasterClient, writer, chunks, 0, size, fs.option.DownloadMaxBytesPs, fs.chunkCache)
+}
+
+// archiveWriter adds the entries to the archive, and returns the writer of the file content, nil for the directories.
+type archiveWriter interface {
+	add(name string, entry *filer.Entry) (io.Writer, error)
+	Close() error
+}
+
+type tarArchiveWriter struct {
+	*tar.Writer
+}
+
+func (t *tarArchiveWriter) add(name string, entry *filer.Entry) (io.Writer, error) {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     int64(entry.Size()),
+		Mode:     int64(entry.Attr.Mode.Perm()),
+		ModTime:  entry.Attr.Mtime,
+		Uid:      int(entry.Attr.Uid),
+		Gid:      int(entry.Attr.Gid),
+	}
+	if entry.IsDirectory() {
+		header.Typeflag = tar.TypeDir
+		header.Name += "/"
+		header.Size = 0
+	}
+	if err := t.WriteHeader(header); err != nil {
+		return nil, err
+	}
+	if entry.IsDirectory() {
+		return nil, nil
+	}
+	return t.Writer, nil
+}
+
+type zipArchiveWriter struct {
+	*zip.Writer
+}
+
+func (z *zipArchiveWriter) add(name string, entry *filer.Entry) (io.Writer, error) {
+	header := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: entry.Attr.Mtime,
+	}
+	header.SetMode(entry.Attr.Mode.Perm())
+	if entry.IsDirectory() {
+		header.Name += "/"
+		header.Method = zip.Store
+		header.SetMode(entry.Attr.Mode.Perm() | os.ModeDir)
+	}
+	writer, err := z.CreateHeader(header)
+	if err != nil || entry.IsDirectory() {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// isArchiveRequest tells whether the directory is read as an archive.
+func isArchiveRequest(r *http.Request) bool {
+	return r.URL.Query().Has("archive")
+}
@@ -0,0 +1,123 @@
+package weed_server
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func testArchiveEntries() []*archiveEntry {
+	mtime := time.Unix(1600000000, 0)
+	file := func(name, content string) *archiveEntry {
+		return &archiveEntry{name: name, entry: &filer.Entry{
+			FullPath: util.FullPath("/dir/" + name),
+			Attr:     filer.Attr{Mtime: mtime, Mode: 0644, FileSize: uint64(len(content))},
+			Content:  []byte(content),
+		}}
+	}
+	return []*archiveEntry{
+		file("a.txt", "hello"),
+		{name: "sub", entry: &filer.Entry{FullPath: "/dir/sub", Attr: filer.Attr{Mtime: mtime, Mode: os.ModeDir | 0755}}},
+		file("sub/b.txt", "world"),
+	}
+}
+
+func TestArchiveFilter(t *testing.T) {
+	filter, err := newArchiveFilter([]string{"*.jpg", "*.png"}, []string{"tmp*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]bool{"a.jpg": true, "b.png": true, "c.txt": false, "tmp.jpg": false} {
+		if filter.matches(name) != expected {
+			t.Errorf("match %s: expected %v", name, expected)
+		}
+	}
+	if filter, _ = newArchiveFilter(nil, nil); !filter.matches("any") {
+		t.Errorf("expected all files without patterns")
+	}
+	if _, err = newArchiveFilter([]string{"[a"}, nil); err == nil {
+		t.Errorf("expected a bad pattern error")
+	}
+}
+
+func TestWriteTarArchive(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&FilerServer{}).writeArchive(&buf, archiveTar, testArchiveEntries()); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := tar.NewReader(&buf)
+	var names []string
+	contents := make(map[string]string)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+		content, _ := io.ReadAll(reader)
+		contents[header.Name] = string(content)
+	}
+	if len(names) != 3 || names[1] != "sub/" {
+		t.Fatalf("unexpected entries %v", names)
+	}
+	if contents["a.txt"] != "hello" || contents["sub/b.txt"] != "world" {
+		t.Errorf("unexpected contents %v", contents)
+	}
+}
+
+func TestWriteZipArchive(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&FilerServer{}).writeArchive(&buf, archiveZip, testArchiveEntries()); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reader.File) != 3 || !reader.File[1].FileInfo().IsDir() {
+		t.Fatalf("unexpected entries %v", reader.File)
+	}
+	f, _ := reader.File[2].Open()
+	content, _ := io.ReadAll(f)
+	if reader.File[2].Name != "sub/b.txt" || string(content) != "world" {
+		t.Errorf("unexpected %s: %q", reader.File[2].Name, content)
+	}
+}
+
+func TestCollectArchiveEntriesCountsDirectories(t *testing.T) {
+	fs := newTestFilerServer(t)
+	fs.option.ArchiveMaxSize = 1024 * 1024
+	fs.option.ArchiveMaxFiles = 5
+	ctx := context.Background()
+	for i := 0; i < 6; i++ {
+		dir := &filer.Entry{FullPath: util.FullPath(fmt.Sprintf("/dir/empty%d", i)), Attr: filer.Attr{Mode: os.ModeDir | 0755}}
+		if err := fs.filer.CreateEntry(ctx, dir, false, false, nil, false); err != nil {
+			t.Fatalf("create %s: %v", dir.FullPath, err)
+		}
+	}
+	filter, _ := newArchiveFilter(nil, nil)
+
+	// the empty directories count against the limit
+	if _, err := fs.collectArchiveEntries(ctx, "/dir", filter); err != errArchiveTooLarge {
+		t.Errorf("expected %v, actual %v", errArchiveTooLarge, err)
+	}
+	fs.option.ArchiveMaxFiles = 6
+	entries, err := fs.collectArchiveEntries(ctx, "/dir", filter)
+	if err != nil || len(entries) != 6 {
+		t.Errorf("expected 6 directories, actual %d %v", len(entries), err)
+	}
+}
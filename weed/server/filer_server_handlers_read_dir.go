@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	ui "github.com/seaweedfs/seaweedfs/weed/server/filer_ui"
 	"github.com/seaweedfs/seaweedfs/weed/stats"
@@ -16,6 +17,8 @@ import (
 // files are sorted by name and paginated via "lastFileName" and "limit".
 // sub directories are listed on the first page, when "lastFileName"
 // is empty.
+// With "sortBy=mtime" or "sortBy=size", only the first "limit" entries
+// are listed, the newest or largest first.
 func (fs *FilerServer) listDirectoryHandler(w http.ResponseWriter, r *http.Request) {
 
 	stats.FilerRequestCounter.WithLabelValues(stats.DirList).Inc()
@@ -34,7 +37,21 @@ func (fs *FilerServer) listDirectoryHandler(w http.ResponseWriter, r *http.Reque
 	namePattern := r.FormValue("namePattern")
 	namePatternExclude := r.FormValue("namePatternExclude")
 
-	entries, shouldDisplayLoadMore, err := fs.filer.ListDirectoryEntries(context.Background(), util.FullPath(path), lastFileName, false, int64(limit), "", namePattern, namePatternExclude)
+	var entries []*filer.Entry
+	var shouldDisplayLoadMore bool
+	var err error
+	if sortBy := r.FormValue("sortBy"); sortBy != filer.SortByName {
+		if err = filer.ValidateSortBy(sortBy); err != nil {
+			writeJsonError(w, r, http.StatusBadRequest, err)
+			return
+		}
+		err = fs.filer.ListDirectorySortedEntries(context.Background(), util.FullPath(path), "", sortBy, int64(limit), func(entry *filer.Entry) bool {
+			entries = append(entries, entry)
+			return true
+		})
+	} else {
+		entries, shouldDisplayLoadMore, err = fs.filer.ListDirectoryEntries(context.Background(), util.FullPath(path), lastFileName, false, int64(limit), "", namePattern, namePatternExclude)
+	}
 
 	if err != nil {
 		glog.V(0).Infof("listDirectory %s %s %d: %s", path, lastFileName, limit, err)
@@ -55,6 +72,9 @@ func (fs *FilerServer) listDirectoryHandler(w http.ResponseWriter, r *http.Reque
 	glog.V(4).Infof("listDirectory %s, last file %s, limit %d: %d items", path, lastFileName, limit, len(entries))
 
 	if r.Header.Get("Accept") == "application/json" {
+		for i, entry := range entries {
+			entries[i] = fs.entryForClient(entry)
+		}
 		writeJsonQuiet(w, r, http.StatusOK, struct {
 			Path                  string
 			Entries               interface{}
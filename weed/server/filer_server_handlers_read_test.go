@@ -0,0 +1,39 @@
+package weed_server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+)
+
+func TestChecksumVerifyingWriter(t *testing.T) {
+	data := []byte("hello world")
+	sum := sha256.Sum256(data)
+	entry := &filer.Entry{Attr: filer.Attr{Sha256: sum[:]}}
+
+	var buf bytes.Buffer
+	cw := newChecksumVerifyingWriter(&buf, entry, int64(len(data)))
+	cw.Write(data[:5])
+	if _, err := cw.Write(data[5:]); err != nil || cw.isMismatched {
+		t.Errorf("verify matching content: %v", err)
+	}
+	if buf.String() != "hello world" {
+		t.Errorf("unexpected content %q", buf.String())
+	}
+
+	buf.Reset()
+	cw = newChecksumVerifyingWriter(&buf, entry, int64(len(data)))
+	cw.Write([]byte("hello"))
+	if _, err := cw.Write([]byte(" there")); err == nil || !cw.isMismatched {
+		t.Errorf("expected checksum mismatch")
+	}
+	if buf.String() != "hello" {
+		t.Errorf("the last write should be held back, written %q", buf.String())
+	}
+
+	if newChecksumVerifyingWriter(&buf, &filer.Entry{}, 0) != nil {
+		t.Errorf("expected no verification without a checksum")
+	}
+}
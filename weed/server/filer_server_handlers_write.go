@@ -12,6 +12,7 @@ import (
 
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
@@ -25,7 +26,8 @@ var (
 	OS_UID = uint32(os.Getuid())
 	OS_GID = uint32(os.Getgid())
 
-	ErrReadOnly = errors.New("read only")
+	ErrReadOnly         = errors.New("read only")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
 )
 
 type FilerPostResult struct {
@@ -34,7 +36,8 @@ type FilerPostResult struct {
 	Error string `json:"error,omitempty"`
 }
 
-func (fs *FilerServer) assignNewFileInfo(so *operation.StorageOption) (fileId, urlLocation string, auth security.EncodedJwt, err error) {
+// assignNewFileInfo assigns a file id, with the auth to upload up to maxSize bytes, or up to the volume size limit if 0
+func (fs *FilerServer) assignNewFileInfo(so *operation.StorageOption, maxSize int64) (fileId, urlLocation string, auth security.EncodedJwt, err error) {
 
 	stats.FilerRequestCounter.WithLabelValues(stats.ChunkAssign).Inc()
 	start := time.Now()
@@ -43,6 +46,10 @@ func (fs *FilerServer) assignNewFileInfo(so *operation.StorageOption) (fileId, u
 	}()
 
 	ar, altRequest := so.ToAssignRequests(1)
+	ar.MaxSize = maxSize
+	if altRequest != nil {
+		altRequest.MaxSize = maxSize
+	}
 
 	assignResult, ae := operation.Assign(fs.filer.GetMaster, fs.grpcDialOption, ar, altRequest)
 	if ae != nil {
@@ -71,7 +78,7 @@ func (fs *FilerServer) assignNewFileInfo(so *operation.StorageOption) (fileId, u
 
 func (fs *FilerServer) PostHandler(w http.ResponseWriter, r *http.Request, contentLength int64) {
 
-	ctx := context.Background()
+	ctx := filer.WithGovernanceBypass(context.Background(), r.Header.Get(s3_constants.AmzBypassGovernanceRetention) == "true")
 
 	destination := r.RequestURI
 	if finalDestination := r.Header.Get(s3_constants.SeaweedStorageDestinationHeader); finalDestination != "" {
@@ -109,6 +116,33 @@ func (fs *FilerServer) PostHandler(w http.ResponseWriter, r *http.Request, conte
 
 }
 
+func hasWritePreconditions(r *http.Request) bool {
+	return writeConditionOf(r).IsSet()
+}
+
+// writeConditionOf honors If-Match and If-None-Match on writes,
+// so clients can do optimistic-concurrency writes based on the entry ETag.
+func writeConditionOf(r *http.Request) *filer.WriteCondition {
+	return &filer.WriteCondition{
+		IfMatch:     r.Header.Get("If-Match"),
+		IfNoneMatch: r.Header.Get("If-None-Match"),
+	}
+}
+
+// checkWritePreconditions fails a conditional write early, before uploading the content.
+// The condition is checked again when saving the entry.
+func (fs *FilerServer) checkWritePreconditions(ctx context.Context, r *http.Request, fullPath util.FullPath) error {
+	condition := writeConditionOf(r)
+	if !condition.IsSet() {
+		return nil
+	}
+	entry, err := fs.filer.FindEntry(ctx, fullPath)
+	if err != nil && err != filer_pb.ErrNotFound {
+		return err
+	}
+	return condition.Check(entry)
+}
+
 func clearName(name string) (string, error) {
 	slashed := strings.HasSuffix(name, "/")
 	name = path.Clean(name)
@@ -203,7 +237,8 @@ func (fs *FilerServer) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 		objectPath = objectPath[0 : len(objectPath)-1]
 	}
 
-	err := fs.filer.DeleteEntryMetaAndData(context.Background(), util.FullPath(objectPath), isRecursive, ignoreRecursiveError, !skipChunkDeletion, false, nil)
+	ctx := filer.WithGovernanceBypass(context.Background(), r.Header.Get(s3_constants.AmzBypassGovernanceRetention) == "true")
+	err := fs.filer.DeleteEntryMetaAndData(ctx, util.FullPath(objectPath), isRecursive, ignoreRecursiveError, !skipChunkDeletion, false, nil)
 	if err != nil {
 		glog.V(1).Infoln("deleting", objectPath, ":", err.Error())
 		httpStatus := http.StatusInternalServerError
@@ -212,6 +247,9 @@ func (fs *FilerServer) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 			writeJsonQuiet(w, r, httpStatus, nil)
 			return
 		}
+		if errors.Is(err, filer.ErrObjectLocked) {
+			httpStatus = http.StatusForbidden
+		}
 		writeJsonError(w, r, httpStatus, err)
 		return
 	}
@@ -251,6 +289,7 @@ func (fs *FilerServer) detectStorageOption(requestURI, qCollection, qReplication
 		DiskType:          util.Nvl(diskType, rule.DiskType),
 		Fsync:             rule.Fsync,
 		VolumeGrowthCount: rule.VolumeGrowthCount,
+		Dedup:             rule.Dedup,
 	}, nil
 }
 
@@ -1,9 +1,13 @@
 package weed_server
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"hash"
 	"io"
 	"net/http"
 	"os"
@@ -36,6 +40,8 @@ func (fs *FilerServer) autoChunk(ctx context.Context, w http.ResponseWriter, r *
 	var reply *FilerPostResult
 	var err error
 	var md5bytes []byte
+	var ackErr error
+	ctx = filer.WithMetaEventTsNs(ctx)
 	if r.Method == "POST" {
 		if r.Header.Get("Content-Type") == "" && strings.HasSuffix(r.URL.Path, "/") {
 			reply, err = fs.mkdir(ctx, w, r)
@@ -43,10 +49,31 @@ func (fs *FilerServer) autoChunk(ctx context.Context, w http.ResponseWriter, r *
 			reply, md5bytes, err = fs.doPostAutoChunk(ctx, w, r, chunkSize, contentLength, so)
 		}
 	} else {
-		reply, md5bytes, err = fs.doPutAutoChunk(ctx, w, r, chunkSize, contentLength, so)
+		// fail early before uploading the content, it is checked again when saving the entry
+		if err = fs.checkWritePreconditions(ctx, r, util.FullPath(r.URL.Path)); err == nil {
+			err = fs.filer.CheckUploadPolicy(util.FullPath(r.URL.Path), r.Header.Get("Content-Type"), r.ContentLength)
+		}
+		if err == nil {
+			reply, md5bytes, err = fs.doPutAutoChunk(ctx, w, r, chunkSize, contentLength, so)
+		}
+	}
+	if err == nil && reply != nil {
+		fullpath := util.FullPath(r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/") && reply.Name != "" {
+			fullpath = fullpath.Child(reply.Name)
+		}
+		ackErr = fs.waitForFilerAcks(ctx, fullpath)
 	}
 	if err != nil {
-		if strings.HasPrefix(err.Error(), "read input:") || err.Error() == io.ErrUnexpectedEOF.Error() {
+		if status, found := uploadPolicyErrorStatus(err); found {
+			writeJsonError(w, r, status, err)
+		} else if errors.Is(err, filer.ErrPreconditionFailed) || errors.Is(err, filer.ErrPreconditionNotFound) {
+			writeJsonError(w, r, http.StatusPreconditionFailed, err)
+		} else if errors.Is(err, filer.ErrObjectLocked) {
+			writeJsonError(w, r, http.StatusForbidden, err)
+		} else if err == ErrChecksumMismatch {
+			writeJsonError(w, r, http.StatusBadRequest, err)
+		} else if strings.HasPrefix(err.Error(), "read input:") || err.Error() == io.ErrUnexpectedEOF.Error() {
 			writeJsonError(w, r, 499, err)
 		} else if strings.HasSuffix(err.Error(), "is a file") {
 			writeJsonError(w, r, http.StatusConflict, err)
@@ -58,6 +85,12 @@ func (fs *FilerServer) autoChunk(ctx context.Context, w http.ResponseWriter, r *
 			md5InBase64 := util.Base64Encode(md5bytes)
 			w.Header().Set("Content-MD5", md5InBase64)
 		}
+		if ackErr != nil {
+			// the file is saved, but may not be visible yet on the other filers
+			w.Header().Set("Warning", fmt.Sprintf("199 - %q", ackErr.Error()))
+			writeJsonQuiet(w, r, http.StatusAccepted, reply)
+			return
+		}
 		writeJsonQuiet(w, r, http.StatusCreated, reply)
 	}
 }
@@ -83,13 +116,19 @@ func (fs *FilerServer) doPostAutoChunk(ctx context.Context, w http.ResponseWrite
 		contentType = ""
 	}
 
-	fileChunks, md5Hash, chunkOffset, err, smallContent := fs.uploadReaderToChunks(w, r, part1, chunkSize, fileName, contentType, contentLength, so)
+	var reader io.Reader = part1
+	sha256Hash := fs.newSha256Hash()
+	if sha256Hash != nil {
+		reader = io.TeeReader(reader, sha256Hash)
+	}
+
+	fileChunks, md5Hash, chunkOffset, err, smallContent := fs.uploadReaderToChunks(w, r, reader, chunkSize, fileName, contentType, contentLength, so)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	md5bytes = md5Hash.Sum(nil)
-	filerResult, replyerr = fs.saveMetaData(ctx, r, fileName, contentType, so, md5bytes, fileChunks, chunkOffset, smallContent)
+	filerResult, replyerr = fs.saveMetaData(ctx, r, fileName, contentType, so, md5bytes, sumOf(sha256Hash), fileChunks, chunkOffset, smallContent)
 	if replyerr != nil {
 		fs.filer.DeleteChunks(fileChunks)
 	}
@@ -105,13 +144,29 @@ func (fs *FilerServer) doPutAutoChunk(ctx context.Context, w http.ResponseWriter
 		contentType = ""
 	}
 
-	fileChunks, md5Hash, chunkOffset, err, smallContent := fs.uploadReaderToChunks(w, r, r.Body, chunkSize, fileName, contentType, contentLength, so)
+	var reader io.Reader = r.Body
+	checksumHeader, checksumHash := amzChecksumOf(r)
+	if checksumHash != nil {
+		reader = io.TeeReader(reader, checksumHash)
+	}
+	sha256Hash := fs.newSha256Hash()
+	if sha256Hash != nil {
+		reader = io.TeeReader(reader, sha256Hash)
+	}
+
+	fileChunks, md5Hash, chunkOffset, err, smallContent := fs.uploadReaderToChunks(w, r, reader, chunkSize, fileName, contentType, contentLength, so)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	if checksumHash != nil && util.Base64Encode(checksumHash.Sum(nil)) != r.Header.Get(checksumHeader) {
+		glog.V(1).Infof("%s %s: %s expected %s", r.URL.Path, checksumHeader, util.Base64Encode(checksumHash.Sum(nil)), r.Header.Get(checksumHeader))
+		fs.filer.DeleteChunks(fileChunks)
+		return nil, nil, ErrChecksumMismatch
+	}
+
 	md5bytes = md5Hash.Sum(nil)
-	filerResult, replyerr = fs.saveMetaData(ctx, r, fileName, contentType, so, md5bytes, fileChunks, chunkOffset, smallContent)
+	filerResult, replyerr = fs.saveMetaData(ctx, r, fileName, contentType, so, md5bytes, sumOf(sha256Hash), fileChunks, chunkOffset, smallContent)
 	if replyerr != nil {
 		fs.filer.DeleteChunks(fileChunks)
 	}
@@ -119,6 +174,31 @@ func (fs *FilerServer) doPutAutoChunk(ctx context.Context, w http.ResponseWriter
 	return
 }
 
+// newSha256Hash returns the hash of the whole content to save in the entry, or nil if not enabled.
+func (fs *FilerServer) newSha256Hash() hash.Hash {
+	if !fs.option.ChecksumSha256 {
+		return nil
+	}
+	return sha256.New()
+}
+
+func sumOf(h hash.Hash) []byte {
+	if h == nil {
+		return nil
+	}
+	return h.Sum(nil)
+}
+
+// amzChecksumOf returns the first x-amz-checksum-* header of the request with its hash.
+func amzChecksumOf(r *http.Request) (header string, checksumHash hash.Hash) {
+	for _, header = range s3_constants.AmzChecksumHeaders {
+		if r.Header.Get(header) != "" {
+			return header, s3_constants.NewAmzChecksumHash(header)
+		}
+	}
+	return "", nil
+}
+
 func isAppend(r *http.Request) bool {
 	return r.URL.Query().Get("op") == "append"
 }
@@ -127,7 +207,7 @@ func skipCheckParentDirEntry(r *http.Request) bool {
 	return r.URL.Query().Get("skipCheckParentDir") == "true"
 }
 
-func (fs *FilerServer) saveMetaData(ctx context.Context, r *http.Request, fileName string, contentType string, so *operation.StorageOption, md5bytes []byte, fileChunks []*filer_pb.FileChunk, chunkOffset int64, content []byte) (filerResult *FilerPostResult, replyerr error) {
+func (fs *FilerServer) saveMetaData(ctx context.Context, r *http.Request, fileName string, contentType string, so *operation.StorageOption, md5bytes, sha256bytes []byte, fileChunks []*filer_pb.FileChunk, chunkOffset int64, content []byte) (filerResult *FilerPostResult, replyerr error) {
 
 	// detect file mode
 	modeStr := r.URL.Query().Get("mode")
@@ -156,12 +236,31 @@ func (fs *FilerServer) saveMetaData(ctx context.Context, r *http.Request, fileNa
 		}
 	}
 
+	isAppend := isAppend(r)
+	isOffsetWrite := len(fileChunks) > 0 && fileChunks[0].Offset > 0
+
+	// the existing entry is read, checked and written under the lock
+	if isAppend || isOffsetWrite || hasWritePreconditions(r) {
+		unlock := fs.entryLocks.Lock(path)
+		defer unlock()
+	}
+
+	if replyerr = fs.checkWritePreconditions(ctx, r, util.FullPath(path)); replyerr != nil {
+		return
+	}
+
 	var entry *filer.Entry
 	var newChunks []*filer_pb.FileChunk
 	var mergedChunks []*filer_pb.FileChunk
 
-	isAppend := isAppend(r)
-	isOffsetWrite := len(fileChunks) > 0 && fileChunks[0].Offset > 0
+	// the chunks created here are not referenced by any entry if the entry is not saved
+	var createdChunks []*filer_pb.FileChunk
+	defer func() {
+		if replyerr != nil && len(createdChunks) > 0 {
+			fs.filer.DeleteChunksNotRecursive(createdChunks)
+		}
+	}()
+
 	// when it is an append
 	if isAppend || isOffsetWrite {
 		existingEntry, findErr := fs.filer.FindEntry(ctx, util.FullPath(path))
@@ -173,6 +272,7 @@ func (fs *FilerServer) saveMetaData(ctx context.Context, r *http.Request, fileNa
 	if entry != nil {
 		entry.Mtime = time.Now()
 		entry.Md5 = nil
+		entry.Sha256 = nil
 		// adjust chunk offsets
 		if isAppend {
 			for _, chunk := range fileChunks {
@@ -180,28 +280,35 @@ func (fs *FilerServer) saveMetaData(ctx context.Context, r *http.Request, fileNa
 			}
 			entry.FileSize += uint64(chunkOffset)
 		}
-		newChunks = append(entry.Chunks, fileChunks...)
-
-		// TODO
+		// move the inlined small content into a chunk before appending
 		if len(entry.Content) > 0 {
-			replyerr = fmt.Errorf("append to small file is not supported yet")
-			return
+			contentChunk, saveErr := fs.saveAsChunk(so)(bytes.NewReader(entry.Content), entry.Name(), 0)
+			if saveErr != nil {
+				replyerr = fmt.Errorf("save small content of %s as chunk: %v", path, saveErr)
+				return
+			}
+			createdChunks = append(createdChunks, contentChunk)
+			entry.Chunks = append([]*filer_pb.FileChunk{contentChunk}, entry.Chunks...)
+			entry.Content = nil
 		}
+		newChunks = append(entry.Chunks, fileChunks...)
 
 	} else {
 		glog.V(4).Infoln("saving", path)
 		newChunks = fileChunks
+		uid, gid := fs.ownerOf(r, path)
 		entry = &filer.Entry{
 			FullPath: util.FullPath(path),
 			Attr: filer.Attr{
 				Mtime:    time.Now(),
 				Crtime:   time.Now(),
 				Mode:     os.FileMode(mode),
-				Uid:      OS_UID,
-				Gid:      OS_GID,
+				Uid:      uid,
+				Gid:      gid,
 				TtlSec:   so.TtlSeconds,
 				Mime:     contentType,
 				Md5:      md5bytes,
+				Sha256:   sha256bytes,
 				FileSize: uint64(chunkOffset),
 			},
 			Content: content,
@@ -221,9 +328,11 @@ func (fs *FilerServer) saveMetaData(ctx context.Context, r *http.Request, fileNa
 		glog.V(0).Infof("manifestize %s: %v", r.RequestURI, replyerr)
 		return
 	}
+	createdChunks = append(createdChunks, newManifestChunks(newChunks, mergedChunks)...)
 	entry.Chunks = mergedChunks
 	if isOffsetWrite {
 		entry.Md5 = nil
+		entry.Sha256 = nil
 		entry.FileSize = entry.Size()
 	}
 
@@ -233,6 +342,12 @@ func (fs *FilerServer) saveMetaData(ctx context.Context, r *http.Request, fileNa
 	}
 
 	entry.Extended = SaveAmzMetaData(r, entry.Extended, false)
+	if isAppend || isOffsetWrite {
+		// the checksums only cover the newly written data
+		for _, header := range s3_constants.AmzChecksumHeaders {
+			delete(entry.Extended, header)
+		}
+	}
 
 	for k, v := range r.Header {
 		if len(v) > 0 && len(v[0]) > 0 {
@@ -245,7 +360,7 @@ func (fs *FilerServer) saveMetaData(ctx context.Context, r *http.Request, fileNa
 		}
 	}
 
-	if dbErr := fs.filer.CreateEntry(ctx, entry, false, false, nil, skipCheckParentDirEntry(r)); dbErr != nil {
+	if dbErr := fs.filer.CreateEntryWithCondition(ctx, entry, writeConditionOf(r), false, nil, skipCheckParentDirEntry(r)); dbErr != nil {
 		replyerr = dbErr
 		filerResult.Error = dbErr.Error()
 		glog.V(0).Infof("failing to write %s to filer server : %v", path, dbErr)
@@ -253,6 +368,20 @@ func (fs *FilerServer) saveMetaData(ctx context.Context, r *http.Request, fileNa
 	return filerResult, replyerr
 }
 
+// newManifestChunks returns the manifest chunks created by manifestizing the input chunks.
+func newManifestChunks(inputChunks, manifestizedChunks []*filer_pb.FileChunk) (manifestChunks []*filer_pb.FileChunk) {
+	inputFileIds := make(map[string]struct{}, len(inputChunks))
+	for _, chunk := range inputChunks {
+		inputFileIds[chunk.GetFileIdString()] = struct{}{}
+	}
+	for _, chunk := range manifestizedChunks {
+		if _, found := inputFileIds[chunk.GetFileIdString()]; chunk.IsChunkManifest && !found {
+			manifestChunks = append(manifestChunks, chunk)
+		}
+	}
+	return
+}
+
 func (fs *FilerServer) saveAsChunk(so *operation.StorageOption) filer.SaveDataAsChunkFunctionType {
 
 	return func(reader io.Reader, name string, offset int64) (*filer_pb.FileChunk, error) {
@@ -260,8 +389,8 @@ func (fs *FilerServer) saveAsChunk(so *operation.StorageOption) filer.SaveDataAs
 		var uploadResult *operation.UploadResult
 
 		err := util.Retry("saveAsChunk", func() error {
-			// assign one file id for one chunk
-			assignedFileId, urlLocation, auth, assignErr := fs.assignNewFileInfo(so)
+			// assign one file id for one chunk, the size of the manifest or the content is not known here
+			assignedFileId, urlLocation, auth, assignErr := fs.assignNewFileInfo(so, 0)
 			if assignErr != nil {
 				return assignErr
 			}
@@ -319,14 +448,15 @@ func (fs *FilerServer) mkdir(ctx context.Context, w http.ResponseWriter, r *http
 	}
 
 	glog.V(4).Infoln("mkdir", path)
+	uid, gid := fs.ownerOf(r, path)
 	entry := &filer.Entry{
 		FullPath: util.FullPath(path),
 		Attr: filer.Attr{
 			Mtime:  time.Now(),
 			Crtime: time.Now(),
 			Mode:   os.FileMode(mode) | os.ModeDir,
-			Uid:    OS_UID,
-			Gid:    OS_GID,
+			Uid:    uid,
+			Gid:    gid,
 		},
 	}
 
@@ -366,11 +496,26 @@ func SaveAmzMetaData(r *http.Request, existing map[string][]byte, isReplace bool
 		}
 	}
 
+	for _, header := range s3_constants.AmzChecksumHeaders {
+		delete(metadata, header)
+		if checksum := r.Header.Get(header); checksum != "" {
+			metadata[header] = []byte(checksum)
+		}
+	}
+
+	// the object lock headers are validated by the s3 gateway
+	if mode, retainUntil := r.Header.Get(s3_constants.AmzObjectLockMode), r.Header.Get(s3_constants.AmzObjectLockRetainUntilDate); mode != "" && retainUntil != "" {
+		metadata[s3_constants.AmzObjectLockMode] = []byte(mode)
+		metadata[s3_constants.AmzObjectLockRetainUntilDate] = []byte(retainUntil)
+	}
+	if legalHold := r.Header.Get(s3_constants.AmzObjectLockLegalHold); legalHold != "" {
+		metadata[s3_constants.AmzObjectLockLegalHold] = []byte(legalHold)
+	}
+
+	// a repeated header is one value separated by commas, same as AWS
 	for header, values := range r.Header {
 		if strings.HasPrefix(header, s3_constants.AmzUserMetaPrefix) {
-			for _, value := range values {
-				metadata[header] = []byte(value)
-			}
+			metadata[header] = []byte(strings.Join(values, ","))
 		}
 	}
 
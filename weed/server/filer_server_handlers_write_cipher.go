@@ -19,7 +19,9 @@ import (
 // handling single chunk POST or PUT upload
 func (fs *FilerServer) encrypt(ctx context.Context, w http.ResponseWriter, r *http.Request, so *operation.StorageOption) (filerResult *FilerPostResult, err error) {
 
-	fileId, urlLocation, auth, err := fs.assignNewFileInfo(so)
+	sizeLimit := int64(fs.option.MaxMB) * 1024 * 1024
+
+	fileId, urlLocation, auth, err := fs.assignNewFileInfo(so, operation.UploadMaxSize(sizeLimit))
 
 	if err != nil || fileId == "" || urlLocation == "" {
 		return nil, fmt.Errorf("fail to allocate volume for %s, collection:%s, datacenter:%s", r.URL.Path, so.Collection, so.DataCenter)
@@ -29,8 +31,6 @@ func (fs *FilerServer) encrypt(ctx context.Context, w http.ResponseWriter, r *ht
 
 	// Note: encrypt(gzip(data)), encrypt data first, then gzip
 
-	sizeLimit := int64(fs.option.MaxMB) * 1024 * 1024
-
 	bytesBuffer := bufPool.Get().(*bytes.Buffer)
 	defer bufPool.Put(bytesBuffer)
 
@@ -70,14 +70,15 @@ func (fs *FilerServer) encrypt(ctx context.Context, w http.ResponseWriter, r *ht
 		}
 	}
 
+	uid, gid := fs.ownerOf(r, path)
 	entry := &filer.Entry{
 		FullPath: util.FullPath(path),
 		Attr: filer.Attr{
 			Mtime:  time.Now(),
 			Crtime: time.Now(),
 			Mode:   0660,
-			Uid:    OS_UID,
-			Gid:    OS_GID,
+			Uid:    uid,
+			Gid:    gid,
 			TtlSec: so.TtlSeconds,
 			Mime:   pu.MimeType,
 			Md5:    util.Base64Md5ToBytes(pu.ContentMd5),
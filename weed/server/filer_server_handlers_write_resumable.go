@@ -0,0 +1,476 @@
+package weed_server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// The resumable uploads follow the tus protocol, https://tus.io/protocols/resumable-upload
+//
+//	POST   /path/to/file            Tus-Resumable, Upload-Length, Upload-Metadata => 201 Location: /path/to/file?uploadId=<id>
+//	HEAD   /path/to/file?uploadId=  => Upload-Offset, Upload-Length
+//	PATCH  /path/to/file?uploadId=  Upload-Offset, Content-Type: application/offset+octet-stream => 204 Upload-Offset
+//	DELETE /path/to/file?uploadId=  => 204, the uploaded chunks are deleted
+//
+// If the path ends with "/", the file name is the "filename" in the Upload-Metadata.
+// The uploaded chunks and the offset are saved in the filer KV store after each chunk,
+// so the client can resume from the last saved offset after a broken connection.
+// The body is read one chunk at a time into a pooled buffer, and the saved data chunks are
+// merged into manifest chunks in batches, so neither the memory nor the saved upload grows with the file.
+// The entry is created when the last byte is uploaded.
+const (
+	TusResumableVersion       = "1.0.0"
+	TusExtensions             = "creation,expiration,termination"
+	ResumableUploadKeyPrefix  = "filer.upload."
+	ResumableUploadExpiration = 24 * time.Hour
+	// the data chunks merged into one manifest chunk while uploading
+	resumableUploadManifestBatch = 1000
+)
+
+var ErrResumableUploadNotFound = errors.New("resumable upload not found")
+
+func isResumableUpload(r *http.Request) bool {
+	return r.URL.Query().Has("uploadId") || (r.Method == http.MethodPost && r.Header.Get("Tus-Resumable") != "")
+}
+
+func (fs *FilerServer) resumableUploadCreateHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := context.Background()
+	if !checkTusResumable(w, r) {
+		return
+	}
+	if r.Header.Get("Upload-Defer-Length") != "" {
+		writeJsonError(w, r, http.StatusBadRequest, errors.New("Upload-Defer-Length is not supported"))
+		return
+	}
+	size, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || size < 0 {
+		writeJsonError(w, r, http.StatusBadRequest, fmt.Errorf("invalid Upload-Length %q", r.Header.Get("Upload-Length")))
+		return
+	}
+	metadata, err := parseTusMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		writeJsonError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	filePath := r.URL.Path
+	if strings.HasSuffix(filePath, "/") {
+		fileName := path.Base(metadata["filename"])
+		if fileName == "." || fileName == "/" {
+			writeJsonError(w, r, http.StatusBadRequest, errors.New("missing filename in Upload-Metadata"))
+			return
+		}
+		filePath += fileName
+	}
+	if entry, findErr := fs.filer.FindEntry(ctx, util.FullPath(filePath)); findErr == nil && entry.IsDirectory() {
+		writeJsonError(w, r, http.StatusConflict, fmt.Errorf("%s is a directory", filePath))
+		return
+	}
+
+	query := r.URL.Query()
+	upload := &filer_pb.ResumableUpload{
+		Id:          hex.EncodeToString(util.RandomBytes(16)),
+		Path:        filePath,
+		Size:        size,
+		Mime:        metadata["filetype"],
+		Mode:        0660,
+		Collection:  query.Get("collection"),
+		Replication: query.Get("replication"),
+		Ttl:         query.Get("ttl"),
+		DiskType:    query.Get("disk"),
+		DataCenter:  query.Get("dataCenter"),
+		Rack:        query.Get("rack"),
+		DataNode:    query.Get("dataNode"),
+		CreatedAtNs: time.Now().UnixNano(),
+		UpdatedAtNs: time.Now().UnixNano(),
+	}
+	if modeStr := query.Get("mode"); modeStr != "" {
+		if mode, parseErr := strconv.ParseUint(modeStr, 8, 32); parseErr == nil {
+			upload.Mode = uint32(mode)
+		}
+	}
+	upload.Uid, upload.Gid = fs.ownerOf(r, filePath)
+
+	// fail early if the storage is not writable
+	if _, err = fs.detectResumableUploadStorageOption(upload); err != nil {
+		writeResumableUploadStorageError(w, r, err)
+		return
+	}
+
+	if size == 0 {
+		err = fs.finishResumableUpload(ctx, upload)
+	} else {
+		err = fs.saveResumableUpload(ctx, upload)
+	}
+	if err != nil {
+		writeJsonError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Location", resumableUploadLocation(upload))
+	setResumableUploadHeaders(w, upload)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (fs *FilerServer) resumableUploadHeadHandler(w http.ResponseWriter, r *http.Request) {
+
+	if !checkTusResumable(w, r) {
+		return
+	}
+	upload, status, err := fs.findResumableUpload(context.Background(), r)
+	if err != nil {
+		w.Header().Set("Tus-Resumable", TusResumableVersion)
+		w.WriteHeader(status)
+		return
+	}
+
+	setResumableUploadHeaders(w, upload)
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.Size, 10))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (fs *FilerServer) resumableUploadPatchHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := context.Background()
+	if !checkTusResumable(w, r) {
+		return
+	}
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		writeJsonError(w, r, http.StatusUnsupportedMediaType, errors.New("the Content-Type should be application/offset+octet-stream"))
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		writeJsonError(w, r, http.StatusBadRequest, fmt.Errorf("invalid Upload-Offset %q", r.Header.Get("Upload-Offset")))
+		return
+	}
+
+	// the chunks of one upload are appended one request at a time
+	unlock := fs.uploadLocks.Lock(r.URL.Query().Get("uploadId"))
+	defer unlock()
+
+	upload, status, err := fs.findResumableUpload(ctx, r)
+	if err != nil {
+		writeJsonError(w, r, status, err)
+		return
+	}
+	if offset != upload.Offset {
+		writeJsonError(w, r, http.StatusConflict, fmt.Errorf("Upload-Offset %d, expected %d", offset, upload.Offset))
+		return
+	}
+	if r.ContentLength > upload.Size-upload.Offset {
+		writeJsonError(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("%d bytes exceed the Upload-Length %d", upload.Offset+r.ContentLength, upload.Size))
+		return
+	}
+	so, err := fs.detectResumableUploadStorageOption(upload)
+	if err != nil {
+		writeResumableUploadStorageError(w, r, err)
+		return
+	}
+
+	maxMB := fs.option.MaxMB
+	if maxMB <= 0 {
+		maxMB = 4
+	}
+	chunkSize := int64(maxMB) * 1024 * 1024
+	reader := io.LimitReader(r.Body, upload.Size-upload.Offset)
+	bytesBuffer := bufPool.Get().(*bytes.Buffer)
+	defer bufPool.Put(bytesBuffer)
+	for upload.Offset < upload.Size {
+		// the received data is kept even if the client stops in the middle of the request
+		bytesBuffer.Reset()
+		n, readErr := bytesBuffer.ReadFrom(io.LimitReader(reader, chunkSize))
+		if n > 0 {
+			if err = fs.saveResumableUploadChunk(ctx, upload, bytesBuffer.Bytes(), so); err != nil {
+				writeJsonError(w, r, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		if readErr != nil {
+			glog.V(1).Infof("resumable upload %s to %s at offset %d: %v", upload.Id, upload.Path, upload.Offset, readErr)
+			break
+		}
+		if n < chunkSize {
+			break
+		}
+	}
+
+	if upload.Offset == upload.Size {
+		if err = fs.finishResumableUpload(ctx, upload); err != nil {
+			writeJsonError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	setResumableUploadHeaders(w, upload)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (fs *FilerServer) resumableUploadDeleteHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := context.Background()
+	if !checkTusResumable(w, r) {
+		return
+	}
+
+	unlock := fs.uploadLocks.Lock(r.URL.Query().Get("uploadId"))
+	defer unlock()
+
+	upload, status, err := fs.findResumableUpload(ctx, r)
+	if err != nil {
+		writeJsonError(w, r, status, err)
+		return
+	}
+	if err = fs.deleteResumableUpload(ctx, upload); err != nil {
+		writeJsonError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Tus-Resumable", TusResumableVersion)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findResumableUpload loads the upload of the request, with the http status if not found or expired.
+func (fs *FilerServer) findResumableUpload(ctx context.Context, r *http.Request) (upload *filer_pb.ResumableUpload, status int, err error) {
+	upload, err = fs.loadResumableUpload(ctx, r.URL.Query().Get("uploadId"))
+	if err == ErrResumableUploadNotFound || err == nil && upload.Path != r.URL.Path {
+		return nil, http.StatusNotFound, ErrResumableUploadNotFound
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if isResumableUploadExpired(upload, time.Now()) {
+		return nil, http.StatusGone, fmt.Errorf("resumable upload %s expired", upload.Id)
+	}
+	return upload, http.StatusOK, nil
+}
+
+func (fs *FilerServer) loadResumableUpload(ctx context.Context, id string) (*filer_pb.ResumableUpload, error) {
+	if id == "" {
+		return nil, ErrResumableUploadNotFound
+	}
+	value, err := fs.filer.Store.KvGet(ctx, []byte(ResumableUploadKeyPrefix+id))
+	if err == filer.ErrKvNotFound || err == nil && len(value) == 0 {
+		return nil, ErrResumableUploadNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load resumable upload %s: %v", id, err)
+	}
+	upload := &filer_pb.ResumableUpload{}
+	if err = proto.Unmarshal(value, upload); err != nil {
+		return nil, fmt.Errorf("unmarshal resumable upload %s: %v", id, err)
+	}
+	return upload, nil
+}
+
+func (fs *FilerServer) saveResumableUpload(ctx context.Context, upload *filer_pb.ResumableUpload) error {
+	value, err := proto.Marshal(upload)
+	if err != nil {
+		return fmt.Errorf("marshal resumable upload %s: %v", upload.Id, err)
+	}
+	if err = fs.filer.Store.KvPut(ctx, []byte(ResumableUploadKeyPrefix+upload.Id), value); err != nil {
+		return fmt.Errorf("save resumable upload %s: %v", upload.Id, err)
+	}
+	return nil
+}
+
+// saveResumableUploadChunk uploads the data as the next chunks of the upload, and saves the upload.
+// Every resumableUploadManifestBatch data chunks are merged into a manifest chunk, so the saved upload stays small.
+func (fs *FilerServer) saveResumableUploadChunk(ctx context.Context, upload *filer_pb.ResumableUpload, data []byte, so *operation.StorageOption) error {
+	chunks, err := fs.dataToChunk(path.Base(upload.Path), upload.Mime, data, upload.Offset, so)
+	if err != nil {
+		fs.filer.DeleteChunks(chunks)
+		return err
+	}
+	previousChunks := upload.Chunks
+	upload.Chunks = append(upload.Chunks, chunks...)
+	if manifestized, manifestErr := filer.MaybeManifestizeBatch(fs.saveAsChunk(so), upload.Chunks, resumableUploadManifestBatch); manifestErr != nil {
+		glog.V(0).Infof("manifestize resumable upload %s: %v", upload.Id, manifestErr)
+	} else {
+		upload.Chunks = manifestized
+	}
+	upload.Offset += int64(len(data))
+	upload.UpdatedAtNs = time.Now().UnixNano()
+	if err = fs.saveResumableUpload(ctx, upload); err != nil {
+		fs.filer.DeleteChunks(chunks)
+		fs.filer.DeleteChunksNotRecursive(newManifestChunks(previousChunks, upload.Chunks))
+		return err
+	}
+	return nil
+}
+
+// finishResumableUpload creates the entry with the uploaded chunks, and forgets the upload.
+func (fs *FilerServer) finishResumableUpload(ctx context.Context, upload *filer_pb.ResumableUpload) error {
+	so, err := fs.detectResumableUploadStorageOption(upload)
+	if err != nil {
+		return err
+	}
+	chunks, err := filer.MaybeManifestize(fs.saveAsChunk(so), upload.Chunks)
+	if err != nil {
+		return fmt.Errorf("manifestize %s: %v", upload.Path, err)
+	}
+	entry := &filer.Entry{
+		FullPath: util.FullPath(upload.Path),
+		Attr: filer.Attr{
+			Mtime:    time.Now(),
+			Crtime:   time.Unix(0, upload.CreatedAtNs),
+			Mode:     os.FileMode(upload.Mode),
+			Uid:      upload.Uid,
+			Gid:      upload.Gid,
+			TtlSec:   so.TtlSeconds,
+			Mime:     upload.Mime,
+			FileSize: uint64(upload.Size),
+		},
+		Chunks: chunks,
+	}
+	if err = fs.filer.CreateEntry(ctx, entry, false, false, nil, false); err != nil {
+		return fmt.Errorf("create %s: %v", upload.Path, err)
+	}
+	if err = fs.filer.Store.KvDelete(ctx, []byte(ResumableUploadKeyPrefix+upload.Id)); err != nil {
+		glog.Warningf("delete finished resumable upload %s: %v", upload.Id, err)
+	}
+	return nil
+}
+
+func (fs *FilerServer) deleteResumableUpload(ctx context.Context, upload *filer_pb.ResumableUpload) error {
+	if err := fs.filer.Store.KvDelete(ctx, []byte(ResumableUploadKeyPrefix+upload.Id)); err != nil {
+		return fmt.Errorf("delete resumable upload %s: %v", upload.Id, err)
+	}
+	fs.filer.DeleteChunks(upload.Chunks)
+	return nil
+}
+
+// loopDeleteExpiredResumableUploads deletes the chunks of the uploads not resumed in time.
+// The uploads are kept while the filer is read only.
+func (fs *FilerServer) loopDeleteExpiredResumableUploads() {
+	ctx := context.Background()
+	for {
+		time.Sleep(time.Hour)
+		if fs.isReadOnly() {
+			continue
+		}
+
+		var expired []*filer_pb.ResumableUpload
+		var startKey []byte
+		for {
+			var count int
+			err := fs.filer.Store.KvList(ctx, []byte(ResumableUploadKeyPrefix), startKey, filer.PaginationSize, func(key []byte, value []byte) bool {
+				count++
+				startKey = append(startKey[:0], key...)
+				upload := &filer_pb.ResumableUpload{}
+				if err := proto.Unmarshal(value, upload); err != nil {
+					glog.V(0).Infof("unmarshal resumable upload %s: %v", key, err)
+					return true
+				}
+				if isResumableUploadExpired(upload, time.Now()) {
+					expired = append(expired, upload)
+				}
+				return true
+			})
+			if err == filer.ErrUnsupportedKvListing {
+				glog.V(0).Infof("filer store %s can not list the expired resumable uploads", fs.filer.Store.GetName())
+				return
+			}
+			if err != nil {
+				glog.V(0).Infof("list resumable uploads: %v", err)
+				break
+			}
+			if count < filer.PaginationSize {
+				break
+			}
+		}
+
+		for _, upload := range expired {
+			unlock := fs.uploadLocks.Lock(upload.Id)
+			if err := fs.deleteResumableUpload(ctx, upload); err != nil {
+				glog.V(0).Infof("delete expired resumable upload %s: %v", upload.Id, err)
+			} else {
+				glog.V(1).Infof("deleted expired resumable upload %s to %s", upload.Id, upload.Path)
+			}
+			unlock()
+		}
+	}
+}
+
+func (fs *FilerServer) detectResumableUploadStorageOption(upload *filer_pb.ResumableUpload) (*operation.StorageOption, error) {
+	return fs.detectStorageOption0(upload.Path, upload.Collection, upload.Replication, upload.Ttl, upload.DiskType, "", upload.DataCenter, upload.Rack, upload.DataNode)
+}
+
+func writeResumableUploadStorageError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == ErrReadOnly {
+		writeJsonError(w, r, http.StatusInsufficientStorage, err)
+	} else {
+		writeJsonError(w, r, http.StatusInternalServerError, err)
+	}
+}
+
+// checkTusResumable rejects the requests of an unsupported tus protocol version.
+func checkTusResumable(w http.ResponseWriter, r *http.Request) bool {
+	if version := r.Header.Get("Tus-Resumable"); version != TusResumableVersion {
+		w.Header().Set("Tus-Version", TusResumableVersion)
+		writeJsonError(w, r, http.StatusPreconditionFailed, fmt.Errorf("unsupported Tus-Resumable %q", version))
+		return false
+	}
+	return true
+}
+
+func setResumableUploadHeaders(w http.ResponseWriter, upload *filer_pb.ResumableUpload) {
+	w.Header().Set("Tus-Resumable", TusResumableVersion)
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	if upload.Offset < upload.Size {
+		w.Header().Set("Upload-Expires", time.Unix(0, upload.UpdatedAtNs).Add(ResumableUploadExpiration).UTC().Format(http.TimeFormat))
+	}
+	w.Header().Set("Access-Control-Expose-Headers", "Location, Tus-Resumable, Upload-Offset, Upload-Length, Upload-Expires")
+}
+
+func resumableUploadLocation(upload *filer_pb.ResumableUpload) string {
+	u := &url.URL{
+		Path:     upload.Path,
+		RawQuery: "uploadId=" + upload.Id,
+	}
+	return u.String()
+}
+
+func isResumableUploadExpired(upload *filer_pb.ResumableUpload, now time.Time) bool {
+	return now.Sub(time.Unix(0, upload.UpdatedAtNs)) > ResumableUploadExpiration
+}
+
+// parseTusMetadata parses the Upload-Metadata header, the comma separated keys with the base64 encoded values.
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("invalid Upload-Metadata %s: %v", key, err)
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
@@ -0,0 +1,59 @@
+package weed_server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+func TestParseTusMetadata(t *testing.T) {
+	metadata, err := parseTusMetadata("filename d29ybGRfZG9taW5hdGlvbl9wbGFuLnBkZg==, filetype YXBwbGljYXRpb24vcGRm,is_confidential")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if metadata["filename"] != "world_domination_plan.pdf" || metadata["filetype"] != "application/pdf" {
+		t.Errorf("unexpected metadata %+v", metadata)
+	}
+	if _, found := metadata["is_confidential"]; !found {
+		t.Errorf("missing the key without value")
+	}
+	if _, err = parseTusMetadata("filename !!!"); err == nil {
+		t.Errorf("expected error for invalid base64")
+	}
+}
+
+func TestResumableUploadRequests(t *testing.T) {
+	upload := &filer_pb.ResumableUpload{
+		Id:          "0123abcd",
+		Path:        "/some dir/file.bin",
+		UpdatedAtNs: time.Now().Add(-time.Hour).UnixNano(),
+	}
+	location := resumableUploadLocation(upload)
+	if location != "/some%20dir/file.bin?uploadId=0123abcd" {
+		t.Errorf("unexpected location %s", location)
+	}
+
+	r := httptest.NewRequest(http.MethodHead, location, nil)
+	if r.URL.Path != upload.Path || !isResumableUpload(r) {
+		t.Errorf("location %s is not the upload", location)
+	}
+	if isResumableUpload(httptest.NewRequest(http.MethodPost, "/some/file.bin", nil)) {
+		t.Errorf("a plain post is not a resumable upload")
+	}
+
+	w := httptest.NewRecorder()
+	if checkTusResumable(w, r) || w.Code != http.StatusPreconditionFailed || w.Header().Get("Tus-Version") != TusResumableVersion {
+		t.Errorf("expected to reject the request without Tus-Resumable: %d", w.Code)
+	}
+	r.Header.Set("Tus-Resumable", TusResumableVersion)
+	if !checkTusResumable(httptest.NewRecorder(), r) {
+		t.Errorf("expected to accept Tus-Resumable %s", TusResumableVersion)
+	}
+
+	if isResumableUploadExpired(upload, time.Now()) || !isResumableUploadExpired(upload, time.Now().Add(ResumableUploadExpiration)) {
+		t.Errorf("unexpected expiration of the upload updated one hour ago")
+	}
+}
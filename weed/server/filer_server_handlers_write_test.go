@@ -0,0 +1,18 @@
+package weed_server
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+func TestNewManifestChunks(t *testing.T) {
+	existingManifest := &filer_pb.FileChunk{FileId: "3,01", IsChunkManifest: true}
+	input := []*filer_pb.FileChunk{existingManifest, {FileId: "3,02"}, {FileId: "3,03"}}
+	manifestized := []*filer_pb.FileChunk{existingManifest, {FileId: "4,04", IsChunkManifest: true}}
+
+	created := newManifestChunks(input, manifestized)
+	if len(created) != 1 || created[0].GetFileIdString() != "4,04" {
+		t.Errorf("expected the new manifest 4,04, actual %v", created)
+	}
+}
@@ -2,6 +2,7 @@ package weed_server
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"fmt"
 	"golang.org/x/exp/slices"
@@ -13,6 +14,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
@@ -78,8 +80,11 @@ func (fs *FilerServer) uploadReaderToChunks(w http.ResponseWriter, r *http.Reque
 			bufPool.Put(bytesBuffer)
 			atomic.AddInt64(&bytesBufferCounter, -1)
 			bytesBufferLimitCond.Signal()
+			// keep the error of a failed chunk, its uploaded and deduped chunks are deleted below
 			uploadErrLock.Lock()
-			uploadErr = err
+			if uploadErr == nil {
+				uploadErr = err
+			}
 			uploadErrLock.Unlock()
 			break
 		}
@@ -94,6 +99,19 @@ func (fs *FilerServer) uploadReaderToChunks(w http.ResponseWriter, r *http.Reque
 				stats.FilerRequestCounter.WithLabelValues(stats.ContentSaveToFiler).Inc()
 				break
 			}
+			if dataSize < int64(chunkSize) && fs.packer.canPack(dataSize, so) {
+				chunk, packErr := fs.packer.pack(bytesBuffer.Bytes(), so)
+				bufPool.Put(bytesBuffer)
+				atomic.AddInt64(&bytesBufferCounter, -1)
+				bytesBufferLimitCond.Signal()
+				if packErr != nil {
+					uploadErr = packErr
+					break
+				}
+				fileChunks = append(fileChunks, chunk)
+				chunkOffset += dataSize
+				break
+			}
 		} else {
 			stats.FilerRequestCounter.WithLabelValues(stats.AutoChunk).Inc()
 		}
@@ -172,6 +190,15 @@ func (fs *FilerServer) doUpload(urlLocation string, limitedReader io.Reader, fil
 }
 
 func (fs *FilerServer) dataToChunk(fileName, contentType string, data []byte, chunkOffset int64, so *operation.StorageOption) ([]*filer_pb.FileChunk, error) {
+	var contentHash string
+	if so.Dedup && len(data) > 0 {
+		contentHash = filer.ChunkContentHash(data)
+		if chunk, found := fs.filer.Deduper.FindChunk(context.Background(), so.Collection, contentHash, chunkOffset); found {
+			stats.FilerRequestCounter.WithLabelValues(stats.ChunkDedup).Inc()
+			return []*filer_pb.FileChunk{chunk}, nil
+		}
+	}
+
 	dataReader := util.NewBytesReader(data)
 
 	// retry to assign a different file id
@@ -183,7 +210,7 @@ func (fs *FilerServer) dataToChunk(fileName, contentType string, data []byte, ch
 
 	err := util.Retry("filerDataToChunk", func() error {
 		// assign one file id for one chunk
-		fileId, urlLocation, auth, uploadErr = fs.assignNewFileInfo(so)
+		fileId, urlLocation, auth, uploadErr = fs.assignNewFileInfo(so, operation.UploadMaxSize(int64(len(data))))
 		if uploadErr != nil {
 			glog.V(4).Infof("retry later due to assign error: %v", uploadErr)
 			stats.FilerRequestCounter.WithLabelValues(stats.ChunkAssignRetry).Inc()
@@ -214,5 +241,11 @@ func (fs *FilerServer) dataToChunk(fileName, contentType string, data []byte, ch
 	if uploadResult.Size == 0 {
 		return nil, nil
 	}
-	return []*filer_pb.FileChunk{uploadResult.ToPbFileChunk(fileId, chunkOffset)}, nil
+	chunk := uploadResult.ToPbFileChunk(fileId, chunkOffset)
+	if contentHash != "" {
+		if err = fs.filer.Deduper.RecordChunk(context.Background(), so.Collection, contentHash, chunk); err != nil {
+			glog.Errorf("record dedup chunk %s: %v", fileId, err)
+		}
+	}
+	return []*filer_pb.FileChunk{chunk}, nil
 }
@@ -0,0 +1,123 @@
+package weed_server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// list all extended attributes, with the values base64 encoded, or get the raw value of one attribute
+// curl http://localhost:8888/path/to/a/file?xattr
+// curl http://localhost:8888/path/to/a/file?xattr=user.name
+func (fs *FilerServer) GetXattrHandler(w http.ResponseWriter, r *http.Request) {
+
+	entry, err := fs.findXattrEntry(r)
+	if err != nil {
+		writeJsonError(w, r, http.StatusNotFound, err)
+		return
+	}
+
+	name := r.URL.Query().Get("xattr")
+	if name == "" {
+		writeJsonQuiet(w, r, http.StatusOK, entry.Xattrs())
+		return
+	}
+
+	value, err := entry.GetXattr(name)
+	if err != nil {
+		writeJsonError(w, r, xattrErrorStatus(err), err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(value)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(value)
+	}
+}
+
+// add or replace one extended attribute, with the request body as the value
+// curl -X PUT --data-binary @value http://localhost:8888/path/to/a/file?xattr=user.name
+func (fs *FilerServer) PutXattrHandler(w http.ResponseWriter, r *http.Request) {
+
+	name := r.URL.Query().Get("xattr")
+	value, err := io.ReadAll(io.LimitReader(r.Body, filer.MaxXattrValueSize+1))
+	if err != nil {
+		writeJsonError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	fs.updateXattr(w, r, func(entry *filer.Entry) error {
+		return entry.SetXattr(name, value)
+	})
+}
+
+// remove one extended attribute
+// curl -X DELETE http://localhost:8888/path/to/a/file?xattr=user.name
+func (fs *FilerServer) DeleteXattrHandler(w http.ResponseWriter, r *http.Request) {
+
+	name := r.URL.Query().Get("xattr")
+	fs.updateXattr(w, r, func(entry *filer.Entry) error {
+		return entry.RemoveXattr(name)
+	})
+}
+
+func (fs *FilerServer) updateXattr(w http.ResponseWriter, r *http.Request, fn func(entry *filer.Entry) error) {
+
+	fullpath := xattrEntryPath(r)
+	var xattrErr error
+	err := fs.filer.UpdateXattrs(context.Background(), fullpath, func(entry *filer.Entry) error {
+		xattrErr = fn(entry)
+		return xattrErr
+	})
+	if err != nil {
+		if xattrErr != nil {
+			writeJsonError(w, r, xattrErrorStatus(xattrErr), xattrErr)
+		} else if errors.Is(err, filer_pb.ErrNotFound) {
+			writeJsonError(w, r, http.StatusNotFound, err)
+		} else {
+			glog.V(0).Infof("failing to update %s xattr : %v", fullpath, err)
+			writeJsonError(w, r, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	writeJsonQuiet(w, r, http.StatusAccepted, nil)
+}
+
+func (fs *FilerServer) findXattrEntry(r *http.Request) (*filer.Entry, error) {
+	path := xattrEntryPath(r)
+	entry, err := fs.filer.FindEntry(context.Background(), path)
+	if err != nil {
+		return nil, fmt.Errorf("find %s: %v", path, err)
+	}
+	return entry, nil
+}
+
+func xattrEntryPath(r *http.Request) util.FullPath {
+	path := r.URL.Path
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return util.FullPath(path)
+}
+
+func xattrErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, filer.ErrXattrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, filer.ErrXattrReserved):
+		return http.StatusForbidden
+	case errors.Is(err, filer.ErrXattrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,90 @@
+package weed_server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/filer/leveldb"
+)
+
+func newTestFilerServer(t *testing.T) *FilerServer {
+	f := filer.NewFiler(nil, nil, "", "", "", "", "", nil)
+	store := &leveldb.LevelDBStore{}
+	config := viper.New()
+	config.Set("leveldb.dir", t.TempDir())
+	if err := store.Initialize(config, "leveldb."); err != nil {
+		t.Fatalf("initialize store: %v", err)
+	}
+	f.SetStore(store)
+	t.Cleanup(store.Shutdown)
+	return &FilerServer{option: &FilerOption{}, filer: f}
+}
+
+func TestXattrHandlers(t *testing.T) {
+	fs := newTestFilerServer(t)
+	ctx := context.Background()
+	if err := fs.filer.CreateEntry(ctx, &filer.Entry{FullPath: "/dir/file.txt", Attr: filer.Attr{Mode: 0644}}, false, false, nil, false); err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+	request := func(method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, url, strings.NewReader(body))
+		switch method {
+		case http.MethodPut:
+			fs.PutXattrHandler(w, r)
+		case http.MethodDelete:
+			fs.DeleteXattrHandler(w, r)
+		default:
+			fs.GetXattrHandler(w, r)
+		}
+		return w
+	}
+
+	if w := request(http.MethodPut, "/dir/missing.txt?xattr=user.a", "1"); w.Code != http.StatusNotFound {
+		t.Errorf("missing file: expected %d, actual %d", http.StatusNotFound, w.Code)
+	}
+	if w := request(http.MethodPut, "/dir/file.txt?xattr=security.selinux", "1"); w.Code != http.StatusForbidden {
+		t.Errorf("reserved name: expected %d, actual %d", http.StatusForbidden, w.Code)
+	}
+	if w := request(http.MethodDelete, "/dir/file.txt?xattr=user.none", ""); w.Code != http.StatusNotFound {
+		t.Errorf("remove missing attribute: expected %d, actual %d", http.StatusNotFound, w.Code)
+	}
+
+	// the concurrent changes to the attributes of the same file are all kept
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if w := request(http.MethodPut, fmt.Sprintf("/dir/file.txt?xattr=user.a%d", i), fmt.Sprintf("v%d", i)); w.Code != http.StatusAccepted {
+				t.Errorf("set user.a%d: expected %d, actual %d %s", i, http.StatusAccepted, w.Code, w.Body.String())
+			}
+		}(i)
+	}
+	wg.Wait()
+	entry, err := fs.filer.FindEntry(ctx, "/dir/file.txt")
+	if err != nil {
+		t.Fatalf("find file: %v", err)
+	}
+	if xattrs := entry.Xattrs(); len(xattrs) != 100 {
+		t.Errorf("expected 100 attributes, actual %d", len(xattrs))
+	}
+
+	if w := request(http.MethodGet, "/dir/file.txt?xattr=user.a7", ""); w.Code != http.StatusOK || w.Body.String() != "v7" {
+		t.Errorf("get user.a7: %d %q", w.Code, w.Body.String())
+	}
+	if w := request(http.MethodDelete, "/dir/file.txt?xattr=user.a7", ""); w.Code != http.StatusAccepted {
+		t.Errorf("remove user.a7: expected %d, actual %d", http.StatusAccepted, w.Code)
+	}
+	if w := request(http.MethodGet, "/dir/file.txt?xattr=user.a7", ""); w.Code != http.StatusNotFound {
+		t.Errorf("get removed user.a7: expected %d, actual %d", http.StatusNotFound, w.Code)
+	}
+}
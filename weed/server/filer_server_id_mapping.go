@@ -0,0 +1,48 @@
+package weed_server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+func (fs *FilerServer) idMappingOf(path string) *filer.IdMapping {
+	m, err := fs.filer.FilerConf.IdMappingOf(path)
+	if err != nil {
+		glog.Errorf("id mapping of %s: %v", path, err)
+		return nil
+	}
+	return m
+}
+
+// ownerOf returns the uid and gid to save for the entry created by the request.
+// The clients can pass their own ids with the uid and gid query parameters,
+// which are translated by the id mapping of the path.
+func (fs *FilerServer) ownerOf(r *http.Request, path string) (uid, gid uint32) {
+	query := r.URL.Query()
+	uidStr, gidStr := query.Get("uid"), query.Get("gid")
+	if uidStr == "" && gidStr == "" {
+		return OS_UID, OS_GID
+	}
+	uid, gid = OS_UID, OS_GID
+	if v, err := strconv.ParseUint(uidStr, 10, 32); err == nil {
+		uid = uint32(v)
+	}
+	if v, err := strconv.ParseUint(gidStr, 10, 32); err == nil {
+		gid = uint32(v)
+	}
+	return fs.idMappingOf(path).ToFiler(uid, gid)
+}
+
+// entryForClient translates the uid and gid of the entry by the id mapping of its path.
+func (fs *FilerServer) entryForClient(entry *filer.Entry) *filer.Entry {
+	m := fs.idMappingOf(string(entry.FullPath))
+	if m == nil {
+		return entry
+	}
+	clientEntry := entry.ShallowClone()
+	clientEntry.Uid, clientEntry.Gid = m.ToClient(entry.Uid, entry.Gid)
+	return clientEntry
+}
@@ -0,0 +1,125 @@
+package weed_server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// smallFilePacker packs the small files written at about the same time into shared container chunks,
+// so the volume servers keep one needle for many files. Each file only references its range of the container.
+// A write waits until its container is uploaded, at most PackWait after the first file of the container.
+type smallFilePacker struct {
+	fs *FilerServer
+	sync.Mutex
+	// the containers being filled, one for each storage option
+	containers map[operation.StorageOption]*packingContainer
+}
+
+type packingContainer struct {
+	so        operation.StorageOption
+	data      []byte
+	fileCount int
+	timer     *time.Timer
+	// closed after the container is uploaded
+	done  chan struct{}
+	chunk *filer_pb.FileChunk
+	err   error
+}
+
+func newSmallFilePacker(fs *FilerServer) *smallFilePacker {
+	glog.V(0).Infof("pack files up to %d bytes into container chunks of %d bytes", fs.option.PackSmallFileLimit, fs.option.PackContainerSize)
+	return &smallFilePacker{
+		fs:         fs,
+		containers: make(map[operation.StorageOption]*packingContainer),
+	}
+}
+
+// canPack tells whether the file of the size is packed instead of uploaded as its own chunk.
+func (p *smallFilePacker) canPack(dataSize int64, so *operation.StorageOption) bool {
+	return p != nil && !so.Dedup && dataSize > 0 && dataSize <= p.fs.option.PackSmallFileLimit
+}
+
+// pack adds the data to a container, and returns the chunk of the file once the container is uploaded.
+func (p *smallFilePacker) pack(data []byte, so *operation.StorageOption) (*filer_pb.FileChunk, error) {
+	p.Lock()
+	c := p.containers[*so]
+	if c != nil && int64(len(c.data)+len(data)) > p.fs.option.PackContainerSize {
+		p.seal(c)
+		c = nil
+	}
+	if c == nil {
+		c = &packingContainer{
+			so:   *so,
+			done: make(chan struct{}),
+		}
+		p.containers[c.so] = c
+		c.timer = time.AfterFunc(p.fs.option.PackWait, func() {
+			p.Lock()
+			p.seal(c)
+			p.Unlock()
+		})
+	}
+	containerOffset := int64(len(c.data))
+	c.data = append(c.data, data...)
+	c.fileCount++
+	if int64(len(c.data)) >= p.fs.option.PackContainerSize {
+		p.seal(c)
+	}
+	p.Unlock()
+
+	<-c.done
+	if c.err != nil {
+		return nil, c.err
+	}
+	stats.FilerRequestCounter.WithLabelValues(stats.ContentPacked).Inc()
+	return &filer_pb.FileChunk{
+		FileId:          c.chunk.FileId,
+		Fid:             c.chunk.Fid,
+		Offset:          0,
+		Size:            uint64(len(data)),
+		Mtime:           c.chunk.Mtime,
+		ETag:            util.Base64Md5(data),
+		ContainerOffset: containerOffset,
+		ContainerSize:   c.chunk.Size,
+	}, nil
+}
+
+// seal stops packing files into the container and uploads it. p.Lock must be held.
+func (p *smallFilePacker) seal(c *packingContainer) {
+	if p.containers[c.so] != c {
+		// already sealed
+		return
+	}
+	delete(p.containers, c.so)
+	c.timer.Stop()
+	go p.upload(c)
+}
+
+func (p *smallFilePacker) upload(c *packingContainer) {
+	defer close(c.done)
+
+	// the files are located by their offsets in the stored container, so it must not be compressed
+	chunks, err := p.fs.dataToChunk("", "application/octet-stream", c.data, 0, &c.so)
+	if err == nil && (len(chunks) != 1 || chunks[0].IsCompressed) {
+		err = fmt.Errorf("unexpected container chunks %v", chunks)
+	}
+	if err == nil {
+		err = p.fs.filer.PackedContainers.RecordContainer(context.Background(), chunks[0].FileId, c.fileCount)
+	}
+	if err != nil {
+		glog.Errorf("upload container of %d packed files: %v", c.fileCount, err)
+		p.fs.filer.DeleteChunks(chunks)
+		c.err = err
+		return
+	}
+	glog.V(4).Infof("packed %d files into container %s of %d bytes", c.fileCount, chunks[0].FileId, len(c.data))
+	c.chunk = chunks[0]
+}
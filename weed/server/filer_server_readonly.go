@@ -0,0 +1,44 @@
+package weed_server
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+// errFilerReadOnly rejects the changes while the filer is read only, e.g. during a maintenance window.
+var errFilerReadOnly = errors.New("filer is read only for maintenance")
+
+func (fs *FilerServer) isReadOnly() bool {
+	return atomic.LoadInt32(&fs.readOnly) == 1
+}
+
+// setReadOnly switches the read only mode, and returns whether it is changed.
+func (fs *FilerServer) setReadOnly(readOnly bool) bool {
+	var value int32
+	if readOnly {
+		value = 1
+	}
+	if atomic.SwapInt32(&fs.readOnly, value) == value {
+		return false
+	}
+	if readOnly {
+		glog.V(0).Infof("filer is read only, the changes are rejected")
+	} else {
+		glog.V(0).Infof("filer is writable again")
+	}
+	return true
+}
+
+// checkWritable rejects the grpc changes while the filer is read only.
+// FailedPrecondition rather than Unavailable, so the clients do not retry or mark the filer as down.
+func (fs *FilerServer) checkWritable() error {
+	if fs.isReadOnly() {
+		return status.Error(codes.FailedPrecondition, errFilerReadOnly.Error())
+	}
+	return nil
+}
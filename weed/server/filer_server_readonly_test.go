@@ -0,0 +1,79 @@
+package weed_server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+)
+
+func TestFilerReadOnly(t *testing.T) {
+	fs := &FilerServer{
+		option:     &FilerOption{},
+		filerGuard: security.NewGuard(nil, "", 0, "", 0),
+	}
+	if changed := fs.setReadOnly(true); !changed {
+		t.Errorf("expected the mode to change")
+	}
+	if changed := fs.setReadOnly(true); changed {
+		t.Errorf("expected the mode to stay")
+	}
+
+	for _, method := range []string{"POST", "PUT", "PATCH", "DELETE"} {
+		w := httptest.NewRecorder()
+		fs.filerHandler(w, httptest.NewRequest(method, "/dir/file.txt", nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected status %d, actual %d", method, http.StatusServiceUnavailable, w.Code)
+		}
+	}
+	w := httptest.NewRecorder()
+	fs.filerHandler(w, httptest.NewRequest("PUT", "/?proxyChunkId=3,01637037d6", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("proxied PUT: expected status %d, actual %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	_, err := fs.CreateEntry(context.Background(), &filer_pb.CreateEntryRequest{Directory: "/dir", Entry: &filer_pb.Entry{Name: "file.txt"}})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("CreateEntry: expected %v, actual %v", codes.FailedPrecondition, err)
+	}
+	_, err = fs.AcquireAdvisoryLock(context.Background(), &filer_pb.AcquireAdvisoryLockRequest{Path: "/dir/file.txt", Lock: &filer_pb.AdvisoryLock{Owner: "client"}})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("AcquireAdvisoryLock: expected %v, actual %v", codes.FailedPrecondition, err)
+	}
+	_, err = fs.ReleaseAdvisoryLock(context.Background(), &filer_pb.ReleaseAdvisoryLockRequest{Path: "/dir/file.txt", Owner: "client"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("ReleaseAdvisoryLock: expected %v, actual %v", codes.FailedPrecondition, err)
+	}
+	_, err = fs.RenewAdvisoryLocks(context.Background(), &filer_pb.RenewAdvisoryLocksRequest{OwnerPrefix: "client"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("RenewAdvisoryLocks: expected %v, actual %v", codes.FailedPrecondition, err)
+	}
+
+	// changing the mode needs the admin jwt
+	if _, err = fs.SetReadOnly(context.Background(), &filer_pb.SetReadOnlyRequest{ReadOnly: false}); err == nil {
+		t.Fatalf("SetReadOnly without the admin key: expected an error")
+	}
+	fs.adminSigningKey = security.SigningKey("secret")
+	if _, err = fs.SetReadOnly(context.Background(), &filer_pb.SetReadOnlyRequest{ReadOnly: false}); err == nil {
+		t.Fatalf("SetReadOnly without the admin jwt: expected an error")
+	}
+	adminCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+string(security.GenJwtForMasterAdmin(fs.adminSigningKey, 10))))
+	resp, err := fs.SetReadOnly(adminCtx, &filer_pb.SetReadOnlyRequest{ReadOnly: false})
+	if err != nil || resp.ReadOnly {
+		t.Fatalf("SetReadOnly: %v %v", resp, err)
+	}
+	if err = fs.checkWritable(); err != nil {
+		t.Errorf("expected writable, actual %v", err)
+	}
+	resp, _ = fs.SetReadOnly(context.Background(), &filer_pb.SetReadOnlyRequest{ReadOnly: true, GetOnly: true})
+	if resp.ReadOnly {
+		t.Errorf("expected get only to keep the mode")
+	}
+}
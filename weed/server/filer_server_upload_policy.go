@@ -0,0 +1,21 @@
+package weed_server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+)
+
+// uploadPolicyErrorStatus maps the errors of the upload policy to the http status
+func uploadPolicyErrorStatus(err error) (status int, found bool) {
+	switch {
+	case errors.Is(err, filer.ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge, true
+	case errors.Is(err, filer.ErrMimeTypeNotAllowed):
+		return http.StatusUnsupportedMediaType, true
+	case errors.Is(err, filer.ErrFileNameNotAllowed):
+		return http.StatusBadRequest, true
+	}
+	return 0, false
+}
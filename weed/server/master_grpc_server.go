@@ -157,6 +157,9 @@ func (ms *MasterServer) SendHeartbeat(stream master_pb.Seaweed_SendHeartbeatServ
 		}
 
 		dn.AdjustMaxVolumeCounts(heartbeat.MaxVolumeCounts)
+		if len(heartbeat.DiskHealths) > 0 {
+			dn.UpdateDiskHealths(heartbeat.DiskHealths)
+		}
 
 		glog.V(4).Infof("master received heartbeat %s", heartbeat.String())
 		stats.MasterReceivedHeartbeatCounter.WithLabelValues("total").Inc()
@@ -176,6 +179,7 @@ func (ms *MasterServer) SendHeartbeat(stream master_pb.Seaweed_SendHeartbeatServ
 			// process delta volume ids if exists for fast volume id updates
 			for _, volInfo := range heartbeat.NewVolumes {
 				message.NewVids = append(message.NewVids, volInfo.Id)
+				ms.warnIfOutOfVolumeIdRanges(needle.VolumeId(volInfo.Id), dn.Url())
 			}
 			for _, volInfo := range heartbeat.DeletedVolumes {
 				message.DeletedVids = append(message.DeletedVids, volInfo.Id)
@@ -190,6 +194,9 @@ func (ms *MasterServer) SendHeartbeat(stream master_pb.Seaweed_SendHeartbeatServ
 				ms.Topo.DataNodeRegistration(dcName, rackName, dn)
 			}
 
+			dn.SetReadOnly(heartbeat.IsReadonly)
+			dn.SetQuarantinedVolumes(heartbeat.QuarantinedVolumes)
+
 			// process heartbeat.Volumes
 			stats.MasterReceivedHeartbeatCounter.WithLabelValues("Volumes").Inc()
 			newVolumes, deletedVolumes := ms.Topo.SyncDataNodeRegistration(heartbeat.Volumes, dn)
@@ -197,11 +204,26 @@ func (ms *MasterServer) SendHeartbeat(stream master_pb.Seaweed_SendHeartbeatServ
 			for _, v := range newVolumes {
 				glog.V(0).Infof("master see new volume %d from %s", uint32(v.Id), dn.Url())
 				message.NewVids = append(message.NewVids, uint32(v.Id))
+				ms.warnIfOutOfVolumeIdRanges(v.Id, dn.Url())
 			}
 			for _, v := range deletedVolumes {
 				glog.V(0).Infof("master see deleted volume %d from %s", uint32(v.Id), dn.Url())
 				message.DeletedVids = append(message.DeletedVids, uint32(v.Id))
 			}
+
+			if len(heartbeat.VolumeCounterDeltas) > 0 {
+				ms.Topo.RecordVolumeCounterDeltas(heartbeat.VolumeCounterDeltas, time.Now())
+			}
+
+			// let the volume server repair its replicas directly from the peers
+			if outOfSyncVolumes := ms.Topo.OutOfSyncVolumes(dn, time.Now()); len(outOfSyncVolumes) > 0 {
+				if err := stream.Send(&master_pb.HeartbeatResponse{
+					OutOfSyncVolumes: outOfSyncVolumes,
+				}); err != nil {
+					glog.Warningf("SendHeartbeat.Send out of sync volumes to %s:%d %v", dn.Ip, dn.Port, err)
+					return err
+				}
+			}
 		}
 
 		if len(heartbeat.NewEcShards) > 0 || len(heartbeat.DeletedEcShards) > 0 {
@@ -393,13 +415,15 @@ func (ms *MasterServer) GetMasterConfiguration(ctx context.Context, req *master_
 	leader, _ := ms.Topo.Leader()
 
 	resp := &master_pb.GetMasterConfigurationResponse{
-		MetricsAddress:         ms.option.MetricsAddress,
-		MetricsIntervalSeconds: uint32(ms.option.MetricsIntervalSec),
-		StorageBackends:        backend.ToPbStorageBackends(),
-		DefaultReplication:     ms.option.DefaultReplicaPlacement,
-		VolumeSizeLimitMB:      uint32(ms.option.VolumeSizeLimitMB),
-		VolumePreallocate:      ms.option.VolumePreallocate,
-		Leader:                 string(leader),
+		MetricsAddress:               ms.option.MetricsAddress,
+		MetricsIntervalSeconds:       uint32(ms.option.MetricsIntervalSec),
+		StorageBackends:              backend.ToPbStorageBackends(),
+		DefaultReplication:           ms.option.DefaultReplicaPlacement,
+		VolumeSizeLimitMB:            uint32(ms.option.VolumeSizeLimitMB),
+		VolumePreallocate:            ms.option.VolumePreallocate,
+		Leader:                       string(leader),
+		JwtSigningKeyFingerprint:     ms.guard.SigningKey.Fingerprint(),
+		JwtSigningReadKeyFingerprint: ms.guard.ReadSigningKey.Fingerprint(),
 	}
 
 	return resp, nil
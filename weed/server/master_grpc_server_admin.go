@@ -15,6 +15,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
 )
 
 /*
@@ -25,6 +26,7 @@ Shell
 ------
 When shell lock,
   * lease an admin token (lockTime, token)
+  * if already locked, wait in the queue and retry, showing the position in the queue
   * start a goroutine to renew the admin token periodically
 
 When shell unlock
@@ -36,6 +38,7 @@ Master
 Master maintains:
   * randomNumber
   * lastLockTime
+  * the queue of the waiting clients
 When master receives the lease/renew request from shell
   If lastLockTime still fresh {
     if is a renew and token is valid {
@@ -43,14 +46,21 @@ When master receives the lease/renew request from shell
       generate the randomNumber => token
       return
     }
-    refuse
+    queue the client, and return its position in the queue
     return
   } else {
+    if other clients are waiting before this one {
+      queue the client, and return its position in the queue
+      return
+    }
     // for fresh lease request
     generate the randomNumber => token
     return
   }
 
+The lock expires if not renewed within the LockDuration, e.g. the shell died.
+The waiting clients are dropped from the queue if they stop retrying within the LockDuration.
+
 When master receives the release lock request from shell
   set the lastLockTime to zero
 
@@ -71,14 +81,22 @@ type AdminLock struct {
 	lastMessage    string
 }
 
+type lockWaiter struct {
+	clientId   string
+	clientName string
+	lastSeen   time.Time
+}
+
 type AdminLocks struct {
-	locks map[string]*AdminLock
+	locks   map[string]*AdminLock
+	waiters map[string][]*lockWaiter
 	sync.RWMutex
 }
 
 func NewAdminLocks() *AdminLocks {
 	return &AdminLocks{
-		locks: make(map[string]*AdminLock),
+		locks:   make(map[string]*AdminLock),
+		waiters: make(map[string][]*lockWaiter),
 	}
 }
 
@@ -103,18 +121,69 @@ func (locks *AdminLocks) isValidToken(lockName string, ts time.Time, token int64
 	return adminLock.accessLockTime.Equal(ts) && adminLock.accessSecret == token
 }
 
-func (locks *AdminLocks) generateToken(lockName string, clientName string) (ts time.Time, token int64) {
+func (locks *AdminLocks) generateToken(lockName string, clientName string, message string) (ts time.Time, token int64) {
 	locks.Lock()
 	defer locks.Unlock()
 	lock := &AdminLock{
 		accessSecret:   rand.Int63(),
 		accessLockTime: time.Now(),
 		lastClient:     clientName,
+		lastMessage:    message,
 	}
 	locks.locks[lockName] = lock
 	return lock.accessLockTime, lock.accessSecret
 }
 
+// waitInQueue adds the client to the end of the queue, or refreshes it if already queued,
+// and returns its position in the queue starting from 1.
+// The clients without an id are not queued, and only get the position after all the waiting clients.
+func (locks *AdminLocks) waitInQueue(lockName string, clientId string, clientName string) (position int) {
+	locks.Lock()
+	defer locks.Unlock()
+	now := time.Now()
+	var waiters []*lockWaiter
+	for _, waiter := range locks.waiters[lockName] {
+		if waiter.lastSeen.Add(LockDuration).After(now) {
+			waiters = append(waiters, waiter)
+		}
+	}
+	defer func() {
+		if len(waiters) == 0 {
+			delete(locks.waiters, lockName)
+		} else {
+			locks.waiters[lockName] = waiters
+		}
+	}()
+	if clientId == "" {
+		return len(waiters) + 1
+	}
+	for i, waiter := range waiters {
+		if waiter.clientId == clientId {
+			waiter.lastSeen = now
+			return i + 1
+		}
+	}
+	waiters = append(waiters, &lockWaiter{clientId: clientId, clientName: clientName, lastSeen: now})
+	return len(waiters)
+}
+
+func (locks *AdminLocks) leaveQueue(lockName string, clientId string) {
+	locks.Lock()
+	defer locks.Unlock()
+	waiters := locks.waiters[lockName]
+	for i, waiter := range waiters {
+		if waiter.clientId == clientId {
+			waiters = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(waiters) == 0 {
+		delete(locks.waiters, lockName)
+	} else {
+		locks.waiters[lockName] = waiters
+	}
+}
+
 func (locks *AdminLocks) deleteLock(lockName string) {
 	locks.Lock()
 	defer locks.Unlock()
@@ -132,15 +201,29 @@ func (ms *MasterServer) LeaseAdminToken(ctx context.Context, req *master_pb.Leas
 		glog.V(4).Infof("LeaseAdminToken %v", lastClient)
 		if req.PreviousToken != 0 && ms.adminLocks.isValidToken(req.LockName, time.Unix(0, req.PreviousLockTime), req.PreviousToken) {
 			// for renew
-			ts, token := ms.adminLocks.generateToken(req.LockName, req.ClientName)
+			ts, token := ms.adminLocks.generateToken(req.LockName, req.ClientName, req.Message)
 			resp.Token, resp.LockTsNs = token, ts.UnixNano()
 			return resp, nil
 		}
-		// refuse since still locked
-		return resp, fmt.Errorf("already locked by %v: %v", lastClient, lastMessage)
+		if req.ClientId == "" {
+			// refuse since still locked
+			return resp, fmt.Errorf("already locked by %v: %v", lastClient, lastMessage)
+		}
+		resp.QueuePosition = int32(ms.adminLocks.waitInQueue(req.LockName, req.ClientId, req.ClientName))
+		resp.LockOwner, resp.LockMessage = lastClient, lastMessage
+		return resp, nil
 	}
+	// first come first served among the waiting clients
+	if position := ms.adminLocks.waitInQueue(req.LockName, req.ClientId, req.ClientName); position > 1 {
+		if req.ClientId == "" {
+			return resp, fmt.Errorf("%d clients are waiting for the lock", position-1)
+		}
+		resp.QueuePosition = int32(position)
+		return resp, nil
+	}
+	ms.adminLocks.leaveQueue(req.LockName, req.ClientId)
 	// for fresh lease request
-	ts, token := ms.adminLocks.generateToken(req.LockName, req.ClientName)
+	ts, token := ms.adminLocks.generateToken(req.LockName, req.ClientName, req.Message)
 	resp.Token, resp.LockTsNs = token, ts.UnixNano()
 	return resp, nil
 }
@@ -190,3 +273,19 @@ func (ms *MasterServer) Ping(ctx context.Context, req *master_pb.PingRequest) (r
 	resp.StopTimeNs = time.Now().UnixNano()
 	return
 }
+
+// SetLogLevel reads the log levels, or changes them with the master admin JWT.
+func (ms *MasterServer) SetLogLevel(ctx context.Context, req *master_pb.SetLogLevelRequest) (*master_pb.SetLogLevelResponse, error) {
+	if req.Levels != "" {
+		if err := security.VerifyMasterAdminGrpc(ctx, ms.adminSigningKey); err != nil {
+			return nil, err
+		}
+	}
+	levels, err := glog.ChangeLevels(req.Levels)
+	if err != nil {
+		return nil, err
+	}
+	return &master_pb.SetLogLevelResponse{
+		Levels: levels,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package weed_server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdminLocksQueue(t *testing.T) {
+	locks := NewAdminLocks()
+
+	if position := locks.waitInQueue("admin", "a", "host-a"); position != 1 {
+		t.Errorf("first waiter at position %d", position)
+	}
+	if position := locks.waitInQueue("admin", "b", "host-b"); position != 2 {
+		t.Errorf("second waiter at position %d", position)
+	}
+	if position := locks.waitInQueue("admin", "a", "host-a"); position != 1 {
+		t.Errorf("retrying keeps the position, got %d", position)
+	}
+	if position := locks.waitInQueue("admin", "", "old-client"); position != 3 {
+		t.Errorf("client without id after the waiters, got %d", position)
+	}
+	if position := locks.waitInQueue("other", "b", "host-b"); position != 1 {
+		t.Errorf("queues are per lock, got %d", position)
+	}
+
+	locks.leaveQueue("admin", "a")
+	if position := locks.waitInQueue("admin", "b", "host-b"); position != 1 {
+		t.Errorf("moves up after the head leaves, got %d", position)
+	}
+
+	// the waiters stopped retrying are dropped
+	locks.waiters["admin"][0].lastSeen = time.Now().Add(-2 * LockDuration)
+	if position := locks.waitInQueue("admin", "c", "host-c"); position != 1 {
+		t.Errorf("stale waiter should be dropped, got %d", position)
+	}
+}
@@ -2,14 +2,84 @@ package weed_server
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/raft"
+	"google.golang.org/grpc/peer"
 
+	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/topology"
 )
 
+const CollectionDeleteTokenDuration = 10 * time.Minute
+
+// ErrCollectionNotEmpty is returned when deleting a collection with data without a valid force token.
+var ErrCollectionNotEmpty = fmt.Errorf("collection is not empty")
+
+type collectionDeleteToken struct {
+	token    string
+	usage    topology.CollectionUsage
+	issuedAt time.Time
+}
+
+// CollectionDeleteTokens keeps the force tokens issued by collection delete dry runs.
+type CollectionDeleteTokens struct {
+	tokens map[string]*collectionDeleteToken
+	sync.Mutex
+}
+
+func NewCollectionDeleteTokens() *CollectionDeleteTokens {
+	return &CollectionDeleteTokens{
+		tokens: make(map[string]*collectionDeleteToken),
+	}
+}
+
+func (tokens *CollectionDeleteTokens) issue(collection string, usage topology.CollectionUsage) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	tokens.Lock()
+	defer tokens.Unlock()
+	tokens.tokens[collection] = &collectionDeleteToken{
+		token:    token,
+		usage:    usage,
+		issuedAt: time.Now(),
+	}
+	return token
+}
+
+// redeem checks the token once. It also refuses if the collection got more volumes since the dry run,
+// since the operator did not see them.
+func (tokens *CollectionDeleteTokens) redeem(collection string, token string, usage topology.CollectionUsage) error {
+	tokens.Lock()
+	defer tokens.Unlock()
+
+	if token == "" {
+		return fmt.Errorf("%w: %q has %d volumes, %d ec volumes and %d files, run a dry run to get a force token",
+			ErrCollectionNotEmpty, collection, usage.VolumeCount, usage.EcVolumeCount, usage.FileCount)
+	}
+	issued, found := tokens.tokens[collection]
+	if !found || issued.token != token {
+		return fmt.Errorf("%w: invalid force token for %q", ErrCollectionNotEmpty, collection)
+	}
+	delete(tokens.tokens, collection)
+	if issued.issuedAt.Add(CollectionDeleteTokenDuration).Before(time.Now()) {
+		return fmt.Errorf("%w: force token for %q expired, run the dry run again", ErrCollectionNotEmpty, collection)
+	}
+	if usage.VolumeCount > issued.usage.VolumeCount || usage.EcVolumeCount > issued.usage.EcVolumeCount {
+		return fmt.Errorf("%w: %q has more volumes than in the dry run, run the dry run again", ErrCollectionNotEmpty, collection)
+	}
+	return nil
+}
+
 func (ms *MasterServer) CollectionList(ctx context.Context, req *master_pb.CollectionListRequest) (*master_pb.CollectionListResponse, error) {
 
 	if !ms.Topo.IsLeader() {
@@ -19,9 +89,15 @@ func (ms *MasterServer) CollectionList(ctx context.Context, req *master_pb.Colle
 	resp := &master_pb.CollectionListResponse{}
 	collections := ms.Topo.ListCollections(req.IncludeNormalVolumes, req.IncludeEcVolumes)
 	for _, c := range collections {
-		resp.Collections = append(resp.Collections, &master_pb.Collection{
+		collection := &master_pb.Collection{
 			Name: c,
-		})
+		}
+		if req.IncludeUsage {
+			usage := ms.Topo.GetCollectionUsage(c)
+			collection.FileCount = usage.FileCount
+			collection.DataSize = usage.DataSize
+		}
+		resp.Collections = append(resp.Collections, collection)
 	}
 
 	return resp, nil
@@ -33,17 +109,48 @@ func (ms *MasterServer) CollectionDelete(ctx context.Context, req *master_pb.Col
 		return nil, raft.ErrNotLeader
 	}
 
-	resp := &master_pb.CollectionDeleteResponse{}
+	client := "unknown"
+	if pr, ok := peer.FromContext(ctx); ok {
+		client = pr.Addr.String()
+	}
+
+	return ms.deleteCollection(req, client)
+}
 
-	err := ms.doDeleteNormalCollection(req.Name)
+// deleteCollection refuses to delete a collection with data, unless the request carries
+// the force token returned by a recent dry run of the same collection.
+func (ms *MasterServer) deleteCollection(req *master_pb.CollectionDeleteRequest, client string) (*master_pb.CollectionDeleteResponse, error) {
 
-	if err != nil {
-		return nil, err
+	usage := ms.Topo.GetCollectionUsage(req.Name)
+	resp := &master_pb.CollectionDeleteResponse{
+		VolumeCount:   usage.VolumeCount,
+		EcVolumeCount: usage.EcVolumeCount,
+		FileCount:     usage.FileCount,
+		Size:          usage.Size,
 	}
 
-	err = ms.doDeleteEcCollection(req.Name)
+	if req.DryRun {
+		resp.ForceToken = ms.collectionDeleteTokens.issue(req.Name, usage)
+		glog.V(0).Infof("audit: collection delete dry run of %q by %s: %d volumes, %d ec volumes, %d files, %d bytes",
+			req.Name, client, usage.VolumeCount, usage.EcVolumeCount, usage.FileCount, usage.Size)
+		return resp, nil
+	}
+
+	if !usage.IsEmpty() {
+		if err := ms.collectionDeleteTokens.redeem(req.Name, req.ForceToken, usage); err != nil {
+			glog.Warningf("audit: collection delete of %q by %s is refused: %v", req.Name, client, err)
+			return nil, err
+		}
+	}
+
+	glog.V(0).Infof("audit: collection delete of %q by %s: %d volumes, %d ec volumes, %d files, %d bytes",
+		req.Name, client, usage.VolumeCount, usage.EcVolumeCount, usage.FileCount, usage.Size)
+
+	if err := ms.doDeleteNormalCollection(req.Name); err != nil {
+		return nil, err
+	}
 
-	if err != nil {
+	if err := ms.doDeleteEcCollection(req.Name); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,42 @@
+package weed_server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/topology"
+)
+
+func TestCollectionDeleteTokens(t *testing.T) {
+	tokens := NewCollectionDeleteTokens()
+	usage := topology.CollectionUsage{VolumeCount: 3, FileCount: 100, Size: 1024}
+
+	if err := tokens.redeem("pictures", "", usage); !errors.Is(err, ErrCollectionNotEmpty) {
+		t.Errorf("delete without token: %v", err)
+	}
+
+	token := tokens.issue("pictures", usage)
+	if err := tokens.redeem("documents", token, usage); !errors.Is(err, ErrCollectionNotEmpty) {
+		t.Errorf("token of another collection: %v", err)
+	}
+	if err := tokens.redeem("pictures", token, usage); err != nil {
+		t.Errorf("valid token: %v", err)
+	}
+	if err := tokens.redeem("pictures", token, usage); !errors.Is(err, ErrCollectionNotEmpty) {
+		t.Errorf("token should be used only once: %v", err)
+	}
+
+	token = tokens.issue("pictures", usage)
+	grown := usage
+	grown.VolumeCount++
+	if err := tokens.redeem("pictures", token, grown); !errors.Is(err, ErrCollectionNotEmpty) {
+		t.Errorf("collection with more volumes: %v", err)
+	}
+
+	token = tokens.issue("pictures", usage)
+	tokens.tokens["pictures"].issuedAt = time.Now().Add(-CollectionDeleteTokenDuration - time.Second)
+	if err := tokens.redeem("pictures", token, usage); !errors.Is(err, ErrCollectionNotEmpty) {
+		t.Errorf("expired token: %v", err)
+	}
+}
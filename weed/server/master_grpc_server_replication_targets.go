@@ -0,0 +1,152 @@
+package weed_server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hashicorp/raft"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/shell"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/topology"
+)
+
+func (ms *MasterServer) GetReplicationTargets(ctx context.Context, req *master_pb.GetReplicationTargetsRequest) (*master_pb.GetReplicationTargetsResponse, error) {
+
+	if !ms.Topo.IsLeader() {
+		return nil, raft.ErrNotLeader
+	}
+
+	resp := &master_pb.GetReplicationTargetsResponse{}
+	for _, target := range ms.Topo.GetReplicationTargets() {
+		resp.Targets = append(resp.Targets, &master_pb.ReplicationTarget{
+			VolumeId:          target.VolumeId,
+			CollectionPattern: target.CollectionPattern,
+			Replication:       target.Replication,
+		})
+	}
+
+	return resp, nil
+}
+
+func (ms *MasterServer) SetReplicationTarget(ctx context.Context, req *master_pb.SetReplicationTargetRequest) (*master_pb.SetReplicationTargetResponse, error) {
+
+	if !ms.Topo.IsLeader() {
+		return nil, raft.ErrNotLeader
+	}
+	if req.Target == nil {
+		return nil, fmt.Errorf("missing replication target")
+	}
+
+	target := topology.ReplicationTarget{
+		VolumeId:          req.Target.VolumeId,
+		CollectionPattern: req.Target.CollectionPattern,
+		Replication:       req.Target.Replication,
+	}
+	if !req.IsDelete {
+		replicaPlacement, err := super_block.NewReplicaPlacementFromString(target.Replication)
+		if err != nil {
+			return nil, fmt.Errorf("replication format: %v", err)
+		}
+		target.Replication = replicaPlacement.String()
+	}
+	if err := ms.Topo.SetReplicationTarget(target, req.IsDelete); err != nil {
+		return nil, fmt.Errorf("set %v: %v", target, err)
+	}
+	if req.IsDelete {
+		glog.V(0).Infof("removed replication target of %v", target)
+	} else {
+		glog.V(0).Infof("added replication target %v", target)
+	}
+
+	return &master_pb.SetReplicationTargetResponse{}, nil
+}
+
+// startReplicationTargetFixer configures the replication of the volumes of each target, and fixes their replicas,
+// until all volumes of the target reach the replication. Then the target is removed.
+func (ms *MasterServer) startReplicationTargetFixer(interval time.Duration) {
+	var commandEnv *shell.CommandEnv
+
+	go func() {
+		for {
+			time.Sleep(interval)
+			if !ms.Topo.IsLeader() || ms.MasterClient.GetMaster() == "" {
+				continue
+			}
+			targets := ms.Topo.GetReplicationTargets()
+			if len(targets) == 0 {
+				continue
+			}
+			if commandEnv == nil {
+				commandEnv, _ = ms.newShellCommandEnv()
+			}
+			if err := ms.fixReplicationTargets(commandEnv, targets); err != nil {
+				glog.V(0).Infof("fix replication targets: %v", err)
+			}
+		}
+	}()
+}
+
+func (ms *MasterServer) fixReplicationTargets(commandEnv *shell.CommandEnv, targets []topology.ReplicationTarget) error {
+	var reached []topology.ReplicationTarget
+	topologyInfo := ms.Topo.ToTopologyInfo()
+	for _, target := range targets {
+		isReached, err := topology.IsReplicationTargetReached(topologyInfo, target)
+		if err != nil {
+			glog.V(0).Infof("drop %v: %v", target, err)
+			reached = append(reached, target)
+			continue
+		}
+		if isReached {
+			glog.V(0).Infof("%v is reached", target)
+			reached = append(reached, target)
+		}
+	}
+	if len(reached) > 0 {
+		if err := ms.Topo.RemoveReplicationTargets(reached); err != nil {
+			return err
+		}
+	}
+	if len(reached) == len(targets) {
+		return nil
+	}
+
+	if err := runShellCommand(commandEnv, "lock"); err != nil {
+		return err
+	}
+	defer runShellCommand(commandEnv, "unlock")
+
+	// the targets may be changed or removed while waiting for the lock
+	remaining := ms.Topo.GetReplicationTargets()
+	for _, target := range remaining {
+		configureArgs := []string{"-replication", target.Replication, "-autoFix=false"}
+		var fixArgs []string
+		if target.VolumeId != 0 {
+			configureArgs = append(configureArgs, "-volumeId", fmt.Sprintf("%d", target.VolumeId))
+		} else {
+			configureArgs = append(configureArgs, "-collectionPattern", target.CollectionPattern)
+			fixArgs = append(fixArgs, "-collectionPattern", target.CollectionPattern)
+		}
+		glog.V(0).Infof("fixing %v", target)
+		if err := runShellCommand(commandEnv, "volume.configure.replication", configureArgs...); err != nil {
+			return fmt.Errorf("configure %v: %v", target, err)
+		}
+		if err := runShellCommand(commandEnv, "volume.fix.replication", fixArgs...); err != nil {
+			return fmt.Errorf("fix %v: %v", target, err)
+		}
+	}
+	return nil
+}
+
+func runShellCommand(commandEnv *shell.CommandEnv, name string, args ...string) error {
+	for _, c := range shell.Commands {
+		if c.Name() == name {
+			return c.Do(args, commandEnv, io.Discard)
+		}
+	}
+	return fmt.Errorf("unknown command %s", name)
+}
@@ -0,0 +1,48 @@
+package weed_server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/raft"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/topology"
+)
+
+func (ms *MasterServer) ExportClusterSnapshot(ctx context.Context, req *master_pb.ExportClusterSnapshotRequest) (*master_pb.ExportClusterSnapshotResponse, error) {
+
+	if !ms.Topo.IsLeader() {
+		return nil, raft.ErrNotLeader
+	}
+
+	snapshot, err := json.MarshalIndent(ms.Topo.ExportSnapshot(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal cluster snapshot: %v", err)
+	}
+
+	return &master_pb.ExportClusterSnapshotResponse{
+		Snapshot: snapshot,
+	}, nil
+}
+
+func (ms *MasterServer) RestoreClusterSnapshot(ctx context.Context, req *master_pb.RestoreClusterSnapshotRequest) (*master_pb.RestoreClusterSnapshotResponse, error) {
+
+	if !ms.Topo.IsLeader() {
+		return nil, raft.ErrNotLeader
+	}
+
+	snapshot, err := topology.ParseClusterSnapshot(req.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if err = ms.Topo.RestoreSnapshot(snapshot); err != nil {
+		return nil, fmt.Errorf("restore cluster snapshot: %v", err)
+	}
+	glog.V(0).Infof("restored cluster snapshot of %v: max volume id %d, file key sequence %d, volume id ranges %q, %d replication targets",
+		snapshot.CreatedAt, snapshot.MaxVolumeId, snapshot.FileKeySequence, snapshot.VolumeIdRanges, len(snapshot.ReplicationTargets))
+
+	return &master_pb.RestoreClusterSnapshotResponse{}, nil
+}
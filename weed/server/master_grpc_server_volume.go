@@ -138,6 +138,7 @@ func (ms *MasterServer) Assign(ctx context.Context, req *master_pb.AssignRequest
 		DataCenter:         req.DataCenter,
 		Rack:               req.Rack,
 		DataNode:           req.DataNode,
+		ChecksumAlgorithm:  ms.option.VolumeChecksum,
 		MemoryMapMaxSizeMb: req.MemoryMapMaxSizeMb,
 	}
 
@@ -182,7 +183,7 @@ func (ms *MasterServer) Assign(ctx context.Context, req *master_pb.AssignRequest
 					DataCenter: dn.GetDataCenterId(),
 				},
 				Count:    count,
-				Auth:     string(security.GenJwtForVolumeServer(ms.guard.SigningKey, ms.guard.ExpiresAfterSec, fid)),
+				Auth:     string(security.GenJwtForVolumeServerUpload(ms.guard.SigningKey, ms.guard.ExpiresAfterSec, fid, req.Collection, ms.uploadMaxSize(req.MaxSize))),
 				Replicas: replicas,
 			}, nil
 		}
@@ -284,6 +285,28 @@ func (ms *MasterServer) VacuumVolume(ctx context.Context, req *master_pb.VacuumV
 	return resp, nil
 }
 
+func (ms *MasterServer) StreamVacuumVolume(req *master_pb.VacuumVolumeRequest, stream master_pb.Seaweed_StreamVacuumVolumeServer) error {
+
+	if !ms.Topo.IsLeader() {
+		return raft.ErrNotLeader
+	}
+
+	// the volumes are vacuumed concurrently, while the stream can only be sent one at a time
+	var sendLock sync.Mutex
+	var sendErr error
+	err := ms.Topo.VacuumWithProgress(ms.grpcDialOption, float64(req.GarbageThreshold), req.VolumeId, req.Collection, ms.preallocateSize, func(progress *master_pb.VacuumVolumeProgress) {
+		sendLock.Lock()
+		defer sendLock.Unlock()
+		if sendErr == nil {
+			sendErr = stream.Send(progress)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return sendErr
+}
+
 func (ms *MasterServer) VolumeMarkReadonly(ctx context.Context, req *master_pb.VolumeMarkReadonlyRequest) (*master_pb.VolumeMarkReadonlyResponse, error) {
 
 	if !ms.Topo.IsLeader() {
@@ -0,0 +1,59 @@
+package weed_server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/raft"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/topology"
+)
+
+func (ms *MasterServer) GetVolumeIdRanges(ctx context.Context, req *master_pb.GetVolumeIdRangesRequest) (*master_pb.GetVolumeIdRangesResponse, error) {
+
+	if !ms.Topo.IsLeader() {
+		return nil, raft.ErrNotLeader
+	}
+
+	ranges := ms.Topo.GetVolumeIdRanges()
+	maxVolumeId := ms.Topo.GetMaxVolumeId()
+	resp := &master_pb.GetVolumeIdRangesResponse{
+		VolumeIdRanges: ranges.String(),
+		MaxVolumeId:    uint32(maxVolumeId),
+	}
+	if next, found := ranges.NextAfter(maxVolumeId); found {
+		resp.NextVolumeId = uint32(next)
+	}
+	for _, vid := range ms.Topo.ListOutOfRangeVolumeIds() {
+		resp.OutOfRangeVolumeIds = append(resp.OutOfRangeVolumeIds, uint32(vid))
+	}
+
+	return resp, nil
+}
+
+func (ms *MasterServer) SetVolumeIdRanges(ctx context.Context, req *master_pb.SetVolumeIdRangesRequest) (*master_pb.SetVolumeIdRangesResponse, error) {
+
+	if !ms.Topo.IsLeader() {
+		return nil, raft.ErrNotLeader
+	}
+
+	ranges, err := topology.ParseVolumeIdRanges(req.VolumeIdRanges)
+	if err != nil {
+		return nil, err
+	}
+	if err = ms.Topo.ChangeVolumeIdRanges(ranges); err != nil {
+		return nil, fmt.Errorf("change volume id ranges to %q: %v", req.VolumeIdRanges, err)
+	}
+	glog.V(0).Infof("volume id ranges changed to %q", ranges.String())
+
+	return &master_pb.SetVolumeIdRangesResponse{}, nil
+}
+
+func (ms *MasterServer) warnIfOutOfVolumeIdRanges(vid needle.VolumeId, url string) {
+	if ranges := ms.Topo.GetVolumeIdRanges(); !ranges.Contains(vid) {
+		glog.Warningf("volume %d on %s is out of the volume id ranges %s", vid, url, ranges)
+	}
+}
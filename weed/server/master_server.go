@@ -33,6 +33,8 @@ import (
 const (
 	SequencerType        = "master.sequencer.type"
 	SequencerSnowflakeId = "master.sequencer.sequencer_snowflake_id"
+	SequencerEtcdUrls    = "master.sequencer.sequencer_etcd_urls"
+	SequencerEtcdKey     = "master.sequencer.sequencer_etcd_key"
 )
 
 type MasterOption struct {
@@ -40,6 +42,7 @@ type MasterOption struct {
 	MetaFolder        string
 	VolumeSizeLimitMB uint32
 	VolumePreallocate bool
+	VolumeChecksum    string
 	// PulseSeconds            int
 	DefaultReplicaPlacement string
 	GarbageThreshold        float64
@@ -74,6 +77,13 @@ type MasterServer struct {
 	adminLocks *AdminLocks
 
 	Cluster *cluster.Cluster
+
+	collectionDeleteTokens *CollectionDeleteTokens
+
+	// the volume actions of the master UI
+	adminSigningKey  security.SigningKey
+	volumeActionLock sync.Mutex
+	volumeActionEnv  *shell.CommandEnv
 }
 
 func NewMasterServer(r *mux.Router, option *MasterOption, peers map[string]rpc.ServerAddress) *MasterServer {
@@ -110,6 +120,9 @@ func NewMasterServer(r *mux.Router, option *MasterOption, peers map[string]rpc.S
 		MasterClient:    wdclient.NewMasterClient(grpcDialOption, "", cluster.MasterType, option.Master, "", "", peers),
 		adminLocks:      NewAdminLocks(),
 		Cluster:         cluster.NewCluster(),
+
+		collectionDeleteTokens: NewCollectionDeleteTokens(),
+		adminSigningKey:        security.SigningKey(v.GetString("jwt.master_admin.key")),
 	}
 	ms.boundedLeaderChan = make(chan int, 16)
 
@@ -132,11 +145,18 @@ func NewMasterServer(r *mux.Router, option *MasterOption, peers map[string]rpc.S
 		r.HandleFunc("/dir/assign", ms.proxyToLeader(ms.guard.WhiteList(ms.dirAssignHandler)))
 		r.HandleFunc("/dir/lookup", ms.guard.WhiteList(ms.dirLookupHandler))
 		r.HandleFunc("/dir/status", ms.proxyToLeader(ms.guard.WhiteList(ms.dirStatusHandler)))
+		r.HandleFunc("/dir/browse", ms.proxyToLeader(ms.guard.WhiteList(ms.dirBrowseHandler)))
 		r.HandleFunc("/col/delete", ms.proxyToLeader(ms.guard.WhiteList(ms.collectionDeleteHandler)))
 		r.HandleFunc("/vol/grow", ms.proxyToLeader(ms.guard.WhiteList(ms.volumeGrowHandler)))
 		r.HandleFunc("/vol/status", ms.proxyToLeader(ms.guard.WhiteList(ms.volumeStatusHandler)))
+		r.HandleFunc("/vol/forecast", ms.proxyToLeader(ms.guard.WhiteList(ms.volumeForecastHandler)))
 		r.HandleFunc("/vol/vacuum", ms.proxyToLeader(ms.guard.WhiteList(ms.volumeVacuumHandler)))
+		r.HandleFunc("/vol/action/vacuum", ms.proxyToLeader(ms.volumeActionGuard(ms.volumeActionVacuumHandler)))
+		r.HandleFunc("/vol/action/readonly", ms.proxyToLeader(ms.volumeActionGuard(ms.volumeActionReadonlyHandler)))
+		r.HandleFunc("/vol/action/fix_replication", ms.proxyToLeader(ms.volumeActionGuard(ms.volumeActionFixReplicationHandler)))
 		r.HandleFunc("/submit", ms.guard.WhiteList(ms.submitFromMasterServerHandler))
+		r.HandleFunc("/log/level", ms.guard.WhiteList(ms.logLevelHandler)).Methods(http.MethodGet)
+		r.HandleFunc("/log/level", ms.adminGuard(ms.setLogLevelHandler)).Methods(http.MethodPost)
 		/*
 			r.HandleFunc("/stats/health", ms.guard.WhiteList(statsHealthHandler))
 			r.HandleFunc("/stats/counter", ms.guard.WhiteList(statsCounterHandler))
@@ -145,6 +165,19 @@ func NewMasterServer(r *mux.Router, option *MasterOption, peers map[string]rpc.S
 		r.HandleFunc("/{fileId}", ms.redirectHandler)
 	}
 
+	if volumeIdRanges := v.GetString("master.volume_id.ranges"); volumeIdRanges != "" {
+		ranges, err := topology.ParseVolumeIdRanges(volumeIdRanges)
+		if err != nil {
+			glog.Fatalf("master.volume_id.ranges: %v", err)
+		}
+		ms.Topo.LoadVolumeIdRanges(ranges)
+	}
+
+	ms.Topo.SetVacuumLimits(
+		uint64(v.GetInt64("master.vacuum.garbage_bytes_threshold_mb"))*1024*1024,
+		v.GetInt("master.vacuum.concurrent_limit"),
+	)
+
 	ms.Topo.StartRefreshWritableVolumes(
 		ms.grpcDialOption,
 		ms.option.GarbageThreshold,
@@ -152,10 +185,16 @@ func NewMasterServer(r *mux.Router, option *MasterOption, peers map[string]rpc.S
 		ms.preallocateSize,
 	)
 
+	ms.Topo.StartCapacityForecast()
+
 	ms.ProcessGrowRequest()
 
 	if !option.IsFollower {
 		ms.startAdminScripts()
+		v.SetDefault("master.replication.fix_interval_minutes", 5)
+		ms.startReplicationTargetFixer(time.Duration(v.GetInt("master.replication.fix_interval_minutes")) * time.Minute)
+		v.SetDefault("master.replication.check_diverged_interval_minutes", 0)
+		ms.startDivergedReplicaChecker(time.Duration(v.GetInt("master.replication.check_diverged_interval_minutes")) * time.Minute)
 	}
 
 	return ms
@@ -253,22 +292,10 @@ func (ms *MasterServer) startAdminScripts() {
 		scriptLines = append(scriptLines, "unlock")
 	}
 
-	masterAddress := string(ms.option.Master)
-
-	var shellOptions shell.ShellOptions
-	shellOptions.GrpcDialOption = grpc.WithTransportCredentials(insecure.NewCredentials())
-	shellOptions.Masters = &masterAddress
-
-	shellOptions.Directory = "/"
-	emptyFilerGroup := ""
-	shellOptions.FilerGroup = &emptyFilerGroup
-
-	commandEnv := shell.NewCommandEnv(&shellOptions)
+	commandEnv, shellOptions := ms.newShellCommandEnv()
 
 	reg, _ := regexp.Compile(`'.*?'|".*?"|\S+`)
 
-	go commandEnv.MasterClient.KeepConnectedToMaster()
-
 	go func() {
 		for {
 			time.Sleep(time.Duration(sleepMinutes) * time.Minute)
@@ -287,6 +314,25 @@ func (ms *MasterServer) startAdminScripts() {
 	}()
 }
 
+// newShellCommandEnv creates the environment to run shell commands in the master, connected to this master.
+func (ms *MasterServer) newShellCommandEnv() (*shell.CommandEnv, *shell.ShellOptions) {
+	masterAddress := string(ms.option.Master)
+
+	shellOptions := &shell.ShellOptions{}
+	shellOptions.GrpcDialOption = grpc.WithTransportCredentials(insecure.NewCredentials())
+	shellOptions.Masters = &masterAddress
+
+	shellOptions.Directory = "/"
+	emptyFilerGroup := ""
+	shellOptions.FilerGroup = &emptyFilerGroup
+
+	commandEnv := shell.NewCommandEnv(shellOptions)
+
+	go commandEnv.MasterClient.KeepConnectedToMaster()
+
+	return commandEnv, shellOptions
+}
+
 func processEachCmd(reg *regexp.Regexp, line string, commandEnv *shell.CommandEnv) {
 	cmds := reg.FindAllString(line, -1)
 	if len(cmds) == 0 {
@@ -322,6 +368,14 @@ func (ms *MasterServer) createSequencer(option *MasterOption) sequence.Sequencer
 			glog.Error(err)
 			seq = nil
 		}
+	case "etcd":
+		var err error
+		urls := util.StringSplit(v.GetString(SequencerEtcdUrls), ",")
+		seq, err = sequence.NewEtcdSequencer(urls, v.GetString(SequencerEtcdKey))
+		if err != nil {
+			glog.Error(err)
+			seq = nil
+		}
 	default:
 		seq = sequence.NewMemorySequencer()
 	}
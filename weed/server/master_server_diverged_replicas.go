@@ -0,0 +1,53 @@
+package weed_server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/shell"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+)
+
+// startDivergedReplicaChecker runs "volume.check.disk" on just the volumes whose replicas diverged,
+// found from the index checksums in the heartbeats. It is off by default.
+func (ms *MasterServer) startDivergedReplicaChecker(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	var commandEnv *shell.CommandEnv
+
+	go func() {
+		for {
+			time.Sleep(interval)
+			if !ms.Topo.IsLeader() || ms.MasterClient.GetMaster() == "" {
+				continue
+			}
+			divergedVolumes := ms.Topo.DivergedVolumes(time.Now())
+			if len(divergedVolumes) == 0 {
+				continue
+			}
+			if commandEnv == nil {
+				commandEnv, _ = ms.newShellCommandEnv()
+			}
+			if err := checkDivergedVolumes(commandEnv, divergedVolumes); err != nil {
+				glog.V(0).Infof("check diverged volumes: %v", err)
+			}
+		}
+	}()
+}
+
+func checkDivergedVolumes(commandEnv *shell.CommandEnv, divergedVolumes []needle.VolumeId) error {
+	if err := runShellCommand(commandEnv, "lock"); err != nil {
+		return err
+	}
+	defer runShellCommand(commandEnv, "unlock")
+
+	for _, vid := range divergedVolumes {
+		glog.V(0).Infof("volume %d has diverged replicas, checking disk", vid)
+		if err := runShellCommand(commandEnv, "volume.check.disk", "-volumeId", fmt.Sprintf("%d", vid), "-slow", "-force"); err != nil {
+			glog.Warningf("check disk of volume %d: %v", vid, err)
+		}
+	}
+	return nil
+}
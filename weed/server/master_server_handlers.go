@@ -111,6 +111,12 @@ func (ms *MasterServer) dirAssignHandler(w http.ResponseWriter, r *http.Request)
 		writableVolumeCount = 0
 	}
 
+	// the max bytes to upload with the returned auth, up to the volume size limit
+	maxSize, e := strconv.ParseInt(r.FormValue("maxSize"), 10, 64)
+	if e != nil {
+		maxSize = 0
+	}
+
 	option, err := ms.getVolumeGrowOption(r)
 	if err != nil {
 		writeJsonQuiet(w, r, http.StatusNotAcceptable, operation.AssignResult{Error: err.Error()})
@@ -139,7 +145,7 @@ func (ms *MasterServer) dirAssignHandler(w http.ResponseWriter, r *http.Request)
 	}
 	fid, count, dnList, err := ms.Topo.PickForWrite(requestedCount, option)
 	if err == nil {
-		ms.maybeAddJwtAuthorization(w, fid, true)
+		ms.maybeAddJwtUploadAuthorization(w, fid, option.Collection, maxSize)
 		dn := dnList.Head()
 		writeJsonQuiet(w, r, http.StatusOK, operation.AssignResult{Fid: fid, Url: dn.Url(), PublicUrl: dn.PublicUrl, Count: count})
 	} else {
@@ -147,6 +153,24 @@ func (ms *MasterServer) dirAssignHandler(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// maybeAddJwtUploadAuthorization allows to upload the file only into the collection, up to the max size capped by the volume size limit
+func (ms *MasterServer) maybeAddJwtUploadAuthorization(w http.ResponseWriter, fileId string, collection string, maxSize int64) {
+	encodedJwt := security.GenJwtForVolumeServerUpload(ms.guard.SigningKey, ms.guard.ExpiresAfterSec, fileId, collection, ms.uploadMaxSize(maxSize))
+	if encodedJwt == "" {
+		return
+	}
+	w.Header().Set("Authorization", "BEARER "+string(encodedJwt))
+}
+
+// uploadMaxSize is the requested max size of an upload, capped by the volume size limit
+func (ms *MasterServer) uploadMaxSize(requestedMaxSize int64) int64 {
+	volumeSizeLimit := int64(ms.option.VolumeSizeLimitMB) * 1024 * 1024
+	if requestedMaxSize > 0 && requestedMaxSize < volumeSizeLimit {
+		return requestedMaxSize
+	}
+	return volumeSizeLimit
+}
+
 func (ms *MasterServer) maybeAddJwtAuthorization(w http.ResponseWriter, fileId string, isWrite bool) {
 	if fileId == "" {
 		return
@@ -1,17 +1,17 @@
 package weed_server
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
-	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
-	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
 	"github.com/seaweedfs/seaweedfs/weed/storage/backend/memory_map"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
@@ -22,24 +22,27 @@ import (
 
 func (ms *MasterServer) collectionDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	collectionName := r.FormValue("collection")
-	collection, ok := ms.Topo.FindCollection(collectionName)
-	if !ok {
+	if _, ok := ms.Topo.FindCollection(collectionName); !ok {
 		writeJsonError(w, r, http.StatusBadRequest, fmt.Errorf("collection %s does not exist", collectionName))
 		return
 	}
-	for _, server := range collection.ListVolumeServers() {
-		err := operation.WithVolumeServerClient(false, server.ServerAddress(), ms.grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
-			_, deleteErr := client.DeleteCollection(context.Background(), &volume_server_pb.DeleteCollectionRequest{
-				Collection: collection.Name,
-			})
-			return deleteErr
-		})
-		if err != nil {
-			writeJsonError(w, r, http.StatusInternalServerError, err)
-			return
-		}
+	resp, err := ms.deleteCollection(&master_pb.CollectionDeleteRequest{
+		Name:       collectionName,
+		DryRun:     r.FormValue("dryRun") == "true",
+		ForceToken: r.FormValue("forceToken"),
+	}, r.RemoteAddr)
+	if errors.Is(err, ErrCollectionNotEmpty) {
+		writeJsonError(w, r, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		writeJsonError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if r.FormValue("dryRun") == "true" {
+		writeJsonQuiet(w, r, http.StatusOK, resp)
+		return
 	}
-	ms.Topo.DeleteCollection(collectionName)
 
 	w.WriteHeader(http.StatusNoContent)
 	return
@@ -52,6 +55,15 @@ func (ms *MasterServer) dirStatusHandler(w http.ResponseWriter, r *http.Request)
 	writeJsonQuiet(w, r, http.StatusOK, m)
 }
 
+func (ms *MasterServer) dirBrowseHandler(w http.ResponseWriter, r *http.Request) {
+	browse, err := ms.Topo.Browse(r.FormValue("dc"), r.FormValue("rack"), r.FormValue("node"))
+	if err != nil {
+		writeJsonError(w, r, http.StatusNotFound, err)
+		return
+	}
+	writeJsonQuiet(w, r, http.StatusOK, browse)
+}
+
 func (ms *MasterServer) volumeVacuumHandler(w http.ResponseWriter, r *http.Request) {
 	gcString := r.FormValue("garbageThreshold")
 	gcThreshold := ms.option.GarbageThreshold
@@ -104,6 +116,48 @@ func (ms *MasterServer) volumeStatusHandler(w http.ResponseWriter, r *http.Reque
 	writeJsonQuiet(w, r, http.StatusOK, m)
 }
 
+func (ms *MasterServer) volumeForecastHandler(w http.ResponseWriter, r *http.Request) {
+	forecasts, collectionGrowths := ms.Topo.CapacityForecasts()
+	m := make(map[string]interface{})
+	m["Version"] = util.Version()
+	m["Forecasts"] = forecasts
+	m["CollectionGrowths"] = collectionGrowths
+	writeJsonQuiet(w, r, http.StatusOK, m)
+}
+
+// get the log levels of the master process, shared by all servers running in one weed server process
+// curl http://localhost:9333/log/level
+func (ms *MasterServer) logLevelHandler(w http.ResponseWriter, r *http.Request) {
+	writeJsonQuiet(w, r, http.StatusOK, map[string]string{"levels": glog.Levels()})
+}
+
+// change the log levels, by v for the -v level or the component names of -vcomponent
+// curl -X POST -H "Authorization: Bearer <master admin jwt>" "http://localhost:9333/log/level?v=1&filer=3"
+func (ms *MasterServer) setLogLevelHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJsonError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	var specs []string
+	for name, values := range r.Form {
+		// the admin jwt may be passed as a query parameter
+		if name == "jwt" {
+			continue
+		}
+		for _, value := range values {
+			specs = append(specs, name+"="+value)
+		}
+	}
+	sort.Strings(specs)
+	levels, err := glog.ChangeLevels(strings.Join(specs, ","))
+	if err != nil {
+		writeJsonError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	glog.V(0).Infof("log level request from %s", r.RemoteAddr)
+	writeJsonQuiet(w, r, http.StatusOK, map[string]string{"levels": levels})
+}
+
 func (ms *MasterServer) redirectHandler(w http.ResponseWriter, r *http.Request) {
 	vid, _, _, _, _ := parseURLPath(r.URL.Path)
 	collection := r.FormValue("collection")
@@ -170,6 +224,7 @@ func (ms *MasterServer) getVolumeGrowOption(r *http.Request) (*topology.VolumeGr
 		DataCenter:         r.FormValue("dataCenter"),
 		Rack:               r.FormValue("rack"),
 		DataNode:           r.FormValue("dataNode"),
+		ChecksumAlgorithm:  ms.option.VolumeChecksum,
 		MemoryMapMaxSizeMb: memoryMapMaxSizeMb,
 	}
 	return volumeGrowOption, nil
@@ -8,6 +8,7 @@ import (
 
 	ui "github.com/seaweedfs/seaweedfs/weed/server/master_ui"
 	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/topology"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
@@ -20,6 +21,8 @@ func (ms *MasterServer) uiStatusHandler(w http.ResponseWriter, r *http.Request)
 	defer ms.Topo.RaftAccessLock.RUnlock()
 
 	if ms.Topo.Raft != nil {
+		forecasts, collectionGrowths := ms.Topo.CapacityForecasts()
+		browse, browseErr := ms.Topo.Browse(r.FormValue("dc"), r.FormValue("rack"), r.FormValue("node"))
 		args := struct {
 			Version           string
 			Topology          interface{}
@@ -27,6 +30,11 @@ func (ms *MasterServer) uiStatusHandler(w http.ResponseWriter, r *http.Request)
 			Stats             map[string]interface{}
 			Counters          *stats.ServerStats
 			VolumeSizeLimitMB uint32
+			Forecasts         []*topology.CapacityForecast
+			CollectionGrowths []*topology.CollectionGrowth
+			Browse            topology.BrowseInfo
+			BrowseError       error
+			AdminAuth         bool
 		}{
 			util.Version(),
 			ms.Topo.ToInfo(),
@@ -34,6 +42,11 @@ func (ms *MasterServer) uiStatusHandler(w http.ResponseWriter, r *http.Request)
 			infos,
 			serverStats,
 			ms.option.VolumeSizeLimitMB,
+			forecasts,
+			collectionGrowths,
+			browse,
+			browseErr,
+			len(ms.adminSigningKey) > 0,
 		}
 		ui.StatusNewRaftTpl.Execute(w, args)
 	}
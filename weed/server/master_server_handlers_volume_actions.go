@@ -0,0 +1,150 @@
+package weed_server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+)
+
+// VolumeActionHeader must be set on the volume action requests. A browser only sends a custom header
+// from the pages of the master itself, so another site can not post the actions with a form.
+const VolumeActionHeader = "X-Seaweedfs-Volume-Action"
+
+// volumeActionGuard only accepts POST requests from the white list with the VolumeActionHeader,
+// signed with the master admin JWT if "jwt.master_admin.key" is configured.
+// The JWT is only read from the header, so another site can not post the actions with the cookies of the browser.
+func (ms *MasterServer) volumeActionGuard(f http.HandlerFunc) http.HandlerFunc {
+	return ms.guard.WhiteList(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJsonError(w, r, http.StatusMethodNotAllowed, fmt.Errorf("%s is not allowed", r.Method))
+			return
+		}
+		if r.Header.Get(VolumeActionHeader) == "" {
+			writeJsonError(w, r, http.StatusForbidden, fmt.Errorf("missing the %s header", VolumeActionHeader))
+			return
+		}
+		if len(ms.adminSigningKey) > 0 {
+			if err := security.VerifyMasterAdminJwt(ms.adminSigningKey, security.GetJwt(r)); err != nil {
+				glog.V(0).Infof("volume action %s from %s: %v", r.URL.Path, r.RemoteAddr, err)
+				writeJsonError(w, r, http.StatusUnauthorized, err)
+				return
+			}
+		}
+		f(w, r)
+	})
+}
+
+// adminGuard only accepts the requests from the white list signed with the master admin JWT,
+// and refuses all requests if "jwt.master_admin.key" is not configured.
+func (ms *MasterServer) adminGuard(f http.HandlerFunc) http.HandlerFunc {
+	return ms.guard.WhiteList(func(w http.ResponseWriter, r *http.Request) {
+		if len(ms.adminSigningKey) == 0 {
+			writeJsonError(w, r, http.StatusForbidden, fmt.Errorf("jwt.master_admin.key is not configured"))
+			return
+		}
+		if err := security.VerifyMasterAdminJwt(ms.adminSigningKey, security.GetJwt(r)); err != nil {
+			glog.V(0).Infof("admin request %s from %s: %v", r.URL.Path, r.RemoteAddr, err)
+			writeJsonError(w, r, http.StatusUnauthorized, err)
+			return
+		}
+		f(w, r)
+	})
+}
+
+func (ms *MasterServer) volumeActionVacuumHandler(w http.ResponseWriter, r *http.Request) {
+	vid, err := parseVolumeActionVolumeId(r)
+	if err != nil {
+		writeJsonError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	gcThreshold := ms.option.GarbageThreshold
+	if gcString := r.FormValue("garbageThreshold"); gcString != "" {
+		if gcThreshold, err = strconv.ParseFloat(gcString, 32); err != nil {
+			writeJsonError(w, r, http.StatusBadRequest, fmt.Errorf("garbageThreshold %s is not a valid float number", gcString))
+			return
+		}
+	}
+	glog.V(0).Infof("vacuum volume %d with threshold %f from %s", vid, gcThreshold, r.RemoteAddr)
+	if err = ms.Topo.VacuumWithProgress(ms.grpcDialOption, gcThreshold, uint32(vid), "", ms.preallocateSize, nil); err != nil {
+		writeJsonError(w, r, http.StatusConflict, err)
+		return
+	}
+	writeJsonQuiet(w, r, http.StatusOK, map[string]interface{}{"volumeId": vid})
+}
+
+// volumeActionReadonlyHandler marks all replicas of the volume readonly, or writable with readonly=false
+func (ms *MasterServer) volumeActionReadonlyHandler(w http.ResponseWriter, r *http.Request) {
+	vid, err := parseVolumeActionVolumeId(r)
+	if err != nil {
+		writeJsonError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	readonly, err := strconv.ParseBool(r.FormValue("readonly"))
+	if err != nil {
+		writeJsonError(w, r, http.StatusBadRequest, fmt.Errorf("readonly %s is not a valid bool", r.FormValue("readonly")))
+		return
+	}
+	dataNodes := ms.Topo.Lookup("", vid)
+	if len(dataNodes) == 0 {
+		writeJsonError(w, r, http.StatusNotFound, fmt.Errorf("volume %d not found", vid))
+		return
+	}
+	glog.V(0).Infof("mark volume %d readonly:%v from %s", vid, readonly, r.RemoteAddr)
+	for _, dn := range dataNodes {
+		err = operation.WithVolumeServerClient(false, dn.ServerAddress(), ms.grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
+			if readonly {
+				_, markErr := client.VolumeMarkReadonly(context.Background(), &volume_server_pb.VolumeMarkReadonlyRequest{VolumeId: uint32(vid)})
+				return markErr
+			}
+			_, markErr := client.VolumeMarkWritable(context.Background(), &volume_server_pb.VolumeMarkWritableRequest{VolumeId: uint32(vid)})
+			return markErr
+		})
+		if err != nil {
+			writeJsonError(w, r, http.StatusInternalServerError, fmt.Errorf("mark volume %d on %s: %v", vid, dn.Url(), err))
+			return
+		}
+	}
+	writeJsonQuiet(w, r, http.StatusOK, map[string]interface{}{"volumeId": vid, "readonly": readonly})
+}
+
+// volumeActionFixReplicationHandler adds the missing replicas of the volume, or removes the extra ones, with "volume.fix.replication"
+func (ms *MasterServer) volumeActionFixReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	vid, err := parseVolumeActionVolumeId(r)
+	if err != nil {
+		writeJsonError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	ms.volumeActionLock.Lock()
+	defer ms.volumeActionLock.Unlock()
+	if ms.volumeActionEnv == nil {
+		ms.volumeActionEnv, _ = ms.newShellCommandEnv()
+	}
+	if err = runShellCommand(ms.volumeActionEnv, "lock"); err != nil {
+		writeJsonError(w, r, http.StatusConflict, err)
+		return
+	}
+	defer runShellCommand(ms.volumeActionEnv, "unlock")
+
+	glog.V(0).Infof("fix replication of volume %d from %s", vid, r.RemoteAddr)
+	if err = runShellCommand(ms.volumeActionEnv, "volume.fix.replication", "-volumeId", fmt.Sprintf("%d", vid)); err != nil {
+		writeJsonError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	writeJsonQuiet(w, r, http.StatusOK, map[string]interface{}{"volumeId": vid})
+}
+
+func parseVolumeActionVolumeId(r *http.Request) (needle.VolumeId, error) {
+	vid, err := needle.NewVolumeId(r.FormValue("volumeId"))
+	if err != nil || vid == 0 {
+		return 0, fmt.Errorf("volumeId %s is not a valid volume id", r.FormValue("volumeId"))
+	}
+	return vid, nil
+}
@@ -0,0 +1,73 @@
+package weed_server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/security"
+)
+
+func TestVolumeActionGuard(t *testing.T) {
+	ms := &MasterServer{guard: security.NewGuard(nil, "", 0, "", 0)}
+	handler := ms.volumeActionGuard(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	post := func(header http.Header) int {
+		r := httptest.NewRequest(http.MethodPost, "/vol/action/vacuum", strings.NewReader("volumeId=1"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		for name, values := range header {
+			r.Header[name] = values
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w.Code
+	}
+
+	// a form posted from another site has no custom header
+	if code := post(nil); code != http.StatusForbidden {
+		t.Errorf("post without the action header: %d", code)
+	}
+	if code := post(http.Header{VolumeActionHeader: {"vacuum"}}); code != http.StatusOK {
+		t.Errorf("post with the action header: %d", code)
+	}
+
+	ms.adminSigningKey = security.SigningKey("secret")
+	if code := post(http.Header{VolumeActionHeader: {"vacuum"}}); code != http.StatusUnauthorized {
+		t.Errorf("post without the admin jwt: %d", code)
+	}
+	jwt := security.GenJwtForMasterAdmin(ms.adminSigningKey, 10)
+	if code := post(http.Header{VolumeActionHeader: {"vacuum"}, "Authorization": {"Bearer " + string(jwt)}}); code != http.StatusOK {
+		t.Errorf("post with the admin jwt: %d", code)
+	}
+}
+
+func TestAdminGuard(t *testing.T) {
+	ms := &MasterServer{guard: security.NewGuard(nil, "", 0, "", 0)}
+	handler := ms.adminGuard(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	post := func(jwt security.EncodedJwt) int {
+		r := httptest.NewRequest(http.MethodPost, "/log/level?v=1", nil)
+		if jwt != "" {
+			r.Header.Set("Authorization", "Bearer "+string(jwt))
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w.Code
+	}
+
+	// refused without the admin key
+	if code := post(""); code != http.StatusForbidden {
+		t.Errorf("post without the admin key configured: %d", code)
+	}
+
+	ms.adminSigningKey = security.SigningKey("secret")
+	if code := post(""); code != http.StatusUnauthorized {
+		t.Errorf("post without the admin jwt: %d", code)
+	}
+	if code := post(security.GenJwtForMasterAdmin(ms.adminSigningKey, 10)); code != http.StatusOK {
+		t.Errorf("post with the admin jwt: %d", code)
+	}
+}
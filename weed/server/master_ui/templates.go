@@ -2,9 +2,43 @@ package master_ui
 
 import (
 	_ "embed"
+	"fmt"
 	"html/template"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
+func growthToHumanReadable(bytesPerDay float64) string {
+	if bytesPerDay < 0 {
+		return "-" + util.BytesToHumanReadable(uint64(-bytesPerDay))
+	}
+	return util.BytesToHumanReadable(uint64(bytesPerDay))
+}
+
+// heatColor shades the usage from green at 0% to red at 100% and above
+func heatColor(usedPercent interface{}) template.CSS {
+	var p float64
+	switch v := usedPercent.(type) {
+	case float64:
+		p = v
+	case float32:
+		p = float64(v)
+	}
+	if p < 0 {
+		p = 0
+	}
+	if p > 100 {
+		p = 100
+	}
+	return template.CSS(fmt.Sprintf("hsl(%d, 70%%, 75%%)", int(120*(100-p)/100)))
+}
+
+var funcMap = template.FuncMap{
+	"bytesToHumanReadable":  util.BytesToHumanReadable,
+	"growthToHumanReadable": growthToHumanReadable,
+	"heatColor":             heatColor,
+}
+
 //go:embed master.html
 var masterHtml string
 
@@ -12,4 +46,4 @@ var masterHtml string
 var masterNewRaftHtml string
 
 var StatusTpl = template.Must(template.New("status").Parse(masterHtml))
-var StatusNewRaftTpl = template.Must(template.New("status").Parse(masterNewRaftHtml))
+var StatusNewRaftTpl = template.Must(template.New("status").Funcs(funcMap).Parse(masterNewRaftHtml))
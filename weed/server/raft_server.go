@@ -58,7 +58,9 @@ var _ raft.FSM = &StateMachine{}
 
 func (s StateMachine) Save() ([]byte, error) {
 	state := topology.MaxVolumeIdCommand{
-		MaxVolumeId: s.topo.GetMaxVolumeId(),
+		MaxVolumeId:        s.topo.GetMaxVolumeId(),
+		VolumeIdRanges:     s.topo.PersistedVolumeIdRanges(),
+		ReplicationTargets: s.topo.PersistedReplicationTargets(),
 	}
 	glog.V(1).Infof("Save raft state %+v", state)
 	return json.Marshal(state)
@@ -72,9 +74,31 @@ func (s StateMachine) Recovery(data []byte) error {
 	}
 	glog.V(1).Infof("Recovery raft state %+v", state)
 	s.topo.UpAdjustMaxVolumeId(state.MaxVolumeId)
+	s.applyReplicationTargets(state.ReplicationTargets)
+	return s.applyVolumeIdRanges(state.VolumeIdRanges)
+}
+
+func (s StateMachine) applyVolumeIdRanges(volumeIdRanges *string) error {
+	if volumeIdRanges == nil {
+		return nil
+	}
+	ranges, err := topology.ParseVolumeIdRanges(*volumeIdRanges)
+	if err != nil {
+		return err
+	}
+	s.topo.ApplyVolumeIdRanges(ranges)
+	glog.V(0).Infof("volume id ranges: %q", *volumeIdRanges)
 	return nil
 }
 
+func (s StateMachine) applyReplicationTargets(targets *[]topology.ReplicationTarget) {
+	if targets == nil {
+		return
+	}
+	s.topo.ApplyReplicationTargets(*targets)
+	glog.V(0).Infof("replication targets: %v", *targets)
+}
+
 func (s *StateMachine) Apply(l *raft.Log) interface{} {
 	before := s.topo.GetMaxVolumeId()
 	state := topology.MaxVolumeIdCommand{}
@@ -85,12 +109,15 @@ func (s *StateMachine) Apply(l *raft.Log) interface{} {
 	s.topo.UpAdjustMaxVolumeId(state.MaxVolumeId)
 
 	glog.V(1).Infoln("max volume id", before, "==>", s.topo.GetMaxVolumeId())
-	return nil
+	s.applyReplicationTargets(state.ReplicationTargets)
+	return s.applyVolumeIdRanges(state.VolumeIdRanges)
 }
 
 func (s *StateMachine) Snapshot() (raft.FSMSnapshot, error) {
 	return &topology.MaxVolumeIdCommand{
-		MaxVolumeId: s.topo.GetMaxVolumeId(),
+		MaxVolumeId:        s.topo.GetMaxVolumeId(),
+		VolumeIdRanges:     s.topo.PersistedVolumeIdRanges(),
+		ReplicationTargets: s.topo.PersistedReplicationTargets(),
 	}, nil
 }
 
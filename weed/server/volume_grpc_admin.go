@@ -13,6 +13,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
 	"github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
@@ -49,6 +50,7 @@ func (vs *VolumeServer) AllocateVolume(ctx context.Context, req *volume_server_p
 		req.Preallocate,
 		req.MemoryMapMaxSizeMb,
 		types.ToDiskType(req.DiskType),
+		req.ChecksumAlgorithm,
 	)
 
 	if err != nil {
@@ -87,6 +89,7 @@ func (vs *VolumeServer) VolumeUnmount(ctx context.Context, req *volume_server_pb
 		glog.Errorf("volume unmount %v: %v", req, err)
 	} else {
 		glog.V(2).Infof("volume unmount %v", req)
+		vs.volumeRequestStats.Remove(req.VolumeId)
 	}
 
 	return resp, err
@@ -103,6 +106,7 @@ func (vs *VolumeServer) VolumeDelete(ctx context.Context, req *volume_server_pb.
 		glog.Errorf("volume delete %v: %v", req, err)
 	} else {
 		glog.V(2).Infof("volume delete %v", req)
+		vs.volumeRequestStats.Remove(req.VolumeId)
 	}
 
 	return resp, err
@@ -227,30 +231,34 @@ func (vs *VolumeServer) VolumeMarkWritable(ctx context.Context, req *volume_serv
 
 func (vs *VolumeServer) VolumeStatus(ctx context.Context, req *volume_server_pb.VolumeStatusRequest) (*volume_server_pb.VolumeStatusResponse, error) {
 
-	resp := &volume_server_pb.VolumeStatusResponse{}
-
 	v := vs.store.GetVolume(needle.VolumeId(req.VolumeId))
 	if v == nil {
 		return nil, fmt.Errorf("not found volume id %d", req.VolumeId)
 	}
 
-	resp.IsReadOnly = v.IsReadOnly()
-
-	return resp, nil
+	return v.GetVolumeStatus(), nil
 }
 
 func (vs *VolumeServer) VolumeServerStatus(ctx context.Context, req *volume_server_pb.VolumeServerStatusRequest) (*volume_server_pb.VolumeServerStatusResponse, error) {
 
 	resp := &volume_server_pb.VolumeServerStatusResponse{
-		MemoryStatus: stats.MemStat(),
-		Version:      util.Version(),
-		DataCenter:   vs.dataCenter,
-		Rack:         vs.rack,
+		MemoryStatus:                 stats.MemStat(),
+		Version:                      util.Version(),
+		DataCenter:                   vs.dataCenter,
+		Rack:                         vs.rack,
+		IsReadonly:                   vs.store.IsReadOnly(),
+		JwtSigningKeyFingerprint:     vs.guard.SigningKey.Fingerprint(),
+		JwtSigningReadKeyFingerprint: vs.guard.ReadSigningKey.Fingerprint(),
+		InFlightUploadDataSize:       vs.uploadAdmission.inFlightSize(),
 	}
 
 	for _, loc := range vs.store.Locations {
 		if dir, e := filepath.Abs(loc.Directory); e == nil {
-			resp.DiskStatuses = append(resp.DiskStatuses, stats.NewDiskStatus(dir))
+			diskStatus := stats.NewDiskStatus(dir)
+			diskStatus.DiskType = loc.DiskType.String()
+			diskStatus.Preallocate = loc.Preallocate
+			diskStatus.DirectIo = loc.DirectIO
+			resp.DiskStatuses = append(resp.DiskStatuses, diskStatus)
 		}
 	}
 
@@ -268,6 +276,61 @@ func (vs *VolumeServer) VolumeServerLeave(ctx context.Context, req *volume_serve
 
 }
 
+func (vs *VolumeServer) VolumeServerMarkReadonly(ctx context.Context, req *volume_server_pb.VolumeServerMarkReadonlyRequest) (*volume_server_pb.VolumeServerMarkReadonlyResponse, error) {
+
+	volumes := vs.store.GetVolumes()
+	resp := &volume_server_pb.VolumeServerMarkReadonlyResponse{
+		VolumeCount: uint32(len(volumes)),
+	}
+
+	if req.IsReadonly {
+		// stop master from redirecting traffic here before refusing the writes
+		for _, v := range volumes {
+			if err := vs.notifyMasterVolumeReadonly(v, true); err != nil {
+				return resp, err
+			}
+		}
+		vs.store.SetReadOnly(true)
+	} else {
+		vs.store.SetReadOnly(false)
+		// volumes marked readonly individually stay readonly
+		for _, v := range volumes {
+			if err := vs.notifyMasterVolumeReadonly(v, v.IsReadOnly()); err != nil {
+				return resp, err
+			}
+		}
+	}
+
+	glog.V(0).Infof("volume server mark readonly %v: %d volumes", req.IsReadonly, len(volumes))
+
+	return resp, nil
+}
+
+func (vs *VolumeServer) VolumeServerStats(ctx context.Context, req *volume_server_pb.VolumeServerStatsRequest) (*volume_server_pb.VolumeServerStatsResponse, error) {
+
+	volumeStats, since := vs.volumeRequestStats.Collect(req.VolumeIds, req.ResetCounters)
+	for _, volumeStat := range volumeStats {
+		volumeId := needle.VolumeId(volumeStat.VolumeId)
+		if v := vs.store.GetVolume(volumeId); v != nil {
+			volumeStat.Collection = v.Collection
+		} else if ecVolume, found := vs.store.FindEcVolume(volumeId); found {
+			volumeStat.Collection = ecVolume.Collection
+		}
+	}
+
+	return &volume_server_pb.VolumeServerStatsResponse{
+		VolumeRequestStats: volumeStats,
+		SinceNs:            since.UnixNano(),
+	}, nil
+}
+
+func (vs *VolumeServer) SlowRequests(ctx context.Context, req *volume_server_pb.SlowRequestsRequest) (*volume_server_pb.SlowRequestsResponse, error) {
+
+	return &volume_server_pb.SlowRequestsResponse{
+		Requests: vs.slowLog.RecentJson(int(req.Limit)),
+	}, nil
+}
+
 func (vs *VolumeServer) VolumeNeedleStatus(ctx context.Context, req *volume_server_pb.VolumeNeedleStatusRequest) (*volume_server_pb.VolumeNeedleStatusResponse, error) {
 
 	resp := &volume_server_pb.VolumeNeedleStatusResponse{}
@@ -305,6 +368,9 @@ func (vs *VolumeServer) VolumeNeedleStatus(ctx context.Context, req *volume_serv
 	if n.HasTtl() {
 		resp.Ttl = n.Ttl.String()
 	}
+	if v := vs.store.GetVolume(volumeId); v != nil {
+		resp.Offset, _ = v.NeedleOffset(n.Id)
+	}
 	return resp, nil
 
 }
@@ -346,3 +412,19 @@ func (vs *VolumeServer) Ping(ctx context.Context, req *volume_server_pb.PingRequ
 	resp.StopTimeNs = time.Now().UnixNano()
 	return
 }
+
+// SetLogLevel reads the log levels, or changes them with the master admin JWT.
+func (vs *VolumeServer) SetLogLevel(ctx context.Context, req *volume_server_pb.SetLogLevelRequest) (*volume_server_pb.SetLogLevelResponse, error) {
+	if req.Levels != "" {
+		if err := security.VerifyMasterAdminGrpc(ctx, vs.adminSigningKey); err != nil {
+			return nil, err
+		}
+	}
+	levels, err := glog.ChangeLevels(req.Levels)
+	if err != nil {
+		return nil, err
+	}
+	return &volume_server_pb.SetLogLevelResponse{
+		Levels: levels,
+	}, nil
+}
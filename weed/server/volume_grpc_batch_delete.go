@@ -86,3 +86,49 @@ func (vs *VolumeServer) BatchDelete(ctx context.Context, req *volume_server_pb.B
 	return resp, nil
 
 }
+
+// BatchNeedleExists checks the file ids in the volume indexes only, so the cookies are not checked.
+func (vs *VolumeServer) BatchNeedleExists(ctx context.Context, req *volume_server_pb.BatchNeedleExistsRequest) (*volume_server_pb.BatchNeedleExistsResponse, error) {
+
+	resp := &volume_server_pb.BatchNeedleExistsResponse{}
+
+	for _, fid := range req.FileIds {
+		vid, id_cookie, err := operation.ParseFileId(fid)
+		if err != nil {
+			resp.Results = append(resp.Results, &volume_server_pb.NeedleExistsResult{
+				FileId: fid,
+				Error:  err.Error(),
+			})
+			continue
+		}
+		volumeId, err := needle.NewVolumeId(vid)
+		if err != nil {
+			resp.Results = append(resp.Results, &volume_server_pb.NeedleExistsResult{
+				FileId: fid,
+				Error:  err.Error(),
+			})
+			continue
+		}
+		needleId, _, err := needle.ParseNeedleIdCookie(id_cookie)
+		if err != nil {
+			resp.Results = append(resp.Results, &volume_server_pb.NeedleExistsResult{
+				FileId: fid,
+				Error:  err.Error(),
+			})
+			continue
+		}
+
+		size, found, err := vs.store.HasVolumeNeedle(volumeId, needleId)
+		result := &volume_server_pb.NeedleExistsResult{
+			FileId: fid,
+			Exists: found,
+			Size:   uint32(size),
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	return resp, nil
+}
@@ -23,7 +23,7 @@ func (vs *VolumeServer) GetMaster() rpc.ServerAddress {
 
 func (vs *VolumeServer) checkWithMaster() (err error) {
 	for {
-		for _, master := range vs.SeedMasterNodes {
+		for _, master := range rpc.ResolveServerAddresses(vs.SeedMasterNodes) {
 			err = operation.WithMasterServerClient(false, master, vs.grpcDialOption, func(masterClient master_pb.SeaweedClient) error {
 				resp, err := masterClient.GetMasterConfiguration(context.Background(), &master_pb.GetMasterConfigurationRequest{})
 				if err != nil {
@@ -53,7 +53,7 @@ func (vs *VolumeServer) heartbeat() {
 	var err error
 	var newLeader rpc.ServerAddress
 	for vs.isHeartbeating {
-		for _, master := range vs.SeedMasterNodes {
+		for _, master := range rpc.ResolveServerAddresses(vs.SeedMasterNodes) {
 			if newLeader != "" {
 				// the new leader may actually is the same master
 				// need to wait a bit before adding itself
@@ -134,6 +134,9 @@ func (vs *VolumeServer) doHeartbeat(masterAddress rpc.ServerAddress, grpcDialOpt
 					}
 				}
 			}
+			if len(in.OutOfSyncVolumes) > 0 {
+				vs.maybeRepairOutOfSyncVolumes(in.OutOfSyncVolumes)
+			}
 			if in.GetLeader() != "" && string(vs.currentMaster) != in.GetLeader() {
 				glog.V(0).Infof("Volume Server found a new master newLeader: %v instead of %v", in.GetLeader(), vs.currentMaster)
 				newLeader = rpc.ServerAddress(in.GetLeader())
@@ -143,6 +146,7 @@ func (vs *VolumeServer) doHeartbeat(masterAddress rpc.ServerAddress, grpcDialOpt
 		}
 	}()
 
+	vs.store.ResetVolumeCounterDeltas()
 	if err = stream.Send(vs.store.CollectHeartbeat()); err != nil {
 		glog.V(0).Infof("Volume Server Failed to talk with master %s: %v", masterAddress, err)
 		return "", err
@@ -44,7 +44,7 @@ func (vs *VolumeServer) FetchAndWriteNeedle(ctx context.Context, req *volume_ser
 		n.Data, n.DataSize = data, uint32(len(data))
 		// copied from *Needle.prepareWriteBuffer()
 		n.Size = 4 + types.Size(n.DataSize) + 1
-		n.Checksum = needle.NewCRC(n.Data)
+		n.SetChecksumAlgorithm(v.SuperBlock.ChecksumAlgorithm())
 		n.LastModified = uint64(time.Now().Unix())
 		n.SetHasLastModifiedDate()
 		if _, localWriteErr := vs.store.WriteVolumeNeedle(v.Id, n, true, false); localWriteErr != nil {
@@ -60,7 +60,7 @@ func (vs *VolumeServer) FetchAndWriteNeedle(ctx context.Context, req *volume_ser
 			go func(targetVolumeServer string) {
 				defer wg.Done()
 				uploadOption := &operation.UploadOption{
-					UploadUrl:         fmt.Sprintf("http://%s/%s?type=replicate", targetVolumeServer, fileId.String()),
+					UploadUrl:         fmt.Sprintf("http://%s/%s?type=replicate&checksum=%s", targetVolumeServer, fileId.String(), v.SuperBlock.ChecksumAlgorithm()),
 					Filename:          "",
 					Cipher:            false,
 					IsInputCompressed: false,
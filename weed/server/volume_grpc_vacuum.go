@@ -16,9 +16,10 @@ func (vs *VolumeServer) VacuumVolumeCheck(ctx context.Context, req *volume_serve
 
 	resp := &volume_server_pb.VacuumVolumeCheckResponse{}
 
-	garbageRatio, err := vs.store.CheckCompactVolume(needle.VolumeId(req.VolumeId))
+	garbageRatio, garbageBytes, err := vs.store.CheckCompactVolume(needle.VolumeId(req.VolumeId))
 
 	resp.GarbageRatio = garbageRatio
+	resp.GarbageBytes = garbageBytes
 
 	if err != nil {
 		glog.V(3).Infof("check volume %d: %v", req.VolumeId, err)
@@ -69,7 +70,7 @@ func (vs *VolumeServer) VacuumVolumeCommit(ctx context.Context, req *volume_serv
 
 	resp := &volume_server_pb.VacuumVolumeCommitResponse{}
 
-	readOnly, err := vs.store.CommitCompactVolume(needle.VolumeId(req.VolumeId))
+	readOnly, reclaimedBytes, err := vs.store.CommitCompactVolume(needle.VolumeId(req.VolumeId))
 
 	if err != nil {
 		glog.Errorf("commit volume %d: %v", req.VolumeId, err)
@@ -77,6 +78,7 @@ func (vs *VolumeServer) VacuumVolumeCommit(ctx context.Context, req *volume_serv
 		glog.V(1).Infof("commit volume %d", req.VolumeId)
 	}
 	resp.IsReadOnly = readOnly
+	resp.ReclaimedBytes = reclaimedBytes
 	return resp, err
 
 }
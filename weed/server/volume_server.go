@@ -10,26 +10,32 @@ import (
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
 	"github.com/seaweedfs/seaweedfs/weed/security"
 	"github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/storage"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 	"github.com/seaweedfs/seaweedfs/weed/storage/types"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
 type VolumeServer struct {
 	volume_server_pb.UnimplementedVolumeServerServer
-	inFlightUploadDataSize        int64
 	inFlightDownloadDataSize      int64
-	concurrentUploadLimit         int64
 	concurrentDownloadLimit       int64
-	inFlightUploadDataLimitCond   *sync.Cond
+	uploadAdmission               *uploadAdmission
 	inFlightDownloadDataLimitCond *sync.Cond
-	inflightUploadDataTimeout     time.Duration
 	hasSlowRead                   bool
+	readRepair                    bool
 	readBufferSizeMB              int
 
+	// repair the replicas found out of sync by the master from the peers
+	peerRepair      bool
+	peerRepairLock  sync.Mutex
+	peerRepairedAt  map[needle.VolumeId]time.Time
+	peerRepairQueue chan *master_pb.OutOfSyncVolume
+
 	SeedMasterNodes []rpc.ServerAddress
 	currentMaster   rpc.ServerAddress
 	pulseSeconds    int
@@ -38,6 +44,8 @@ type VolumeServer struct {
 	store           *storage.Store
 	guard           *security.Guard
 	grpcDialOption  grpc.DialOption
+	// verifies the admin requests, e.g. changing the log levels
+	adminSigningKey security.SigningKey
 
 	needleMapKind           storage.NeedleMapKind
 	ReadMode                string
@@ -47,11 +55,14 @@ type VolumeServer struct {
 	fileSizeLimitBytes      int64
 	isHeartbeating          bool
 	stopChan                chan bool
+	volumeRequestStats      *stats.VolumeRequestStats
+	slowLog                 *stats.SlowRequestLog
 }
 
 func NewVolumeServer(adminMux, publicMux *http.ServeMux, ip string,
 	port int, grpcPort int, publicUrl string,
 	folders []string, maxCounts []int32, minFreeSpaces []util.MinFreeSpace, diskTypes []types.DiskType,
+	preallocates []bool, directIOs []bool,
 	idxFolder string,
 	needleMapKind storage.NeedleMapKind,
 	masterNodes []rpc.ServerAddress, pulseSeconds int,
@@ -63,8 +74,15 @@ func NewVolumeServer(adminMux, publicMux *http.ServeMux, ip string,
 	concurrentUploadLimit int64,
 	concurrentDownloadLimit int64,
 	inflightUploadDataTimeout time.Duration,
+	inflightUploadQueueLength int,
 	hasSlowRead bool,
 	readBufferSizeMB int,
+	diskSmart bool,
+	diskSpillover bool,
+	readRepair bool,
+	peerRepair bool,
+	volumeLoadOption storage.VolumeLoadOption,
+	slowLog *stats.SlowRequestLog,
 ) *VolumeServer {
 
 	v := util.GetViper()
@@ -88,20 +106,31 @@ func NewVolumeServer(adminMux, publicMux *http.ServeMux, ip string,
 		fileSizeLimitBytes:            int64(fileSizeLimitMB) * 1024 * 1024,
 		isHeartbeating:                true,
 		stopChan:                      make(chan bool),
-		inFlightUploadDataLimitCond:   sync.NewCond(new(sync.Mutex)),
+		volumeRequestStats:            stats.NewVolumeRequestStats(),
+		slowLog:                       slowLog,
+		uploadAdmission:               newUploadAdmission(concurrentUploadLimit, inflightUploadQueueLength, inflightUploadDataTimeout),
 		inFlightDownloadDataLimitCond: sync.NewCond(new(sync.Mutex)),
-		concurrentUploadLimit:         concurrentUploadLimit,
 		concurrentDownloadLimit:       concurrentDownloadLimit,
-		inflightUploadDataTimeout:     inflightUploadDataTimeout,
 		hasSlowRead:                   hasSlowRead,
+		readRepair:                    readRepair,
 		readBufferSizeMB:              readBufferSizeMB,
+		peerRepair:                    peerRepair,
+		peerRepairedAt:                make(map[needle.VolumeId]time.Time),
+		peerRepairQueue:               make(chan *master_pb.OutOfSyncVolume, 1024),
 	}
 	vs.SeedMasterNodes = masterNodes
 
 	vs.checkWithMaster()
 
-	vs.store = storage.NewStore(vs.grpcDialOption, ip, port, grpcPort, publicUrl, folders, maxCounts, minFreeSpaces, idxFolder, vs.needleMapKind, diskTypes)
+	vs.store = storage.NewStore(vs.grpcDialOption, ip, port, grpcPort, publicUrl, folders, maxCounts, minFreeSpaces, idxFolder, vs.needleMapKind, diskTypes, volumeLoadOption)
+	for i, location := range vs.store.Locations {
+		location.Preallocate = preallocates[i]
+		location.DirectIO = directIOs[i]
+	}
+	vs.store.DiskHealth = stats.NewDiskHealthCollector(diskSmart)
+	vs.store.DiskSpillover = diskSpillover
 	vs.guard = security.NewGuard(whiteList, signingKey, expiresAfterSec, readSigningKey, readExpiresAfterSec)
+	vs.adminSigningKey = security.SigningKey(v.GetString("jwt.master_admin.key"))
 
 	handleStaticResources(adminMux)
 	adminMux.HandleFunc("/status", vs.statusHandler)
@@ -123,6 +152,9 @@ func NewVolumeServer(adminMux, publicMux *http.ServeMux, ip string,
 	}
 
 	go vs.heartbeat()
+	if peerRepair {
+		go vs.loopRepairOutOfSyncVolumes()
+	}
 	go stats.LoopPushingMetric("volumeServer", util.JoinHostPort(ip, port), vs.metricsAddress, vs.metricsIntervalSec)
 
 	return vs
@@ -13,6 +13,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/security"
 	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 )
 
 /*
@@ -60,43 +61,22 @@ func (vs *VolumeServer) privateStoreHandler(w http.ResponseWriter, r *http.Reque
 			inFlightDownloadSize = atomic.LoadInt64(&vs.inFlightDownloadDataSize)
 		}
 		vs.inFlightDownloadDataLimitCond.L.Unlock()
-		vs.GetOrHeadHandler(w, r)
+		vs.handleWithVolumeStats(w, r, vs.GetOrHeadHandler)
 	case "DELETE":
 		stats.DeleteRequest()
-		vs.guard.WhiteList(vs.DeleteHandler)(w, r)
+		vs.handleWithVolumeStats(w, r, vs.guard.WhiteList(vs.DeleteHandler))
 	case "PUT", "POST":
 		contentLength := getContentLength(r)
 		// exclude the replication from the concurrentUploadLimitMB
-		if r.URL.Query().Get("type") != "replicate" && vs.concurrentUploadLimit != 0 {
-			startTime := time.Now()
-			vs.inFlightUploadDataLimitCond.L.Lock()
-			inFlightUploadDataSize := atomic.LoadInt64(&vs.inFlightUploadDataSize)
-			for inFlightUploadDataSize > vs.concurrentUploadLimit {
-				//wait timeout check
-				if startTime.Add(vs.inflightUploadDataTimeout).Before(time.Now()) {
-					vs.inFlightUploadDataLimitCond.L.Unlock()
-					err := fmt.Errorf("reject because inflight upload data %d > %d, and wait timeout", inFlightUploadDataSize, vs.concurrentUploadLimit)
-					glog.V(1).Infof("too many requests: %v", err)
-					writeJsonError(w, r, http.StatusTooManyRequests, err)
-					return
-				}
-				glog.V(4).Infof("wait because inflight upload data %d > %d", inFlightUploadDataSize, vs.concurrentUploadLimit)
-				vs.inFlightUploadDataLimitCond.Wait()
-				inFlightUploadDataSize = atomic.LoadInt64(&vs.inFlightUploadDataSize)
-			}
-			vs.inFlightUploadDataLimitCond.L.Unlock()
+		if err := vs.uploadAdmission.admit(r.Context(), contentLength, r.URL.Query().Get("type") == "replicate"); err != nil {
+			vs.rejectUpload(w, r, err)
+			return
 		}
-		atomic.AddInt64(&vs.inFlightUploadDataSize, contentLength)
-		defer func() {
-			atomic.AddInt64(&vs.inFlightUploadDataSize, -contentLength)
-			if vs.concurrentUploadLimit != 0 {
-				vs.inFlightUploadDataLimitCond.Signal()
-			}
-		}()
+		defer vs.uploadAdmission.release(contentLength)
 
 		// processs uploads
 		stats.WriteRequest()
-		vs.guard.WhiteList(vs.PostHandler)(w, r)
+		vs.handleWithVolumeStats(w, r, vs.guard.WhiteList(vs.PostHandler))
 
 	case "OPTIONS":
 		stats.ReadRequest()
@@ -105,6 +85,21 @@ func (vs *VolumeServer) privateStoreHandler(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// rejectUpload tells the client to back off and retry the upload later.
+func (vs *VolumeServer) rejectUpload(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case errUploadQueueFull:
+		w.Header().Set("Retry-After", uploadRetryAfterSeconds)
+		writeJsonError(w, r, http.StatusServiceUnavailable, fmt.Errorf("reject because %v, inflight upload data limit %d", err, vs.uploadAdmission.limit))
+	case errUploadWaitTimeout:
+		w.Header().Set("Retry-After", uploadRetryAfterSeconds)
+		writeJsonError(w, r, http.StatusTooManyRequests, fmt.Errorf("reject because inflight upload data > %d, and wait timeout", vs.uploadAdmission.limit))
+	default:
+		glog.V(4).Infof("upload cancelled from %s: %v", r.RemoteAddr, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
 func getContentLength(r *http.Request) int64 {
 	contentLength := r.Header.Get("Content-Length")
 	if contentLength != "" {
@@ -134,7 +129,7 @@ func (vs *VolumeServer) publicReadOnlyHandler(w http.ResponseWriter, r *http.Req
 			inFlightDownloadSize = atomic.LoadInt64(&vs.inFlightDownloadDataSize)
 		}
 		vs.inFlightDownloadDataLimitCond.L.Unlock()
-		vs.GetOrHeadHandler(w, r)
+		vs.handleWithVolumeStats(w, r, vs.GetOrHeadHandler)
 	case "OPTIONS":
 		stats.ReadRequest()
 		w.Header().Add("Access-Control-Allow-Methods", "GET, OPTIONS")
@@ -143,18 +138,24 @@ func (vs *VolumeServer) publicReadOnlyHandler(w http.ResponseWriter, r *http.Req
 }
 
 func (vs *VolumeServer) maybeCheckJwtAuthorization(r *http.Request, vid, fid string, isWrite bool) bool {
+	_, ok := vs.checkJwtAuthorization(r, vid, fid, isWrite)
+	return ok
+}
+
+// checkJwtAuthorization also returns the claims of the jwt, which are nil if no jwt is required
+func (vs *VolumeServer) checkJwtAuthorization(r *http.Request, vid, fid string, isWrite bool) (*security.SeaweedFileIdClaims, bool) {
 
 	var signingKey security.SigningKey
 
 	if isWrite {
 		if len(vs.guard.SigningKey) == 0 {
-			return true
+			return nil, true
 		} else {
 			signingKey = vs.guard.SigningKey
 		}
 	} else {
 		if len(vs.guard.ReadSigningKey) == 0 {
-			return true
+			return nil, true
 		} else {
 			signingKey = vs.guard.ReadSigningKey
 		}
@@ -163,25 +164,47 @@ func (vs *VolumeServer) maybeCheckJwtAuthorization(r *http.Request, vid, fid str
 	tokenStr := security.GetJwt(r)
 	if tokenStr == "" {
 		glog.V(1).Infof("missing jwt from %s", r.RemoteAddr)
-		return false
+		return nil, false
 	}
 
 	token, err := security.DecodeJwt(signingKey, tokenStr, &security.SeaweedFileIdClaims{})
 	if err != nil {
 		glog.V(1).Infof("jwt verification error from %s: %v", r.RemoteAddr, err)
-		return false
+		return nil, false
 	}
 	if !token.Valid {
 		glog.V(1).Infof("jwt invalid from %s: %v", r.RemoteAddr, tokenStr)
-		return false
+		return nil, false
 	}
 
 	if sc, ok := token.Claims.(*security.SeaweedFileIdClaims); ok {
 		if sepIndex := strings.LastIndex(fid, "_"); sepIndex > 0 {
 			fid = fid[:sepIndex]
 		}
-		return sc.Fid == vid+","+fid
+		if sc.Fid != vid+","+fid {
+			return nil, false
+		}
+		if sc.Collection != "" && !vs.isVolumeInCollection(vid, sc.Collection) {
+			glog.V(1).Infof("jwt from %s for collection %s can not access volume %s", r.RemoteAddr, sc.Collection, vid)
+			return nil, false
+		}
+		return sc, true
 	}
 	glog.V(1).Infof("unexpected jwt from %s: %v", r.RemoteAddr, tokenStr)
-	return false
+	return nil, false
+}
+
+func (vs *VolumeServer) isVolumeInCollection(vid string, collection string) bool {
+	volumeId, err := needle.NewVolumeId(vid)
+	if err != nil {
+		return false
+	}
+	if v := vs.store.GetVolume(volumeId); v != nil {
+		return v.Collection == collection
+	}
+	if ecVolume, found := vs.store.FindEcVolume(volumeId); found {
+		return ecVolume.Collection == collection
+	}
+	// the request fails later for the missing volume
+	return true
 }
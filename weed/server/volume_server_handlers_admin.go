@@ -37,6 +37,8 @@ func (vs *VolumeServer) statusHandler(w http.ResponseWriter, r *http.Request) {
 		if dir, e := filepath.Abs(loc.Directory); e == nil {
 			newDiskStatus := stats.NewDiskStatus(dir)
 			newDiskStatus.DiskType = loc.DiskType.String()
+			newDiskStatus.Preallocate = loc.Preallocate
+			newDiskStatus.DirectIo = loc.DirectIO
 			ds = append(ds, newDiskStatus)
 		}
 	}
@@ -54,6 +56,8 @@ func (vs *VolumeServer) statsDiskHandler(w http.ResponseWriter, r *http.Request)
 		if dir, e := filepath.Abs(loc.Directory); e == nil {
 			newDiskStatus := stats.NewDiskStatus(dir)
 			newDiskStatus.DiskType = loc.DiskType.String()
+			newDiskStatus.Preallocate = loc.Preallocate
+			newDiskStatus.DirectIo = loc.DirectIO
 			ds = append(ds, newDiskStatus)
 		}
 	}
@@ -0,0 +1,76 @@
+package weed_server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+)
+
+// statsResponseWriter keeps the response status and size for the volume statistics
+type statsResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func (w *statsResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statsResponseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+// handleWithVolumeStats counts the request in the statistics of the volume it accesses.
+// Only the volumes and ec volumes on this server are counted, not any volume id in the url.
+func (vs *VolumeServer) handleWithVolumeStats(w http.ResponseWriter, r *http.Request, handler http.HandlerFunc) {
+	vid, fid, _, _, _ := parseURLPath(r.URL.Path)
+	volumeId, err := needle.NewVolumeId(vid)
+	if err != nil {
+		handler(w, r)
+		return
+	}
+	isLocalVolume := vs.hasVolumeOrEcVolume(volumeId)
+
+	sw := &statsResponseWriter{ResponseWriter: w, status: http.StatusOK}
+	start := time.Now()
+	handler(sw, r)
+	latency := time.Since(start)
+
+	if isLocalVolume {
+		isError := sw.status >= http.StatusInternalServerError
+		switch r.Method {
+		case "GET", "HEAD":
+			vs.volumeRequestStats.RecordRead(uint32(volumeId), sw.written, latency, isError)
+		case "DELETE":
+			vs.volumeRequestStats.RecordDelete(uint32(volumeId), latency, isError)
+		default:
+			vs.volumeRequestStats.RecordWrite(uint32(volumeId), getContentLength(r), latency, isError)
+		}
+	}
+
+	size := sw.written
+	if r.Method != "GET" && r.Method != "HEAD" {
+		size = getContentLength(r)
+	}
+	vs.slowLog.Record(r.Method, latency, &stats.SlowRequest{
+		VolumeId: uint32(volumeId),
+		Fid:      vid + "," + fid,
+		Size:     size,
+		Peer:     r.RemoteAddr,
+		Status:   sw.status,
+	})
+}
+
+func (vs *VolumeServer) hasVolumeOrEcVolume(volumeId needle.VolumeId) bool {
+	if vs.store.HasVolume(volumeId) {
+		return true
+	}
+	_, found := vs.store.FindEcVolume(volumeId)
+	return found
+}
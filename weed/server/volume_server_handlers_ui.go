@@ -22,6 +22,8 @@ func (vs *VolumeServer) uiStatusHandler(w http.ResponseWriter, r *http.Request)
 		if dir, e := filepath.Abs(loc.Directory); e == nil {
 			newDiskStatus := stats.NewDiskStatus(dir)
 			newDiskStatus.DiskType = loc.DiskType.String()
+			newDiskStatus.Preallocate = loc.Preallocate
+			newDiskStatus.DirectIo = loc.DirectIO
 			ds = append(ds, newDiskStatus)
 		}
 	}
@@ -30,15 +30,24 @@ func (vs *VolumeServer) PostHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !vs.maybeCheckJwtAuthorization(r, vid, fid, true) {
+	claims, ok := vs.checkJwtAuthorization(r, vid, fid, true)
+	if !ok {
 		writeJsonError(w, r, http.StatusUnauthorized, errors.New("wrong jwt"))
 		return
 	}
+	sizeLimit := vs.fileSizeLimitBytes
+	if claims != nil && claims.MaxSize > 0 && claims.MaxSize < sizeLimit {
+		sizeLimit = claims.MaxSize
+	}
 
 	bytesBuffer := bufPool.Get().(*bytes.Buffer)
 	defer bufPool.Put(bytesBuffer)
 
-	reqNeedle, originalSize, contentMd5, ne := needle.CreateNeedleFromRequest(r, vs.fileSizeLimitBytes, bytesBuffer)
+	reqNeedle, originalSize, contentMd5, ne := needle.CreateNeedleFromRequest(r, sizeLimit, bytesBuffer)
+	if errors.Is(ne, needle.ErrorTooLarge) {
+		writeJsonError(w, r, http.StatusRequestEntityTooLarge, ne)
+		return
+	}
 	if ne != nil {
 		writeJsonError(w, r, http.StatusBadRequest, ne)
 		return
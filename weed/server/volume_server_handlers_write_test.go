@@ -0,0 +1,46 @@
+package weed_server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/security"
+)
+
+func TestPostHandlerRejectsUploadOverJwtMaxSize(t *testing.T) {
+	signingKey := "secret"
+	vs := &VolumeServer{
+		guard:              security.NewGuard(nil, signingKey, 10, "", 0),
+		fileSizeLimitBytes: 1024,
+	}
+
+	for _, tc := range []struct {
+		maxSize        int64
+		body           string
+		expectedStatus int
+	}{
+		{maxSize: 10, body: strings.Repeat("a", 11), expectedStatus: http.StatusRequestEntityTooLarge},
+		{maxSize: 2048, body: strings.Repeat("a", 1025), expectedStatus: http.StatusRequestEntityTooLarge},
+	} {
+		jwt := security.GenJwtForVolumeServerUpload(security.SigningKey(signingKey), 10, "3,01637037d6", "", tc.maxSize)
+		r := httptest.NewRequest(http.MethodPut, "/3,01637037d6", strings.NewReader(tc.body))
+		r.Header.Set("Authorization", "BEARER "+string(jwt))
+		w := httptest.NewRecorder()
+		vs.PostHandler(w, r)
+		if w.Code != tc.expectedStatus {
+			t.Errorf("max size %d, upload %d bytes: expected status %d, actual %d %s", tc.maxSize, len(tc.body), tc.expectedStatus, w.Code, w.Body.String())
+		}
+	}
+
+	// the upload of another file is refused
+	jwt := security.GenJwtForVolumeServerUpload(security.SigningKey(signingKey), 10, "3,01637037d7", "", 10)
+	r := httptest.NewRequest(http.MethodPut, "/3,01637037d6", strings.NewReader("a"))
+	r.Header.Set("Authorization", "BEARER "+string(jwt))
+	w := httptest.NewRecorder()
+	vs.PostHandler(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, actual %d", http.StatusUnauthorized, w.Code)
+	}
+}
@@ -0,0 +1,127 @@
+package weed_server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/storage"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+const (
+	// PeerRepairMaxMissingRatio refuses to pull from a peer missing too much here, likely not the same volume
+	PeerRepairMaxMissingRatio = 0.3
+	// PeerRepairRetryInterval is the time before repairing the same volume again
+	PeerRepairRetryInterval = 10 * time.Minute
+)
+
+// maybeRepairOutOfSyncVolumes repairs the volumes the master finds missing files or deletions of their peers,
+// one volume at a time in the background.
+func (vs *VolumeServer) maybeRepairOutOfSyncVolumes(outOfSyncVolumes []*master_pb.OutOfSyncVolume) {
+	if !vs.peerRepair {
+		return
+	}
+	vs.peerRepairLock.Lock()
+	defer vs.peerRepairLock.Unlock()
+	for _, outOfSync := range outOfSyncVolumes {
+		volumeId := needle.VolumeId(outOfSync.VolumeId)
+		if lastRepair, found := vs.peerRepairedAt[volumeId]; found && time.Since(lastRepair) < PeerRepairRetryInterval {
+			continue
+		}
+		vs.peerRepairedAt[volumeId] = time.Now()
+		select {
+		case vs.peerRepairQueue <- outOfSync:
+		default:
+			delete(vs.peerRepairedAt, volumeId)
+		}
+	}
+}
+
+func (vs *VolumeServer) loopRepairOutOfSyncVolumes() {
+	for outOfSync := range vs.peerRepairQueue {
+		volumeId := needle.VolumeId(outOfSync.VolumeId)
+		for _, peer := range outOfSync.Peers {
+			replicaPeer := &grpcReplicaPeer{
+				address:        rpc.ServerAddress(peer),
+				grpcDialOption: vs.grpcDialOption,
+				volumeId:       volumeId,
+				collection:     outOfSync.Collection,
+			}
+			result, err := vs.store.RepairVolumeFromPeer(volumeId, replicaPeer, PeerRepairMaxMissingRatio)
+			stats.VolumeServerRequestCounter.WithLabelValues(stats.PeerRepairPulled).Add(float64(result.Pulled))
+			stats.VolumeServerRequestCounter.WithLabelValues(stats.PeerRepairDeleted).Add(float64(result.Deleted))
+			if err != nil {
+				stats.VolumeServerRequestCounter.WithLabelValues(stats.ErrorPeerRepair).Inc()
+				glog.Warningf("peer repair volume %d from %s: %v", volumeId, peer, err)
+				continue
+			}
+			glog.V(0).Infof("peer repair volume %d from %s: pulled %d needles, deleted %d needles", volumeId, peer, result.Pulled, result.Deleted)
+		}
+	}
+}
+
+// grpcReplicaPeer reads the volume replica on another volume server.
+type grpcReplicaPeer struct {
+	address        rpc.ServerAddress
+	grpcDialOption grpc.DialOption
+	volumeId       needle.VolumeId
+	collection     string
+}
+
+func (p *grpcReplicaPeer) ReadIndex() (indexBytes []byte, err error) {
+	var buf bytes.Buffer
+	err = operation.WithVolumeServerClient(true, p.address, p.grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
+		copyFileClient, err := client.CopyFile(context.Background(), &volume_server_pb.CopyFileRequest{
+			VolumeId:           uint32(p.volumeId),
+			Ext:                ".idx",
+			CompactionRevision: math.MaxUint32,
+			StopOffset:         math.MaxInt64,
+			Collection:         p.collection,
+		})
+		if err != nil {
+			return err
+		}
+		for {
+			resp, receiveErr := copyFileClient.Recv()
+			if receiveErr != nil {
+				if receiveErr == io.EOF {
+					return nil
+				}
+				return fmt.Errorf("receive %d.idx: %v", p.volumeId, receiveErr)
+			}
+			buf.Write(resp.FileContent)
+		}
+	})
+	return buf.Bytes(), err
+}
+
+func (p *grpcReplicaPeer) ReadNeedleBlob(offset int64, size types.Size) (needleBlob []byte, err error) {
+	err = operation.WithVolumeServerClient(false, p.address, p.grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
+		resp, readErr := client.ReadNeedleBlob(context.Background(), &volume_server_pb.ReadNeedleBlobRequest{
+			VolumeId: uint32(p.volumeId),
+			Offset:   offset,
+			Size:     int32(size),
+		})
+		if readErr != nil {
+			return readErr
+		}
+		needleBlob = resp.NeedleBlob
+		return nil
+	})
+	return
+}
+
+var _ storage.ReplicaPeer = (*grpcReplicaPeer)(nil)
@@ -0,0 +1,105 @@
+package weed_server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// repairNeedle reads the needle failing the CRC check from another replica into n,
+// and overwrites the local corrupted copy with it.
+func (vs *VolumeServer) repairNeedle(volumeId needle.VolumeId, n *needle.Needle) error {
+	err := vs.doRepairNeedle(volumeId, n)
+	if err != nil {
+		stats.VolumeServerRequestCounter.WithLabelValues(stats.ErrorReadRepair).Inc()
+		return err
+	}
+	stats.VolumeServerRequestCounter.WithLabelValues(stats.ReadRepaired).Inc()
+	return nil
+}
+
+func (vs *VolumeServer) doRepairNeedle(volumeId needle.VolumeId, n *needle.Needle) error {
+	v := vs.store.GetVolume(volumeId)
+	if v == nil {
+		return fmt.Errorf("volume %d not found", volumeId)
+	}
+	if v.ReplicaPlacement.GetCopyCount() == 1 {
+		return fmt.Errorf("volume %d has no replicas", volumeId)
+	}
+
+	lookupResult, err := operation.LookupVolumeId(vs.GetMaster, vs.grpcDialOption, volumeId.String())
+	if err != nil {
+		return fmt.Errorf("lookup volume %d: %v", volumeId, err)
+	}
+
+	selfUrl := util.JoinHostPort(vs.store.Ip, vs.store.Port)
+	for _, location := range lookupResult.Locations {
+		if location.Url == selfUrl {
+			continue
+		}
+		blob, size, readErr := vs.readRemoteNeedleBlob(location, volumeId, n.Id)
+		if readErr != nil {
+			glog.Warningf("read repair volume %d needle %s from %s: %v", volumeId, n.Id, location.Url, readErr)
+			continue
+		}
+		// verify the needle read from the replica
+		repaired := &needle.Needle{Id: n.Id}
+		if parseErr := repaired.ReadBytes(blob, 0, size, v.Version()); parseErr != nil {
+			glog.Warningf("read repair volume %d needle %s from %s: %v", volumeId, n.Id, location.Url, parseErr)
+			continue
+		}
+		if repaired.Id != n.Id {
+			glog.Warningf("read repair volume %d needle %s from %s: unexpected needle %s", volumeId, n.Id, location.Url, repaired.Id)
+			continue
+		}
+		*n = *repaired
+
+		if v.IsReadOnly() {
+			glog.Warningf("read repair volume %d needle %s from %s: not overwriting readonly volume", volumeId, n.Id, location.Url)
+			return nil
+		}
+		if writeErr := v.WriteNeedleBlob(n.Id, blob, size); writeErr != nil {
+			glog.Errorf("read repair volume %d needle %s: overwrite local copy: %v", volumeId, n.Id, writeErr)
+			return nil
+		}
+		glog.Warningf("read repair volume %d needle %s: replaced the corrupted local copy with the one from %s", volumeId, n.Id, location.Url)
+		return nil
+	}
+
+	return fmt.Errorf("no healthy replica of volume %d needle %s", volumeId, n.Id)
+}
+
+func (vs *VolumeServer) readRemoteNeedleBlob(location operation.Location, volumeId needle.VolumeId, needleId types.NeedleId) (blob []byte, size types.Size, err error) {
+	err = operation.WithVolumeServerClient(false, location.ServerAddress(), vs.grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
+		// the needle status also verifies the CRC on the replica
+		status, statusErr := client.VolumeNeedleStatus(context.Background(), &volume_server_pb.VolumeNeedleStatusRequest{
+			VolumeId: uint32(volumeId),
+			NeedleId: uint64(needleId),
+		})
+		if statusErr != nil {
+			return statusErr
+		}
+		if status.Offset == 0 {
+			return fmt.Errorf("needle offset unknown")
+		}
+		resp, readErr := client.ReadNeedleBlob(context.Background(), &volume_server_pb.ReadNeedleBlobRequest{
+			VolumeId: uint32(volumeId),
+			NeedleId: uint64(needleId),
+			Offset:   status.Offset,
+			Size:     int32(status.Size),
+		})
+		if readErr != nil {
+			return readErr
+		}
+		blob, size = resp.NeedleBlob, types.Size(status.Size)
+		return nil
+	})
+	return
+}
@@ -0,0 +1,121 @@
+package weed_server
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+)
+
+// uploadRetryAfterSeconds is the Retry-After of the rejected uploads, the clients back off further on their own.
+const uploadRetryAfterSeconds = "1"
+
+var (
+	errUploadQueueFull   = errors.New("upload queue is full")
+	errUploadWaitTimeout = errors.New("upload wait timeout")
+)
+
+// uploadAdmission admits the uploads while the inflight upload data is within the limit.
+// The other uploads wait in a bounded first in first out queue, until enough inflight data is done,
+// the wait times out, or the client is gone. The uploads beyond the queue length are rejected at once.
+type uploadAdmission struct {
+	limit       int64
+	queueLength int
+	timeout     time.Duration
+
+	sync.Mutex
+	inFlight int64
+	waiters  *list.List // of *uploadWaiter
+}
+
+type uploadWaiter struct {
+	size int64
+	// closed once the upload is admitted
+	admitted chan struct{}
+}
+
+func newUploadAdmission(limit int64, queueLength int, timeout time.Duration) *uploadAdmission {
+	return &uploadAdmission{
+		limit:       limit,
+		queueLength: queueLength,
+		timeout:     timeout,
+		waiters:     list.New(),
+	}
+}
+
+// admit waits until the upload of the size can start. A nil error must be followed by release.
+// With the limit of 0, or for the replication between volume servers, force admits the upload at once.
+func (a *uploadAdmission) admit(ctx context.Context, size int64, force bool) error {
+	a.Lock()
+	if force || a.limit == 0 || (a.inFlight <= a.limit && a.waiters.Len() == 0) {
+		a.inFlight += size
+		a.Unlock()
+		return nil
+	}
+	if a.waiters.Len() >= a.queueLength {
+		a.Unlock()
+		stats.VolumeServerUploadShedCounter.WithLabelValues("queueFull").Inc()
+		return errUploadQueueFull
+	}
+	w := &uploadWaiter{
+		size:     size,
+		admitted: make(chan struct{}),
+	}
+	element := a.waiters.PushBack(w)
+	stats.VolumeServerUploadQueueGauge.Set(float64(a.waiters.Len()))
+	a.Unlock()
+
+	timer := time.NewTimer(a.timeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case <-w.admitted:
+		return nil
+	case <-timer.C:
+		err = errUploadWaitTimeout
+		stats.VolumeServerUploadShedCounter.WithLabelValues("timeout").Inc()
+	case <-ctx.Done():
+		err = ctx.Err()
+		stats.VolumeServerUploadShedCounter.WithLabelValues("cancelled").Inc()
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	select {
+	case <-w.admitted:
+		// admitted while giving up
+		a.inFlight -= size
+		a.admitWaiters()
+	default:
+		a.waiters.Remove(element)
+		stats.VolumeServerUploadQueueGauge.Set(float64(a.waiters.Len()))
+	}
+	return err
+}
+
+// release marks the admitted upload of the size as done, and admits the waiting uploads.
+func (a *uploadAdmission) release(size int64) {
+	a.Lock()
+	defer a.Unlock()
+	a.inFlight -= size
+	a.admitWaiters()
+}
+
+// admitWaiters admits the waiting uploads in order while the inflight data is within the limit. a.Lock must be held.
+func (a *uploadAdmission) admitWaiters() {
+	for a.waiters.Len() > 0 && a.inFlight <= a.limit {
+		w := a.waiters.Remove(a.waiters.Front()).(*uploadWaiter)
+		a.inFlight += w.size
+		close(w.admitted)
+	}
+	stats.VolumeServerUploadQueueGauge.Set(float64(a.waiters.Len()))
+}
+
+func (a *uploadAdmission) inFlightSize() int64 {
+	a.Lock()
+	defer a.Unlock()
+	return a.inFlight
+}
@@ -0,0 +1,85 @@
+package weed_server
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUploadAdmission(t *testing.T) {
+	a := newUploadAdmission(100, 2, time.Second)
+	ctx := context.Background()
+
+	// admitted while the inflight data is within the limit
+	if err := a.admit(ctx, 80, false); err != nil {
+		t.Fatalf("admit: %v", err)
+	}
+	if err := a.admit(ctx, 80, false); err != nil {
+		t.Fatalf("admit: %v", err)
+	}
+
+	// the next ones wait in order, and the one beyond the queue length is rejected
+	admitted := make(chan int64, 2)
+	for _, size := range []int64{10, 20} {
+		go func(size int64) {
+			if err := a.admit(ctx, size, false); err != nil {
+				t.Errorf("admit %d: %v", size, err)
+			}
+			admitted <- size
+		}(size)
+		for lastWaiterSize(a) != size {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if err := a.admit(ctx, 1, false); err != errUploadQueueFull {
+		t.Errorf("expected %v, actual %v", errUploadQueueFull, err)
+	}
+	if err := a.admit(ctx, 1, true); err != nil {
+		t.Errorf("forced admit: %v", err)
+	}
+	a.release(1)
+
+	a.release(80)
+	a.release(80)
+	if first, second := <-admitted, <-admitted; first+second != 30 {
+		t.Errorf("unexpected admitted %d and %d", first, second)
+	}
+	if inFlight := a.inFlightSize(); inFlight != 30 {
+		t.Errorf("expected inflight 30, actual %d", inFlight)
+	}
+	a.release(10)
+	a.release(20)
+}
+
+func TestUploadAdmissionTimeout(t *testing.T) {
+	a := newUploadAdmission(10, 1, 10*time.Millisecond)
+	ctx := context.Background()
+	if err := a.admit(ctx, 20, false); err != nil {
+		t.Fatalf("admit: %v", err)
+	}
+	if err := a.admit(ctx, 5, false); err != errUploadWaitTimeout {
+		t.Errorf("expected %v, actual %v", errUploadWaitTimeout, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := a.admit(cancelled, 5, false); err != context.Canceled {
+		t.Errorf("expected %v, actual %v", context.Canceled, err)
+	}
+	if size := lastWaiterSize(a); size != 0 {
+		t.Errorf("expected no waiters, actual waiter of %d", size)
+	}
+	a.release(20)
+	if inFlight := a.inFlightSize(); inFlight != 0 {
+		t.Errorf("expected inflight 0, actual %d", inFlight)
+	}
+}
+
+func lastWaiterSize(a *uploadAdmission) int64 {
+	a.Lock()
+	defer a.Unlock()
+	if a.waiters.Len() == 0 {
+		return 0
+	}
+	return a.waiters.Back().Value.(*uploadWaiter).size
+}
@@ -5,12 +5,16 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/cluster"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
 )
 
 func init() {
@@ -25,9 +29,18 @@ func (c *commandClusterCheck) Name() string {
 }
 
 func (c *commandClusterCheck) Help() string {
-	return `check current cluster network connectivity
+	return `check current cluster network connectivity and configuration
 
-	cluster.check
+	cluster.check [-maxClockSkew=500ms]
+
+	Besides the connectivity between all masters, volume servers and filers, this checks
+	  * the masters have the same volumeSizeLimit, default replication and jwt signing keys
+	  * the volume servers have the same jwt signing keys as the masters
+	  * the filers in the filer group have the same store and jwt filer signing key
+	  * the volumes are not larger than the volumeSizeLimit
+	  * the replications in use are possible with the data centers, racks and servers
+	  * the clocks of the servers are in sync
+	The jwt signing keys are compared by fingerprint, the keys themselves are not revealed.
 
 `
 }
@@ -35,6 +48,7 @@ func (c *commandClusterCheck) Help() string {
 func (c *commandClusterCheck) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
 	clusterPsCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	maxClockSkew := clusterPsCommand.Duration("maxClockSkew", 500*time.Millisecond, "report the servers with their clocks off by more than this")
 	if err = clusterPsCommand.Parse(args); err != nil {
 		return nil
 	}
@@ -94,6 +108,8 @@ func (c *commandClusterCheck) Do(args []string, commandEnv *CommandEnv, writer i
 		masters = append(masters, master)
 	}
 
+	findings := &clusterCheckFindings{maxClockSkewMs: float32(*maxClockSkew) / float32(time.Millisecond)}
+
 	// check from master to volume servers
 	for _, master := range masters {
 		for _, volumeServer := range volumeServers {
@@ -104,7 +120,7 @@ func (c *commandClusterCheck) Do(args []string, commandEnv *CommandEnv, writer i
 					TargetType: cluster.VolumeServerType,
 				})
 				if err == nil {
-					printTiming(writer, pong.StartTimeNs, pong.RemoteTimeNs, pong.StopTimeNs)
+					findings.checkClockSkew(string(master), string(volumeServer), printTiming(writer, pong.StartTimeNs, pong.RemoteTimeNs, pong.StopTimeNs))
 				}
 				return err
 			})
@@ -127,7 +143,7 @@ func (c *commandClusterCheck) Do(args []string, commandEnv *CommandEnv, writer i
 					TargetType: cluster.MasterType,
 				})
 				if err == nil {
-					printTiming(writer, pong.StartTimeNs, pong.RemoteTimeNs, pong.StopTimeNs)
+					findings.checkClockSkew(string(sourceMaster), string(targetMaster), printTiming(writer, pong.StartTimeNs, pong.RemoteTimeNs, pong.StopTimeNs))
 				}
 				return err
 			})
@@ -167,7 +183,7 @@ func (c *commandClusterCheck) Do(args []string, commandEnv *CommandEnv, writer i
 					TargetType: cluster.MasterType,
 				})
 				if err == nil {
-					printTiming(writer, pong.StartTimeNs, pong.RemoteTimeNs, pong.StopTimeNs)
+					findings.checkClockSkew(string(master), string(filer), -printTiming(writer, pong.StartTimeNs, pong.RemoteTimeNs, pong.StopTimeNs))
 				}
 				return err
 			})
@@ -240,11 +256,232 @@ func (c *commandClusterCheck) Do(args []string, commandEnv *CommandEnv, writer i
 		}
 	}
 
+	// check the configuration
+	masterConfiguration := checkMasters(commandEnv, masters, findings)
+	if masterConfiguration != nil {
+		checkVolumeServers(commandEnv, volumeServers, masterConfiguration, findings)
+	}
+	filerReplications := checkFilers(commandEnv, filers, findings)
+	checkVolumeSizes(topologyInfo, volumeSizeLimitMb, findings)
+	replications := collectReplications(topologyInfo)
+	if masterConfiguration != nil && masterConfiguration.DefaultReplication != "" {
+		replications[masterConfiguration.DefaultReplication] = "the master default replication"
+	}
+	for filer, replication := range filerReplications {
+		replications[replication] = fmt.Sprintf("the filer %s default replication", filer)
+	}
+	checkReplications(topologyInfo, replications, findings)
+
+	if len(findings.issues) == 0 {
+		fmt.Fprintf(writer, "no configuration issues found\n")
+		return nil
+	}
+	fmt.Fprintf(writer, "found %d issues:\n", len(findings.issues))
+	for _, issue := range findings.issues {
+		fmt.Fprintf(writer, "  * %s\n", issue)
+	}
 	return nil
 }
 
-func printTiming(writer io.Writer, startNs, remoteNs, stopNs int64) {
+// printTiming prints the round trip, and returns how much the remote clock is ahead.
+func printTiming(writer io.Writer, startNs, remoteNs, stopNs int64) (deltaTimeMs float32) {
 	roundTripTimeMs := float32(stopNs-startNs) / 1000000
-	deltaTimeMs := float32(remoteNs-(startNs+stopNs)/2) / 1000000
+	deltaTimeMs = float32(remoteNs-(startNs+stopNs)/2) / 1000000
 	fmt.Fprintf(writer, "ok round trip %.3fms clock delta %.3fms\n", roundTripTimeMs, deltaTimeMs)
+	return
+}
+
+type clusterCheckFindings struct {
+	maxClockSkewMs float32
+	issues         []string
+}
+
+func (f *clusterCheckFindings) add(format string, a ...interface{}) {
+	f.issues = append(f.issues, fmt.Sprintf(format, a...))
+}
+
+func (f *clusterCheckFindings) checkClockSkew(source, target string, deltaTimeMs float32) {
+	if deltaTimeMs > f.maxClockSkewMs || -deltaTimeMs > f.maxClockSkewMs {
+		f.add("the clock of %s is %.0fms off %s, sync the clocks, e.g. with ntp", target, deltaTimeMs, source)
+	}
+}
+
+// checkMasters compares the configurations of the masters, and returns one of them.
+func checkMasters(commandEnv *CommandEnv, masters []rpc.ServerAddress, findings *clusterCheckFindings) (masterConfiguration *master_pb.GetMasterConfigurationResponse) {
+	configurations := make(map[rpc.ServerAddress]*master_pb.GetMasterConfigurationResponse)
+	for _, master := range masters {
+		err := rpc.WithMasterClient(false, master, commandEnv.option.GrpcDialOption, false, func(client master_pb.SeaweedClient) error {
+			resp, err := client.GetMasterConfiguration(context.Background(), &master_pb.GetMasterConfigurationRequest{})
+			if err == nil {
+				configurations[master] = resp
+			}
+			return err
+		})
+		if err != nil {
+			findings.add("can not read the configuration of master %s: %v", master, err)
+		}
+	}
+	diffs := []struct {
+		name  string
+		value func(*master_pb.GetMasterConfigurationResponse) string
+	}{
+		{"-volumeSizeLimitMB", func(r *master_pb.GetMasterConfigurationResponse) string {
+			return fmt.Sprintf("%d", r.VolumeSizeLimitMB)
+		}},
+		{"-defaultReplication", func(r *master_pb.GetMasterConfigurationResponse) string { return r.DefaultReplication }},
+		{"jwt.signing.key", func(r *master_pb.GetMasterConfigurationResponse) string { return r.JwtSigningKeyFingerprint }},
+		{"jwt.signing.read.key", func(r *master_pb.GetMasterConfigurationResponse) string { return r.JwtSigningReadKeyFingerprint }},
+	}
+	for _, diff := range diffs {
+		values := make(map[string][]rpc.ServerAddress)
+		for _, master := range masters {
+			if resp, found := configurations[master]; found {
+				values[diff.value(resp)] = append(values[diff.value(resp)], master)
+			}
+		}
+		if len(values) > 1 {
+			findings.add("the masters have different %s: %s, use the same on all masters", diff.name, describeServerValues(values))
+		}
+	}
+	for _, master := range masters {
+		if resp, found := configurations[master]; found {
+			return resp
+		}
+	}
+	return nil
+}
+
+// checkVolumeServers compares the jwt signing keys of the volume servers with the masters.
+func checkVolumeServers(commandEnv *CommandEnv, volumeServers []rpc.ServerAddress, masterConfiguration *master_pb.GetMasterConfigurationResponse, findings *clusterCheckFindings) {
+	for _, volumeServer := range volumeServers {
+		err := rpc.WithVolumeServerClient(false, volumeServer, commandEnv.option.GrpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
+			resp, err := client.VolumeServerStatus(context.Background(), &volume_server_pb.VolumeServerStatusRequest{})
+			if err != nil {
+				return err
+			}
+			if resp.JwtSigningKeyFingerprint != masterConfiguration.JwtSigningKeyFingerprint {
+				findings.add("volume server %s has a different jwt.signing.key than the masters, writes will be rejected", volumeServer)
+			}
+			if resp.JwtSigningReadKeyFingerprint != masterConfiguration.JwtSigningReadKeyFingerprint {
+				findings.add("volume server %s has a different jwt.signing.read.key than the masters, reads will be rejected", volumeServer)
+			}
+			return nil
+		})
+		if err != nil {
+			findings.add("can not read the status of volume server %s: %v", volumeServer, err)
+		}
+	}
+}
+
+// checkFilers compares the stores and jwt filer signing keys of the filers in the filer group, and returns their default replications.
+func checkFilers(commandEnv *CommandEnv, filers []rpc.ServerAddress, findings *clusterCheckFindings) (replications map[rpc.ServerAddress]string) {
+	replications = make(map[rpc.ServerAddress]string)
+	stores := make(map[string][]rpc.ServerAddress)
+	keys := make(map[string][]rpc.ServerAddress)
+	for _, filer := range filers {
+		err := rpc.WithFilerClient(false, filer, commandEnv.option.GrpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+			resp, err := client.GetFilerConfiguration(context.Background(), &filer_pb.GetFilerConfigurationRequest{})
+			if err != nil {
+				return err
+			}
+			stores[resp.Store] = append(stores[resp.Store], filer)
+			keys[resp.JwtFilerSigningKeyFingerprint] = append(keys[resp.JwtFilerSigningKeyFingerprint], filer)
+			if resp.Replication != "" {
+				replications[filer] = resp.Replication
+			}
+			return nil
+		})
+		if err != nil {
+			findings.add("can not read the configuration of filer %s: %v", filer, err)
+		}
+	}
+	if len(stores) > 1 {
+		findings.add("the filers in the same filer group have different stores: %s, use one filer group per store", describeServerValues(stores))
+	}
+	if len(keys) > 1 {
+		findings.add("the filers have different jwt.filer_signing.key: %s, use the same on all filers", describeServerValues(keys))
+	}
+	return
+}
+
+func describeServerValues(values map[string][]rpc.ServerAddress) string {
+	var descriptions []string
+	for value, servers := range values {
+		if value == "" {
+			value = "<empty>"
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%s on %v", value, servers))
+	}
+	sort.Strings(descriptions)
+	return strings.Join(descriptions, ", ")
+}
+
+// checkVolumeSizes finds the volumes over the volume size limit, with some slack for the writes in flight.
+func checkVolumeSizes(topologyInfo *master_pb.TopologyInfo, volumeSizeLimitMb uint64, findings *clusterCheckFindings) {
+	limit := volumeSizeLimitMb * 1024 * 1024
+	var oversized []uint32
+	eachDataNode(topologyInfo, func(dc string, rack RackId, dn *master_pb.DataNodeInfo) {
+		for _, diskInfo := range dn.DiskInfos {
+			for _, v := range diskInfo.VolumeInfos {
+				if v.Size > limit+limit/10 {
+					oversized = append(oversized, v.Id)
+				}
+			}
+		}
+	})
+	if len(oversized) > 0 {
+		findings.add("%d volume replicas are over the volumeSizeLimit of %d MB, e.g. volume %d, the limit was lowered or a master has another limit", len(oversized), volumeSizeLimitMb, oversized[0])
+	}
+}
+
+// collectReplications returns the replications of the volumes, with a volume using each.
+func collectReplications(topologyInfo *master_pb.TopologyInfo) (replications map[string]string) {
+	replications = make(map[string]string)
+	eachDataNode(topologyInfo, func(dc string, rack RackId, dn *master_pb.DataNodeInfo) {
+		for _, diskInfo := range dn.DiskInfos {
+			for _, v := range diskInfo.VolumeInfos {
+				rp, _ := super_block.NewReplicaPlacementFromByte(byte(v.ReplicaPlacement))
+				if rp != nil {
+					replications[rp.String()] = fmt.Sprintf("volume %d", v.Id)
+				}
+			}
+		}
+	})
+	return
+}
+
+// checkReplications finds the replications in use impossible to place in the topology.
+func checkReplications(topologyInfo *master_pb.TopologyInfo, replications map[string]string, findings *clusterCheckFindings) {
+	for replication, usedBy := range replications {
+		rp, err := super_block.NewReplicaPlacementFromString(replication)
+		if err != nil {
+			continue
+		}
+		if !isReplicationPossible(topologyInfo, rp) {
+			findings.add("replication %s used by %s needs more data centers, racks or servers than the cluster has", replication, usedBy)
+		}
+	}
+}
+
+// isReplicationPossible checks there are enough data centers, racks in one data center, and servers in one rack.
+func isReplicationPossible(topologyInfo *master_pb.TopologyInfo, rp *super_block.ReplicaPlacement) bool {
+	dataCenterCount, maxRackCount, maxServerCount := 0, 0, 0
+	for _, dc := range topologyInfo.DataCenterInfos {
+		rackCount := 0
+		for _, rack := range dc.RackInfos {
+			if len(rack.DataNodeInfos) > 0 {
+				rackCount++
+			}
+			if len(rack.DataNodeInfos) > maxServerCount {
+				maxServerCount = len(rack.DataNodeInfos)
+			}
+		}
+		if rackCount > 0 {
+			dataCenterCount++
+		}
+		if rackCount > maxRackCount {
+			maxRackCount = rackCount
+		}
+	}
+	return rp.DiffDataCenterCount < dataCenterCount && rp.DiffRackCount < maxRackCount && rp.SameRackCount < maxServerCount
 }
@@ -0,0 +1,41 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+)
+
+func TestIsReplicationPossible(t *testing.T) {
+	// one data center with two racks, of two and one servers
+	topologyInfo := &master_pb.TopologyInfo{
+		DataCenterInfos: []*master_pb.DataCenterInfo{{
+			Id: "dc1",
+			RackInfos: []*master_pb.RackInfo{
+				{Id: "rack1", DataNodeInfos: []*master_pb.DataNodeInfo{{Id: "server1"}, {Id: "server2"}}},
+				{Id: "rack2", DataNodeInfos: []*master_pb.DataNodeInfo{{Id: "server3"}}},
+			},
+		}},
+	}
+	for replication, expected := range map[string]bool{
+		"000": true,
+		"001": true,
+		"010": true,
+		"002": false,
+		"020": false,
+		"100": false,
+	} {
+		rp, _ := super_block.NewReplicaPlacementFromString(replication)
+		assert.Equal(t, expected, isReplicationPossible(topologyInfo, rp), replication)
+	}
+}
+
+func TestClusterCheckClockSkew(t *testing.T) {
+	findings := &clusterCheckFindings{maxClockSkewMs: 500}
+	findings.checkClockSkew("master:9333", "volume:8080", 120)
+	findings.checkClockSkew("master:9333", "volume:8080", -600)
+	assert.Equal(t, 1, len(findings.issues))
+}
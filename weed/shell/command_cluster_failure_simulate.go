@@ -0,0 +1,273 @@
+package shell
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+)
+
+func init() {
+	Commands = append(Commands, &commandClusterFailureSimulate{})
+}
+
+type commandClusterFailureSimulate struct {
+}
+
+// FailureSimulation is what the loss of a data center, a rack or a server would do to the volumes.
+type FailureSimulation struct {
+	DataCenter             string           `json:"dataCenter,omitempty"`
+	Rack                   string           `json:"rack,omitempty"`
+	Node                   string           `json:"node,omitempty"`
+	Servers                int              `json:"servers"`
+	UnreadableVolumes      []*FailureVolume `json:"unreadableVolumes,omitempty"`
+	UnderReplicatedVolumes []*FailureVolume `json:"underReplicatedVolumes,omitempty"`
+	UnreadableEcVolumes    []*FailureVolume `json:"unreadableEcVolumes,omitempty"`
+	DegradedEcVolumes      []*FailureVolume `json:"degradedEcVolumes,omitempty"`
+}
+
+// FailureVolume is a volume losing some of its replicas, or an ec volume losing some of its shards.
+type FailureVolume struct {
+	VolumeId    uint32 `json:"volumeId"`
+	Collection  string `json:"collection"`
+	Replication string `json:"replication,omitempty"`
+	Lost        int    `json:"lost"`
+	Left        int    `json:"left"`
+	Expected    int    `json:"expected"`
+}
+
+func (c *commandClusterFailureSimulate) Name() string {
+	return "cluster.failure.simulate"
+}
+
+func (c *commandClusterFailureSimulate) Help() string {
+	return `tell which volumes would become unreadable or under replicated if a data center, a rack or a server fails
+
+	cluster.failure.simulate -dataCenter=dc1                  # simulate the loss of the data center dc1
+	cluster.failure.simulate -dataCenter=dc1 -rack=rack1      # simulate the loss of the rack rack1 in dc1
+	cluster.failure.simulate -node=192.168.1.1:8080           # simulate the loss of one volume server
+	cluster.failure.simulate                                  # audit the loss of each rack, and of each data center if there are several
+
+	The simulation only reads the current topology, nothing is changed.
+	With the loss
+	  a volume is unreadable when none of its replicas is left
+	  a volume is under replicated when fewer replicas are left than its replication asks for
+	  an ec volume is unreadable when fewer than ` + fmt.Sprint(erasure_coding.DataShardsCount) + ` distinct shards are left
+	  an ec volume is degraded when some of its ` + fmt.Sprint(erasure_coding.TotalShardsCount) + ` shards are lost, but it is still readable
+	The volumes already under replicated before the loss, and not losing any replica, are not reported.
+	The audit prints one summary line per rack or data center, use -v to list the volumes.
+
+`
+}
+
+func (c *commandClusterFailureSimulate) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	failureSimulateCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	dataCenter := failureSimulateCommand.String("dataCenter", "", "the data center to lose")
+	rack := failureSimulateCommand.String("rack", "", "the rack to lose, in any data center unless -dataCenter is set")
+	node := failureSimulateCommand.String("node", "", "the volume server to lose, <host>:<port>")
+	verbose := failureSimulateCommand.Bool("v", false, "list the volumes of each rack or data center of the audit")
+	if err = failureSimulateCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	topologyInfo, _, err := collectTopologyInfo(commandEnv, 0)
+	if err != nil {
+		return err
+	}
+
+	var domains []failureDomain
+	isAudit := *dataCenter == "" && *rack == "" && *node == ""
+	if !isAudit {
+		domains = []failureDomain{{dataCenter: *dataCenter, rack: *rack, node: *node}}
+	} else {
+		domains = auditFailureDomains(topologyInfo)
+		if len(domains) == 0 {
+			return fmt.Errorf("no data nodes at all")
+		}
+	}
+
+	var simulations []*FailureSimulation
+	for _, domain := range domains {
+		simulation := simulateFailure(topologyInfo, domain)
+		if simulation.Servers == 0 && !isAudit {
+			return fmt.Errorf("no volume server in %s", domain)
+		}
+		simulations = append(simulations, simulation)
+	}
+
+	if writeCommandData(writer, simulations) {
+		return nil
+	}
+	for _, simulation := range simulations {
+		if !isAudit || *verbose {
+			printFailureSimulation(writer, simulation)
+		} else {
+			fmt.Fprintf(writer, "%s\n", simulation.summary())
+		}
+	}
+	return nil
+}
+
+// failureDomain is the data center, the rack or the server simulated to fail, the empty fields match any.
+type failureDomain struct {
+	dataCenter string
+	rack       string
+	node       string
+}
+
+func (d failureDomain) contains(loc location) bool {
+	return (d.dataCenter == "" || d.dataCenter == loc.dc) &&
+		(d.rack == "" || d.rack == loc.rack) &&
+		(d.node == "" || d.node == loc.dataNode.Id)
+}
+
+func (d failureDomain) String() string {
+	switch {
+	case d.node != "":
+		return "server " + d.node
+	case d.rack != "" && d.dataCenter != "":
+		return "rack " + d.dataCenter + " " + d.rack
+	case d.rack != "":
+		return "rack " + d.rack
+	default:
+		return "data center " + d.dataCenter
+	}
+}
+
+// auditFailureDomains lists each rack, and each data center if there are several.
+func auditFailureDomains(topologyInfo *master_pb.TopologyInfo) (domains []failureDomain) {
+	for _, dc := range topologyInfo.DataCenterInfos {
+		if len(topologyInfo.DataCenterInfos) > 1 {
+			domains = append(domains, failureDomain{dataCenter: dc.Id})
+		}
+		for _, rack := range dc.RackInfos {
+			domains = append(domains, failureDomain{dataCenter: dc.Id, rack: rack.Id})
+		}
+	}
+	return
+}
+
+type ecShardLocation struct {
+	location  location
+	shardInfo *master_pb.VolumeEcShardInformationMessage
+}
+
+func simulateFailure(topologyInfo *master_pb.TopologyInfo, domain failureDomain) *FailureSimulation {
+	simulation := &FailureSimulation{
+		DataCenter: domain.dataCenter,
+		Rack:       domain.rack,
+		Node:       domain.node,
+	}
+
+	ecShardLocations := make(map[uint32][]*ecShardLocation)
+	eachDataNode(topologyInfo, func(dc string, rack RackId, dn *master_pb.DataNodeInfo) {
+		loc := newLocation(dc, string(rack), dn)
+		if domain.contains(loc) {
+			simulation.Servers++
+		}
+		for _, diskInfo := range dn.DiskInfos {
+			for _, shardInfo := range diskInfo.EcShardInfos {
+				ecShardLocations[shardInfo.Id] = append(ecShardLocations[shardInfo.Id], &ecShardLocation{location: loc, shardInfo: shardInfo})
+			}
+		}
+	})
+
+	volumeReplicas, _ := collectVolumeReplicaLocations(topologyInfo)
+	for vid, replicas := range volumeReplicas {
+		var lost int
+		for _, replica := range replicas {
+			if domain.contains(*replica.location) {
+				lost++
+			}
+		}
+		if lost == 0 {
+			continue
+		}
+		replicaPlacement, _ := super_block.NewReplicaPlacementFromByte(byte(replicas[0].info.ReplicaPlacement))
+		volume := &FailureVolume{
+			VolumeId:    vid,
+			Collection:  replicas[0].info.Collection,
+			Replication: replicaPlacement.String(),
+			Lost:        lost,
+			Left:        len(replicas) - lost,
+			Expected:    replicaPlacement.GetCopyCount(),
+		}
+		if volume.Left == 0 {
+			simulation.UnreadableVolumes = append(simulation.UnreadableVolumes, volume)
+		} else if volume.Left < volume.Expected {
+			simulation.UnderReplicatedVolumes = append(simulation.UnderReplicatedVolumes, volume)
+		}
+	}
+
+	for vid, shardLocations := range ecShardLocations {
+		var lostShards, leftShards erasure_coding.ShardBits
+		for _, shardLocation := range shardLocations {
+			if domain.contains(shardLocation.location) {
+				lostShards = lostShards.Plus(erasure_coding.ShardBits(shardLocation.shardInfo.EcIndexBits))
+			} else {
+				leftShards = leftShards.Plus(erasure_coding.ShardBits(shardLocation.shardInfo.EcIndexBits))
+			}
+		}
+		// the shards also kept on a surviving server are not lost
+		lostShards = lostShards.Minus(leftShards)
+		if lostShards.ShardIdCount() == 0 {
+			continue
+		}
+		volume := &FailureVolume{
+			VolumeId:   vid,
+			Collection: shardLocations[0].shardInfo.Collection,
+			Lost:       lostShards.ShardIdCount(),
+			Left:       leftShards.ShardIdCount(),
+			Expected:   erasure_coding.TotalShardsCount,
+		}
+		if volume.Left < erasure_coding.DataShardsCount {
+			simulation.UnreadableEcVolumes = append(simulation.UnreadableEcVolumes, volume)
+		} else {
+			simulation.DegradedEcVolumes = append(simulation.DegradedEcVolumes, volume)
+		}
+	}
+
+	for _, volumes := range [][]*FailureVolume{simulation.UnreadableVolumes, simulation.UnderReplicatedVolumes, simulation.UnreadableEcVolumes, simulation.DegradedEcVolumes} {
+		sort.Slice(volumes, func(i, j int) bool {
+			return volumes[i].VolumeId < volumes[j].VolumeId
+		})
+	}
+
+	return simulation
+}
+
+func (simulation *FailureSimulation) domain() failureDomain {
+	return failureDomain{dataCenter: simulation.DataCenter, rack: simulation.Rack, node: simulation.Node}
+}
+
+func (simulation *FailureSimulation) summary() string {
+	return fmt.Sprintf("losing %s with %d servers: %d unreadable volumes, %d under replicated volumes, %d unreadable ec volumes, %d degraded ec volumes",
+		simulation.domain(), simulation.Servers,
+		len(simulation.UnreadableVolumes), len(simulation.UnderReplicatedVolumes),
+		len(simulation.UnreadableEcVolumes), len(simulation.DegradedEcVolumes))
+}
+
+func printFailureSimulation(writer io.Writer, simulation *FailureSimulation) {
+	fmt.Fprintf(writer, "%s\n", simulation.summary())
+	for _, volume := range simulation.UnreadableVolumes {
+		fmt.Fprintf(writer, "  unreadable volume %d collection:%q replication:%s lost %d replicas\n",
+			volume.VolumeId, volume.Collection, volume.Replication, volume.Lost)
+	}
+	for _, volume := range simulation.UnderReplicatedVolumes {
+		fmt.Fprintf(writer, "  under replicated volume %d collection:%q replication:%s %d of %d replicas left\n",
+			volume.VolumeId, volume.Collection, volume.Replication, volume.Left, volume.Expected)
+	}
+	for _, volume := range simulation.UnreadableEcVolumes {
+		fmt.Fprintf(writer, "  unreadable ec volume %d collection:%q %d of %d shards left, %d needed\n",
+			volume.VolumeId, volume.Collection, volume.Left, volume.Expected, erasure_coding.DataShardsCount)
+	}
+	for _, volume := range simulation.DegradedEcVolumes {
+		fmt.Fprintf(writer, "  degraded ec volume %d collection:%q %d of %d shards left\n",
+			volume.VolumeId, volume.Collection, volume.Left, volume.Expected)
+	}
+}
@@ -0,0 +1,63 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+)
+
+func TestSimulateFailure(t *testing.T) {
+	volume := func(id uint32, replicaPlacement uint32) *master_pb.VolumeInformationMessage {
+		return &master_pb.VolumeInformationMessage{Id: id, ReplicaPlacement: replicaPlacement}
+	}
+	ecNode := func(node *master_pb.DataNodeInfo, shardBits uint32) *master_pb.DataNodeInfo {
+		node.DiskInfos[""].EcShardInfos = []*master_pb.VolumeEcShardInformationMessage{{Id: 9, EcIndexBits: shardBits}}
+		return node
+	}
+	// replication 010 is 2 copies on different racks, 001 is 2 copies in the same rack
+	topologyInfo := &master_pb.TopologyInfo{
+		DataCenterInfos: []*master_pb.DataCenterInfo{{
+			Id: "dc1",
+			RackInfos: []*master_pb.RackInfo{
+				{Id: "rack1", DataNodeInfos: []*master_pb.DataNodeInfo{
+					ecNode(testDataNode("server1:8080", 8, volume(1, 0), volume(2, 10), volume(3, 1)), 0b00_0000_0011_1111),
+					ecNode(testDataNode("server2:8080", 8, volume(3, 1), volume(4, 10)), 0b00_0000_1100_0000),
+				}},
+				{Id: "rack2", DataNodeInfos: []*master_pb.DataNodeInfo{
+					ecNode(testDataNode("server3:8080", 8, volume(2, 10), volume(4, 10)), 0b11_1111_0000_0001),
+				}},
+			},
+		}},
+	}
+
+	simulation := simulateFailure(topologyInfo, failureDomain{dataCenter: "dc1", rack: "rack1"})
+	assert.Equal(t, 2, simulation.Servers)
+	assert.Equal(t, []*FailureVolume{
+		{VolumeId: 1, Replication: "000", Lost: 1, Left: 0, Expected: 1},
+		{VolumeId: 3, Replication: "001", Lost: 2, Left: 0, Expected: 2},
+	}, simulation.UnreadableVolumes)
+	assert.Equal(t, []*FailureVolume{
+		{VolumeId: 2, Replication: "010", Lost: 1, Left: 1, Expected: 2},
+		{VolumeId: 4, Replication: "010", Lost: 1, Left: 1, Expected: 2},
+	}, simulation.UnderReplicatedVolumes)
+	// shard 0 is also on server3
+	assert.Equal(t, []*FailureVolume{{VolumeId: 9, Lost: 7, Left: 7, Expected: 14}}, simulation.UnreadableEcVolumes)
+	assert.Empty(t, simulation.DegradedEcVolumes)
+
+	simulation = simulateFailure(topologyInfo, failureDomain{node: "server2:8080"})
+	assert.Equal(t, 1, simulation.Servers)
+	assert.Empty(t, simulation.UnreadableVolumes)
+	assert.Equal(t, []*FailureVolume{
+		{VolumeId: 3, Replication: "001", Lost: 1, Left: 1, Expected: 2},
+		{VolumeId: 4, Replication: "010", Lost: 1, Left: 1, Expected: 2},
+	}, simulation.UnderReplicatedVolumes)
+	assert.Equal(t, []*FailureVolume{{VolumeId: 9, Lost: 2, Left: 12, Expected: 14}}, simulation.DegradedEcVolumes)
+
+	simulation = simulateFailure(topologyInfo, failureDomain{rack: "rack3"})
+	assert.Equal(t, 0, simulation.Servers)
+	assert.Empty(t, simulation.UnderReplicatedVolumes)
+
+	assert.Equal(t, []failureDomain{{dataCenter: "dc1", rack: "rack1"}, {dataCenter: "dc1", rack: "rack2"}}, auditFailureDomains(topologyInfo))
+}
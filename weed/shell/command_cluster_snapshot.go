@@ -0,0 +1,105 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/topology"
+)
+
+func init() {
+	Commands = append(Commands, &commandClusterSnapshot{})
+}
+
+type commandClusterSnapshot struct {
+}
+
+func (c *commandClusterSnapshot) Name() string {
+	return "cluster.snapshot"
+}
+
+func (c *commandClusterSnapshot) Help() string {
+	return `export the master state kept in raft to a file, or bootstrap a new master cluster from it
+
+	cluster.snapshot -o=masters.json         # export the max volume id, the file key sequence, the volume id ranges and the replication targets
+	cluster.snapshot -restore=masters.json   # restore them to the current master cluster
+
+	Export the snapshot regularly. When all masters and their raft directories are lost but the volume servers survive,
+	start a new master cluster, and restore the snapshot before creating new volumes, so the volume ids and file keys
+	of the surviving volumes are never assigned again, and the cluster settings are back.
+	The max volume id and the file key sequence only move forward. The volume id ranges and the replication targets are replaced.
+
+`
+}
+
+func (c *commandClusterSnapshot) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	clusterSnapshotCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	outputFile := clusterSnapshotCommand.String("o", "", "the file to export the snapshot to")
+	restoreFile := clusterSnapshotCommand.String("restore", "", "the snapshot file to restore")
+	if err = clusterSnapshotCommand.Parse(args); err != nil {
+		return nil
+	}
+	if (*outputFile == "") == (*restoreFile == "") {
+		return fmt.Errorf("use either -o or -restore")
+	}
+
+	if *outputFile != "" {
+		var resp *master_pb.ExportClusterSnapshotResponse
+		err = commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
+			resp, err = client.ExportClusterSnapshot(context.Background(), &master_pb.ExportClusterSnapshotRequest{})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("export cluster snapshot: %v", err)
+		}
+		if err = os.WriteFile(*outputFile, resp.Snapshot, 0644); err != nil {
+			return err
+		}
+		return writeClusterSnapshot(writer, "exported to "+*outputFile, resp.Snapshot)
+	}
+
+	if err = commandEnv.confirmIsLocked(args); err != nil {
+		return
+	}
+	data, err := os.ReadFile(*restoreFile)
+	if err != nil {
+		return err
+	}
+	if _, err = topology.ParseClusterSnapshot(data); err != nil {
+		return err
+	}
+	err = commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
+		_, err := client.RestoreClusterSnapshot(context.Background(), &master_pb.RestoreClusterSnapshotRequest{
+			Snapshot: data,
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("restore cluster snapshot: %v", err)
+	}
+	return writeClusterSnapshot(writer, "restored from "+*restoreFile, data)
+}
+
+func writeClusterSnapshot(writer io.Writer, action string, data []byte) error {
+	snapshot, err := topology.ParseClusterSnapshot(data)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "cluster snapshot of %v %s\n", snapshot.CreatedAt.Format("2006-01-02T15:04:05Z"), action)
+	fmt.Fprintf(writer, "max volume id: %d\n", snapshot.MaxVolumeId)
+	fmt.Fprintf(writer, "file key sequence: %d\n", snapshot.FileKeySequence)
+	if snapshot.VolumeIdRanges == "" {
+		fmt.Fprintf(writer, "volume id ranges: unrestricted\n")
+	} else {
+		fmt.Fprintf(writer, "volume id ranges: %s\n", snapshot.VolumeIdRanges)
+	}
+	for _, target := range snapshot.ReplicationTargets {
+		fmt.Fprintf(writer, "replication target: %s\n", target)
+	}
+	return nil
+}
@@ -0,0 +1,478 @@
+package shell
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandClusterTopologyDiff{})
+}
+
+type commandClusterTopologyDiff struct {
+}
+
+// TopologySnapshot is the topology saved to a file by cluster.topology.diff -o.
+type TopologySnapshot struct {
+	CreatedAt         time.Time       `json:"createdAt"`
+	VolumeSizeLimitMb uint64          `json:"volumeSizeLimitMb"`
+	Topology          json.RawMessage `json:"topology"`
+	topologyInfo      *master_pb.TopologyInfo
+}
+
+// TopologyDiff is what changed in the topology between two snapshots.
+type TopologyDiff struct {
+	Since              time.Time                 `json:"since"`
+	Until              time.Time                 `json:"until"`
+	ServersAppeared    []*TopologyServer         `json:"serversAppeared,omitempty"`
+	ServersDisappeared []*TopologyServer         `json:"serversDisappeared,omitempty"`
+	VolumesAdded       []*TopologyVolumeChange   `json:"volumesAdded,omitempty"`
+	VolumesRemoved     []*TopologyVolumeChange   `json:"volumesRemoved,omitempty"`
+	VolumesMoved       []*TopologyVolumeChange   `json:"volumesMoved,omitempty"`
+	VolumesReadOnly    []uint32                  `json:"volumesReadOnly,omitempty"`
+	VolumesWritable    []uint32                  `json:"volumesWritable,omitempty"`
+	Capacity           []*TopologyCapacityChange `json:"capacity,omitempty"`
+	Total              *TopologyCapacityChange   `json:"total"`
+}
+
+type TopologyServer struct {
+	Id         string `json:"id"`
+	DataCenter string `json:"dataCenter"`
+	Rack       string `json:"rack"`
+}
+
+// TopologyVolumeChange is a volume, or an ec volume, added, removed or moved between the servers.
+// The locations of an ec volume are the servers with the shard ids, as server[0 1 2].
+type TopologyVolumeChange struct {
+	VolumeId   uint32   `json:"volumeId"`
+	Collection string   `json:"collection"`
+	Ec         bool     `json:"ec,omitempty"`
+	From       []string `json:"from,omitempty"`
+	To         []string `json:"to,omitempty"`
+}
+
+type TopologyCapacity struct {
+	Volumes    int64  `json:"volumes"`
+	EcShards   int64  `json:"ecShards"`
+	MaxVolumes int64  `json:"maxVolumes"`
+	Size       uint64 `json:"size"`
+}
+
+// TopologyCapacityChange is the capacity of a disk type of a server, or of the whole cluster, before and after.
+type TopologyCapacityChange struct {
+	Server   string           `json:"server,omitempty"`
+	DiskType string           `json:"diskType,omitempty"`
+	Before   TopologyCapacity `json:"before"`
+	After    TopologyCapacity `json:"after"`
+}
+
+func (c *commandClusterTopologyDiff) Name() string {
+	return "cluster.topology.diff"
+}
+
+func (c *commandClusterTopologyDiff) Help() string {
+	return `save the topology to a file, and tell what changed since then
+
+	cluster.topology.diff -o=topology.json                                # save the current topology
+	cluster.topology.diff -since=topology.json                            # what changed from the saved topology to now
+	cluster.topology.diff -since=topology.json -o=topology.json           # and save the current topology for the next time
+	cluster.topology.diff -since=yesterday.json -to=today.json            # what changed between two saved topologies
+
+	The changes are:
+	  the volume servers appeared or disappeared
+	  the volumes and ec volumes added, removed, or moved to other servers
+	  the volumes became read only or writable
+	  the capacity of each server and disk type, when the volume count, the ec shard count
+	  or the max volume count changed, or the size changed by at least -sizeChangeMB
+
+	Save the topology regularly, e.g. daily, to answer "what changed since yesterday" during an incident.
+
+`
+}
+
+func (c *commandClusterTopologyDiff) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	topologyDiffCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	outputFile := topologyDiffCommand.String("o", "", "the file to save the current topology to")
+	sinceFile := topologyDiffCommand.String("since", "", "the saved topology to compare with")
+	toFile := topologyDiffCommand.String("to", "", "compare with this saved topology instead of the current one")
+	sizeChangeMB := topologyDiffCommand.Uint64("sizeChangeMB", 1024, "report the servers whose size changed by at least this many MB")
+	if err = topologyDiffCommand.Parse(args); err != nil {
+		return nil
+	}
+	if *outputFile == "" && *sinceFile == "" {
+		return fmt.Errorf("use -o or -since")
+	}
+	if *toFile != "" && (*sinceFile == "" || *outputFile != "") {
+		return fmt.Errorf("-to is only used with -since, and not with -o")
+	}
+
+	var since, until *TopologySnapshot
+	if *sinceFile != "" {
+		if since, err = readTopologySnapshot(*sinceFile); err != nil {
+			return err
+		}
+	}
+	if *toFile != "" {
+		if until, err = readTopologySnapshot(*toFile); err != nil {
+			return err
+		}
+	} else {
+		topologyInfo, volumeSizeLimitMb, err := collectTopologyInfo(commandEnv, 0)
+		if err != nil {
+			return err
+		}
+		until = &TopologySnapshot{
+			CreatedAt:         time.Now(),
+			VolumeSizeLimitMb: volumeSizeLimitMb,
+			topologyInfo:      topologyInfo,
+		}
+	}
+
+	if *outputFile != "" {
+		if err = writeTopologySnapshot(*outputFile, until); err != nil {
+			return err
+		}
+		if since == nil {
+			fmt.Fprintf(writer, "saved the topology to %s\n", *outputFile)
+			return nil
+		}
+	}
+
+	diff := diffTopology(since, until, *sizeChangeMB*1024*1024)
+	if writeCommandData(writer, diff) {
+		return nil
+	}
+	printTopologyDiff(writer, diff)
+	if *outputFile != "" {
+		fmt.Fprintf(writer, "saved the topology to %s\n", *outputFile)
+	}
+	return nil
+}
+
+func readTopologySnapshot(fileName string) (*TopologySnapshot, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &TopologySnapshot{}
+	if err = json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("parse topology %s: %v", fileName, err)
+	}
+	snapshot.topologyInfo = &master_pb.TopologyInfo{}
+	if err = protojson.Unmarshal(snapshot.Topology, snapshot.topologyInfo); err != nil {
+		return nil, fmt.Errorf("parse topology %s: %v", fileName, err)
+	}
+	return snapshot, nil
+}
+
+func writeTopologySnapshot(fileName string, snapshot *TopologySnapshot) (err error) {
+	if snapshot.Topology, err = protojson.Marshal(snapshot.topologyInfo); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, data, 0644)
+}
+
+// topologyState is the volume and ec shard locations, and the capacity, of a topology.
+type topologyState struct {
+	servers  map[string]*TopologyServer
+	volumes  map[uint32]map[string]*master_pb.VolumeInformationMessage
+	ecShards map[uint32]map[string]*master_pb.VolumeEcShardInformationMessage
+	capacity map[topologyDisk]*TopologyCapacity
+}
+
+type topologyDisk struct {
+	server   string
+	diskType string
+}
+
+func newTopologyState(topologyInfo *master_pb.TopologyInfo) *topologyState {
+	state := &topologyState{
+		servers:  make(map[string]*TopologyServer),
+		volumes:  make(map[uint32]map[string]*master_pb.VolumeInformationMessage),
+		ecShards: make(map[uint32]map[string]*master_pb.VolumeEcShardInformationMessage),
+		capacity: make(map[topologyDisk]*TopologyCapacity),
+	}
+	eachDataNode(topologyInfo, func(dc string, rack RackId, dn *master_pb.DataNodeInfo) {
+		state.servers[dn.Id] = &TopologyServer{Id: dn.Id, DataCenter: dc, Rack: string(rack)}
+		for diskType, diskInfo := range dn.DiskInfos {
+			key := topologyDisk{server: dn.Id, diskType: types.ToDiskType(diskType).ReadableString()}
+			capacity := state.capacity[key]
+			if capacity == nil {
+				capacity = &TopologyCapacity{}
+				state.capacity[key] = capacity
+			}
+			capacity.MaxVolumes += diskInfo.MaxVolumeCount
+			for _, v := range diskInfo.VolumeInfos {
+				capacity.Volumes++
+				capacity.Size += v.Size
+				if state.volumes[v.Id] == nil {
+					state.volumes[v.Id] = make(map[string]*master_pb.VolumeInformationMessage)
+				}
+				state.volumes[v.Id][dn.Id] = v
+			}
+			for _, ecShardInfo := range diskInfo.EcShardInfos {
+				capacity.EcShards += int64(erasure_coding.ShardBits(ecShardInfo.EcIndexBits).ShardIdCount())
+				if state.ecShards[ecShardInfo.Id] == nil {
+					state.ecShards[ecShardInfo.Id] = make(map[string]*master_pb.VolumeEcShardInformationMessage)
+				}
+				state.ecShards[ecShardInfo.Id][dn.Id] = ecShardInfo
+			}
+		}
+	})
+	return state
+}
+
+func (state *topologyState) total() (total TopologyCapacity) {
+	for _, capacity := range state.capacity {
+		total.Volumes += capacity.Volumes
+		total.EcShards += capacity.EcShards
+		total.MaxVolumes += capacity.MaxVolumes
+		total.Size += capacity.Size
+	}
+	return
+}
+
+// diffTopology compares the topology until with the topology since.
+// The capacity changes of a server are reported when its counts changed, or its size changed by at least sizeChange bytes.
+func diffTopology(since, until *TopologySnapshot, sizeChange uint64) *TopologyDiff {
+	before, after := newTopologyState(since.topologyInfo), newTopologyState(until.topologyInfo)
+	diff := &TopologyDiff{
+		Since: since.CreatedAt,
+		Until: until.CreatedAt,
+	}
+
+	for id, server := range after.servers {
+		if _, found := before.servers[id]; !found {
+			diff.ServersAppeared = append(diff.ServersAppeared, server)
+		}
+	}
+	for id, server := range before.servers {
+		if _, found := after.servers[id]; !found {
+			diff.ServersDisappeared = append(diff.ServersDisappeared, server)
+		}
+	}
+	sortTopologyServers(diff.ServersAppeared)
+	sortTopologyServers(diff.ServersDisappeared)
+
+	for _, vid := range unionVolumeIds(before.volumes, after.volumes) {
+		from, to := before.volumes[vid], after.volumes[vid]
+		change := &TopologyVolumeChange{
+			VolumeId:   vid,
+			Collection: anyVolumeCollection(to, from),
+			From:       volumeLocations(from),
+			To:         volumeLocations(to),
+		}
+		diff.addVolumeChange(change)
+		if len(from) > 0 && len(to) > 0 {
+			wasReadOnly, isReadOnly := anyVolumeReadOnly(from), anyVolumeReadOnly(to)
+			if !wasReadOnly && isReadOnly {
+				diff.VolumesReadOnly = append(diff.VolumesReadOnly, vid)
+			} else if wasReadOnly && !isReadOnly {
+				diff.VolumesWritable = append(diff.VolumesWritable, vid)
+			}
+		}
+	}
+	for _, vid := range unionVolumeIds(before.ecShards, after.ecShards) {
+		from, to := before.ecShards[vid], after.ecShards[vid]
+		change := &TopologyVolumeChange{
+			VolumeId: vid,
+			Ec:       true,
+			From:     ecShardLocations(from),
+			To:       ecShardLocations(to),
+		}
+		for _, shards := range from {
+			change.Collection = shards.Collection
+		}
+		for _, shards := range to {
+			change.Collection = shards.Collection
+		}
+		diff.addVolumeChange(change)
+	}
+
+	for key := range after.capacity {
+		if _, found := before.capacity[key]; !found {
+			before.capacity[key] = &TopologyCapacity{}
+		}
+	}
+	for key, capacity := range before.capacity {
+		change := &TopologyCapacityChange{Server: key.server, DiskType: key.diskType, Before: *capacity}
+		if capacity := after.capacity[key]; capacity != nil {
+			change.After = *capacity
+		}
+		if change.isChanged(sizeChange) {
+			diff.Capacity = append(diff.Capacity, change)
+		}
+	}
+	sort.Slice(diff.Capacity, func(i, j int) bool {
+		if diff.Capacity[i].Server != diff.Capacity[j].Server {
+			return diff.Capacity[i].Server < diff.Capacity[j].Server
+		}
+		return diff.Capacity[i].DiskType < diff.Capacity[j].DiskType
+	})
+	diff.Total = &TopologyCapacityChange{Before: before.total(), After: after.total()}
+
+	return diff
+}
+
+func (diff *TopologyDiff) addVolumeChange(change *TopologyVolumeChange) {
+	switch {
+	case len(change.From) == 0:
+		diff.VolumesAdded = append(diff.VolumesAdded, change)
+	case len(change.To) == 0:
+		diff.VolumesRemoved = append(diff.VolumesRemoved, change)
+	case !slices.Equal(change.From, change.To):
+		diff.VolumesMoved = append(diff.VolumesMoved, change)
+	}
+}
+
+func (diff *TopologyDiff) isEmpty() bool {
+	return len(diff.ServersAppeared) == 0 && len(diff.ServersDisappeared) == 0 &&
+		len(diff.VolumesAdded) == 0 && len(diff.VolumesRemoved) == 0 && len(diff.VolumesMoved) == 0 &&
+		len(diff.VolumesReadOnly) == 0 && len(diff.VolumesWritable) == 0 && len(diff.Capacity) == 0
+}
+
+func (change *TopologyCapacityChange) isChanged(sizeChange uint64) bool {
+	before, after := change.Before, change.After
+	if before.Volumes != after.Volumes || before.EcShards != after.EcShards || before.MaxVolumes != after.MaxVolumes {
+		return true
+	}
+	if before.Size > after.Size {
+		return before.Size-after.Size >= sizeChange
+	}
+	return after.Size > before.Size && after.Size-before.Size >= sizeChange
+}
+
+func unionVolumeIds[T any](before, after map[uint32]T) (vids []uint32) {
+	for vid := range before {
+		vids = append(vids, vid)
+	}
+	for vid := range after {
+		if _, found := before[vid]; !found {
+			vids = append(vids, vid)
+		}
+	}
+	slices.Sort(vids)
+	return
+}
+
+func volumeLocations(replicas map[string]*master_pb.VolumeInformationMessage) (locations []string) {
+	for server := range replicas {
+		locations = append(locations, server)
+	}
+	slices.Sort(locations)
+	return
+}
+
+func ecShardLocations(shards map[string]*master_pb.VolumeEcShardInformationMessage) (locations []string) {
+	for server, shardInfo := range shards {
+		locations = append(locations, fmt.Sprintf("%s%v", server, erasure_coding.ShardBits(shardInfo.EcIndexBits).ShardIds()))
+	}
+	slices.Sort(locations)
+	return
+}
+
+func anyVolumeCollection(replicaSets ...map[string]*master_pb.VolumeInformationMessage) string {
+	for _, replicas := range replicaSets {
+		for _, replica := range replicas {
+			return replica.Collection
+		}
+	}
+	return ""
+}
+
+func anyVolumeReadOnly(replicas map[string]*master_pb.VolumeInformationMessage) bool {
+	for _, replica := range replicas {
+		if replica.ReadOnly {
+			return true
+		}
+	}
+	return false
+}
+
+func sortTopologyServers(servers []*TopologyServer) {
+	sort.Slice(servers, func(i, j int) bool {
+		return servers[i].Id < servers[j].Id
+	})
+}
+
+func printTopologyDiff(writer io.Writer, diff *TopologyDiff) {
+	fmt.Fprintf(writer, "changes from %s to %s (%v)\n", diff.Since.Format(time.RFC3339), diff.Until.Format(time.RFC3339), diff.Until.Sub(diff.Since).Round(time.Second))
+	if diff.isEmpty() {
+		fmt.Fprintf(writer, "no changes\n")
+	}
+	printTopologyServers(writer, "servers appeared", diff.ServersAppeared)
+	printTopologyServers(writer, "servers disappeared", diff.ServersDisappeared)
+	printTopologyVolumeChanges(writer, "volumes added", diff.VolumesAdded)
+	printTopologyVolumeChanges(writer, "volumes removed", diff.VolumesRemoved)
+	printTopologyVolumeChanges(writer, "volumes moved", diff.VolumesMoved)
+	if len(diff.VolumesReadOnly) > 0 {
+		fmt.Fprintf(writer, "volumes became read only: %v\n", diff.VolumesReadOnly)
+	}
+	if len(diff.VolumesWritable) > 0 {
+		fmt.Fprintf(writer, "volumes became writable: %v\n", diff.VolumesWritable)
+	}
+	if len(diff.Capacity) > 0 {
+		fmt.Fprintf(writer, "capacity:\n")
+		for _, change := range diff.Capacity {
+			fmt.Fprintf(writer, "  %s %s %s\n", change.Server, change.DiskType, change.String())
+		}
+	}
+	fmt.Fprintf(writer, "total %s\n", diff.Total.String())
+}
+
+func printTopologyServers(writer io.Writer, title string, servers []*TopologyServer) {
+	if len(servers) == 0 {
+		return
+	}
+	fmt.Fprintf(writer, "%s: %d\n", title, len(servers))
+	for _, server := range servers {
+		fmt.Fprintf(writer, "  %s/%s/%s\n", server.DataCenter, server.Rack, server.Id)
+	}
+}
+
+func printTopologyVolumeChanges(writer io.Writer, title string, changes []*TopologyVolumeChange) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintf(writer, "%s: %d\n", title, len(changes))
+	for _, change := range changes {
+		kind := "volume"
+		if change.Ec {
+			kind = "ec volume"
+		}
+		fmt.Fprintf(writer, "  %s %d collection:%q", kind, change.VolumeId, change.Collection)
+		if len(change.From) > 0 {
+			fmt.Fprintf(writer, " from %s", strings.Join(change.From, ","))
+		}
+		if len(change.To) > 0 {
+			fmt.Fprintf(writer, " to %s", strings.Join(change.To, ","))
+		}
+		fmt.Fprintln(writer)
+	}
+}
+
+func (change *TopologyCapacityChange) String() string {
+	before, after := change.Before, change.After
+	return fmt.Sprintf("volumes %d->%d ecShards %d->%d maxVolumes %d->%d size %s->%s",
+		before.Volumes, after.Volumes, before.EcShards, after.EcShards, before.MaxVolumes, after.MaxVolumes,
+		util.BytesToHumanReadable(before.Size), util.BytesToHumanReadable(after.Size))
+}
@@ -0,0 +1,134 @@
+package shell
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+)
+
+func testTopology(nodes ...*master_pb.DataNodeInfo) *master_pb.TopologyInfo {
+	return &master_pb.TopologyInfo{
+		DataCenterInfos: []*master_pb.DataCenterInfo{{
+			Id:        "dc1",
+			RackInfos: []*master_pb.RackInfo{{Id: "rack1", DataNodeInfos: nodes}},
+		}},
+	}
+}
+
+func testDataNode(id string, maxVolumeCount int64, volumes ...*master_pb.VolumeInformationMessage) *master_pb.DataNodeInfo {
+	return &master_pb.DataNodeInfo{
+		Id: id,
+		DiskInfos: map[string]*master_pb.DiskInfo{"": {
+			MaxVolumeCount: maxVolumeCount,
+			VolumeCount:    int64(len(volumes)),
+			VolumeInfos:    volumes,
+		}},
+	}
+}
+
+func TestDiffTopology(t *testing.T) {
+	since := &TopologySnapshot{
+		CreatedAt: time.Unix(1000, 0),
+		topologyInfo: testTopology(
+			testDataNode("server1:8080", 8,
+				&master_pb.VolumeInformationMessage{Id: 1, Size: 100},
+				&master_pb.VolumeInformationMessage{Id: 2, Size: 200},
+				&master_pb.VolumeInformationMessage{Id: 3, Size: 300, Collection: "c"}),
+			testDataNode("server2:8080", 8,
+				&master_pb.VolumeInformationMessage{Id: 1, Size: 100},
+				&master_pb.VolumeInformationMessage{Id: 4, Size: 400, ReadOnly: true})),
+	}
+	until := &TopologySnapshot{
+		CreatedAt: time.Unix(2000, 0),
+		topologyInfo: testTopology(
+			testDataNode("server1:8080", 8,
+				&master_pb.VolumeInformationMessage{Id: 1, Size: 100},
+				&master_pb.VolumeInformationMessage{Id: 2, Size: 200, ReadOnly: true},
+				&master_pb.VolumeInformationMessage{Id: 5, Size: 10}),
+			testDataNode("server3:8080", 16,
+				&master_pb.VolumeInformationMessage{Id: 1, Size: 100},
+				&master_pb.VolumeInformationMessage{Id: 4, Size: 400})),
+	}
+
+	diff := diffTopology(since, until, 1024)
+
+	assert.Equal(t, []*TopologyServer{{Id: "server3:8080", DataCenter: "dc1", Rack: "rack1"}}, diff.ServersAppeared)
+	assert.Equal(t, []*TopologyServer{{Id: "server2:8080", DataCenter: "dc1", Rack: "rack1"}}, diff.ServersDisappeared)
+	assert.Equal(t, []*TopologyVolumeChange{{VolumeId: 5, To: []string{"server1:8080"}}}, diff.VolumesAdded)
+	assert.Equal(t, []*TopologyVolumeChange{{VolumeId: 3, Collection: "c", From: []string{"server1:8080"}}}, diff.VolumesRemoved)
+	assert.Equal(t, []*TopologyVolumeChange{
+		{VolumeId: 1, From: []string{"server1:8080", "server2:8080"}, To: []string{"server1:8080", "server3:8080"}},
+		{VolumeId: 4, From: []string{"server2:8080"}, To: []string{"server3:8080"}},
+	}, diff.VolumesMoved)
+	assert.Equal(t, []uint32{2}, diff.VolumesReadOnly)
+	assert.Equal(t, []uint32{4}, diff.VolumesWritable)
+
+	// server1 keeps 3 volumes, and its size changed by less than 1024 bytes
+	if assert.Len(t, diff.Capacity, 2) {
+		assert.Equal(t, "server2:8080", diff.Capacity[0].Server)
+		assert.Equal(t, "hdd", diff.Capacity[0].DiskType)
+		assert.Equal(t, TopologyCapacity{Volumes: 2, MaxVolumes: 8, Size: 500}, diff.Capacity[0].Before)
+		assert.Equal(t, TopologyCapacity{}, diff.Capacity[0].After)
+		assert.Equal(t, "server3:8080", diff.Capacity[1].Server)
+		assert.Equal(t, TopologyCapacity{Volumes: 2, MaxVolumes: 16, Size: 500}, diff.Capacity[1].After)
+	}
+	assert.Equal(t, TopologyCapacity{Volumes: 5, MaxVolumes: 16, Size: 1100}, diff.Total.Before)
+	assert.Equal(t, TopologyCapacity{Volumes: 5, MaxVolumes: 24, Size: 810}, diff.Total.After)
+
+	// the size changes over the threshold are reported
+	diff = diffTopology(since, until, 100)
+	assert.Len(t, diff.Capacity, 3)
+}
+
+func TestDiffTopologyEcShards(t *testing.T) {
+	ecNode := func(id string, shardBits uint32) *master_pb.DataNodeInfo {
+		return &master_pb.DataNodeInfo{
+			Id: id,
+			DiskInfos: map[string]*master_pb.DiskInfo{"": {
+				EcShardInfos: []*master_pb.VolumeEcShardInformationMessage{{Id: 7, Collection: "c", EcIndexBits: shardBits}},
+			}},
+		}
+	}
+	since := &TopologySnapshot{topologyInfo: testTopology(ecNode("server1:8080", 0b0011), ecNode("server2:8080", 0b1100))}
+	until := &TopologySnapshot{topologyInfo: testTopology(ecNode("server1:8080", 0b0111), ecNode("server2:8080", 0b1000))}
+
+	diff := diffTopology(since, until, 1024)
+
+	assert.Equal(t, []*TopologyVolumeChange{{
+		VolumeId:   7,
+		Collection: "c",
+		Ec:         true,
+		From:       []string{"server1:8080[0 1]", "server2:8080[2 3]"},
+		To:         []string{"server1:8080[0 1 2]", "server2:8080[3]"},
+	}}, diff.VolumesMoved)
+	assert.Len(t, diff.Capacity, 2)
+	assert.Equal(t, int64(4), diff.Total.After.EcShards)
+
+	diff = diffTopology(until, until, 0)
+	assert.True(t, diff.isEmpty())
+}
+
+func TestTopologySnapshotFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "topology.json")
+	snapshot := &TopologySnapshot{
+		CreatedAt:         time.Unix(1000, 0).UTC(),
+		VolumeSizeLimitMb: 30000,
+		topologyInfo:      testTopology(testDataNode("server1:8080", 8, &master_pb.VolumeInformationMessage{Id: 1, Size: 100})),
+	}
+	assert.NoError(t, writeTopologySnapshot(fileName, snapshot))
+
+	read, err := readTopologySnapshot(fileName)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.CreatedAt, read.CreatedAt)
+	assert.Equal(t, uint64(30000), read.VolumeSizeLimitMb)
+	assert.True(t, diffTopology(snapshot, read, 0).isEmpty())
+
+	var output bytes.Buffer
+	printTopologyDiff(&output, diffTopology(snapshot, read, 0))
+	assert.Contains(t, output.String(), "no changes")
+}
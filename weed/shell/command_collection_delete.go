@@ -7,6 +7,7 @@ import (
 	"io"
 
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
 func init() {
@@ -23,7 +24,11 @@ func (c *commandCollectionDelete) Name() string {
 func (c *commandCollectionDelete) Help() string {
 	return `delete specified collection
 
-	collection.delete -collection <collection_name> -force
+	collection.delete -collection <collection_name>                                 # dry run, shows what will be deleted
+	collection.delete -collection <collection_name> -force -forceToken=<token>      # apply with the token from the dry run
+
+	The master only deletes an empty collection directly. A collection with data is deleted only with
+	the force token printed by a dry run within the last 10 minutes, and only if it has not got more volumes since.
 
 `
 }
@@ -33,6 +38,7 @@ func (c *commandCollectionDelete) Do(args []string, commandEnv *CommandEnv, writ
 	colDeleteCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
 	collectionName := colDeleteCommand.String("collection", "", "collection to delete. Use '_default_' for the empty-named collection.")
 	applyBalancing := colDeleteCommand.Bool("force", false, "apply the collection")
+	forceToken := colDeleteCommand.String("forceToken", "", "the force token from the dry run, required if the collection is not empty")
 	if err = colDeleteCommand.Parse(args); err != nil {
 		return nil
 	}
@@ -46,22 +52,28 @@ func (c *commandCollectionDelete) Do(args []string, commandEnv *CommandEnv, writ
 		return fmt.Errorf("empty collection name is not allowed")
 	}
 
+	displayName := *collectionName
 	if *collectionName == "_default_" {
 		*collectionName = ""
 	}
 
-	if !*applyBalancing {
-		fmt.Fprintf(writer, "collection '%s' will be deleted. Use -force to apply the change.\n", *collectionName)
-		return nil
-	}
-
 	err = commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
-		_, err = client.CollectionDelete(context.Background(), &master_pb.CollectionDeleteRequest{
-			Name: *collectionName,
+		resp, deleteErr := client.CollectionDelete(context.Background(), &master_pb.CollectionDeleteRequest{
+			Name:       *collectionName,
+			DryRun:     !*applyBalancing,
+			ForceToken: *forceToken,
 		})
-		return err
+		if deleteErr != nil {
+			return deleteErr
+		}
+		if !*applyBalancing {
+			fmt.Fprintf(writer, "collection '%s' will be deleted: %d volumes, %d ec volumes, %d files, %s on disk.\n",
+				*collectionName, resp.VolumeCount, resp.EcVolumeCount, resp.FileCount, util.BytesToHumanReadable(resp.Size))
+			fmt.Fprintf(writer, "Use -force to apply the change:\n  collection.delete -collection %s -force -forceToken=%s\n", displayName, resp.ForceToken)
+		}
+		return nil
 	})
-	if err != nil {
+	if err != nil || !*applyBalancing {
 		return
 	}
 
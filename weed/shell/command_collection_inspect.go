@@ -0,0 +1,233 @@
+package shell
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+)
+
+func init() {
+	Commands = append(Commands, &commandCollectionInspect{})
+}
+
+type commandCollectionInspect struct {
+}
+
+func (c *commandCollectionInspect) Name() string {
+	return "collection.inspect"
+}
+
+func (c *commandCollectionInspect) Help() string {
+	return `summarize placement, sizes, ttls and hot volumes of one collection
+
+	collection.inspect -collection <collection_name> [-nearFullPercent=90] [-top=10] [-json]
+
+	The summary includes
+		* replica placement conformity: under replicated, over replicated and misplaced volumes
+		* logical bytes (one replica, without deleted bytes) and physical bytes (all replicas)
+		* distribution of volume replicas per data center
+		* volumes by ttl
+		* volumes near the volume size limit
+		* read only volumes
+		* most recently modified volumes
+
+`
+}
+
+type CollectionInspection struct {
+	Collection        string                              `json:"collection"`
+	VolumeCount       int                                 `json:"volumeCount"`
+	ReplicaCount      int                                 `json:"replicaCount"`
+	FileCount         uint64                              `json:"fileCount"`
+	LogicalBytes      uint64                              `json:"logicalBytes"`
+	PhysicalBytes     uint64                              `json:"physicalBytes"`
+	DataCenters       map[string]*CollectionDataCenterUse `json:"dataCenters"`
+	Ttls              map[string]int                      `json:"ttls"`
+	UnderReplicated   []uint32                            `json:"underReplicated"`
+	OverReplicated    []uint32                            `json:"overReplicated"`
+	Misplaced         []uint32                            `json:"misplaced"`
+	ReadOnlyVolumes   []uint32                            `json:"readOnlyVolumes"`
+	NearFullVolumes   []*CollectionVolumeBrief            `json:"nearFullVolumes"`
+	HotVolumes        []*CollectionVolumeBrief            `json:"hotVolumes"`
+	VolumeSizeLimitMb uint64                              `json:"volumeSizeLimitMb"`
+}
+
+type CollectionDataCenterUse struct {
+	ReplicaCount  int    `json:"replicaCount"`
+	PhysicalBytes uint64 `json:"physicalBytes"`
+}
+
+type CollectionVolumeBrief struct {
+	Id               uint32  `json:"id"`
+	Size             uint64  `json:"size"`
+	FullPercent      float64 `json:"fullPercent"`
+	ModifiedAtSecond int64   `json:"modifiedAtSecond"`
+}
+
+func (c *commandCollectionInspect) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	colInspectCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	collectionName := colInspectCommand.String("collection", "", "collection to inspect. Use '_default_' for the empty-named collection.")
+	nearFullPercent := colInspectCommand.Float64("nearFullPercent", 90, "volumes over this percentage of the volume size limit are reported as near full")
+	topCount := colInspectCommand.Int("top", 10, "number of most recently modified volumes to report")
+	jsonOutput := colInspectCommand.Bool("json", false, "output in json format")
+	if err = colInspectCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	if *collectionName == "" {
+		return fmt.Errorf("empty collection name is not allowed")
+	}
+	if *collectionName == "_default_" {
+		*collectionName = ""
+	}
+
+	topologyInfo, volumeSizeLimitMb, err := collectTopologyInfo(commandEnv, 0)
+	if err != nil {
+		return err
+	}
+
+	inspection := inspectCollection(topologyInfo, *collectionName, volumeSizeLimitMb, *nearFullPercent, *topCount)
+
+	if *jsonOutput {
+		data, marshalErr := json.MarshalIndent(inspection, "", "  ")
+		if marshalErr != nil {
+			return marshalErr
+		}
+		fmt.Fprintln(writer, string(data))
+		return nil
+	}
+
+	inspection.writeTo(writer)
+	return nil
+}
+
+func inspectCollection(topologyInfo *master_pb.TopologyInfo, collection string, volumeSizeLimitMb uint64, nearFullPercent float64, topCount int) *CollectionInspection {
+	inspection := &CollectionInspection{
+		Collection:        collection,
+		DataCenters:       make(map[string]*CollectionDataCenterUse),
+		Ttls:              make(map[string]int),
+		VolumeSizeLimitMb: volumeSizeLimitMb,
+	}
+
+	volumeReplicas, _ := collectVolumeReplicaLocations(topologyInfo)
+
+	var volumeIds []uint32
+	for vid, replicas := range volumeReplicas {
+		if replicas[0].info.Collection != collection {
+			continue
+		}
+		volumeIds = append(volumeIds, vid)
+	}
+	sort.Slice(volumeIds, func(i, j int) bool {
+		return volumeIds[i] < volumeIds[j]
+	})
+
+	var briefs []*CollectionVolumeBrief
+	for _, vid := range volumeIds {
+		replicas := volumeReplicas[vid]
+		inspection.VolumeCount++
+
+		var largest *master_pb.VolumeInformationMessage
+		isReadOnly := false
+		for _, replica := range replicas {
+			inspection.ReplicaCount++
+			inspection.PhysicalBytes += replica.info.Size
+			dcUse, found := inspection.DataCenters[replica.location.dc]
+			if !found {
+				dcUse = &CollectionDataCenterUse{}
+				inspection.DataCenters[replica.location.dc] = dcUse
+			}
+			dcUse.ReplicaCount++
+			dcUse.PhysicalBytes += replica.info.Size
+			if largest == nil || replica.info.Size > largest.Size {
+				largest = replica.info
+			}
+			isReadOnly = isReadOnly || replica.info.ReadOnly
+		}
+
+		if largest.FileCount > largest.DeleteCount {
+			inspection.FileCount += largest.FileCount - largest.DeleteCount
+		}
+		if largest.Size > largest.DeletedByteCount {
+			inspection.LogicalBytes += largest.Size - largest.DeletedByteCount
+		}
+		inspection.Ttls[needle.LoadTTLFromUint32(largest.Ttl).String()]++
+		if isReadOnly {
+			inspection.ReadOnlyVolumes = append(inspection.ReadOnlyVolumes, vid)
+		}
+
+		replicaPlacement, _ := super_block.NewReplicaPlacementFromByte(byte(largest.ReplicaPlacement))
+		if replicaPlacement.GetCopyCount() > len(replicas) {
+			inspection.UnderReplicated = append(inspection.UnderReplicated, vid)
+		} else if replicaPlacement.GetCopyCount() < len(replicas) {
+			inspection.OverReplicated = append(inspection.OverReplicated, vid)
+		} else if isMisplaced(replicas, replicaPlacement) {
+			inspection.Misplaced = append(inspection.Misplaced, vid)
+		}
+
+		brief := &CollectionVolumeBrief{
+			Id:               vid,
+			Size:             largest.Size,
+			ModifiedAtSecond: largest.ModifiedAtSecond,
+		}
+		if volumeSizeLimitMb > 0 {
+			brief.FullPercent = float64(largest.Size) * 100 / float64(volumeSizeLimitMb*1024*1024)
+			if brief.FullPercent >= nearFullPercent {
+				inspection.NearFullVolumes = append(inspection.NearFullVolumes, brief)
+			}
+		}
+		briefs = append(briefs, brief)
+	}
+
+	sort.Slice(briefs, func(i, j int) bool {
+		return briefs[i].ModifiedAtSecond > briefs[j].ModifiedAtSecond
+	})
+	if len(briefs) > topCount {
+		briefs = briefs[:topCount]
+	}
+	inspection.HotVolumes = briefs
+
+	return inspection
+}
+
+func (inspection *CollectionInspection) writeTo(writer io.Writer) {
+	fmt.Fprintf(writer, "collection:\"%s\"\tvolumeCount:%d\treplicaCount:%d\tfileCount:%d\n", inspection.Collection, inspection.VolumeCount, inspection.ReplicaCount, inspection.FileCount)
+	fmt.Fprintf(writer, "logicalBytes:%d\tphysicalBytes:%d\n", inspection.LogicalBytes, inspection.PhysicalBytes)
+
+	fmt.Fprintf(writer, "placement:\tunderReplicated:%v\toverReplicated:%v\tmisplaced:%v\n", inspection.UnderReplicated, inspection.OverReplicated, inspection.Misplaced)
+
+	var dcs []string
+	for dc := range inspection.DataCenters {
+		dcs = append(dcs, dc)
+	}
+	sort.Strings(dcs)
+	for _, dc := range dcs {
+		dcUse := inspection.DataCenters[dc]
+		fmt.Fprintf(writer, "dataCenter:%s\treplicaCount:%d\tphysicalBytes:%d\n", dc, dcUse.ReplicaCount, dcUse.PhysicalBytes)
+	}
+
+	var ttls []string
+	for ttl := range inspection.Ttls {
+		ttls = append(ttls, ttl)
+	}
+	sort.Strings(ttls)
+	for _, ttl := range ttls {
+		fmt.Fprintf(writer, "ttl:%q\tvolumeCount:%d\n", ttl, inspection.Ttls[ttl])
+	}
+
+	fmt.Fprintf(writer, "readOnly volumes:%v\n", inspection.ReadOnlyVolumes)
+	for _, brief := range inspection.NearFullVolumes {
+		fmt.Fprintf(writer, "near full volume:%d\tsize:%d\tfull:%.1f%%\n", brief.Id, brief.Size, brief.FullPercent)
+	}
+	for _, brief := range inspection.HotVolumes {
+		fmt.Fprintf(writer, "hot volume:%d\tsize:%d\tmodified:%s\n", brief.Id, brief.Size, time.Unix(brief.ModifiedAtSecond, 0).Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,33 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspectCollection(t *testing.T) {
+	topo := parseOutput(topoData)
+
+	inspection := inspectCollection(topo, "collection0", 1024, 90, 3)
+
+	assert.Greater(t, inspection.VolumeCount, 0)
+	assert.GreaterOrEqual(t, inspection.ReplicaCount, inspection.VolumeCount)
+	assert.GreaterOrEqual(t, inspection.PhysicalBytes, inspection.LogicalBytes)
+	assert.Equal(t, 3, len(inspection.HotVolumes))
+	for i := 1; i < len(inspection.HotVolumes); i++ {
+		assert.GreaterOrEqual(t, inspection.HotVolumes[i-1].ModifiedAtSecond, inspection.HotVolumes[i].ModifiedAtSecond)
+	}
+	for _, brief := range inspection.NearFullVolumes {
+		assert.GreaterOrEqual(t, brief.FullPercent, float64(90))
+	}
+
+	replicaCount := 0
+	for _, dcUse := range inspection.DataCenters {
+		replicaCount += dcUse.ReplicaCount
+	}
+	assert.Equal(t, inspection.ReplicaCount, replicaCount)
+
+	empty := inspectCollection(topo, "no_such_collection", 1024, 90, 3)
+	assert.Equal(t, 0, empty.VolumeCount)
+}
@@ -28,6 +28,15 @@ func (c *commandEcBalance) Help() string {
 	return `balance all ec shards among all racks and volume servers
 
 	ec.balance [-c EACH_COLLECTION|<collection_name>] [-force] [-dataCenter <data_center>]
+	ec.balance -maxShardsPerRack=4 -maxShardsPerNode=2 [-force]
+
+	The shards of one volume are spread evenly over the racks, and over the volume servers of each rack.
+	-maxShardsPerRack and -maxShardsPerNode cap the shards of one volume in one rack or on one volume server
+	further, e.g. with 4 racks and -maxShardsPerRack=4, losing any one rack keeps 10 shards to recover the volume.
+
+	The placement violations, the volumes with more shards in one rack or on one volume server than the caps,
+	are printed before and after the balancing. Without a cap, the limit is the 4 parity shards,
+	since losing a rack or a volume server with more shards of a volume loses the volume.
 
 	Algorithm:
 
@@ -105,6 +114,8 @@ func (c *commandEcBalance) Do(args []string, commandEnv *CommandEnv, writer io.W
 	collection := balanceCommand.String("collection", "EACH_COLLECTION", "collection name, or \"EACH_COLLECTION\" for each collection")
 	dc := balanceCommand.String("dataCenter", "", "only apply the balancing for this dataCenter")
 	applyBalancing := balanceCommand.Bool("force", false, "apply the balancing plan")
+	maxShardsPerRack := balanceCommand.Int("maxShardsPerRack", 0, "the most shards of one volume in a rack, 0 to only spread them evenly")
+	maxShardsPerNode := balanceCommand.Int("maxShardsPerNode", 0, "the most shards of one volume on a volume server, 0 to only spread them evenly")
 	if err = balanceCommand.Parse(args); err != nil {
 		return nil
 	}
@@ -124,6 +135,8 @@ func (c *commandEcBalance) Do(args []string, commandEnv *CommandEnv, writer io.W
 	}
 
 	racks := collectRacks(allEcNodes)
+	limits := ecShardLimits{maxShardsPerRack: *maxShardsPerRack, maxShardsPerNode: *maxShardsPerNode}
+	printEcPlacementViolations(writer, "before balancing", collectEcPlacementViolations(allEcNodes, *collection, limits))
 
 	if *collection == "EACH_COLLECTION" {
 		collections, err := ListCollectionNames(commandEnv, false, true)
@@ -133,12 +146,12 @@ func (c *commandEcBalance) Do(args []string, commandEnv *CommandEnv, writer io.W
 		fmt.Printf("balanceEcVolumes collections %+v\n", len(collections))
 		for _, c := range collections {
 			fmt.Printf("balanceEcVolumes collection %+v\n", c)
-			if err = balanceEcVolumes(commandEnv, c, allEcNodes, racks, *applyBalancing); err != nil {
+			if err = balanceEcVolumes(commandEnv, c, allEcNodes, racks, limits, *applyBalancing); err != nil {
 				return err
 			}
 		}
 	} else {
-		if err = balanceEcVolumes(commandEnv, *collection, allEcNodes, racks, *applyBalancing); err != nil {
+		if err = balanceEcVolumes(commandEnv, *collection, allEcNodes, racks, limits, *applyBalancing); err != nil {
 			return err
 		}
 	}
@@ -147,9 +160,99 @@ func (c *commandEcBalance) Do(args []string, commandEnv *CommandEnv, writer io.W
 		return fmt.Errorf("balance ec racks: %v", err)
 	}
 
+	printEcPlacementViolations(writer, "after balancing", collectEcPlacementViolations(allEcNodes, *collection, limits))
+
 	return nil
 }
 
+// ecShardLimits caps the shards of one volume in a rack and on a volume server, 0 for no cap
+type ecShardLimits struct {
+	maxShardsPerRack int
+	maxShardsPerNode int
+}
+
+func (limits ecShardLimits) rackLimit(average int) int {
+	if limits.maxShardsPerRack > 0 && limits.maxShardsPerRack < average {
+		return limits.maxShardsPerRack
+	}
+	return average
+}
+
+func (limits ecShardLimits) nodeLimit(average int) int {
+	if limits.maxShardsPerNode > 0 && limits.maxShardsPerNode < average {
+		return limits.maxShardsPerNode
+	}
+	return average
+}
+
+// collectEcPlacementViolations lists the volumes with too many shards in one rack or on one volume server
+func collectEcPlacementViolations(allEcNodes []*EcNode, collection string, limits ecShardLimits) (violations []string) {
+	rackLimit, nodeLimit := limits.maxShardsPerRack, limits.maxShardsPerNode
+	if rackLimit <= 0 {
+		rackLimit = erasure_coding.ParityShardsCount
+	}
+	if nodeLimit <= 0 {
+		nodeLimit = erasure_coding.ParityShardsCount
+	}
+
+	rackShardCounts := make(map[needle.VolumeId]map[RackId]int)
+	nodeShardCounts := make(map[needle.VolumeId]map[string]int)
+	for _, ecNode := range allEcNodes {
+		diskInfo, found := ecNode.info.DiskInfos[string(types.HardDriveType)]
+		if !found {
+			continue
+		}
+		for _, shardInfo := range diskInfo.EcShardInfos {
+			if collection != "EACH_COLLECTION" && shardInfo.Collection != collection {
+				continue
+			}
+			vid := needle.VolumeId(shardInfo.Id)
+			count := erasure_coding.ShardBits(shardInfo.EcIndexBits).ShardIdCount()
+			if rackShardCounts[vid] == nil {
+				rackShardCounts[vid] = make(map[RackId]int)
+				nodeShardCounts[vid] = make(map[string]int)
+			}
+			rackShardCounts[vid][ecNode.rack] += count
+			nodeShardCounts[vid][ecNode.info.Id] += count
+		}
+	}
+
+	var vids []needle.VolumeId
+	for vid := range rackShardCounts {
+		vids = append(vids, vid)
+	}
+	slices.Sort(vids)
+	for _, vid := range vids {
+		var rackViolations, nodeViolations []string
+		for rack, count := range rackShardCounts[vid] {
+			if count > rackLimit {
+				rackViolations = append(rackViolations, fmt.Sprintf("ec volume %d has %d shards in rack %s, over %d", vid, count, rack, rackLimit))
+			}
+		}
+		for node, count := range nodeShardCounts[vid] {
+			if count > nodeLimit {
+				nodeViolations = append(nodeViolations, fmt.Sprintf("ec volume %d has %d shards on %s, over %d", vid, count, node, nodeLimit))
+			}
+		}
+		slices.Sort(rackViolations)
+		slices.Sort(nodeViolations)
+		violations = append(violations, rackViolations...)
+		violations = append(violations, nodeViolations...)
+	}
+	return
+}
+
+func printEcPlacementViolations(writer io.Writer, when string, violations []string) {
+	if len(violations) == 0 {
+		fmt.Fprintf(writer, "no ec shard placement violations %s\n", when)
+		return
+	}
+	fmt.Fprintf(writer, "%d ec shard placement violations %s:\n", len(violations), when)
+	for _, violation := range violations {
+		fmt.Fprintf(writer, "  %s\n", violation)
+	}
+}
+
 func collectRacks(allEcNodes []*EcNode) map[RackId]*EcRack {
 	// collect racks info
 	racks := make(map[RackId]*EcRack)
@@ -165,7 +268,7 @@ func collectRacks(allEcNodes []*EcNode) map[RackId]*EcRack {
 	return racks
 }
 
-func balanceEcVolumes(commandEnv *CommandEnv, collection string, allEcNodes []*EcNode, racks map[RackId]*EcRack, applyBalancing bool) error {
+func balanceEcVolumes(commandEnv *CommandEnv, collection string, allEcNodes []*EcNode, racks map[RackId]*EcRack, limits ecShardLimits, applyBalancing bool) error {
 
 	fmt.Printf("balanceEcVolumes %s\n", collection)
 
@@ -173,11 +276,11 @@ func balanceEcVolumes(commandEnv *CommandEnv, collection string, allEcNodes []*E
 		return fmt.Errorf("delete duplicated collection %s ec shards: %v", collection, err)
 	}
 
-	if err := balanceEcShardsAcrossRacks(commandEnv, allEcNodes, racks, collection, applyBalancing); err != nil {
+	if err := balanceEcShardsAcrossRacks(commandEnv, allEcNodes, racks, collection, limits, applyBalancing); err != nil {
 		return fmt.Errorf("balance across racks collection %s ec shards: %v", collection, err)
 	}
 
-	if err := balanceEcShardsWithinRacks(commandEnv, allEcNodes, racks, collection, applyBalancing); err != nil {
+	if err := balanceEcShardsWithinRacks(commandEnv, allEcNodes, racks, collection, limits, applyBalancing); err != nil {
 		return fmt.Errorf("balance within racks collection %s ec shards: %v", collection, err)
 	}
 
@@ -230,22 +333,22 @@ func doDeduplicateEcShards(commandEnv *CommandEnv, collection string, vid needle
 	return nil
 }
 
-func balanceEcShardsAcrossRacks(commandEnv *CommandEnv, allEcNodes []*EcNode, racks map[RackId]*EcRack, collection string, applyBalancing bool) error {
+func balanceEcShardsAcrossRacks(commandEnv *CommandEnv, allEcNodes []*EcNode, racks map[RackId]*EcRack, collection string, limits ecShardLimits, applyBalancing bool) error {
 	// collect vid => []ecNode, since previous steps can change the locations
 	vidLocations := collectVolumeIdToEcNodes(allEcNodes)
 	// spread the ec shards evenly
 	for vid, locations := range vidLocations {
-		if err := doBalanceEcShardsAcrossRacks(commandEnv, collection, vid, locations, racks, applyBalancing); err != nil {
+		if err := doBalanceEcShardsAcrossRacks(commandEnv, collection, vid, locations, racks, limits, applyBalancing); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func doBalanceEcShardsAcrossRacks(commandEnv *CommandEnv, collection string, vid needle.VolumeId, locations []*EcNode, racks map[RackId]*EcRack, applyBalancing bool) error {
+func doBalanceEcShardsAcrossRacks(commandEnv *CommandEnv, collection string, vid needle.VolumeId, locations []*EcNode, racks map[RackId]*EcRack, limits ecShardLimits, applyBalancing bool) error {
 
-	// calculate average number of shards an ec rack should have for one volume
-	averageShardsPerEcRack := ceilDivide(erasure_coding.TotalShardsCount, len(racks))
+	// calculate average number of shards an ec rack should have for one volume, capped by the rack limit
+	averageShardsPerEcRack := limits.rackLimit(ceilDivide(erasure_coding.TotalShardsCount, len(racks)))
 
 	// see the volume's shards are in how many racks, and how many in each rack
 	rackToShardCount := groupByCount(locations, func(ecNode *EcNode) (id string, count int) {
@@ -271,16 +374,23 @@ func doBalanceEcShardsAcrossRacks(commandEnv *CommandEnv, collection string, vid
 		rackId := pickOneRack(racks, rackToShardCount, averageShardsPerEcRack)
 		if rackId == "" {
 			fmt.Printf("ec shard %d.%d at %s can not find a destination rack\n", vid, shardId, ecNode.info.Id)
+			// the shard stays where it is
+			ecNode.addEcVolumeShards(vid, collection, []uint32{uint32(shardId)})
 			continue
 		}
 		var possibleDestinationEcNodes []*EcNode
 		for _, n := range racks[rackId].ecNodes {
 			possibleDestinationEcNodes = append(possibleDestinationEcNodes, n)
 		}
-		err := pickOneEcNodeAndMoveOneShard(commandEnv, averageShardsPerEcRack, ecNode, collection, vid, shardId, possibleDestinationEcNodes, applyBalancing)
+		moved, err := pickOneEcNodeAndMoveOneShard(commandEnv, limits.nodeLimit(averageShardsPerEcRack), ecNode, collection, vid, shardId, possibleDestinationEcNodes, applyBalancing)
 		if err != nil {
 			return err
 		}
+		if !moved {
+			fmt.Printf("ec shard %d.%d at %s can not find a destination in rack %s\n", vid, shardId, ecNode.info.Id, rackId)
+			ecNode.addEcVolumeShards(vid, collection, []uint32{uint32(shardId)})
+			continue
+		}
 		rackToShardCount[string(rackId)] += 1
 		rackToShardCount[string(ecNode.rack)] -= 1
 		racks[rackId].freeEcSlot -= 1
@@ -309,7 +419,7 @@ func pickOneRack(rackToEcNodes map[RackId]*EcRack, rackToShardCount map[string]i
 	return ""
 }
 
-func balanceEcShardsWithinRacks(commandEnv *CommandEnv, allEcNodes []*EcNode, racks map[RackId]*EcRack, collection string, applyBalancing bool) error {
+func balanceEcShardsWithinRacks(commandEnv *CommandEnv, allEcNodes []*EcNode, racks map[RackId]*EcRack, collection string, limits ecShardLimits, applyBalancing bool) error {
 	// collect vid => []ecNode, since previous steps can change the locations
 	vidLocations := collectVolumeIdToEcNodes(allEcNodes)
 
@@ -334,7 +444,7 @@ func balanceEcShardsWithinRacks(commandEnv *CommandEnv, allEcNodes []*EcNode, ra
 				}
 			}
 			sourceEcNodes := rackEcNodesWithVid[rackId]
-			averageShardsPerEcNode := ceilDivide(rackToShardCount[rackId], len(possibleDestinationEcNodes))
+			averageShardsPerEcNode := limits.nodeLimit(ceilDivide(rackToShardCount[rackId], len(possibleDestinationEcNodes)))
 			if err := doBalanceEcShardsWithinOneRack(commandEnv, averageShardsPerEcNode, collection, vid, sourceEcNodes, possibleDestinationEcNodes, applyBalancing); err != nil {
 				return err
 			}
@@ -358,8 +468,7 @@ func doBalanceEcShardsWithinOneRack(commandEnv *CommandEnv, averageShardsPerEcNo
 
 			fmt.Printf("%s has %d overlimit, moving ec shard %d.%d\n", ecNode.info.Id, overLimitCount, vid, shardId)
 
-			err := pickOneEcNodeAndMoveOneShard(commandEnv, averageShardsPerEcNode, ecNode, collection, vid, shardId, possibleDestinationEcNodes, applyBalancing)
-			if err != nil {
+			if _, err := pickOneEcNodeAndMoveOneShard(commandEnv, averageShardsPerEcNode, ecNode, collection, vid, shardId, possibleDestinationEcNodes, applyBalancing); err != nil {
 				return err
 			}
 
@@ -453,7 +562,7 @@ func doBalanceEcRack(commandEnv *CommandEnv, ecRack *EcRack, applyBalancing bool
 	return nil
 }
 
-func pickOneEcNodeAndMoveOneShard(commandEnv *CommandEnv, averageShardsPerEcNode int, existingLocation *EcNode, collection string, vid needle.VolumeId, shardId erasure_coding.ShardId, possibleDestinationEcNodes []*EcNode, applyBalancing bool) error {
+func pickOneEcNodeAndMoveOneShard(commandEnv *CommandEnv, averageShardsPerEcNode int, existingLocation *EcNode, collection string, vid needle.VolumeId, shardId erasure_coding.ShardId, possibleDestinationEcNodes []*EcNode, applyBalancing bool) (moved bool, err error) {
 
 	sortEcNodesByFreeslotsDescending(possibleDestinationEcNodes)
 
@@ -471,15 +580,15 @@ func pickOneEcNodeAndMoveOneShard(commandEnv *CommandEnv, averageShardsPerEcNode
 
 		fmt.Printf("%s moves ec shard %d.%d to %s\n", existingLocation.info.Id, vid, shardId, destEcNode.info.Id)
 
-		err := moveMountedShardToEcNode(commandEnv, existingLocation, collection, vid, shardId, destEcNode, applyBalancing)
+		err = moveMountedShardToEcNode(commandEnv, existingLocation, collection, vid, shardId, destEcNode, applyBalancing)
 		if err != nil {
-			return err
+			return false, err
 		}
 
-		return nil
+		return true, nil
 	}
 
-	return nil
+	return false, nil
 }
 
 func pickNEcShardsToMoveFrom(ecNodes []*EcNode, vid needle.VolumeId, n int) map[erasure_coding.ShardId]*EcNode {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
+	"strings"
 	"sync"
 	"time"
 
@@ -36,6 +37,7 @@ func (c *commandEcEncode) Help() string {
 
 	ec.encode [-collection=""] [-fullPercent=95 -quietFor=1h]
 	ec.encode [-collection=""] [-volumeId=<volume_id>]
+	ec.encode [-collection=""] [-fullPercent=95 -quietFor=1h] [-window=22:00-06:00] [-maxMBpsPerSource=100]
 
 	This command will:
 	1. freeze one volume
@@ -51,6 +53,12 @@ func (c *commandEcEncode) Help() string {
 	If you only have less than 4 volume servers, with erasure coding, at least you can afford to
 	have 4 corrupted shard files.
 
+	With -window, volumes are only encoded in the daily time window of the local time, so a run
+	outside of the window does nothing, and a run stops before the next volume when the window closes.
+	With -maxMBpsPerSource, the copying of the shards from the source volume server is limited
+	to this speed in total. Both help the periodic scripts in master.toml to encode the cold data
+	without slowing down the production traffic.
+
 `
 }
 
@@ -62,6 +70,8 @@ func (c *commandEcEncode) Do(args []string, commandEnv *CommandEnv, writer io.Wr
 	fullPercentage := encodeCommand.Float64("fullPercent", 95, "the volume reaches the percentage of max volume size")
 	quietPeriod := encodeCommand.Duration("quietFor", time.Hour, "select volumes without no writes for this period")
 	parallelCopy := encodeCommand.Bool("parallelCopy", true, "copy shards in parallel")
+	window := encodeCommand.String("window", "", "only encode volumes in this daily time window of the local time, e.g. 22:00-06:00")
+	maxMBpsPerSource := encodeCommand.Int("maxMBpsPerSource", 0, "limit the copying speed from each source volume server in mega bytes per second")
 	if err = encodeCommand.Parse(args); err != nil {
 		return nil
 	}
@@ -70,11 +80,21 @@ func (c *commandEcEncode) Do(args []string, commandEnv *CommandEnv, writer io.Wr
 		return
 	}
 
+	encodeWindow, err := parseTimeWindow(*window)
+	if err != nil {
+		return err
+	}
+	if !encodeWindow.contains(time.Now()) {
+		fmt.Fprintf(writer, "skip ec encode outside of the time window %s\n", *window)
+		return nil
+	}
+	bytesPerSecond := int64(*maxMBpsPerSource) * 1024 * 1024
+
 	vid := needle.VolumeId(*volumeId)
 
 	// volumeId is provided
 	if vid != 0 {
-		return doEcEncode(commandEnv, *collection, vid, *parallelCopy)
+		return doEcEncode(commandEnv, *collection, vid, *parallelCopy, bytesPerSecond)
 	}
 
 	// apply to all volumes in the collection
@@ -84,7 +104,11 @@ func (c *commandEcEncode) Do(args []string, commandEnv *CommandEnv, writer io.Wr
 	}
 	fmt.Printf("ec encode volumes: %v\n", volumeIds)
 	for _, vid := range volumeIds {
-		if err = doEcEncode(commandEnv, *collection, vid, *parallelCopy); err != nil {
+		if !encodeWindow.contains(time.Now()) {
+			fmt.Fprintf(writer, "stop ec encode at the end of the time window %s\n", *window)
+			break
+		}
+		if err = doEcEncode(commandEnv, *collection, vid, *parallelCopy, bytesPerSecond); err != nil {
 			return err
 		}
 	}
@@ -92,7 +116,7 @@ func (c *commandEcEncode) Do(args []string, commandEnv *CommandEnv, writer io.Wr
 	return nil
 }
 
-func doEcEncode(commandEnv *CommandEnv, collection string, vid needle.VolumeId, parallelCopy bool) (err error) {
+func doEcEncode(commandEnv *CommandEnv, collection string, vid needle.VolumeId, parallelCopy bool, bytesPerSecond int64) (err error) {
 	if !commandEnv.isLocked() {
 		return fmt.Errorf("lock is lost")
 	}
@@ -118,7 +142,7 @@ func doEcEncode(commandEnv *CommandEnv, collection string, vid needle.VolumeId,
 	}
 
 	// balance the ec shards to current cluster
-	err = spreadEcShards(commandEnv, vid, collection, locations, parallelCopy)
+	err = spreadEcShards(commandEnv, vid, collection, locations, parallelCopy, bytesPerSecond)
 	if err != nil {
 		return fmt.Errorf("spread ec shards for volume %d from %s: %v", vid, locations[0].Url, err)
 	}
@@ -142,7 +166,7 @@ func generateEcShards(grpcDialOption grpc.DialOption, volumeId needle.VolumeId,
 
 }
 
-func spreadEcShards(commandEnv *CommandEnv, volumeId needle.VolumeId, collection string, existingLocations []wdclient.Location, parallelCopy bool) (err error) {
+func spreadEcShards(commandEnv *CommandEnv, volumeId needle.VolumeId, collection string, existingLocations []wdclient.Location, parallelCopy bool, bytesPerSecond int64) (err error) {
 
 	allEcNodes, totalFreeEcSlots, err := collectEcNodes(commandEnv, "")
 	if err != nil {
@@ -161,7 +185,7 @@ func spreadEcShards(commandEnv *CommandEnv, volumeId needle.VolumeId, collection
 	allocatedEcIds := balancedEcDistribution(allocatedDataNodes)
 
 	// ask the data nodes to copy from the source volume server
-	copiedShardIds, err := parallelCopyEcShardsFromSource(commandEnv.option.GrpcDialOption, allocatedDataNodes, allocatedEcIds, volumeId, collection, existingLocations[0], parallelCopy)
+	copiedShardIds, err := parallelCopyEcShardsFromSource(commandEnv.option.GrpcDialOption, allocatedDataNodes, allocatedEcIds, volumeId, collection, existingLocations[0], parallelCopy, bytesPerSecond)
 	if err != nil {
 		return err
 	}
@@ -191,16 +215,30 @@ func spreadEcShards(commandEnv *CommandEnv, volumeId needle.VolumeId, collection
 
 }
 
-func parallelCopyEcShardsFromSource(grpcDialOption grpc.DialOption, targetServers []*EcNode, allocatedEcIds [][]uint32, volumeId needle.VolumeId, collection string, existingLocation wdclient.Location, parallelCopy bool) (actuallyCopied []uint32, err error) {
+func parallelCopyEcShardsFromSource(grpcDialOption grpc.DialOption, targetServers []*EcNode, allocatedEcIds [][]uint32, volumeId needle.VolumeId, collection string, existingLocation wdclient.Location, parallelCopy bool, bytesPerSecond int64) (actuallyCopied []uint32, err error) {
 
 	fmt.Printf("parallelCopyEcShardsFromSource %d %s\n", volumeId, existingLocation.Url)
 
+	// all copies read from the same source, so the parallel copies share its speed limit
+	copyBytesPerSecond := bytesPerSecond
+	if parallelCopy && bytesPerSecond > 0 {
+		copyCount := 0
+		for i, server := range targetServers {
+			if len(allocatedEcIds[i]) > 0 && rpc.NewServerAddressFromDataNode(server.info) != existingLocation.ServerAddress() {
+				copyCount++
+			}
+		}
+		if copyCount > 1 {
+			copyBytesPerSecond = bytesPerSecond / int64(copyCount)
+		}
+	}
+
 	var wg sync.WaitGroup
 	shardIdChan := make(chan []uint32, len(targetServers))
 	copyFunc := func(server *EcNode, allocatedEcShardIds []uint32) {
 		defer wg.Done()
 		copiedShardIds, copyErr := oneServerCopyAndMountEcShardsFromSource(grpcDialOption, server,
-			allocatedEcShardIds, volumeId, collection, existingLocation.ServerAddress())
+			allocatedEcShardIds, volumeId, collection, existingLocation.ServerAddress(), copyBytesPerSecond)
 		if copyErr != nil {
 			err = copyErr
 		} else {
@@ -300,3 +338,39 @@ func collectVolumeIdsForEcEncode(commandEnv *CommandEnv, selectedCollection stri
 
 	return
 }
+
+// timeWindow is a daily time window of the local time, which may wrap around midnight, e.g. 22:00-06:00
+type timeWindow struct {
+	start, end time.Duration
+}
+
+// parseTimeWindow parses "HH:MM-HH:MM", and returns nil for an empty string, meaning no time limit.
+func parseTimeWindow(window string) (*timeWindow, error) {
+	if window == "" {
+		return nil, nil
+	}
+	parts := strings.Split(window, "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("time window %s should be like 22:00-06:00", window)
+	}
+	tw := &timeWindow{}
+	for i, t := range []*time.Duration{&tw.start, &tw.end} {
+		clock, err := time.Parse("15:04", strings.TrimSpace(parts[i]))
+		if err != nil {
+			return nil, fmt.Errorf("time window %s should be like 22:00-06:00: %v", window, err)
+		}
+		*t = time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute
+	}
+	return tw, nil
+}
+
+func (tw *timeWindow) contains(t time.Time) bool {
+	if tw == nil || tw.start == tw.end {
+		return true
+	}
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if tw.start < tw.end {
+		return tw.start <= clock && clock < tw.end
+	}
+	return tw.start <= clock || clock < tw.end
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
 	"testing"
+	"time"
 )
 
 func TestEcDistribution(t *testing.T) {
@@ -29,3 +30,36 @@ func TestEcDistribution(t *testing.T) {
 	}
 
 }
+
+func TestTimeWindow(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2022, 1, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	tw, err := parseTimeWindow("")
+	if err != nil || !tw.contains(at(12, 0)) {
+		t.Errorf("empty window should always contain: %v", err)
+	}
+
+	tw, err = parseTimeWindow("01:30-05:00")
+	if err != nil {
+		t.Fatalf("parse window: %v", err)
+	}
+	if !tw.contains(at(1, 30)) || !tw.contains(at(4, 59)) || tw.contains(at(5, 0)) || tw.contains(at(1, 29)) {
+		t.Errorf("unexpected window %+v", tw)
+	}
+
+	tw, err = parseTimeWindow("22:00-06:00")
+	if err != nil {
+		t.Fatalf("parse window: %v", err)
+	}
+	if !tw.contains(at(23, 0)) || !tw.contains(at(0, 0)) || !tw.contains(at(5, 59)) || tw.contains(at(6, 0)) || tw.contains(at(12, 0)) {
+		t.Errorf("unexpected wrapped window %+v", tw)
+	}
+
+	for _, bad := range []string{"22:00", "22:00-25:00", "night-day"} {
+		if _, err = parseTimeWindow(bad); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
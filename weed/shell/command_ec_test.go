@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 )
@@ -28,7 +30,7 @@ func TestCommandEcBalanceSmall(t *testing.T) {
 	}
 
 	racks := collectRacks(allEcNodes)
-	balanceEcVolumes(nil, "c1", allEcNodes, racks, false)
+	balanceEcVolumes(nil, "c1", allEcNodes, racks, ecShardLimits{}, false)
 }
 
 func TestCommandEcBalanceNothingToMove(t *testing.T) {
@@ -43,7 +45,7 @@ func TestCommandEcBalanceNothingToMove(t *testing.T) {
 	}
 
 	racks := collectRacks(allEcNodes)
-	balanceEcVolumes(nil, "c1", allEcNodes, racks, false)
+	balanceEcVolumes(nil, "c1", allEcNodes, racks, ecShardLimits{}, false)
 }
 
 func TestCommandEcBalanceAddNewServers(t *testing.T) {
@@ -60,7 +62,7 @@ func TestCommandEcBalanceAddNewServers(t *testing.T) {
 	}
 
 	racks := collectRacks(allEcNodes)
-	balanceEcVolumes(nil, "c1", allEcNodes, racks, false)
+	balanceEcVolumes(nil, "c1", allEcNodes, racks, ecShardLimits{}, false)
 }
 
 func TestCommandEcBalanceAddNewRacks(t *testing.T) {
@@ -77,7 +79,7 @@ func TestCommandEcBalanceAddNewRacks(t *testing.T) {
 	}
 
 	racks := collectRacks(allEcNodes)
-	balanceEcVolumes(nil, "c1", allEcNodes, racks, false)
+	balanceEcVolumes(nil, "c1", allEcNodes, racks, ecShardLimits{}, false)
 }
 
 func TestCommandEcBalanceVolumeEvenButRackUneven(t *testing.T) {
@@ -119,7 +121,7 @@ func TestCommandEcBalanceVolumeEvenButRackUneven(t *testing.T) {
 	}
 
 	racks := collectRacks(allEcNodes)
-	balanceEcVolumes(nil, "c1", allEcNodes, racks, false)
+	balanceEcVolumes(nil, "c1", allEcNodes, racks, ecShardLimits{}, false)
 	balanceEcRacks(nil, racks, false)
 }
 
@@ -138,3 +140,33 @@ func newEcNode(dc string, rack string, dataNodeId string, freeEcSlot int) *EcNod
 func (ecNode *EcNode) addEcVolumeAndShardsForTest(vid uint32, collection string, shardIds []uint32) *EcNode {
 	return ecNode.addEcVolumeShards(needle.VolumeId(vid), collection, shardIds)
 }
+
+func TestCommandEcBalanceShardLimits(t *testing.T) {
+
+	allEcNodes := []*EcNode{
+		newEcNode("dc1", "rack1", "dn1", 100).addEcVolumeAndShardsForTest(1, "c1", []uint32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13}),
+		newEcNode("dc1", "rack2", "dn2", 100),
+		newEcNode("dc1", "rack2", "dn3", 100),
+	}
+	limits := ecShardLimits{maxShardsPerNode: 4}
+
+	assert.Equal(t, []string{
+		"ec volume 1 has 14 shards in rack rack1, over 4",
+		"ec volume 1 has 14 shards on dn1, over 4",
+	}, collectEcPlacementViolations(allEcNodes, "c1", limits))
+
+	racks := collectRacks(allEcNodes)
+	balanceEcVolumes(nil, "c1", allEcNodes, racks, limits, false)
+
+	// half of the shards move to the other rack, at most 4 on each volume server
+	assert.Equal(t, 7, findEcVolumeShards(allEcNodes[0], 1).ShardIdCount())
+	dn2Count, dn3Count := findEcVolumeShards(allEcNodes[1], 1).ShardIdCount(), findEcVolumeShards(allEcNodes[2], 1).ShardIdCount()
+	assert.Equal(t, 7, dn2Count+dn3Count)
+	assert.LessOrEqual(t, dn2Count, 4)
+	assert.LessOrEqual(t, dn3Count, 4)
+	assert.Equal(t, []string{
+		"ec volume 1 has 7 shards in rack rack1, over 4",
+		"ec volume 1 has 7 shards in rack rack2, over 4",
+		"ec volume 1 has 7 shards on dn1, over 4",
+	}, collectEcPlacementViolations(allEcNodes, "c1", limits))
+}
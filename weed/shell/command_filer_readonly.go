@@ -0,0 +1,94 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandFilerReadOnly{})
+}
+
+type commandFilerReadOnly struct {
+}
+
+func (c *commandFilerReadOnly) Name() string {
+	return "filer.readonly"
+}
+
+func (c *commandFilerReadOnly) Help() string {
+	return `show or change the read only mode of the filers at runtime
+
+	filer.readonly                                   # show the mode of all filers
+	filer.readonly -enable                           # reject the changes on all filers, e.g. for a maintenance window
+	filer.readonly -disable                          # accept the changes again
+	filer.readonly -filer=<host>:<port> -enable      # only change one filer
+
+	Changing the mode needs jwt.master_admin.key in security.toml, the same on the filers and the shell.
+
+	A read only filer keeps serving the reads, and rejects the http changes with 503
+	and the grpc changes with FailedPrecondition. The mode is not persisted, a restarted
+	filer is read only again only with -readOnly.
+
+`
+}
+
+func (c *commandFilerReadOnly) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	readOnlyCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	enable := readOnlyCommand.Bool("enable", false, "switch the filers to read only")
+	disable := readOnlyCommand.Bool("disable", false, "switch the filers back to writable")
+	filer := readOnlyCommand.String("filer", "", "only the filer <host>:<port>")
+	if err = readOnlyCommand.Parse(args); err != nil {
+		return nil
+	}
+	if *enable && *disable {
+		return fmt.Errorf("-enable and -disable are exclusive")
+	}
+
+	var nodes []logLevelNode
+	if *filer != "" {
+		nodes = append(nodes, logLevelNode{cluster.FilerType, rpc.ServerAddress(*filer)})
+	} else if nodes, err = collectLogLevelNodes(commandEnv, cluster.FilerType); err != nil {
+		return err
+	}
+
+	request := &filer_pb.SetReadOnlyRequest{
+		ReadOnly: *enable,
+		GetOnly:  !*enable && !*disable,
+	}
+	ctx := security.WithMasterAdminJwt(context.Background(), security.SigningKey(util.GetViper().GetString("jwt.master_admin.key")))
+	var failed int
+	for _, node := range nodes {
+		var readOnly bool
+		setErr := rpc.WithFilerClient(false, node.address, commandEnv.option.GrpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+			resp, err := client.SetReadOnly(ctx, request)
+			if err == nil {
+				readOnly = resp.ReadOnly
+			}
+			return err
+		})
+		if setErr != nil {
+			fmt.Fprintf(writer, "filer %s: %v\n", node.address, setErr)
+			failed++
+			continue
+		}
+		mode := "writable"
+		if readOnly {
+			mode = "read only"
+		}
+		fmt.Fprintf(writer, "filer %s: %s\n", node.address, mode)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed on %d of %d filers", failed, len(nodes))
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package shell
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/replication"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+func init() {
+	Commands = append(Commands, &commandFilerSyncOffsetDelete{})
+}
+
+type commandFilerSyncOffsetDelete struct {
+}
+
+func (c *commandFilerSyncOffsetDelete) Name() string {
+	return "filer.sync.offset.delete"
+}
+
+func (c *commandFilerSyncOffsetDelete) Help() string {
+	return `delete offsets of filer.sync that are no longer used
+
+	filer.sync.offset.delete -sourceFiler=<host>:<port> -path=/buckets    # delete one offset
+	filer.sync.offset.delete -signature=<signature>                        # the source filer is gone
+	filer.sync.offset.delete -olderThan=720h                               # delete the listed offsets not updated for 30 days
+
+	The offsets are kept on the target filer, which is the filer of weed shell unless -filer is given.
+	Without an offset, the next filer.sync from the source starts from the current time, unless
+	its -a.fromTsMs or -b.fromTsMs option is set.
+
+`
+}
+
+func (c *commandFilerSyncOffsetDelete) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	deleteCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	targetFiler := deleteCommand.String("filer", "", "the target filer of filer.sync, default to the filer of weed shell")
+	sourceFiler := deleteCommand.String("sourceFiler", "", "the source filer of filer.sync")
+	signature := deleteCommand.Int("signature", 0, "the signature of the source filer, instead of -sourceFiler")
+	sourcePath := deleteCommand.String("path", "/", "the source path of filer.sync")
+	olderThan := deleteCommand.Duration("olderThan", 0, "delete all listed offsets older than this duration, instead of one offset")
+	if err = deleteCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	if *olderThan > 0 {
+		return withSyncTargetFiler(commandEnv, *targetFiler, func(client filer_pb.SeaweedFilerClient) error {
+			return deleteStaleSyncOffsets(client, time.Now().Add(-*olderThan), writer)
+		})
+	}
+
+	sourceSignature, err := resolveSyncSourceSignature(commandEnv, *sourceFiler, *signature)
+	if err != nil {
+		return err
+	}
+	return withSyncTargetFiler(commandEnv, *targetFiler, func(client filer_pb.SeaweedFilerClient) error {
+		if err := deleteSyncOffset(client, sourceSignature, *sourcePath); err != nil {
+			return err
+		}
+		fmt.Fprintf(writer, "deleted sync offset of signature %d path %s\n", sourceSignature, *sourcePath)
+		return nil
+	})
+}
+
+func deleteStaleSyncOffsets(client filer_pb.SeaweedFilerClient, cutoff time.Time, writer io.Writer) error {
+	offsets, err := replication.ListSyncOffsets(client)
+	if err != nil {
+		return fmt.Errorf("list sync offsets: %v", err)
+	}
+	for _, offset := range offsets {
+		offsetTsNs, err := replication.GetSyncOffset(client, replication.SyncOffsetKey(replication.SyncSignaturePrefixByPath(offset.SourcePath), offset.SourceSignature))
+		if err != nil {
+			return fmt.Errorf("read sync offset of %s: %v", offset.SourceFiler, err)
+		}
+		if offsetTsNs >= cutoff.UnixNano() {
+			continue
+		}
+		if err = deleteSyncOffset(client, offset.SourceSignature, offset.SourcePath); err != nil {
+			return err
+		}
+		fmt.Fprintf(writer, "deleted sync offset of %s (signature %d) path %s, last synced %s\n",
+			offset.SourceFiler, offset.SourceSignature, offset.SourcePath, time.Unix(0, offsetTsNs).Format(time.RFC3339))
+	}
+	return nil
+}
+
+func deleteSyncOffset(client filer_pb.SeaweedFilerClient, signature int32, sourcePath string) error {
+	syncKey := replication.SyncOffsetKey(replication.SyncSignaturePrefixByPath(sourcePath), signature)
+	if err := replication.DeleteSyncOffset(client, syncKey); err != nil {
+		return fmt.Errorf("delete sync offset: %v", err)
+	}
+	if err := replication.UnregisterSyncOffset(client, signature, sourcePath); err != nil {
+		return fmt.Errorf("unregister sync offset: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/replication"
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+func init() {
+	Commands = append(Commands, &commandFilerSyncOffsetList{})
+}
+
+type commandFilerSyncOffsetList struct {
+}
+
+func (c *commandFilerSyncOffsetList) Name() string {
+	return "filer.sync.offset.list"
+}
+
+func (c *commandFilerSyncOffsetList) Help() string {
+	return `list the progress of filer.sync into a target filer
+
+	filer.sync.offset.list                          # the offsets kept on the filer of weed shell
+	filer.sync.offset.list -filer=<host>:<port>     # the offsets kept on another target filer
+
+	filer.sync keeps, on the target filer, the time of the last metadata change it has synced from
+	each source filer and path, and resumes from there. Each offset is shown with its lag.
+	Offsets written by filer.sync before it registered its offsets are not listed, but can still be
+	changed with filer.sync.offset.set and filer.sync.offset.delete.
+
+`
+}
+
+func (c *commandFilerSyncOffsetList) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	listCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	targetFiler := listCommand.String("filer", "", "the target filer of filer.sync, default to the filer of weed shell")
+	if err = listCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	return withSyncTargetFiler(commandEnv, *targetFiler, func(client filer_pb.SeaweedFilerClient) error {
+		offsets, err := replication.ListSyncOffsets(client)
+		if err != nil {
+			return fmt.Errorf("list sync offsets: %v", err)
+		}
+		if len(offsets) == 0 {
+			fmt.Fprintf(writer, "no sync offsets\n")
+			return nil
+		}
+		now := time.Now()
+		for _, offset := range offsets {
+			offsetTsNs, err := replication.GetSyncOffset(client, replication.SyncOffsetKey(replication.SyncSignaturePrefixByPath(offset.SourcePath), offset.SourceSignature))
+			if err != nil {
+				return fmt.Errorf("read sync offset of %s: %v", offset.SourceFiler, err)
+			}
+			writeSyncOffset(writer, offset, offsetTsNs, now)
+		}
+		return nil
+	})
+}
+
+func writeSyncOffset(writer io.Writer, offset *filer_pb.FilerSyncOffset, offsetTsNs int64, now time.Time) {
+	fmt.Fprintf(writer, "%s (signature %d) %s => %s\n", offset.SourceFiler, offset.SourceSignature, offset.SourcePath, offset.TargetPath)
+	if offsetTsNs == 0 {
+		fmt.Fprintf(writer, "    offset: none\n")
+	} else {
+		offsetTime := time.Unix(0, offsetTsNs)
+		fmt.Fprintf(writer, "    offset: %s (%d), lag %v\n", offsetTime.Format(time.RFC3339), offsetTsNs, now.Sub(offsetTime).Truncate(time.Second))
+	}
+	fmt.Fprintf(writer, "    registered: %s\n", time.Unix(0, offset.RegisteredAtNs).Format(time.RFC3339))
+}
+
+func withSyncTargetFiler(commandEnv *CommandEnv, targetFiler string, fn func(client filer_pb.SeaweedFilerClient) error) error {
+	if targetFiler == "" {
+		return commandEnv.WithFilerClient(false, fn)
+	}
+	return rpc.WithFilerClient(false, rpc.ServerAddress(targetFiler), commandEnv.option.GrpcDialOption, fn)
+}
+
+// resolveSyncSourceSignature uses the signature if given, or asks the source filer for its signature.
+func resolveSyncSourceSignature(commandEnv *CommandEnv, sourceFiler string, signature int) (int32, error) {
+	if signature != 0 {
+		return int32(signature), nil
+	}
+	if sourceFiler == "" {
+		return 0, fmt.Errorf("specify the source filer by -sourceFiler or -signature")
+	}
+	var sourceSignature int32
+	err := rpc.WithFilerClient(false, rpc.ServerAddress(sourceFiler), commandEnv.option.GrpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.GetFilerConfiguration(context.Background(), &filer_pb.GetFilerConfigurationRequest{})
+		if err != nil {
+			return err
+		}
+		sourceSignature = resp.Signature
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("read signature of source filer %s: %v", sourceFiler, err)
+	}
+	return sourceSignature, nil
+}
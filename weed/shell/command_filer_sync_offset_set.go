@@ -0,0 +1,89 @@
+package shell
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/replication"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+func init() {
+	Commands = append(Commands, &commandFilerSyncOffsetSet{})
+}
+
+type commandFilerSyncOffsetSet struct {
+}
+
+func (c *commandFilerSyncOffsetSet) Name() string {
+	return "filer.sync.offset.set"
+}
+
+func (c *commandFilerSyncOffsetSet) Help() string {
+	return `move the offset of filer.sync to a point in time
+
+	filer.sync.offset.set -sourceFiler=<host>:<port> -timeAgo=2h              # sync again the changes of the last 2 hours
+	filer.sync.offset.set -signature=<signature> -path=/buckets -timestamp=2022-10-01T00:00:00Z
+	filer.sync.offset.set -filer=<target host>:<port> -sourceFiler=<host>:<port> -timeAgo=0s   # skip all pending changes
+
+	The offset is kept on the target filer, which is the filer of weed shell unless -filer is given.
+	The source is the source filer, or its signature shown by filer.sync.offset.list when the source
+	filer is gone. The path is the source path of filer.sync.
+
+	Stop the filer.sync process first. A running filer.sync overwrites the offset as it makes progress.
+
+`
+}
+
+func (c *commandFilerSyncOffsetSet) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	setCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	targetFiler := setCommand.String("filer", "", "the target filer of filer.sync, default to the filer of weed shell")
+	sourceFiler := setCommand.String("sourceFiler", "", "the source filer of filer.sync")
+	signature := setCommand.Int("signature", 0, "the signature of the source filer, instead of -sourceFiler")
+	sourcePath := setCommand.String("path", "/", "the source path of filer.sync")
+	timestamp := setCommand.String("timestamp", "", "the new offset in RFC3339 format, e.g. 2022-10-01T00:00:00Z")
+	timeAgo := setCommand.Duration("timeAgo", -1, "the new offset as a duration before now, e.g. 2h")
+	if err = setCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	var offsetTime time.Time
+	switch {
+	case *timestamp != "" && *timeAgo >= 0:
+		return fmt.Errorf("use either -timestamp or -timeAgo")
+	case *timestamp != "":
+		if offsetTime, err = time.Parse(time.RFC3339, *timestamp); err != nil {
+			return fmt.Errorf("parse timestamp %s: %v", *timestamp, err)
+		}
+	case *timeAgo >= 0:
+		offsetTime = time.Now().Add(-*timeAgo)
+	default:
+		return fmt.Errorf("specify the new offset by -timestamp or -timeAgo")
+	}
+
+	sourceSignature, err := resolveSyncSourceSignature(commandEnv, *sourceFiler, *signature)
+	if err != nil {
+		return err
+	}
+
+	return withSyncTargetFiler(commandEnv, *targetFiler, func(client filer_pb.SeaweedFilerClient) error {
+		syncKey := replication.SyncOffsetKey(replication.SyncSignaturePrefixByPath(*sourcePath), sourceSignature)
+		previousTsNs, err := replication.GetSyncOffset(client, syncKey)
+		if err != nil {
+			return fmt.Errorf("read sync offset: %v", err)
+		}
+		if err = replication.SetSyncOffset(client, syncKey, offsetTime.UnixNano()); err != nil {
+			return fmt.Errorf("set sync offset: %v", err)
+		}
+		if previousTsNs == 0 {
+			fmt.Fprintf(writer, "sync offset of signature %d path %s set to %s\n", sourceSignature, *sourcePath, offsetTime.Format(time.RFC3339))
+		} else {
+			fmt.Fprintf(writer, "sync offset of signature %d path %s moved from %s to %s\n", sourceSignature, *sourcePath,
+				time.Unix(0, previousTsNs).Format(time.RFC3339), offsetTime.Format(time.RFC3339))
+		}
+		return nil
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"mime"
 	"strings"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
@@ -33,6 +34,18 @@ func (c *commandFsConfigure) Help() string {
 	fs.configure -locationPrefix=/my/folder -collection=abc
 	fs.configure -locationPrefix=/my/folder -collection=abc -ttl=7d
 
+	# example: store identical chunks only once for backup workloads
+	fs.configure -locationPrefix=/backup/ -dedup
+
+	# example: share a folder with clients of different user databases, mapping client uid 1000-1999 to filer uid 101000-101999
+	fs.configure -locationPrefix=/shared/ -uidMap=1000:101000:1000 -gidMap=1000:101000:1000 -squashRoot
+
+	# example: accept up to 10MB images only, with simple file names
+	fs.configure -locationPrefix=/buckets/images/ -maxFileSizeMB=10 -allowedMimeTypes=image/* -fileNamePattern='^[\w.-]+$'
+
+	# example: return the writes only after 1 other filer in the filer group has applied them
+	fs.configure -locationPrefix=/critical/ -filerAckCount=1
+
 	# example: configure adding only 1 physical volume for each bucket collection
 	fs.configure -locationPrefix=/buckets/ -volumeGrowthCount=1
 
@@ -58,6 +71,15 @@ func (c *commandFsConfigure) Do(args []string, commandEnv *CommandEnv, writer io
 	dataCenter := fsConfigureCommand.String("dataCenter", "", "assign writes to this dataCenter")
 	rack := fsConfigureCommand.String("rack", "", "assign writes to this rack")
 	dataNode := fsConfigureCommand.String("dataNode", "", "assign writes to this dataNode")
+	dedup := fsConfigureCommand.Bool("dedup", false, "reuse existing chunks with the same content in the collection")
+	uidMap := fsConfigureCommand.String("uidMap", "", "comma separated clientStart:filerStart:count ranges to translate the client uid")
+	gidMap := fsConfigureCommand.String("gidMap", "", "comma separated clientStart:filerStart:count ranges to translate the client gid")
+	squashRoot := fsConfigureCommand.Bool("squashRoot", false, "save the writes of the client root user as nobody")
+	maxFileSizeMB := fsConfigureCommand.Int64("maxFileSizeMB", 0, "reject the files larger than this size in MB")
+	allowedMimeTypes := fsConfigureCommand.String("allowedMimeTypes", "", "comma separated mime types to accept, e.g. image/png,image/*")
+	blockedMimeTypes := fsConfigureCommand.String("blockedMimeTypes", "", "comma separated mime types to reject, e.g. application/x-msdownload")
+	fileNamePattern := fsConfigureCommand.String("fileNamePattern", "", "regular expression the file names must match")
+	filerAckCount := fsConfigureCommand.Int("filerAckCount", 0, "wait until this number of other filers have applied the metadata changes before returning the writes")
 	volumeGrowthCount := fsConfigureCommand.Int("volumeGrowthCount", 0, "the number of physical volumes to add if no writable volumes")
 	isDelete := fsConfigureCommand.Bool("delete", false, "delete the configuration by locationPrefix")
 	apply := fsConfigureCommand.Bool("apply", false, "update and apply filer configuration")
@@ -72,6 +94,14 @@ func (c *commandFsConfigure) Do(args []string, commandEnv *CommandEnv, writer io
 
 	if *locationPrefix != "" {
 		infoAboutSimulationMode(writer, *apply, "-apply")
+		allowedMimeTypeList, err := splitMimeTypes(*allowedMimeTypes)
+		if err != nil {
+			return fmt.Errorf("allowedMimeTypes: %v", err)
+		}
+		blockedMimeTypeList, err := splitMimeTypes(*blockedMimeTypes)
+		if err != nil {
+			return fmt.Errorf("blockedMimeTypes: %v", err)
+		}
 		locConf := &filer_pb.FilerConf_PathConf{
 			LocationPrefix:    *locationPrefix,
 			Collection:        *collection,
@@ -84,6 +114,25 @@ func (c *commandFsConfigure) Do(args []string, commandEnv *CommandEnv, writer io
 			DataCenter:        *dataCenter,
 			Rack:              *rack,
 			DataNode:          *dataNode,
+			Dedup:             *dedup,
+			UidMap:            splitIdRanges(*uidMap),
+			GidMap:            splitIdRanges(*gidMap),
+			SquashRoot:        *squashRoot,
+			MaxFileSize:       *maxFileSizeMB * 1024 * 1024,
+			AllowedMimeTypes:  allowedMimeTypeList,
+			BlockedMimeTypes:  blockedMimeTypeList,
+			FileNamePattern:   *fileNamePattern,
+			FilerAckCount:     uint32(*filerAckCount),
+		}
+
+		// check id mapping
+		if _, err = filer.NewIdMapping(locConf); err != nil {
+			return err
+		}
+
+		// check upload policy
+		if _, err = filer.NewUploadPolicy(locConf); err != nil {
+			return err
 		}
 
 		// check collection
@@ -130,6 +179,38 @@ func (c *commandFsConfigure) Do(args []string, commandEnv *CommandEnv, writer io
 
 }
 
+func splitIdRanges(specs string) (ranges []string) {
+	for _, spec := range strings.Split(specs, ",") {
+		if spec = strings.TrimSpace(spec); spec != "" {
+			ranges = append(ranges, spec)
+		}
+	}
+	return
+}
+
+// splitMimeTypes parses the comma separated mime types, either exact types without parameters, or like "image/*" and "*/*"
+func splitMimeTypes(specs string) (mimeTypes []string, err error) {
+	for _, spec := range strings.Split(specs, ",") {
+		spec = strings.ToLower(strings.TrimSpace(spec))
+		if spec == "" {
+			continue
+		}
+		mainType, subType, found := strings.Cut(spec, "/")
+		if !found || mainType == "" || subType == "" || strings.Contains(subType, "/") {
+			return nil, fmt.Errorf("invalid mime type %s", spec)
+		}
+		if subType == "*" {
+			if mainType != "*" && strings.Contains(mainType, "*") {
+				return nil, fmt.Errorf("invalid mime type %s", spec)
+			}
+		} else if mediaType, params, parseErr := mime.ParseMediaType(spec); parseErr != nil || len(params) > 0 || mediaType != spec {
+			return nil, fmt.Errorf("invalid mime type %s", spec)
+		}
+		mimeTypes = append(mimeTypes, spec)
+	}
+	return
+}
+
 func infoAboutSimulationMode(writer io.Writer, forceMode bool, forceModeOption string) {
 	if forceMode {
 		return
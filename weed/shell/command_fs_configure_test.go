@@ -0,0 +1,23 @@
+package shell
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitMimeTypes(t *testing.T) {
+	mimeTypes, err := splitMimeTypes(" Image/PNG, image/*,,*/*, application/vnd.ms-excel ")
+	if err != nil {
+		t.Fatalf("splitMimeTypes: %v", err)
+	}
+	expected := []string{"image/png", "image/*", "*/*", "application/vnd.ms-excel"}
+	if !reflect.DeepEqual(mimeTypes, expected) {
+		t.Errorf("expected %v, actual %v", expected, mimeTypes)
+	}
+
+	for _, spec := range []string{"image", "image/", "/png", "image/png/x", "text/plain; charset=utf-8", "im*/*", "image/p ng"} {
+		if _, err := splitMimeTypes(spec); err == nil {
+			t.Errorf("expected %q to be invalid", spec)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package shell
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandFsFind{})
+}
+
+type commandFsFind struct {
+}
+
+func (c *commandFsFind) Name() string {
+	return "fs.find"
+}
+
+func (c *commandFsFind) Help() string {
+	return `find entries under a directory by name, size, modification time, ttl or collection
+
+	fs.find /buckets/logs                                      # print all entries
+	fs.find -name=*.log -minSize=100MiB /buckets/logs          # large log files
+	fs.find -type=f -olderThan=720h -delete /tmp               # delete files not modified for 30 days
+	fs.find -collection=pictures -ttl=none -setTtl=30d /pics   # give the files without ttl a ttl of 30 days
+	fs.find -newerThan=1h -print0 /data                        # separate the paths by NUL instead of new line
+
+	The name is a glob pattern matching the entry name, as in filepath.Match.
+	Sizes accept units, e.g. 10MB or 1GiB. The ttl is like 3m, 4h, 5d, 6w, 7M, 8y, or "none".
+	All filters must match. The matched entries are printed while the directories are walked,
+	with -concurrency directories listed at the same time.
+
+	-delete deletes the matched files with their data, but not directories.
+	-setTtl changes the ttl of the matched entries in the filer.
+
+`
+}
+
+// fsFindMatcher holds the filters of fs.find, an unset filter matches everything
+type fsFindMatcher struct {
+	namePattern string
+	entryType   string
+	minSize     uint64
+	maxSize     uint64
+	newerThan   time.Duration
+	olderThan   time.Duration
+	ttlSec      int32 // -1 to not filter by ttl
+	collection  string
+	// the collection of each volume, since an entry has a collection by the volumes of its chunks
+	volumeCollections map[uint32]string
+}
+
+func (m *fsFindMatcher) matches(entry *filer_pb.Entry, now time.Time) bool {
+	if m.namePattern != "" {
+		if matched, _ := filepath.Match(m.namePattern, entry.Name); !matched {
+			return false
+		}
+	}
+	switch m.entryType {
+	case "f":
+		if entry.IsDirectory {
+			return false
+		}
+	case "d":
+		if !entry.IsDirectory {
+			return false
+		}
+	}
+	if m.minSize > 0 || m.maxSize > 0 {
+		if entry.IsDirectory {
+			return false
+		}
+		size := filer.FileSize(entry)
+		if size < m.minSize || (m.maxSize > 0 && size > m.maxSize) {
+			return false
+		}
+	}
+	mtime := time.Unix(entry.GetAttributes().GetMtime(), 0)
+	if m.newerThan > 0 && mtime.Before(now.Add(-m.newerThan)) {
+		return false
+	}
+	if m.olderThan > 0 && !mtime.Before(now.Add(-m.olderThan)) {
+		return false
+	}
+	if m.ttlSec >= 0 && entry.GetAttributes().GetTtlSec() != m.ttlSec {
+		return false
+	}
+	if m.collection != "" && !m.isInCollection(entry) {
+		return false
+	}
+	return true
+}
+
+func (m *fsFindMatcher) isInCollection(entry *filer_pb.Entry) bool {
+	for _, chunk := range entry.Chunks {
+		if collection, found := m.volumeCollections[chunk.Fid.GetVolumeId()]; found && isCollectionMatched(collection, m.collection) {
+			return true
+		}
+	}
+	return false
+}
+
+func collectVolumeCollections(commandEnv *CommandEnv) (volumeCollections map[uint32]string, err error) {
+	topologyInfo, _, err := collectTopologyInfo(commandEnv, 0)
+	if err != nil {
+		return nil, err
+	}
+	volumeCollections = make(map[uint32]string)
+	eachDataNode(topologyInfo, func(dc string, rack RackId, dn *master_pb.DataNodeInfo) {
+		for _, diskInfo := range dn.DiskInfos {
+			for _, vi := range diskInfo.VolumeInfos {
+				volumeCollections[vi.Id] = vi.Collection
+			}
+			for _, ecShardInfo := range diskInfo.EcShardInfos {
+				volumeCollections[ecShardInfo.Id] = ecShardInfo.Collection
+			}
+		}
+	})
+	return volumeCollections, nil
+}
+
+func parseTtlSeconds(ttl string) (int32, error) {
+	if ttl == "none" {
+		return 0, nil
+	}
+	t, err := needle.ReadTTL(ttl)
+	if err != nil {
+		return 0, fmt.Errorf("parse ttl %s: %v", ttl, err)
+	}
+	return int32(t.Minutes() * 60), nil
+}
+
+func (c *commandFsFind) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	findCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	name := findCommand.String("name", "", "glob pattern of the entry name, e.g. *.log")
+	entryType := findCommand.String("type", "", "f for files, d for directories")
+	minSize := findCommand.String("minSize", "", "minimum file size, e.g. 10MB")
+	maxSize := findCommand.String("maxSize", "", "maximum file size, e.g. 1GiB")
+	newerThan := findCommand.Duration("newerThan", 0, "modified within this duration, e.g. 24h")
+	olderThan := findCommand.Duration("olderThan", 0, "not modified within this duration, e.g. 720h")
+	ttl := findCommand.String("ttl", "", "entries with this ttl, e.g. 7d, or none for entries without ttl")
+	collection := findCommand.String("collection", "", "files with data in this collection. Use '_default_' for the empty-named collection.")
+	isDelete := findCommand.Bool("delete", false, "delete the matched files")
+	setTtl := findCommand.String("setTtl", "", "change the ttl of the matched entries, e.g. 30d, or none to remove the ttl")
+	print0 := findCommand.Bool("print0", false, "end each path with NUL instead of new line")
+	concurrency := findCommand.Int("concurrency", 5, "number of directories to list at the same time")
+	if err = findCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	matcher := &fsFindMatcher{
+		namePattern: *name,
+		entryType:   *entryType,
+		newerThan:   *newerThan,
+		olderThan:   *olderThan,
+		ttlSec:      -1,
+		collection:  *collection,
+	}
+	if *entryType != "" && *entryType != "f" && *entryType != "d" {
+		return fmt.Errorf("unknown type %s, should be f or d", *entryType)
+	}
+	if _, err = filepath.Match(*name, ""); err != nil {
+		return fmt.Errorf("name pattern %s: %v", *name, err)
+	}
+	if *minSize != "" {
+		if matcher.minSize, err = util.ParseBytes(*minSize); err != nil {
+			return fmt.Errorf("minSize %s: %v", *minSize, err)
+		}
+	}
+	if *maxSize != "" {
+		if matcher.maxSize, err = util.ParseBytes(*maxSize); err != nil {
+			return fmt.Errorf("maxSize %s: %v", *maxSize, err)
+		}
+	}
+	if *ttl != "" {
+		if matcher.ttlSec, err = parseTtlSeconds(*ttl); err != nil {
+			return err
+		}
+	}
+	if *collection != "" {
+		if matcher.volumeCollections, err = collectVolumeCollections(commandEnv); err != nil {
+			return err
+		}
+	}
+	finder := &fsFinder{
+		commandEnv: commandEnv,
+		matcher:    matcher,
+		isDelete:   *isDelete,
+		newTtlSec:  -1,
+		separator:  "\n",
+		writer:     writer,
+		now:        time.Now(),
+	}
+	if *setTtl != "" {
+		if finder.newTtlSec, err = parseTtlSeconds(*setTtl); err != nil {
+			return err
+		}
+	}
+	if *print0 {
+		finder.separator = "\x00"
+	}
+	if *concurrency < 1 {
+		*concurrency = 1
+	}
+	finder.tokens = make(chan struct{}, *concurrency)
+
+	dir, err := commandEnv.parseUrl(findInputDirectory(findCommand.Args()))
+	if err != nil {
+		return err
+	}
+	if err = commandEnv.checkDirectory(dir); err != nil {
+		return err
+	}
+
+	finder.wg.Add(1)
+	finder.findIn(util.FullPath(dir))
+	finder.wg.Wait()
+
+	if finder.isDelete || finder.newTtlSec >= 0 {
+		fmt.Fprintf(writer, "matched %d entries, %d failed\n", finder.matchedCount, finder.failedCount)
+	}
+	return finder.err
+}
+
+type fsFinder struct {
+	commandEnv *CommandEnv
+	matcher    *fsFindMatcher
+	isDelete   bool
+	newTtlSec  int32 // -1 to keep the ttl
+	separator  string
+	now        time.Time
+	tokens     chan struct{}
+	wg         sync.WaitGroup
+
+	writer       io.Writer
+	matchedCount int64
+	failedCount  int64
+	err          error
+	sync.Mutex
+}
+
+func (f *fsFinder) findIn(dir util.FullPath) {
+	defer f.wg.Done()
+
+	var subDirs []util.FullPath
+	f.tokens <- struct{}{}
+	err := filer_pb.ReadDirAllEntries(f.commandEnv, dir, "", func(entry *filer_pb.Entry, isLast bool) error {
+		if f.matcher.matches(entry, f.now) {
+			f.act(dir, entry)
+		}
+		if entry.IsDirectory {
+			subDirs = append(subDirs, dir.Child(entry.Name))
+		}
+		return nil
+	})
+	<-f.tokens
+
+	if err != nil {
+		f.Lock()
+		if f.err == nil {
+			f.err = fmt.Errorf("list %s: %v", dir, err)
+		}
+		f.Unlock()
+	}
+	for _, subDir := range subDirs {
+		f.wg.Add(1)
+		go f.findIn(subDir)
+	}
+}
+
+func (f *fsFinder) act(dir util.FullPath, entry *filer_pb.Entry) {
+	var err error
+	if f.isDelete && !entry.IsDirectory {
+		err = filer_pb.Remove(f.commandEnv, string(dir), entry.Name, true, false, false, false, nil)
+	}
+	if err == nil && f.newTtlSec >= 0 && !(f.isDelete && !entry.IsDirectory) {
+		err = f.commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+			if entry.Attributes == nil {
+				entry.Attributes = &filer_pb.Attributes{}
+			}
+			entry.Attributes.TtlSec = f.newTtlSec
+			return filer_pb.UpdateEntry(client, &filer_pb.UpdateEntryRequest{
+				Directory: string(dir),
+				Entry:     entry,
+			})
+		})
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	f.matchedCount++
+	if err != nil {
+		f.failedCount++
+		fmt.Fprintf(f.writer, "fs.find: %s: %v\n", dir.Child(entry.Name), err)
+		return
+	}
+	fmt.Fprintf(f.writer, "%s%s", dir.Child(entry.Name), f.separator)
+}
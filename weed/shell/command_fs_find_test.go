@@ -0,0 +1,53 @@
+package shell
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+func TestFsFindMatcher(t *testing.T) {
+	now := time.Now()
+	file := &filer_pb.Entry{
+		Name: "app.log",
+		Attributes: &filer_pb.Attributes{
+			FileSize: 2 * 1024 * 1024,
+			Mtime:    now.Add(-48 * time.Hour).Unix(),
+			TtlSec:   7 * 24 * 3600,
+		},
+		Chunks: []*filer_pb.FileChunk{{Fid: &filer_pb.FileId{VolumeId: 3}, Size: 2 * 1024 * 1024}},
+	}
+	dir := &filer_pb.Entry{Name: "logs", IsDirectory: true, Attributes: &filer_pb.Attributes{Mtime: now.Unix()}}
+
+	tests := []struct {
+		name    string
+		matcher fsFindMatcher
+		entry   *filer_pb.Entry
+		want    bool
+	}{
+		{"everything", fsFindMatcher{ttlSec: -1}, file, true},
+		{"name", fsFindMatcher{namePattern: "*.log", ttlSec: -1}, file, true},
+		{"other name", fsFindMatcher{namePattern: "*.txt", ttlSec: -1}, file, false},
+		{"files only", fsFindMatcher{entryType: "f", ttlSec: -1}, dir, false},
+		{"directories only", fsFindMatcher{entryType: "d", ttlSec: -1}, dir, true},
+		{"min size", fsFindMatcher{minSize: 1024 * 1024, ttlSec: -1}, file, true},
+		{"max size", fsFindMatcher{maxSize: 1024 * 1024, ttlSec: -1}, file, false},
+		{"sizes skip directories", fsFindMatcher{minSize: 1, ttlSec: -1}, dir, false},
+		{"older than", fsFindMatcher{olderThan: 24 * time.Hour, ttlSec: -1}, file, true},
+		{"newer than", fsFindMatcher{newerThan: 24 * time.Hour, ttlSec: -1}, file, false},
+		{"ttl", fsFindMatcher{ttlSec: 7 * 24 * 3600}, file, true},
+		{"no ttl", fsFindMatcher{ttlSec: 0}, file, false},
+		{"collection", fsFindMatcher{collection: "logs", ttlSec: -1, volumeCollections: map[uint32]string{3: "logs"}}, file, true},
+		{"other collection", fsFindMatcher{collection: "pics", ttlSec: -1, volumeCollections: map[uint32]string{3: "logs"}}, file, false},
+	}
+	for _, tt := range tests {
+		if got := tt.matcher.matches(tt.entry, now); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if ttlSec, err := parseTtlSeconds("7d"); err != nil || ttlSec != 7*24*3600 {
+		t.Errorf("parse ttl 7d: %d %v", ttlSec, err)
+	}
+}
@@ -0,0 +1,259 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+func init() {
+	Commands = append(Commands, &commandFsKvList{})
+	Commands = append(Commands, &commandFsKvDelete{})
+	Commands = append(Commands, &commandFsKvUsage{})
+}
+
+type commandFsKvList struct {
+}
+
+func (c *commandFsKvList) Name() string {
+	return "fs.kv.list"
+}
+
+func (c *commandFsKvList) Help() string {
+	return `list the keys of the filer kv store by prefix
+
+	fs.kv.list -prefix=sync.                 # list the filer.sync offsets
+	fs.kv.list -prefix=iam. -values          # also print the values
+	fs.kv.list -prefix=dedup.ref: -limit=100 -start=dedup.ref:3,01637037d6
+
+	The keys and values with unprintable characters are printed as quoted Go strings,
+	and can be passed quoted to -start, and to fs.kv.delete.
+
+	Listing is supported by the leveldb, leveldb2, leveldb3, redis, redis2 and redis_lua filer stores.
+	The redis stores scan all keys under the prefix for each page.
+`
+}
+
+func (c *commandFsKvList) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	kvListCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	prefix := kvListCommand.String("prefix", "", "the key prefix")
+	start := kvListCommand.String("start", "", "list the keys after this key")
+	limit := kvListCommand.Int64("limit", 0, "the maximum number of keys to list, 0 for all keys")
+	showValues := kvListCommand.Bool("values", false, "print the values")
+	if err = kvListCommand.Parse(args); err != nil {
+		return nil
+	}
+	if *prefix == "" {
+		return fmt.Errorf("need a key -prefix, known prefixes: %s", strings.Join(filer.KvNamespaces, " "))
+	}
+	startKey, err := parseKvKey(*start)
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	err = listKvEntries(commandEnv, []byte(*prefix), startKey, *limit, !*showValues, func(kvEntry *filer_pb.KvEntry) {
+		count++
+		if *showValues {
+			fmt.Fprintf(writer, "%s\t%s\n", formatKvBytes(kvEntry.Key), formatKvBytes(kvEntry.Value))
+		} else {
+			fmt.Fprintf(writer, "%s\t%d bytes\n", formatKvBytes(kvEntry.Key), kvEntry.ValueSize)
+		}
+	})
+	fmt.Fprintf(writer, "total %d keys\n", count)
+	return err
+}
+
+type commandFsKvDelete struct {
+}
+
+func (c *commandFsKvDelete) Name() string {
+	return "fs.kv.delete"
+}
+
+func (c *commandFsKvDelete) Help() string {
+	return `delete keys from the filer kv store
+
+	fs.kv.delete -key=clusterId                     # delete one key
+	fs.kv.delete -key='"sync.\x00\x00\x01\x02"'     # the unprintable keys are quoted as printed by fs.kv.list
+	fs.kv.delete -prefix=sync./buckets              # list the keys to delete
+	fs.kv.delete -prefix=sync./buckets -apply       # delete all keys with the prefix
+`
+}
+
+func (c *commandFsKvDelete) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	kvDeleteCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	keyStr := kvDeleteCommand.String("key", "", "the key to delete")
+	prefix := kvDeleteCommand.String("prefix", "", "delete all keys with the prefix")
+	apply := kvDeleteCommand.Bool("apply", false, "delete the keys with the prefix, instead of only listing them")
+	if err = kvDeleteCommand.Parse(args); err != nil {
+		return nil
+	}
+	if (*keyStr == "") == (*prefix == "") {
+		return fmt.Errorf("use -key or -prefix")
+	}
+
+	if *keyStr != "" {
+		key, parseErr := parseKvKey(*keyStr)
+		if parseErr != nil {
+			return parseErr
+		}
+		if err = deleteKvKey(commandEnv, key); err != nil {
+			return err
+		}
+		fmt.Fprintf(writer, "deleted %s\n", formatKvBytes(key))
+		return nil
+	}
+
+	var keys [][]byte
+	if err = listKvEntries(commandEnv, []byte(*prefix), nil, 0, true, func(kvEntry *filer_pb.KvEntry) {
+		keys = append(keys, kvEntry.Key)
+	}); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if !*apply {
+			fmt.Fprintf(writer, "to delete %s\n", formatKvBytes(key))
+			continue
+		}
+		if err = deleteKvKey(commandEnv, key); err != nil {
+			return err
+		}
+		fmt.Fprintf(writer, "deleted %s\n", formatKvBytes(key))
+	}
+	if !*apply {
+		fmt.Fprintf(writer, "%d keys to delete, use -apply to delete them\n", len(keys))
+	}
+	return nil
+}
+
+type commandFsKvUsage struct {
+}
+
+func (c *commandFsKvUsage) Name() string {
+	return "fs.kv.usage"
+}
+
+func (c *commandFsKvUsage) Help() string {
+	return `show the number of keys and the value bytes of each namespace in the filer kv store
+
+	fs.kv.usage                          # the namespaces used by the filer, filer.sync, filer.remote.sync and iam
+	fs.kv.usage sync. remote.sync.       # only these prefixes
+`
+}
+
+func (c *commandFsKvUsage) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	request := &filer_pb.KvUsageRequest{}
+	for _, arg := range args {
+		prefix, parseErr := parseKvKey(arg)
+		if parseErr != nil {
+			return parseErr
+		}
+		request.Prefixes = append(request.Prefixes, prefix)
+	}
+
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.KvUsage(context.Background(), request)
+		if err != nil {
+			return err
+		}
+		if resp.Error != "" {
+			return errors.New(resp.Error)
+		}
+		var keyCount, valueBytes int64
+		for _, namespace := range resp.Namespaces {
+			fmt.Fprintf(writer, "%-24s %10d keys %12d bytes\n", formatKvBytes(namespace.Prefix), namespace.KeyCount, namespace.ValueBytes)
+			keyCount += namespace.KeyCount
+			valueBytes += namespace.ValueBytes
+		}
+		fmt.Fprintf(writer, "%-24s %10d keys %12d bytes\n", "total", keyCount, valueBytes)
+		return nil
+	})
+}
+
+// listKvEntries pages through the kv entries with the prefix, up to limit entries if limit is positive.
+func listKvEntries(commandEnv *CommandEnv, prefix []byte, startKey []byte, limit int64, keysOnly bool, fn func(kvEntry *filer_pb.KvEntry)) error {
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		for {
+			pageSize := int64(filer.PaginationSize)
+			if limit > 0 && limit < pageSize {
+				pageSize = limit
+			}
+			resp, err := client.KvList(context.Background(), &filer_pb.KvListRequest{
+				Prefix:   prefix,
+				StartKey: startKey,
+				Limit:    pageSize,
+				KeysOnly: keysOnly,
+			})
+			if err != nil {
+				return err
+			}
+			if resp.Error != "" {
+				return errors.New(resp.Error)
+			}
+			for _, kvEntry := range resp.Entries {
+				fn(kvEntry)
+				startKey = kvEntry.Key
+			}
+			if limit > 0 {
+				limit -= int64(len(resp.Entries))
+				if limit <= 0 {
+					return nil
+				}
+			}
+			if int64(len(resp.Entries)) < pageSize {
+				return nil
+			}
+		}
+	})
+}
+
+func deleteKvKey(commandEnv *CommandEnv, key []byte) error {
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.KvDelete(context.Background(), &filer_pb.KvDeleteRequest{Key: key})
+		if err != nil {
+			return err
+		}
+		if resp.Error != "" {
+			return fmt.Errorf("delete %s: %s", formatKvBytes(key), resp.Error)
+		}
+		return nil
+	})
+}
+
+// formatKvBytes quotes the keys and values with unprintable characters.
+func formatKvBytes(data []byte) string {
+	s := string(data)
+	if strings.HasPrefix(s, "\"") {
+		return strconv.Quote(s)
+	}
+	for _, r := range s {
+		if r == unicode.ReplacementChar || !unicode.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+// parseKvKey accepts the keys as printed by formatKvBytes.
+func parseKvKey(s string) ([]byte, error) {
+	if strings.HasPrefix(s, "\"") {
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("parse quoted key %s: %v", s, err)
+		}
+		return []byte(unquoted), nil
+	}
+	return []byte(s), nil
+}
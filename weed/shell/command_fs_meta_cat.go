@@ -50,6 +50,7 @@ func (c *commandFsMetaCat) Do(args []string, commandEnv *CommandEnv, writer io.W
 		}
 
 		filer.ProtoToText(writer, respLookupEntry.Entry)
+		fmt.Fprintln(writer)
 
 		bytes, _ := proto.Marshal(respLookupEntry.Entry)
 		gzippedBytes, _ := util.GzipData(bytes)
@@ -0,0 +1,266 @@
+package shell
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandFsMetaDump{})
+}
+
+type commandFsMetaDump struct {
+}
+
+func (c *commandFsMetaDump) Name() string {
+	return "fs.meta.dump"
+}
+
+func (c *commandFsMetaDump) Help() string {
+	return `export directory and file meta data in a standard format for analytics
+
+	fs.meta.dump -o meta.jsonl /path/to/dir       # one json object per line
+	fs.meta.dump -o meta.jsonl.gz /path/to/dir    # gzip compressed, also enabled by -gzip
+	fs.meta.dump -o meta.jsonl -chunks=false /    # skip the chunk list
+
+	Each line contains the path, size, times, mode, owner, mime, ttl, checksums, chunks,
+	hard link id and counter, and the extended attributes of one entry.
+	Parent directories always come before their children.
+	Only the "jsonl" format is supported. The Parquet output is not supported,
+	so -format=parquet and the output file names ending with .parquet are rejected.
+
+	The exported file can be loaded back by fs.meta.import, which recreates the meta data
+	pointing to the existing chunks.
+
+`
+}
+
+// MetaDumpRecord is one line of the jsonl metadata dump.
+type MetaDumpRecord struct {
+	Path        string            `json:"path"`
+	IsDirectory bool              `json:"isDirectory,omitempty"`
+	Size        uint64            `json:"size"`
+	Mtime       int64             `json:"mtime"`
+	Crtime      int64             `json:"crtime"`
+	Mode        uint32            `json:"mode"`
+	Uid         uint32            `json:"uid"`
+	Gid         uint32            `json:"gid"`
+	Mime        string            `json:"mime,omitempty"`
+	TtlSec      int32             `json:"ttlSec,omitempty"`
+	Md5         []byte            `json:"md5,omitempty"`
+	Sha256      []byte            `json:"sha256,omitempty"`
+	Content     []byte            `json:"content,omitempty"`
+	Quota       int64             `json:"quota,omitempty"`
+	Chunks      []*MetaDumpChunk  `json:"chunks,omitempty"`
+	Extended    map[string][]byte `json:"extended,omitempty"`
+	// the links of one file share the hard link id, the filer counts the links again when they are imported
+	HardLinkId      []byte `json:"hardLinkId,omitempty"`
+	HardLinkCounter int32  `json:"hardLinkCounter,omitempty"`
+}
+
+type MetaDumpChunk struct {
+	FileId          string `json:"fileId"`
+	Offset          int64  `json:"offset"`
+	Size            uint64 `json:"size"`
+	Mtime           int64  `json:"mtime"`
+	ETag            string `json:"eTag,omitempty"`
+	CipherKey       []byte `json:"cipherKey,omitempty"`
+	IsCompressed    bool   `json:"isCompressed,omitempty"`
+	IsChunkManifest bool   `json:"isChunkManifest,omitempty"`
+	// a packed small file is stored at the container offset of a container chunk
+	ContainerOffset int64  `json:"containerOffset,omitempty"`
+	ContainerSize   uint64 `json:"containerSize,omitempty"`
+}
+
+func (c *commandFsMetaDump) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	fsMetaDumpCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	verbose := fsMetaDumpCommand.Bool("v", false, "print out each processed files")
+	outputFileName := fsMetaDumpCommand.String("o", "", "output the meta data to this file")
+	format := fsMetaDumpCommand.String("format", "jsonl", "output format, only jsonl is supported, parquet is not supported")
+	isGzip := fsMetaDumpCommand.Bool("gzip", false, "gzip the output, enabled by default if the output file name ends with .gz")
+	includeChunks := fsMetaDumpCommand.Bool("chunks", true, "include the chunk list of each file")
+	if err = fsMetaDumpCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	if err = checkMetaDumpFormat(*format, *outputFileName); err != nil {
+		return err
+	}
+
+	path, parseErr := commandEnv.parseUrl(findInputDirectory(fsMetaDumpCommand.Args()))
+	if parseErr != nil {
+		return parseErr
+	}
+
+	fileName := *outputFileName
+	if fileName == "" {
+		t := time.Now()
+		fileName = fmt.Sprintf("%s-%4d%02d%02d-%02d%02d%02d.jsonl",
+			commandEnv.option.FilerAddress.ToHttpAddress(), t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second())
+		if *isGzip {
+			fileName += ".gz"
+		}
+	}
+
+	dst, openErr := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if openErr != nil {
+		return fmt.Errorf("failed to create file %s: %v", fileName, openErr)
+	}
+	defer dst.Close()
+
+	bufWriter := bufio.NewWriterSize(dst, 1024*1024)
+	var out io.Writer = bufWriter
+	var gzWriter *gzip.Writer
+	if *isGzip || strings.HasSuffix(fileName, ".gz") {
+		gzWriter = gzip.NewWriter(bufWriter)
+		out = gzWriter
+	}
+
+	var writeErr error
+	err = doTraverseBfsAndSaving(commandEnv, writer, path, *verbose, func(entry *filer_pb.FullEntry, outputChan chan interface{}) error {
+		outputChan <- toMetaDumpRecord(entry, *includeChunks)
+		return nil
+	}, func(outputChan chan interface{}) {
+		encoder := json.NewEncoder(out)
+		for item := range outputChan {
+			if writeErr != nil {
+				continue
+			}
+			writeErr = encoder.Encode(item)
+		}
+	})
+	if err == nil {
+		err = writeErr
+	}
+
+	if gzWriter != nil {
+		if closeErr := gzWriter.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if flushErr := bufWriter.Flush(); err == nil {
+		err = flushErr
+	}
+
+	if err == nil {
+		fmt.Fprintf(writer, "meta data for http://%s%s is dumped to %s\n", commandEnv.option.FilerAddress.ToHttpAddress(), path, fileName)
+	}
+
+	return err
+
+}
+
+// checkMetaDumpFormat rejects the formats other than jsonl, including parquet by the format or the file name.
+func checkMetaDumpFormat(format, fileName string) error {
+	if strings.EqualFold(format, "parquet") || strings.HasSuffix(strings.ToLower(fileName), ".parquet") {
+		return fmt.Errorf("parquet output is not supported, use -format=jsonl")
+	}
+	if format != "jsonl" {
+		return fmt.Errorf("unsupported format %s, only jsonl is supported", format)
+	}
+	return nil
+}
+
+func toMetaDumpRecord(fullEntry *filer_pb.FullEntry, includeChunks bool) *MetaDumpRecord {
+	entry := fullEntry.Entry
+	record := &MetaDumpRecord{
+		Path:            string(util.FullPath(fullEntry.Dir).Child(entry.Name)),
+		IsDirectory:     entry.IsDirectory,
+		Size:            filer.FileSize(entry),
+		Content:         entry.Content,
+		Quota:           entry.Quota,
+		Extended:        entry.Extended,
+		HardLinkId:      entry.HardLinkId,
+		HardLinkCounter: entry.HardLinkCounter,
+	}
+	if attr := entry.Attributes; attr != nil {
+		record.Mtime = attr.Mtime
+		record.Crtime = attr.Crtime
+		record.Mode = attr.FileMode
+		record.Uid = attr.Uid
+		record.Gid = attr.Gid
+		record.Mime = attr.Mime
+		record.TtlSec = attr.TtlSec
+		record.Md5 = attr.Md5
+		record.Sha256 = attr.Sha256
+	}
+	if includeChunks {
+		for _, chunk := range entry.Chunks {
+			record.Chunks = append(record.Chunks, &MetaDumpChunk{
+				FileId:          chunk.GetFileIdString(),
+				Offset:          chunk.Offset,
+				Size:            chunk.Size,
+				Mtime:           chunk.Mtime,
+				ETag:            chunk.ETag,
+				CipherKey:       chunk.CipherKey,
+				IsCompressed:    chunk.IsCompressed,
+				IsChunkManifest: chunk.IsChunkManifest,
+				ContainerOffset: chunk.ContainerOffset,
+				ContainerSize:   chunk.ContainerSize,
+			})
+		}
+	}
+	return record
+}
+
+func (record *MetaDumpRecord) toFullEntry() (*filer_pb.FullEntry, error) {
+	dir, name := util.FullPath(record.Path).DirAndName()
+	if name == "" {
+		return nil, fmt.Errorf("invalid path %q", record.Path)
+	}
+	entry := &filer_pb.Entry{
+		Name:            name,
+		IsDirectory:     record.IsDirectory,
+		Content:         record.Content,
+		Quota:           record.Quota,
+		Extended:        record.Extended,
+		HardLinkId:      record.HardLinkId,
+		HardLinkCounter: record.HardLinkCounter,
+		Attributes: &filer_pb.Attributes{
+			FileSize: record.Size,
+			Mtime:    record.Mtime,
+			Crtime:   record.Crtime,
+			FileMode: record.Mode,
+			Uid:      record.Uid,
+			Gid:      record.Gid,
+			Mime:     record.Mime,
+			TtlSec:   record.TtlSec,
+			Md5:      record.Md5,
+			Sha256:   record.Sha256,
+		},
+	}
+	for _, c := range record.Chunks {
+		fid, err := filer_pb.ToFileIdObject(c.FileId)
+		if err != nil {
+			return nil, fmt.Errorf("%s chunk %s: %v", record.Path, c.FileId, err)
+		}
+		entry.Chunks = append(entry.Chunks, &filer_pb.FileChunk{
+			Fid:             fid,
+			Offset:          c.Offset,
+			Size:            c.Size,
+			Mtime:           c.Mtime,
+			ETag:            c.ETag,
+			CipherKey:       c.CipherKey,
+			IsCompressed:    c.IsCompressed,
+			IsChunkManifest: c.IsChunkManifest,
+			ContainerOffset: c.ContainerOffset,
+			ContainerSize:   c.ContainerSize,
+		})
+	}
+	return &filer_pb.FullEntry{
+		Dir:   dir,
+		Entry: entry,
+	}, nil
+}
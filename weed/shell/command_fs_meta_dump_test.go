@@ -0,0 +1,70 @@
+package shell
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+func TestMetaDumpRecordRoundTrip(t *testing.T) {
+	fullEntry := &filer_pb.FullEntry{
+		Dir: "/buckets/b1",
+		Entry: &filer_pb.Entry{
+			Name: "a.txt",
+			Chunks: []*filer_pb.FileChunk{
+				{Fid: &filer_pb.FileId{VolumeId: 3, FileKey: 0x1234, Cookie: 0x5678}, Offset: 0, Size: 100, Mtime: 10, ETag: "e1"},
+				{Fid: &filer_pb.FileId{VolumeId: 4, FileKey: 0x9abc, Cookie: 0xdef0}, Offset: 100, Size: 50, Mtime: 11, ETag: "e2"},
+				// packed into a container chunk
+				{Fid: &filer_pb.FileId{VolumeId: 5, FileKey: 0x1111, Cookie: 0x2222}, Offset: 150, Size: 10, Mtime: 12, ContainerOffset: 4096, ContainerSize: 8192},
+			},
+			Attributes: &filer_pb.Attributes{
+				FileSize: 160,
+				Mtime:    1000,
+				Crtime:   900,
+				FileMode: 0644,
+				Uid:      1,
+				Gid:      2,
+				Mime:     "text/plain",
+				Md5:      []byte{1, 2},
+				Sha256:   []byte{3, 4},
+			},
+			Extended:        map[string][]byte{"X-Amz-Meta-A": []byte("b")},
+			HardLinkId:      []byte{5, 6, 7},
+			HardLinkCounter: 2,
+		},
+	}
+
+	var buf bytes.Buffer
+	gzWriter := gzip.NewWriter(&buf)
+	assert.Nil(t, json.NewEncoder(gzWriter).Encode(toMetaDumpRecord(fullEntry, true)))
+	assert.Nil(t, gzWriter.Close())
+
+	reader, err := newMetaDumpReader(&buf)
+	assert.Nil(t, err)
+	record := &MetaDumpRecord{}
+	assert.Nil(t, json.NewDecoder(reader).Decode(record))
+	assert.Equal(t, "/buckets/b1/a.txt", record.Path)
+	assert.Equal(t, uint64(160), record.Size)
+	assert.Equal(t, "3,123400005678", record.Chunks[0].FileId)
+
+	// GetFileIdString() caches the file id string
+	for _, chunk := range fullEntry.Entry.Chunks {
+		chunk.FileId = ""
+	}
+	restored, err := record.toFullEntry()
+	assert.Nil(t, err)
+	assert.True(t, proto.Equal(fullEntry, restored), "restored %v", restored)
+}
+
+func TestMetaDumpFormat(t *testing.T) {
+	assert.Nil(t, checkMetaDumpFormat("jsonl", "meta.jsonl.gz"))
+	assert.NotNil(t, checkMetaDumpFormat("parquet", "meta.out"))
+	assert.NotNil(t, checkMetaDumpFormat("jsonl", "meta.Parquet"))
+	assert.NotNil(t, checkMetaDumpFormat("csv", ""))
+}
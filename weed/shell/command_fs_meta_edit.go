@@ -0,0 +1,191 @@
+package shell
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandFsMetaEdit{})
+}
+
+type commandFsMetaEdit struct {
+}
+
+func (c *commandFsMetaEdit) Name() string {
+	return "fs.meta.edit"
+}
+
+func (c *commandFsMetaEdit) Help() string {
+	return `edit the meta data of a file or directory
+
+	# fix the mime type
+	fs.meta.edit -mime=text/plain /dir/file_name
+
+	# remove dangling chunks, by the file ids shown in fs.meta.cat
+	fs.meta.edit -removeChunk=3,01637037d6 -removeChunk=4,0263703d2e /dir/file_name
+
+	# set or delete extended attributes
+	fs.meta.edit -setExtended=key1=value1 -setExtended=key2=value2 -deleteExtended=key3 /dir/file_name
+
+	# apply the changes
+	fs.meta.edit -mime=text/plain -apply /dir/file_name
+
+	The edited meta data is printed, and only saved with -apply.
+	The range of a removed chunk reads as zeros, and its data is deleted if still on the volume servers.
+	The file size is set by the remaining chunks, and the md5 and sha256 of the content are cleared.
+`
+}
+
+type metaEdit struct {
+	mime           string
+	removeChunks   []string
+	setExtended    []string
+	deleteExtended []string
+}
+
+func (c *commandFsMetaEdit) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	metaEditCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	mimeType := metaEditCommand.String("mime", "", "the new mime type")
+	var removeChunks, setExtended, deleteExtended listFlag
+	metaEditCommand.Var(&removeChunks, "removeChunk", "the file id of the chunk to remove, repeatable")
+	metaEditCommand.Var(&setExtended, "setExtended", "the key=value extended attribute to set, repeatable")
+	metaEditCommand.Var(&deleteExtended, "deleteExtended", "the key of the extended attribute to delete, repeatable")
+	apply := metaEditCommand.Bool("apply", false, "save the changes")
+	if err = metaEditCommand.Parse(args); err != nil {
+		return nil
+	}
+	if metaEditCommand.NArg() == 0 {
+		return fmt.Errorf("need to specify the file or directory path")
+	}
+	infoAboutSimulationMode(writer, *apply, "-apply")
+
+	path, err := commandEnv.parseUrl(findInputDirectory(metaEditCommand.Args()))
+	if err != nil {
+		return err
+	}
+	dir, name := util.FullPath(path).DirAndName()
+
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+
+		resp, lookupErr := filer_pb.LookupEntry(client, &filer_pb.LookupDirectoryEntryRequest{
+			Directory: dir,
+			Name:      name,
+		})
+		if lookupErr != nil {
+			return lookupErr
+		}
+		entry := resp.Entry
+
+		if editErr := applyMetaEdit(entry, &metaEdit{
+			mime:           *mimeType,
+			removeChunks:   removeChunks,
+			setExtended:    setExtended,
+			deleteExtended: deleteExtended,
+		}); editErr != nil {
+			return editErr
+		}
+
+		if textErr := filer.ProtoToText(writer, entry); textErr != nil {
+			return textErr
+		}
+		fmt.Fprintln(writer)
+
+		if !*apply {
+			return nil
+		}
+		if updateErr := filer_pb.UpdateEntry(client, &filer_pb.UpdateEntryRequest{
+			Directory: dir,
+			Entry:     entry,
+		}); updateErr != nil {
+			return updateErr
+		}
+		fmt.Fprintf(writer, "updated %s\n", path)
+		return nil
+	})
+
+}
+
+// applyMetaEdit changes the entry, after validating all the changes.
+func applyMetaEdit(entry *filer_pb.Entry, edit *metaEdit) error {
+
+	if edit.mime != "" {
+		if _, _, err := mime.ParseMediaType(edit.mime); err != nil {
+			return fmt.Errorf("invalid mime type %s: %v", edit.mime, err)
+		}
+		if entry.Attributes == nil {
+			entry.Attributes = &filer_pb.Attributes{}
+		}
+		entry.Attributes.Mime = edit.mime
+	}
+
+	if len(edit.removeChunks) > 0 {
+		if entry.IsDirectory {
+			return fmt.Errorf("%s is a directory without chunks", entry.Name)
+		}
+		toRemove := make(map[string]bool)
+		for _, fileId := range edit.removeChunks {
+			toRemove[fileId] = true
+		}
+		var chunks []*filer_pb.FileChunk
+		for _, chunk := range entry.Chunks {
+			fileId := chunk.GetFileIdString()
+			if toRemove[fileId] {
+				delete(toRemove, fileId)
+				continue
+			}
+			chunks = append(chunks, chunk)
+		}
+		for fileId := range toRemove {
+			return fmt.Errorf("chunk %s not found in %s", fileId, entry.Name)
+		}
+		entry.Chunks = chunks
+		// the content changed, so the file size follows the remaining chunks and the old digests are dropped
+		if entry.Attributes == nil {
+			entry.Attributes = &filer_pb.Attributes{}
+		}
+		entry.Attributes.FileSize = filer.TotalSize(chunks)
+		entry.Attributes.Md5 = nil
+		entry.Attributes.Sha256 = nil
+	}
+
+	for _, keyValue := range edit.setExtended {
+		key, value, found := strings.Cut(keyValue, "=")
+		if !found || key == "" {
+			return fmt.Errorf("extended attribute %s should be key=value", keyValue)
+		}
+		if entry.Extended == nil {
+			entry.Extended = make(map[string][]byte)
+		}
+		entry.Extended[key] = []byte(value)
+	}
+
+	for _, key := range edit.deleteExtended {
+		if _, found := entry.Extended[key]; !found {
+			return fmt.Errorf("extended attribute %s not found in %s", key, entry.Name)
+		}
+		delete(entry.Extended, key)
+	}
+
+	return nil
+}
+
+// listFlag collects the values of a repeated flag
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
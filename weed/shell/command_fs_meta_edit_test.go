@@ -0,0 +1,48 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+func TestApplyMetaEdit(t *testing.T) {
+	newEntry := func() *filer_pb.Entry {
+		return &filer_pb.Entry{
+			Name:       "a.txt",
+			Attributes: &filer_pb.Attributes{Mime: "application/octet-stream", FileSize: 200, Md5: []byte("md5"), Sha256: []byte("sha256")},
+			Chunks: []*filer_pb.FileChunk{
+				{FileId: "3,01637037d6", Offset: 0, Size: 100},
+				{FileId: "4,0263703d2e", Offset: 100, Size: 100},
+			},
+			Extended: map[string][]byte{"k1": []byte("v1")},
+		}
+	}
+
+	entry := newEntry()
+	assert.Nil(t, applyMetaEdit(entry, &metaEdit{
+		mime:           "text/plain; charset=utf-8",
+		removeChunks:   []string{"3,01637037d6"},
+		setExtended:    []string{"k2=v=2"},
+		deleteExtended: []string{"k1"},
+	}))
+	assert.Equal(t, "text/plain; charset=utf-8", entry.Attributes.Mime)
+	assert.Equal(t, 1, len(entry.Chunks))
+	assert.Equal(t, int64(100), entry.Chunks[0].Offset)
+	assert.Equal(t, uint64(200), entry.Attributes.FileSize)
+	assert.Nil(t, entry.Attributes.Md5)
+	assert.Nil(t, entry.Attributes.Sha256)
+	assert.Equal(t, map[string][]byte{"k2": []byte("v=2")}, entry.Extended)
+
+	entry = newEntry()
+	assert.Nil(t, applyMetaEdit(entry, &metaEdit{removeChunks: []string{"4,0263703d2e"}}))
+	assert.Equal(t, uint64(100), entry.Attributes.FileSize)
+
+	assert.NotNil(t, applyMetaEdit(newEntry(), &metaEdit{mime: "text/"}))
+	assert.NotNil(t, applyMetaEdit(newEntry(), &metaEdit{removeChunks: []string{"5,01"}}))
+	assert.NotNil(t, applyMetaEdit(newEntry(), &metaEdit{setExtended: []string{"k2"}}))
+	assert.NotNil(t, applyMetaEdit(newEntry(), &metaEdit{deleteExtended: []string{"k2"}}))
+	assert.NotNil(t, applyMetaEdit(&filer_pb.Entry{IsDirectory: true}, &metaEdit{removeChunks: []string{"3,01637037d6"}}))
+}
@@ -0,0 +1,128 @@
+package shell
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+func init() {
+	Commands = append(Commands, &commandFsMetaImport{})
+}
+
+type commandFsMetaImport struct {
+}
+
+func (c *commandFsMetaImport) Name() string {
+	return "fs.meta.import"
+}
+
+func (c *commandFsMetaImport) Help() string {
+	return `import meta data exported by fs.meta.dump
+
+	fs.meta.import meta.jsonl
+	fs.meta.import -v meta.jsonl.gz    # gzip compressed files are detected automatically
+
+	The entries are recreated pointing to the chunks listed in the dump, so the chunks must still
+	exist on the volume servers. Files dumped with -chunks=false can not be restored and are skipped.
+
+`
+}
+
+func (c *commandFsMetaImport) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	metaImportCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	verbose := metaImportCommand.Bool("v", false, "print out each imported entry")
+	if err = metaImportCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	if metaImportCommand.NArg() == 0 {
+		fmt.Fprintf(writer, "missing a metadata file\n")
+		return nil
+	}
+	fileName := metaImportCommand.Arg(0)
+
+	src, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("open %s: %v", fileName, err)
+	}
+	defer src.Close()
+
+	reader, err := newMetaDumpReader(src)
+	if err != nil {
+		return fmt.Errorf("read %s: %v", fileName, err)
+	}
+
+	var dirCount, fileCount, skippedCount uint64
+	lastLogTime := time.Now()
+
+	err = commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+
+		decoder := json.NewDecoder(reader)
+		for {
+			record := &MetaDumpRecord{}
+			if err := decoder.Decode(record); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return fmt.Errorf("decode record after %d entries: %v", dirCount+fileCount+skippedCount, err)
+			}
+
+			if !record.IsDirectory && len(record.Chunks) == 0 && len(record.Content) == 0 && record.Size > 0 {
+				fmt.Fprintf(writer, "skip %s: no chunks\n", record.Path)
+				skippedCount++
+				continue
+			}
+
+			fullEntry, err := record.toFullEntry()
+			if err != nil {
+				return err
+			}
+
+			if *verbose || lastLogTime.Add(time.Second).Before(time.Now()) {
+				if !*verbose {
+					lastLogTime = time.Now()
+				}
+				fmt.Fprintf(writer, "import %s\n", record.Path)
+			}
+
+			if err := filer_pb.CreateEntry(client, &filer_pb.CreateEntryRequest{
+				Directory: fullEntry.Dir,
+				Entry:     fullEntry.Entry,
+			}); err != nil {
+				return fmt.Errorf("create %s: %v", record.Path, err)
+			}
+
+			if record.IsDirectory {
+				dirCount++
+			} else {
+				fileCount++
+			}
+		}
+
+	})
+
+	fmt.Fprintf(writer, "total %d directories, %d files, %d skipped\n", dirCount, fileCount, skippedCount)
+	if err == nil {
+		fmt.Fprintf(writer, "%s is imported.\n", fileName)
+	}
+
+	return err
+}
+
+func newMetaDumpReader(src io.Reader) (io.Reader, error) {
+	bufReader := bufio.NewReaderSize(src, 1024*1024)
+	magic, err := bufReader.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(bufReader)
+	}
+	return bufReader, nil
+}
@@ -0,0 +1,95 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+)
+
+func init() {
+	Commands = append(Commands, &commandFsMetaVerify{})
+}
+
+type commandFsMetaVerify struct {
+}
+
+func (c *commandFsMetaVerify) Name() string {
+	return "fs.meta.verify"
+}
+
+func (c *commandFsMetaVerify) Help() string {
+	return `verify the filer store reflects the recent meta log events
+
+	fs.meta.verify [-timeAgo=1h] [-sampleSize=1000] [-apply] [<path prefix>]
+
+	The filer replays the meta log events of the last timeAgo, and reports the paths whose store entries differ:
+	  missing     the entry created by the last event is not in the store
+	  stale       the entry in the store differs from the last event
+	  unexpected  the entry deleted by the last event is still in the store
+	  unlogged    the entry changed in the store has no event, checked on up to sampleSize store entries
+
+	These indicate the store corruption or the missed replication from other filers.
+	With -apply, the last events of the diverged paths are written to the store again,
+	except for the unlogged entries and the deleted directories.
+
+	fs.meta.verify                          # verify the changes in the last hour
+	fs.meta.verify -timeAgo=24h /buckets/   # verify the changes of the buckets in the last day
+	fs.meta.verify -apply                   # also repair the store
+
+`
+}
+
+func (c *commandFsMetaVerify) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	verifyCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	timeAgo := verifyCommand.Duration("timeAgo", time.Hour, "verify the meta log events in this duration before now")
+	sampleSize := verifyCommand.Int("sampleSize", 1000, "check up to this many store entries have their events, 0 to skip")
+	apply := verifyCommand.Bool("apply", false, "apply the last events of the diverged paths to the store again")
+	if err = verifyCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	var pathPrefix string
+	if verifyCommand.NArg() > 0 {
+		if pathPrefix, err = commandEnv.parseUrl(verifyCommand.Arg(0)); err != nil {
+			return err
+		}
+	}
+
+	var resp *filer_pb.VerifyMetadataResponse
+	err = commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		var verifyErr error
+		resp, verifyErr = client.VerifyMetadata(context.Background(), &filer_pb.VerifyMetadataRequest{
+			SinceNs:    time.Now().Add(-*timeAgo).UnixNano(),
+			PathPrefix: pathPrefix,
+			SampleSize: int32(*sampleSize),
+			Apply:      *apply,
+		})
+		return verifyErr
+	})
+	if err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("verify metadata: %s", resp.Error)
+	}
+
+	for _, divergence := range resp.Divergences {
+		fmt.Fprintf(writer, "%-10s %s at %s", divergence.Kind, divergence.Path, time.Unix(0, divergence.TsNs).Format(time.RFC3339))
+		if divergence.Applied {
+			fmt.Fprintf(writer, " applied")
+		}
+		if divergence.Error != "" {
+			fmt.Fprintf(writer, " not applied: %s", divergence.Error)
+		}
+		fmt.Fprintln(writer)
+	}
+	fmt.Fprintf(writer, "verified %d events of %d paths, sampled %d entries, %d divergences\n",
+		resp.EventCount, resp.PathCount, resp.SampledCount, len(resp.Divergences))
+
+	return nil
+}
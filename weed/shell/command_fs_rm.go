@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
@@ -31,6 +32,9 @@ func (c *commandFsRm) Help() string {
 
 	The option "-r" can be recursive.
 	The option "-f" can be ignored by recursive error.
+
+	A recursive removal runs on the filer, which walks the folder in large pages and deletes
+	the file chunks in batches. The progress is printed every second.
 `
 }
 
@@ -76,6 +80,13 @@ func (c *commandFsRm) Do(args []string, commandEnv *CommandEnv, writer io.Writer
 				continue
 			}
 
+			if isRecursive {
+				if err = c.removeRecursively(client, targetDir, targetName, ignoreRecursiveError, writer); err != nil {
+					fmt.Fprintf(writer, "rm: %s: %v\n", targetPath, err)
+				}
+				continue
+			}
+
 			request := &filer_pb.DeleteEntryRequest{
 				Directory:            targetDir,
 				Name:                 targetName,
@@ -98,3 +109,36 @@ func (c *commandFsRm) Do(args []string, commandEnv *CommandEnv, writer io.Writer
 
 	return
 }
+
+func (c *commandFsRm) removeRecursively(client filer_pb.SeaweedFilerClient, dir, name string, ignoreRecursiveError bool, writer io.Writer) error {
+	stream, err := client.StreamDeleteEntry(context.Background(), &filer_pb.StreamDeleteEntryRequest{
+		Directory:            dir,
+		Name:                 name,
+		IsDeleteData:         true,
+		IgnoreRecursiveError: ignoreRecursiveError,
+	})
+	if err != nil {
+		return err
+	}
+
+	lastLogTime := time.Now()
+	var progress *filer_pb.StreamDeleteEntryResponse
+	for {
+		resp, recvErr := stream.Recv()
+		if recvErr == io.EOF {
+			break
+		}
+		if recvErr != nil {
+			return recvErr
+		}
+		progress = resp
+		if lastLogTime.Add(time.Second).Before(time.Now()) {
+			lastLogTime = time.Now()
+			fmt.Fprintf(writer, "deleting %s: %d entries, %d chunks\n", resp.Directory, resp.DeletedEntryCount, resp.DeletedChunkCount)
+		}
+	}
+	if progress != nil && progress.DeletedEntryCount > 1 {
+		fmt.Fprintf(writer, "deleted %s: %d entries, %d chunks\n", util.JoinPath(dir, name), progress.DeletedEntryCount, progress.DeletedChunkCount)
+	}
+	return nil
+}
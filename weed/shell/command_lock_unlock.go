@@ -1,7 +1,6 @@
 package shell
 
 import (
-	"github.com/seaweedfs/seaweedfs/weed/util"
 	"io"
 )
 
@@ -22,12 +21,15 @@ func (c *commandLock) Help() string {
 	return `lock in order to exclusively manage the cluster
 
 	This is a blocking operation if there is alread another lock.
+	The clients waiting for the lock are served in order, and the position in the queue is shown.
+
+	The lock is a lease renewed in the background, and expires if not renewed, e.g. the shell died.
 `
 }
 
 func (c *commandLock) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	commandEnv.locker.RequestLock(util.DetectedHostAddress())
+	commandEnv.lock(writer)
 
 	return nil
 }
@@ -49,7 +51,7 @@ func (c *commandUnlock) Help() string {
 
 func (c *commandUnlock) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	commandEnv.locker.ReleaseLock()
+	commandEnv.unlock()
 
 	return nil
 }
@@ -0,0 +1,233 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+)
+
+func init() {
+	Commands = append(Commands, &commandNodeUpgradeStart{})
+	Commands = append(Commands, &commandNodeUpgradeFinish{})
+}
+
+type commandNodeUpgradeStart struct {
+}
+
+func (c *commandNodeUpgradeStart) Name() string {
+	return "node.upgrade.start"
+}
+
+func (c *commandNodeUpgradeStart) Help() string {
+	return `prepare a volume server to be stopped for an upgrade
+
+	node.upgrade.start -node <volume server host:port> [-drainTimeout=1m]
+
+	This command
+	1. marks the volume server readonly, so the master stops assigning writes to it,
+	2. waits until the uploads in flight on the volume server are finished,
+	3. verifies every volume on the volume server has healthy replicas on other volume servers.
+
+	The volumes only stored on this volume server, missing replicas, or with replicas
+	of different sizes are reported, and these volumes would be unavailable or stale during the upgrade.
+
+	After upgrading and restarting the volume server, run node.upgrade.finish.
+`
+}
+
+func (c *commandNodeUpgradeStart) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	upgradeCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	nodeStr := upgradeCommand.String("node", "", "the volume server <host>:<port>")
+	drainTimeout := upgradeCommand.Duration("drainTimeout", time.Minute, "the time to wait for the uploads in flight")
+	if err = upgradeCommand.Parse(args); err != nil {
+		return nil
+	}
+	if *nodeStr == "" {
+		return fmt.Errorf("need to specify volume server by -node=<host>:<port>")
+	}
+
+	if err = commandEnv.confirmIsLocked(args); err != nil {
+		return
+	}
+
+	volumeServer := rpc.ServerAddress(*nodeStr)
+	grpcDialOption := commandEnv.option.GrpcDialOption
+
+	if err = markVolumeServerReadonly(grpcDialOption, volumeServer, true, writer); err != nil {
+		return err
+	}
+
+	if err = waitForUploadsInFlight(grpcDialOption, volumeServer, *drainTimeout, writer); err != nil {
+		return err
+	}
+
+	topologyInfo, _, err := collectTopologyInfo(commandEnv, 0)
+	if err != nil {
+		return err
+	}
+	if problems := checkNodeVolumeReplicas(topologyInfo, *nodeStr, writer); problems > 0 {
+		return fmt.Errorf("%d volumes on %s are not safe to upgrade", problems, volumeServer)
+	}
+
+	fmt.Fprintf(writer, "volume server %s is ready to upgrade\n", volumeServer)
+	return nil
+}
+
+type commandNodeUpgradeFinish struct {
+}
+
+func (c *commandNodeUpgradeFinish) Name() string {
+	return "node.upgrade.finish"
+}
+
+func (c *commandNodeUpgradeFinish) Help() string {
+	return `restore a volume server after an upgrade
+
+	node.upgrade.finish -node <volume server host:port> [-timeout=1m]
+
+	This command
+	1. waits until the volume server sends heartbeats to the master again,
+	2. marks the volume server writable,
+	3. verifies every volume on the volume server has healthy replicas.
+
+	Volumes marked readonly individually by volume.mark stay readonly.
+`
+}
+
+func (c *commandNodeUpgradeFinish) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	upgradeCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	nodeStr := upgradeCommand.String("node", "", "the volume server <host>:<port>")
+	timeout := upgradeCommand.Duration("timeout", time.Minute, "the time to wait for the volume server to join the cluster")
+	if err = upgradeCommand.Parse(args); err != nil {
+		return nil
+	}
+	if *nodeStr == "" {
+		return fmt.Errorf("need to specify volume server by -node=<host>:<port>")
+	}
+
+	if err = commandEnv.confirmIsLocked(args); err != nil {
+		return
+	}
+
+	volumeServer := rpc.ServerAddress(*nodeStr)
+
+	var topologyInfo *master_pb.TopologyInfo
+	for deadline := time.Now().Add(*timeout); ; {
+		if topologyInfo, _, err = collectTopologyInfo(commandEnv, 0); err != nil {
+			return err
+		}
+		if findDataNodeInfo(topologyInfo, *nodeStr) != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("volume server %s did not join the cluster in %v", volumeServer, *timeout)
+		}
+		fmt.Fprintf(writer, "waiting for volume server %s to join the cluster...\n", volumeServer)
+		time.Sleep(time.Second)
+	}
+
+	if err = markVolumeServerReadonly(commandEnv.option.GrpcDialOption, volumeServer, false, writer); err != nil {
+		return err
+	}
+
+	// wait for the volumes loaded after the restart to be reported
+	if topologyInfo, _, err = collectTopologyInfo(commandEnv, 5*time.Second); err != nil {
+		return err
+	}
+	if problems := checkNodeVolumeReplicas(topologyInfo, *nodeStr, writer); problems > 0 {
+		return fmt.Errorf("%d volumes on %s have unhealthy replicas", problems, volumeServer)
+	}
+
+	fmt.Fprintf(writer, "volume server %s is upgraded\n", volumeServer)
+	return nil
+}
+
+func waitForUploadsInFlight(grpcDialOption grpc.DialOption, volumeServer rpc.ServerAddress, timeout time.Duration, writer io.Writer) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var inFlight int64
+		err := operation.WithVolumeServerClient(false, volumeServer, grpcDialOption, func(volumeServerClient volume_server_pb.VolumeServerClient) error {
+			resp, statusErr := volumeServerClient.VolumeServerStatus(context.Background(), &volume_server_pb.VolumeServerStatusRequest{})
+			if statusErr != nil {
+				return statusErr
+			}
+			inFlight = resp.InFlightUploadDataSize
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("status of volume server %s: %v", volumeServer, err)
+		}
+		if inFlight == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("volume server %s still has %d bytes of uploads in flight after %v", volumeServer, inFlight, timeout)
+		}
+		fmt.Fprintf(writer, "waiting for %d bytes of uploads in flight on %s...\n", inFlight, volumeServer)
+		time.Sleep(time.Second)
+	}
+}
+
+func findDataNodeInfo(topologyInfo *master_pb.TopologyInfo, node string) (found *master_pb.DataNodeInfo) {
+	eachDataNode(topologyInfo, func(dc string, rack RackId, dn *master_pb.DataNodeInfo) {
+		if dn.Id == node {
+			found = dn
+		}
+	})
+	return
+}
+
+// checkNodeVolumeReplicas reports the volumes on the node without other replicas, missing replicas, or with different replicas.
+func checkNodeVolumeReplicas(topologyInfo *master_pb.TopologyInfo, node string, writer io.Writer) (problems int) {
+	volumeReplicas, _ := collectVolumeReplicaLocations(topologyInfo)
+
+	var vids []uint32
+	for vid, replicas := range volumeReplicas {
+		for _, replica := range replicas {
+			if replica.location.dataNode.Id == node {
+				vids = append(vids, vid)
+				break
+			}
+		}
+	}
+	sort.Slice(vids, func(i, j int) bool { return vids[i] < vids[j] })
+
+	for _, vid := range vids {
+		replicas := volumeReplicas[vid]
+		replicaPlacement, _ := super_block.NewReplicaPlacementFromByte(byte(replicas[0].info.ReplicaPlacement))
+		switch {
+		case len(replicas) == 1:
+			fmt.Fprintf(writer, "volume %d has no replica on other volume servers\n", vid)
+		case replicaPlacement != nil && len(replicas) < replicaPlacement.GetCopyCount():
+			fmt.Fprintf(writer, "volume %d has only %d of %d replicas\n", vid, len(replicas), replicaPlacement.GetCopyCount())
+		case !isReplicasConsistent(replicas):
+			fmt.Fprintf(writer, "volume %d has replicas of different file counts or sizes\n", vid)
+		default:
+			continue
+		}
+		problems++
+	}
+	return
+}
+
+func isReplicasConsistent(replicas []*VolumeReplica) bool {
+	for _, replica := range replicas[1:] {
+		if replica.info.FileCount != replicas[0].info.FileCount || replica.info.Size != replicas[0].info.Size {
+			return false
+		}
+	}
+	return true
+}
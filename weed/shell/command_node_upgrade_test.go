@@ -0,0 +1,53 @@
+package shell
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+)
+
+func TestCheckNodeVolumeReplicas(t *testing.T) {
+	dataNode := func(id string, volumes ...*master_pb.VolumeInformationMessage) *master_pb.DataNodeInfo {
+		return &master_pb.DataNodeInfo{
+			Id:        id,
+			DiskInfos: map[string]*master_pb.DiskInfo{"": {VolumeInfos: volumes}},
+		}
+	}
+	topologyInfo := &master_pb.TopologyInfo{
+		DataCenterInfos: []*master_pb.DataCenterInfo{{
+			Id: "dc1",
+			RackInfos: []*master_pb.RackInfo{{
+				Id: "rack1",
+				DataNodeInfos: []*master_pb.DataNodeInfo{
+					dataNode("server1:8080",
+						&master_pb.VolumeInformationMessage{Id: 1, Size: 100, FileCount: 1, ReplicaPlacement: 1},
+						&master_pb.VolumeInformationMessage{Id: 2, Size: 100, FileCount: 1},
+						&master_pb.VolumeInformationMessage{Id: 3, Size: 100, FileCount: 1, ReplicaPlacement: 2},
+						&master_pb.VolumeInformationMessage{Id: 4, Size: 100, FileCount: 1, ReplicaPlacement: 1},
+					),
+					dataNode("server2:8080",
+						&master_pb.VolumeInformationMessage{Id: 1, Size: 100, FileCount: 1, ReplicaPlacement: 1},
+						&master_pb.VolumeInformationMessage{Id: 3, Size: 100, FileCount: 1, ReplicaPlacement: 2},
+						&master_pb.VolumeInformationMessage{Id: 4, Size: 200, FileCount: 2, ReplicaPlacement: 1},
+						&master_pb.VolumeInformationMessage{Id: 5, Size: 100, FileCount: 1},
+					),
+				},
+			}},
+		}},
+	}
+
+	var buf bytes.Buffer
+	assert.Equal(t, 3, checkNodeVolumeReplicas(topologyInfo, "server1:8080", &buf))
+	assert.Equal(t, "volume 2 has no replica on other volume servers\n"+
+		"volume 3 has only 2 of 3 replicas\n"+
+		"volume 4 has replicas of different file counts or sizes\n", buf.String())
+
+	buf.Reset()
+	assert.Equal(t, 3, checkNodeVolumeReplicas(topologyInfo, "server2:8080", &buf))
+
+	assert.NotNil(t, findDataNodeInfo(topologyInfo, "server2:8080"))
+	assert.Nil(t, findDataNodeInfo(topologyInfo, "server3:8080"))
+}
@@ -0,0 +1,87 @@
+package shell
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// CommandEnvelope is the output of one command with "weed shell -json", printed as one json line.
+type CommandEnvelope struct {
+	Command  string      `json:"command"`
+	Status   string      `json:"status"`
+	Error    string      `json:"error,omitempty"`
+	Warnings []string    `json:"warnings,omitempty"`
+	Output   string      `json:"output,omitempty"`
+	Data     interface{} `json:"data,omitempty"`
+}
+
+const (
+	commandStatusOk    = "ok"
+	commandStatusError = "error"
+)
+
+// commandOutput is the writer passed to the commands with -json.
+// It collects the text output, the warnings and the structured data of the commands supporting it.
+type commandOutput struct {
+	bytes.Buffer
+	warnings []string
+	data     interface{}
+}
+
+// isJsonOutput tells whether the output of the command goes into the json envelope.
+func isJsonOutput(writer io.Writer) bool {
+	_, ok := writer.(*commandOutput)
+	return ok
+}
+
+// writeCommandData sets the structured result of the command in the json envelope.
+// It returns false for the plain text output, so the command prints the text instead.
+func writeCommandData(writer io.Writer, data interface{}) bool {
+	out, ok := writer.(*commandOutput)
+	if !ok {
+		return false
+	}
+	out.data = data
+	return true
+}
+
+// writeCommandWarning adds a warning to the json envelope, or prints it as a line of the text output.
+func writeCommandWarning(writer io.Writer, format string, a ...interface{}) {
+	if out, ok := writer.(*commandOutput); ok {
+		out.warnings = append(out.warnings, fmt.Sprintf(format, a...))
+		return
+	}
+	fmt.Fprintf(writer, format+"\n", a...)
+}
+
+// runCommandWithEnvelope runs the command and writes its output wrapped in the json envelope.
+func runCommandWithEnvelope(c command, args []string, commandEnv *CommandEnv, writer io.Writer) {
+	out := &commandOutput{}
+	err := c.Do(args, commandEnv, out)
+	envelope := &CommandEnvelope{
+		Command:  c.Name(),
+		Status:   commandStatusOk,
+		Warnings: out.warnings,
+		Output:   out.String(),
+		Data:     out.data,
+	}
+	if err != nil {
+		envelope.Status = commandStatusError
+		envelope.Error = err.Error()
+	}
+	writeCommandEnvelope(writer, envelope)
+}
+
+func writeCommandEnvelope(writer io.Writer, envelope *CommandEnvelope) {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		data, _ = json.Marshal(&CommandEnvelope{
+			Command: envelope.Command,
+			Status:  commandStatusError,
+			Error:   fmt.Sprintf("marshal output: %v", err),
+		})
+	}
+	writer.Write(append(data, '\n'))
+}
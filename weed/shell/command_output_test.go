@@ -0,0 +1,57 @@
+package shell
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testOutputCommand struct {
+	err error
+}
+
+func (c *testOutputCommand) Name() string {
+	return "test.output"
+}
+
+func (c *testOutputCommand) Help() string {
+	return "test the json envelope"
+}
+
+func (c *testOutputCommand) Do(args []string, commandEnv *CommandEnv, writer io.Writer) error {
+	fmt.Fprintf(writer, "checking %v\n", args)
+	writeCommandWarning(writer, "volume %d is readonly", 3)
+	writeCommandData(writer, map[string]int{"volumes": 2})
+	return c.err
+}
+
+func TestCommandEnvelope(t *testing.T) {
+	var buf bytes.Buffer
+	runCommandWithEnvelope(&testOutputCommand{}, []string{"-v"}, nil, &buf)
+
+	var envelope CommandEnvelope
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &envelope))
+	assert.Equal(t, "test.output", envelope.Command)
+	assert.Equal(t, commandStatusOk, envelope.Status)
+	assert.Equal(t, []string{"volume 3 is readonly"}, envelope.Warnings)
+	assert.Equal(t, "checking [-v]\n", envelope.Output)
+	assert.Equal(t, map[string]interface{}{"volumes": float64(2)}, envelope.Data)
+	assert.Equal(t, byte('\n'), buf.Bytes()[buf.Len()-1], "one envelope per line")
+
+	buf.Reset()
+	runCommandWithEnvelope(&testOutputCommand{err: fmt.Errorf("lock is lost")}, nil, nil, &buf)
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &envelope))
+	assert.Equal(t, commandStatusError, envelope.Status)
+	assert.Equal(t, "lock is lost", envelope.Error)
+}
+
+func TestCommandTextOutput(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, (&testOutputCommand{}).Do([]string{"-v"}, nil, &buf))
+	assert.Equal(t, "checking [-v]\nvolume 3 is readonly\n", buf.String())
+	assert.False(t, isJsonOutput(&buf))
+}
@@ -0,0 +1,84 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandRemoteCacheStats{})
+}
+
+type commandRemoteCacheStats struct {
+}
+
+func (c *commandRemoteCacheStats) Name() string {
+	return "remote.cache.stats"
+}
+
+func (c *commandRemoteCacheStats) Help() string {
+	return `show the cache hits and misses of reading the mounted remote storage
+
+	remote.cache.stats           # the hits and misses of each mounted directory
+	remote.cache.stats -top=20   # also list the 20 directories with the most misses of each mount
+
+	A hit is a read served from the local cluster, a miss fetches the content from the remote storage.
+	The statistics are counted by the connected filer since it started.
+	The directories with the most misses can be warmed up with remote.cache.warm.
+
+`
+}
+
+func (c *commandRemoteCacheStats) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	remoteCacheStatsCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	top := remoteCacheStatsCommand.Int("top", 5, "list this number of directories with the most misses of each mount")
+	if err = remoteCacheStatsCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	resp, err := collectRemoteCacheStatistics(commandEnv, *top)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "since %v\n", time.Unix(0, resp.SinceNs).Format(time.RFC3339))
+	if len(resp.Mounts) == 0 {
+		fmt.Fprintf(writer, "no reads of mounted remote storage\n")
+		return nil
+	}
+	for _, mount := range resp.Mounts {
+		hitRatio := float64(0)
+		if total := mount.HitCount + mount.MissCount; total > 0 {
+			hitRatio = float64(mount.HitCount) * 100 / float64(total)
+		}
+		fmt.Fprintf(writer, "%s hits:%d(%s) misses:%d(%s) hit ratio:%.1f%%\n",
+			mount.Directory,
+			mount.HitCount, util.BytesToHumanReadable(mount.HitBytes),
+			mount.MissCount, util.BytesToHumanReadable(mount.MissBytes),
+			hitRatio)
+		for _, missedDir := range mount.MissedDirectories {
+			fmt.Fprintf(writer, "    %s misses:%d(%s)\n", missedDir.Directory, missedDir.MissCount, util.BytesToHumanReadable(missedDir.MissBytes))
+		}
+	}
+	return nil
+}
+
+func collectRemoteCacheStatistics(commandEnv *CommandEnv, top int) (resp *filer_pb.RemoteCacheStatisticsResponse, err error) {
+	err = commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err = client.RemoteCacheStatistics(context.Background(), &filer_pb.RemoteCacheStatisticsRequest{
+			TopMissedDirectories: int32(top),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collect remote cache statistics: %v", err)
+	}
+	return
+}
@@ -0,0 +1,190 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandRemoteCacheWarm{})
+}
+
+type commandRemoteCacheWarm struct {
+}
+
+func (c *commandRemoteCacheWarm) Name() string {
+	return "remote.cache.warm"
+}
+
+func (c *commandRemoteCacheWarm) Help() string {
+	return `prefetch the content of hot directories of mounted remote storage, within a byte budget
+
+	# warm up the 10 directories with the most cache misses, see remote.cache.stats
+	remote.cache.warm -budget=10GiB
+	remote.cache.warm -dir=/xxx -hot=5 -budget=1GiB
+	# warm up these directories, in this order
+	remote.cache.warm -prefix=/xxx/reports/2022,/xxx/images -budget=50GiB
+
+	In each directory, the newest files are cached first, until the budget is used up.
+	Files already cached are skipped, and do not count against the budget.
+
+	To keep warming up in the background, add it to the master.maintenance scripts in master.toml.
+
+`
+}
+
+// remoteCacheWarmFile is a file not cached yet
+type remoteCacheWarmFile struct {
+	dir   util.FullPath
+	entry *filer_pb.Entry
+}
+
+func (c *commandRemoteCacheWarm) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	remoteCacheWarmCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	dir := remoteCacheWarmCommand.String("dir", "", "only warm up hot directories under this mounted directory")
+	prefixes := remoteCacheWarmCommand.String("prefix", "", "comma separated directories to warm up, instead of the hot directories")
+	hot := remoteCacheWarmCommand.Int("hot", 10, "warm up this number of directories with the most cache misses of each mount")
+	budget := remoteCacheWarmCommand.String("budget", "1GiB", "the maximum bytes to cache, e.g. 500MiB, 10GiB")
+	concurrency := remoteCacheWarmCommand.Int("concurrent", 8, "concurrent file caching")
+	if err = remoteCacheWarmCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	budgetBytes, err := util.ParseBytes(*budget)
+	if err != nil {
+		return fmt.Errorf("parse budget %s: %v", *budget, err)
+	}
+
+	var dirs []util.FullPath
+	if *prefixes != "" {
+		for _, prefix := range strings.Split(*prefixes, ",") {
+			if prefix = strings.TrimSpace(prefix); prefix != "" {
+				dirs = append(dirs, util.FullPath(prefix))
+			}
+		}
+	} else {
+		if dirs, err = collectHotRemoteDirectories(commandEnv, util.FullPath(*dir), *hot); err != nil {
+			return err
+		}
+	}
+	if len(dirs) == 0 {
+		fmt.Fprintf(writer, "no directories to warm up\n")
+		return nil
+	}
+
+	var candidates []*remoteCacheWarmFile
+	for _, d := range dirs {
+		if _, _, _, _, detectErr := detectMountInfo(commandEnv, writer, string(d)); detectErr != nil {
+			return detectErr
+		}
+		dirCandidates, listErr := collectRemoteCacheWarmFiles(commandEnv, d)
+		if listErr != nil {
+			return listErr
+		}
+		candidates = append(candidates, dirCandidates...)
+	}
+
+	files, totalBytes := selectRemoteCacheWarmFiles(candidates, budgetBytes)
+	fmt.Fprintf(writer, "warm up %d files, %s of budget %s, in %d directories\n",
+		len(files), util.BytesToHumanReadable(totalBytes), util.BytesToHumanReadable(budgetBytes), len(dirs))
+
+	return cacheRemoteCacheWarmFiles(commandEnv, writer, files, *concurrency)
+}
+
+// collectHotRemoteDirectories returns the directories with the most cache misses, counted by the filer
+func collectHotRemoteDirectories(commandEnv *CommandEnv, underDir util.FullPath, hot int) (dirs []util.FullPath, err error) {
+	resp, err := collectRemoteCacheStatistics(commandEnv, hot)
+	if err != nil {
+		return nil, err
+	}
+	for _, mount := range resp.Mounts {
+		for _, missedDir := range mount.MissedDirectories {
+			d := util.FullPath(missedDir.Directory)
+			if underDir != "" && d != underDir && !d.IsUnder(underDir) {
+				continue
+			}
+			dirs = append(dirs, d)
+		}
+	}
+	return
+}
+
+// collectRemoteCacheWarmFiles lists the files not cached yet under the directory, the newest first
+func collectRemoteCacheWarmFiles(commandEnv *CommandEnv, dir util.FullPath) (files []*remoteCacheWarmFile, err error) {
+	err = recursivelyTraverseDirectory(commandEnv, dir, func(parent util.FullPath, entry *filer_pb.Entry) bool {
+		if shouldCacheToLocal(entry) {
+			files = append(files, &remoteCacheWarmFile{dir: parent, entry: entry})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %v", dir, err)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].entry.RemoteEntry.RemoteMtime > files[j].entry.RemoteEntry.RemoteMtime
+	})
+	return
+}
+
+// selectRemoteCacheWarmFiles picks the files in order, skipping the ones not fitting in the remaining budget.
+// A file in more than one listed directory is only picked once.
+func selectRemoteCacheWarmFiles(candidates []*remoteCacheWarmFile, budget uint64) (files []*remoteCacheWarmFile, totalBytes uint64) {
+	picked := make(map[util.FullPath]bool)
+	for _, candidate := range candidates {
+		fullPath := candidate.dir.Child(candidate.entry.Name)
+		if picked[fullPath] {
+			continue
+		}
+		size := uint64(candidate.entry.RemoteEntry.RemoteSize)
+		if totalBytes+size > budget {
+			continue
+		}
+		picked[fullPath] = true
+		files = append(files, candidate)
+		totalBytes += size
+	}
+	return
+}
+
+func cacheRemoteCacheWarmFiles(commandEnv *CommandEnv, writer io.Writer, files []*remoteCacheWarmFile, concurrency int) error {
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var executionErr error
+	limitedConcurrentExecutor := util.NewLimitedConcurrentExecutor(concurrency)
+	for _, file := range files {
+		wg.Add(1)
+		file := file
+		limitedConcurrentExecutor.Execute(func() {
+			defer wg.Done()
+			fullPath := file.dir.Child(file.entry.Name)
+			err := commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+				_, err := client.CacheRemoteObjectToLocalCluster(context.Background(), &filer_pb.CacheRemoteObjectToLocalClusterRequest{
+					Directory: string(file.dir),
+					Name:      file.entry.Name,
+				})
+				return err
+			})
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				fmt.Fprintf(writer, "cache %s: %v\n", fullPath, err)
+				if executionErr == nil {
+					executionErr = fmt.Errorf("cache %s: %v", fullPath, err)
+				}
+				return
+			}
+			fmt.Fprintf(writer, "cached %s %s\n", fullPath, util.BytesToHumanReadable(uint64(file.entry.RemoteEntry.RemoteSize)))
+		})
+	}
+	wg.Wait()
+	return executionErr
+}
@@ -0,0 +1,35 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestSelectRemoteCacheWarmFiles(t *testing.T) {
+	file := func(dir, name string, size int64) *remoteCacheWarmFile {
+		return &remoteCacheWarmFile{dir: util.FullPath(dir), entry: &filer_pb.Entry{
+			Name:        name,
+			RemoteEntry: &filer_pb.RemoteEntry{RemoteSize: size},
+		}}
+	}
+	candidates := []*remoteCacheWarmFile{
+		file("/mnt/a", "1", 40),
+		file("/mnt/a", "2", 50),
+		file("/mnt/a", "3", 10),
+		file("/mnt/a", "1", 40),
+		file("/mnt/a", "4", 5),
+	}
+	files, totalBytes := selectRemoteCacheWarmFiles(candidates, 60)
+	var names []string
+	for _, f := range files {
+		names = append(names, f.entry.Name)
+	}
+	if len(names) != 3 || names[0] != "1" || names[1] != "3" || names[2] != "4" {
+		t.Errorf("expected files 1, 3 and 4 within the budget, got %v", names)
+	}
+	if totalBytes != 55 {
+		t.Errorf("expected 55 bytes, got %d", totalBytes)
+	}
+}
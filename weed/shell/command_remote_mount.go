@@ -40,6 +40,8 @@ func (c *commandRemoteMount) Help() string {
 	# mount and pull one directory in the bucket
 	remote.mount -dir=/xxx -remote=cloud1/bucket/dir1
 
+	The credentials and the remote location are checked before mounting, unless -skipCheck is set.
+
 	# after mount, start a separate process to write updates to remote storage
 	weed filer.remote.sync -filer=<filerHost>:<filerPort> -dir=/xxx
 
@@ -52,6 +54,7 @@ func (c *commandRemoteMount) Do(args []string, commandEnv *CommandEnv, writer io
 
 	dir := remoteMountCommand.String("dir", "", "a directory in filer")
 	nonEmpty := remoteMountCommand.Bool("nonempty", false, "allows the mounting over a non-empty directory")
+	skipCheck := remoteMountCommand.Bool("skipCheck", false, "skip checking the credentials and the remote location before mounting")
 	remote := remoteMountCommand.String("remote", "", "a directory in remote storage, ex. <storageName>/<bucket>/path/to/dir")
 
 	if err = remoteMountCommand.Parse(args); err != nil {
@@ -74,6 +77,13 @@ func (c *commandRemoteMount) Do(args []string, commandEnv *CommandEnv, writer io
 		return err
 	}
 
+	// fail early on wrong credentials or a missing bucket, before creating the mount directory
+	if !*skipCheck {
+		if err = checkRemoteStorageLocation(remoteConf, remoteStorageLocation); err != nil {
+			return err
+		}
+	}
+
 	// sync metadata from remote
 	if err = syncMetadata(commandEnv, writer, *dir, *nonEmpty, remoteConf, remoteStorageLocation); err != nil {
 		return fmt.Errorf("pull metadata: %v", err)
@@ -87,6 +97,17 @@ func (c *commandRemoteMount) Do(args []string, commandEnv *CommandEnv, writer io
 	return nil
 }
 
+func checkRemoteStorageLocation(remoteConf *remote_pb.RemoteConf, remoteStorageLocation *remote_pb.RemoteStorageLocation) error {
+	client, err := remote_storage.GetRemoteStorage(remoteConf)
+	if err != nil {
+		return err
+	}
+	if err = client.CheckLocation(remoteStorageLocation); err != nil {
+		return fmt.Errorf("check %s: %v", remote_storage.FormatLocation(remoteStorageLocation), err)
+	}
+	return nil
+}
+
 func listExistingRemoteStorageMounts(commandEnv *CommandEnv, writer io.Writer) (mappings *remote_pb.RemoteStorageMapping, err error) {
 
 	// read current mapping
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 )
 
 func init() {
@@ -38,8 +39,8 @@ func (c *commandS3BucketCreate) Do(args []string, commandEnv *CommandEnv, writer
 		return nil
 	}
 
-	if *bucketName == "" {
-		return fmt.Errorf("empty bucket name")
+	if err = s3_constants.VerifyBucketName(*bucketName); err != nil {
+		return fmt.Errorf("invalid bucket name %s: %v", *bucketName, err)
 	}
 
 	err = commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
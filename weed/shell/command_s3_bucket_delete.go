@@ -1,12 +1,12 @@
 package shell
 
 import (
-	"context"
 	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
 	"io"
 
+	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 )
 
@@ -53,10 +53,7 @@ func (c *commandS3BucketDelete) Do(args []string, commandEnv *CommandEnv, writer
 
 	// delete the collection directly first
 	err = commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
-		_, err = client.CollectionDelete(context.Background(), &master_pb.CollectionDeleteRequest{
-			Name: *bucketName,
-		})
-		return err
+		return operation.DeleteCollection(client, *bucketName)
 	})
 	if err != nil {
 		return
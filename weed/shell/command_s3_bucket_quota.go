@@ -5,8 +5,10 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"strconv"
 
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 )
 
 func init() {
@@ -25,6 +27,13 @@ func (c *commandS3BucketQuota) Help() string {
 
 	Example:
 		s3.bucket.quota -name=<bucket_name> -op=set -sizeMB=1024
+		s3.bucket.quota -name=<bucket_name> -op=set -sizeMB=1024 -objects=1000000 -warnPercent=80
+
+	The S3 gateways reject the writes to a bucket over its quota with QuotaExceeded,
+	and log a warning once the usage passes the warning percentage, 90% by default.
+	The usage is the live bytes and files of the bucket collection, read from the master about every minute.
+	The files are the stored chunks, so a large object of many chunks counts as many files,
+	and the parts of the pending multipart uploads count too. Overwriting an object takes no more of the object count.
 `
 }
 
@@ -34,6 +43,8 @@ func (c *commandS3BucketQuota) Do(args []string, commandEnv *CommandEnv, writer
 	bucketName := bucketCommand.String("name", "", "bucket name")
 	operationName := bucketCommand.String("op", "set", "operation name [set|get|remove|enable|disable]")
 	sizeMB := bucketCommand.Int64("sizeMB", 0, "bucket quota size in MiB")
+	objects := bucketCommand.Int64("objects", 0, "bucket quota on the object count, 0 for no limit")
+	warnPercent := bucketCommand.Int64("warnPercent", 0, "warn when the usage passes this percentage of the quota, 0 for the default")
 	if err = bucketCommand.Parse(args); err != nil {
 		return nil
 	}
@@ -64,11 +75,24 @@ func (c *commandS3BucketQuota) Do(args []string, commandEnv *CommandEnv, writer
 		switch *operationName {
 		case "set":
 			bucketEntry.Quota = *sizeMB * 1024 * 1024
+			if bucketEntry.Extended == nil {
+				bucketEntry.Extended = make(map[string][]byte)
+			}
+			setOrDeleteQuotaAttribute(bucketEntry.Extended, s3_constants.X_SeaweedFS_Quota_Objects, *objects)
+			setOrDeleteQuotaAttribute(bucketEntry.Extended, s3_constants.X_SeaweedFS_Quota_Warning_Percent, *warnPercent)
 		case "get":
 			fmt.Fprintf(writer, "bucket quota: %dMiB \n", bucketEntry.Quota/1024/1024)
+			if objects, found := bucketEntry.Extended[s3_constants.X_SeaweedFS_Quota_Objects]; found {
+				fmt.Fprintf(writer, "bucket object quota: %s \n", objects)
+			}
+			if percent, found := bucketEntry.Extended[s3_constants.X_SeaweedFS_Quota_Warning_Percent]; found {
+				fmt.Fprintf(writer, "bucket quota warning: %s%% \n", percent)
+			}
 			return nil
 		case "remove":
 			bucketEntry.Quota = 0
+			delete(bucketEntry.Extended, s3_constants.X_SeaweedFS_Quota_Objects)
+			delete(bucketEntry.Extended, s3_constants.X_SeaweedFS_Quota_Warning_Percent)
 		case "enable":
 			if bucketEntry.Quota < 0 {
 				bucketEntry.Quota = -bucketEntry.Quota
@@ -95,3 +119,11 @@ func (c *commandS3BucketQuota) Do(args []string, commandEnv *CommandEnv, writer
 	return err
 
 }
+
+func setOrDeleteQuotaAttribute(extended map[string][]byte, key string, value int64) {
+	if value > 0 {
+		extended[key] = []byte(strconv.FormatInt(value, 10))
+	} else {
+		delete(extended, key)
+	}
+}
@@ -0,0 +1,188 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/cluster"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const s3Type = "s3"
+
+func init() {
+	Commands = append(Commands, &commandServerLogLevel{})
+}
+
+type commandServerLogLevel struct {
+}
+
+func (c *commandServerLogLevel) Name() string {
+	return "server.loglevel"
+}
+
+func (c *commandServerLogLevel) Help() string {
+	return `show or change the log levels of the servers at runtime
+
+	server.loglevel                                  # show the log levels of all masters, volume servers and filers
+	server.loglevel v=1                              # set the -v level of all of them
+	server.loglevel -nodeType=filer filer=3          # log the filer component at level 3 on all filers
+	server.loglevel -volumeServer=<host>:<port> volume=2 v=0
+	server.loglevel -master=<host>:<port> master=0   # turn off the master component level
+	server.loglevel -s3=<host>:<port> s3=3
+
+	The levels are name=N, with v for the -v level or one of the components of -vcomponent,
+	which are master, volume, filer and s3. A component level only raises the verbosity of the files
+	of the component above the -v level, and the components not listed keep their levels.
+	The servers running in one "weed server" process share the log levels.
+	The s3 gateways are not registered in the master, so only the one of -s3 is changed.
+	Changing the levels needs the jwt.master_admin.key of security.toml, the same on the shell and the servers.
+
+`
+}
+
+func (c *commandServerLogLevel) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	logLevelCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	nodeType := logLevelCommand.String("nodeType", "", "only the nodes of the type: master, volumeServer or filer")
+	master := logLevelCommand.String("master", "", "only the master <host>:<port>")
+	volumeServer := logLevelCommand.String("volumeServer", "", "only the volume server <host>:<port>")
+	filer := logLevelCommand.String("filer", "", "only the filer <host>:<port>")
+	s3 := logLevelCommand.String("s3", "", "only the s3 gateway <host>:<port>, with the grpc port at port+10000")
+	if err = logLevelCommand.Parse(args); err != nil {
+		return nil
+	}
+	levels := strings.Join(logLevelCommand.Args(), ",")
+
+	var nodes []logLevelNode
+	switch {
+	case *master != "":
+		nodes = append(nodes, logLevelNode{cluster.MasterType, rpc.ServerAddress(*master)})
+	case *volumeServer != "":
+		nodes = append(nodes, logLevelNode{cluster.VolumeServerType, rpc.ServerAddress(*volumeServer)})
+	case *filer != "":
+		nodes = append(nodes, logLevelNode{cluster.FilerType, rpc.ServerAddress(*filer)})
+	case *s3 != "":
+		nodes = append(nodes, logLevelNode{s3Type, rpc.ServerAddress(*s3)})
+	default:
+		if nodes, err = collectLogLevelNodes(commandEnv, *nodeType); err != nil {
+			return err
+		}
+	}
+
+	var failed int
+	for _, node := range nodes {
+		currentLevels, setErr := setServerLogLevel(commandEnv.option.GrpcDialOption, node, levels)
+		if setErr != nil {
+			fmt.Fprintf(writer, "%s %s: %v\n", node.nodeType, node.address, setErr)
+			failed++
+			continue
+		}
+		fmt.Fprintf(writer, "%s %s: %s\n", node.nodeType, node.address, currentLevels)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed on %d of %d servers", failed, len(nodes))
+	}
+	return nil
+}
+
+type logLevelNode struct {
+	nodeType string
+	address  rpc.ServerAddress
+}
+
+func collectLogLevelNodes(commandEnv *CommandEnv, nodeType string) (nodes []logLevelNode, err error) {
+	switch nodeType {
+	case "", cluster.MasterType, cluster.VolumeServerType, cluster.FilerType:
+	default:
+		return nil, fmt.Errorf("unknown node type %s, expect %s, %s or %s", nodeType, cluster.MasterType, cluster.VolumeServerType, cluster.FilerType)
+	}
+
+	if nodeType == "" || nodeType == cluster.MasterType {
+		for _, master := range commandEnv.MasterClient.GetMasters() {
+			nodes = append(nodes, logLevelNode{cluster.MasterType, master})
+		}
+	}
+
+	if nodeType == "" || nodeType == cluster.VolumeServerType {
+		topologyInfo, _, err := collectTopologyInfo(commandEnv, 0)
+		if err != nil {
+			return nil, err
+		}
+		eachDataNode(topologyInfo, func(dc string, rack RackId, dn *master_pb.DataNodeInfo) {
+			nodes = append(nodes, logLevelNode{cluster.VolumeServerType, rpc.NewServerAddressFromDataNode(dn)})
+		})
+	}
+
+	if nodeType == "" || nodeType == cluster.FilerType {
+		err = commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
+			resp, err := client.ListClusterNodes(context.Background(), &master_pb.ListClusterNodesRequest{
+				ClientType: cluster.FilerType,
+				FilerGroup: *commandEnv.option.FilerGroup,
+			})
+			if err != nil {
+				return err
+			}
+			for _, node := range resp.ClusterNodes {
+				nodes = append(nodes, logLevelNode{cluster.FilerType, rpc.ServerAddress(node.Address)})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("list filers: %v", err)
+		}
+	}
+
+	return nodes, nil
+}
+
+// setServerLogLevel changes the log levels of the server, or only reads them if the levels are empty
+func setServerLogLevel(grpcDialOption grpc.DialOption, node logLevelNode, levels string) (currentLevels string, err error) {
+	ctx := security.WithMasterAdminJwt(context.Background(), security.SigningKey(util.GetViper().GetString("jwt.master_admin.key")))
+	switch node.nodeType {
+	case cluster.MasterType:
+		err = operation.WithMasterServerClient(false, node.address, grpcDialOption, func(client master_pb.SeaweedClient) error {
+			resp, setErr := client.SetLogLevel(ctx, &master_pb.SetLogLevelRequest{Levels: levels})
+			if setErr == nil {
+				currentLevels = resp.Levels
+			}
+			return setErr
+		})
+	case cluster.VolumeServerType:
+		err = operation.WithVolumeServerClient(false, node.address, grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
+			resp, setErr := client.SetLogLevel(ctx, &volume_server_pb.SetLogLevelRequest{Levels: levels})
+			if setErr == nil {
+				currentLevels = resp.Levels
+			}
+			return setErr
+		})
+	case cluster.FilerType:
+		err = rpc.WithFilerClient(false, node.address, grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+			resp, setErr := client.SetLogLevel(ctx, &filer_pb.SetLogLevelRequest{Levels: levels})
+			if setErr == nil {
+				currentLevels = resp.Levels
+			}
+			return setErr
+		})
+	case s3Type:
+		err = rpc.WithGrpcClient(false, func(grpcConnection *grpc.ClientConn) error {
+			resp, setErr := rpc.NewS3Client(grpcConnection).SetLogLevel(ctx, &rpc.SetLogLevelRequest{Levels: levels})
+			if setErr == nil {
+				currentLevels = resp.Levels
+			}
+			return setErr
+		}, node.address.ToGrpcAddress(), false, grpcDialOption)
+	}
+	return
+}
@@ -0,0 +1,98 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+)
+
+func init() {
+	Commands = append(Commands, &commandServerSlowlog{})
+}
+
+type commandServerSlowlog struct {
+}
+
+func (c *commandServerSlowlog) Name() string {
+	return "server.slowlog"
+}
+
+func (c *commandServerSlowlog) Help() string {
+	return `show the recent slow requests of a volume server, filer or s3 gateway, the oldest first
+
+	server.slowlog -volumeServer=<host>:<port> [-n=20]
+	server.slowlog -filer=<host>:<port> [-n=20]
+	server.slowlog -s3=<host>:<port> [-n=20]
+
+	Each line is the json of a request slower than the threshold of its api, set on the server by
+	-slowLog.thresholds, e.g. "default=1s,GET=200ms". The servers also write the slow requests to their logs.
+
+`
+}
+
+func (c *commandServerSlowlog) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	slowlogCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	volumeServer := slowlogCommand.String("volumeServer", "", "the volume server <host>:<port>")
+	filer := slowlogCommand.String("filer", "", "the filer <host>:<port>")
+	s3 := slowlogCommand.String("s3", "", "the s3 gateway <host>:<port>, with the grpc port at port+10000")
+	limit := slowlogCommand.Int("n", 20, "the number of the latest slow requests, 0 for all the kept ones")
+	if err = slowlogCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	requests, err := fetchSlowRequests(commandEnv.option.GrpcDialOption, *volumeServer, *filer, *s3, int32(*limit))
+	if err != nil {
+		return err
+	}
+	if len(requests) == 0 {
+		fmt.Fprintf(writer, "no slow requests\n")
+	}
+	for _, request := range requests {
+		fmt.Fprintln(writer, request)
+	}
+	return nil
+}
+
+func fetchSlowRequests(grpcDialOption grpc.DialOption, volumeServer, filer, s3 string, limit int32) (requests []string, err error) {
+	switch {
+	case volumeServer != "" && filer == "" && s3 == "":
+		err = operation.WithVolumeServerClient(false, rpc.ServerAddress(volumeServer), grpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
+			resp, slowErr := client.SlowRequests(context.Background(), &volume_server_pb.SlowRequestsRequest{Limit: limit})
+			if slowErr == nil {
+				requests = resp.Requests
+			}
+			return slowErr
+		})
+	case filer != "" && volumeServer == "" && s3 == "":
+		err = rpc.WithFilerClient(false, rpc.ServerAddress(filer), grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+			resp, slowErr := client.SlowRequests(context.Background(), &filer_pb.SlowRequestsRequest{Limit: limit})
+			if slowErr == nil {
+				requests = resp.Requests
+			}
+			return slowErr
+		})
+	case s3 != "" && volumeServer == "" && filer == "":
+		err = rpc.WithGrpcClient(false, func(grpcConnection *grpc.ClientConn) error {
+			resp, slowErr := rpc.NewS3Client(grpcConnection).SlowRequests(context.Background(), &rpc.SlowRequestsRequest{Limit: limit})
+			if slowErr == nil {
+				requests = resp.Requests
+			}
+			return slowErr
+		}, rpc.ServerAddress(s3).ToGrpcAddress(), false, grpcDialOption)
+	default:
+		return nil, fmt.Errorf("use one of -volumeServer, -filer or -s3")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("slow requests: %v", err)
+	}
+	return requests, nil
+}
@@ -4,8 +4,10 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/storage/types"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
 )
 
@@ -187,10 +189,45 @@ func TestBalance(t *testing.T) {
 func TestVolumeSelection(t *testing.T) {
 	topologyInfo := parseOutput(topoData)
 
-	vids, err := collectVolumeIdsForTierChange(nil, topologyInfo, 1000, types.ToDiskType("hdd"), "", 20.0, 0)
+	vids, err := collectVolumeIdsForTierChange(nil, topologyInfo, 1000, types.ToDiskType("hdd"), "", 20.0, 0, 0, 0)
 	if err != nil {
 		t.Errorf("collectVolumeIdsForTierChange: %v", err)
 	}
 	assert.Equal(t, 378, len(vids))
 
 }
+
+func TestVolumeSelectionByAccessAge(t *testing.T) {
+	now := time.Now().Unix()
+	day := int64(24 * 3600)
+	volume := func(id uint32, diskType string, modifiedAt, readAt int64) *master_pb.VolumeInformationMessage {
+		return &master_pb.VolumeInformationMessage{Id: id, Size: 1000 * 1024 * 1024, DiskType: diskType, ModifiedAtSecond: modifiedAt, LastReadAtSecond: readAt}
+	}
+	dataNode := func(id string, diskType string, volumes ...*master_pb.VolumeInformationMessage) *master_pb.DataNodeInfo {
+		return &master_pb.DataNodeInfo{Id: id, DiskInfos: map[string]*master_pb.DiskInfo{diskType: {Type: diskType, VolumeInfos: volumes}}}
+	}
+	topologyInfo := &master_pb.TopologyInfo{DataCenterInfos: []*master_pb.DataCenterInfo{{Id: "dc1", RackInfos: []*master_pb.RackInfo{{Id: "rack1", DataNodeInfos: []*master_pb.DataNodeInfo{
+		dataNode("dn1", "ssd",
+			volume(1, "ssd", now-50*day, now-40*day), // cold
+			volume(2, "ssd", now-50*day, now-40*day), // read recently on the other replica
+			volume(3, "ssd", now-50*day, 0),          // never read
+			volume(4, "ssd", now-10*day, 0),          // written recently
+		),
+		dataNode("dn2", "ssd",
+			volume(2, "ssd", now-50*day, now-3600),
+		),
+		dataNode("dn3", "",
+			volume(5, "", now-50*day, now-3600), // hot
+			volume(6, "", now-50*day, now-40*day),
+			volume(7, "", now-50*day, 0),
+		),
+	}}}}}}
+
+	vids, err := collectVolumeIdsForTierChange(nil, topologyInfo, 1000, types.SsdType, "", 95, 0, 30*24*time.Hour, 0)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []needle.VolumeId{1, 3}, vids)
+
+	vids, err = collectVolumeIdsForTierChange(nil, topologyInfo, 1000, types.HardDriveType, "", 95, 24*time.Hour, 0, 24*time.Hour)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []needle.VolumeId{5}, vids)
+}
@@ -30,25 +30,48 @@ func (c *commandVolumeConfigureReplication) Name() string {
 func (c *commandVolumeConfigureReplication) Help() string {
 	return `change volume replication value
 
-	This command changes a volume replication value. It should be followed by "volume.fix.replication".
+	volume.configure.replication -replication 001 -volumeId 7
+	volume.configure.replication -replication 010 -collectionPattern "logs*"
+
+	This command changes a volume replication value. It also records the replication in the master,
+	which runs "volume.configure.replication" and "volume.fix.replication" every master.replication.fix_interval_minutes,
+	until all the volumes have the replication and enough replicas. The volumes created meanwhile are changed too.
+	Use -autoFix=false to only change the current volumes, and follow with "volume.fix.replication".
+
+	volume.configure.replication -list                             # list the replications not reached yet
+	volume.configure.replication -cancel -collectionPattern "logs*" # stop fixing the volumes
 
 `
 }
 
-func (c *commandVolumeConfigureReplication) Do(args []string, commandEnv *CommandEnv, _ io.Writer) (err error) {
+func (c *commandVolumeConfigureReplication) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
 	configureReplicationCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
 	volumeIdInt := configureReplicationCommand.Int("volumeId", 0, "the volume id")
 	replicationString := configureReplicationCommand.String("replication", "", "the intended replication value")
 	collectionPattern := configureReplicationCommand.String("collectionPattern", "", "match with wildcard characters '*' and '?'")
+	autoFix := configureReplicationCommand.Bool("autoFix", true, "let the master fix the replicas until all volumes reach the replication")
+	listTargets := configureReplicationCommand.Bool("list", false, "list the replications the master is still fixing")
+	cancelTarget := configureReplicationCommand.Bool("cancel", false, "stop fixing the replication of the volume or the collection pattern")
 	if err = configureReplicationCommand.Parse(args); err != nil {
 		return nil
 	}
 
+	if *listTargets {
+		return listReplicationTargets(commandEnv, writer)
+	}
+
 	if err = commandEnv.confirmIsLocked(args); err != nil {
 		return
 	}
 
+	if *cancelTarget {
+		return setReplicationTarget(commandEnv, &master_pb.ReplicationTarget{
+			VolumeId:          uint32(*volumeIdInt),
+			CollectionPattern: *collectionPattern,
+		}, true)
+	}
+
 	if *replicationString == "" {
 		return fmt.Errorf("empty replication value")
 	}
@@ -99,8 +122,59 @@ func (c *commandVolumeConfigureReplication) Do(args []string, commandEnv *Comman
 			return
 		}
 	})
+	if err != nil {
+		return err
+	}
+
+	if *autoFix {
+		err = setReplicationTarget(commandEnv, &master_pb.ReplicationTarget{
+			VolumeId:          uint32(vid),
+			CollectionPattern: *collectionPattern,
+			Replication:       replicaPlacement.String(),
+		}, false)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(writer, "the master fixes the replicas until all volumes reach replication %s\n", replicaPlacement)
+	}
+
+	return nil
+}
 
-	return err
+func setReplicationTarget(commandEnv *CommandEnv, target *master_pb.ReplicationTarget, isDelete bool) error {
+	err := commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
+		_, err := client.SetReplicationTarget(context.Background(), &master_pb.SetReplicationTargetRequest{
+			Target:   target,
+			IsDelete: isDelete,
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("set replication target: %v", err)
+	}
+	return nil
+}
+
+func listReplicationTargets(commandEnv *CommandEnv, writer io.Writer) error {
+	var resp *master_pb.GetReplicationTargetsResponse
+	err := commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) (err error) {
+		resp, err = client.GetReplicationTargets(context.Background(), &master_pb.GetReplicationTargetsRequest{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("get replication targets: %v", err)
+	}
+	if len(resp.Targets) == 0 {
+		fmt.Fprintf(writer, "all volumes reached their replication\n")
+	}
+	for _, target := range resp.Targets {
+		if target.VolumeId != 0 {
+			fmt.Fprintf(writer, "volume %d: replication %s\n", target.VolumeId, target.Replication)
+		} else {
+			fmt.Fprintf(writer, "collections %q: replication %s\n", target.CollectionPattern, target.Replication)
+		}
+	}
+	return nil
 }
 
 func getVolumeFilter(replicaPlacement *super_block.ReplicaPlacement, volumeId uint32, collectionPattern string) func(message *master_pb.VolumeInformationMessage) bool {
@@ -115,6 +189,6 @@ func getVolumeFilter(replicaPlacement *super_block.ReplicaPlacement, volumeId ui
 		if err != nil {
 			return false
 		}
-		return matched
+		return matched && v.ReplicaPlacement != replicaPlacementInt32
 	}
 }
@@ -1,14 +1,26 @@
 package shell
 
 import (
+	"bytes"
+	"context"
 	"flag"
+	"fmt"
 	"io"
 	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
 	"time"
 
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
 )
 
 func init() {
@@ -26,16 +38,29 @@ func (c *commandVolumeDeleteEmpty) Help() string {
 	return `delete empty volumes from all volume servers
 
 	volume.deleteEmpty -quietFor=24h -force
+	volume.deleteEmpty -quietFor=24h -backupDir=/data/empty_volumes -force
+
+	This command deletes the empty volumes from all volume servers.
 
-	This command deletes all empty volumes from one volume server.
+	A volume is only deleted when all its replicas are reported, all of them are empty,
+	and none of them was written within the quiet period.
+	Before deleting, the replicas are marked readonly and checked again on the volume servers,
+	so a write landing after the topology was collected keeps the volume, and the .idx file of
+	each replica is saved to the backup directory.
 
 `
 }
 
+type emptyVolumeReplica struct {
+	server rpc.ServerAddress
+	info   *master_pb.VolumeInformationMessage
+}
+
 func (c *commandVolumeDeleteEmpty) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
 	volDeleteCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
 	quietPeriod := volDeleteCommand.Duration("quietFor", 24*time.Hour, "select empty volumes with no recent writes, avoid newly created ones")
+	backupDir := volDeleteCommand.String("backupDir", filepath.Join(os.TempDir(), "empty_volumes"), "the directory to save the .idx files of the deleted volumes")
 	applyBalancing := volDeleteCommand.Bool("force", false, "apply to delete empty volumes")
 	if err = volDeleteCommand.Parse(args); err != nil {
 		return nil
@@ -52,26 +77,150 @@ func (c *commandVolumeDeleteEmpty) Do(args []string, commandEnv *CommandEnv, wri
 		return err
 	}
 
-	quietSeconds := int64(*quietPeriod / time.Second)
-	nowUnixSeconds := time.Now().Unix()
+	emptyVolumes := collectEmptyVolumes(topologyInfo, int64(*quietPeriod/time.Second), time.Now().Unix(), writer)
+
+	if *applyBalancing && len(emptyVolumes) > 0 {
+		if err = os.MkdirAll(*backupDir, 0755); err != nil {
+			return fmt.Errorf("create backup directory %s: %v", *backupDir, err)
+		}
+	}
+
+	for _, replicas := range emptyVolumes {
+		vid := needle.VolumeId(replicas[0].info.Id)
+		if !*applyBalancing {
+			for _, replica := range replicas {
+				log.Printf("empty volume %d from %s", vid, replica.server)
+			}
+			continue
+		}
+		if deleteErr := deleteEmptyVolume(commandEnv, vid, replicas, *backupDir); deleteErr != nil {
+			fmt.Fprintf(writer, "volume %d: %v\n", vid, deleteErr)
+			err = deleteErr
+		}
+	}
+
+	return
+}
 
+// collectEmptyVolumes groups the replicas by volume, keeping the volumes with all replicas reported, empty and quiet.
+func collectEmptyVolumes(topologyInfo *master_pb.TopologyInfo, quietSeconds, nowUnixSeconds int64, writer io.Writer) (emptyVolumes [][]*emptyVolumeReplica) {
+
+	volumeReplicas := make(map[uint32][]*emptyVolumeReplica)
 	eachDataNode(topologyInfo, func(dc string, rack RackId, dn *master_pb.DataNodeInfo) {
 		for _, diskInfo := range dn.DiskInfos {
 			for _, v := range diskInfo.VolumeInfos {
-				if v.Size <= 8 && v.ModifiedAtSecond+quietSeconds < nowUnixSeconds {
-					if *applyBalancing {
-						log.Printf("deleting empty volume %d from %s", v.Id, dn.Id)
-						if deleteErr := deleteVolume(commandEnv.option.GrpcDialOption, needle.VolumeId(v.Id), rpc.NewServerAddressFromDataNode(dn)); deleteErr != nil {
-							err = deleteErr
-						}
-						continue
-					} else {
-						log.Printf("empty volume %d from %s", v.Id, dn.Id)
-					}
-				}
+				volumeReplicas[v.Id] = append(volumeReplicas[v.Id], &emptyVolumeReplica{
+					server: rpc.NewServerAddressFromDataNode(dn),
+					info:   v,
+				})
 			}
 		}
 	})
 
+	var vids []uint32
+	for vid := range volumeReplicas {
+		vids = append(vids, vid)
+	}
+	sort.Slice(vids, func(i, j int) bool { return vids[i] < vids[j] })
+
+	for _, vid := range vids {
+		replicas := volumeReplicas[vid]
+		isEmpty, isQuiet := true, true
+		for _, replica := range replicas {
+			if replica.info.Size > super_block.EmptyVolumeMaxSize || replica.info.FileCount > 0 {
+				isEmpty = false
+			}
+			if replica.info.ModifiedAtSecond+quietSeconds >= nowUnixSeconds {
+				isQuiet = false
+			}
+		}
+		if !isEmpty {
+			continue
+		}
+		if !isQuiet {
+			fmt.Fprintf(writer, "skip empty volume %d: written within the quiet period\n", vid)
+			continue
+		}
+		replicaPlacement, _ := super_block.NewReplicaPlacementFromByte(byte(replicas[0].info.ReplicaPlacement))
+		if replicaPlacement != nil && len(replicas) < replicaPlacement.GetCopyCount() {
+			fmt.Fprintf(writer, "skip empty volume %d: only %d of %d replicas reported\n", vid, len(replicas), replicaPlacement.GetCopyCount())
+			continue
+		}
+		emptyVolumes = append(emptyVolumes, replicas)
+	}
 	return
 }
+
+// deleteEmptyVolume stops the writes to all replicas, confirms they are still empty, saves their index files, and deletes them.
+func deleteEmptyVolume(commandEnv *CommandEnv, vid needle.VolumeId, replicas []*emptyVolumeReplica, backupDir string) (err error) {
+	grpcDialOption := commandEnv.option.GrpcDialOption
+
+	for _, replica := range replicas {
+		if err = markVolumeWritable(grpcDialOption, vid, replica.server, false); err != nil {
+			err = fmt.Errorf("mark readonly on %s: %v", replica.server, err)
+			break
+		}
+	}
+	if err == nil {
+		for _, replica := range replicas {
+			if err = backupEmptyVolumeIndex(grpcDialOption, vid, replica, backupDir); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		// keep the volume as it was
+		for _, replica := range replicas {
+			if !replica.info.ReadOnly {
+				if markErr := markVolumeWritable(grpcDialOption, vid, replica.server, true); markErr != nil {
+					log.Printf("restore volume %d writable on %s: %v", vid, replica.server, markErr)
+				}
+			}
+		}
+		return err
+	}
+
+	for _, replica := range replicas {
+		log.Printf("deleting empty volume %d from %s", vid, replica.server)
+		if deleteErr := deleteVolume(grpcDialOption, vid, replica.server); deleteErr != nil {
+			err = fmt.Errorf("delete from %s: %v", replica.server, deleteErr)
+		}
+	}
+	return err
+}
+
+// backupEmptyVolumeIndex checks the replica on the volume server is still empty, and saves its .idx file to the backup directory.
+func backupEmptyVolumeIndex(grpcDialOption grpc.DialOption, vid needle.VolumeId, replica *emptyVolumeReplica, backupDir string) error {
+	return operation.WithVolumeServerClient(true, replica.server, grpcDialOption, func(volumeServerClient volume_server_pb.VolumeServerClient) error {
+		status, err := volumeServerClient.ReadVolumeFileStatus(context.Background(), &volume_server_pb.ReadVolumeFileStatusRequest{
+			VolumeId: uint32(vid),
+		})
+		if err != nil {
+			return fmt.Errorf("read status on %s: %v", replica.server, err)
+		}
+		if status.DatFileSize > super_block.EmptyVolumeMaxSize || status.FileCount > 0 {
+			return fmt.Errorf("not empty any more on %s: %d files, %d bytes", replica.server, status.FileCount, status.DatFileSize)
+		}
+
+		copyFileClient, err := volumeServerClient.CopyFile(context.Background(), &volume_server_pb.CopyFileRequest{
+			VolumeId:           uint32(vid),
+			Ext:                ".idx",
+			CompactionRevision: math.MaxUint32,
+			StopOffset:         math.MaxInt64,
+			Collection:         replica.info.Collection,
+		})
+		if err != nil {
+			return fmt.Errorf("copy .idx from %s: %v", replica.server, err)
+		}
+		var buf bytes.Buffer
+		if err = writeToBuffer(copyFileClient, &buf); err != nil {
+			return fmt.Errorf("copy .idx from %s: %v", replica.server, err)
+		}
+
+		name := fmt.Sprintf("%d.%s.idx", vid, replica.server)
+		if replica.info.Collection != "" {
+			name = replica.info.Collection + "_" + name
+		}
+		return os.WriteFile(filepath.Join(backupDir, name), buf.Bytes(), 0644)
+	})
+}
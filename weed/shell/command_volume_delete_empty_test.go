@@ -0,0 +1,46 @@
+package shell
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+)
+
+func TestCollectEmptyVolumes(t *testing.T) {
+	dataNode := func(id string, volumes ...*master_pb.VolumeInformationMessage) *master_pb.DataNodeInfo {
+		return &master_pb.DataNodeInfo{
+			Id:        id,
+			DiskInfos: map[string]*master_pb.DiskInfo{"": {VolumeInfos: volumes}},
+		}
+	}
+	topologyInfo := &master_pb.TopologyInfo{
+		DataCenterInfos: []*master_pb.DataCenterInfo{{
+			Id: "dc1",
+			RackInfos: []*master_pb.RackInfo{{
+				Id: "rack1",
+				DataNodeInfos: []*master_pb.DataNodeInfo{
+					dataNode("server1:8080",
+						&master_pb.VolumeInformationMessage{Id: 1, Size: 8, ReplicaPlacement: 1},
+						&master_pb.VolumeInformationMessage{Id: 2, Size: 8, ReplicaPlacement: 1},
+						&master_pb.VolumeInformationMessage{Id: 3, Size: 8, ReplicaPlacement: 1},
+						&master_pb.VolumeInformationMessage{Id: 4, Size: 8, ModifiedAtSecond: 990},
+					),
+					dataNode("server2:8080",
+						&master_pb.VolumeInformationMessage{Id: 1, Size: 8, ReplicaPlacement: 1},
+						&master_pb.VolumeInformationMessage{Id: 2, Size: 1024, FileCount: 1, ReplicaPlacement: 1},
+					),
+				},
+			}},
+		}},
+	}
+
+	emptyVolumes := collectEmptyVolumes(topologyInfo, 60, 1000, io.Discard)
+
+	// volume 2 has a non empty replica, volume 3 misses a replica, volume 4 is written recently
+	assert.Equal(t, 1, len(emptyVolumes))
+	assert.Equal(t, uint32(1), emptyVolumes[0][0].info.Id)
+	assert.Equal(t, 2, len(emptyVolumes[0]))
+}
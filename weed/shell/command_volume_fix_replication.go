@@ -26,8 +26,33 @@ func init() {
 
 type commandVolumeFixReplication struct {
 	collectionPattern *string
+	result            *FixReplicationResult
 }
 
+// FixReplicationResult is the data of volume.fix.replication in the json envelope of "weed shell -json".
+type FixReplicationResult struct {
+	Applied         bool                    `json:"applied"`
+	OverReplicated  []uint32                `json:"overReplicated,omitempty"`
+	Misplaced       []uint32                `json:"misplaced,omitempty"`
+	UnderReplicated []uint32                `json:"underReplicated,omitempty"`
+	Actions         []*FixReplicationAction `json:"actions,omitempty"`
+}
+
+// FixReplicationAction is a replica deleted or copied, or planned to with -n.
+type FixReplicationAction struct {
+	Action      string `json:"action"`
+	VolumeId    uint32 `json:"volumeId"`
+	Collection  string `json:"collection"`
+	Replication string `json:"replication"`
+	Source      string `json:"source,omitempty"`
+	Target      string `json:"target,omitempty"`
+}
+
+const (
+	fixReplicationDelete    = "delete"
+	fixReplicationReplicate = "replicate"
+)
+
 func (c *commandVolumeFixReplication) Name() string {
 	return "volume.fix.replication"
 }
@@ -43,6 +68,7 @@ func (c *commandVolumeFixReplication) Help() string {
 	volume.fix.replication -n                             # do not take action
 	volume.fix.replication                                # actually deleting or copying the volume files and mount the volume
 	volume.fix.replication -collectionPattern=important*  # fix any collections with prefix "important"
+	volume.fix.replication -volumeId=12                   # fix only the volume 12
 
 	Note:
 		* each time this will only add back one replica for each volume id that is under replicated.
@@ -60,6 +86,7 @@ func (c *commandVolumeFixReplication) Do(args []string, commandEnv *CommandEnv,
 	skipChange := volFixReplicationCommand.Bool("n", false, "skip the changes")
 	retryCount := volFixReplicationCommand.Int("retry", 0, "how many times to retry")
 	volumesPerStep := volFixReplicationCommand.Int("volumesPerStep", 0, "how many volumes to fix in one cycle")
+	volumeId := volFixReplicationCommand.Uint("volumeId", 0, "fix only this volume id")
 
 	if err = volFixReplicationCommand.Parse(args); err != nil {
 		return nil
@@ -70,6 +97,12 @@ func (c *commandVolumeFixReplication) Do(args []string, commandEnv *CommandEnv,
 	}
 
 	takeAction := !*skipChange
+	c.result = &FixReplicationResult{Applied: takeAction}
+	defer func() {
+		if err == nil {
+			writeCommandData(writer, c.result)
+		}
+	}()
 
 	underReplicatedVolumeIdsCount := 1
 	for underReplicatedVolumeIdsCount > 0 {
@@ -84,6 +117,12 @@ func (c *commandVolumeFixReplication) Do(args []string, commandEnv *CommandEnv,
 		// find all volumes that needs replication
 		// collect all data nodes
 		volumeReplicas, allLocations := collectVolumeReplicaLocations(topologyInfo)
+		if *volumeId != 0 {
+			volumeReplicas = map[uint32][]*VolumeReplica{uint32(*volumeId): volumeReplicas[uint32(*volumeId)]}
+			if len(volumeReplicas[uint32(*volumeId)]) == 0 {
+				return fmt.Errorf("volume %d not found", *volumeId)
+			}
+		}
 
 		if len(allLocations) == 0 {
 			return fmt.Errorf("no data nodes at all")
@@ -105,6 +144,11 @@ func (c *commandVolumeFixReplication) Do(args []string, commandEnv *CommandEnv,
 			}
 		}
 
+		// the fixing repeats until no volume is under replicated, so only add the volumes not found before
+		c.result.OverReplicated = appendNewVolumeIds(c.result.OverReplicated, overReplicatedVolumeIds)
+		c.result.Misplaced = appendNewVolumeIds(c.result.Misplaced, misplacedVolumeIds)
+		c.result.UnderReplicated = appendNewVolumeIds(c.result.UnderReplicated, underReplicatedVolumeIds)
+
 		if !commandEnv.isLocked() {
 			return fmt.Errorf("lock is lost")
 		}
@@ -208,7 +252,7 @@ func (c *commandVolumeFixReplication) deleteOneVolume(commandEnv *CommandEnv, wr
 		collectionIsMismatch := false
 		for _, volumeReplica := range replicas {
 			if volumeReplica.info.Collection != replica.info.Collection {
-				fmt.Fprintf(writer, "skip delete volume %d as collection %s is mismatch: %s\n", replica.info.Id, replica.info.Collection, volumeReplica.info.Collection)
+				writeCommandWarning(writer, "skip delete volume %d as collection %s is mismatch: %s", replica.info.Id, replica.info.Collection, volumeReplica.info.Collection)
 				collectionIsMismatch = true
 			}
 		}
@@ -217,6 +261,7 @@ func (c *commandVolumeFixReplication) deleteOneVolume(commandEnv *CommandEnv, wr
 		}
 
 		fmt.Fprintf(writer, "deleting volume %d from %s ...\n", replica.info.Id, replica.location.dataNode.Id)
+		c.addAction(fixReplicationDelete, replica, replicaPlacement, replica.location.dataNode.Id, "")
 
 		if !takeAction {
 			break
@@ -274,6 +319,7 @@ func (c *commandVolumeFixReplication) fixOneUnderReplicatedVolume(commandEnv *Co
 			// ask the volume server to replicate the volume
 			foundNewLocation = true
 			fmt.Fprintf(writer, "replicating volume %d %s from %s to dataNode %s ...\n", replica.info.Id, replicaPlacement, replica.location.dataNode.Id, dst.dataNode.Id)
+			c.addAction(fixReplicationReplicate, replica, replicaPlacement, replica.location.dataNode.Id, dst.dataNode.Id)
 
 			if !takeAction {
 				// adjust free volume count
@@ -317,11 +363,34 @@ func (c *commandVolumeFixReplication) fixOneUnderReplicatedVolume(commandEnv *Co
 	}
 
 	if !foundNewLocation && !hasSkippedCollection {
-		fmt.Fprintf(writer, "failed to place volume %d replica as %s, existing:%+v\n", replica.info.Id, replicaPlacement, len(replicas))
+		writeCommandWarning(writer, "failed to place volume %d replica as %s, existing:%+v", replica.info.Id, replicaPlacement, len(replicas))
 	}
 	return nil
 }
 
+func (c *commandVolumeFixReplication) addAction(action string, replica *VolumeReplica, replicaPlacement *super_block.ReplicaPlacement, source, target string) {
+	if c.result == nil {
+		return
+	}
+	c.result.Actions = append(c.result.Actions, &FixReplicationAction{
+		Action:      action,
+		VolumeId:    replica.info.Id,
+		Collection:  replica.info.Collection,
+		Replication: replicaPlacement.String(),
+		Source:      source,
+		Target:      target,
+	})
+}
+
+func appendNewVolumeIds(volumeIds []uint32, found []uint32) []uint32 {
+	for _, vid := range found {
+		if !slices.Contains(volumeIds, vid) {
+			volumeIds = append(volumeIds, vid)
+		}
+	}
+	return volumeIds
+}
+
 func keepDataNodesSorted(dataNodes []location, diskType types.DiskType) {
 	fn := capacityByFreeVolumeCount(diskType)
 	slices.SortFunc(dataNodes, func(a, b location) bool {
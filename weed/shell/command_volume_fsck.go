@@ -15,6 +15,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -37,8 +38,31 @@ func init() {
 }
 
 type commandVolumeFsck struct {
-	env          *CommandEnv
-	forcePurging *bool
+	env               *CommandEnv
+	forcePurging      *bool
+	collection        *string
+	volumeIds         map[uint32]bool
+	existingVolumeIds map[uint32]bool
+	result            *FsckResult
+}
+
+// FsckResult is the data of volume.fsck in the json envelope of "weed shell -json".
+type FsckResult struct {
+	Volumes      []*FsckVolumeResult `json:"volumes,omitempty"`
+	Entries      uint64              `json:"entries"`
+	Orphans      uint64              `json:"orphans"`
+	OrphanBytes  uint64              `json:"orphanBytes"`
+	MissingPaths []string            `json:"missingPaths,omitempty"`
+	Estimate     *FsckEstimate       `json:"estimate,omitempty"`
+}
+
+// FsckVolumeResult is a volume replica with orphan entries.
+type FsckVolumeResult struct {
+	DataNode    string `json:"dataNode"`
+	VolumeId    uint32 `json:"volumeId"`
+	Entries     uint64 `json:"entries"`
+	Orphans     uint64 `json:"orphans"`
+	OrphanBytes uint64 `json:"orphanBytes"`
 }
 
 func (c *commandVolumeFsck) Name() string {
@@ -62,6 +86,18 @@ func (c *commandVolumeFsck) Help() string {
 	2. collect all file ids from the filer, as set B
 	3. find out the set B subtract A
 
+	The check can be scoped with -collection and -volumeId, e.g.
+		volume.fsck -collection=bucket1
+		volume.fsck -volumeId=3,7,12
+
+	For a quick health check of a huge cluster, -sample only estimates the rates without purging, e.g.
+		volume.fsck -sample=0.01
+	checks 1% of the volumes against all file ids of the filer, and
+		volume.fsck -sample=0.01 -findMissingChunksInFiler
+	checks the chunks of 1% of the files against the volume servers.
+	The orphan or missing rates are extrapolated with 95% confidence intervals, which are compared with
+	-sampleThreshold to tell whether a full volume.fsck is warranted.
+
 `
 }
 
@@ -77,16 +113,36 @@ func (c *commandVolumeFsck) Do(args []string, commandEnv *CommandEnv, writer io.
 	purgeAbsent := fsckCommand.Bool("reallyDeleteFilerEntries", false, "<expert only!> delete missing file entries from filer if the corresponding volume is missing for any reason, please ensure all still existing/expected volumes are connected! used together with findMissingChunksInFiler")
 	tempPath := fsckCommand.String("tempPath", path.Join(os.TempDir()), "path for temporary idx files")
 	cutoffTimeAgo := fsckCommand.Duration("cutoffTimeAgo", 5*time.Minute, "only include entries  on volume servers before this cutoff time to check orphan chunks")
+	c.collection = fsckCommand.String("collection", "", "only check volumes in this collection. Use '_default_' for the empty-named collection.")
+	volumeIds := fsckCommand.String("volumeId", "", "only check these comma separated volume ids")
+	sample := fsckCommand.Float64("sample", 0, "only estimate the rates from this fraction of the volumes, or of the files with findMissingChunksInFiler, e.g. 0.01")
+	sampleThreshold := fsckCommand.Float64("sampleThreshold", 0.001, "a full check is warranted if the estimated rate is above this fraction, used together with sample")
 
 	if err = fsckCommand.Parse(args); err != nil {
 		return nil
 	}
 
+	if c.volumeIds, err = parseVolumeIds(*volumeIds); err != nil {
+		return err
+	}
+	if *sample < 0 || *sample > 1 {
+		return fmt.Errorf("sample %v should be a fraction between 0 and 1", *sample)
+	}
+	if *sample > 0 && (*applyPurging || *purgeAbsent) {
+		return fmt.Errorf("sample only estimates, it can not be used to delete anything")
+	}
+
 	if err = commandEnv.confirmIsLocked(args); err != nil {
 		return
 	}
 
 	c.env = commandEnv
+	c.result = &FsckResult{}
+	defer func() {
+		if err == nil {
+			writeCommandData(writer, c.result)
+		}
+	}()
 
 	// create a temp folder
 	tempFolder, err := os.MkdirTemp(*tempPath, "sw_fsck")
@@ -119,7 +175,27 @@ func (c *commandVolumeFsck) Do(args []string, commandEnv *CommandEnv, writer io.
 		return fmt.Errorf("read filer buckets path: %v", err)
 	}
 
-	collectMtime := time.Now().Unix()
+	collectMtime := time.Now().UnixNano()
+	if *sample > 0 && *findMissingChunksInFiler {
+		if *findMissingChunksInVolumeId > 0 {
+			for _, volumeIdToVInfo := range dataNodeVolumeIdToVInfo {
+				for volumeId := range volumeIdToVInfo {
+					if uint32(*findMissingChunksInVolumeId) != volumeId {
+						delete(volumeIdToVInfo, volumeId)
+					}
+				}
+			}
+		}
+		return c.estimateFilerFilesMissingChunks(dataNodeVolumeIdToVInfo, *findMissingChunksInFilerPath, *sample, writer, *verbose, collectMtime, *sampleThreshold)
+	}
+	var volumeCount int
+	if *sample > 0 {
+		var sampledCount int
+		sampledCount, volumeCount = sampleVolumes(dataNodeVolumeIdToVInfo, *sample)
+		if *verbose {
+			fmt.Fprintf(writer, "sampled %d of %d volumes\n", sampledCount, volumeCount)
+		}
+	}
 	// collect each volume file ids
 	for dataNodeId, volumeIdToVInfo := range dataNodeVolumeIdToVInfo {
 		for volumeId, vinfo := range volumeIdToVInfo {
@@ -155,6 +231,9 @@ func (c *commandVolumeFsck) Do(args []string, commandEnv *CommandEnv, writer io.
 		if err = c.collectFilerFileIds(dataNodeVolumeIdToVInfo, tempFolder, writer, *verbose); err != nil {
 			return fmt.Errorf("failed to collect file ids from filer: %v", err)
 		}
+		if *sample > 0 {
+			return c.estimateExtraChunksInVolumeServers(dataNodeVolumeIdToVInfo, tempFolder, writer, *verbose, volumeCount, *sampleThreshold)
+		}
 		// volume file ids subtract filer file ids
 		if err = c.findExtraChunksInVolumeServers(dataNodeVolumeIdToVInfo, tempFolder, writer, *verbose, *applyPurging); err != nil {
 			return fmt.Errorf("findExtraChunksInVolumeServers: %v", err)
@@ -227,11 +306,15 @@ func (c *commandVolumeFsck) collectFilerFileIdAndPaths(dataNodeVolumeIdToVInfo m
 				f.Write(buffer)
 				f.Write([]byte(i.path))
 				// fmt.Fprintf(writer, "%d,%x%08x %d %s\n", i.vid, i.fileKey, i.cookie, len(i.path), i.path)
+			} else if c.existingVolumeIds[i.vid] {
+				// the volume exists, but is not selected to check
+				continue
 			} else {
 				fmt.Fprintf(writer, "%d,%x%08x %s volume not found\n", i.vid, i.fileKey, i.cookie, i.path)
+				c.result.MissingPaths = append(c.result.MissingPaths, string(i.path))
 				if purgeAbsent {
-					fmt.Printf("deleting path %s after volume not found", i.path)
-					c.httpDelete(i.path, verbose)
+					fmt.Fprintf(writer, "deleting path %s after volume not found\n", i.path)
+					c.httpDelete(i.path, writer, verbose)
 				}
 			}
 		}
@@ -242,7 +325,7 @@ func (c *commandVolumeFsck) collectFilerFileIdAndPaths(dataNodeVolumeIdToVInfo m
 func (c *commandVolumeFsck) findFilerChunksMissingInVolumeServers(volumeIdToVInfo map[uint32]VInfo, tempFolder string, dataNodeId string, writer io.Writer, verbose bool, applyPurging bool) error {
 
 	for volumeId, vinfo := range volumeIdToVInfo {
-		checkErr := c.oneVolumeFileIdsCheckOneVolume(tempFolder, dataNodeId, volumeId, writer, verbose, applyPurging)
+		checkErr := c.oneVolumeFileIdsCheckOneVolume(tempFolder, dataNodeId, volumeId, vinfo.server, writer, verbose, applyPurging)
 		if checkErr != nil {
 			return fmt.Errorf("failed to collect file ids from volume %d on %s: %v", volumeId, vinfo.server, checkErr)
 		}
@@ -282,6 +365,7 @@ func (c *commandVolumeFsck) findExtraChunksInVolumeServers(dataNodeVolumeIdToVIn
 			totalInUseCount += inUseCount
 			totalOrphanChunkCount += uint64(len(orphanFileIds))
 			totalOrphanDataSize += orphanDataSize
+			c.result.addVolume(dataNodeId, volumeId, inUseCount, orphanFileIds, orphanDataSize)
 
 			if verbose {
 				for _, fid := range orphanFileIds {
@@ -469,13 +553,15 @@ func (c *commandVolumeFsck) collectFilerFileIds(dataNodeVolumeIdToVInfo map[stri
 		buffer := make([]byte, 8)
 		for item := range outputChan {
 			i := item.(*Item)
-			util.Uint64toBytes(buffer, i.fileKey)
-			files[i.vid].Write(buffer)
+			if f, ok := files[i.vid]; ok {
+				util.Uint64toBytes(buffer, i.fileKey)
+				f.Write(buffer)
+			}
 		}
 	})
 }
 
-func (c *commandVolumeFsck) oneVolumeFileIdsCheckOneVolume(tempFolder string, dataNodeId string, volumeId uint32, writer io.Writer, verbose bool, applyPurging bool) (err error) {
+func (c *commandVolumeFsck) oneVolumeFileIdsCheckOneVolume(tempFolder string, dataNodeId string, volumeId uint32, server rpc.ServerAddress, writer io.Writer, verbose bool, applyPurging bool) (err error) {
 
 	if verbose {
 		fmt.Fprintf(writer, "find missing file chunks in dataNodeId %s volume %d ...\n", dataNodeId, volumeId)
@@ -505,6 +591,8 @@ func (c *commandVolumeFsck) oneVolumeFileIdsCheckOneVolume(tempFolder string, da
 	buffer := make([]byte, 16)
 	item := &Item{}
 	var readSize int
+	var missingFileIds []string
+	missingPaths := make(map[string]util.FullPath)
 	for {
 		readSize, err = io.ReadFull(br, buffer)
 		if err != nil || readSize != 16 {
@@ -526,19 +614,48 @@ func (c *commandVolumeFsck) oneVolumeFileIdsCheckOneVolume(tempFolder string, da
 
 		needleId := types.NeedleId(item.fileKey)
 		if _, found := db.Get(needleId); !found {
-			fmt.Fprintf(writer, "%s\n", item.path)
+			fileId := needle.NewFileId(needle.VolumeId(volumeId), item.fileKey, item.cookie).String()
+			missingFileIds = append(missingFileIds, fileId)
+			missingPaths[fileId] = item.path
+		}
+	}
+
+	// the copied index misses the chunks written since then, so check the volume server again
+	for len(missingFileIds) > 0 {
+		batch := missingFileIds
+		if len(batch) > fsckNeedleExistsBatchSize {
+			batch = batch[:fsckNeedleExistsBatchSize]
+		}
+		missingFileIds = missingFileIds[len(batch):]
+
+		results, checkErr := operation.NeedleExistsAtOneVolumeServer(server, c.env.option.GrpcDialOption, batch)
+		if checkErr != nil {
+			return fmt.Errorf("check missing chunks on %s: %v", server, checkErr)
+		}
+		for _, result := range results {
+			if result.Exists {
+				continue
+			}
+			path := missingPaths[result.FileId]
+			fmt.Fprintf(writer, "%s\n", path)
+			c.result.MissingPaths = append(c.result.MissingPaths, string(path))
 
 			if applyPurging {
 				// defining the URL this way automatically escapes complex path names
-				c.httpDelete(item.path, verbose)
+				c.httpDelete(path, writer, verbose)
 			}
 		}
 	}
 	return nil
 }
 
-func (c *commandVolumeFsck) httpDelete(path util.FullPath, verbose bool) {
+// httpDelete deletes the path from the filer, reporting the failure as a warning.
+func (c *commandVolumeFsck) httpDelete(path util.FullPath, writer io.Writer, verbose bool) {
 	req, err := http.NewRequest(http.MethodDelete, "", nil)
+	if err != nil {
+		writeCommandWarning(writer, "HTTP delete request %s: %v", path, err)
+		return
+	}
 
 	req.URL = &url.URL{
 		Scheme: "http",
@@ -546,28 +663,25 @@ func (c *commandVolumeFsck) httpDelete(path util.FullPath, verbose bool) {
 		Path:   string(path),
 	}
 	if verbose {
-		fmt.Printf("full HTTP delete request to be sent: %v\n", req)
-	}
-	if err != nil {
-		fmt.Errorf("HTTP delete request error: %v\n", err)
+		fmt.Fprintf(writer, "full HTTP delete request to be sent: %v\n", req)
 	}
 
 	client := &http.Client{}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Errorf("DELETE fetch error: %v\n", err)
+		writeCommandWarning(writer, "DELETE %s fetch error: %v", path, err)
+		return
 	}
 	defer resp.Body.Close()
 
-	_, err = ioutil.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Errorf("DELETE response error: %v\n", err)
+	if _, err = ioutil.ReadAll(resp.Body); err != nil {
+		writeCommandWarning(writer, "DELETE %s response error: %v", path, err)
 	}
 
 	if verbose {
-		fmt.Println("delete response Status : ", resp.Status)
-		fmt.Println("delete response Headers : ", resp.Header)
+		fmt.Fprintln(writer, "delete response Status : ", resp.Status)
+		fmt.Fprintln(writer, "delete response Headers : ", resp.Header)
 	}
 }
 
@@ -615,6 +729,25 @@ func (c *commandVolumeFsck) oneVolumeFileIdsSubtractFilerFileIds(tempFolder stri
 
 }
 
+// addVolume adds the orphans of the volume replica to the result.
+func (r *FsckResult) addVolume(dataNodeId string, volumeId uint32, inUseCount uint64, orphanFileIds []string, orphanDataSize uint64) {
+	orphanCount := uint64(len(orphanFileIds))
+	r.Entries += inUseCount + orphanCount
+	r.Orphans += orphanCount
+	r.OrphanBytes += orphanDataSize
+	if orphanCount > 0 {
+		r.Volumes = append(r.Volumes, &FsckVolumeResult{
+			DataNode:    dataNodeId,
+			VolumeId:    volumeId,
+			Entries:     inUseCount + orphanCount,
+			Orphans:     orphanCount,
+			OrphanBytes: orphanDataSize,
+		})
+	}
+}
+
+const fsckNeedleExistsBatchSize = 1000
+
 type VInfo struct {
 	server     rpc.ServerAddress
 	collection string
@@ -628,18 +761,34 @@ func (c *commandVolumeFsck) collectVolumeIds(commandEnv *CommandEnv, verbose boo
 		fmt.Fprintf(writer, "collecting volume id and locations from master ...\n")
 	}
 
-	volumeIdToServer = make(map[string]map[uint32]VInfo)
 	// collect topology information
 	topologyInfo, _, err := collectTopologyInfo(commandEnv, 0)
 	if err != nil {
 		return
 	}
+	volumeIdToServer = c.selectVolumeIds(topologyInfo)
 
+	if verbose {
+		fmt.Fprintf(writer, "collected %d volumes and locations.\n", len(volumeIdToServer))
+	}
+	return
+}
+
+// selectVolumeIds returns the locations of the volumes to check, and remembers all existing volume ids.
+func (c *commandVolumeFsck) selectVolumeIds(topologyInfo *master_pb.TopologyInfo) (volumeIdToServer map[string]map[uint32]VInfo) {
+	volumeIdToServer = make(map[string]map[uint32]VInfo)
+	c.existingVolumeIds = make(map[uint32]bool)
 	eachDataNode(topologyInfo, func(dc string, rack RackId, t *master_pb.DataNodeInfo) {
 		for _, diskInfo := range t.DiskInfos {
 			dataNodeId := t.GetId()
-			volumeIdToServer[dataNodeId] = make(map[uint32]VInfo)
+			if _, found := volumeIdToServer[dataNodeId]; !found {
+				volumeIdToServer[dataNodeId] = make(map[uint32]VInfo)
+			}
 			for _, vi := range diskInfo.VolumeInfos {
+				c.existingVolumeIds[vi.Id] = true
+				if !c.isVolumeSelected(vi.Id, vi.Collection) {
+					continue
+				}
 				volumeIdToServer[dataNodeId][vi.Id] = VInfo{
 					server:     rpc.NewServerAddressFromDataNode(t),
 					collection: vi.Collection,
@@ -648,6 +797,10 @@ func (c *commandVolumeFsck) collectVolumeIds(commandEnv *CommandEnv, verbose boo
 				}
 			}
 			for _, ecShardInfo := range diskInfo.EcShardInfos {
+				c.existingVolumeIds[ecShardInfo.Id] = true
+				if !c.isVolumeSelected(ecShardInfo.Id, ecShardInfo.Collection) {
+					continue
+				}
 				volumeIdToServer[dataNodeId][ecShardInfo.Id] = VInfo{
 					server:     rpc.NewServerAddressFromDataNode(t),
 					collection: ecShardInfo.Collection,
@@ -657,9 +810,34 @@ func (c *commandVolumeFsck) collectVolumeIds(commandEnv *CommandEnv, verbose boo
 			}
 		}
 	})
+	return
+}
 
-	if verbose {
-		fmt.Fprintf(writer, "collected %d volumes and locations.\n", len(volumeIdToServer))
+func (c *commandVolumeFsck) isVolumeSelected(volumeId uint32, collection string) bool {
+	if len(c.volumeIds) > 0 && !c.volumeIds[volumeId] {
+		return false
+	}
+	if c.collection != nil && *c.collection != "" {
+		if *c.collection == "_default_" {
+			return collection == ""
+		}
+		return collection == *c.collection
+	}
+	return true
+}
+
+func parseVolumeIds(volumeIds string) (vids map[uint32]bool, err error) {
+	vids = make(map[uint32]bool)
+	for _, vidString := range strings.Split(volumeIds, ",") {
+		vidString = strings.TrimSpace(vidString)
+		if vidString == "" {
+			continue
+		}
+		vid, parseErr := strconv.ParseUint(vidString, 10, 32)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse volume id %s: %v", vidString, parseErr)
+		}
+		vids[uint32(vid)] = true
 	}
 	return
 }
@@ -0,0 +1,321 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// the z value of the 95% confidence intervals of the sampled estimates
+const fsckSampleZ = 1.96
+
+// whether a full volume.fsck is warranted by the sampled estimate
+const (
+	fsckVerdictWarranted    = "warranted"
+	fsckVerdictNotNeeded    = "notNeeded"
+	fsckVerdictInconclusive = "inconclusive"
+)
+
+// FsckEstimate is the result of volume.fsck -sample, extrapolated from the sampled volumes or files.
+type FsckEstimate struct {
+	Kind       string  `json:"kind"`
+	Sampled    int     `json:"sampled"`
+	Population float64 `json:"population"`
+	Rate       float64 `json:"rate"`
+	Lower      float64 `json:"lower"`
+	Upper      float64 `json:"upper"`
+	Count      float64 `json:"count"`
+	Bytes      float64 `json:"bytes,omitempty"`
+	Verdict    string  `json:"verdict"`
+}
+
+// sampleVolumes keeps one replica of a random fraction of the volumes, at least one volume,
+// and returns the number of the sampled volumes and of all volumes.
+func sampleVolumes(dataNodeVolumeIdToVInfo map[string]map[uint32]VInfo, fraction float64) (sampledCount, volumeCount int) {
+	sampled := make(map[uint32]bool)
+	for _, volumeIdToVInfo := range dataNodeVolumeIdToVInfo {
+		for volumeId := range volumeIdToVInfo {
+			if _, found := sampled[volumeId]; !found {
+				sampled[volumeId] = rand.Float64() < fraction
+			}
+		}
+	}
+	volumeCount = len(sampled)
+	if volumeCount == 0 {
+		return 0, 0
+	}
+
+	var anySampled bool
+	for _, isSampled := range sampled {
+		anySampled = anySampled || isSampled
+	}
+	if !anySampled {
+		// map iteration order picks a random volume
+		for volumeId := range sampled {
+			sampled[volumeId] = true
+			break
+		}
+	}
+
+	for _, volumeIdToVInfo := range dataNodeVolumeIdToVInfo {
+		for volumeId := range volumeIdToVInfo {
+			if sampled[volumeId] {
+				// only check the first replica found
+				sampled[volumeId] = false
+				sampledCount++
+				continue
+			}
+			delete(volumeIdToVInfo, volumeId)
+		}
+	}
+	return
+}
+
+// estimateExtraChunksInVolumeServers extrapolates the orphan chunks of all volumes from the sampled volumes.
+func (c *commandVolumeFsck) estimateExtraChunksInVolumeServers(dataNodeVolumeIdToVInfo map[string]map[uint32]VInfo, tempFolder string, writer io.Writer, verbose bool, volumeCount int, threshold float64) error {
+
+	var entries, orphans []float64
+	var totalOrphanDataSize uint64
+	for dataNodeId, volumeIdToVInfo := range dataNodeVolumeIdToVInfo {
+		for volumeId, vinfo := range volumeIdToVInfo {
+			inUseCount, orphanFileIds, orphanDataSize, checkErr := c.oneVolumeFileIdsSubtractFilerFileIds(tempFolder, dataNodeId, volumeId, writer, verbose)
+			if checkErr != nil {
+				return fmt.Errorf("failed to collect file ids from volume %d on %s: %v", volumeId, vinfo.server, checkErr)
+			}
+			if verbose {
+				for _, fid := range orphanFileIds {
+					fmt.Fprintf(writer, "%s\n", fid)
+				}
+			}
+			c.result.addVolume(dataNodeId, volumeId, inUseCount, orphanFileIds, orphanDataSize)
+			entries = append(entries, float64(inUseCount+uint64(len(orphanFileIds))))
+			orphans = append(orphans, float64(len(orphanFileIds)))
+			totalOrphanDataSize += orphanDataSize
+		}
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(writer, "no volumes to sample\n")
+		return nil
+	}
+
+	var totalEntries, totalOrphans float64
+	for i := range entries {
+		totalEntries += entries[i]
+		totalOrphans += orphans[i]
+	}
+	scale := float64(volumeCount) / float64(len(entries))
+	rate, lower, upper := ratioInterval(orphans, entries, volumeCount, fsckSampleZ)
+	fmt.Fprintf(writer, "\nSampled\t\tvolumes:%d of %d\tentries:%.0f\torphan:%.0f\t%dB\n",
+		len(entries), volumeCount, totalEntries, totalOrphans, totalOrphanDataSize)
+	fmt.Fprintf(writer, "Estimated\torphan rate:%.2f%%\t95%% confidence:%.2f%%..%.2f%%\torphan:~%.0f of ~%.0f entries\t~%.0fB\n",
+		rate*100, lower*100, upper*100, totalOrphans*scale, totalEntries*scale, float64(totalOrphanDataSize)*scale)
+	c.result.Estimate = &FsckEstimate{
+		Kind:       "orphan",
+		Sampled:    len(entries),
+		Population: float64(volumeCount),
+		Rate:       rate,
+		Lower:      lower,
+		Upper:      upper,
+		Count:      totalOrphans * scale,
+		Bytes:      float64(totalOrphanDataSize) * scale,
+		Verdict:    printFsckVerdict(writer, "orphan", lower, upper, threshold),
+	}
+	fmt.Fprintf(writer, "This could be normal if multiple filers or no filers are used.\n")
+	return nil
+}
+
+// estimateFilerFilesMissingChunks checks the chunks of a random fraction of the files under the filer path
+// against the volume servers, and extrapolates the files with missing chunks.
+func (c *commandVolumeFsck) estimateFilerFilesMissingChunks(dataNodeVolumeIdToVInfo map[string]map[uint32]VInfo, filerPath string, fraction float64, writer io.Writer, verbose bool, collectMtime int64, threshold float64) error {
+
+	selected := make(map[uint32]bool)
+	for _, volumeIdToVInfo := range dataNodeVolumeIdToVInfo {
+		for volumeId := range volumeIdToVInfo {
+			selected[volumeId] = true
+		}
+	}
+
+	if verbose {
+		fmt.Fprintf(writer, "sampling files from filer ...\n")
+	}
+
+	type Item struct {
+		path    util.FullPath
+		fileIds []string
+	}
+	var fileCount, sampledCount uint64
+	var sampledFiles []*Item
+	err := doTraverseBfsAndSaving(c.env, nil, filerPath, false, func(entry *filer_pb.FullEntry, outputChan chan interface{}) (err error) {
+		if entry.Entry.IsDirectory || len(entry.Entry.Chunks) == 0 {
+			return nil
+		}
+		atomic.AddUint64(&fileCount, 1)
+		if rand.Float64() >= fraction {
+			return nil
+		}
+		atomic.AddUint64(&sampledCount, 1)
+		dataChunks, manifestChunks, resolveErr := filer.ResolveChunkManifest(filer.LookupFn(c.env), entry.Entry.Chunks, 0, math.MaxInt64)
+		if resolveErr != nil {
+			// the manifest chunk is missing
+			dataChunks, manifestChunks = nil, entry.Entry.Chunks
+		}
+		item := &Item{path: util.NewFullPath(entry.Dir, entry.Entry.Name)}
+		for _, chunk := range append(dataChunks, manifestChunks...) {
+			if chunk.Mtime > collectMtime {
+				continue
+			}
+			if vid := chunk.Fid.GetVolumeId(); c.existingVolumeIds[vid] && !selected[vid] {
+				// the volume exists, but is not selected to check
+				continue
+			}
+			item.fileIds = append(item.fileIds, chunk.GetFileIdString())
+		}
+		if len(item.fileIds) > 0 {
+			outputChan <- item
+		}
+		return nil
+	}, func(outputChan chan interface{}) {
+		for item := range outputChan {
+			sampledFiles = append(sampledFiles, item.(*Item))
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("sample files under %s: %v", filerPath, err)
+	}
+
+	// check the sampled chunks in batches on one replica of each volume
+	serverFileIds := make(map[rpc.ServerAddress][]string)
+	missing := make(map[string]bool)
+	for _, item := range sampledFiles {
+		for _, fileId := range item.fileIds {
+			fid, parseErr := needle.ParseFileIdFromString(fileId)
+			if parseErr != nil {
+				missing[fileId] = true
+				continue
+			}
+			locations, found := c.env.MasterClient.GetLocations(uint32(fid.VolumeId))
+			if !found || len(locations) == 0 {
+				missing[fileId] = true
+				continue
+			}
+			server := locations[0].ServerAddress()
+			serverFileIds[server] = append(serverFileIds[server], fileId)
+		}
+	}
+	for server, fileIds := range serverFileIds {
+		for len(fileIds) > 0 {
+			batch := fileIds
+			if len(batch) > fsckNeedleExistsBatchSize {
+				batch = batch[:fsckNeedleExistsBatchSize]
+			}
+			fileIds = fileIds[len(batch):]
+
+			results, checkErr := operation.NeedleExistsAtOneVolumeServer(server, c.env.option.GrpcDialOption, batch)
+			if checkErr != nil {
+				return fmt.Errorf("check sampled chunks on %s: %v", server, checkErr)
+			}
+			for _, result := range results {
+				if !result.Exists {
+					missing[result.FileId] = true
+				}
+			}
+		}
+	}
+
+	var brokenCount int
+	for _, item := range sampledFiles {
+		for _, fileId := range item.fileIds {
+			if missing[fileId] {
+				fmt.Fprintf(writer, "%s\n", item.path)
+				c.result.MissingPaths = append(c.result.MissingPaths, string(item.path))
+				brokenCount++
+				break
+			}
+		}
+	}
+
+	if len(sampledFiles) == 0 {
+		fmt.Fprintf(writer, "no files sampled out of %d files under %s\n", fileCount, filerPath)
+		return nil
+	}
+	// only the files with chunks on the selected volumes are checked
+	checkedFileCount := float64(fileCount) * float64(len(sampledFiles)) / float64(sampledCount)
+	rate, lower, upper := wilsonInterval(float64(brokenCount), float64(len(sampledFiles)), fsckSampleZ)
+	fmt.Fprintf(writer, "\nSampled\t\tfiles:%d of ~%.0f\tmissing chunks:%d\n", len(sampledFiles), checkedFileCount, brokenCount)
+	fmt.Fprintf(writer, "Estimated\tmissing rate:%.2f%%\t95%% confidence:%.2f%%..%.2f%%\tfiles missing chunks:~%.0f\n",
+		rate*100, lower*100, upper*100, rate*checkedFileCount)
+	c.result.Estimate = &FsckEstimate{
+		Kind:       "missing",
+		Sampled:    len(sampledFiles),
+		Population: checkedFileCount,
+		Rate:       rate,
+		Lower:      lower,
+		Upper:      upper,
+		Count:      rate * checkedFileCount,
+		Verdict:    printFsckVerdict(writer, "missing", lower, upper, threshold),
+	}
+	return nil
+}
+
+func printFsckVerdict(writer io.Writer, kind string, lower, upper, threshold float64) string {
+	switch {
+	case lower > threshold:
+		fmt.Fprintf(writer, "the %s rate is above %.2f%%, a full volume.fsck is warranted\n", kind, threshold*100)
+		return fsckVerdictWarranted
+	case upper <= threshold:
+		fmt.Fprintf(writer, "the %s rate is below %.2f%%, a full volume.fsck is not needed\n", kind, threshold*100)
+		return fsckVerdictNotNeeded
+	default:
+		fmt.Fprintf(writer, "inconclusive whether the %s rate is above %.2f%%, sample more with a larger -sample\n", kind, threshold*100)
+		return fsckVerdictInconclusive
+	}
+}
+
+// wilsonInterval is the rate of the successes in the independent trials with its Wilson score interval.
+func wilsonInterval(successes, trials, z float64) (rate, lower, upper float64) {
+	if trials == 0 {
+		return 0, 0, 1
+	}
+	rate = successes / trials
+	z2 := z * z
+	center := (rate + z2/(2*trials)) / (1 + z2/trials)
+	halfWidth := z / (1 + z2/trials) * math.Sqrt(rate*(1-rate)/trials+z2/(4*trials*trials))
+	return rate, math.Max(0, center-halfWidth), math.Min(1, center+halfWidth)
+}
+
+// ratioInterval estimates the rate of the orphans in the entries of all volumes from the sampled volumes.
+// The volumes are the sampling units, so the interval is the wider one of the ratio estimator over the volumes
+// and the Wilson interval over the entries, which still bounds the rate when the volumes vary too little to tell.
+func ratioInterval(orphans, entries []float64, volumeCount int, z float64) (rate, lower, upper float64) {
+	n := float64(len(entries))
+	var totalOrphans, totalEntries float64
+	for i := range entries {
+		totalOrphans += orphans[i]
+		totalEntries += entries[i]
+	}
+	rate, lower, upper = wilsonInterval(totalOrphans, totalEntries, z)
+	if len(entries) < 2 || totalEntries == 0 {
+		return
+	}
+
+	var sumSquares float64
+	for i := range entries {
+		residual := orphans[i] - rate*entries[i]
+		sumSquares += residual * residual
+	}
+	meanEntries := totalEntries / n
+	finiteCorrection := math.Max(0, 1-n/float64(volumeCount))
+	standardError := math.Sqrt(finiteCorrection*sumSquares/(n-1)/n) / meanEntries
+	lower = math.Max(0, math.Min(lower, rate-z*standardError))
+	upper = math.Min(1, math.Max(upper, rate+z*standardError))
+	return
+}
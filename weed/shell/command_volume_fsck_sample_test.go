@@ -0,0 +1,125 @@
+package shell
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+)
+
+func TestSampleVolumes(t *testing.T) {
+	dataNodeVolumeIdToVInfo := map[string]map[uint32]VInfo{
+		"server1:8080": {1: {server: "server1:8080"}, 2: {server: "server1:8080"}},
+		"server2:8080": {1: {server: "server2:8080"}, 2: {server: "server2:8080"}, 3: {server: "server2:8080"}},
+	}
+
+	sampledCount, volumeCount := sampleVolumes(dataNodeVolumeIdToVInfo, 0)
+
+	assert.Equal(t, 1, sampledCount, "at least one volume is sampled")
+	assert.Equal(t, 3, volumeCount)
+	var replicas int
+	for _, volumeIdToVInfo := range dataNodeVolumeIdToVInfo {
+		replicas += len(volumeIdToVInfo)
+	}
+	assert.Equal(t, 1, replicas, "only one replica of the sampled volume is kept")
+
+	dataNodeVolumeIdToVInfo = map[string]map[uint32]VInfo{
+		"server1:8080": {1: {server: "server1:8080"}, 2: {server: "server1:8080"}},
+		"server2:8080": {1: {server: "server2:8080"}, 3: {server: "server2:8080"}},
+	}
+	sampledCount, volumeCount = sampleVolumes(dataNodeVolumeIdToVInfo, 1)
+	assert.Equal(t, 3, sampledCount)
+	assert.Equal(t, 3, volumeCount)
+}
+
+func TestWilsonInterval(t *testing.T) {
+	rate, lower, upper := wilsonInterval(0, 1000, fsckSampleZ)
+	assert.Equal(t, 0.0, rate)
+	assert.Equal(t, 0.0, lower)
+	assert.InDelta(t, 0.0038, upper, 0.0001)
+
+	rate, lower, upper = wilsonInterval(50, 1000, fsckSampleZ)
+	assert.Equal(t, 0.05, rate)
+	assert.InDelta(t, 0.0381, lower, 0.0001)
+	assert.InDelta(t, 0.0653, upper, 0.0001)
+}
+
+func TestRatioInterval(t *testing.T) {
+	// the orphans concentrate in one volume, so the volumes vary more than the entries tell
+	orphans := []float64{0, 0, 0, 100}
+	entries := []float64{1000, 1000, 1000, 1000}
+
+	rate, lower, upper := ratioInterval(orphans, entries, 100, fsckSampleZ)
+
+	_, entryLower, entryUpper := wilsonInterval(100, 4000, fsckSampleZ)
+	assert.Equal(t, 0.025, rate)
+	assert.Less(t, lower, entryLower)
+	assert.Greater(t, upper, entryUpper)
+
+	// all volumes are sampled, so the estimate is exact over the volumes
+	rate, lower, upper = ratioInterval(orphans, entries, 4, fsckSampleZ)
+	assert.Equal(t, 0.025, rate)
+	assert.Equal(t, entryLower, lower)
+	assert.Equal(t, entryUpper, upper)
+}
+
+func TestPrintFsckVerdict(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Equal(t, fsckVerdictWarranted, printFsckVerdict(&buf, "orphan", 0.002, 0.004, 0.001))
+	assert.Contains(t, buf.String(), "warranted")
+
+	buf.Reset()
+	assert.Equal(t, fsckVerdictNotNeeded, printFsckVerdict(&buf, "orphan", 0, 0.0005, 0.001))
+	assert.Contains(t, buf.String(), "not needed")
+
+	buf.Reset()
+	assert.Equal(t, fsckVerdictInconclusive, printFsckVerdict(&buf, "orphan", 0, 0.004, 0.001))
+	assert.Contains(t, buf.String(), "inconclusive")
+}
+
+func TestFsckSelectVolumeIds(t *testing.T) {
+	topologyInfo := &master_pb.TopologyInfo{DataCenterInfos: []*master_pb.DataCenterInfo{{
+		Id: "dc1",
+		RackInfos: []*master_pb.RackInfo{{
+			Id: "rack1",
+			DataNodeInfos: []*master_pb.DataNodeInfo{{
+				Id: "server1:8080",
+				DiskInfos: map[string]*master_pb.DiskInfo{"": {
+					VolumeInfos: []*master_pb.VolumeInformationMessage{
+						{Id: 1, Collection: "bucket1"},
+						{Id: 2, Collection: "bucket2"},
+						{Id: 3},
+					},
+					EcShardInfos: []*master_pb.VolumeEcShardInformationMessage{
+						{Id: 4, Collection: "bucket1"},
+					},
+				}},
+			}},
+		}},
+	}}}
+	selected := func(collection, volumeIds string) (vids []uint32) {
+		c := &commandVolumeFsck{collection: &collection}
+		var err error
+		c.volumeIds, err = parseVolumeIds(volumeIds)
+		assert.Nil(t, err)
+		for vid := range c.selectVolumeIds(topologyInfo)["server1:8080"] {
+			vids = append(vids, vid)
+		}
+		assert.Equal(t, 4, len(c.existingVolumeIds), "all volumes exist")
+		sort.Slice(vids, func(i, j int) bool { return vids[i] < vids[j] })
+		return
+	}
+
+	assert.Equal(t, []uint32{1, 2, 3, 4}, selected("", ""))
+	assert.Equal(t, []uint32{1, 4}, selected("bucket1", ""))
+	assert.Equal(t, []uint32{3}, selected("_default_", ""))
+	assert.Equal(t, []uint32{2, 3}, selected("", "2, 3,9"))
+	assert.Equal(t, []uint32{4}, selected("bucket1", "2,4"))
+	assert.Nil(t, selected("bucket2", "1"))
+
+	_, err := parseVolumeIds("1,x")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,98 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/topology"
+)
+
+func init() {
+	Commands = append(Commands, &commandVolumeIdRanges{})
+}
+
+type commandVolumeIdRanges struct {
+}
+
+func (c *commandVolumeIdRanges) Name() string {
+	return "volume.id.ranges"
+}
+
+func (c *commandVolumeIdRanges) Help() string {
+	return `show or change the volume id ranges this cluster assigns new volume ids from
+
+	volume.id.ranges                                   # show the ranges, the next volume id, and the volumes out of the ranges
+	volume.id.ranges -set=1-1000000,3000001-4000000    # assign new volume ids only from these inclusive ranges
+	volume.id.ranges -clear                            # assign new volume ids without restriction
+
+	When clusters replicate volumes to each other, give each cluster its own ranges, so two clusters
+	never create different volumes with the same id.
+	The ranges can also be set in master.toml as master.volume_id.ranges. Once changed by this command,
+	the ranges are kept in the raft state and take precedence over master.toml.
+	A new volume id is always larger than the current max volume id, so ranges below it are not used.
+
+`
+}
+
+func (c *commandVolumeIdRanges) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	volumeIdRangesCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	setRanges := volumeIdRangesCommand.String("set", "", "comma separated inclusive volume id ranges, e.g. 1-1000000,3000001-4000000")
+	clearRanges := volumeIdRangesCommand.Bool("clear", false, "remove the restriction on volume ids")
+	if err = volumeIdRangesCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	if *setRanges != "" || *clearRanges {
+		if *setRanges != "" && *clearRanges {
+			return fmt.Errorf("use either -set or -clear")
+		}
+		if err = commandEnv.confirmIsLocked(args); err != nil {
+			return
+		}
+		if _, err = topology.ParseVolumeIdRanges(*setRanges); err != nil {
+			return err
+		}
+		err = commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
+			_, err := client.SetVolumeIdRanges(context.Background(), &master_pb.SetVolumeIdRangesRequest{
+				VolumeIdRanges: *setRanges,
+			})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("set volume id ranges: %v", err)
+		}
+	}
+
+	var resp *master_pb.GetVolumeIdRangesResponse
+	err = commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
+		resp, err = client.GetVolumeIdRanges(context.Background(), &master_pb.GetVolumeIdRangesRequest{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("get volume id ranges: %v", err)
+	}
+
+	writeVolumeIdRanges(writer, resp)
+	return nil
+}
+
+func writeVolumeIdRanges(writer io.Writer, resp *master_pb.GetVolumeIdRangesResponse) {
+	if resp.VolumeIdRanges == "" {
+		fmt.Fprintf(writer, "volume id ranges: unrestricted\n")
+	} else {
+		fmt.Fprintf(writer, "volume id ranges: %s\n", resp.VolumeIdRanges)
+	}
+	fmt.Fprintf(writer, "max volume id: %d\n", resp.MaxVolumeId)
+	if resp.NextVolumeId == 0 {
+		fmt.Fprintf(writer, "next volume id: none, the ranges are used up\n")
+	} else {
+		fmt.Fprintf(writer, "next volume id: %d\n", resp.NextVolumeId)
+	}
+	if len(resp.OutOfRangeVolumeIds) > 0 {
+		fmt.Fprintf(writer, "%d volumes out of the ranges: %v\n", len(resp.OutOfRangeVolumeIds), resp.OutOfRangeVolumeIds)
+	}
+}
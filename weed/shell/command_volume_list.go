@@ -2,10 +2,12 @@ package shell
 
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
+	"github.com/seaweedfs/seaweedfs/weed/util"
 	"golang.org/x/exp/slices"
 	"path/filepath"
 
@@ -18,8 +20,20 @@ func init() {
 
 type commandVolumeList struct {
 	collectionPattern *string
+	collection        *string
+	dataCenter        *string
+	rack              *string
+	dataNode          *string
 	readonly          *bool
+	writable          *bool
 	volumeId          *uint64
+	minSizeMB         *uint64
+	maxSizeMB         *uint64
+	sortBy            *string
+	desc              *bool
+	limit             *int
+	page              *int
+	isJson            *bool
 }
 
 func (c *commandVolumeList) Name() string {
@@ -31,6 +45,17 @@ func (c *commandVolumeList) Help() string {
 
 	This command list all volumes as a tree of dataCenter > rack > dataNode > volume.
 
+	volume.list -collection=pictures -dataCenter=dc1       # filter by collection and location
+	volume.list -readonly -minSizeMB=1000                  # readonly volumes with at least 1000MB
+	volume.list -sortBy=size -desc -limit=20               # the 20 biggest volumes
+	volume.list -sortBy=garbage -desc -limit=50 -page=2    # the 51st to 100th volumes with the most deleted bytes
+	volume.list -json -collection=_default_                # volumes of the empty-named collection as json
+
+	With -sortBy, -limit or -json, the volumes are listed flat, one volume replica per line,
+	with its data center, rack, data node and disk type. Erasure coded volumes are not included.
+	With "weed shell -json", the flat list is the data of the json envelope.
+	The sort keys are id, size, fileCount, deleteCount, deletedBytes, garbage and modified.
+
 `
 }
 
@@ -39,23 +64,223 @@ func (c *commandVolumeList) Do(args []string, commandEnv *CommandEnv, writer io.
 	volumeListCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
 	verbosityLevel := volumeListCommand.Int("v", 5, "verbose mode: 0, 1, 2, 3, 4, 5")
 	c.collectionPattern = volumeListCommand.String("collectionPattern", "", "match with wildcard characters '*' and '?'")
+	c.collection = volumeListCommand.String("collection", "", "show only this collection. Use '_default_' for the empty-named collection.")
+	c.dataCenter = volumeListCommand.String("dataCenter", "", "show only this data center")
+	c.rack = volumeListCommand.String("rack", "", "show only this rack")
+	c.dataNode = volumeListCommand.String("dataNode", "", "show only this volume server, e.g. 192.168.1.2:8080")
 	c.readonly = volumeListCommand.Bool("readonly", false, "show only readonly")
+	c.writable = volumeListCommand.Bool("writable", false, "show only writable")
 	c.volumeId = volumeListCommand.Uint64("volumeId", 0, "show only volume id")
+	c.minSizeMB = volumeListCommand.Uint64("minSizeMB", 0, "show only volumes with at least this size in MB")
+	c.maxSizeMB = volumeListCommand.Uint64("maxSizeMB", 0, "show only volumes with at most this size in MB")
+	c.sortBy = volumeListCommand.String("sortBy", "", "list volumes flat, sorted by [id|size|fileCount|deleteCount|deletedBytes|garbage|modified]")
+	c.desc = volumeListCommand.Bool("desc", false, "sort in descending order")
+	c.limit = volumeListCommand.Int("limit", 0, "list volumes flat, at most this number of volumes per page")
+	c.page = volumeListCommand.Int("page", 1, "the page number starting from 1, used with -limit")
+	c.isJson = volumeListCommand.Bool("json", false, "list volumes flat in json format")
 
 	if err = volumeListCommand.Parse(args); err != nil {
 		return nil
 	}
 
+	if *c.readonly && *c.writable {
+		return fmt.Errorf("-readonly and -writable can not be used together")
+	}
+	if *c.limit < 0 || *c.page < 1 {
+		return fmt.Errorf("-limit should not be negative and -page should start from 1")
+	}
+	if *c.sortBy != "" && volumeListSortKeys[*c.sortBy] == nil {
+		return fmt.Errorf("unknown sort key %s", *c.sortBy)
+	}
+
 	// collect topology information
 	topologyInfo, volumeSizeLimitMb, err := collectTopologyInfo(commandEnv, 0)
 	if err != nil {
 		return err
 	}
 
+	if *c.sortBy != "" || *c.limit > 0 || *c.isJson || isJsonOutput(writer) {
+		return c.writeVolumeList(writer, topologyInfo)
+	}
+
 	c.writeTopologyInfo(writer, topologyInfo, volumeSizeLimitMb, *verbosityLevel)
 	return nil
 }
 
+// VolumeListEntry is one volume replica in the flat volume list.
+type VolumeListEntry struct {
+	DataCenter       string `json:"dataCenter"`
+	Rack             string `json:"rack"`
+	DataNode         string `json:"dataNode"`
+	DiskType         string `json:"diskType"`
+	Id               uint32 `json:"id"`
+	Collection       string `json:"collection"`
+	Size             uint64 `json:"size"`
+	FileCount        uint64 `json:"fileCount"`
+	DeleteCount      uint64 `json:"deleteCount"`
+	DeletedByteCount uint64 `json:"deletedByteCount"`
+	ReadOnly         bool   `json:"readOnly"`
+	ReplicaPlacement uint32 `json:"replicaPlacement"`
+	Ttl              uint32 `json:"ttl"`
+	Version          uint32 `json:"version"`
+	ModifiedAtSecond int64  `json:"modifiedAtSecond"`
+	RemoteStorage    string `json:"remoteStorage,omitempty"`
+}
+
+type VolumeListPage struct {
+	Total   int                `json:"total"`
+	Page    int                `json:"page"`
+	Limit   int                `json:"limit"`
+	Volumes []*VolumeListEntry `json:"volumes"`
+}
+
+var volumeListSortKeys = map[string]func(a, b *VolumeListEntry) bool{
+	"id": func(a, b *VolumeListEntry) bool {
+		return a.Id < b.Id
+	},
+	"size": func(a, b *VolumeListEntry) bool {
+		return a.Size < b.Size
+	},
+	"fileCount": func(a, b *VolumeListEntry) bool {
+		return a.FileCount < b.FileCount
+	},
+	"deleteCount": func(a, b *VolumeListEntry) bool {
+		return a.DeleteCount < b.DeleteCount
+	},
+	"deletedBytes": func(a, b *VolumeListEntry) bool {
+		return a.DeletedByteCount < b.DeletedByteCount
+	},
+	"garbage": func(a, b *VolumeListEntry) bool {
+		return a.garbageRatio() < b.garbageRatio()
+	},
+	"modified": func(a, b *VolumeListEntry) bool {
+		return a.ModifiedAtSecond < b.ModifiedAtSecond
+	},
+}
+
+func (e *VolumeListEntry) garbageRatio() float64 {
+	if e.Size == 0 {
+		return 0
+	}
+	return float64(e.DeletedByteCount) / float64(e.Size)
+}
+
+func (c *commandVolumeList) writeVolumeList(writer io.Writer, t *master_pb.TopologyInfo) error {
+	volumes := c.collectVolumeListEntries(t)
+	c.sortVolumeListEntries(volumes)
+
+	page := &VolumeListPage{
+		Total:   len(volumes),
+		Page:    *c.page,
+		Limit:   *c.limit,
+		Volumes: paginateVolumeListEntries(volumes, *c.limit, *c.page),
+	}
+
+	if writeCommandData(writer, page) {
+		return nil
+	}
+	if *c.isJson {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(page)
+	}
+
+	for _, v := range page.Volumes {
+		fmt.Fprintf(writer, "volume %d collection:%q size:%d file_count:%d delete_count:%d deleted_bytes:%d readonly:%v dataNode:%s disk:%s rack:%s dataCenter:%s\n",
+			v.Id, v.Collection, v.Size, v.FileCount, v.DeleteCount, v.DeletedByteCount, v.ReadOnly, v.DataNode, v.DiskType, v.Rack, v.DataCenter)
+	}
+	if *c.limit > 0 {
+		fmt.Fprintf(writer, "page %d, %d of %d volumes\n", *c.page, len(page.Volumes), page.Total)
+	} else {
+		fmt.Fprintf(writer, "total %d volumes\n", page.Total)
+	}
+	return nil
+}
+
+func (c *commandVolumeList) collectVolumeListEntries(t *master_pb.TopologyInfo) (volumes []*VolumeListEntry) {
+	for _, dc := range t.DataCenterInfos {
+		if c.isNotMatchDataCenter(dc.Id) {
+			continue
+		}
+		for _, r := range dc.RackInfos {
+			if c.isNotMatchRack(r.Id) {
+				continue
+			}
+			for _, dn := range r.DataNodeInfos {
+				if c.isNotMatchDataNode(dn.Id) {
+					continue
+				}
+				for _, diskInfo := range dn.DiskInfos {
+					diskType := diskInfo.Type
+					if diskType == "" {
+						diskType = "hdd"
+					}
+					for _, vi := range diskInfo.VolumeInfos {
+						if c.isNotMatchVolume(vi) {
+							continue
+						}
+						volumes = append(volumes, &VolumeListEntry{
+							DataCenter:       dc.Id,
+							Rack:             r.Id,
+							DataNode:         dn.Id,
+							DiskType:         diskType,
+							Id:               vi.Id,
+							Collection:       vi.Collection,
+							Size:             vi.Size,
+							FileCount:        vi.FileCount,
+							DeleteCount:      vi.DeleteCount,
+							DeletedByteCount: vi.DeletedByteCount,
+							ReadOnly:         vi.ReadOnly,
+							ReplicaPlacement: vi.ReplicaPlacement,
+							Ttl:              vi.Ttl,
+							Version:          vi.Version,
+							ModifiedAtSecond: vi.ModifiedAtSecond,
+							RemoteStorage:    vi.RemoteStorageName,
+						})
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+func (c *commandVolumeList) sortVolumeListEntries(volumes []*VolumeListEntry) {
+	sortKey := *c.sortBy
+	if sortKey == "" {
+		sortKey = "id"
+	}
+	less := volumeListSortKeys[sortKey]
+	slices.SortStableFunc(volumes, func(a, b *VolumeListEntry) bool {
+		if less(a, b) {
+			return !*c.desc
+		}
+		if less(b, a) {
+			return *c.desc
+		}
+		// keep the replicas of one volume together and in a stable order
+		if a.Id != b.Id {
+			return a.Id < b.Id
+		}
+		return a.DataNode < b.DataNode
+	})
+}
+
+func paginateVolumeListEntries(volumes []*VolumeListEntry, limit, page int) []*VolumeListEntry {
+	if limit <= 0 {
+		return volumes
+	}
+	start := (page - 1) * limit
+	if start >= len(volumes) {
+		return nil
+	}
+	stop := start + limit
+	if stop > len(volumes) {
+		stop = len(volumes)
+	}
+	return volumes[start:stop]
+}
+
 func diskInfosToString(diskInfos map[string]*master_pb.DiskInfo) string {
 	var buf bytes.Buffer
 	for diskType, diskInfo := range diskInfos {
@@ -80,6 +305,9 @@ func (c *commandVolumeList) writeTopologyInfo(writer io.Writer, t *master_pb.Top
 	})
 	var s statistics
 	for _, dc := range t.DataCenterInfos {
+		if c.isNotMatchDataCenter(dc.Id) {
+			continue
+		}
 		s = s.plus(c.writeDataCenterInfo(writer, dc, verbosityLevel))
 	}
 	output(verbosityLevel >= 0, writer, "%+v \n", s)
@@ -93,6 +321,9 @@ func (c *commandVolumeList) writeDataCenterInfo(writer io.Writer, t *master_pb.D
 		return a.Id < b.Id
 	})
 	for _, r := range t.RackInfos {
+		if c.isNotMatchRack(r.Id) {
+			continue
+		}
 		s = s.plus(c.writeRackInfo(writer, r, verbosityLevel))
 	}
 	output(verbosityLevel >= 1, writer, "  DataCenter %s %+v \n", t.Id, s)
@@ -106,6 +337,9 @@ func (c *commandVolumeList) writeRackInfo(writer io.Writer, t *master_pb.RackInf
 		return a.Id < b.Id
 	})
 	for _, dn := range t.DataNodeInfos {
+		if c.isNotMatchDataNode(dn.Id) {
+			continue
+		}
 		s = s.plus(c.writeDataNodeInfo(writer, dn, verbosityLevel))
 	}
 	output(verbosityLevel >= 2, writer, "    Rack %s %+v \n", t.Id, s)
@@ -113,7 +347,17 @@ func (c *commandVolumeList) writeRackInfo(writer io.Writer, t *master_pb.RackInf
 }
 
 func (c *commandVolumeList) writeDataNodeInfo(writer io.Writer, t *master_pb.DataNodeInfo, verbosityLevel int) statistics {
-	output(verbosityLevel >= 3, writer, "      DataNode %s%s\n", t.Id, diskInfosToString(t.DiskInfos))
+	readOnly := ""
+	if t.IsReadonly {
+		readOnly = " readonly"
+	}
+	output(verbosityLevel >= 3, writer, "      DataNode %s%s%s\n", t.Id, readOnly, diskInfosToString(t.DiskInfos))
+	for _, diskHealth := range t.DiskHealths {
+		writeDiskLocationSpace(writer, diskHealth, verbosityLevel)
+	}
+	for _, q := range t.QuarantinedVolumes {
+		output(verbosityLevel >= 3, writer, "        quarantined volume %d collection:%q dir:%s reason:%s\n", q.Id, q.Collection, q.Dir, q.Reason)
+	}
 	var s statistics
 	for _, diskInfo := range t.DiskInfos {
 		s = s.plus(c.writeDiskInfo(writer, diskInfo, verbosityLevel))
@@ -122,10 +366,60 @@ func (c *commandVolumeList) writeDataNodeInfo(writer io.Writer, t *master_pb.Dat
 	return s
 }
 
+func writeDiskLocationSpace(writer io.Writer, t *master_pb.DiskHealth, verbosityLevel int) {
+	spaceLow := ""
+	if t.IsSpaceLow {
+		spaceLow = " space low"
+	}
+	var free uint64
+	if t.All > t.Used {
+		free = t.All - t.Used
+	}
+	output(verbosityLevel >= 3, writer, "        Location %s %s free:%s/%s%s\n",
+		t.Dir, t.DiskType, util.BytesToHumanReadable(free), util.BytesToHumanReadable(t.All), spaceLow)
+}
+
+func (c *commandVolumeList) isNotMatchDataCenter(dataCenter string) bool {
+	return *c.dataCenter != "" && *c.dataCenter != dataCenter
+}
+
+func (c *commandVolumeList) isNotMatchRack(rack string) bool {
+	return *c.rack != "" && *c.rack != rack
+}
+
+func (c *commandVolumeList) isNotMatchDataNode(dataNode string) bool {
+	return *c.dataNode != "" && *c.dataNode != dataNode
+}
+
+func (c *commandVolumeList) isNotMatchVolume(vi *master_pb.VolumeInformationMessage) bool {
+	if c.isNotMatchDiskInfo(vi.ReadOnly, vi.Collection, vi.Id) {
+		return true
+	}
+	if *c.minSizeMB > 0 && vi.Size < *c.minSizeMB*1024*1024 {
+		return true
+	}
+	if *c.maxSizeMB > 0 && vi.Size > *c.maxSizeMB*1024*1024 {
+		return true
+	}
+	return false
+}
+
 func (c *commandVolumeList) isNotMatchDiskInfo(readOnly bool, collection string, volumeId uint32) bool {
 	if *c.readonly && !readOnly {
 		return true
 	}
+	if *c.writable && readOnly {
+		return true
+	}
+	if *c.collection != "" {
+		if *c.collection == "_default_" {
+			if collection != "" {
+				return true
+			}
+		} else if *c.collection != collection {
+			return true
+		}
+	}
 	if *c.collectionPattern != "" {
 		if matched, _ := filepath.Match(*c.collectionPattern, collection); !matched {
 			return true
@@ -148,13 +442,13 @@ func (c *commandVolumeList) writeDiskInfo(writer io.Writer, t *master_pb.DiskInf
 		return a.Id < b.Id
 	})
 	for _, vi := range t.VolumeInfos {
-		if c.isNotMatchDiskInfo(vi.ReadOnly, vi.Collection, vi.Id) {
+		if c.isNotMatchVolume(vi) {
 			continue
 		}
 		s = s.plus(writeVolumeInformationMessage(writer, vi, verbosityLevel))
 	}
 	for _, ecShardInfo := range t.EcShardInfos {
-		if c.isNotMatchDiskInfo(false, ecShardInfo.Collection, ecShardInfo.Id) {
+		if *c.minSizeMB > 0 || *c.maxSizeMB > 0 || c.isNotMatchDiskInfo(false, ecShardInfo.Collection, ecShardInfo.Id) {
 			continue
 		}
 		output(verbosityLevel >= 5, writer, "          ec volume id:%v collection:%v shards:%v\n", ecShardInfo.Id, ecShardInfo.Collection, erasure_coding.ShardBits(ecShardInfo.EcIndexBits).ShardIds())
@@ -20,6 +20,84 @@ func TestParsing(t *testing.T) {
 
 }
 
+func TestVolumeListFilterSortAndPaginate(t *testing.T) {
+	topo := parseOutput(topoData)
+
+	c := newTestVolumeListCommand()
+	*c.collection = "collection0"
+	*c.dataCenter = "dc2"
+	*c.sortBy = "size"
+	*c.desc = true
+
+	volumes := c.collectVolumeListEntries(topo)
+	c.sortVolumeListEntries(volumes)
+	assert.NotEmpty(t, volumes)
+	for i, v := range volumes {
+		assert.Equal(t, "collection0", v.Collection)
+		assert.Equal(t, "dc2", v.DataCenter)
+		if i > 0 {
+			assert.GreaterOrEqual(t, volumes[i-1].Size, v.Size)
+		}
+	}
+
+	firstPage := paginateVolumeListEntries(volumes, 2, 1)
+	secondPage := paginateVolumeListEntries(volumes, 2, 2)
+	assert.Equal(t, volumes[0:2], firstPage)
+	assert.Equal(t, volumes[2:4], secondPage)
+	assert.Empty(t, paginateVolumeListEntries(volumes, 2, len(volumes)))
+}
+
+func TestVolumeListSizeFilter(t *testing.T) {
+	topo := parseOutput(topoData)
+
+	c := newTestVolumeListCommand()
+	*c.minSizeMB = 1000
+	for _, v := range c.collectVolumeListEntries(topo) {
+		assert.GreaterOrEqual(t, v.Size, uint64(1000*1024*1024))
+	}
+
+	c = newTestVolumeListCommand()
+	*c.maxSizeMB = 10
+	for _, v := range c.collectVolumeListEntries(topo) {
+		assert.LessOrEqual(t, v.Size, uint64(10*1024*1024))
+	}
+}
+
+func TestVolumeListJsonEnvelopeData(t *testing.T) {
+	topo := parseOutput(topoData)
+
+	c := newTestVolumeListCommand()
+	*c.page = 1
+	out := &commandOutput{}
+	assert.Nil(t, c.writeVolumeList(out, topo))
+
+	page, ok := out.data.(*VolumeListPage)
+	assert.True(t, ok)
+	assert.Equal(t, len(c.collectVolumeListEntries(topo)), page.Total)
+	assert.Equal(t, page.Total, len(page.Volumes))
+	assert.Empty(t, out.String(), "the volumes are only in the data")
+}
+
+func newTestVolumeListCommand() *commandVolumeList {
+	return &commandVolumeList{
+		collectionPattern: new(string),
+		collection:        new(string),
+		dataCenter:        new(string),
+		rack:              new(string),
+		dataNode:          new(string),
+		readonly:          new(bool),
+		writable:          new(bool),
+		volumeId:          new(uint64),
+		minSizeMB:         new(uint64),
+		maxSizeMB:         new(uint64),
+		sortBy:            new(string),
+		desc:              new(bool),
+		limit:             new(int),
+		page:              new(int),
+		isJson:            new(bool),
+	}
+}
+
 func parseOutput(output string) *master_pb.TopologyInfo {
 	lines := strings.Split(output, "\n")
 	var topo *master_pb.TopologyInfo
@@ -1,11 +1,17 @@
 package shell
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
+	"sort"
 
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 )
 
@@ -21,9 +27,18 @@ func (c *commandVolumeMark) Name() string {
 }
 
 func (c *commandVolumeMark) Help() string {
-	return `Mark volume writable or readonly from one volume server
+	return `Mark volume writable or readonly on all its replicas, or on one volume server
 
+	# all replicas of one volume
+	volume.mark -volumeId <volume id> -writable or -readonly
+	# all replicas of all volumes of a collection
+	volume.mark -collection <collection name> -writable or -readonly
+	# only the replica on one volume server
 	volume.mark -node <volume server host:port> -volumeId <volume id> -writable or -readonly
+
+	The previous state of each replica is printed. The state of all replicas is verified after the change,
+	and if any replica fails, the replicas already changed are reverted to their previous state.
+	The volumes of a collection are changed one by one, stopping at the first failed volume.
 `
 }
 
@@ -31,7 +46,8 @@ func (c *commandVolumeMark) Do(args []string, commandEnv *CommandEnv, writer io.
 
 	volMarkCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
 	volumeIdInt := volMarkCommand.Int("volumeId", 0, "the volume id")
-	nodeStr := volMarkCommand.String("node", "", "the volume server <host>:<port>")
+	collection := volMarkCommand.String("collection", "", "mark all volumes of the collection")
+	nodeStr := volMarkCommand.String("node", "", "only mark the replica on the volume server <host>:<port>")
 	writable := volMarkCommand.Bool("writable", false, "volume mark writable")
 	readonly := volMarkCommand.Bool("readonly", false, "volume mark readonly")
 	if err = volMarkCommand.Parse(args); err != nil {
@@ -43,14 +59,141 @@ func (c *commandVolumeMark) Do(args []string, commandEnv *CommandEnv, writer io.
 	} else if *writable {
 		markWritable = true
 	}
+	if (*volumeIdInt == 0) == (*collection == "") {
+		return fmt.Errorf("use -volumeId or -collection")
+	}
+	if *nodeStr != "" && *volumeIdInt == 0 {
+		return fmt.Errorf("-node needs -volumeId")
+	}
 
 	if err = commandEnv.confirmIsLocked(args); err != nil {
 		return
 	}
 
-	sourceVolumeServer := rpc.ServerAddress(*nodeStr)
+	marker := &grpcReplicaMarker{grpcDialOption: commandEnv.option.GrpcDialOption}
+
+	if *nodeStr != "" {
+		volumeId := needle.VolumeId(*volumeIdInt)
+		return markReplicasAtomically(marker, volumeId, []rpc.ServerAddress{rpc.ServerAddress(*nodeStr)}, markWritable, writer)
+	}
+
+	topologyInfo, _, err := collectTopologyInfo(commandEnv, 0)
+	if err != nil {
+		return err
+	}
+	volumeReplicas, _ := collectVolumeReplicaLocations(topologyInfo)
+
+	var volumeIds []uint32
+	if *volumeIdInt != 0 {
+		if _, found := volumeReplicas[uint32(*volumeIdInt)]; !found {
+			return fmt.Errorf("volume %d not found", *volumeIdInt)
+		}
+		volumeIds = append(volumeIds, uint32(*volumeIdInt))
+	} else {
+		for vid, replicas := range volumeReplicas {
+			if replicas[0].info.Collection == *collection {
+				volumeIds = append(volumeIds, vid)
+			}
+		}
+		if len(volumeIds) == 0 {
+			return fmt.Errorf("no volumes in collection %s", *collection)
+		}
+		sort.Slice(volumeIds, func(i, j int) bool {
+			return volumeIds[i] < volumeIds[j]
+		})
+	}
+
+	for _, vid := range volumeIds {
+		var servers []rpc.ServerAddress
+		for _, replica := range volumeReplicas[vid] {
+			servers = append(servers, rpc.NewServerAddressFromDataNode(replica.location.dataNode))
+		}
+		if err = markReplicasAtomically(marker, needle.VolumeId(vid), servers, markWritable, writer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replicaMarker reads and changes the readonly state of one volume replica
+type replicaMarker interface {
+	isReadonly(volumeId needle.VolumeId, server rpc.ServerAddress) (bool, error)
+	markWritable(volumeId needle.VolumeId, server rpc.ServerAddress, writable bool) error
+}
+
+type grpcReplicaMarker struct {
+	grpcDialOption grpc.DialOption
+}
+
+func (m *grpcReplicaMarker) isReadonly(volumeId needle.VolumeId, server rpc.ServerAddress) (isReadonly bool, err error) {
+	err = operation.WithVolumeServerClient(false, server, m.grpcDialOption, func(volumeServerClient volume_server_pb.VolumeServerClient) error {
+		resp, statusErr := volumeServerClient.VolumeStatus(context.Background(), &volume_server_pb.VolumeStatusRequest{
+			VolumeId: uint32(volumeId),
+		})
+		if statusErr != nil {
+			return statusErr
+		}
+		// the readonly state by the disk space or the volume server is not changed by marking the volume
+		isReadonly = resp.IsMarkedReadOnly
+		return nil
+	})
+	return
+}
+
+func (m *grpcReplicaMarker) markWritable(volumeId needle.VolumeId, server rpc.ServerAddress, writable bool) error {
+	return markVolumeWritable(m.grpcDialOption, volumeId, server, writable)
+}
+
+// markReplicasAtomically changes all replicas of the volume, or reverts the changed ones to their previous state.
+func markReplicasAtomically(marker replicaMarker, volumeId needle.VolumeId, servers []rpc.ServerAddress, writable bool, writer io.Writer) error {
+
+	stateOf := func(isReadonly bool) string {
+		if isReadonly {
+			return "readonly"
+		}
+		return "writable"
+	}
+
+	previousReadonly := make(map[rpc.ServerAddress]bool)
+	for _, server := range servers {
+		isReadonly, err := marker.isReadonly(volumeId, server)
+		if err != nil {
+			return fmt.Errorf("volume %d on %s: read state: %v", volumeId, server, err)
+		}
+		previousReadonly[server] = isReadonly
+		fmt.Fprintf(writer, "volume %d on %s was %s\n", volumeId, server, stateOf(isReadonly))
+	}
+
+	var changed []rpc.ServerAddress
+	var markErr error
+	for _, server := range servers {
+		changed = append(changed, server)
+		if markErr = marker.markWritable(volumeId, server, writable); markErr != nil {
+			markErr = fmt.Errorf("volume %d on %s: mark %s: %v", volumeId, server, stateOf(!writable), markErr)
+			break
+		}
+		isReadonly, err := marker.isReadonly(volumeId, server)
+		if err != nil {
+			markErr = fmt.Errorf("volume %d on %s: verify: %v", volumeId, server, err)
+			break
+		}
+		if isReadonly == writable {
+			markErr = fmt.Errorf("volume %d on %s: still %s", volumeId, server, stateOf(isReadonly))
+			break
+		}
+	}
 
-	volumeId := needle.VolumeId(*volumeIdInt)
+	if markErr == nil {
+		fmt.Fprintf(writer, "volume %d is %s on %d replicas\n", volumeId, stateOf(!writable), len(servers))
+		return nil
+	}
 
-	return markVolumeWritable(commandEnv.option.GrpcDialOption, volumeId, sourceVolumeServer, markWritable)
+	for _, server := range changed {
+		if err := marker.markWritable(volumeId, server, !previousReadonly[server]); err != nil {
+			fmt.Fprintf(writer, "volume %d on %s: revert to %s: %v\n", volumeId, server, stateOf(previousReadonly[server]), err)
+			continue
+		}
+		fmt.Fprintf(writer, "volume %d on %s reverted to %s\n", volumeId, server, stateOf(previousReadonly[server]))
+	}
+	return markErr
 }
@@ -0,0 +1,61 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+)
+
+type fakeReplicaMarker struct {
+	readonly map[rpc.ServerAddress]bool
+	// the servers failing to be marked, or ignoring the change
+	failing map[rpc.ServerAddress]bool
+	ignored map[rpc.ServerAddress]bool
+}
+
+func (m *fakeReplicaMarker) isReadonly(volumeId needle.VolumeId, server rpc.ServerAddress) (bool, error) {
+	return m.readonly[server], nil
+}
+
+func (m *fakeReplicaMarker) markWritable(volumeId needle.VolumeId, server rpc.ServerAddress, writable bool) error {
+	if m.failing[server] {
+		return fmt.Errorf("connection refused")
+	}
+	if !m.ignored[server] {
+		m.readonly[server] = !writable
+	}
+	return nil
+}
+
+func TestMarkReplicasAtomically(t *testing.T) {
+	servers := []rpc.ServerAddress{"dn1:8080", "dn2:8080", "dn3:8080"}
+
+	marker := &fakeReplicaMarker{readonly: map[rpc.ServerAddress]bool{"dn2:8080": true}}
+	var buf bytes.Buffer
+	assert.Nil(t, markReplicasAtomically(marker, 7, servers, false, &buf))
+	assert.Equal(t, map[rpc.ServerAddress]bool{"dn1:8080": true, "dn2:8080": true, "dn3:8080": true}, marker.readonly)
+	assert.Contains(t, buf.String(), "volume 7 on dn1:8080 was writable")
+	assert.Contains(t, buf.String(), "volume 7 on dn2:8080 was readonly")
+
+	// the third replica fails, the first two are reverted
+	marker = &fakeReplicaMarker{
+		readonly: map[rpc.ServerAddress]bool{"dn2:8080": true},
+		failing:  map[rpc.ServerAddress]bool{"dn3:8080": true},
+	}
+	assert.NotNil(t, markReplicasAtomically(marker, 7, servers, false, &bytes.Buffer{}))
+	assert.Equal(t, map[rpc.ServerAddress]bool{"dn1:8080": false, "dn2:8080": true}, marker.readonly)
+
+	// the second replica does not change, failing the verification
+	marker = &fakeReplicaMarker{
+		readonly: map[rpc.ServerAddress]bool{"dn1:8080": true, "dn2:8080": true, "dn3:8080": true},
+		ignored:  map[rpc.ServerAddress]bool{"dn2:8080": true},
+	}
+	err := markReplicasAtomically(marker, 7, servers, true, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "still readonly")
+	assert.Equal(t, map[rpc.ServerAddress]bool{"dn1:8080": true, "dn2:8080": true, "dn3:8080": true}, marker.readonly)
+}
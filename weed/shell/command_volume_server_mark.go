@@ -0,0 +1,80 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+)
+
+func init() {
+	Commands = append(Commands, &commandVolumeServerMark{})
+}
+
+type commandVolumeServerMark struct {
+}
+
+func (c *commandVolumeServerMark) Name() string {
+	return "volumeServer.mark"
+}
+
+func (c *commandVolumeServerMark) Help() string {
+	return `mark all volumes on one volume server readonly or writable
+
+	volumeServer.mark -node <volume server host:port> -readonly
+	volumeServer.mark -node <volume server host:port> -writable
+
+	This is useful when replacing a disk or when the hardware is degraded.
+	A readonly volume server still serves reads and deletes, but refuses writes and new volumes,
+	and the master stops assigning writes to it.
+	Volumes marked readonly individually by volume.mark stay readonly after -writable.
+
+	The readonly mode is not persisted. A restarted volume server is writable again.
+`
+}
+
+func (c *commandVolumeServerMark) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	vsMarkCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	nodeStr := vsMarkCommand.String("node", "", "the volume server <host>:<port>")
+	writable := vsMarkCommand.Bool("writable", false, "mark the volume server writable")
+	readonly := vsMarkCommand.Bool("readonly", false, "mark the volume server readonly")
+	if err = vsMarkCommand.Parse(args); err != nil {
+		return nil
+	}
+	if *writable == *readonly {
+		return fmt.Errorf("use -readonly or -writable")
+	}
+	if *nodeStr == "" {
+		return fmt.Errorf("need to specify volume server by -node=<host>:<port>")
+	}
+
+	if err = commandEnv.confirmIsLocked(args); err != nil {
+		return
+	}
+
+	return markVolumeServerReadonly(commandEnv.option.GrpcDialOption, rpc.ServerAddress(*nodeStr), *readonly, writer)
+}
+
+func markVolumeServerReadonly(grpcDialOption grpc.DialOption, volumeServer rpc.ServerAddress, isReadonly bool, writer io.Writer) error {
+	return operation.WithVolumeServerClient(false, volumeServer, grpcDialOption, func(volumeServerClient volume_server_pb.VolumeServerClient) error {
+		resp, markErr := volumeServerClient.VolumeServerMarkReadonly(context.Background(), &volume_server_pb.VolumeServerMarkReadonlyRequest{
+			IsReadonly: isReadonly,
+		})
+		if markErr != nil {
+			return fmt.Errorf("mark volume server %s readonly=%v: %v", volumeServer, isReadonly, markErr)
+		}
+		state := "writable"
+		if isReadonly {
+			state = "readonly"
+		}
+		fmt.Fprintf(writer, "volume server %s is %s, with %d volumes\n", volumeServer, state, resp.VolumeCount)
+		return nil
+	})
+}
@@ -0,0 +1,120 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+func init() {
+	Commands = append(Commands, &commandVolumeStatus{})
+}
+
+type commandVolumeStatus struct {
+}
+
+func (c *commandVolumeStatus) Name() string {
+	return "volume.status"
+}
+
+func (c *commandVolumeStatus) Help() string {
+	return `show the detailed status of a volume on all its replicas, or on one volume server
+
+	volume.status <volume id>
+	volume.status -node <volume server host:port> <volume id>
+
+	This command asks the volume servers directly, to look into an unhealthy volume:
+	why it is readonly, its last IO error, the compaction revision, the index type,
+	the file and delete counts, and the free space of the disk it is on.
+`
+}
+
+func (c *commandVolumeStatus) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	volStatusCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	volumeIdInt := volStatusCommand.Int("volumeId", 0, "the volume id")
+	nodeStr := volStatusCommand.String("node", "", "only ask the volume server <host>:<port>")
+	if err = volStatusCommand.Parse(args); err != nil {
+		return nil
+	}
+	if *volumeIdInt == 0 && volStatusCommand.NArg() > 0 {
+		if *volumeIdInt, err = strconv.Atoi(volStatusCommand.Arg(0)); err != nil {
+			return fmt.Errorf("volume id %s: %v", volStatusCommand.Arg(0), err)
+		}
+	}
+	if *volumeIdInt <= 0 {
+		return fmt.Errorf("missing the volume id")
+	}
+	volumeId := needle.VolumeId(*volumeIdInt)
+
+	var servers []rpc.ServerAddress
+	if *nodeStr != "" {
+		servers = append(servers, rpc.ServerAddress(*nodeStr))
+	} else {
+		locations, found := commandEnv.MasterClient.GetLocations(uint32(volumeId))
+		if !found {
+			return fmt.Errorf("volume %d not found", volumeId)
+		}
+		for _, location := range locations {
+			servers = append(servers, location.ServerAddress())
+		}
+	}
+
+	for _, server := range servers {
+		var resp *volume_server_pb.VolumeStatusResponse
+		err = operation.WithVolumeServerClient(false, server, commandEnv.option.GrpcDialOption, func(volumeServerClient volume_server_pb.VolumeServerClient) error {
+			var statusErr error
+			resp, statusErr = volumeServerClient.VolumeStatus(context.Background(), &volume_server_pb.VolumeStatusRequest{
+				VolumeId: uint32(volumeId),
+			})
+			return statusErr
+		})
+		if err != nil {
+			fmt.Fprintf(writer, "volume %d on %s: %v\n\n", volumeId, server, err)
+			continue
+		}
+		writeVolumeStatus(writer, volumeId, server, resp)
+	}
+
+	return nil
+}
+
+func writeVolumeStatus(writer io.Writer, volumeId needle.VolumeId, server rpc.ServerAddress, resp *volume_server_pb.VolumeStatusResponse) {
+	fmt.Fprintf(writer, "volume %d on %s\n", volumeId, server)
+	fmt.Fprintf(writer, "  collection:          %q\n", resp.Collection)
+	if resp.IsReadOnly {
+		fmt.Fprintf(writer, "  state:               readonly (%s)\n", strings.Join(resp.ReadOnlyReasons, ", "))
+	} else {
+		fmt.Fprintf(writer, "  state:               writable\n")
+	}
+	if resp.IsCompacting {
+		fmt.Fprintf(writer, "  compacting:          true\n")
+	}
+	if resp.LastIoError != "" {
+		fmt.Fprintf(writer, "  last io error:       %s\n", resp.LastIoError)
+	}
+	fmt.Fprintf(writer, "  compaction revision: %d\n", resp.CompactionRevision)
+	fmt.Fprintf(writer, "  index type:          %s\n", resp.IndexType)
+	if resp.ChecksumAlgorithm != "" {
+		fmt.Fprintf(writer, "  checksum:            %s\n", resp.ChecksumAlgorithm)
+	}
+	fmt.Fprintf(writer, "  files:               %d, deleted %d (%d bytes)\n", resp.FileCount, resp.DeleteCount, resp.DeletedByteCount)
+	fmt.Fprintf(writer, "  dat/idx size:        %d / %d bytes\n", resp.DatFileSize, resp.IdxFileSize)
+	if resp.ModifiedAtSecond > 0 {
+		fmt.Fprintf(writer, "  modified at:         %s\n", time.Unix(resp.ModifiedAtSecond, 0).Format(time.RFC3339))
+	}
+	if disk := resp.DiskStatus; disk != nil {
+		fmt.Fprintf(writer, "  disk:                %s %s, %d of %d bytes free (%.2f%%)\n", types.ToDiskType(disk.DiskType).ReadableString(), disk.Dir, disk.Free, disk.All, disk.PercentFree)
+	}
+	fmt.Fprintln(writer)
+}
@@ -0,0 +1,39 @@
+package shell
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+)
+
+func TestWriteVolumeStatus(t *testing.T) {
+	var buf bytes.Buffer
+	writeVolumeStatus(&buf, 3, "localhost:8080", &volume_server_pb.VolumeStatusResponse{
+		IsReadOnly:         true,
+		ReadOnlyReasons:    []string{"disk space low at /data"},
+		LastIoError:        "input/output error",
+		CompactionRevision: 2,
+		IndexType:          "leveldb",
+		ChecksumAlgorithm:  "xxhash",
+		FileCount:          10,
+		DeleteCount:        4,
+		DiskStatus:         &volume_server_pb.DiskStatus{Dir: "/data", DiskType: "hdd", All: 1000, Free: 10, PercentFree: 1},
+	})
+	output := buf.String()
+	for _, expected := range []string{
+		"volume 3 on localhost:8080",
+		"readonly (disk space low at /data)",
+		"last io error:       input/output error",
+		"compaction revision: 2",
+		"index type:          leveldb",
+		"checksum:            xxhash",
+		"files:               10, deleted 4",
+		"hdd /data, 10 of 1000 bytes free",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("missing %q in:\n%s", expected, output)
+		}
+	}
+}
@@ -27,10 +27,6 @@ type volumeTierMoveJob struct {
 
 type commandVolumeTierMove struct {
 	activeServers sync.Map
-	queues        map[rpc.ServerAddress]chan volumeTierMoveJob
-	//activeServers     map[rpc.ServerAddress]struct{}
-	//activeServersLock sync.Mutex
-	//activeServersCond *sync.Cond
 }
 
 func (c *commandVolumeTierMove) Name() string {
@@ -42,6 +38,17 @@ func (c *commandVolumeTierMove) Help() string {
 
 	volume.tier.move -fromDiskType=hdd -toDiskType=ssd [-collectionPattern=""] [-fullPercent=95] [-quietFor=1h] [-parallelLimit=4]
 
+	# move the volumes on ssd not read for 30 days to hdd
+	volume.tier.move -fromDiskType=ssd -toDiskType=hdd -notReadFor=720h
+	# move the volumes on hdd read within the last day back to ssd, regardless of the fullness and writes
+	volume.tier.move -fromDiskType=hdd -toDiskType=ssd -readWithin=24h
+
+	The volume servers report the last read time of each volume, or the last modified time if not read
+	since the volume is created. With replicas, the most recent time of all replicas counts.
+
+	The moves are planned first and printed, and only executed with -force,
+	copying to each target server one volume at a time, on at most -parallelLimit target servers in parallel.
+
 	Even if the volume is replicated, only one replica will be changed and the rest replicas will be dropped.
 	So "volume.fix.replication" and "volume.balance" should be followed.
 
@@ -54,6 +61,8 @@ func (c *commandVolumeTierMove) Do(args []string, commandEnv *CommandEnv, writer
 	collectionPattern := tierCommand.String("collectionPattern", "", "match with wildcard characters '*' and '?'")
 	fullPercentage := tierCommand.Float64("fullPercent", 95, "the volume reaches the percentage of max volume size")
 	quietPeriod := tierCommand.Duration("quietFor", 24*time.Hour, "select volumes without no writes for this period")
+	notReadFor := tierCommand.Duration("notReadFor", 0, "select volumes without reads or writes for this period")
+	readWithin := tierCommand.Duration("readWithin", 0, "select volumes read within this period, ignoring -fullPercent and -quietFor")
 	source := tierCommand.String("fromDiskType", "", "the source disk type")
 	target := tierCommand.String("toDiskType", "", "the target disk type")
 	parallelLimit := tierCommand.Int("parallelLimit", 0, "limit the number of parallel copying jobs")
@@ -63,6 +72,10 @@ func (c *commandVolumeTierMove) Do(args []string, commandEnv *CommandEnv, writer
 	}
 	infoAboutSimulationMode(writer, *applyChange, "-force")
 
+	if *notReadFor > 0 && *readWithin > 0 {
+		return fmt.Errorf("-notReadFor and -readWithin can not be used together")
+	}
+
 	if err = commandEnv.confirmIsLocked(args); err != nil {
 		return
 	}
@@ -81,11 +94,11 @@ func (c *commandVolumeTierMove) Do(args []string, commandEnv *CommandEnv, writer
 	}
 
 	// collect all volumes that should change
-	volumeIds, err := collectVolumeIdsForTierChange(commandEnv, topologyInfo, volumeSizeLimitMb, fromDiskType, *collectionPattern, *fullPercentage, *quietPeriod)
+	volumeIds, err := collectVolumeIdsForTierChange(commandEnv, topologyInfo, volumeSizeLimitMb, fromDiskType, *collectionPattern, *fullPercentage, *quietPeriod, *notReadFor, *readWithin)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("tier move volumes: %v\n", volumeIds)
+	fmt.Fprintf(writer, "tier move volumes: %v\n", volumeIds)
 
 	_, allLocations := collectVolumeReplicaLocations(topologyInfo)
 	allLocations = filterLocationsByDiskType(allLocations, toDiskType)
@@ -95,46 +108,42 @@ func (c *commandVolumeTierMove) Do(args []string, commandEnv *CommandEnv, writer
 		allLocations = allLocations[:*parallelLimit]
 	}
 
-	wg := sync.WaitGroup{}
-	bufferLen := len(allLocations)
-	c.queues = make(map[rpc.ServerAddress]chan volumeTierMoveJob)
+	// plan all moves before changing anything, counting the planned volumes on the target servers
+	plan := make(map[rpc.ServerAddress][]volumeTierMoveJob)
+	targets := make(map[rpc.ServerAddress]location)
+	for _, vid := range volumeIds {
+		if err = c.planVolumeTierMove(commandEnv, writer, vid, toDiskType, allLocations, plan, targets); err != nil {
+			fmt.Fprintf(writer, "tier move volume %d: %v\n", vid, err)
+		}
+		allLocations = rotateDataNodes(allLocations)
+	}
 
-	for _, dst := range allLocations {
-		destServerAddress := rpc.NewServerAddressFromDataNode(dst.dataNode)
-		c.queues[destServerAddress] = make(chan volumeTierMoveJob, bufferLen)
+	if !*applyChange {
+		return nil
+	}
 
+	// one worker per target server, so the copying is bounded by the number of target servers
+	wg := sync.WaitGroup{}
+	for destServerAddress, jobs := range plan {
 		wg.Add(1)
-		go func(dst location, jobs <-chan volumeTierMoveJob, applyChanges bool) {
+		go func(dst location, jobs []volumeTierMoveJob) {
 			defer wg.Done()
-			for job := range jobs {
+			for _, job := range jobs {
 				fmt.Fprintf(writer, "moving volume %d from %s to %s with disk type %s ...\n", job.vid, job.src, dst.dataNode.Id, toDiskType.ReadableString())
 
 				locations, found := commandEnv.MasterClient.GetLocations(uint32(job.vid))
 				if !found {
-					fmt.Printf("volume %d not found", job.vid)
+					fmt.Fprintf(writer, "volume %d not found\n", job.vid)
 					continue
 				}
 
 				unlock := c.Lock(job.src)
-
-				if applyChanges {
-					if err := c.doMoveOneVolume(commandEnv, writer, job.vid, toDiskType, locations, job.src, dst); err != nil {
-						fmt.Fprintf(writer, "move volume %d %s => %s: %v\n", job.vid, job.src, dst.dataNode.Id, err)
-					}
+				if err := c.doMoveOneVolume(commandEnv, writer, job.vid, toDiskType, locations, job.src, dst); err != nil {
+					fmt.Fprintf(writer, "move volume %d %s => %s: %v\n", job.vid, job.src, dst.dataNode.Id, err)
 				}
 				unlock()
 			}
-		}(dst, c.queues[destServerAddress], *applyChange)
-	}
-
-	for _, vid := range volumeIds {
-		if err = c.doVolumeTierMove(commandEnv, writer, vid, toDiskType, allLocations); err != nil {
-			fmt.Printf("tier move volume %d: %v\n", vid, err)
-		}
-		allLocations = rotateDataNodes(allLocations)
-	}
-	for key, _ := range c.queues {
-		close(c.queues[key])
+		}(targets[destServerAddress], jobs)
 	}
 
 	wg.Wait()
@@ -177,7 +186,7 @@ func isOneOf(server string, locations []wdclient.Location) bool {
 	return false
 }
 
-func (c *commandVolumeTierMove) doVolumeTierMove(commandEnv *CommandEnv, writer io.Writer, vid needle.VolumeId, toDiskType types.DiskType, allLocations []location) (err error) {
+func (c *commandVolumeTierMove) planVolumeTierMove(commandEnv *CommandEnv, writer io.Writer, vid needle.VolumeId, toDiskType types.DiskType, allLocations []location, plan map[rpc.ServerAddress][]volumeTierMoveJob, targets map[rpc.ServerAddress]location) (err error) {
 	// find volume location
 	locations, found := commandEnv.MasterClient.GetLocations(uint32(vid))
 	if !found {
@@ -185,36 +194,36 @@ func (c *commandVolumeTierMove) doVolumeTierMove(commandEnv *CommandEnv, writer
 	}
 
 	// find one server with the most empty volume slots with target disk type
-	hasFoundTarget := false
 	fn := capacityByFreeVolumeCount(toDiskType)
 	for _, dst := range allLocations {
-		if fn(dst.dataNode) > 0 && !hasFoundTarget {
-			// ask the volume server to replicate the volume
-			if isOneOf(dst.dataNode.Id, locations) {
-				continue
-			}
-			var sourceVolumeServer rpc.ServerAddress
-			for _, loc := range locations {
-				if loc.Url != dst.dataNode.Id {
-					sourceVolumeServer = loc.ServerAddress()
-				}
-			}
-			if sourceVolumeServer == "" {
-				continue
+		if fn(dst.dataNode) <= 0 {
+			continue
+		}
+		// ask the volume server to replicate the volume
+		if isOneOf(dst.dataNode.Id, locations) {
+			continue
+		}
+		var sourceVolumeServer rpc.ServerAddress
+		for _, loc := range locations {
+			if loc.Url != dst.dataNode.Id {
+				sourceVolumeServer = loc.ServerAddress()
 			}
-			hasFoundTarget = true
+		}
+		if sourceVolumeServer == "" {
+			continue
+		}
 
-			// adjust volume count
-			dst.dataNode.DiskInfos[string(toDiskType)].VolumeCount++
+		// adjust volume count
+		dst.dataNode.DiskInfos[string(toDiskType)].VolumeCount++
 
-			destServerAddress := rpc.NewServerAddressFromDataNode(dst.dataNode)
-			c.queues[destServerAddress] <- volumeTierMoveJob{sourceVolumeServer, vid}
-		}
+		destServerAddress := rpc.NewServerAddressFromDataNode(dst.dataNode)
+		plan[destServerAddress] = append(plan[destServerAddress], volumeTierMoveJob{sourceVolumeServer, vid})
+		targets[destServerAddress] = dst
+		fmt.Fprintf(writer, "plan to move volume %d from %s to %s with disk type %s\n", vid, sourceVolumeServer, dst.dataNode.Id, toDiskType.ReadableString())
+		return nil
 	}
 
-	if !hasFoundTarget {
-		fmt.Fprintf(writer, "can not find disk type %s for volume %d\n", toDiskType.ReadableString(), vid)
-	}
+	fmt.Fprintf(writer, "can not find disk type %s for volume %d\n", toDiskType.ReadableString(), vid)
 
 	return nil
 }
@@ -251,12 +260,37 @@ func (c *commandVolumeTierMove) doMoveOneVolume(commandEnv *CommandEnv, writer i
 	return nil
 }
 
-func collectVolumeIdsForTierChange(commandEnv *CommandEnv, topologyInfo *master_pb.TopologyInfo, volumeSizeLimitMb uint64, sourceTier types.DiskType, collectionPattern string, fullPercentage float64, quietPeriod time.Duration) (vids []needle.VolumeId, err error) {
+func collectVolumeIdsForTierChange(commandEnv *CommandEnv, topologyInfo *master_pb.TopologyInfo, volumeSizeLimitMb uint64, sourceTier types.DiskType, collectionPattern string, fullPercentage float64, quietPeriod time.Duration, notReadFor time.Duration, readWithin time.Duration) (vids []needle.VolumeId, err error) {
 
 	quietSeconds := int64(quietPeriod / time.Second)
 	nowUnixSeconds := time.Now().Unix()
 
-	fmt.Printf("collect %s volumes quiet for: %d seconds\n", sourceTier, quietSeconds)
+	if readWithin > 0 {
+		fmt.Printf("collect %s volumes read within: %d seconds\n", sourceTier, int64(readWithin/time.Second))
+	} else {
+		fmt.Printf("collect %s volumes quiet for: %d seconds\n", sourceTier, quietSeconds)
+	}
+	if notReadFor > 0 {
+		fmt.Printf("collect %s volumes not read for: %d seconds\n", sourceTier, int64(notReadFor/time.Second))
+	}
+
+	// the reads can go to any replica, so the volume is as recently accessed as its most recently accessed replica
+	lastReads := make(map[uint32]int64)
+	lastAccesses := make(map[uint32]int64)
+	eachDataNode(topologyInfo, func(dc string, rack RackId, dn *master_pb.DataNodeInfo) {
+		for _, diskInfo := range dn.DiskInfos {
+			for _, v := range diskInfo.VolumeInfos {
+				if v.LastReadAtSecond > lastReads[v.Id] {
+					lastReads[v.Id] = v.LastReadAtSecond
+				}
+				for _, t := range []int64{v.LastReadAtSecond, v.ModifiedAtSecond} {
+					if t > lastAccesses[v.Id] {
+						lastAccesses[v.Id] = t
+					}
+				}
+			}
+		}
+	})
 
 	vidMap := make(map[uint32]bool)
 	eachDataNode(topologyInfo, func(dc string, rack RackId, dn *master_pb.DataNodeInfo) {
@@ -273,7 +307,22 @@ func collectVolumeIdsForTierChange(commandEnv *CommandEnv, topologyInfo *master_
 					}
 				}
 
-				if v.ModifiedAtSecond+quietSeconds < nowUnixSeconds && types.ToDiskType(v.DiskType) == sourceTier {
+				if types.ToDiskType(v.DiskType) != sourceTier {
+					continue
+				}
+
+				if readWithin > 0 {
+					if lastReads[v.Id] > 0 && lastReads[v.Id]+int64(readWithin/time.Second) >= nowUnixSeconds {
+						vidMap[v.Id] = true
+					}
+					continue
+				}
+
+				if notReadFor > 0 && lastAccesses[v.Id]+int64(notReadFor/time.Second) >= nowUnixSeconds {
+					continue
+				}
+
+				if v.ModifiedAtSecond+quietSeconds < nowUnixSeconds {
 					if float64(v.Size) > fullPercentage/100*float64(volumeSizeLimitMb)*1024*1024 {
 						vidMap[v.Id] = true
 					}
@@ -0,0 +1,288 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandVolumeTop{})
+}
+
+type commandVolumeTop struct {
+}
+
+func (c *commandVolumeTop) Name() string {
+	return "volume.top"
+}
+
+func (c *commandVolumeTop) Help() string {
+	return `show the busiest volumes by requests, bytes or latency
+
+	volume.top                                  # the 10 volumes with the most requests since the volume servers started
+	volume.top -interval=10s                    # the busiest volumes during the next 10 seconds, with rates per second
+	volume.top -sortBy=readP99 -n=20            # the 20 volumes with the slowest reads
+	volume.top -collection=pictures -node=192.168.1.2:8080
+
+	Each line is one volume replica on one volume server, since a hot volume loads the disk it is on.
+	The sort keys are requests, reads, writes, deletes, errors, readBytes, writeBytes, readP99 and writeP99.
+	The latency percentiles are estimated from power-of-two buckets, so they are upper bounds.
+
+	Hot volumes can be moved away with volume.move, or made readonly with volume.mark,
+	so new writes go to other volumes.
+
+`
+}
+
+// volumeTopEntry is the request statistics of one volume replica
+type volumeTopEntry struct {
+	node  rpc.ServerAddress
+	stats *volume_server_pb.VolumeRequestStats
+}
+
+var volumeTopSortKeys = map[string]func(s *volume_server_pb.VolumeRequestStats) float64{
+	"requests": func(s *volume_server_pb.VolumeRequestStats) float64 {
+		return float64(s.ReadCount + s.WriteCount + s.DeleteCount)
+	},
+	"reads": func(s *volume_server_pb.VolumeRequestStats) float64 {
+		return float64(s.ReadCount)
+	},
+	"writes": func(s *volume_server_pb.VolumeRequestStats) float64 {
+		return float64(s.WriteCount)
+	},
+	"deletes": func(s *volume_server_pb.VolumeRequestStats) float64 {
+		return float64(s.DeleteCount)
+	},
+	"errors": func(s *volume_server_pb.VolumeRequestStats) float64 {
+		return float64(s.ErrorCount)
+	},
+	"readBytes": func(s *volume_server_pb.VolumeRequestStats) float64 {
+		return float64(s.ReadBytes)
+	},
+	"writeBytes": func(s *volume_server_pb.VolumeRequestStats) float64 {
+		return float64(s.WriteBytes)
+	},
+	"readP99": func(s *volume_server_pb.VolumeRequestStats) float64 {
+		return float64(s.ReadLatencyP99Us)
+	},
+	"writeP99": func(s *volume_server_pb.VolumeRequestStats) float64 {
+		return float64(s.WriteLatencyP99Us)
+	},
+}
+
+func (c *commandVolumeTop) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	volumeTopCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	topN := volumeTopCommand.Int("n", 10, "show this number of volumes")
+	sortBy := volumeTopCommand.String("sortBy", "requests", "sort by [requests|reads|writes|deletes|errors|readBytes|writeBytes|readP99|writeP99]")
+	interval := volumeTopCommand.Duration("interval", 0, "measure during this interval instead of since the volume servers started")
+	collection := volumeTopCommand.String("collection", "", "show only this collection. Use '_default_' for the empty-named collection.")
+	node := volumeTopCommand.String("node", "", "show only this volume server, e.g. 192.168.1.2:8080")
+	if err = volumeTopCommand.Parse(args); err != nil {
+		return nil
+	}
+	sortKey, found := volumeTopSortKeys[*sortBy]
+	if !found {
+		return fmt.Errorf("unknown sort key %s", *sortBy)
+	}
+
+	var nodes []rpc.ServerAddress
+	if *node != "" {
+		nodes = append(nodes, rpc.ServerAddress(*node))
+	} else {
+		topologyInfo, _, err := collectTopologyInfo(commandEnv, 0)
+		if err != nil {
+			return err
+		}
+		eachDataNode(topologyInfo, func(dc string, rack RackId, dn *master_pb.DataNodeInfo) {
+			nodes = append(nodes, rpc.NewServerAddressFromDataNode(dn))
+		})
+	}
+
+	entries, since, err := collectVolumeTopEntries(commandEnv, nodes)
+	if err != nil {
+		return err
+	}
+	seconds := time.Since(since).Seconds()
+	if *interval > 0 {
+		time.Sleep(*interval)
+		after, _, err := collectVolumeTopEntries(commandEnv, nodes)
+		if err != nil {
+			return err
+		}
+		entries = diffVolumeTopEntries(entries, after)
+		seconds = interval.Seconds()
+	}
+
+	entries = filterVolumeTopEntries(entries, *collection)
+	sortVolumeTopEntries(entries, sortKey)
+	if *topN > 0 && len(entries) > *topN {
+		entries = entries[:*topN]
+	}
+
+	writeVolumeTopEntries(writer, entries, *interval > 0, seconds)
+	return nil
+}
+
+func collectVolumeTopEntries(commandEnv *CommandEnv, nodes []rpc.ServerAddress) (entries []*volumeTopEntry, since time.Time, err error) {
+	since = time.Now()
+	for _, node := range nodes {
+		err = operation.WithVolumeServerClient(false, node, commandEnv.option.GrpcDialOption, func(client volume_server_pb.VolumeServerClient) error {
+			resp, statsErr := client.VolumeServerStats(context.Background(), &volume_server_pb.VolumeServerStatsRequest{})
+			if statsErr != nil {
+				return statsErr
+			}
+			if nodeSince := time.Unix(0, resp.SinceNs); nodeSince.Before(since) {
+				since = nodeSince
+			}
+			for _, volumeStats := range resp.VolumeRequestStats {
+				entries = append(entries, &volumeTopEntry{node: node, stats: volumeStats})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, since, fmt.Errorf("collect request statistics from %s: %v", node, err)
+		}
+	}
+	return
+}
+
+// diffVolumeTopEntries returns the requests between the two collections
+func diffVolumeTopEntries(before, after []*volumeTopEntry) (diff []*volumeTopEntry) {
+	type volumeReplica struct {
+		node     rpc.ServerAddress
+		volumeId uint32
+	}
+	previous := make(map[volumeReplica]*volume_server_pb.VolumeRequestStats)
+	for _, entry := range before {
+		previous[volumeReplica{entry.node, entry.stats.VolumeId}] = entry.stats
+	}
+	for _, entry := range after {
+		prev, found := previous[volumeReplica{entry.node, entry.stats.VolumeId}]
+		// the counters start over when the volume server restarts
+		if !found || prev.ReadCount > entry.stats.ReadCount || prev.WriteCount > entry.stats.WriteCount || prev.DeleteCount > entry.stats.DeleteCount {
+			diff = append(diff, entry)
+			continue
+		}
+		diff = append(diff, &volumeTopEntry{node: entry.node, stats: subtractVolumeRequestStats(entry.stats, prev)})
+	}
+	return
+}
+
+func subtractVolumeRequestStats(a, b *volume_server_pb.VolumeRequestStats) *volume_server_pb.VolumeRequestStats {
+	s := &volume_server_pb.VolumeRequestStats{
+		VolumeId:            a.VolumeId,
+		Collection:          a.Collection,
+		ReadCount:           a.ReadCount - b.ReadCount,
+		WriteCount:          a.WriteCount - b.WriteCount,
+		DeleteCount:         a.DeleteCount - b.DeleteCount,
+		ErrorCount:          a.ErrorCount - b.ErrorCount,
+		ReadBytes:           a.ReadBytes - b.ReadBytes,
+		WriteBytes:          a.WriteBytes - b.WriteBytes,
+		ReadLatencyBuckets:  subtractBuckets(a.ReadLatencyBuckets, b.ReadLatencyBuckets),
+		WriteLatencyBuckets: subtractBuckets(a.WriteLatencyBuckets, b.WriteLatencyBuckets),
+	}
+	s.ReadLatencyP50Us = uint64(stats.LatencyPercentile(s.ReadLatencyBuckets, 0.50).Microseconds())
+	s.ReadLatencyP95Us = uint64(stats.LatencyPercentile(s.ReadLatencyBuckets, 0.95).Microseconds())
+	s.ReadLatencyP99Us = uint64(stats.LatencyPercentile(s.ReadLatencyBuckets, 0.99).Microseconds())
+	s.WriteLatencyP50Us = uint64(stats.LatencyPercentile(s.WriteLatencyBuckets, 0.50).Microseconds())
+	s.WriteLatencyP95Us = uint64(stats.LatencyPercentile(s.WriteLatencyBuckets, 0.95).Microseconds())
+	s.WriteLatencyP99Us = uint64(stats.LatencyPercentile(s.WriteLatencyBuckets, 0.99).Microseconds())
+	return s
+}
+
+func subtractBuckets(a, b []uint64) []uint64 {
+	diff := make([]uint64, len(a))
+	for i := range a {
+		diff[i] = a[i]
+		if i < len(b) && b[i] <= a[i] {
+			diff[i] -= b[i]
+		}
+	}
+	return diff
+}
+
+func filterVolumeTopEntries(entries []*volumeTopEntry, collection string) (filtered []*volumeTopEntry) {
+	for _, entry := range entries {
+		if collection != "" && !isCollectionMatched(entry.stats.Collection, collection) {
+			continue
+		}
+		if entry.stats.ReadCount+entry.stats.WriteCount+entry.stats.DeleteCount == 0 {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	return
+}
+
+func isCollectionMatched(volumeCollection, collection string) bool {
+	if collection == "_default_" {
+		return volumeCollection == ""
+	}
+	return volumeCollection == collection
+}
+
+func sortVolumeTopEntries(entries []*volumeTopEntry, sortKey func(s *volume_server_pb.VolumeRequestStats) float64) {
+	slices.SortStableFunc(entries, func(a, b *volumeTopEntry) bool {
+		if ka, kb := sortKey(a.stats), sortKey(b.stats); ka != kb {
+			return ka > kb
+		}
+		if a.stats.VolumeId != b.stats.VolumeId {
+			return a.stats.VolumeId < b.stats.VolumeId
+		}
+		return a.node < b.node
+	})
+}
+
+func writeVolumeTopEntries(writer io.Writer, entries []*volumeTopEntry, isRate bool, seconds float64) {
+	if len(entries) == 0 {
+		fmt.Fprintf(writer, "no requests in the last %.0f seconds\n", seconds)
+		return
+	}
+	if isRate {
+		fmt.Fprintf(writer, "requests per second in the last %.0f seconds\n", seconds)
+	} else {
+		fmt.Fprintf(writer, "requests in the last %.0f seconds\n", seconds)
+	}
+	fmt.Fprintf(writer, "%-8s %-22s %-16s %10s %10s %10s %8s %10s %10s %16s %16s\n",
+		"volume", "node", "collection", "reads", "writes", "deletes", "errors", "read", "written", "read p50/p99", "write p50/p99")
+	count := func(n uint64) string {
+		if isRate {
+			return fmt.Sprintf("%.1f", float64(n)/seconds)
+		}
+		return fmt.Sprintf("%d", n)
+	}
+	size := func(n uint64) string {
+		if isRate {
+			return util.BytesToHumanReadable(uint64(float64(n)/seconds)) + "/s"
+		}
+		return util.BytesToHumanReadable(n)
+	}
+	for _, entry := range entries {
+		s := entry.stats
+		fmt.Fprintf(writer, "%-8d %-22s %-16s %10s %10s %10s %8s %10s %10s %16s %16s\n",
+			s.VolumeId, entry.node, s.Collection,
+			count(s.ReadCount), count(s.WriteCount), count(s.DeleteCount), count(s.ErrorCount),
+			size(s.ReadBytes), size(s.WriteBytes),
+			latencyPair(s.ReadLatencyP50Us, s.ReadLatencyP99Us), latencyPair(s.WriteLatencyP50Us, s.WriteLatencyP99Us))
+	}
+}
+
+func latencyPair(p50Us, p99Us uint64) string {
+	if p99Us == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%v/%v", time.Duration(p50Us)*time.Microsecond, time.Duration(p99Us)*time.Microsecond)
+}
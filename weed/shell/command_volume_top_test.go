@@ -0,0 +1,36 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+)
+
+func TestDiffVolumeTopEntries(t *testing.T) {
+	before := []*volumeTopEntry{
+		{node: "n1:8080", stats: &volume_server_pb.VolumeRequestStats{VolumeId: 1, ReadCount: 10, ReadBytes: 1000, ReadLatencyBuckets: []uint64{0, 10}}},
+		{node: "n2:8080", stats: &volume_server_pb.VolumeRequestStats{VolumeId: 1, ReadCount: 50}},
+	}
+	after := []*volumeTopEntry{
+		{node: "n1:8080", stats: &volume_server_pb.VolumeRequestStats{VolumeId: 1, ReadCount: 15, ReadBytes: 1500, ReadLatencyBuckets: []uint64{0, 10, 5}}},
+		// restarted volume server
+		{node: "n2:8080", stats: &volume_server_pb.VolumeRequestStats{VolumeId: 1, ReadCount: 3}},
+		{node: "n3:8080", stats: &volume_server_pb.VolumeRequestStats{VolumeId: 2, WriteCount: 7}},
+	}
+
+	diff := diffVolumeTopEntries(before, after)
+	if len(diff) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(diff))
+	}
+	if s := diff[0].stats; s.ReadCount != 5 || s.ReadBytes != 500 || s.ReadLatencyP99Us != 8 {
+		t.Errorf("unexpected diff: %+v", s)
+	}
+	if diff[1].stats.ReadCount != 3 || diff[2].stats.WriteCount != 7 {
+		t.Errorf("new counters should be kept: %+v %+v", diff[1].stats, diff[2].stats)
+	}
+
+	sortVolumeTopEntries(diff, volumeTopSortKeys["requests"])
+	if diff[0].node != "n3:8080" || diff[2].node != "n2:8080" {
+		t.Errorf("unexpected order: %s %s %s", diff[0].node, diff[1].node, diff[2].node)
+	}
+}
@@ -3,9 +3,11 @@ package shell
 import (
 	"context"
 	"flag"
+	"fmt"
 	"io"
 
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
 func init() {
@@ -24,6 +26,9 @@ func (c *commandVacuum) Help() string {
 
 	volume.vacuum [-garbageThreshold=0.3] [-collection=<collection name>] [-volumeId=<volume id>]
 
+	The master compacts the replicas of each volume one after another, not in parallel,
+	and this command prints the progress of each replica and the reclaimed bytes.
+
 `
 }
 
@@ -41,17 +46,53 @@ func (c *commandVacuum) Do(args []string, commandEnv *CommandEnv, writer io.Writ
 		return
 	}
 
+	vacuumedVolumes := make(map[uint32]bool)
+	var reclaimedBytes uint64
 	err = commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
-		_, err = client.VacuumVolume(context.Background(), &master_pb.VacuumVolumeRequest{
+		stream, err := client.StreamVacuumVolume(context.Background(), &master_pb.VacuumVolumeRequest{
 			GarbageThreshold: float32(*garbageThreshold),
 			VolumeId:         uint32(*volumeId),
 			Collection:       *collection,
 		})
-		return err
+		if err != nil {
+			return err
+		}
+		for {
+			progress, recvErr := stream.Recv()
+			if recvErr == io.EOF {
+				return nil
+			}
+			if recvErr != nil {
+				return recvErr
+			}
+			printVacuumProgress(writer, progress)
+			if progress.Stage == "commit" && progress.Error == "" {
+				vacuumedVolumes[progress.VolumeId] = true
+				reclaimedBytes += progress.ReclaimedBytes
+			}
+		}
 	})
 	if err != nil {
 		return
 	}
 
+	fmt.Fprintf(writer, "vacuumed %d volumes, reclaimed %s\n", len(vacuumedVolumes), util.BytesToHumanReadable(reclaimedBytes))
+
 	return nil
 }
+
+func printVacuumProgress(writer io.Writer, progress *master_pb.VacuumVolumeProgress) {
+	fmt.Fprintf(writer, "volume %d on %s: ", progress.VolumeId, progress.Server)
+	switch {
+	case progress.Error != "":
+		fmt.Fprintf(writer, "%s failed: %s\n", progress.Stage, progress.Error)
+	case progress.Stage == "compact" && progress.ProcessedBytes == 0:
+		fmt.Fprintf(writer, "compacting, %s garbage\n", util.BytesToHumanReadable(progress.GarbageBytes))
+	case progress.Stage == "compact":
+		fmt.Fprintf(writer, "compacting, processed %s\n", util.BytesToHumanReadable(uint64(progress.ProcessedBytes)))
+	case progress.Stage == "commit":
+		fmt.Fprintf(writer, "committed, reclaimed %s\n", util.BytesToHumanReadable(progress.ReclaimedBytes))
+	default:
+		fmt.Fprintf(writer, "%s\n", progress.Stage)
+	}
+}
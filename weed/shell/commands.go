@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"google.golang.org/grpc"
 
+	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
@@ -23,6 +25,8 @@ type ShellOptions struct {
 	FilerGroup   *string
 	FilerAddress rpc.ServerAddress
 	Directory    string
+	// wrap the output of each command in a json envelope
+	Json bool
 }
 
 type CommandEnv struct {
@@ -30,6 +34,7 @@ type CommandEnv struct {
 	MasterClient *wdclient.MasterClient
 	option       *ShellOptions
 	locker       *exclusive_locks.ExclusiveLocker
+	stopRenewal  chan struct{}
 }
 
 type command interface {
@@ -80,6 +85,41 @@ func (ce *CommandEnv) confirmIsLocked(args []string) error {
 
 }
 
+// lock blocks until the exclusive lock is leased, and starts a goroutine to renew the lease until unlock.
+func (ce *CommandEnv) lock(writer io.Writer) {
+	if ce.locker.IsLocked() {
+		return
+	}
+	clientName := util.DetectedHostAddress()
+	ce.locker.RequestLock(clientName, writer)
+	ce.stopRenewal = make(chan struct{})
+	go ce.renewLock(clientName, ce.stopRenewal)
+}
+
+func (ce *CommandEnv) renewLock(clientName string, stopRenewal chan struct{}) {
+	ticker := time.NewTicker(exclusive_locks.RenewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopRenewal:
+			return
+		case <-ticker.C:
+			if err := ce.locker.RenewLock(clientName); err != nil {
+				glog.Errorf("failed to renew lock: %v", err)
+				return
+			}
+		}
+	}
+}
+
+func (ce *CommandEnv) unlock() {
+	if ce.stopRenewal != nil {
+		close(ce.stopRenewal)
+		ce.stopRenewal = nil
+	}
+	ce.locker.ReleaseLock()
+}
+
 func (ce *CommandEnv) isLocked() bool {
 	if ce == nil {
 		return true
@@ -48,6 +48,14 @@ func RunShell(options ShellOptions) {
 
 	commandEnv := NewCommandEnv(&options)
 
+	// keep the standard output only for the json envelopes
+	var banner io.Writer = os.Stdout
+	prompt := "> "
+	if options.Json {
+		banner = os.Stderr
+		prompt = ""
+	}
+
 	go commandEnv.MasterClient.KeepConnectedToMaster()
 	commandEnv.MasterClient.WaitUntilConnected()
 
@@ -67,12 +75,12 @@ func RunShell(options ShellOptions) {
 			}
 			return nil
 		})
-		fmt.Printf("master: %s ", *options.Masters)
+		fmt.Fprintf(banner, "master: %s ", *options.Masters)
 		if len(filers) > 0 {
-			fmt.Printf("filers: %v", filers)
+			fmt.Fprintf(banner, "filers: %v", filers)
 			commandEnv.option.FilerAddress = filers[rand.Intn(len(filers))]
 		}
-		fmt.Println()
+		fmt.Fprintln(banner)
 	}
 
 	if commandEnv.option.FilerAddress != "" {
@@ -82,7 +90,7 @@ func RunShell(options ShellOptions) {
 				return err
 			}
 			if resp.ClusterId != "" {
-				fmt.Printf(`
+				fmt.Fprintf(banner, `
 ---
 Free Monitoring Data URL:
 https://cloud.seaweedfs.com/ui/%s
@@ -94,7 +102,7 @@ https://cloud.seaweedfs.com/ui/%s
 	}
 
 	for {
-		cmd, err := line.Prompt("> ")
+		cmd, err := line.Prompt(prompt)
 		if err != nil {
 			if err != io.EOF {
 				fmt.Printf("%v\n", err)
@@ -134,14 +142,20 @@ func processEachCmd(reg *regexp.Regexp, cmd string, commandEnv *CommandEnv) bool
 			foundCommand := false
 			for _, c := range Commands {
 				if c.Name() == cmd || c.Name() == "fs."+cmd {
-					if err := c.Do(args, commandEnv, os.Stdout); err != nil {
+					if commandEnv.option.Json {
+						runCommandWithEnvelope(c, args, commandEnv, os.Stdout)
+					} else if err := c.Do(args, commandEnv, os.Stdout); err != nil {
 						fmt.Fprintf(os.Stderr, "error: %v\n", err)
 					}
 					foundCommand = true
 				}
 			}
 			if !foundCommand {
-				fmt.Fprintf(os.Stderr, "unknown command: %v\n", cmd)
+				if commandEnv.option.Json {
+					writeCommandEnvelope(os.Stdout, &CommandEnvelope{Command: cmd, Status: commandStatusError, Error: "unknown command"})
+				} else {
+					fmt.Fprintf(os.Stderr, "unknown command: %v\n", cmd)
+				}
 			}
 		}
 
@@ -0,0 +1,192 @@
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+)
+
+const smartCheckInterval = 10 * time.Minute
+
+// DiskHealthCollector reports the utilization of the disks holding the volume directories,
+// and optionally a SMART health summary from smartctl.
+type DiskHealthCollector struct {
+	smartEnabled bool
+	ioSamples    map[string]diskIoSample
+	smartResults map[string]*smartResult
+	sync.Mutex
+}
+
+type diskIoSample struct {
+	ioTicksMs uint64
+	at        time.Time
+}
+
+type smartResult struct {
+	status      string
+	temperature int32
+	checkedAt   time.Time
+	checking    bool
+}
+
+func NewDiskHealthCollector(smartEnabled bool) *DiskHealthCollector {
+	return &DiskHealthCollector{
+		smartEnabled: smartEnabled,
+		ioSamples:    make(map[string]diskIoSample),
+		smartResults: make(map[string]*smartResult),
+	}
+}
+
+func (c *DiskHealthCollector) Collect(dir string, diskType string) *master_pb.DiskHealth {
+	diskStatus := NewDiskStatus(dir)
+	health := &master_pb.DiskHealth{
+		Dir:      dir,
+		DiskType: diskType,
+		All:      diskStatus.All,
+		Used:     diskStatus.Used,
+	}
+
+	device, err := deviceOfDir(dir)
+	if err != nil {
+		glog.V(4).Infof("find device of %s: %v", dir, err)
+		return health
+	}
+	health.Device = device
+
+	c.Lock()
+	defer c.Unlock()
+
+	if ioInProgress, ioTicksMs, found := readDiskStats(device); found {
+		health.IoInProgress = ioInProgress
+		now := time.Now()
+		if last, hasLast := c.ioSamples[device]; hasLast && ioTicksMs >= last.ioTicksMs {
+			if elapsedMs := now.Sub(last.at).Milliseconds(); elapsedMs > 0 {
+				busy := float32(ioTicksMs-last.ioTicksMs) * 100 / float32(elapsedMs)
+				if busy > 100 {
+					busy = 100
+				}
+				health.BusyPercent = busy
+			}
+		}
+		c.ioSamples[device] = diskIoSample{ioTicksMs: ioTicksMs, at: now}
+	}
+
+	if c.smartEnabled {
+		disk := parentDisk(device)
+		result, found := c.smartResults[disk]
+		if !found {
+			result = &smartResult{}
+			c.smartResults[disk] = result
+		}
+		health.SmartStatus, health.Temperature = result.status, result.temperature
+		if !result.checking && time.Since(result.checkedAt) > smartCheckInterval {
+			result.checking = true
+			go c.checkSmart(disk, result)
+		}
+	}
+
+	return health
+}
+
+func (c *DiskHealthCollector) checkSmart(disk string, result *smartResult) {
+	status, temperature, err := readSmart(disk)
+	if err != nil {
+		glog.V(1).Infof("smartctl %s: %v", disk, err)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	result.status, result.temperature = status, temperature
+	result.checkedAt = time.Now()
+	result.checking = false
+	if status == "FAILED" {
+		glog.Warningf("disk %s SMART health check failed", disk)
+	}
+}
+
+func readSmart(disk string) (status string, temperature int32, err error) {
+	// smartctl uses the exit code bits to report disk problems, so the output is parsed regardless of the error
+	output, runErr := exec.Command("smartctl", "-H", "-A", "-j", "/dev/"+disk).Output()
+	var report struct {
+		SmartStatus *struct {
+			Passed bool `json:"passed"`
+		} `json:"smart_status"`
+		Temperature struct {
+			Current int32 `json:"current"`
+		} `json:"temperature"`
+	}
+	if err = json.Unmarshal(output, &report); err != nil {
+		if runErr != nil {
+			return "", 0, runErr
+		}
+		return "", 0, err
+	}
+	if report.SmartStatus != nil {
+		status = "FAILED"
+		if report.SmartStatus.Passed {
+			status = "PASSED"
+		}
+	}
+	return status, report.Temperature.Current, nil
+}
+
+// deviceOfDir returns the block device name, e.g. sda1, of the file system holding the directory
+func deviceOfDir(dir string) (string, error) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(dir, &st); err != nil {
+		return "", err
+	}
+	dev := uint64(st.Dev)
+	major := ((dev >> 8) & 0xfff) | ((dev >> 32) & ^uint64(0xfff))
+	minor := (dev & 0xff) | ((dev >> 12) & ^uint64(0xff))
+	link, err := os.Readlink(fmt.Sprintf("/sys/dev/block/%d:%d", major, minor))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(link), nil
+}
+
+// parentDisk returns the whole disk of a partition, e.g. sda for sda1
+func parentDisk(device string) string {
+	if _, err := os.Stat(filepath.Join("/sys/class/block", device, "partition")); err != nil {
+		return device
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join("/sys/class/block", device))
+	if err != nil {
+		return device
+	}
+	return filepath.Base(filepath.Dir(resolved))
+}
+
+func readDiskStats(device string) (ioInProgress, ioTicksMs uint64, found bool) {
+	f, err := os.Open("/proc/diskstats")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	return parseDiskStats(bufio.NewScanner(f), device)
+}
+
+func parseDiskStats(scanner *bufio.Scanner, device string) (ioInProgress, ioTicksMs uint64, found bool) {
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 13 || fields[2] != device {
+			continue
+		}
+		ioInProgress, _ = strconv.ParseUint(fields[11], 10, 64)
+		ioTicksMs, _ = strconv.ParseUint(fields[12], 10, 64)
+		return ioInProgress, ioTicksMs, true
+	}
+	return
+}
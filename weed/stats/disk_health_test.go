@@ -0,0 +1,21 @@
+package stats
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestParseDiskStats(t *testing.T) {
+	diskStats := `   8       0 sda 1403 0 93582 1227 417 379 7544 403 0 1620 1630 0 0 0 0
+   8       1 sda1 1178 0 84550 1042 413 379 7544 399 3 1460 1441 0 0 0 0
+ 259       0 nvme0n1 52310 1234 4315370 9123 81020 56231 4567890 51234 0 70120 60357 0 0 0 0
+`
+	ioInProgress, ioTicksMs, found := parseDiskStats(bufio.NewScanner(strings.NewReader(diskStats)), "sda1")
+	if !found || ioInProgress != 3 || ioTicksMs != 1460 {
+		t.Errorf("sda1: found %v, in progress %d, io ticks %d", found, ioInProgress, ioTicksMs)
+	}
+	if _, _, found = parseDiskStats(bufio.NewScanner(strings.NewReader(diskStats)), "sdb"); found {
+		t.Errorf("sdb should not be found")
+	}
+}
@@ -70,6 +70,22 @@ var (
 			Help:      "Counter of master leader changes.",
 		}, []string{"type"})
 
+	MasterCapacityDaysUntilFullGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "SeaweedFS",
+			Subsystem: "master",
+			Name:      "capacity_days_until_full",
+			Help:      "Forecast days until the disks of a data center and disk type are full.",
+		}, []string{"dataCenter", "diskType"})
+
+	MasterCollectionGrowthGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "SeaweedFS",
+			Subsystem: "master",
+			Name:      "collection_growth_bytes_per_day",
+			Help:      "Growth of the used space of a collection, including replicas.",
+		}, []string{"collection"})
+
 	FilerRequestCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "SeaweedFS",
@@ -112,6 +128,14 @@ var (
 			Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 24),
 		}, []string{"store", "type"})
 
+	FilerDeletionBacklogGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "SeaweedFS",
+			Subsystem: "filer",
+			Name:      "deletion_backlog",
+			Help:      "Number of file ids waiting to be deleted from the volume servers.",
+		})
+
 	FilerSyncOffsetGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "SeaweedFS",
@@ -120,6 +144,14 @@ var (
 			Help:      "The offset of the filer synchronization service.",
 		}, []string{"sourceFiler", "targetFiler", "clientName", "path"})
 
+	ChunkCacheRequestCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "SeaweedFS",
+			Subsystem: "chunkCache",
+			Name:      "request_total",
+			Help:      "Counter of chunk cache lookups and admissions by tier.",
+		}, []string{"tier", "type"})
+
 	VolumeServerRequestCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "SeaweedFS",
@@ -177,6 +209,22 @@ var (
 			Help:      "Resource usage",
 		}, []string{"name", "type"})
 
+	VolumeServerUploadQueueGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "SeaweedFS",
+			Subsystem: "volumeServer",
+			Name:      "upload_queue",
+			Help:      "Number of uploads waiting for the inflight upload data to go below the limit.",
+		})
+
+	VolumeServerUploadShedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "SeaweedFS",
+			Subsystem: "volumeServer",
+			Name:      "upload_shed_total",
+			Help:      "Counter of uploads rejected because the queue is full, the wait timed out, or the client is gone.",
+		}, []string{"reason"})
+
 	S3RequestCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "SeaweedFS",
@@ -193,6 +241,62 @@ var (
 			Help:      "Bucketed histogram of s3 request processing time.",
 			Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 24),
 		}, []string{"type", "bucket"})
+
+	S3BucketQuotaUsageGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "SeaweedFS",
+			Subsystem: "s3",
+			Name:      "bucket_quota_usage_ratio",
+			Help:      "The bucket usage divided by its quota.",
+		}, []string{"bucket", "type"})
+
+	S3BucketQuotaExceededCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "SeaweedFS",
+			Subsystem: "s3",
+			Name:      "bucket_quota_exceeded_total",
+			Help:      "Counter of writes rejected by the bucket quota.",
+		}, []string{"bucket"})
+
+	S3BucketMultipartUploadsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "SeaweedFS",
+			Subsystem: "s3",
+			Name:      "bucket_multipart_uploads",
+			Help:      "The number of multipart uploads in progress.",
+		}, []string{"bucket"})
+
+	S3BucketMultipartUploadOldestAgeGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "SeaweedFS",
+			Subsystem: "s3",
+			Name:      "bucket_multipart_upload_oldest_age_seconds",
+			Help:      "The age of the oldest multipart upload in progress, 0 without any.",
+		}, []string{"bucket"})
+
+	S3BucketMultipartUploadsAbortedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "SeaweedFS",
+			Subsystem: "s3",
+			Name:      "bucket_multipart_uploads_aborted_total",
+			Help:      "Counter of incomplete multipart uploads aborted by the lifecycle rules.",
+		}, []string{"bucket"})
+
+	AuditEventCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "SeaweedFS",
+			Subsystem: "audit",
+			Name:      "event_total",
+			Help:      "Counter of audit events sent, dropped or failed to send.",
+		}, []string{"service", "result"})
+
+	SlowRequestCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "SeaweedFS",
+			Subsystem: "slowlog",
+			Name:      "request_total",
+			Help:      "Counter of requests slower than the slow log thresholds, including the ones not logged by the rate limit.",
+		}, []string{"service", "api"})
 )
 
 func init() {
@@ -201,13 +305,17 @@ func init() {
 	Gather.MustRegister(MasterReceivedHeartbeatCounter)
 	Gather.MustRegister(MasterLeaderChangeCounter)
 	Gather.MustRegister(MasterReplicaPlacementMismatch)
+	Gather.MustRegister(MasterCapacityDaysUntilFullGauge)
+	Gather.MustRegister(MasterCollectionGrowthGauge)
 
 	Gather.MustRegister(FilerRequestCounter)
 	Gather.MustRegister(FilerRequestHistogram)
 	Gather.MustRegister(FilerStoreCounter)
 	Gather.MustRegister(FilerStoreHistogram)
 	Gather.MustRegister(FilerSyncOffsetGauge)
+	Gather.MustRegister(FilerDeletionBacklogGauge)
 	Gather.MustRegister(FilerServerLastSendTsOfSubscribeGauge)
+	Gather.MustRegister(ChunkCacheRequestCounter)
 	Gather.MustRegister(collectors.NewGoCollector())
 	Gather.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 
@@ -218,9 +326,19 @@ func init() {
 	Gather.MustRegister(VolumeServerReadOnlyVolumeGauge)
 	Gather.MustRegister(VolumeServerDiskSizeGauge)
 	Gather.MustRegister(VolumeServerResourceGauge)
+	Gather.MustRegister(VolumeServerUploadQueueGauge)
+	Gather.MustRegister(VolumeServerUploadShedCounter)
 
 	Gather.MustRegister(S3RequestCounter)
 	Gather.MustRegister(S3RequestHistogram)
+	Gather.MustRegister(S3BucketQuotaUsageGauge)
+	Gather.MustRegister(S3BucketQuotaExceededCounter)
+	Gather.MustRegister(S3BucketMultipartUploadsGauge)
+	Gather.MustRegister(S3BucketMultipartUploadOldestAgeGauge)
+	Gather.MustRegister(S3BucketMultipartUploadsAbortedCounter)
+
+	Gather.MustRegister(AuditEventCounter)
+	Gather.MustRegister(SlowRequestCounter)
 }
 
 func LoopPushingMetric(name, instance, addr string, intervalSeconds int) {
@@ -9,6 +9,11 @@ const (
 	ErrorSizeMismatchOffsetSize = "errorSizeMismatchOffsetSize"
 	ErrorSizeMismatch           = "errorSizeMismatch"
 	ErrorCRC                    = "errorCRC"
+	ReadRepaired                = "readRepaired"
+	ErrorReadRepair             = "errorReadRepair"
+	PeerRepairPulled            = "peerRepairPulled"
+	PeerRepairDeleted           = "peerRepairDeleted"
+	ErrorPeerRepair             = "errorPeerRepair"
 	ErrorIndexOutOfRange        = "errorIndexOutOfRange"
 
 	// master topology
@@ -26,7 +31,9 @@ const (
 
 	// filer handler
 	DirList                  = "dirList"
+	DirArchive               = "dirArchive"
 	ContentSaveToFiler       = "contentSaveToFiler"
+	ContentPacked            = "contentPacked"
 	AutoChunk                = "autoChunk"
 	ChunkProxy               = "chunkProxy"
 	ChunkAssign              = "chunkAssign"
@@ -34,10 +41,12 @@ const (
 	ChunkDoUploadRetry       = "chunkDoUploadRetry"
 	ChunkUploadRetry         = "chunkUploadRetry"
 	ChunkAssignRetry         = "chunkAssignRetry"
+	ChunkDedup               = "chunkDedup"
 	ErrorReadNotFound        = "read.notfound"
 	ErrorReadInternal        = "read.internal.error"
 	ErrorWriteEntry          = "write.entry.failed"
 	RepeatErrorUploadContent = "upload.content.repeat.failed"
 	ErrorReadCache           = "read.cache.failed"
 	ErrorReadStream          = "read.stream.failed"
+	ErrorReadChecksum        = "read.checksum.mismatch"
 )
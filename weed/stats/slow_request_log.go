@@ -0,0 +1,160 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+const (
+	// the slow requests logged per second at most, the others are only counted
+	SlowRequestLogMaxPerSecond = 10
+	// the recent slow requests kept for the shell
+	SlowRequestLogRecentCount = 1000
+
+	slowRequestDefaultApi = "default"
+)
+
+// SlowRequest is one request slower than the threshold of its api.
+type SlowRequest struct {
+	Time     time.Time `json:"time"`
+	Service  string    `json:"service"`
+	Api      string    `json:"api"`
+	Duration string    `json:"duration"`
+	VolumeId uint32    `json:"volumeId,omitempty"`
+	Fid      string    `json:"fid,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Size     int64     `json:"size,omitempty"`
+	Peer     string    `json:"peer,omitempty"`
+	Status   int       `json:"status,omitempty"`
+}
+
+func (r *SlowRequest) Json() []byte {
+	data, _ := json.Marshal(r)
+	return data
+}
+
+// SlowRequestLog logs the requests slower than the threshold of their api as json,
+// and keeps the recent ones for the shell.
+type SlowRequestLog struct {
+	service          string
+	thresholds       map[string]time.Duration
+	defaultThreshold time.Duration
+
+	sync.Mutex
+	recent       []*SlowRequest
+	next         int
+	second       int64
+	loggedSecond int
+}
+
+// ParseSlowRequestThresholds parses the thresholds like "default=1s,GET=200ms,PutObject=2s".
+// The apis without a threshold use the default one, or are not logged if there is no default.
+func ParseSlowRequestThresholds(s string) (thresholds map[string]time.Duration, err error) {
+	thresholds = make(map[string]time.Duration)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		api, value, found := strings.Cut(item, "=")
+		if !found {
+			// a single duration is the default threshold
+			api, value = slowRequestDefaultApi, item
+		}
+		threshold, parseErr := time.ParseDuration(strings.TrimSpace(value))
+		if parseErr != nil || threshold <= 0 {
+			return nil, fmt.Errorf("invalid slow request threshold %q", item)
+		}
+		thresholds[strings.TrimSpace(api)] = threshold
+	}
+	return thresholds, nil
+}
+
+// NewSlowRequestLog returns nil if there are no thresholds, to disable the slow log.
+func NewSlowRequestLog(service string, thresholds string) (*SlowRequestLog, error) {
+	parsed, err := ParseSlowRequestThresholds(thresholds)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed) == 0 {
+		return nil, nil
+	}
+	l := &SlowRequestLog{
+		service:          service,
+		thresholds:       parsed,
+		defaultThreshold: parsed[slowRequestDefaultApi],
+	}
+	glog.V(0).Infof("%s logs the slow requests: %s", service, thresholds)
+	return l, nil
+}
+
+func (l *SlowRequestLog) threshold(api string) time.Duration {
+	if threshold, found := l.thresholds[api]; found {
+		return threshold
+	}
+	return l.defaultThreshold
+}
+
+// Record logs the request if it is slower than the threshold of its api.
+func (l *SlowRequestLog) Record(api string, duration time.Duration, r *SlowRequest) {
+	if l == nil {
+		return
+	}
+	threshold := l.threshold(api)
+	if threshold == 0 || duration < threshold {
+		return
+	}
+	SlowRequestCounter.WithLabelValues(l.service, api).Inc()
+
+	now := time.Now()
+	r.Time, r.Service, r.Api, r.Duration = now, l.service, api, duration.String()
+
+	l.Lock()
+	if l.second != now.Unix() {
+		l.second, l.loggedSecond = now.Unix(), 0
+	}
+	if l.loggedSecond >= SlowRequestLogMaxPerSecond {
+		l.Unlock()
+		return
+	}
+	l.loggedSecond++
+	if len(l.recent) < SlowRequestLogRecentCount {
+		l.recent = append(l.recent, r)
+	} else {
+		l.recent[l.next] = r
+	}
+	l.next = (l.next + 1) % SlowRequestLogRecentCount
+	l.Unlock()
+
+	glog.Warningf("slow request %s", r.Json())
+}
+
+// Recent returns the latest slow requests, the oldest first.
+func (l *SlowRequestLog) Recent(limit int) (requests []*SlowRequest) {
+	if l == nil {
+		return nil
+	}
+	l.Lock()
+	defer l.Unlock()
+	count := len(l.recent)
+	if limit <= 0 || limit > count {
+		limit = count
+	}
+	for i := count - limit; i < count; i++ {
+		requests = append(requests, l.recent[(l.next+i)%count])
+	}
+	return
+}
+
+// RecentJson returns the latest slow requests as json, the oldest first.
+func (l *SlowRequestLog) RecentJson(limit int) (requests []string) {
+	for _, r := range l.Recent(limit) {
+		requests = append(requests, string(r.Json()))
+	}
+	return
+}
@@ -0,0 +1,72 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSlowRequestThresholds(t *testing.T) {
+	thresholds, err := ParseSlowRequestThresholds("default=1s, GET=200ms,PutObject=2s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thresholds["default"] != time.Second || thresholds["GET"] != 200*time.Millisecond || thresholds["PutObject"] != 2*time.Second {
+		t.Errorf("thresholds: %v", thresholds)
+	}
+	if thresholds, _ = ParseSlowRequestThresholds("500ms"); thresholds["default"] != 500*time.Millisecond {
+		t.Errorf("single threshold: %v", thresholds)
+	}
+	for _, invalid := range []string{"GET=fast", "GET=-1s", "GET="} {
+		if _, err = ParseSlowRequestThresholds(invalid); err == nil {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+	if l, _ := NewSlowRequestLog("volume", ""); l != nil {
+		t.Errorf("expected no slow log without thresholds")
+	}
+}
+
+func TestSlowRequestLog(t *testing.T) {
+	l, err := NewSlowRequestLog("volume", "GET=100ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Record("GET", 50*time.Millisecond, &SlowRequest{Fid: "fast"})
+	l.Record("DELETE", time.Second, &SlowRequest{Fid: "no threshold"})
+	l.Record("GET", 150*time.Millisecond, &SlowRequest{Fid: "3,01"})
+	recent := l.Recent(10)
+	if len(recent) != 1 || recent[0].Fid != "3,01" || recent[0].Service != "volume" || recent[0].Duration != "150ms" {
+		t.Fatalf("recent: %+v", recent)
+	}
+
+	// at most SlowRequestLogMaxPerSecond requests are logged in one second
+	for i := 0; i < 2*SlowRequestLogMaxPerSecond; i++ {
+		l.Record("GET", time.Second, &SlowRequest{VolumeId: uint32(i)})
+	}
+	if count := len(l.Recent(0)); count >= 1+2*SlowRequestLogMaxPerSecond {
+		t.Errorf("%d requests logged, expected the rate limit to drop some", count)
+	}
+
+	var nilLog *SlowRequestLog
+	nilLog.Record("GET", time.Hour, &SlowRequest{})
+	if nilLog.Recent(10) != nil {
+		t.Errorf("expected no requests of a nil slow log")
+	}
+}
+
+func TestSlowRequestLogRecentOrder(t *testing.T) {
+	l := &SlowRequestLog{service: "filer", defaultThreshold: time.Millisecond}
+	for i := 0; i < SlowRequestLogRecentCount+5; i++ {
+		// one request per second, not limited
+		l.second = 0
+		l.Record("GET", time.Second, &SlowRequest{Size: int64(i)})
+	}
+	recent := l.Recent(3)
+	if len(recent) != 3 || recent[0].Size != SlowRequestLogRecentCount+2 || recent[2].Size != SlowRequestLogRecentCount+4 {
+		t.Errorf("recent: %+v %+v %+v", recent[0], recent[1], recent[2])
+	}
+	if all := l.Recent(0); len(all) != SlowRequestLogRecentCount || all[0].Size != 5 {
+		t.Errorf("expected the oldest kept request to be 5, got %d of %d", all[0].Size, len(all))
+	}
+}
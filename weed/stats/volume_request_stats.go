@@ -0,0 +1,173 @@
+package stats
+
+import (
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
+)
+
+// bucket i counts the requests taking [2^i, 2^(i+1)) microseconds, the last bucket has all slower ones
+const LatencyBucketCount = 28
+
+type latencyHistogram [LatencyBucketCount]uint64
+
+func (h *latencyHistogram) observe(latency time.Duration) {
+	us := latency.Microseconds()
+	bucket := 0
+	for us > 1 && bucket < LatencyBucketCount-1 {
+		us >>= 1
+		bucket++
+	}
+	h[bucket]++
+}
+
+// LatencyPercentile estimates the latency percentile, 0 < p <= 1, as the upper bound of the bucket holding it
+func LatencyPercentile(buckets []uint64, p float64) time.Duration {
+	var total uint64
+	for _, count := range buckets {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	target := uint64(float64(total)*p + 0.5)
+	if target == 0 {
+		target = 1
+	}
+	var sum uint64
+	for i, count := range buckets {
+		sum += count
+		if sum >= target {
+			return time.Duration(uint64(2)<<i) * time.Microsecond
+		}
+	}
+	return time.Duration(uint64(2)<<(len(buckets)-1)) * time.Microsecond
+}
+
+type volumeRequestCounter struct {
+	readCount    uint64
+	writeCount   uint64
+	deleteCount  uint64
+	errorCount   uint64
+	readBytes    uint64
+	writeBytes   uint64
+	readLatency  latencyHistogram
+	writeLatency latencyHistogram
+}
+
+// VolumeRequestStats counts the requests, bytes and latencies of each volume, to find the hot volumes.
+type VolumeRequestStats struct {
+	volumes map[uint32]*volumeRequestCounter
+	since   time.Time
+	sync.Mutex
+}
+
+func NewVolumeRequestStats() *VolumeRequestStats {
+	return &VolumeRequestStats{
+		volumes: make(map[uint32]*volumeRequestCounter),
+		since:   time.Now(),
+	}
+}
+
+func (s *VolumeRequestStats) counterOf(volumeId uint32) *volumeRequestCounter {
+	counter, found := s.volumes[volumeId]
+	if !found {
+		counter = &volumeRequestCounter{}
+		s.volumes[volumeId] = counter
+	}
+	return counter
+}
+
+func (s *VolumeRequestStats) RecordRead(volumeId uint32, size int64, latency time.Duration, isError bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	counter := s.counterOf(volumeId)
+	counter.readCount++
+	counter.readBytes += uint64(size)
+	counter.readLatency.observe(latency)
+	if isError {
+		counter.errorCount++
+	}
+}
+
+func (s *VolumeRequestStats) RecordWrite(volumeId uint32, size int64, latency time.Duration, isError bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	counter := s.counterOf(volumeId)
+	counter.writeCount++
+	counter.writeBytes += uint64(size)
+	counter.writeLatency.observe(latency)
+	if isError {
+		counter.errorCount++
+	}
+}
+
+func (s *VolumeRequestStats) RecordDelete(volumeId uint32, latency time.Duration, isError bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	counter := s.counterOf(volumeId)
+	counter.deleteCount++
+	counter.writeLatency.observe(latency)
+	if isError {
+		counter.errorCount++
+	}
+}
+
+// Remove forgets the statistics of the volume, after it is deleted or unmounted.
+func (s *VolumeRequestStats) Remove(volumeId uint32) {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.volumes, volumeId)
+}
+
+// Collect returns the statistics of the volumes, or all volumes if none is given, and optionally starts over.
+func (s *VolumeRequestStats) Collect(volumeIds []uint32, reset bool) (volumeStats []*volume_server_pb.VolumeRequestStats, since time.Time) {
+	s.Lock()
+	defer s.Unlock()
+
+	if len(volumeIds) == 0 {
+		for volumeId := range s.volumes {
+			volumeIds = append(volumeIds, volumeId)
+		}
+	}
+	for _, volumeId := range volumeIds {
+		counter, found := s.volumes[volumeId]
+		if !found {
+			continue
+		}
+		volumeStats = append(volumeStats, counter.toPb(volumeId))
+	}
+
+	since = s.since
+	if reset {
+		s.volumes = make(map[uint32]*volumeRequestCounter)
+		s.since = time.Now()
+	}
+	return
+}
+
+func (c *volumeRequestCounter) toPb(volumeId uint32) *volume_server_pb.VolumeRequestStats {
+	readBuckets, writeBuckets := c.readLatency[:], c.writeLatency[:]
+	return &volume_server_pb.VolumeRequestStats{
+		VolumeId:            volumeId,
+		ReadCount:           c.readCount,
+		WriteCount:          c.writeCount,
+		DeleteCount:         c.deleteCount,
+		ErrorCount:          c.errorCount,
+		ReadBytes:           c.readBytes,
+		WriteBytes:          c.writeBytes,
+		ReadLatencyBuckets:  append([]uint64(nil), readBuckets...),
+		WriteLatencyBuckets: append([]uint64(nil), writeBuckets...),
+		ReadLatencyP50Us:    uint64(LatencyPercentile(readBuckets, 0.50).Microseconds()),
+		ReadLatencyP95Us:    uint64(LatencyPercentile(readBuckets, 0.95).Microseconds()),
+		ReadLatencyP99Us:    uint64(LatencyPercentile(readBuckets, 0.99).Microseconds()),
+		WriteLatencyP50Us:   uint64(LatencyPercentile(writeBuckets, 0.50).Microseconds()),
+		WriteLatencyP95Us:   uint64(LatencyPercentile(writeBuckets, 0.95).Microseconds()),
+		WriteLatencyP99Us:   uint64(LatencyPercentile(writeBuckets, 0.99).Microseconds()),
+	}
+}
@@ -0,0 +1,53 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVolumeRequestStats(t *testing.T) {
+	s := NewVolumeRequestStats()
+	for i := 0; i < 98; i++ {
+		s.RecordRead(1, 100, 3*time.Millisecond, false)
+	}
+	s.RecordRead(1, 100, 300*time.Millisecond, false)
+	s.RecordRead(1, 0, 2*time.Second, true)
+	s.RecordWrite(2, 1000, time.Millisecond, false)
+	s.RecordDelete(2, time.Millisecond, false)
+
+	volumeStats, _ := s.Collect([]uint32{1}, false)
+	if len(volumeStats) != 1 {
+		t.Fatalf("expected stats of volume 1, got %d", len(volumeStats))
+	}
+	v := volumeStats[0]
+	if v.ReadCount != 100 || v.ReadBytes != 9900 || v.ErrorCount != 1 {
+		t.Errorf("unexpected read stats: %+v", v)
+	}
+	// 3ms is in the [2048us, 4096us) bucket
+	if v.ReadLatencyP50Us != 4096 {
+		t.Errorf("read p50 %dus, expected 4096us", v.ReadLatencyP50Us)
+	}
+	// 300ms is in the [262144us, 524288us) bucket
+	if v.ReadLatencyP99Us != 524288 {
+		t.Errorf("read p99 %dus, expected 524288us", v.ReadLatencyP99Us)
+	}
+
+	all, _ := s.Collect(nil, true)
+	if len(all) != 2 {
+		t.Errorf("expected stats of 2 volumes, got %d", len(all))
+	}
+	if afterReset, _ := s.Collect(nil, false); len(afterReset) != 0 {
+		t.Errorf("expected no stats after reset, got %d", len(afterReset))
+	}
+}
+
+func TestVolumeRequestStatsRemove(t *testing.T) {
+	s := NewVolumeRequestStats()
+	s.RecordRead(1, 100, time.Millisecond, false)
+	s.RecordWrite(2, 100, time.Millisecond, false)
+
+	s.Remove(1)
+	if all, _ := s.Collect(nil, false); len(all) != 1 || all[0].VolumeId != 2 {
+		t.Errorf("expected only the stats of volume 2, got %v", all)
+	}
+}
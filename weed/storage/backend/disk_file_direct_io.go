@@ -0,0 +1,92 @@
+package backend
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+const directIOAlignment = 4096
+
+var (
+	_ BackendStorageFile = &DirectIODiskFile{}
+)
+
+// DirectIODiskFile reads a file with O_DIRECT, bypassing the page cache,
+// so a compaction or erasure coding pass over a whole volume does not evict the hot data.
+// The reads are done in aligned blocks, and the file can not be written.
+type DirectIODiskFile struct {
+	*DiskFile
+	bufferLock sync.Mutex
+	buffer     []byte
+}
+
+// OpenDirectIODiskFile opens the file for direct reads, or for normal reads if the file system does not support O_DIRECT.
+func OpenDirectIODiskFile(fileName string) (BackendStorageFile, error) {
+	file, err := os.OpenFile(fileName, os.O_RDONLY|syscall.O_DIRECT, 0)
+	if err != nil {
+		glog.V(1).Infof("open %s with O_DIRECT: %v", fileName, err)
+		if file, err = os.Open(fileName); err != nil {
+			return nil, err
+		}
+		return NewDiskFile(file), nil
+	}
+	return &DirectIODiskFile{DiskFile: NewDiskFile(file)}, nil
+}
+
+// alignedBuffer returns a buffer of the size, starting at an aligned memory address.
+func (df *DirectIODiskFile) alignedBuffer(size int) []byte {
+	if cap(df.buffer) < size {
+		raw := make([]byte, size+directIOAlignment)
+		shift := 0
+		if remainder := int(uintptr(unsafe.Pointer(&raw[0])) % directIOAlignment); remainder != 0 {
+			shift = directIOAlignment - remainder
+		}
+		df.buffer = raw[shift : shift+size]
+	}
+	return df.buffer[:size]
+}
+
+func (df *DirectIODiskFile) ReadAt(p []byte, off int64) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	df.bufferLock.Lock()
+	defer df.bufferLock.Unlock()
+
+	alignedOffset := off &^ (directIOAlignment - 1)
+	alignedEnd := (off + int64(len(p)) + directIOAlignment - 1) &^ (directIOAlignment - 1)
+	buffer := df.alignedBuffer(int(alignedEnd - alignedOffset))
+
+	// a read reaching the end of the file returns less than the aligned size
+	read, readErr := df.File.ReadAt(buffer, alignedOffset)
+	if readErr != nil && readErr != io.EOF {
+		return 0, readErr
+	}
+	start := int(off - alignedOffset)
+	if read <= start {
+		return 0, io.EOF
+	}
+	n = copy(p, buffer[start:read])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (df *DirectIODiskFile) WriteAt(p []byte, off int64) (n int, err error) {
+	return 0, fmt.Errorf("%s is opened for direct reads only", df.Name())
+}
+
+func (df *DirectIODiskFile) Write(p []byte) (n int, err error) {
+	return df.WriteAt(p, 0)
+}
+
+func (df *DirectIODiskFile) Truncate(off int64) error {
+	return fmt.Errorf("%s is opened for direct reads only", df.Name())
+}
@@ -0,0 +1,56 @@
+package backend
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirectIODiskFileReadAt(t *testing.T) {
+	data := make([]byte, 3*directIOAlignment+100)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	fileName := filepath.Join(t.TempDir(), "1.dat")
+	if err := os.WriteFile(fileName, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// the aligned reads do not depend on whether the file system supports O_DIRECT
+	file, err := os.Open(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	df := &DirectIODiskFile{DiskFile: NewDiskFile(file)}
+	defer df.Close()
+
+	for _, tc := range []struct {
+		off, size int
+	}{
+		{0, 10},
+		{100, directIOAlignment},
+		{directIOAlignment - 1, 2},
+		{2*directIOAlignment + 7, directIOAlignment + 93},
+	} {
+		p := make([]byte, tc.size)
+		n, err := df.ReadAt(p, int64(tc.off))
+		if err != nil || n != tc.size {
+			t.Fatalf("read %d bytes at %d: %d, %v", tc.size, tc.off, n, err)
+		}
+		if string(p) != string(data[tc.off:tc.off+tc.size]) {
+			t.Errorf("read %d bytes at %d: unexpected data", tc.size, tc.off)
+		}
+	}
+
+	p := make([]byte, 200)
+	n, err := df.ReadAt(p, int64(len(data)-50))
+	if err != io.EOF || n != 50 || string(p[:n]) != string(data[len(data)-50:]) {
+		t.Errorf("read past the end: %d, %v", n, err)
+	}
+	if n, err = df.ReadAt(p, int64(len(data)+10)); err != io.EOF || n != 0 {
+		t.Errorf("read after the end: %d, %v", n, err)
+	}
+	if _, err = df.WriteAt(p, 0); err == nil {
+		t.Errorf("write should fail")
+	}
+}
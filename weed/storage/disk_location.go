@@ -5,13 +5,16 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
@@ -30,11 +33,34 @@ type DiskLocation struct {
 	volumes                map[needle.VolumeId]*Volume
 	volumesLock            sync.RWMutex
 
+	// preallocate the .dat files of new and compacted volumes to the volume size limit
+	Preallocate bool
+	// read the .dat files with O_DIRECT during compaction and erasure coding
+	DirectIO bool
+
 	// erasure coding
 	ecVolumes     map[needle.VolumeId]*erasure_coding.EcVolume
 	ecVolumesLock sync.RWMutex
 
 	isDiskSpaceLow bool
+	// set when a write ran out of space, until the free space grows again
+	isDiskFull   bool
+	freeWhenFull uint64
+	// serializes the disk space checks with marking the location full
+	diskSpaceLock sync.Mutex
+	// 1 when the whole volume server is marked read only, accessed atomically
+	isServerReadOnly int32
+
+	// the volumes failing to load at startup, protected by volumesLock
+	quarantinedVolumes map[needle.VolumeId]*master_pb.QuarantinedVolume
+}
+
+// VolumeLoadOption controls loading the existing volumes at startup
+type VolumeLoadOption struct {
+	// the number of volumes loaded at the same time in each directory, 0 to choose by the cpu count
+	Concurrency int
+	// a volume not loaded in time is quarantined, 0 to wait forever
+	Timeout time.Duration
 }
 
 func GenerateDirUuid(dir string) (dirUuidString string, err error) {
@@ -79,6 +105,7 @@ func NewDiskLocation(dir string, maxVolumeCount int32, minFreeSpace util.MinFree
 		MinFreeSpace:           minFreeSpace,
 	}
 	location.volumes = make(map[needle.VolumeId]*Volume)
+	location.quarantinedVolumes = make(map[needle.VolumeId]*master_pb.QuarantinedVolume)
 	location.ecVolumes = make(map[needle.VolumeId]*erasure_coding.EcVolume)
 	go location.CheckDiskSpace()
 	return location
@@ -115,19 +142,34 @@ func getValidVolumeName(basename string) string {
 }
 
 func (l *DiskLocation) loadExistingVolume(dirEntry os.DirEntry, needleMapKind NeedleMapKind, skipIfEcVolumesExists bool) bool {
+	result := l.loadVolumeFile(dirEntry, needleMapKind, skipIfEcVolumesExists, 0)
+	return result.isVolume && result.err == nil
+}
+
+// volumeLoadResult is how loading one volume went
+type volumeLoadResult struct {
+	vid        needle.VolumeId
+	collection string
+	// false if the file is not a volume to load
+	isVolume bool
+	duration time.Duration
+	err      error
+}
+
+func (l *DiskLocation) loadVolumeFile(dirEntry os.DirEntry, needleMapKind NeedleMapKind, skipIfEcVolumesExists bool, timeout time.Duration) (result volumeLoadResult) {
 	basename := dirEntry.Name()
 	if dirEntry.IsDir() {
-		return false
+		return
 	}
 	volumeName := getValidVolumeName(basename)
 	if volumeName == "" {
-		return false
+		return
 	}
 
 	// skip if ec volumes exists
 	if skipIfEcVolumesExists {
 		if util.FileExists(l.Directory + "/" + volumeName + ".ecx") {
-			return false
+			return
 		}
 	}
 
@@ -138,15 +180,16 @@ func (l *DiskLocation) loadExistingVolume(dirEntry os.DirEntry, needleMapKind Ne
 		glog.Warningf("volume %s was not completed: %s", volumeName, string(note))
 		removeVolumeFiles(l.Directory + "/" + volumeName)
 		removeVolumeFiles(l.IdxDirectory + "/" + volumeName)
-		return false
+		return
 	}
 
 	// parse out collection, volume id
 	vid, collection, err := volumeIdFromFileName(basename)
 	if err != nil {
 		glog.Warningf("get volume id failed, %s, err : %s", volumeName, err)
-		return false
+		return
 	}
+	result.vid, result.collection, result.isVolume = vid, collection, true
 
 	// avoid loading one volume more than once
 	l.volumesLock.RLock()
@@ -154,14 +197,17 @@ func (l *DiskLocation) loadExistingVolume(dirEntry os.DirEntry, needleMapKind Ne
 	l.volumesLock.RUnlock()
 	if found {
 		glog.V(1).Infof("loaded volume, %v", vid)
-		return true
+		return
 	}
 
 	// load the volume
-	v, e := NewVolume(l.Directory, l.IdxDirectory, collection, vid, needleMapKind, nil, nil, 0, 0)
+	start := time.Now()
+	v, e := l.newVolumeWithTimeout(collection, vid, needleMapKind, timeout)
+	result.duration = time.Since(start)
 	if e != nil {
 		glog.V(0).Infof("new volume %s error %s", volumeName, e)
-		return false
+		result.err = e
+		return
 	}
 
 	l.SetVolume(vid, v)
@@ -169,10 +215,38 @@ func (l *DiskLocation) loadExistingVolume(dirEntry os.DirEntry, needleMapKind Ne
 	size, _, _ := v.FileStat()
 	glog.V(0).Infof("data file %s, replication=%s v=%d size=%d ttl=%s",
 		l.Directory+"/"+volumeName+".dat", v.ReplicaPlacement, v.Version(), size, v.Ttl.String())
-	return true
+	return
 }
 
-func (l *DiskLocation) concurrentLoadingVolumes(needleMapKind NeedleMapKind, concurrency int) {
+// newVolumeWithTimeout gives up waiting for a volume, e.g. with a huge or damaged .idx file,
+// and closes it if it is loaded later.
+func (l *DiskLocation) newVolumeWithTimeout(collection string, vid needle.VolumeId, needleMapKind NeedleMapKind, timeout time.Duration) (*Volume, error) {
+	if timeout <= 0 {
+		return NewVolume(l.Directory, l.IdxDirectory, collection, vid, needleMapKind, nil, nil, 0, 0, needle.ChecksumCrc32c)
+	}
+	type loaded struct {
+		v   *Volume
+		err error
+	}
+	done := make(chan loaded, 1)
+	go func() {
+		v, err := NewVolume(l.Directory, l.IdxDirectory, collection, vid, needleMapKind, nil, nil, 0, 0, needle.ChecksumCrc32c)
+		done <- loaded{v, err}
+	}()
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-time.After(timeout):
+		go func() {
+			if r := <-done; r.v != nil {
+				r.v.Close()
+			}
+		}()
+		return nil, fmt.Errorf("not loaded in %v", timeout)
+	}
+}
+
+func (l *DiskLocation) concurrentLoadingVolumes(needleMapKind NeedleMapKind, concurrency int, timeout time.Duration) {
 
 	task_queue := make(chan os.DirEntry, 10*concurrency)
 	go func() {
@@ -192,37 +266,105 @@ func (l *DiskLocation) concurrentLoadingVolumes(needleMapKind NeedleMapKind, con
 		close(task_queue)
 	}()
 
+	start := time.Now()
+	var results []volumeLoadResult
+	var resultsLock sync.Mutex
 	var wg sync.WaitGroup
 	for workerNum := 0; workerNum < concurrency; workerNum++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for fi := range task_queue {
-				_ = l.loadExistingVolume(fi, needleMapKind, true)
+				result := l.loadVolumeFile(fi, needleMapKind, true, timeout)
+				if !result.isVolume {
+					continue
+				}
+				if result.err != nil {
+					l.quarantineVolume(result.vid, result.collection, result.err)
+				}
+				resultsLock.Lock()
+				results = append(results, result)
+				resultsLock.Unlock()
 			}
 		}()
 	}
 	wg.Wait()
 
+	glog.V(0).Infof("dir %s: %s", l.Directory, summarizeVolumeLoading(results, time.Since(start)))
 }
 
-func (l *DiskLocation) loadExistingVolumes(needleMapKind NeedleMapKind) {
-
-	workerNum := runtime.NumCPU()
-	val, ok := os.LookupEnv("GOMAXPROCS")
-	if ok {
-		num, err := strconv.Atoi(val)
-		if err != nil || num < 1 {
-			num = 10
-			glog.Warningf("failed to set worker number from GOMAXPROCS , set to default:10")
+// summarizeVolumeLoading tells how long the loading took, and the slowest volumes
+func summarizeVolumeLoading(results []volumeLoadResult, elapsed time.Duration) string {
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].duration > results[j].duration
+	})
+	var failed int
+	var slowest []string
+	for _, result := range results {
+		if result.err != nil {
+			failed++
 		}
-		workerNum = num
-	} else {
-		if workerNum <= 10 {
-			workerNum = 10
+		if len(slowest) < 5 && result.duration > 0 {
+			slowest = append(slowest, fmt.Sprintf("%d %v", result.vid, result.duration.Round(time.Millisecond)))
+		}
+	}
+	summary := fmt.Sprintf("loaded %d volumes in %v, %d quarantined", len(results)-failed, elapsed.Round(time.Millisecond), failed)
+	if len(slowest) > 0 {
+		summary += ", slowest: " + strings.Join(slowest, ", ")
+	}
+	return summary
+}
+
+// quarantineVolume leaves the files of a volume failing to load on disk, and reports it to the master.
+// A quarantined volume id is not created again until the volume server restarts.
+func (l *DiskLocation) quarantineVolume(vid needle.VolumeId, collection string, err error) {
+	glog.Errorf("quarantine volume %d in %s: %v", vid, l.Directory, err)
+	l.volumesLock.Lock()
+	defer l.volumesLock.Unlock()
+	l.quarantinedVolumes[vid] = &master_pb.QuarantinedVolume{
+		Id:         uint32(vid),
+		Collection: collection,
+		Dir:        l.Directory,
+		Reason:     err.Error(),
+	}
+}
+
+func (l *DiskLocation) QuarantinedVolumes() (quarantined []*master_pb.QuarantinedVolume) {
+	l.volumesLock.RLock()
+	defer l.volumesLock.RUnlock()
+	for _, q := range l.quarantinedVolumes {
+		quarantined = append(quarantined, q)
+	}
+	return
+}
+
+func (l *DiskLocation) isQuarantined(vid needle.VolumeId) bool {
+	l.volumesLock.RLock()
+	defer l.volumesLock.RUnlock()
+	_, found := l.quarantinedVolumes[vid]
+	return found
+}
+
+func (l *DiskLocation) loadExistingVolumes(needleMapKind NeedleMapKind, loadOption VolumeLoadOption) {
+
+	workerNum := loadOption.Concurrency
+	if workerNum <= 0 {
+		workerNum = runtime.NumCPU()
+		val, ok := os.LookupEnv("GOMAXPROCS")
+		if ok {
+			num, err := strconv.Atoi(val)
+			if err != nil || num < 1 {
+				num = 10
+				glog.Warningf("failed to set worker number from GOMAXPROCS , set to default:10")
+			}
+			workerNum = num
+		} else {
+			if workerNum <= 10 {
+				workerNum = 10
+			}
 		}
 	}
-	l.concurrentLoadingVolumes(needleMapKind, workerNum)
+	l.concurrentLoadingVolumes(needleMapKind, workerNum, loadOption.Timeout)
 	glog.V(0).Infof("Store started on dir: %s with %d volumes max %d", l.Directory, len(l.volumes), l.MaxVolumeCount)
 
 	l.loadAllEcShards()
@@ -422,15 +564,25 @@ func (l *DiskLocation) UnUsedSpace(volumeSizeLimit uint64) (unUsedSpace uint64)
 func (l *DiskLocation) CheckDiskSpace() {
 	for {
 		if dir, e := filepath.Abs(l.Directory); e == nil {
+			// read the free space under the lock, so a stale reading does not clear a newer full mark
+			l.diskSpaceLock.Lock()
 			s := stats.NewDiskStatus(dir)
 			stats.VolumeServerResourceGauge.WithLabelValues(l.Directory, "all").Set(float64(s.All))
 			stats.VolumeServerResourceGauge.WithLabelValues(l.Directory, "used").Set(float64(s.Used))
 			stats.VolumeServerResourceGauge.WithLabelValues(l.Directory, "free").Set(float64(s.Free))
 
 			isLow, desc := l.MinFreeSpace.IsLow(s.Free, s.PercentFree)
+			if l.isDiskFull {
+				if s.Free > l.freeWhenFull {
+					l.isDiskFull = false
+				} else {
+					isLow, desc = true, fmt.Sprintf("ran out of space with %d bytes free", l.freeWhenFull)
+				}
+			}
 			if isLow != l.isDiskSpaceLow {
 				l.isDiskSpaceLow = !l.isDiskSpaceLow
 			}
+			l.diskSpaceLock.Unlock()
 
 			logLevel := glog.Level(4)
 			if l.isDiskSpaceLow {
@@ -443,3 +595,30 @@ func (l *DiskLocation) CheckDiskSpace() {
 	}
 
 }
+
+// markDiskFull stops the writes to this location after a write ran out of space,
+// even if the free space looks above the minimum, until the free space grows.
+func (l *DiskLocation) markDiskFull(err error) {
+	l.diskSpaceLock.Lock()
+	defer l.diskSpaceLock.Unlock()
+	if dir, e := filepath.Abs(l.Directory); e == nil {
+		l.freeWhenFull = stats.NewDiskStatus(dir).Free
+	}
+	l.isDiskFull = true
+	if !l.isDiskSpaceLow {
+		l.isDiskSpaceLow = true
+		glog.Warningf("dir %s is full: %v", l.Directory, err)
+	}
+}
+
+func (l *DiskLocation) setServerReadOnly(isReadOnly bool) {
+	var value int32
+	if isReadOnly {
+		value = 1
+	}
+	atomic.StoreInt32(&l.isServerReadOnly, value)
+}
+
+func (l *DiskLocation) IsServerReadOnly() bool {
+	return atomic.LoadInt32(&l.isServerReadOnly) == 1
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestQuarantineVolumesFailingToLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	// a good volume, and a volume with a damaged super block
+	v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, needle.ChecksumCrc32c)
+	if err != nil {
+		t.Fatalf("new volume: %v", err)
+	}
+	v.Close()
+	if err = os.WriteFile(filepath.Join(dir, "col_2.dat"), []byte("not a volume"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "col_2.idx"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewStore(nil, "localhost", 8080, 18080, "localhost:8080", []string{dir}, []int32{8},
+		[]util.MinFreeSpace{{}}, "", NeedleMapInMemory, []types.DiskType{types.HardDriveType}, VolumeLoadOption{Concurrency: 2, Timeout: time.Minute})
+	defer s.Close()
+
+	if s.findVolume(1) == nil {
+		t.Errorf("volume 1 is not loaded")
+	}
+	if s.findVolume(2) != nil {
+		t.Errorf("damaged volume 2 is loaded")
+	}
+
+	quarantined := s.CollectHeartbeat().QuarantinedVolumes
+	if len(quarantined) != 1 || quarantined[0].Id != 2 || quarantined[0].Collection != "col" || quarantined[0].Reason == "" {
+		t.Fatalf("quarantined volumes: %+v", quarantined)
+	}
+
+	if err = s.AddVolume(2, "col", NeedleMapInMemory, "000", "", 0, 0, types.HardDriveType, ""); err == nil {
+		t.Errorf("quarantined volume 2 is created again")
+	}
+}
+
+func TestSummarizeVolumeLoading(t *testing.T) {
+	summary := summarizeVolumeLoading([]volumeLoadResult{
+		{vid: 1, duration: time.Second},
+		{vid: 2, duration: 3 * time.Second, err: fmt.Errorf("not loaded in 3s")},
+		{vid: 3, duration: 2 * time.Second},
+	}, 4*time.Second)
+	if summary != "loaded 2 volumes in 4s, 1 quarantined, slowest: 2 3s, 3 2s, 1 1s" {
+		t.Errorf("summary: %s", summary)
+	}
+	if !strings.HasPrefix(summarizeVolumeLoading(nil, 0), "loaded 0 volumes") {
+		t.Errorf("empty summary")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/klauspost/reedsolomon"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/storage/backend"
 	"github.com/seaweedfs/seaweedfs/weed/storage/idx"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle_map"
 	"github.com/seaweedfs/seaweedfs/weed/storage/types"
@@ -54,8 +55,8 @@ func WriteSortedFileFromIdx(baseFileName string, ext string) (e error) {
 }
 
 // WriteEcFiles generates .ec00 ~ .ec13 files
-func WriteEcFiles(baseFileName string) error {
-	return generateEcFiles(baseFileName, 256*1024, ErasureCodingLargeBlockSize, ErasureCodingSmallBlockSize)
+func WriteEcFiles(baseFileName string, isDirectIO bool) error {
+	return generateEcFiles(baseFileName, 256*1024, ErasureCodingLargeBlockSize, ErasureCodingSmallBlockSize, isDirectIO)
 }
 
 func RebuildEcFiles(baseFileName string) ([]uint32, error) {
@@ -66,14 +67,23 @@ func ToExt(ecIndex int) string {
 	return fmt.Sprintf(".ec%02d", ecIndex)
 }
 
-func generateEcFiles(baseFileName string, bufferSize int, largeBlockSize int64, smallBlockSize int64) error {
-	file, err := os.OpenFile(baseFileName+".dat", os.O_RDONLY, 0)
+func generateEcFiles(baseFileName string, bufferSize int, largeBlockSize int64, smallBlockSize int64, isDirectIO bool) error {
+	var file backend.BackendStorageFile
+	var err error
+	if isDirectIO {
+		file, err = backend.OpenDirectIODiskFile(baseFileName + ".dat")
+	} else {
+		var f *os.File
+		if f, err = os.OpenFile(baseFileName+".dat", os.O_RDONLY, 0); err == nil {
+			file = backend.NewDiskFile(f)
+		}
+	}
 	if err != nil {
 		return fmt.Errorf("failed to open dat file: %v", err)
 	}
 	defer file.Close()
 
-	fi, err := file.Stat()
+	fi, err := os.Stat(baseFileName + ".dat")
 	if err != nil {
 		return fmt.Errorf("failed to stat dat file: %v", err)
 	}
@@ -117,7 +127,7 @@ func generateMissingEcFiles(baseFileName string, bufferSize int, largeBlockSize
 	return
 }
 
-func encodeData(file *os.File, enc reedsolomon.Encoder, startOffset, blockSize int64, buffers [][]byte, outputs []*os.File) error {
+func encodeData(file io.ReaderAt, enc reedsolomon.Encoder, startOffset, blockSize int64, buffers [][]byte, outputs []*os.File) error {
 
 	bufferSize := int64(len(buffers[0]))
 	batchCount := blockSize / bufferSize
@@ -159,7 +169,7 @@ func closeEcFiles(files []*os.File) {
 	}
 }
 
-func encodeDataOneBatch(file *os.File, enc reedsolomon.Encoder, startOffset, blockSize int64, buffers [][]byte, outputs []*os.File) error {
+func encodeDataOneBatch(file io.ReaderAt, enc reedsolomon.Encoder, startOffset, blockSize int64, buffers [][]byte, outputs []*os.File) error {
 
 	// read data into buffers
 	for i := 0; i < DataShardsCount; i++ {
@@ -191,7 +201,7 @@ func encodeDataOneBatch(file *os.File, enc reedsolomon.Encoder, startOffset, blo
 	return nil
 }
 
-func encodeDatFile(remainingSize int64, err error, baseFileName string, bufferSize int, largeBlockSize int64, file *os.File, smallBlockSize int64) error {
+func encodeDatFile(remainingSize int64, err error, baseFileName string, bufferSize int, largeBlockSize int64, file io.ReaderAt, smallBlockSize int64) error {
 
 	var processedSize int64
 
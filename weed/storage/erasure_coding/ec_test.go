@@ -22,7 +22,7 @@ func TestEncodingDecoding(t *testing.T) {
 	bufferSize := 50
 	baseFileName := "1"
 
-	err := generateEcFiles(baseFileName, bufferSize, largeBlockSize, smallBlockSize)
+	err := generateEcFiles(baseFileName, bufferSize, largeBlockSize, smallBlockSize, false)
 	if err != nil {
 		t.Logf("generateEcFiles: %v", err)
 	}
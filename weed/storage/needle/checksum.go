@@ -0,0 +1,90 @@
+package needle
+
+import (
+	"fmt"
+	"hash"
+	"hash/crc32"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// ChecksumAlgorithm is the checksum of the needle data, chosen per volume in the super block.
+// Each needle marks its own algorithm in the flags, so the needles can be read without the super block,
+// e.g. after being copied, compacted or erasure coded, and the volumes without the choice keep CRC32C.
+type ChecksumAlgorithm uint32
+
+const (
+	// ChecksumCrc32c is computed with the SSE4.2 or ARMv8 CRC32 instructions when the CPU has them.
+	ChecksumCrc32c ChecksumAlgorithm = iota
+	// ChecksumXxhash is the lower 32 bits of xxhash64, faster on the CPUs without CRC32 instructions.
+	ChecksumXxhash
+)
+
+func ParseChecksumAlgorithm(name string) (ChecksumAlgorithm, error) {
+	switch name {
+	case "", "crc32c":
+		return ChecksumCrc32c, nil
+	case "xxhash":
+		return ChecksumXxhash, nil
+	}
+	return ChecksumCrc32c, fmt.Errorf("unknown checksum algorithm %s, expecting crc32c or xxhash", name)
+}
+
+func (a ChecksumAlgorithm) String() string {
+	switch a {
+	case ChecksumXxhash:
+		return "xxhash"
+	}
+	return "crc32c"
+}
+
+func (a ChecksumAlgorithm) Checksum(b []byte) CRC {
+	switch a {
+	case ChecksumXxhash:
+		return CRC(xxhash.Sum64(b))
+	}
+	return NewCRC(b)
+}
+
+// NewHash computes the checksum of the data written in pieces.
+func (a ChecksumAlgorithm) NewHash() hash.Hash32 {
+	switch a {
+	case ChecksumXxhash:
+		return xxhash32{xxhash.New()}
+	}
+	return crc32.New(table)
+}
+
+type xxhash32 struct {
+	*xxhash.Digest
+}
+
+func (d xxhash32) Size() int {
+	return 4
+}
+
+func (d xxhash32) Sum(b []byte) []byte {
+	s := d.Sum32()
+	return append(b, byte(s>>24), byte(s>>16), byte(s>>8), byte(s))
+}
+
+func (d xxhash32) Sum32() uint32 {
+	return uint32(d.Sum64())
+}
+
+func (n *Needle) GetChecksumAlgorithm() ChecksumAlgorithm {
+	if n.Flags&FlagXxhashChecksum != 0 {
+		return ChecksumXxhash
+	}
+	return ChecksumCrc32c
+}
+
+// SetChecksumAlgorithm marks the algorithm in the flags and computes the checksum of the data.
+func (n *Needle) SetChecksumAlgorithm(a ChecksumAlgorithm) {
+	if a == ChecksumXxhash {
+		n.Flags = n.Flags | FlagXxhashChecksum
+	} else {
+		n.Flags = n.Flags &^ FlagXxhashChecksum
+	}
+	n.Checksum = a.Checksum(n.Data)
+}
@@ -0,0 +1,45 @@
+package needle
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+func TestChecksumAlgorithm(t *testing.T) {
+	data := []byte("the quick brown fox jumps over the lazy dog")
+	for _, algorithm := range []ChecksumAlgorithm{ChecksumCrc32c, ChecksumXxhash} {
+		parsed, err := ParseChecksumAlgorithm(algorithm.String())
+		assert.Nil(t, err)
+		assert.Equal(t, algorithm, parsed)
+
+		// the checksum computed in pieces is the same
+		h := algorithm.NewHash()
+		h.Write(data[:10])
+		h.Write(data[10:])
+		assert.Equal(t, algorithm.Checksum(data), CRC(h.Sum32()), algorithm.String())
+
+		n := &Needle{Cookie: types.Cookie(123), Id: types.NeedleId(123), Data: data}
+		n.SetChecksumAlgorithm(algorithm)
+		var buf bytes.Buffer
+		_, _, err = n.prepareWriteBuffer(CurrentVersion, &buf)
+		assert.Nil(t, err)
+
+		// the algorithm is read from the flags of the needle
+		blob := buf.Bytes()
+		read := &Needle{Id: n.Id}
+		assert.Nil(t, read.ReadBytes(blob, 0, n.Size, CurrentVersion), algorithm.String())
+		assert.Equal(t, algorithm, read.GetChecksumAlgorithm())
+		assert.Equal(t, n.Checksum, read.Checksum)
+
+		blob[types.NeedleHeaderSize+4] ^= 0xff
+		assert.ErrorIs(t, (&Needle{Id: n.Id}).ReadBytes(blob, 0, n.Size, CurrentVersion), ErrorCRC)
+	}
+	assert.NotEqual(t, ChecksumCrc32c.Checksum(data), ChecksumXxhash.Checksum(data))
+
+	_, err := ParseChecksumAlgorithm("md5")
+	assert.NotNil(t, err)
+}
@@ -153,6 +153,27 @@ func ParseNeedleIdCookie(key_hash_string string) (NeedleId, Cookie, error) {
 	return needleId, cookie, nil
 }
 
+// IsExpired tells whether the needle has its own TTL, and the TTL has passed since it was written.
+// Needles in version 2 volumes have no append time, so their last modified time is used.
+func (n *Needle) IsExpired(now time.Time) bool {
+	if !n.HasTtl() || n.Ttl == nil {
+		return false
+	}
+	ttlMinutes := n.Ttl.Minutes()
+	if ttlMinutes == 0 {
+		return false
+	}
+	var writtenAt time.Time
+	if n.AppendAtNs > 0 {
+		writtenAt = time.Unix(0, int64(n.AppendAtNs))
+	} else if n.HasLastModifiedDate() {
+		writtenAt = time.Unix(int64(n.LastModified), 0)
+	} else {
+		return false
+	}
+	return !now.Before(writtenAt.Add(time.Duration(ttlMinutes) * time.Minute))
+}
+
 func (n *Needle) LastModifiedString() string {
 	return time.Unix(int64(n.LastModified), 0).Format("2006-01-02T15:04:05")
 }
@@ -109,8 +109,11 @@ func parsePut(r *http.Request, sizeLimit int64, pu *ParsedUpload) error {
 	if err == io.EOF || dataSize == sizeLimit+1 {
 		io.Copy(io.Discard, r.Body)
 	}
-	pu.Data = pu.bytesBuffer.Bytes()
 	r.Body.Close()
+	if dataSize == sizeLimit+1 {
+		return fmt.Errorf("%w of %d bytes", ErrorTooLarge, sizeLimit)
+	}
+	pu.Data = pu.bytesBuffer.Bytes()
 	return nil
 }
 
@@ -148,7 +151,7 @@ func parseMultipart(r *http.Request, sizeLimit int64, pu *ParsedUpload) (e error
 		return
 	}
 	if dataSize == sizeLimit+1 {
-		e = fmt.Errorf("file over the limited %d bytes", sizeLimit)
+		e = fmt.Errorf("%w of %d bytes", ErrorTooLarge, sizeLimit)
 		return
 	}
 	pu.Data = pu.bytesBuffer.Bytes()
@@ -172,7 +175,7 @@ func parseMultipart(r *http.Request, sizeLimit int64, pu *ParsedUpload) (e error
 				return
 			}
 			if dataSize2 == sizeLimit+1 {
-				e = fmt.Errorf("file over the limited %d bytes", sizeLimit)
+				e = fmt.Errorf("%w of %d bytes", ErrorTooLarge, sizeLimit)
 				return
 			}
 
@@ -18,12 +18,15 @@ const (
 	FlagHasLastModifiedDate = 0x08
 	FlagHasTtl              = 0x10
 	FlagHasPairs            = 0x20
+	FlagXxhashChecksum      = 0x40
 	FlagIsChunkManifest     = 0x80
 	LastModifiedBytesLength = 5
 	TtlBytesLength          = 2
 )
 
 var ErrorSizeMismatch = errors.New("size mismatch")
+var ErrorCRC = errors.New("CRC error! Data On Disk Corrupted")
+var ErrorTooLarge = errors.New("file over the size limit")
 
 func (n *Needle) DiskSize(version Version) int64 {
 	return GetActualSize(n.Size, version)
@@ -71,11 +74,11 @@ func (n *Needle) ReadBytes(bytes []byte, offset int64, size Size, version Versio
 	}
 	if size > 0 {
 		checksum := util.BytesToUint32(bytes[NeedleHeaderSize+size : NeedleHeaderSize+size+NeedleChecksumSize])
-		newChecksum := NewCRC(n.Data)
+		newChecksum := n.GetChecksumAlgorithm().Checksum(n.Data)
 		if checksum != newChecksum.Value() && checksum != uint32(newChecksum) {
 			// the crc.Value() function is to be deprecated. this double checking is for backward compatible.
 			stats.VolumeServerRequestCounter.WithLabelValues(stats.ErrorCRC).Inc()
-			return errors.New("CRC error! Data On Disk Corrupted")
+			return ErrorCRC
 		}
 		n.Checksum = newChecksum
 	}
@@ -237,7 +240,7 @@ func (n *Needle) ReadNeedleBodyBytes(needleBody []byte, version Version) (err er
 		n.Checksum = NewCRC(n.Data)
 	case Version2, Version3:
 		err = n.readNeedleDataVersion2(needleBody[0:n.Size])
-		n.Checksum = NewCRC(n.Data)
+		n.Checksum = n.GetChecksumAlgorithm().Checksum(n.Data)
 
 		if version == Version3 {
 			tsOffset := n.Size + NeedleChecksumSize
@@ -0,0 +1,31 @@
+package needle
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+func TestReadBytesCRCError(t *testing.T) {
+	n := &Needle{
+		Cookie:   types.Cookie(123),
+		Id:       types.NeedleId(123),
+		Data:     []byte("abcd"),
+		Checksum: NewCRC([]byte("abcd")),
+	}
+	var buf bytes.Buffer
+	_, _, err := n.prepareWriteBuffer(CurrentVersion, &buf)
+	assert.Nil(t, err)
+
+	blob := buf.Bytes()
+	read := &Needle{Id: n.Id}
+	assert.Nil(t, read.ReadBytes(blob, 0, n.Size, CurrentVersion))
+	assert.Equal(t, []byte("abcd"), read.Data)
+
+	// flip one byte of the data
+	blob[types.NeedleHeaderSize+4] ^= 0xff
+	assert.ErrorIs(t, (&Needle{Id: n.Id}).ReadBytes(blob, 0, n.Size, CurrentVersion), ErrorCRC)
+}
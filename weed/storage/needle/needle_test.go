@@ -2,6 +2,7 @@ package needle
 
 import (
 	"testing"
+	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/storage/types"
 )
@@ -47,3 +48,31 @@ func BenchmarkParseKeyHash(b *testing.B) {
 		ParseNeedleIdCookie("4ed44ed44ed44ed4c8116e41")
 	}
 }
+
+func TestNeedleIsExpired(t *testing.T) {
+	now := time.Now()
+	ttl, _ := ReadTTL("1h")
+
+	n := &Needle{}
+	if n.IsExpired(now) {
+		t.Errorf("needle without ttl should not expire")
+	}
+
+	n = &Needle{Ttl: ttl, AppendAtNs: uint64(now.Add(-2 * time.Hour).UnixNano())}
+	n.SetHasTtl()
+	if !n.IsExpired(now) {
+		t.Errorf("needle written 2 hours ago with 1h ttl should expire")
+	}
+	n.AppendAtNs = uint64(now.Add(-30 * time.Minute).UnixNano())
+	if n.IsExpired(now) {
+		t.Errorf("needle written 30 minutes ago with 1h ttl should not expire")
+	}
+
+	// version 2 needles have no append time
+	n = &Needle{Ttl: ttl, LastModified: uint64(now.Add(-2 * time.Hour).Unix())}
+	n.SetHasTtl()
+	n.SetHasLastModifiedDate()
+	if !n.IsExpired(now) {
+		t.Errorf("needle modified 2 hours ago with 1h ttl should expire")
+	}
+}
@@ -21,6 +21,21 @@ const (
 	NeedleMapLevelDbLarge                // large memory footprint, 12MB total, 4write buffer, 8 block buffer
 )
 
+// String is the name of the "-index" option of the volume server
+func (kind NeedleMapKind) String() string {
+	switch kind {
+	case NeedleMapInMemory:
+		return "memory"
+	case NeedleMapLevelDb:
+		return "leveldb"
+	case NeedleMapLevelDbMedium:
+		return "leveldbMedium"
+	case NeedleMapLevelDbLarge:
+		return "leveldbLarge"
+	}
+	return "unknown"
+}
+
 type NeedleMapper interface {
 	Put(key NeedleId, offset Offset, size Size) error
 	Get(key NeedleId) (element *needle_map.NeedleValue, ok bool)
@@ -32,6 +47,7 @@ type NeedleMapper interface {
 	FileCount() int
 	DeletedCount() int
 	MaxFileKey() NeedleId
+	IndexChecksum() uint64
 	IndexFileSize() uint64
 	Sync() error
 	ReadIndexEntry(n int64) (key NeedleId, offset Offset, size Size, err error)
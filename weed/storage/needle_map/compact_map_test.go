@@ -12,7 +12,7 @@ func TestSnowflakeSequencer(t *testing.T) {
 	seq, _ := sequence.NewSnowflakeSequencer("for_test", 1)
 
 	for i := 0; i < 200000; i++ {
-		id := seq.NextFileId(1)
+		id, _ := seq.NextFileId(1)
 		oldOffset, oldSize := m.Set(NeedleId(id), ToOffset(8), 3000073)
 		if oldSize != 0 {
 			t.Errorf("id %d oldOffset %v oldSize %d", id, oldOffset, oldSize)
@@ -191,7 +191,7 @@ func (m *LevelDbNeedleMap) Delete(key NeedleId, offset Offset) error {
 	if !found || oldNeedle.Size.IsDeleted() {
 		return nil
 	}
-	m.logDelete(oldNeedle.Size)
+	m.logDelete(key, oldNeedle.Size)
 
 	// write to index file first
 	if err := m.appendToIndexFile(key, offset, TombstoneFileSize); err != nil {
@@ -45,10 +45,12 @@ func doLoading(file *os.File, nm *NeedleMap) (*NeedleMap, error) {
 				nm.DeletionCounter++
 				nm.DeletionByteCounter = nm.DeletionByteCounter + uint64(oldSize)
 			}
+			nm.updateIndexChecksum(key, oldSize, size)
 		} else {
 			oldSize := nm.m.Delete(NeedleId(key))
 			nm.DeletionCounter++
 			nm.DeletionByteCounter = nm.DeletionByteCounter + uint64(oldSize)
+			nm.updateIndexChecksum(key, oldSize, 0)
 		}
 		return nil
 	})
@@ -67,7 +69,7 @@ func (nm *NeedleMap) Get(key NeedleId) (element *needle_map.NeedleValue, ok bool
 }
 func (nm *NeedleMap) Delete(key NeedleId, offset Offset) error {
 	deletedBytes := nm.m.Delete(NeedleId(key))
-	nm.logDelete(deletedBytes)
+	nm.logDelete(key, deletedBytes)
 	return nm.appendToIndexFile(key, offset, TombstoneFileSize)
 }
 func (nm *NeedleMap) Close() {
@@ -120,10 +122,12 @@ func (nm *NeedleMap) DoOffsetLoading(v *Volume, indexFile *os.File, startFrom ui
 				nm.DeletionCounter++
 				nm.DeletionByteCounter = nm.DeletionByteCounter + uint64(oldSize)
 			}
+			nm.updateIndexChecksum(key, oldSize, size)
 		} else {
 			oldSize := nm.m.Delete(NeedleId(key))
 			nm.DeletionCounter++
 			nm.DeletionByteCounter = nm.DeletionByteCounter + uint64(oldSize)
+			nm.updateIndexChecksum(key, oldSize, 0)
 		}
 		return nil
 	})
@@ -16,13 +16,17 @@ type mapMetric struct {
 	DeletionByteCounter uint64 `json:"DeletionByteCounter"`
 	FileByteCounter     uint64 `json:"FileByteCounter"`
 	MaximumFileKey      uint64 `json:"MaxFileKey"`
+	IndexHashSum        uint64 `json:"IndexHashSum"`
+	// the counters loaded by the bloom filter walk are estimates, so the checksum is not reported
+	indexChecksumUnknown bool
 }
 
-func (mm *mapMetric) logDelete(deletedByteCount Size) {
+func (mm *mapMetric) logDelete(key NeedleId, deletedByteCount Size) {
 	if mm == nil {
 		return
 	}
 	mm.LogDeletionCounter(deletedByteCount)
+	mm.updateIndexChecksum(key, deletedByteCount, 0)
 }
 
 func (mm *mapMetric) logPut(key NeedleId, oldSize Size, newSize Size) {
@@ -34,6 +38,27 @@ func (mm *mapMetric) logPut(key NeedleId, oldSize Size, newSize Size) {
 	if oldSize > 0 && oldSize.IsValid() {
 		mm.LogDeletionCounter(oldSize)
 	}
+	mm.updateIndexChecksum(key, oldSize, newSize)
+}
+
+// updateIndexChecksum replaces the old size of the needle with the new size in the checksum.
+// The checksum sums up the hash of each live needle id and size, which does not depend on
+// the order of the writes or the offsets, so the replicas with the same needles have the same checksum.
+func (mm *mapMetric) updateIndexChecksum(key NeedleId, oldSize Size, newSize Size) {
+	if oldSize.IsValid() {
+		atomic.AddUint64(&mm.IndexHashSum, -indexEntryHash(key, oldSize))
+	}
+	if newSize.IsValid() {
+		atomic.AddUint64(&mm.IndexHashSum, indexEntryHash(key, newSize))
+	}
+}
+
+// indexEntryHash mixes the needle id and size, with the finalizer of splitmix64
+func indexEntryHash(key NeedleId, size Size) uint64 {
+	h := uint64(key) ^ uint64(uint32(size))<<32 ^ uint64(uint32(size))
+	h = (h ^ (h >> 30)) * 0xbf58476d1ce4e5b9
+	h = (h ^ (h >> 27)) * 0x94d049bb133111eb
+	return h ^ (h >> 31)
 }
 func (mm *mapMetric) LogFileCounter(newSize Size) {
 	if mm == nil {
@@ -75,6 +100,13 @@ func (mm *mapMetric) DeletedCount() int {
 	}
 	return int(atomic.LoadUint32(&mm.DeletionCounter))
 }
+// IndexChecksum is 0 if unknown
+func (mm *mapMetric) IndexChecksum() uint64 {
+	if mm == nil || mm.indexChecksumUnknown {
+		return 0
+	}
+	return atomic.LoadUint64(&mm.IndexHashSum)
+}
 func (mm *mapMetric) MaxFileKey() NeedleId {
 	if mm == nil {
 		return 0
@@ -94,6 +126,7 @@ func (mm *mapMetric) MaybeSetMaxFileKey(key NeedleId) {
 func needleMapMetricFromIndexFile(r *os.File, mm *mapMetric) error {
 	var bf *boom.BloomFilter
 	buf := make([]byte, NeedleIdSize)
+	mm.indexChecksumUnknown = true
 	err := reverseWalkIndexFile(r, func(entryCount int64) {
 		bf = boom.NewBloomFilter(uint(entryCount), 0.001)
 	}, func(key NeedleId, offset Offset, size Size) error {
@@ -29,3 +29,40 @@ func TestFastLoadingNeedleMapMetrics(t *testing.T) {
 	glog.V(0).Infof("DeletedCount expected %d actual %d", nm.DeletedCount(), mm.DeletedCount())
 	glog.V(0).Infof("MaxFileKey expected %d actual %d", nm.MaxFileKey(), mm.MaxFileKey())
 }
+
+func TestIndexChecksum(t *testing.T) {
+	aIdxFile, _ := os.CreateTemp("", "a.idx")
+	defer os.Remove(aIdxFile.Name())
+	bIdxFile, _ := os.CreateTemp("", "b.idx")
+	defer os.Remove(bIdxFile.Name())
+	a, b := NewCompactNeedleMap(aIdxFile), NewCompactNeedleMap(bIdxFile)
+
+	// the same needles written in different orders and offsets
+	a.Put(1, ToOffset(8), 100)
+	a.Put(2, ToOffset(16), 200)
+	a.Put(3, ToOffset(24), 300)
+	a.Delete(2, ToOffset(32))
+	a.Put(1, ToOffset(40), 110)
+
+	b.Put(3, ToOffset(8), 300)
+	b.Put(1, ToOffset(16), 110)
+	b.Put(2, ToOffset(24), 200)
+	b.Delete(2, ToOffset(32))
+
+	if a.IndexChecksum() != b.IndexChecksum() {
+		t.Errorf("same needles: %x != %x", a.IndexChecksum(), b.IndexChecksum())
+	}
+
+	mm, err := newNeedleMapMetricFromIndexFile(aIdxFile)
+	if err != nil {
+		t.Fatalf("load metric: %v", err)
+	}
+	if mm.IndexChecksum() != 0 {
+		t.Errorf("loaded from index: expected unknown checksum, actual %x", mm.IndexChecksum())
+	}
+
+	b.Put(4, ToOffset(40), 400)
+	if a.IndexChecksum() == b.IndexChecksum() {
+		t.Errorf("different needles: %x", a.IndexChecksum())
+	}
+}
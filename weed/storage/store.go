@@ -1,11 +1,15 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"syscall"
+	"time"
 
 	"google.golang.org/grpc"
 
@@ -23,6 +27,7 @@ import (
 
 const (
 	MAX_TTL_VOLUME_REMOVAL_DELAY = 10 // 10 minutes
+	lastReadTimeSaveInterval     = time.Hour
 )
 
 type ReadOption struct {
@@ -70,6 +75,13 @@ type Store struct {
 	NewEcShardsChan     chan master_pb.VolumeEcShardInformationMessage
 	DeletedEcShardsChan chan master_pb.VolumeEcShardInformationMessage
 	isStopping          bool
+	DiskHealth          *stats.DiskHealthCollector
+	// move a volume to another location of the same disk type when its location runs out of space
+	DiskSpillover bool
+	spillingOver  sync.Map
+	// the volume counters in the last heartbeat
+	reportedVolumeCounters     map[needle.VolumeId]volumeCounters
+	reportedVolumeCountersLock sync.Mutex
 }
 
 func (s *Store) String() (str string) {
@@ -78,15 +90,24 @@ func (s *Store) String() (str string) {
 }
 
 func NewStore(grpcDialOption grpc.DialOption, ip string, port int, grpcPort int, publicUrl string, dirnames []string, maxVolumeCounts []int32,
-	minFreeSpaces []util.MinFreeSpace, idxFolder string, needleMapKind NeedleMapKind, diskTypes []DiskType) (s *Store) {
+	minFreeSpaces []util.MinFreeSpace, idxFolder string, needleMapKind NeedleMapKind, diskTypes []DiskType, loadOption VolumeLoadOption) (s *Store) {
 	s = &Store{grpcDialOption: grpcDialOption, Port: port, Ip: ip, GrpcPort: grpcPort, PublicUrl: publicUrl, NeedleMapKind: needleMapKind}
 	s.Locations = make([]*DiskLocation, 0)
 	for i := 0; i < len(dirnames); i++ {
 		location := NewDiskLocation(dirnames[i], int32(maxVolumeCounts[i]), minFreeSpaces[i], idxFolder, diskTypes[i])
-		location.loadExistingVolumes(needleMapKind)
 		s.Locations = append(s.Locations, location)
 		stats.VolumeServerMaxVolumeCounter.Add(float64(maxVolumeCounts[i]))
 	}
+	// the disks are loaded in parallel
+	var wg sync.WaitGroup
+	for _, location := range s.Locations {
+		wg.Add(1)
+		go func(location *DiskLocation) {
+			defer wg.Done()
+			location.loadExistingVolumes(needleMapKind, loadOption)
+		}(location)
+	}
+	wg.Wait()
 	s.NewVolumesChan = make(chan master_pb.VolumeShortInformationMessage, 3)
 	s.DeletedVolumesChan = make(chan master_pb.VolumeShortInformationMessage, 3)
 
@@ -95,7 +116,10 @@ func NewStore(grpcDialOption grpc.DialOption, ip string, port int, grpcPort int,
 
 	return
 }
-func (s *Store) AddVolume(volumeId needle.VolumeId, collection string, needleMapKind NeedleMapKind, replicaPlacement string, ttlString string, preallocate int64, MemoryMapMaxSizeMb uint32, diskType DiskType) error {
+func (s *Store) AddVolume(volumeId needle.VolumeId, collection string, needleMapKind NeedleMapKind, replicaPlacement string, ttlString string, preallocate int64, MemoryMapMaxSizeMb uint32, diskType DiskType, checksumAlgorithm string) error {
+	if s.IsReadOnly() {
+		return fmt.Errorf("volume server %s:%d is read only", s.Ip, s.Port)
+	}
 	rt, e := super_block.NewReplicaPlacementFromString(replicaPlacement)
 	if e != nil {
 		return e
@@ -104,7 +128,11 @@ func (s *Store) AddVolume(volumeId needle.VolumeId, collection string, needleMap
 	if e != nil {
 		return e
 	}
-	e = s.addVolume(volumeId, collection, needleMapKind, rt, ttl, preallocate, MemoryMapMaxSizeMb, diskType)
+	checksum, e := needle.ParseChecksumAlgorithm(checksumAlgorithm)
+	if e != nil {
+		return e
+	}
+	e = s.addVolume(volumeId, collection, needleMapKind, rt, ttl, preallocate, MemoryMapMaxSizeMb, diskType, checksum)
 	return e
 }
 func (s *Store) DeleteCollection(collection string) (e error) {
@@ -147,14 +175,22 @@ func (s *Store) FindFreeLocation(diskType DiskType) (ret *DiskLocation) {
 	}
 	return ret
 }
-func (s *Store) addVolume(vid needle.VolumeId, collection string, needleMapKind NeedleMapKind, replicaPlacement *super_block.ReplicaPlacement, ttl *needle.TTL, preallocate int64, memoryMapMaxSizeMb uint32, diskType DiskType) error {
+func (s *Store) addVolume(vid needle.VolumeId, collection string, needleMapKind NeedleMapKind, replicaPlacement *super_block.ReplicaPlacement, ttl *needle.TTL, preallocate int64, memoryMapMaxSizeMb uint32, diskType DiskType, checksumAlgorithm needle.ChecksumAlgorithm) error {
 	if s.findVolume(vid) != nil {
 		return fmt.Errorf("Volume Id %d already exists!", vid)
 	}
+	for _, location := range s.Locations {
+		if location.isQuarantined(vid) {
+			return fmt.Errorf("volume %d is quarantined in %s", vid, location.Directory)
+		}
+	}
 	if location := s.FindFreeLocation(diskType); location != nil {
-		glog.V(0).Infof("In dir %s adds volume:%v collection:%s replicaPlacement:%v ttl:%v",
-			location.Directory, vid, collection, replicaPlacement, ttl)
-		if volume, err := NewVolume(location.Directory, location.IdxDirectory, collection, vid, needleMapKind, replicaPlacement, ttl, preallocate, memoryMapMaxSizeMb); err == nil {
+		glog.V(0).Infof("In dir %s adds volume:%v collection:%s replicaPlacement:%v ttl:%v checksum:%v",
+			location.Directory, vid, collection, replicaPlacement, ttl, checksumAlgorithm)
+		if preallocate == 0 && location.Preallocate {
+			preallocate = int64(s.GetVolumeSizeLimit())
+		}
+		if volume, err := NewVolume(location.Directory, location.IdxDirectory, collection, vid, needleMapKind, replicaPlacement, ttl, preallocate, memoryMapMaxSizeMb, checksumAlgorithm); err == nil {
 			location.SetVolume(vid, volume)
 			glog.V(0).Infof("add volume %d", vid)
 			s.NewVolumesChan <- master_pb.VolumeShortInformationMessage{
@@ -243,9 +279,15 @@ func (s *Store) CollectHeartbeat() *master_pb.Heartbeat {
 	collectionVolumeReadOnlyCount := make(map[string]map[string]uint8)
 	for _, location := range s.Locations {
 		var deleteVids []needle.VolumeId
-		maxVolumeCounts[string(location.DiskType)] += uint32(location.MaxVolumeCount)
+		if s.DiskSpillover && location.isDiskSpaceLow {
+			// no free slots on a full location, so the master grows volumes elsewhere
+			maxVolumeCounts[string(location.DiskType)] += uint32(location.VolumesLen() + (location.EcVolumesLen()+erasure_coding.DataShardsCount-1)/erasure_coding.DataShardsCount)
+		} else {
+			maxVolumeCounts[string(location.DiskType)] += uint32(location.MaxVolumeCount)
+		}
 		location.volumesLock.RLock()
 		for _, v := range location.volumes {
+			v.maybeSaveLastReadTime(lastReadTimeSaveInterval)
 			curMaxFileKey, volumeMessage := v.ToVolumeInformationMessage()
 			if volumeMessage == nil {
 				continue
@@ -323,8 +365,16 @@ func (s *Store) CollectHeartbeat() *master_pb.Heartbeat {
 	}
 
 	var uuidList []string
+	var diskHealths []*master_pb.DiskHealth
+	var quarantinedVolumes []*master_pb.QuarantinedVolume
 	for _, loc := range s.Locations {
 		uuidList = append(uuidList, loc.DirectoryUuid)
+		quarantinedVolumes = append(quarantinedVolumes, loc.QuarantinedVolumes()...)
+		if s.DiskHealth != nil {
+			diskHealth := s.DiskHealth.Collect(loc.Directory, loc.DiskType.ReadableString())
+			diskHealth.IsSpaceLow = loc.isDiskSpaceLow
+			diskHealths = append(diskHealths, diskHealth)
+		}
 	}
 
 	for col, size := range collectionVolumeSize {
@@ -338,19 +388,38 @@ func (s *Store) CollectHeartbeat() *master_pb.Heartbeat {
 	}
 
 	return &master_pb.Heartbeat{
-		Ip:              s.Ip,
-		Port:            uint32(s.Port),
-		GrpcPort:        uint32(s.GrpcPort),
-		PublicUrl:       s.PublicUrl,
-		MaxVolumeCounts: maxVolumeCounts,
-		MaxFileKey:      NeedleIdToUint64(maxFileKey),
-		DataCenter:      s.dataCenter,
-		Rack:            s.rack,
-		Volumes:         volumeMessages,
-		HasNoVolumes:    len(volumeMessages) == 0,
-		LocationUuids:   uuidList,
+		Ip:                  s.Ip,
+		Port:                uint32(s.Port),
+		GrpcPort:            uint32(s.GrpcPort),
+		PublicUrl:           s.PublicUrl,
+		MaxVolumeCounts:     maxVolumeCounts,
+		MaxFileKey:          NeedleIdToUint64(maxFileKey),
+		DataCenter:          s.dataCenter,
+		Rack:                s.rack,
+		Volumes:             volumeMessages,
+		HasNoVolumes:        len(volumeMessages) == 0,
+		LocationUuids:       uuidList,
+		DiskHealths:         diskHealths,
+		IsReadonly:          s.IsReadOnly(),
+		QuarantinedVolumes:  quarantinedVolumes,
+		VolumeCounterDeltas: s.collectVolumeCounterDeltas(volumeMessages),
+	}
+
+}
+
+// SetReadOnly marks all volumes on this server read only, or back to their own state.
+// It is not persisted, so a restarted volume server is writable again.
+func (s *Store) SetReadOnly(isReadOnly bool) {
+	for _, location := range s.Locations {
+		location.setServerReadOnly(isReadOnly)
 	}
+}
 
+func (s *Store) IsReadOnly() bool {
+	if len(s.Locations) == 0 {
+		return false
+	}
+	return s.Locations[0].IsServerReadOnly()
 }
 
 func (s *Store) SetStopping() {
@@ -373,6 +442,9 @@ func (s *Store) WriteVolumeNeedle(i needle.VolumeId, n *needle.Needle, checkCook
 			return
 		}
 		_, _, isUnchanged, err = v.writeNeedle2(n, checkCookie, fsync && s.isStopping)
+		if err != nil && s.DiskSpillover && errors.Is(err, syscall.ENOSPC) {
+			go s.spillOver(v)
+		}
 		return
 	}
 	glog.V(0).Infoln("volume", i, "not found!")
@@ -397,6 +469,30 @@ func (s *Store) ReadVolumeNeedle(i needle.VolumeId, n *needle.Needle, readOption
 	return 0, fmt.Errorf("volume %d not found", i)
 }
 
+// HasVolumeNeedle looks up the needle in the index of the volume or the ec volume, without reading the needle.
+func (s *Store) HasVolumeNeedle(i needle.VolumeId, id NeedleId) (size Size, found bool, err error) {
+	if v := s.findVolume(i); v != nil {
+		v.dataFileAccessLock.RLock()
+		defer v.dataFileAccessLock.RUnlock()
+		nv, ok := v.nm.Get(id)
+		if !ok || nv.Offset.IsZero() || nv.Size.IsDeleted() {
+			return 0, false, nil
+		}
+		return nv.Size, true, nil
+	}
+	if ev, ok := s.FindEcVolume(i); ok {
+		_, size, err := ev.FindNeedleFromEcx(id)
+		if err == erasure_coding.NotFoundError || err == nil && size.IsDeleted() {
+			return 0, false, nil
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		return size, true, nil
+	}
+	return 0, false, fmt.Errorf("volume %d not found", i)
+}
+
 func (s *Store) ReadVolumeNeedleMetaAt(i needle.VolumeId, n *needle.Needle, offset int64, size int32) error {
 	if v := s.findVolume(i); v != nil {
 		return v.readNeedleMetaAt(n, offset, size)
@@ -414,6 +510,17 @@ func (s *Store) GetVolume(i needle.VolumeId) *Volume {
 	return s.findVolume(i)
 }
 
+func (s *Store) GetVolumes() (volumes []*Volume) {
+	for _, location := range s.Locations {
+		location.volumesLock.RLock()
+		for _, v := range location.volumes {
+			volumes = append(volumes, v)
+		}
+		location.volumesLock.RUnlock()
+	}
+	return
+}
+
 func (s *Store) HasVolume(i needle.VolumeId) bool {
 	v := s.findVolume(i)
 	return v != nil
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+)
+
+// spillOver moves a volume whose location ran out of space to the location of the same disk type
+// with the most free volume slots, so the volume takes writes again.
+func (s *Store) spillOver(v *Volume) {
+	if _, loaded := s.spillingOver.LoadOrStore(v.Id, true); loaded {
+		return
+	}
+	defer s.spillingOver.Delete(v.Id)
+
+	if err := s.moveVolumeToFreeLocation(v); err != nil {
+		glog.Warningf("spill over volume %d: %v", v.Id, err)
+	}
+}
+
+func (s *Store) moveVolumeToFreeLocation(v *Volume) error {
+	source := v.location
+	if source == nil || v.HasRemoteFile() {
+		return fmt.Errorf("volume is not on a local disk")
+	}
+	target := s.FindFreeLocation(source.DiskType)
+	if target == nil || target == source {
+		return fmt.Errorf("no other %s location with free space", source.DiskType.ReadableString())
+	}
+
+	datSize, idxSize, _ := v.FileStat()
+	if free := stats.NewDiskStatus(target.Directory).Free; free < datSize+idxSize {
+		return fmt.Errorf("%s has %d bytes free, less than the volume size %d", target.Directory, free, datSize+idxSize)
+	}
+
+	// stop the deletes, the writes are already stopped by the full location
+	v.noWriteLock.Lock()
+	noWriteOrDelete := v.noWriteOrDelete
+	v.noWriteOrDelete = true
+	v.noWriteLock.Unlock()
+	restoreNoWrite := func() {
+		v.noWriteLock.Lock()
+		v.noWriteOrDelete = noWriteOrDelete
+		v.noWriteLock.Unlock()
+	}
+
+	targetBaseFileName := VolumeFileName(target.Directory, v.Collection, int(v.Id))
+	targetIdxBaseFileName := VolumeFileName(target.IdxDirectory, v.Collection, int(v.Id))
+	// the .idx files stay in place if all locations share one index directory
+	isIdxMoved := source.IdxDirectory != target.IdxDirectory
+	removeTargetFiles := func() {
+		removeVolumeFiles(targetBaseFileName)
+		if isIdxMoved {
+			removeVolumeFiles(targetIdxBaseFileName)
+		}
+	}
+
+	// a volume copy interrupted by a restart is removed when loading
+	noteFile := targetBaseFileName + ".note"
+	if err := os.WriteFile(noteFile, []byte("spilling over from "+source.Directory), 0644); err != nil {
+		restoreNoWrite()
+		return err
+	}
+
+	glog.V(0).Infof("spill over volume %d from %s to %s", v.Id, source.Directory, target.Directory)
+	v.dataFileAccessLock.RLock()
+	err := copyVolumeFile(v.FileName(".dat"), targetBaseFileName+".dat", true)
+	if err == nil && isIdxMoved {
+		err = copyVolumeFile(v.FileName(".idx"), targetIdxBaseFileName+".idx", true)
+	}
+	if err == nil {
+		err = copyVolumeFile(v.FileName(".vif"), targetBaseFileName+".vif", false)
+	}
+	v.dataFileAccessLock.RUnlock()
+	if err != nil {
+		removeTargetFiles()
+		restoreNoWrite()
+		return err
+	}
+	if newDatSize, newIdxSize, _ := v.FileStat(); newDatSize != datSize || newIdxSize != idxSize {
+		removeTargetFiles()
+		restoreNoWrite()
+		return fmt.Errorf("volume changed while copying")
+	}
+	if err = os.Remove(noteFile); err != nil {
+		removeTargetFiles()
+		restoreNoWrite()
+		return err
+	}
+
+	// load the copy before unloading the volume, so the reads do not miss it
+	entryFileName := targetBaseFileName + ".vif"
+	if _, err = os.Stat(entryFileName); err != nil {
+		entryFileName = targetIdxBaseFileName + ".idx"
+	}
+	fileInfo, err := os.Stat(entryFileName)
+	if err != nil || !target.loadExistingVolume(fs.FileInfoToDirEntry(fileInfo), s.NeedleMapKind, false) {
+		removeTargetFiles()
+		restoreNoWrite()
+		return fmt.Errorf("load the copy in %s failed", target.Directory)
+	}
+	if newVolume, found := target.FindVolume(v.Id); found {
+		newVolume.noWriteLock.Lock()
+		newVolume.noWriteOrDelete = noWriteOrDelete
+		newVolume.noWriteLock.Unlock()
+	}
+
+	if err = source.UnloadVolume(v.Id); err != nil {
+		glog.Warningf("unload volume %d from %s: %v", v.Id, source.Directory, err)
+	}
+	removeVolumeFiles(VolumeFileName(source.Directory, v.Collection, int(v.Id)))
+	if isIdxMoved {
+		removeVolumeFiles(VolumeFileName(source.IdxDirectory, v.Collection, int(v.Id)))
+	}
+	glog.V(0).Infof("spilled over volume %d to %s", v.Id, target.Directory)
+	return nil
+}
+
+func copyVolumeFile(src, dst string, isRequired bool) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		if os.IsNotExist(err) && !isRequired {
+			return nil
+		}
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(dstFile, srcFile); err != nil {
+		dstFile.Close()
+		return fmt.Errorf("copy %s to %s: %v", src, dst, err)
+	}
+	if err = dstFile.Sync(); err != nil {
+		dstFile.Close()
+		return err
+	}
+	return dstFile.Close()
+}
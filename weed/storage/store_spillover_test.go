@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestSpillOverToFreeLocation(t *testing.T) {
+	dirs := []string{t.TempDir(), t.TempDir()}
+	s := NewStore(nil, "localhost", 8080, 18080, "localhost:8080", dirs, []int32{8, 8},
+		[]util.MinFreeSpace{{}, {}}, "", NeedleMapInMemory, []types.DiskType{types.HardDriveType, types.HardDriveType}, VolumeLoadOption{})
+	defer s.Close()
+
+	vid := needle.VolumeId(1)
+	if err := s.AddVolume(vid, "", NeedleMapInMemory, "000", "", 0, 0, types.HardDriveType, ""); err != nil {
+		t.Fatalf("add volume: %v", err)
+	}
+	source := s.findVolume(vid).location
+	n := newRandomNeedle(1)
+	if _, err := s.WriteVolumeNeedle(vid, n, true, false); err != nil {
+		t.Fatalf("write needle: %v", err)
+	}
+
+	source.markDiskFull(syscall.ENOSPC)
+	if _, err := s.WriteVolumeNeedle(vid, newRandomNeedle(2), true, false); err == nil {
+		t.Fatalf("write to a full location should fail")
+	}
+
+	if err := s.moveVolumeToFreeLocation(s.findVolume(vid)); err != nil {
+		t.Fatalf("spill over: %v", err)
+	}
+	if location := s.findVolume(vid).location; location == source {
+		t.Fatalf("volume is still in %s", source.Directory)
+	}
+	if _, found := source.FindVolume(vid); found {
+		t.Errorf("volume is still loaded in %s", source.Directory)
+	}
+
+	read := &needle.Needle{Id: n.Id, Cookie: n.Cookie}
+	if _, err := s.ReadVolumeNeedle(vid, read, nil, nil); err != nil {
+		t.Fatalf("read needle after spill over: %v", err)
+	}
+	if string(read.Data) != string(n.Data) {
+		t.Errorf("needle data changed after spill over")
+	}
+	if _, err := s.WriteVolumeNeedle(vid, newRandomNeedle(2), true, false); err != nil {
+		t.Errorf("write after spill over: %v", err)
+	}
+}
@@ -8,15 +8,20 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 )
 
-func (s *Store) CheckCompactVolume(volumeId needle.VolumeId) (float64, error) {
+// CheckCompactVolume returns the garbage ratio and the garbage bytes a compaction would reclaim.
+func (s *Store) CheckCompactVolume(volumeId needle.VolumeId) (float64, uint64, error) {
 	if v := s.findVolume(volumeId); v != nil {
-		glog.V(3).Infof("volume %d garbage level: %f", volumeId, v.garbageLevel())
-		return v.garbageLevel(), nil
+		garbageBytes, _ := v.garbageSize()
+		glog.V(3).Infof("volume %d garbage level: %f, %d bytes", volumeId, v.garbageLevel(), garbageBytes)
+		return v.garbageLevel(), garbageBytes, nil
 	}
-	return 0, fmt.Errorf("volume id %d is not found during check compact", volumeId)
+	return 0, 0, fmt.Errorf("volume id %d is not found during check compact", volumeId)
 }
 func (s *Store) CompactVolume(vid needle.VolumeId, preallocate int64, compactionBytePerSecond int64, progressFn ProgressFunc) error {
 	if v := s.findVolume(vid); v != nil {
+		if preallocate == 0 && v.location != nil && v.location.Preallocate {
+			preallocate = int64(s.GetVolumeSizeLimit())
+		}
 		s := stats.NewDiskStatus(v.dir)
 		if int64(s.Free) < preallocate {
 			return fmt.Errorf("free space: %d bytes, not enough for %d bytes", s.Free, preallocate)
@@ -25,14 +30,21 @@ func (s *Store) CompactVolume(vid needle.VolumeId, preallocate int64, compaction
 	}
 	return fmt.Errorf("volume id %d is not found during compact", vid)
 }
-func (s *Store) CommitCompactVolume(vid needle.VolumeId) (bool, error) {
+
+// CommitCompactVolume switches to the compacted volume, and returns the bytes it is smaller than the volume before
+func (s *Store) CommitCompactVolume(vid needle.VolumeId) (readOnly bool, reclaimedBytes uint64, err error) {
 	if s.isStopping {
-		return false, fmt.Errorf("volume id %d skips compact because volume is stopping", vid)
+		return false, 0, fmt.Errorf("volume id %d skips compact because volume is stopping", vid)
 	}
 	if v := s.findVolume(vid); v != nil {
-		return v.IsReadOnly(), v.CommitCompact()
+		sizeBefore, _, _ := v.FileStat()
+		err = v.CommitCompact()
+		if sizeAfter, _, _ := v.FileStat(); err == nil && sizeAfter < sizeBefore {
+			reclaimedBytes = sizeBefore - sizeAfter
+		}
+		return v.IsReadOnly(), reclaimedBytes, err
 	}
-	return false, fmt.Errorf("volume id %d is not found during commit compact", vid)
+	return false, 0, fmt.Errorf("volume id %d is not found during commit compact", vid)
 }
 func (s *Store) CommitCleanupVolume(vid needle.VolumeId) error {
 	if v := s.findVolume(vid); v != nil {
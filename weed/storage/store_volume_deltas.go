@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+)
+
+type volumeCounters struct {
+	fileCount     int64
+	deleteCount   int64
+	size          int64
+	indexChecksum uint64
+}
+
+// collectVolumeCounterDeltas compares the volumes with the last heartbeat, and returns the changed ones.
+// All volumes are changed in the first heartbeat to a master.
+func (s *Store) collectVolumeCounterDeltas(volumeMessages []*master_pb.VolumeInformationMessage) (deltas []*master_pb.VolumeCounterDelta) {
+	s.reportedVolumeCountersLock.Lock()
+	defer s.reportedVolumeCountersLock.Unlock()
+
+	reported := make(map[needle.VolumeId]volumeCounters, len(volumeMessages))
+	for _, m := range volumeMessages {
+		current := volumeCounters{
+			fileCount:     int64(m.FileCount),
+			deleteCount:   int64(m.DeleteCount),
+			size:          int64(m.Size),
+			indexChecksum: m.IndexChecksum,
+		}
+		reported[needle.VolumeId(m.Id)] = current
+		last, found := s.reportedVolumeCounters[needle.VolumeId(m.Id)]
+		if found && last == current {
+			continue
+		}
+		deltas = append(deltas, &master_pb.VolumeCounterDelta{
+			Id:            m.Id,
+			Collection:    m.Collection,
+			FileCount:     current.fileCount - last.fileCount,
+			DeleteCount:   current.deleteCount - last.deleteCount,
+			Size:          current.size - last.size,
+			IndexChecksum: current.indexChecksum,
+		})
+	}
+	s.reportedVolumeCounters = reported
+	return
+}
+
+// ResetVolumeCounterDeltas reports all volumes as changed in the next heartbeat, e.g. to a new master.
+func (s *Store) ResetVolumeCounterDeltas() {
+	s.reportedVolumeCountersLock.Lock()
+	s.reportedVolumeCounters = nil
+	s.reportedVolumeCountersLock.Unlock()
+}
@@ -1,11 +1,14 @@
 package super_block
 
 import (
+	"math"
+
 	"google.golang.org/protobuf/proto"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	. "github.com/seaweedfs/seaweedfs/weed/storage/types"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
@@ -13,13 +16,17 @@ const (
 	SuperBlockSize = 8
 )
 
+// EmptyVolumeMaxSize is the size of a volume without any needles, with at most the checksum algorithm in the extra.
+var EmptyVolumeMaxSize = uint64(paddedSize(SuperBlockSize + proto.Size(&master_pb.SuperBlockExtra{ChecksumAlgorithm: math.MaxUint32})))
+
 /*
 * Super block currently has 8 bytes allocated for each volume.
 * Byte 0: version, 1 or 2
 * Byte 1: Replica Placement strategy, 000, 001, 002, 010, etc
 * Byte 2 and byte 3: Time to live. See TTL for definition
 * Byte 4 and byte 5: The number of times the volume has been compacted.
-* Rest bytes: Reserved
+* Byte 6 and byte 7: The size of the extra, e.g. the erasure coding and the checksum algorithm, following the super block.
+* The extra is padded, so the first needle is aligned.
  */
 type SuperBlock struct {
 	Version            needle.Version
@@ -33,11 +40,18 @@ type SuperBlock struct {
 func (s *SuperBlock) BlockSize() int {
 	switch s.Version {
 	case needle.Version2, needle.Version3:
-		return SuperBlockSize + int(s.ExtraSize)
+		return paddedSize(SuperBlockSize + int(s.ExtraSize))
 	}
 	return SuperBlockSize
 }
 
+func paddedSize(size int) int {
+	if size%NeedlePaddingSize != 0 {
+		size += NeedlePaddingSize - size%NeedlePaddingSize
+	}
+	return size
+}
+
 func (s *SuperBlock) Bytes() []byte {
 	header := make([]byte, SuperBlockSize)
 	header[0] = byte(s.Version)
@@ -59,11 +73,20 @@ func (s *SuperBlock) Bytes() []byte {
 		util.Uint16toBytes(header[6:8], s.ExtraSize)
 
 		header = append(header, extraData...)
+		header = append(header, make([]byte, paddedSize(len(header))-len(header))...)
 	}
 
 	return header
 }
 
+// ChecksumAlgorithm is the checksum of the needles written to the volume, CRC32C if not set in the extra.
+func (s *SuperBlock) ChecksumAlgorithm() needle.ChecksumAlgorithm {
+	if s.Extra == nil {
+		return needle.ChecksumCrc32c
+	}
+	return needle.ChecksumAlgorithm(s.Extra.ChecksumAlgorithm)
+}
+
 func (s *SuperBlock) Initialized() bool {
 	return s.ReplicaPlacement != nil && s.Ttl != nil
 }
@@ -1,9 +1,14 @@
 package super_block
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/backend"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
 )
 
 func TestSuperBlockReadWrite(t *testing.T) {
@@ -23,3 +28,40 @@ func TestSuperBlockReadWrite(t *testing.T) {
 	}
 
 }
+
+func TestSuperBlockChecksumAlgorithm(t *testing.T) {
+	rp, _ := NewReplicaPlacementFromByte(byte(001))
+	s := &SuperBlock{
+		Version:          needle.CurrentVersion,
+		ReplicaPlacement: rp,
+		Ttl:              needle.EMPTY_TTL,
+		Extra:            &master_pb.SuperBlockExtra{ChecksumAlgorithm: uint32(needle.ChecksumXxhash)},
+	}
+
+	// the extra is padded, so the first needle is aligned
+	bytes := s.Bytes()
+	if len(bytes) != s.BlockSize() || len(bytes)%types.NeedlePaddingSize != 0 || uint64(len(bytes)) > EmptyVolumeMaxSize {
+		t.Fatalf("super block with extra is %d bytes, block size %d", len(bytes), s.BlockSize())
+	}
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "1.dat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	datBackend := backend.NewDiskFile(f)
+	defer datBackend.Close()
+	if _, err = datBackend.WriteAt(bytes, 0); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadSuperBlock(datBackend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.ChecksumAlgorithm() != needle.ChecksumXxhash || read.BlockSize() != len(bytes) {
+		t.Errorf("read checksum %v block size %d", read.ChecksumAlgorithm(), read.BlockSize())
+	}
+
+	if (&SuperBlock{Version: needle.CurrentVersion}).ChecksumAlgorithm() != needle.ChecksumCrc32c {
+		t.Errorf("volumes without extra should use crc32c")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"path"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
@@ -40,6 +41,7 @@ type Volume struct {
 	asyncRequestsChan     chan *needle.AsyncRequest
 	lastModifiedTsSeconds uint64 // unix time in seconds
 	lastAppendAtNs        uint64 // unix time in nanoseconds
+	lastReadTsSeconds     uint64 // unix time in seconds, accessed atomically
 
 	lastCompactIndexOffset uint64
 	lastCompactRevision    uint16
@@ -53,11 +55,15 @@ type Volume struct {
 	lastIoError error
 }
 
-func NewVolume(dirname string, dirIdx string, collection string, id needle.VolumeId, needleMapKind NeedleMapKind, replicaPlacement *super_block.ReplicaPlacement, ttl *needle.TTL, preallocate int64, memoryMapMaxSizeMb uint32) (v *Volume, e error) {
+func NewVolume(dirname string, dirIdx string, collection string, id needle.VolumeId, needleMapKind NeedleMapKind, replicaPlacement *super_block.ReplicaPlacement, ttl *needle.TTL, preallocate int64, memoryMapMaxSizeMb uint32, checksumAlgorithm needle.ChecksumAlgorithm) (v *Volume, e error) {
 	// if replicaPlacement is nil, the superblock will be loaded from disk
 	v = &Volume{dir: dirname, dirIdx: dirIdx, Collection: collection, Id: id, MemoryMapMaxSizeMb: memoryMapMaxSizeMb,
 		asyncRequestsChan: make(chan *needle.AsyncRequest, 128)}
 	v.SuperBlock = super_block.SuperBlock{ReplicaPlacement: replicaPlacement, Ttl: ttl}
+	if checksumAlgorithm != needle.ChecksumCrc32c {
+		// only used if the volume is new, otherwise the super block is read from the disk
+		v.SuperBlock.Extra = &master_pb.SuperBlockExtra{ChecksumAlgorithm: uint32(checksumAlgorithm)}
+	}
 	v.needleMapKind = needleMapKind
 	e = v.load(true, true, needleMapKind, preallocate)
 	v.startWorker()
@@ -180,6 +186,11 @@ func (v *Volume) DiskType() types.DiskType {
 	return v.location.DiskType
 }
 
+// UsesDirectIO tells whether to read the whole .dat file bypassing the page cache.
+func (v *Volume) UsesDirectIO() bool {
+	return v.location != nil && v.location.DirectIO
+}
+
 func (v *Volume) SetStopping() {
 	v.dataFileAccessLock.Lock()
 	defer v.dataFileAccessLock.Unlock()
@@ -198,6 +209,8 @@ func (v *Volume) SetStopping() {
 func (v *Volume) SyncToDisk() {
 	v.dataFileAccessLock.Lock()
 	defer v.dataFileAccessLock.Unlock()
+	v.saveLastReadTime()
+
 	if v.nm != nil {
 		if err := v.nm.Sync(); err != nil {
 			glog.Warningf("Volume Close fail to sync volume idx %d", v.Id)
@@ -250,7 +263,7 @@ func (v *Volume) expired(contentSize uint64, volumeSizeLimit uint64) bool {
 		// skip if we don't know size limit
 		return false
 	}
-	if contentSize <= super_block.SuperBlockSize {
+	if contentSize <= uint64(v.SuperBlock.BlockSize()) {
 		return false
 	}
 	if v.Ttl == nil || v.Ttl.Minutes() == 0 {
@@ -281,7 +294,7 @@ func (v *Volume) expiredLongEnough(maxDelayMinutes uint32) bool {
 	return false
 }
 
-func (v *Volume) collectStatus() (maxFileKey types.NeedleId, datFileSize int64, modTime time.Time, fileCount, deletedCount, deletedSize uint64, ok bool) {
+func (v *Volume) collectStatus() (maxFileKey types.NeedleId, datFileSize int64, modTime time.Time, fileCount, deletedCount, deletedSize, indexChecksum uint64, ok bool) {
 	v.dataFileAccessLock.RLock()
 	defer v.dataFileAccessLock.RUnlock()
 	glog.V(4).Infof("collectStatus volume %d", v.Id)
@@ -298,13 +311,14 @@ func (v *Volume) collectStatus() (maxFileKey types.NeedleId, datFileSize int64,
 	deletedCount = uint64(v.nm.DeletedCount())
 	deletedSize = v.nm.DeletedSize()
 	fileCount = uint64(v.nm.FileCount())
+	indexChecksum = v.nm.IndexChecksum()
 
 	return
 }
 
 func (v *Volume) ToVolumeInformationMessage() (types.NeedleId, *master_pb.VolumeInformationMessage) {
 
-	maxFileKey, volumeSize, modTime, fileCount, deletedCount, deletedSize, ok := v.collectStatus()
+	maxFileKey, volumeSize, modTime, fileCount, deletedCount, deletedSize, indexChecksum, ok := v.collectStatus()
 
 	if !ok {
 		return 0, nil
@@ -324,6 +338,8 @@ func (v *Volume) ToVolumeInformationMessage() (types.NeedleId, *master_pb.Volume
 		CompactRevision:  uint32(v.SuperBlock.CompactionRevision),
 		ModifiedAtSecond: modTime.Unix(),
 		DiskType:         string(v.location.DiskType),
+		IndexChecksum:    indexChecksum,
+		LastReadAtSecond: v.LastReadAtSecond(),
 	}
 
 	volumeInfo.RemoteStorageName, volumeInfo.RemoteStorageKey = v.RemoteStorageNameKey()
@@ -331,6 +347,39 @@ func (v *Volume) ToVolumeInformationMessage() (types.NeedleId, *master_pb.Volume
 	return maxFileKey, volumeInfo
 }
 
+// markRead records the time of reading the volume, to move the volumes not read for long to cheaper disks.
+func (v *Volume) markRead() {
+	now := uint64(time.Now().Unix())
+	if atomic.LoadUint64(&v.lastReadTsSeconds) < now {
+		atomic.StoreUint64(&v.lastReadTsSeconds, now)
+	}
+}
+
+// LastReadAtSecond is the unix time of the last read, or 0 if the volume has never been read.
+func (v *Volume) LastReadAtSecond() int64 {
+	return int64(atomic.LoadUint64(&v.lastReadTsSeconds))
+}
+
+// maybeSaveLastReadTime saves the last read time into the .vif file if it is older than the interval,
+// so the time survives restarts without saving the file on every read, and is copied along with the volume.
+func (v *Volume) maybeSaveLastReadTime(interval time.Duration) {
+	if v.volumeInfo == nil || v.LastReadAtSecond() < v.volumeInfo.LastReadAtSecond+int64(interval/time.Second) {
+		return
+	}
+	v.saveLastReadTime()
+}
+
+func (v *Volume) saveLastReadTime() {
+	lastRead := v.LastReadAtSecond()
+	if v.volumeInfo == nil || lastRead <= v.volumeInfo.LastReadAtSecond {
+		return
+	}
+	v.volumeInfo.LastReadAtSecond = lastRead
+	if err := v.SaveVolumeInfo(); err != nil {
+		glog.Warningf("volume %d save last read time: %v", v.Id, err)
+	}
+}
+
 func (v *Volume) RemoteStorageNameKey() (storageName, storageKey string) {
 	if v.volumeInfo == nil {
 		return
@@ -344,5 +393,60 @@ func (v *Volume) RemoteStorageNameKey() (storageName, storageKey string) {
 func (v *Volume) IsReadOnly() bool {
 	v.noWriteLock.RLock()
 	defer v.noWriteLock.RUnlock()
-	return v.noWriteOrDelete || v.noWriteCanDelete || v.location.isDiskSpaceLow
+	return v.noWriteOrDelete || v.noWriteCanDelete || v.location.isDiskSpaceLow || v.location.IsServerReadOnly()
+}
+
+// IsMarkedReadOnly tells whether the volume itself is marked readonly, ignoring the disk space and the volume server state.
+func (v *Volume) IsMarkedReadOnly() bool {
+	v.noWriteLock.RLock()
+	defer v.noWriteLock.RUnlock()
+	return v.noWriteOrDelete || v.noWriteCanDelete
+}
+
+// ReadOnlyReasons explains why the volume is readonly, or nothing if it is writable.
+func (v *Volume) ReadOnlyReasons() (reasons []string) {
+	v.noWriteLock.RLock()
+	defer v.noWriteLock.RUnlock()
+	if v.noWriteOrDelete {
+		reasons = append(reasons, "marked readonly, no write or delete")
+	}
+	if v.noWriteCanDelete {
+		reasons = append(reasons, "marked readonly, can delete")
+	}
+	if v.location.isDiskSpaceLow {
+		reasons = append(reasons, "disk space low at "+v.location.Directory)
+	}
+	if v.location.IsServerReadOnly() {
+		reasons = append(reasons, "volume server readonly")
+	}
+	return
+}
+
+// GetVolumeStatus collects the details of the volume to look into its health.
+func (v *Volume) GetVolumeStatus() *volume_server_pb.VolumeStatusResponse {
+	datSize, idxSize, modTime := v.FileStat()
+	resp := &volume_server_pb.VolumeStatusResponse{
+		IsReadOnly:         v.IsReadOnly(),
+		ReadOnlyReasons:    v.ReadOnlyReasons(),
+		IsMarkedReadOnly:   v.IsMarkedReadOnly(),
+		CompactionRevision: uint32(v.SuperBlock.CompactionRevision),
+		IndexType:          v.needleMapKind.String(),
+		FileCount:          v.FileCount(),
+		DeleteCount:        v.DeletedCount(),
+		DeletedByteCount:   v.DeletedSize(),
+		DatFileSize:        datSize,
+		IdxFileSize:        idxSize,
+		IsCompacting:       v.isCompacting || v.isCommitCompacting,
+		Collection:         v.Collection,
+		DiskStatus:         stats.NewDiskStatus(v.dir),
+		ChecksumAlgorithm:  v.SuperBlock.ChecksumAlgorithm().String(),
+	}
+	if !modTime.IsZero() {
+		resp.ModifiedAtSecond = modTime.Unix()
+	}
+	if v.lastIoError != nil {
+		resp.LastIoError = v.lastIoError.Error()
+	}
+	resp.DiskStatus.DiskType = string(v.location.DiskType)
+	return resp
 }
@@ -2,7 +2,6 @@ package storage
 
 import (
 	"fmt"
-	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
 	"io"
 	"os"
 
@@ -155,7 +154,7 @@ func (v *Volume) checkIdxFile() error {
 	if err != nil {
 		return fmt.Errorf("get stat %s: %v", v.FileName(".dat"), err)
 	}
-	if datFileSize <= super_block.SuperBlockSize {
+	if datFileSize <= int64(v.SuperBlock.BlockSize()) {
 		return nil
 	}
 	indexFileName := v.FileName(".idx")
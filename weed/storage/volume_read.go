@@ -24,6 +24,7 @@ func (v *Volume) readNeedle(n *needle.Needle, readOption *ReadOption, onReadSize
 	if !ok || nv.Offset.IsZero() {
 		return -1, ErrorNotFound
 	}
+	v.markRead()
 	readSize := nv.Size
 	if readSize.IsDeleted() {
 		if readOption != nil && readOption.ReadDeleted && readSize != TombstoneFileSize {
@@ -64,20 +65,11 @@ func (v *Volume) readNeedle(n *needle.Needle, readOption *ReadOption, onReadSize
 		}
 	}
 	count = int(n.DataSize)
-	if !n.HasTtl() {
-		return
-	}
-	ttlMinutes := n.Ttl.Minutes()
-	if ttlMinutes == 0 {
-		return
-	}
-	if !n.HasLastModifiedDate() {
-		return
-	}
-	if time.Now().Before(time.Unix(0, int64(n.AppendAtNs)).Add(time.Duration(ttlMinutes) * time.Minute)) {
-		return
+	// the needle ttl also applies in volumes without a ttl, so files with different lifetimes can share volumes
+	if n.IsExpired(time.Now()) {
+		return -1, ErrorNotFound
 	}
-	return -1, ErrorNotFound
+	return
 }
 
 // read needle at a specific offset
@@ -117,6 +109,7 @@ func (v *Volume) readNeedleDataInto(n *needle.Needle, readOption *ReadOption, wr
 	if !ok || nv.Offset.IsZero() {
 		return ErrorNotFound
 	}
+	v.markRead()
 	readSize := nv.Size
 	if readSize.IsDeleted() {
 		if readOption != nil && readOption.ReadDeleted && readSize != TombstoneFileSize {
@@ -139,7 +132,7 @@ func (v *Volume) readNeedleDataInto(n *needle.Needle, readOption *ReadOption, wr
 	defer mem.Free(buf)
 
 	// read needle data
-	crc := needle.CRC(0)
+	checksum := n.GetChecksumAlgorithm().NewHash()
 	for x := offset; x < offset+size; x += int64(len(buf)) {
 
 		if readOption.HasSlowRead {
@@ -165,7 +158,7 @@ func (v *Volume) readNeedleDataInto(n *needle.Needle, readOption *ReadOption, wr
 
 		toWrite := min(count, int(offset+size-x))
 		if toWrite > 0 {
-			crc = crc.Update(buf[0:toWrite])
+			checksum.Write(buf[0:toWrite])
 			if _, err = writer.Write(buf[0:toWrite]); err != nil {
 				return fmt.Errorf("ReadNeedleData write: %v", err)
 			}
@@ -181,9 +174,10 @@ func (v *Volume) readNeedleDataInto(n *needle.Needle, readOption *ReadOption, wr
 			break
 		}
 	}
+	crc := needle.CRC(checksum.Sum32())
 	if offset == 0 && size == int64(n.DataSize) && (n.Checksum != crc && uint32(n.Checksum) != crc.Value()) {
 		// the crc.Value() function is to be deprecated. this double checking is for backward compatible.
-		return fmt.Errorf("ReadNeedleData checksum %v expected %v", crc, n.Checksum)
+		return fmt.Errorf("ReadNeedleData checksum %v expected %v: %w", crc, n.Checksum, needle.ErrorCRC)
 	}
 	return nil
 
@@ -267,3 +261,14 @@ func ScanVolumeFileFrom(version needle.Version, datBackend backend.BackendStorag
 	}
 	return nil
 }
+
+// NeedleOffset returns the actual offset of the needle in the .dat file.
+func (v *Volume) NeedleOffset(needleId NeedleId) (offset int64, found bool) {
+	v.dataFileAccessLock.RLock()
+	defer v.dataFileAccessLock.RUnlock()
+	nv, ok := v.nm.Get(needleId)
+	if !ok || nv.Offset.IsZero() {
+		return 0, false
+	}
+	return nv.Offset.ToActualOffset(), true
+}
@@ -13,7 +13,7 @@ import (
 func TestReadNeedMetaWithWritesAndUpdates(t *testing.T) {
 	dir := t.TempDir()
 
-	v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0)
+	v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, needle.ChecksumCrc32c)
 	if err != nil {
 		t.Fatalf("volume creation: %v", err)
 	}
@@ -50,7 +50,7 @@ func TestReadNeedMetaWithWritesAndUpdates(t *testing.T) {
 func TestReadNeedMetaWithDeletesThenWrites(t *testing.T) {
 	dir := t.TempDir()
 
-	v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0)
+	v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, needle.ChecksumCrc32c)
 	if err != nil {
 		t.Fatalf("volume creation: %v", err)
 	}
@@ -91,3 +91,36 @@ func TestReadNeedMetaWithDeletesThenWrites(t *testing.T) {
 		expectedLastUpdateTime += 2000
 	}
 }
+
+func TestLastReadTimeSurvivesReload(t *testing.T) {
+	dir := t.TempDir()
+
+	v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, needle.ChecksumCrc32c)
+	if err != nil {
+		t.Fatalf("volume creation: %v", err)
+	}
+	n := newRandomNeedle(1)
+	if _, _, _, err := v.writeNeedle2(n, true, false); err != nil {
+		t.Fatalf("write needle: %v", err)
+	}
+	assert.Equal(t, int64(0), v.LastReadAtSecond(), "writes are not reads")
+
+	readNeedle := &needle.Needle{Id: n.Id}
+	if _, err := v.readNeedle(readNeedle, nil, nil); err != nil {
+		t.Fatalf("read needle: %v", err)
+	}
+	lastRead := v.LastReadAtSecond()
+	assert.NotZero(t, lastRead)
+
+	// the first read is saved, the later ones at most once per interval
+	v.maybeSaveLastReadTime(lastReadTimeSaveInterval)
+	assert.Equal(t, lastRead, v.volumeInfo.LastReadAtSecond)
+
+	v.Close()
+	v, err = NewVolume(dir, dir, "", 1, NeedleMapInMemory, nil, nil, 0, 0, needle.ChecksumCrc32c)
+	if err != nil {
+		t.Fatalf("volume reload: %v", err)
+	}
+	defer v.Close()
+	assert.Equal(t, lastRead, v.LastReadAtSecond())
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/idx"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle_map"
+	. "github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+// ReplicaPeer reads the index and the needles of the same volume on another volume server.
+type ReplicaPeer interface {
+	ReadIndex() ([]byte, error)
+	ReadNeedleBlob(offset int64, size Size) ([]byte, error)
+}
+
+type ReplicaRepairResult struct {
+	Pulled  int
+	Deleted int
+}
+
+// RepairVolumeFromPeer pulls the needles missing in the volume from the peer, and deletes the needles deleted on the peer.
+func (s *Store) RepairVolumeFromPeer(i needle.VolumeId, peer ReplicaPeer, maxMissingRatio float64) (ReplicaRepairResult, error) {
+	v := s.findVolume(i)
+	if v == nil {
+		return ReplicaRepairResult{}, fmt.Errorf("volume %d not found", i)
+	}
+	if v.IsReadOnly() {
+		return ReplicaRepairResult{}, fmt.Errorf("volume %d is read only", i)
+	}
+	return v.repairFromPeer(peer, maxMissingRatio)
+}
+
+// repairFromPeer makes up the differences with the peer replica.
+// The needles deleted here are not pulled again, and the repair is refused
+// if the peer has more than maxMissingRatio of its needles missing here, likely not the same volume.
+func (v *Volume) repairFromPeer(peer ReplicaPeer, maxMissingRatio float64) (result ReplicaRepairResult, err error) {
+
+	indexBytes, err := peer.ReadIndex()
+	if err != nil {
+		return result, fmt.Errorf("read peer index: %v", err)
+	}
+
+	// the last state of each needle on the peer, keeping the deletions
+	peerDb := needle_map.NewMemDb()
+	defer peerDb.Close()
+	err = idx.WalkIndexFile(bytes.NewReader(indexBytes), 0, func(key NeedleId, offset Offset, size Size) error {
+		if size.IsDeleted() {
+			size = TombstoneFileSize
+		}
+		return peerDb.Set(key, offset, size)
+	})
+	if err != nil {
+		return result, fmt.Errorf("load peer index: %v", err)
+	}
+
+	// the local state seen for each needle, checked again under the write lock before changing it,
+	// since the needle can be written or deleted locally while reading from the peer
+	var missing, deleted, deletedLocal []needle_map.NeedleValue
+	var peerCount int
+	v.dataFileAccessLock.RLock()
+	compactionRevision := v.SuperBlock.CompactionRevision
+	peerDb.AscendingVisit(func(value needle_map.NeedleValue) error {
+		local, found := v.nm.Get(value.Key)
+		if value.Size.IsDeleted() {
+			if found && local.Size.IsValid() {
+				deleted = append(deleted, value)
+				deletedLocal = append(deletedLocal, *local)
+			}
+			return nil
+		}
+		peerCount++
+		if !found {
+			missing = append(missing, value)
+		}
+		return nil
+	})
+	v.dataFileAccessLock.RUnlock()
+
+	if peerCount > 0 && float64(len(missing))/float64(peerCount) > maxMissingRatio {
+		return result, fmt.Errorf("%d of the %d needles of the peer are missing, more than %.2f", len(missing), peerCount, maxMissingRatio)
+	}
+
+	for _, value := range missing {
+		needleBlob, readErr := peer.ReadNeedleBlob(value.Offset.ToActualOffset(), value.Size)
+		if readErr != nil {
+			return result, fmt.Errorf("read needle %s from peer: %v", value.Key, readErr)
+		}
+		pulled, writeErr := v.pullNeedleBlob(value, needleBlob)
+		if writeErr != nil {
+			return result, fmt.Errorf("write needle %s: %v", value.Key, writeErr)
+		}
+		if pulled {
+			result.Pulled++
+		}
+	}
+
+	for i, value := range deleted {
+		removed, deleteErr := v.deleteUnchangedNeedle(deletedLocal[i], compactionRevision)
+		if deleteErr != nil {
+			return result, fmt.Errorf("delete needle %s: %v", value.Key, deleteErr)
+		}
+		if removed {
+			result.Deleted++
+		}
+	}
+
+	return result, nil
+}
+
+// pullNeedleBlob writes the needle read from the peer, unless it has been written or deleted here meanwhile.
+func (v *Volume) pullNeedleBlob(value needle_map.NeedleValue, needleBlob []byte) (bool, error) {
+	v.dataFileAccessLock.Lock()
+	defer v.dataFileAccessLock.Unlock()
+	if _, found := v.nm.Get(value.Key); found {
+		return false, nil
+	}
+	if err := v.doWriteNeedleBlob(value.Key, needleBlob, value.Size); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// deleteUnchangedNeedle deletes the needle if it is still at the same offset with the same size,
+// and the volume has not been compacted, so a newer write of the needle is kept.
+func (v *Volume) deleteUnchangedNeedle(local needle_map.NeedleValue, compactionRevision uint16) (bool, error) {
+	v.dataFileAccessLock.Lock()
+	defer v.dataFileAccessLock.Unlock()
+	if v.SuperBlock.CompactionRevision != compactionRevision {
+		return false, nil
+	}
+	current, found := v.nm.Get(local.Key)
+	if !found || current.Offset != local.Offset || current.Size != local.Size {
+		return false, nil
+	}
+	if MaxPossibleVolumeSize < v.nm.ContentSize()+uint64(needle.GetActualSize(0, v.Version())) {
+		return false, fmt.Errorf("volume size limit %d exceeded! current size is %d", MaxPossibleVolumeSize, v.nm.ContentSize())
+	}
+	size, err := v.doDeleteRequest(&needle.Needle{Id: local.Key})
+	return size > 0, err
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"os"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+type localReplicaPeer struct {
+	v *Volume
+}
+
+func (p *localReplicaPeer) ReadIndex() ([]byte, error) {
+	return os.ReadFile(p.v.IndexFileName() + ".idx")
+}
+
+func (p *localReplicaPeer) ReadNeedleBlob(offset int64, size types.Size) ([]byte, error) {
+	return p.v.ReadNeedleBlob(offset, size)
+}
+
+func TestRepairFromPeer(t *testing.T) {
+	newTestVolume := func() *Volume {
+		dir := t.TempDir()
+		v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, needle.ChecksumCrc32c)
+		if err != nil {
+			t.Fatalf("volume creation: %v", err)
+		}
+		return v
+	}
+	source, target := newTestVolume(), newTestVolume()
+	defer source.Close()
+	defer target.Close()
+
+	for i := 1; i <= 10; i++ {
+		n := newRandomNeedle(uint64(i))
+		if _, _, _, err := source.writeNeedle2(n, true, false); err != nil {
+			t.Fatalf("write needle %d: %v", i, err)
+		}
+		if i <= 7 {
+			if _, _, _, err := target.writeNeedle2(n, true, false); err != nil {
+				t.Fatalf("write needle %d: %v", i, err)
+			}
+		}
+	}
+	// deleted on the source only, and on the target only
+	if _, err := source.doDeleteRequest(newEmptyNeedle(2)); err != nil {
+		t.Fatalf("delete needle 2: %v", err)
+	}
+	if _, err := target.doDeleteRequest(newEmptyNeedle(3)); err != nil {
+		t.Fatalf("delete needle 3: %v", err)
+	}
+
+	if _, err := target.repairFromPeer(&localReplicaPeer{v: source}, 0.1); err == nil {
+		t.Errorf("expected to refuse the repair with 3 of 9 needles missing")
+	}
+
+	result, err := target.repairFromPeer(&localReplicaPeer{v: source}, 0.5)
+	if err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	if result.Pulled != 3 || result.Deleted != 1 {
+		t.Errorf("expected 3 pulled and 1 deleted, got %+v", result)
+	}
+
+	for i := 1; i <= 10; i++ {
+		nv, found := target.nm.Get(types.Uint64ToNeedleId(uint64(i)))
+		expectLive := i != 2 && i != 3
+		if !found || nv.Size.IsValid() != expectLive {
+			t.Errorf("needle %d: found %v, expected live %v", i, found, expectLive)
+			continue
+		}
+		if expectLive {
+			n := newEmptyNeedle(uint64(i))
+			if _, err := target.readNeedle(n, nil, nil); err != nil {
+				t.Errorf("read needle %d: %v", i, err)
+			}
+		}
+	}
+
+	// nothing left to repair
+	if result, err = target.repairFromPeer(&localReplicaPeer{v: source}, 0.5); err != nil || result.Pulled != 0 || result.Deleted != 0 {
+		t.Errorf("expected no changes, got %+v %v", result, err)
+	}
+}
+
+// concurrentWritePeer writes the needles on the local volume while the repair reads from the peer
+type concurrentWritePeer struct {
+	localReplicaPeer
+	local   *Volume
+	written bool
+	needles []*needle.Needle
+}
+
+func (p *concurrentWritePeer) ReadNeedleBlob(offset int64, size types.Size) ([]byte, error) {
+	if !p.written {
+		p.written = true
+		for _, n := range p.needles {
+			if _, _, _, err := p.local.writeNeedle2(n, true, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return p.localReplicaPeer.ReadNeedleBlob(offset, size)
+}
+
+func TestRepairFromPeerKeepsConcurrentWrites(t *testing.T) {
+	newTestVolume := func() *Volume {
+		dir := t.TempDir()
+		v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, needle.ChecksumCrc32c)
+		if err != nil {
+			t.Fatalf("volume creation: %v", err)
+		}
+		return v
+	}
+	source, target := newTestVolume(), newTestVolume()
+	defer source.Close()
+	defer target.Close()
+
+	for i := 1; i <= 3; i++ {
+		n := newRandomNeedle(uint64(i))
+		if _, _, _, err := source.writeNeedle2(n, true, false); err != nil {
+			t.Fatalf("write needle %d: %v", i, err)
+		}
+		if i == 1 {
+			if _, _, _, err := target.writeNeedle2(n, true, false); err != nil {
+				t.Fatalf("write needle %d: %v", i, err)
+			}
+		}
+	}
+	// deleted on the source, rewritten on the target while repairing
+	if _, err := source.doDeleteRequest(newEmptyNeedle(1)); err != nil {
+		t.Fatalf("delete needle 1: %v", err)
+	}
+	rewritten, written := newRandomNeedle(1), newRandomNeedle(3)
+	peer := &concurrentWritePeer{localReplicaPeer: localReplicaPeer{v: source}, local: target, needles: []*needle.Needle{rewritten, written}}
+
+	result, err := target.repairFromPeer(peer, 1)
+	if err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	if result.Pulled != 1 || result.Deleted != 0 {
+		t.Errorf("expected 1 pulled and 0 deleted, got %+v", result)
+	}
+	for _, expected := range []*needle.Needle{rewritten, written} {
+		n := newEmptyNeedle(uint64(expected.Id))
+		if _, err := target.readNeedle(n, nil, nil); err != nil {
+			t.Errorf("read needle %d: %v", expected.Id, err)
+		} else if string(n.Data) != string(expected.Data) {
+			t.Errorf("needle %d: the local write was overwritten", expected.Id)
+		}
+	}
+}
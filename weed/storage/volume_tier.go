@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"sync/atomic"
+
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
 	"github.com/seaweedfs/seaweedfs/weed/storage/backend"
@@ -22,6 +24,10 @@ func (v *Volume) maybeLoadVolumeInfo() (found bool) {
 		v.volumeInfo.Version = uint32(needle.CurrentVersion)
 	}
 
+	if v.volumeInfo.LastReadAtSecond > v.LastReadAtSecond() {
+		atomic.StoreUint64(&v.lastReadTsSeconds, uint64(v.volumeInfo.LastReadAtSecond))
+	}
+
 	if v.hasRemoteFile {
 		glog.V(0).Infof("volume %d is tiered to %s as %s and read only", v.Id,
 			v.volumeInfo.Files[0].BackendName(), v.volumeInfo.Files[0].Key)
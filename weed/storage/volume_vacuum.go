@@ -20,19 +20,28 @@ import (
 type ProgressFunc func(processed int64) bool
 
 func (v *Volume) garbageLevel() float64 {
-	if v.ContentSize() == 0 {
+	deletedSize, fileSize := v.garbageSize()
+	if fileSize == 0 {
 		return 0
 	}
-	deletedSize := v.DeletedSize()
-	fileSize := v.ContentSize()
+	return float64(deletedSize) / float64(fileSize)
+}
+
+// garbageSize returns the bytes taken by deleted needles, and the bytes they are counted against
+func (v *Volume) garbageSize() (deletedSize, fileSize uint64) {
+	if v.ContentSize() == 0 {
+		return 0, 0
+	}
+	deletedSize = v.DeletedSize()
+	fileSize = v.ContentSize()
 	if v.DeletedCount() > 0 && v.DeletedSize() == 0 {
 		// this happens for .sdx converted back to normal .idx
 		// where deleted entry size is missing
 		datFileSize, _, _ := v.FileStat()
-		deletedSize = datFileSize - fileSize - super_block.SuperBlockSize
+		deletedSize = datFileSize - fileSize - uint64(v.SuperBlock.BlockSize())
 		fileSize = datFileSize
 	}
-	return float64(deletedSize) / float64(fileSize)
+	return
 }
 
 // compact a volume based on deletions in .dat files
@@ -347,7 +356,7 @@ type VolumeFileScanner4Vacuum struct {
 	dstBackend     backend.BackendStorageFile
 	nm             *needle_map.MemDb
 	newOffset      int64
-	now            uint64
+	now            time.Time
 	writeThrottler *util.WriteThrottler
 }
 
@@ -364,7 +373,7 @@ func (scanner *VolumeFileScanner4Vacuum) ReadNeedleBody() bool {
 }
 
 func (scanner *VolumeFileScanner4Vacuum) VisitNeedle(n *needle.Needle, offset int64, needleHeader, needleBody []byte) error {
-	if n.HasTtl() && scanner.now >= n.LastModified+uint64(scanner.v.Ttl.Minutes()*60) {
+	if n.IsExpired(scanner.now) {
 		return nil
 	}
 	nv, ok := scanner.v.nm.Get(n.Id)
@@ -398,7 +407,7 @@ func (v *Volume) copyDataAndGenerateIndexFile(dstName, idxName string, prealloca
 
 	scanner := &VolumeFileScanner4Vacuum{
 		v:              v,
-		now:            uint64(time.Now().Unix()),
+		now:            time.Now(),
 		nm:             nm,
 		dstBackend:     dst,
 		writeThrottler: util.NewWriteThrottler(compactionBytePerSecond),
@@ -428,13 +437,19 @@ func (v *Volume) copyDataBasedOnIndexFile(srcDatName, srcIdxName, dstDatName, da
 	if err = oldNm.LoadFromIdx(srcIdxName); err != nil {
 		return err
 	}
-	if dataFile, err = os.Open(srcDatName); err != nil {
-		return err
+	if v.UsesDirectIO() {
+		if srcDatBackend, err = backend.OpenDirectIODiskFile(srcDatName); err != nil {
+			return err
+		}
+	} else {
+		if dataFile, err = os.Open(srcDatName); err != nil {
+			return err
+		}
+		srcDatBackend = backend.NewDiskFile(dataFile)
 	}
-	srcDatBackend = backend.NewDiskFile(dataFile)
 	defer srcDatBackend.Close()
 
-	now := uint64(time.Now().Unix())
+	now := time.Now()
 
 	sb.CompactionRevision++
 	dstDatBackend.WriteAt(sb.Bytes(), 0)
@@ -460,7 +475,7 @@ func (v *Volume) copyDataBasedOnIndexFile(srcDatName, srcIdxName, dstDatName, da
 			return fmt.Errorf("cannot hydrate needle from file: %s", err)
 		}
 
-		if n.HasTtl() && now >= n.LastModified+uint64(sb.Ttl.Minutes()*60) {
+		if n.IsExpired(now) {
 			return nil
 		}
 
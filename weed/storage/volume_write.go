@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"syscall"
 	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
@@ -27,12 +28,16 @@ func (v *Volume) checkReadWriteError(err error) {
 	if err.Error() == "input/output error" {
 		v.lastIoError = err
 	}
+	if errors.Is(err, syscall.ENOSPC) && v.location != nil {
+		v.location.markDiskFull(err)
+	}
 }
 
 // isFileUnchanged checks whether this needle to write is same as last one.
 // It requires serialized access in the same volume.
 func (v *Volume) isFileUnchanged(n *needle.Needle) bool {
-	if v.Ttl.String() != "" {
+	// rewrite the needle to restart its ttl
+	if v.Ttl.String() != "" || n.HasTtl() {
 		return false
 	}
 
@@ -310,6 +315,11 @@ func (v *Volume) WriteNeedleBlob(needleId NeedleId, needleBlob []byte, size Size
 	v.dataFileAccessLock.Lock()
 	defer v.dataFileAccessLock.Unlock()
 
+	return v.doWriteNeedleBlob(needleId, needleBlob, size)
+}
+
+// doWriteNeedleBlob appends the needle blob, with the dataFileAccessLock held.
+func (v *Volume) doWriteNeedleBlob(needleId NeedleId, needleBlob []byte, size Size) error {
 	if MaxPossibleVolumeSize < v.nm.ContentSize()+uint64(len(needleBlob)) {
 		return fmt.Errorf("volume size limit %d exceeded! current size is %d", MaxPossibleVolumeSize, v.nm.ContentSize())
 	}
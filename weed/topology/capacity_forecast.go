@@ -0,0 +1,247 @@
+package topology
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+const (
+	CapacitySampleInterval = 10 * time.Minute
+	capacitySampleCount    = 7 * 24 * 6 // 7 days of samples
+	// shorter histories give too noisy growth rates
+	minCapacityForecastHistory = time.Hour
+)
+
+type capacitySample struct {
+	at   time.Time
+	used uint64
+}
+
+type capacityKey struct {
+	dataCenter string
+	diskType   string
+}
+
+// CapacityForecast is the growth of the used space of one disk type in one data center,
+// and when it runs out of space at this rate. DaysUntilFull is -1 if the used space is not growing,
+// or the history is too short.
+type CapacityForecast struct {
+	DataCenter        string  `json:"dataCenter"`
+	DiskType          string  `json:"diskType"`
+	UsedBytes         uint64  `json:"usedBytes"`
+	CapacityBytes     uint64  `json:"capacityBytes"`
+	GrowthBytesPerDay float64 `json:"growthBytesPerDay"`
+	DaysUntilFull     float64 `json:"daysUntilFull"`
+}
+
+// CollectionGrowth is the ingest rate of one collection, counting all replicas.
+type CollectionGrowth struct {
+	Collection        string  `json:"collection"`
+	UsedBytes         uint64  `json:"usedBytes"`
+	GrowthBytesPerDay float64 `json:"growthBytesPerDay"`
+}
+
+// capacityHistory keeps the used space sampled from the heartbeats, to forecast when the disks are full.
+// It only lives in the leader, so the history starts over when the leader changes.
+type capacityHistory struct {
+	sync.Mutex
+	disks       map[capacityKey][]capacitySample
+	capacities  map[capacityKey]uint64
+	collections map[string][]capacitySample
+}
+
+func newCapacityHistory() *capacityHistory {
+	return &capacityHistory{
+		disks:       make(map[capacityKey][]capacitySample),
+		capacities:  make(map[capacityKey]uint64),
+		collections: make(map[string][]capacitySample),
+	}
+}
+
+func appendCapacitySample(samples []capacitySample, sample capacitySample) []capacitySample {
+	samples = append(samples, sample)
+	if len(samples) > capacitySampleCount {
+		samples = samples[len(samples)-capacitySampleCount:]
+	}
+	return samples
+}
+
+func (h *capacityHistory) record(now time.Time, used, capacities map[capacityKey]uint64, collectionUsed map[string]uint64) {
+	h.Lock()
+	defer h.Unlock()
+
+	for key := range h.disks {
+		if _, found := capacities[key]; !found {
+			delete(h.disks, key)
+		}
+	}
+	for key := range capacities {
+		h.disks[key] = appendCapacitySample(h.disks[key], capacitySample{at: now, used: used[key]})
+	}
+	h.capacities = capacities
+
+	for collection := range h.collections {
+		if _, found := collectionUsed[collection]; !found {
+			delete(h.collections, collection)
+		}
+	}
+	for collection, used := range collectionUsed {
+		h.collections[collection] = appendCapacitySample(h.collections[collection], capacitySample{at: now, used: used})
+	}
+}
+
+// growthBytesPerDay is the least squares slope of the samples, or false if the history is too short.
+func growthBytesPerDay(samples []capacitySample) (float64, bool) {
+	if len(samples) < 2 || samples[len(samples)-1].at.Sub(samples[0].at) < minCapacityForecastHistory {
+		return 0, false
+	}
+	start := samples[0].at
+	var sumX, sumY, sumXX, sumXY float64
+	for _, sample := range samples {
+		x := sample.at.Sub(start).Hours() / 24
+		y := float64(sample.used)
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumXY += x * y
+	}
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+	return (n*sumXY - sumX*sumY) / denominator, true
+}
+
+func (h *capacityHistory) forecasts() (forecasts []*CapacityForecast, growths []*CollectionGrowth) {
+	h.Lock()
+	defer h.Unlock()
+
+	for key, samples := range h.disks {
+		forecast := &CapacityForecast{
+			DataCenter:    key.dataCenter,
+			DiskType:      key.diskType,
+			UsedBytes:     samples[len(samples)-1].used,
+			CapacityBytes: h.capacities[key],
+			DaysUntilFull: -1,
+		}
+		if growth, ok := growthBytesPerDay(samples); ok {
+			forecast.GrowthBytesPerDay = growth
+			if growth > 0 {
+				forecast.DaysUntilFull = 0
+				if forecast.CapacityBytes > forecast.UsedBytes {
+					forecast.DaysUntilFull = float64(forecast.CapacityBytes-forecast.UsedBytes) / growth
+				}
+			}
+		}
+		forecasts = append(forecasts, forecast)
+	}
+	sort.Slice(forecasts, func(i, j int) bool {
+		if forecasts[i].DataCenter != forecasts[j].DataCenter {
+			return forecasts[i].DataCenter < forecasts[j].DataCenter
+		}
+		return forecasts[i].DiskType < forecasts[j].DiskType
+	})
+
+	for collection, samples := range h.collections {
+		growth, _ := growthBytesPerDay(samples)
+		growths = append(growths, &CollectionGrowth{
+			Collection:        collection,
+			UsedBytes:         samples[len(samples)-1].used,
+			GrowthBytesPerDay: growth,
+		})
+	}
+	sort.Slice(growths, func(i, j int) bool {
+		return growths[i].GrowthBytesPerDay > growths[j].GrowthBytesPerDay ||
+			growths[i].GrowthBytesPerDay == growths[j].GrowthBytesPerDay && growths[i].Collection < growths[j].Collection
+	})
+	return
+}
+
+// RecordCapacitySample adds the current used space and capacity of each data center and disk type,
+// and of each collection, to the history. The capacity is the volume slots times the volume size limit,
+// and an erasure coded shard is counted as a tenth of a full volume.
+func (t *Topology) RecordCapacitySample(now time.Time) {
+	used := make(map[capacityKey]uint64)
+	capacities := make(map[capacityKey]uint64)
+	collectionUsed := make(map[string]uint64)
+
+	topologyInfo := t.ToTopologyInfo()
+	for _, dc := range topologyInfo.DataCenterInfos {
+		for _, rack := range dc.RackInfos {
+			for _, dn := range rack.DataNodeInfos {
+				for diskType, diskInfo := range dn.DiskInfos {
+					key := capacityKey{dataCenter: dc.Id, diskType: types.ToDiskType(diskType).ReadableString()}
+					capacities[key] += uint64(diskInfo.MaxVolumeCount) * t.volumeSizeLimit
+					used[key] += diskUsedBytes(diskInfo, t.volumeSizeLimit)
+					for _, v := range diskInfo.VolumeInfos {
+						collectionUsed[v.Collection] += liveBytes(v)
+					}
+				}
+			}
+		}
+	}
+
+	t.capacityHistory.record(now, used, capacities, collectionUsed)
+}
+
+// liveBytes is the volume size without the deleted bytes, which may be reported larger
+// than the size after a compaction
+func liveBytes(v *master_pb.VolumeInformationMessage) uint64 {
+	if v.Size < v.DeletedByteCount {
+		return 0
+	}
+	return v.Size - v.DeletedByteCount
+}
+
+func diskUsedBytes(diskInfo *master_pb.DiskInfo, volumeSizeLimit uint64) (used uint64) {
+	for _, v := range diskInfo.VolumeInfos {
+		used += v.Size
+	}
+	var ecShardCount uint64
+	for _, ecShardInfo := range diskInfo.EcShardInfos {
+		ecShardCount += uint64(erasure_coding.ShardBits(ecShardInfo.EcIndexBits).ShardIdCount())
+	}
+	used += ecShardCount * volumeSizeLimit / erasure_coding.DataShardsCount
+	return
+}
+
+// CapacityForecasts forecasts when each disk type in each data center is full, and lists the collection ingest rates.
+func (t *Topology) CapacityForecasts() ([]*CapacityForecast, []*CollectionGrowth) {
+	return t.capacityHistory.forecasts()
+}
+
+func (t *Topology) StartCapacityForecast() {
+	go func() {
+		for {
+			if t.IsLeader() {
+				t.RecordCapacitySample(time.Now())
+				t.updateCapacityForecastMetrics()
+			} else {
+				stats.MasterCapacityDaysUntilFullGauge.Reset()
+				stats.MasterCollectionGrowthGauge.Reset()
+			}
+			time.Sleep(CapacitySampleInterval)
+		}
+	}()
+}
+
+func (t *Topology) updateCapacityForecastMetrics() {
+	forecasts, growths := t.CapacityForecasts()
+	stats.MasterCapacityDaysUntilFullGauge.Reset()
+	for _, forecast := range forecasts {
+		if forecast.DaysUntilFull >= 0 {
+			stats.MasterCapacityDaysUntilFullGauge.WithLabelValues(forecast.DataCenter, forecast.DiskType).Set(forecast.DaysUntilFull)
+		}
+	}
+	stats.MasterCollectionGrowthGauge.Reset()
+	for _, growth := range growths {
+		stats.MasterCollectionGrowthGauge.WithLabelValues(growth.Collection).Set(growth.GrowthBytesPerDay)
+	}
+}
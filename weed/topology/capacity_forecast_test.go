@@ -0,0 +1,73 @@
+package topology
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+)
+
+func TestCapacityForecast(t *testing.T) {
+	h := newCapacityHistory()
+	key := capacityKey{dataCenter: "dc1", diskType: "hdd"}
+	start := time.Now()
+
+	h.record(start, map[capacityKey]uint64{key: 1000}, map[capacityKey]uint64{key: 11000}, map[string]uint64{"logs": 500})
+	forecasts, growths := h.forecasts()
+	if len(forecasts) != 1 || forecasts[0].DaysUntilFull != -1 {
+		t.Fatalf("expected no forecast from one sample: %+v", forecasts[0])
+	}
+	if len(growths) != 1 || growths[0].GrowthBytesPerDay != 0 {
+		t.Fatalf("expected no growth from one sample: %+v", growths[0])
+	}
+
+	for day := 1; day <= 4; day++ {
+		h.record(start.Add(time.Duration(day)*24*time.Hour),
+			map[capacityKey]uint64{key: 1000 + uint64(day)*1000},
+			map[capacityKey]uint64{key: 11000},
+			map[string]uint64{"logs": 500 + uint64(day)*500})
+	}
+	forecasts, growths = h.forecasts()
+	if forecasts[0].UsedBytes != 5000 || forecasts[0].GrowthBytesPerDay != 1000 {
+		t.Errorf("unexpected forecast %+v", forecasts[0])
+	}
+	if forecasts[0].DaysUntilFull != 6 {
+		t.Errorf("expected full in 6 days, got %v", forecasts[0].DaysUntilFull)
+	}
+	if growths[0].GrowthBytesPerDay != 500 {
+		t.Errorf("unexpected collection growth %+v", growths[0])
+	}
+
+	// a removed data center and collection are forgotten
+	h.record(start.Add(5*24*time.Hour), map[capacityKey]uint64{}, map[capacityKey]uint64{}, map[string]uint64{})
+	forecasts, growths = h.forecasts()
+	if len(forecasts) != 0 || len(growths) != 0 {
+		t.Errorf("expected empty forecasts, got %d and %d", len(forecasts), len(growths))
+	}
+}
+
+func TestCapacityForecastShrinking(t *testing.T) {
+	h := newCapacityHistory()
+	key := capacityKey{dataCenter: "dc1", diskType: "ssd"}
+	start := time.Now()
+	for hour := 0; hour <= 4; hour++ {
+		h.record(start.Add(time.Duration(hour)*time.Hour),
+			map[capacityKey]uint64{key: 5000 - uint64(hour)*100},
+			map[capacityKey]uint64{key: 10000},
+			map[string]uint64{})
+	}
+	forecasts, _ := h.forecasts()
+	if forecasts[0].GrowthBytesPerDay >= 0 || forecasts[0].DaysUntilFull != -1 {
+		t.Errorf("expected no forecast for shrinking usage: %+v", forecasts[0])
+	}
+}
+
+func TestLiveBytes(t *testing.T) {
+	if live := liveBytes(&master_pb.VolumeInformationMessage{Size: 100, DeletedByteCount: 30}); live != 70 {
+		t.Errorf("expected 70 live bytes, got %d", live)
+	}
+	// the deleted bytes may outgrow the size after a compaction
+	if live := liveBytes(&master_pb.VolumeInformationMessage{Size: 8, DeletedByteCount: 30}); live != 0 {
+		t.Errorf("expected 0 live bytes, got %d", live)
+	}
+}
@@ -11,6 +11,10 @@ import (
 
 type MaxVolumeIdCommand struct {
 	MaxVolumeId needle.VolumeId `json:"maxVolumeId"`
+	// nil if the volume id ranges are not changed
+	VolumeIdRanges *string `json:"volumeIdRanges,omitempty"`
+	// nil if the replication targets are not changed
+	ReplicationTargets *[]ReplicationTarget `json:"replicationTargets,omitempty"`
 }
 
 func NewMaxVolumeIdCommand(value needle.VolumeId) *MaxVolumeIdCommand {
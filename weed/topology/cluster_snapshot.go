@@ -0,0 +1,90 @@
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+)
+
+const ClusterSnapshotVersion = 1
+
+// ClusterSnapshot is the master state kept in raft, exported to bootstrap a new master cluster
+// when all masters are lost but the volume servers survive.
+type ClusterSnapshot struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	// the volume ids up to it are assigned
+	MaxVolumeId needle.VolumeId `json:"maxVolumeId"`
+	// the file keys below it are assigned
+	FileKeySequence    uint64              `json:"fileKeySequence"`
+	VolumeIdRanges     string              `json:"volumeIdRanges"`
+	ReplicationTargets []ReplicationTarget `json:"replicationTargets"`
+}
+
+func (t *Topology) ExportSnapshot() *ClusterSnapshot {
+	return &ClusterSnapshot{
+		Version:            ClusterSnapshotVersion,
+		CreatedAt:          time.Now().UTC(),
+		MaxVolumeId:        t.GetMaxVolumeId(),
+		FileKeySequence:    t.Sequence.Peek(),
+		VolumeIdRanges:     t.GetVolumeIdRanges().String(),
+		ReplicationTargets: t.GetReplicationTargets(),
+	}
+}
+
+func ParseClusterSnapshot(data []byte) (*ClusterSnapshot, error) {
+	snapshot := &ClusterSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("parse cluster snapshot: %v", err)
+	}
+	if snapshot.Version != ClusterSnapshotVersion {
+		return nil, fmt.Errorf("cluster snapshot version %d, expecting %d", snapshot.Version, ClusterSnapshotVersion)
+	}
+	if _, err := ParseVolumeIdRanges(snapshot.VolumeIdRanges); err != nil {
+		return nil, fmt.Errorf("cluster snapshot: %v", err)
+	}
+	return snapshot, nil
+}
+
+// RestoreSnapshot sets the volume id ranges and the replication targets of the snapshot for all masters.
+// The max volume id and the file key sequence only move forward, never reusing what the surviving volumes have.
+func (t *Topology) RestoreSnapshot(snapshot *ClusterSnapshot) error {
+	ranges, err := ParseVolumeIdRanges(snapshot.VolumeIdRanges)
+	if err != nil {
+		return err
+	}
+	targets := snapshot.ReplicationTargets
+	if targets == nil {
+		targets = []ReplicationTarget{}
+	}
+	t.Sequence.SetMax(snapshot.FileKeySequence)
+
+	t.RaftAccessLock.RLock()
+	defer t.RaftAccessLock.RUnlock()
+
+	if t.Raft == nil {
+		t.UpAdjustMaxVolumeId(snapshot.MaxVolumeId)
+		t.ApplyVolumeIdRanges(ranges)
+		t.ApplyReplicationTargets(targets)
+		return nil
+	}
+	maxVolumeId := t.GetMaxVolumeId()
+	if snapshot.MaxVolumeId > maxVolumeId {
+		maxVolumeId = snapshot.MaxVolumeId
+	}
+	rangesString := ranges.String()
+	b, err := json.Marshal(&MaxVolumeIdCommand{
+		MaxVolumeId:        maxVolumeId,
+		VolumeIdRanges:     &rangesString,
+		ReplicationTargets: &targets,
+	})
+	if err != nil {
+		return fmt.Errorf("failed marshal MaxVolumeIdCommand: %+v", err)
+	}
+	if future := t.Raft.Apply(b, time.Second); future.Error() != nil {
+		return future.Error()
+	}
+	return nil
+}
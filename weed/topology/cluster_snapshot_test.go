@@ -0,0 +1,57 @@
+package topology
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/sequence"
+)
+
+func TestExportAndRestoreSnapshot(t *testing.T) {
+	topo := NewTopology("weedfs", sequence.NewMemorySequencer(), 32*1024, 5, false)
+	topo.UpAdjustMaxVolumeId(42)
+	topo.Sequence.SetMax(1000)
+	ranges, _ := ParseVolumeIdRanges("1-100,201-300")
+	topo.ApplyVolumeIdRanges(ranges)
+	topo.SetReplicationTarget(ReplicationTarget{CollectionPattern: "logs*", Replication: "001"}, false)
+
+	data, err := json.Marshal(topo.ExportSnapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := ParseClusterSnapshot(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the new cluster has seen volume 10 in the heartbeats
+	restored := NewTopology("weedfs", sequence.NewMemorySequencer(), 32*1024, 5, false)
+	restored.UpAdjustMaxVolumeId(10)
+	if err = restored.RestoreSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if restored.GetMaxVolumeId() != 42 {
+		t.Errorf("max volume id %d", restored.GetMaxVolumeId())
+	}
+	if restored.Sequence.Peek() <= 1000 {
+		t.Errorf("file key sequence %d", restored.Sequence.Peek())
+	}
+	if restored.GetVolumeIdRanges().String() != "1-100,201-300" {
+		t.Errorf("volume id ranges %s", restored.GetVolumeIdRanges())
+	}
+	if !reflect.DeepEqual(restored.GetReplicationTargets(), topo.GetReplicationTargets()) {
+		t.Errorf("replication targets %v", restored.GetReplicationTargets())
+	}
+
+	// the max volume id never goes back
+	snapshot.MaxVolumeId = 5
+	restored.RestoreSnapshot(snapshot)
+	if restored.GetMaxVolumeId() != 42 {
+		t.Errorf("max volume id went back to %d", restored.GetMaxVolumeId())
+	}
+
+	if _, err = ParseClusterSnapshot([]byte(`{"version":2}`)); err == nil {
+		t.Errorf("expected an unknown version to fail")
+	}
+}
@@ -19,6 +19,11 @@ func NewDataCenter(id string) *DataCenter {
 	return dc
 }
 
+// AvailableSpaceFor keeps new volumes away from the read only volume servers in the data center.
+func (dc *DataCenter) AvailableSpaceFor(option *VolumeGrowOption) int64 {
+	return dc.NodeImpl.AvailableSpaceFor(option) - readOnlyAvailableSpaceFor(dc, option)
+}
+
 func (dc *DataCenter) GetOrCreateRack(rackName string) *Rack {
 	dc.Lock()
 	defer dc.Unlock()
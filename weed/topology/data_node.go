@@ -21,6 +21,10 @@ type DataNode struct {
 	PublicUrl string
 	LastSeen  int64 // unix time in seconds
 	Counter   int   // in race condition, the previous dataNode was not dead
+
+	diskHealths        []*master_pb.DiskHealth
+	isReadOnly         bool
+	quarantinedVolumes []*master_pb.QuarantinedVolume
 }
 
 func NewDataNode(id string) *DataNode {
@@ -228,12 +232,98 @@ func (dn *DataNode) ServerAddress() rpc.ServerAddress {
 }
 
 type DataNodeInfo struct {
-	Url       string `json:"Url"`
-	PublicUrl string `json:"PublicUrl"`
-	Volumes   int64  `json:"Volumes"`
-	EcShards  int64  `json:"EcShards"`
-	Max       int64  `json:"Max"`
-	VolumeIds string `json:"VolumeIds"`
+	Url         string           `json:"Url"`
+	PublicUrl   string           `json:"PublicUrl"`
+	Volumes     int64            `json:"Volumes"`
+	EcShards    int64            `json:"EcShards"`
+	Max         int64            `json:"Max"`
+	VolumeIds   string           `json:"VolumeIds"`
+	DiskHealths []DiskHealthInfo `json:"DiskHealths,omitempty"`
+}
+
+type DiskHealthInfo struct {
+	Dir          string  `json:"Dir"`
+	Device       string  `json:"Device"`
+	DiskType     string  `json:"DiskType"`
+	UsedPercent  float32 `json:"UsedPercent"`
+	BusyPercent  float32 `json:"BusyPercent"`
+	IoInProgress uint64  `json:"IoInProgress"`
+	SmartStatus  string  `json:"SmartStatus,omitempty"`
+	Temperature  int32   `json:"Temperature,omitempty"`
+}
+
+func (dn *DataNode) UpdateDiskHealths(diskHealths []*master_pb.DiskHealth) {
+	dn.Lock()
+	defer dn.Unlock()
+	dn.diskHealths = diskHealths
+}
+
+// SetReadOnly records whether the whole volume server is read only, reported in its heartbeats.
+func (dn *DataNode) SetReadOnly(isReadOnly bool) {
+	dn.Lock()
+	defer dn.Unlock()
+	dn.isReadOnly = isReadOnly
+}
+
+// SetQuarantinedVolumes records the volumes failing to load on the volume server, reported in its heartbeats.
+func (dn *DataNode) SetQuarantinedVolumes(quarantinedVolumes []*master_pb.QuarantinedVolume) {
+	dn.Lock()
+	defer dn.Unlock()
+	known := make(map[uint32]bool)
+	for _, q := range dn.quarantinedVolumes {
+		known[q.Id] = true
+	}
+	for _, q := range quarantinedVolumes {
+		if !known[q.Id] {
+			glog.Warningf("volume %d in %s on %s is quarantined: %s", q.Id, q.Dir, dn.Url(), q.Reason)
+		}
+	}
+	dn.quarantinedVolumes = quarantinedVolumes
+}
+
+func (dn *DataNode) GetQuarantinedVolumes() []*master_pb.QuarantinedVolume {
+	dn.RLock()
+	defer dn.RUnlock()
+	return dn.quarantinedVolumes
+}
+
+func (dn *DataNode) IsReadOnly() bool {
+	dn.RLock()
+	defer dn.RUnlock()
+	return dn.isReadOnly
+}
+
+// AvailableSpaceFor keeps new volumes away from a read only volume server.
+func (dn *DataNode) AvailableSpaceFor(option *VolumeGrowOption) int64 {
+	if dn.IsReadOnly() {
+		return 0
+	}
+	return dn.NodeImpl.AvailableSpaceFor(option)
+}
+
+// readOnlyAvailableSpaceFor sums the free slots of the read only data nodes under the node,
+// which are counted in the disk usages of the node, but can not take new volumes.
+func readOnlyAvailableSpaceFor(n Node, option *VolumeGrowOption) (space int64) {
+	for _, child := range n.Children() {
+		dn, isDataNode := child.(*DataNode)
+		if !isDataNode {
+			space += readOnlyAvailableSpaceFor(child, option)
+			continue
+		}
+		if !dn.IsReadOnly() {
+			continue
+		}
+		if free := dn.NodeImpl.AvailableSpaceFor(option); free > 0 {
+			space += free
+		}
+	}
+	return
+}
+
+func (dn *DataNode) GetDiskHealths() []*master_pb.DiskHealth {
+	dn.RLock()
+	defer dn.RUnlock()
+	return dn.diskHealths
 }
 
 func (dn *DataNode) ToInfo() (info DataNodeInfo) {
@@ -258,15 +348,38 @@ func (dn *DataNode) ToInfo() (info DataNodeInfo) {
 	info.EcShards = ecShardCount
 	info.Max = maxVolumeCount
 	info.VolumeIds = volumeIds
+	info.DiskHealths = dn.diskHealthInfos()
 
 	return
 }
 
+func (dn *DataNode) diskHealthInfos() (infos []DiskHealthInfo) {
+	for _, diskHealth := range dn.GetDiskHealths() {
+		healthInfo := DiskHealthInfo{
+			Dir:          diskHealth.Dir,
+			Device:       diskHealth.Device,
+			DiskType:     diskHealth.DiskType,
+			BusyPercent:  diskHealth.BusyPercent,
+			IoInProgress: diskHealth.IoInProgress,
+			SmartStatus:  diskHealth.SmartStatus,
+			Temperature:  diskHealth.Temperature,
+		}
+		if diskHealth.All > 0 {
+			healthInfo.UsedPercent = float32(diskHealth.Used) * 100 / float32(diskHealth.All)
+		}
+		infos = append(infos, healthInfo)
+	}
+	return
+}
+
 func (dn *DataNode) ToDataNodeInfo() *master_pb.DataNodeInfo {
 	m := &master_pb.DataNodeInfo{
-		Id:        string(dn.Id()),
-		DiskInfos: make(map[string]*master_pb.DiskInfo),
-		GrpcPort:  uint32(dn.GrpcPort),
+		Id:                 string(dn.Id()),
+		DiskInfos:          make(map[string]*master_pb.DiskInfo),
+		GrpcPort:           uint32(dn.GrpcPort),
+		DiskHealths:        dn.GetDiskHealths(),
+		IsReadonly:         dn.IsReadOnly(),
+		QuarantinedVolumes: dn.GetQuarantinedVolumes(),
 	}
 	for _, c := range dn.Children() {
 		disk := c.(*Disk)
@@ -22,6 +22,11 @@ func NewRack(id string) *Rack {
 	return r
 }
 
+// AvailableSpaceFor keeps new volumes away from the read only volume servers in the rack.
+func (r *Rack) AvailableSpaceFor(option *VolumeGrowOption) int64 {
+	return r.NodeImpl.AvailableSpaceFor(option) - readOnlyAvailableSpaceFor(r, option)
+}
+
 func (r *Rack) FindDataNode(ip string, port int) *DataNode {
 	for _, c := range r.Children() {
 		dn := c.(*DataNode)
@@ -0,0 +1,120 @@
+package topology
+
+import (
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+)
+
+// ReplicaSyncGracePeriod is how long the replicas are not written before their differences are treated as out of sync.
+// The in-flight writes and deletions make the replicas differ only for a short time.
+const ReplicaSyncGracePeriod = 10 * time.Minute
+
+// OutOfSyncVolumes lists the writable volumes of the data node missing files or deletions of their replicas,
+// with the peers to repair them from.
+func (t *Topology) OutOfSyncVolumes(dn *DataNode, now time.Time) (outOfSyncVolumes []*master_pb.OutOfSyncVolume) {
+	for _, v := range dn.GetVolumes() {
+		if v.ReadOnly || v.RemoteStorageName != "" || v.ReplicaPlacement == nil || v.ReplicaPlacement.GetCopyCount() <= 1 {
+			continue
+		}
+		var peers []string
+		for _, peer := range t.Lookup(v.Collection, v.Id) {
+			if peer == dn {
+				continue
+			}
+			peerVolume, err := peer.GetVolumesById(v.Id)
+			if err != nil {
+				continue
+			}
+			if isReplicaOutOfSync(v, peerVolume, now) {
+				peers = append(peers, string(peer.ServerAddress()))
+			}
+		}
+		if len(peers) > 0 {
+			outOfSyncVolumes = append(outOfSyncVolumes, &master_pb.OutOfSyncVolume{
+				VolumeId:   uint32(v.Id),
+				Collection: v.Collection,
+				Peers:      peers,
+			})
+		}
+	}
+	return
+}
+
+// isReplicaOutOfSync checks the peer has more files or deletions than the replica, after both are not written for the grace period.
+func isReplicaOutOfSync(replica, peer storage.VolumeInfo, now time.Time) bool {
+	if replica.CompactRevision != peer.CompactRevision {
+		// the counters restart after the compaction
+		return false
+	}
+	lastModified := replica.ModifiedAtSecond
+	if peer.ModifiedAtSecond > lastModified {
+		lastModified = peer.ModifiedAtSecond
+	}
+	if now.Sub(time.Unix(lastModified, 0)) < ReplicaSyncGracePeriod {
+		return false
+	}
+	return peer.FileCount > replica.FileCount || peer.DeleteCount > replica.DeleteCount
+}
+
+type changedVolume struct {
+	collection string
+	changedAt  time.Time
+}
+
+// RecordVolumeCounterDeltas remembers the volumes changed in the heartbeat, to compare their replicas after they settle.
+func (t *Topology) RecordVolumeCounterDeltas(deltas []*master_pb.VolumeCounterDelta, now time.Time) {
+	t.changedVolumesLock.Lock()
+	defer t.changedVolumesLock.Unlock()
+	for _, delta := range deltas {
+		t.changedVolumes[needle.VolumeId(delta.Id)] = changedVolume{
+			collection: delta.Collection,
+			changedAt:  now,
+		}
+	}
+}
+
+// DivergedVolumes compares the replicas of the volumes not changed for the grace period since their last change,
+// and lists the ones with different needles. Each change is compared once.
+func (t *Topology) DivergedVolumes(now time.Time) (divergedVolumes []needle.VolumeId) {
+	settled := make(map[needle.VolumeId]string)
+	t.changedVolumesLock.Lock()
+	for vid, changed := range t.changedVolumes {
+		if now.Sub(changed.changedAt) >= ReplicaSyncGracePeriod {
+			settled[vid] = changed.collection
+			delete(t.changedVolumes, vid)
+		}
+	}
+	t.changedVolumesLock.Unlock()
+
+	for vid, collection := range settled {
+		var replicas []storage.VolumeInfo
+		for _, dn := range t.Lookup(collection, vid) {
+			if v, err := dn.GetVolumesById(vid); err == nil && !v.ReadOnly && v.RemoteStorageName == "" {
+				replicas = append(replicas, v)
+			}
+		}
+		for i := 1; i < len(replicas); i++ {
+			if isReplicaDiverged(replicas[0], replicas[i]) {
+				divergedVolumes = append(divergedVolumes, vid)
+				break
+			}
+		}
+	}
+	return
+}
+
+// isReplicaDiverged compares the index checksums of the replicas.
+// The replicas are not compared if either checksum is unknown, e.g. loaded by the leveldb needle maps.
+func isReplicaDiverged(a, b storage.VolumeInfo) bool {
+	if a.CompactRevision != b.CompactRevision {
+		// the needle maps are rebuilt by the compaction
+		return false
+	}
+	if a.IndexChecksum == 0 || b.IndexChecksum == 0 {
+		return false
+	}
+	return a.IndexChecksum != b.IndexChecksum
+}
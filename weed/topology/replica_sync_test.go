@@ -0,0 +1,54 @@
+package topology
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage"
+)
+
+func TestIsReplicaOutOfSync(t *testing.T) {
+	now := time.Now()
+	idle := now.Add(-time.Hour).Unix()
+	replica := storage.VolumeInfo{FileCount: 10, DeleteCount: 2, ModifiedAtSecond: idle}
+
+	cases := []struct {
+		name     string
+		peer     storage.VolumeInfo
+		expected bool
+	}{
+		{"same counts", storage.VolumeInfo{FileCount: 10, DeleteCount: 2, ModifiedAtSecond: idle}, false},
+		{"missing files", storage.VolumeInfo{FileCount: 12, DeleteCount: 2, ModifiedAtSecond: idle}, true},
+		{"missing deletions", storage.VolumeInfo{FileCount: 10, DeleteCount: 3, ModifiedAtSecond: idle}, true},
+		{"peer is behind", storage.VolumeInfo{FileCount: 8, DeleteCount: 2, ModifiedAtSecond: idle}, false},
+		{"recently written", storage.VolumeInfo{FileCount: 12, DeleteCount: 2, ModifiedAtSecond: now.Unix()}, false},
+		{"compacted", storage.VolumeInfo{FileCount: 12, DeleteCount: 2, ModifiedAtSecond: idle, CompactRevision: 1}, false},
+	}
+	for _, c := range cases {
+		if actual := isReplicaOutOfSync(replica, c.peer, now); actual != c.expected {
+			t.Errorf("%s: expected %v, actual %v", c.name, c.expected, actual)
+		}
+	}
+}
+
+func TestIsReplicaDiverged(t *testing.T) {
+	replica := storage.VolumeInfo{FileCount: 10, DeleteCount: 2, IndexChecksum: 0xabc}
+
+	cases := []struct {
+		name     string
+		peer     storage.VolumeInfo
+		expected bool
+	}{
+		{"same checksum", storage.VolumeInfo{FileCount: 10, DeleteCount: 2, IndexChecksum: 0xabc}, false},
+		{"same checksum with more index entries", storage.VolumeInfo{FileCount: 11, DeleteCount: 3, IndexChecksum: 0xabc}, false},
+		{"different checksum", storage.VolumeInfo{FileCount: 10, DeleteCount: 2, IndexChecksum: 0xdef}, true},
+		{"no checksum with the same counts", storage.VolumeInfo{FileCount: 10, DeleteCount: 2}, false},
+		{"no checksum with different counts", storage.VolumeInfo{FileCount: 10, DeleteCount: 1}, false},
+		{"compacted", storage.VolumeInfo{FileCount: 10, DeleteCount: 2, IndexChecksum: 0xdef, CompactRevision: 1}, false},
+	}
+	for _, c := range cases {
+		if actual := isReplicaDiverged(replica, c.peer); actual != c.expected {
+			t.Errorf("%s: expected %v, actual %v", c.name, c.expected, actual)
+		}
+	}
+}
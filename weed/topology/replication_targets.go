@@ -0,0 +1,155 @@
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+)
+
+// ReplicationTarget is the replication wanted for one volume, or for the volumes of the collections matching a pattern,
+// kept until all their replicas are placed.
+type ReplicationTarget struct {
+	VolumeId          uint32 `json:"volumeId,omitempty"`
+	CollectionPattern string `json:"collectionPattern,omitempty"`
+	Replication       string `json:"replication"`
+}
+
+func (target ReplicationTarget) String() string {
+	if target.VolumeId != 0 {
+		return fmt.Sprintf("volume %d replication %s", target.VolumeId, target.Replication)
+	}
+	return fmt.Sprintf("collections %q replication %s", target.CollectionPattern, target.Replication)
+}
+
+func (target ReplicationTarget) isSameVolumes(other ReplicationTarget) bool {
+	return target.VolumeId == other.VolumeId && (target.VolumeId != 0 || target.CollectionPattern == other.CollectionPattern)
+}
+
+func (target ReplicationTarget) matches(v *master_pb.VolumeInformationMessage) bool {
+	if target.VolumeId != 0 {
+		return v.Id == target.VolumeId
+	}
+	matched, err := filepath.Match(target.CollectionPattern, v.Collection)
+	return err == nil && matched
+}
+
+func (t *Topology) GetReplicationTargets() []ReplicationTarget {
+	t.replicationTargetsLock.RLock()
+	defer t.replicationTargetsLock.RUnlock()
+	return append([]ReplicationTarget(nil), t.replicationTargets...)
+}
+
+// ApplyReplicationTargets uses the targets replicated by raft.
+func (t *Topology) ApplyReplicationTargets(targets []ReplicationTarget) {
+	t.replicationTargetsLock.Lock()
+	defer t.replicationTargetsLock.Unlock()
+	t.replicationTargets = targets
+}
+
+// SetReplicationTarget adds the target for all masters, replacing the target of the same volumes,
+// or removes the target of the same volumes.
+func (t *Topology) SetReplicationTarget(target ReplicationTarget, isDelete bool) error {
+	return t.updateReplicationTargets(func(existing ReplicationTarget) bool {
+		return !existing.isSameVolumes(target)
+	}, target, isDelete)
+}
+
+// RemoveReplicationTargets removes the reached targets, unless they were changed in the meantime.
+func (t *Topology) RemoveReplicationTargets(reached []ReplicationTarget) error {
+	return t.updateReplicationTargets(func(existing ReplicationTarget) bool {
+		for _, target := range reached {
+			if existing == target {
+				return false
+			}
+		}
+		return true
+	}, ReplicationTarget{}, true)
+}
+
+func (t *Topology) updateReplicationTargets(keepFn func(existing ReplicationTarget) bool, added ReplicationTarget, isDelete bool) error {
+	t.replicationTargetsUpdate.Lock()
+	defer t.replicationTargetsUpdate.Unlock()
+
+	var targets []ReplicationTarget
+	for _, existing := range t.GetReplicationTargets() {
+		if keepFn(existing) {
+			targets = append(targets, existing)
+		}
+	}
+	if !isDelete {
+		targets = append(targets, added)
+	}
+	return t.changeReplicationTargets(targets)
+}
+
+// changeReplicationTargets sets the targets for all masters.
+func (t *Topology) changeReplicationTargets(targets []ReplicationTarget) error {
+	t.RaftAccessLock.RLock()
+	defer t.RaftAccessLock.RUnlock()
+
+	if t.Raft == nil {
+		t.ApplyReplicationTargets(targets)
+		return nil
+	}
+	if targets == nil {
+		targets = []ReplicationTarget{}
+	}
+	b, err := json.Marshal(&MaxVolumeIdCommand{
+		MaxVolumeId:        t.GetMaxVolumeId(),
+		ReplicationTargets: &targets,
+	})
+	if err != nil {
+		return fmt.Errorf("failed marshal MaxVolumeIdCommand: %+v", err)
+	}
+	if future := t.Raft.Apply(b, time.Second); future.Error() != nil {
+		return future.Error()
+	}
+	return nil
+}
+
+// PersistedReplicationTargets returns the targets to keep in the raft state.
+func (t *Topology) PersistedReplicationTargets() *[]ReplicationTarget {
+	targets := t.GetReplicationTargets()
+	if targets == nil {
+		targets = []ReplicationTarget{}
+	}
+	return &targets
+}
+
+// IsReplicationTargetReached checks whether all volumes of the target use the replication,
+// and have at least as many replicas as the replication asks for.
+func IsReplicationTargetReached(topologyInfo *master_pb.TopologyInfo, target ReplicationTarget) (bool, error) {
+	replicaPlacement, err := super_block.NewReplicaPlacementFromString(target.Replication)
+	if err != nil {
+		return false, err
+	}
+	replicaCounts := make(map[uint32]int)
+	isReached := true
+	for _, dc := range topologyInfo.DataCenterInfos {
+		for _, rack := range dc.RackInfos {
+			for _, dn := range rack.DataNodeInfos {
+				for _, diskInfo := range dn.DiskInfos {
+					for _, v := range diskInfo.VolumeInfos {
+						if !target.matches(v) {
+							continue
+						}
+						if v.ReplicaPlacement != uint32(replicaPlacement.Byte()) {
+							isReached = false
+						}
+						replicaCounts[v.Id]++
+					}
+				}
+			}
+		}
+	}
+	for _, count := range replicaCounts {
+		if count < replicaPlacement.GetCopyCount() {
+			isReached = false
+		}
+	}
+	return isReached, nil
+}
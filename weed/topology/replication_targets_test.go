@@ -0,0 +1,91 @@
+package topology
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+)
+
+func replicationTestTopology(volumesPerNode ...[]*master_pb.VolumeInformationMessage) *master_pb.TopologyInfo {
+	rack := &master_pb.RackInfo{Id: "rack1"}
+	for _, volumes := range volumesPerNode {
+		rack.DataNodeInfos = append(rack.DataNodeInfos, &master_pb.DataNodeInfo{
+			DiskInfos: map[string]*master_pb.DiskInfo{"": {VolumeInfos: volumes}},
+		})
+	}
+	return &master_pb.TopologyInfo{
+		DataCenterInfos: []*master_pb.DataCenterInfo{{Id: "dc1", RackInfos: []*master_pb.RackInfo{rack}}},
+	}
+}
+
+func TestIsReplicationTargetReached(t *testing.T) {
+	target := ReplicationTarget{CollectionPattern: "logs*", Replication: "001"}
+
+	// volume 2 is configured but has one replica only, volume 3 is in another collection
+	topologyInfo := replicationTestTopology(
+		[]*master_pb.VolumeInformationMessage{
+			{Id: 1, Collection: "logs1", ReplicaPlacement: 1},
+			{Id: 2, Collection: "logs2", ReplicaPlacement: 1},
+			{Id: 3, Collection: "images", ReplicaPlacement: 0},
+		},
+		[]*master_pb.VolumeInformationMessage{
+			{Id: 1, Collection: "logs1", ReplicaPlacement: 1},
+		},
+	)
+	if isReached, err := IsReplicationTargetReached(topologyInfo, target); err != nil || isReached {
+		t.Errorf("expected volume 2 to miss a replica: %v %v", isReached, err)
+	}
+
+	topologyInfo.DataCenterInfos[0].RackInfos[0].DataNodeInfos[1].DiskInfos[""].VolumeInfos = append(
+		topologyInfo.DataCenterInfos[0].RackInfos[0].DataNodeInfos[1].DiskInfos[""].VolumeInfos,
+		&master_pb.VolumeInformationMessage{Id: 2, Collection: "logs2", ReplicaPlacement: 1})
+	if isReached, err := IsReplicationTargetReached(topologyInfo, target); err != nil || !isReached {
+		t.Errorf("expected the replication to be reached: %v %v", isReached, err)
+	}
+
+	if isReached, err := IsReplicationTargetReached(topologyInfo, ReplicationTarget{VolumeId: 3, Replication: "001"}); err != nil || isReached {
+		t.Errorf("expected volume 3 not to be configured: %v %v", isReached, err)
+	}
+}
+
+func TestSetReplicationTarget(t *testing.T) {
+	topo := NewTopology("weedfs", nil, 32*1024, 5, false)
+
+	topo.SetReplicationTarget(ReplicationTarget{CollectionPattern: "logs*", Replication: "001"}, false)
+	topo.SetReplicationTarget(ReplicationTarget{VolumeId: 7, Replication: "010"}, false)
+	topo.SetReplicationTarget(ReplicationTarget{CollectionPattern: "logs*", Replication: "002"}, false)
+	expected := []ReplicationTarget{
+		{VolumeId: 7, Replication: "010"},
+		{CollectionPattern: "logs*", Replication: "002"},
+	}
+	if targets := topo.GetReplicationTargets(); !reflect.DeepEqual(targets, expected) {
+		t.Errorf("expected %v, got %v", expected, targets)
+	}
+
+	topo.SetReplicationTarget(ReplicationTarget{VolumeId: 7}, true)
+	expected = []ReplicationTarget{
+		{CollectionPattern: "logs*", Replication: "002"},
+	}
+	if targets := topo.GetReplicationTargets(); !reflect.DeepEqual(targets, expected) {
+		t.Errorf("expected %v, got %v", expected, targets)
+	}
+}
+
+func TestRemoveReplicationTargetsKeepsChangedTargets(t *testing.T) {
+	topo := NewTopology("weedfs", nil, 32*1024, 5, false)
+
+	reached := ReplicationTarget{CollectionPattern: "logs*", Replication: "001"}
+	topo.SetReplicationTarget(reached, false)
+	topo.SetReplicationTarget(ReplicationTarget{VolumeId: 7, Replication: "010"}, false)
+	// changed after the fixer checked it
+	topo.SetReplicationTarget(ReplicationTarget{VolumeId: 7, Replication: "002"}, false)
+
+	topo.RemoveReplicationTargets([]ReplicationTarget{reached, {VolumeId: 7, Replication: "010"}})
+	expected := []ReplicationTarget{
+		{VolumeId: 7, Replication: "002"},
+	}
+	if targets := topo.GetReplicationTargets(); !reflect.DeepEqual(targets, expected) {
+		t.Errorf("expected %v, got %v", expected, targets)
+	}
+}
@@ -35,6 +35,18 @@ func ReplicatedWrite(masterFn operation.GetMasterFn, grpcDialOption grpc.DialOpt
 			glog.V(0).Infoln(err)
 			return
 		}
+		// the checksum algorithm of this volume is also used by the replicas, so all replicas have the same etag
+		if v := s.GetVolume(volumeId); v != nil && v.Version() != needle.Version1 {
+			n.SetChecksumAlgorithm(v.SuperBlock.ChecksumAlgorithm())
+		}
+	} else if checksum := r.FormValue("checksum"); checksum != "" {
+		checksumAlgorithm, parseErr := needle.ParseChecksumAlgorithm(checksum)
+		if parseErr != nil {
+			return false, parseErr
+		}
+		if v := s.GetVolume(volumeId); v != nil && v.Version() != needle.Version1 {
+			n.SetChecksumAlgorithm(checksumAlgorithm)
+		}
 	}
 
 	// read fsync value
@@ -64,8 +76,9 @@ func ReplicatedWrite(masterFn operation.GetMasterFn, grpcDialOption grpc.DialOpt
 				Path:   r.URL.Path,
 			}
 			q := url.Values{
-				"type": {"replicate"},
-				"ttl":  {n.Ttl.String()},
+				"type":     {"replicate"},
+				"ttl":      {n.Ttl.String()},
+				"checksum": {n.GetChecksumAlgorithm().String()},
 			}
 			if n.LastModified > 0 {
 				q.Set("ts", strconv.FormatUint(n.LastModified, 10))
@@ -22,7 +22,9 @@ import (
 )
 
 type Topology struct {
-	vacuumLockCounter int64
+	vacuumLockCounter           int64
+	vacuumGarbageBytesThreshold uint64
+	vacuumConcurrency           int
 	NodeImpl
 
 	collectionMap  *util.ConcurrentReadMap
@@ -45,6 +47,19 @@ type Topology struct {
 	RaftAccessLock sync.RWMutex
 	UuidAccessLock sync.RWMutex
 	UuidMap        map[string][]string
+
+	volumeIdRanges      VolumeIdRanges
+	isVolumeIdRangesSet bool // changed by volume.id.ranges and kept in the raft state, overriding master.toml
+	volumeIdRangesLock  sync.RWMutex
+
+	replicationTargets       []ReplicationTarget
+	replicationTargetsLock   sync.RWMutex
+	replicationTargetsUpdate sync.Mutex // serializes the read-modify-write of the targets
+
+	capacityHistory *capacityHistory
+
+	changedVolumes     map[needle.VolumeId]changedVolume
+	changedVolumesLock sync.Mutex
 }
 
 func NewTopology(id string, seq sequence.Sequencer, volumeSizeLimit uint64, pulse int, replicationAsMin bool) *Topology {
@@ -67,6 +82,9 @@ func NewTopology(id string, seq sequence.Sequencer, volumeSizeLimit uint64, puls
 
 	t.Configuration = &Configuration{}
 
+	t.capacityHistory = newCapacityHistory()
+	t.changedVolumes = make(map[needle.VolumeId]changedVolume)
+
 	return t
 }
 
@@ -137,7 +155,11 @@ func (t *Topology) Lookup(collection string, vid needle.VolumeId) (dataNodes []*
 
 func (t *Topology) NextVolumeId() (needle.VolumeId, error) {
 	vid := t.GetMaxVolumeId()
-	next := vid.Next()
+	ranges := t.GetVolumeIdRanges()
+	next, found := ranges.NextAfter(vid)
+	if !found {
+		return 0, fmt.Errorf("volume id ranges %s are used up, max volume id is %d", ranges, vid)
+	}
 
 	t.RaftAccessLock.RLock()
 	defer t.RaftAccessLock.RUnlock()
@@ -169,7 +191,10 @@ func (t *Topology) PickForWrite(count uint64, option *VolumeGrowOption) (string,
 	if datanodes.Length() == 0 {
 		return "", 0, nil, fmt.Errorf("no writable volumes available for collection:%s replication:%s ttl:%s", option.Collection, option.ReplicaPlacement.String(), option.Ttl.String())
 	}
-	fileId := t.Sequence.NextFileId(count)
+	fileId, err := t.Sequence.NextFileId(count)
+	if err != nil {
+		return "", 0, nil, fmt.Errorf("failed to generate file id: %v", err)
+	}
 	return needle.NewFileId(*vid, fileId, rand.Uint32()).String(), count, datanodes, nil
 }
 
@@ -208,6 +233,56 @@ func (t *Topology) FindCollection(collectionName string) (*Collection, bool) {
 	return c.(*Collection), hasCollection
 }
 
+// CollectionUsage summarizes the data stored in a collection.
+type CollectionUsage struct {
+	VolumeCount   uint64
+	EcVolumeCount uint64
+	FileCount     uint64
+	Size          uint64
+	DataSize      uint64 // the live bytes, counting each volume once
+}
+
+// IsEmpty returns true if the collection has no ec volumes and no live files.
+func (u CollectionUsage) IsEmpty() bool {
+	return u.EcVolumeCount == 0 && u.FileCount == 0
+}
+
+// GetCollectionUsage counts the volumes and the live files of a collection.
+// The size adds up all replicas, which is the disk space released by deleting the collection.
+func (t *Topology) GetCollectionUsage(collectionName string) (usage CollectionUsage) {
+	if collection, found := t.FindCollection(collectionName); found {
+		volumeIds := make(map[needle.VolumeId]bool)
+		for _, dn := range collection.ListVolumeServers() {
+			for _, v := range dn.GetVolumes() {
+				if v.Collection != collectionName {
+					continue
+				}
+				usage.Size += v.Size
+				if !volumeIds[v.Id] {
+					volumeIds[v.Id] = true
+					if v.FileCount > v.DeleteCount {
+						usage.FileCount += uint64(v.FileCount - v.DeleteCount)
+					}
+					if v.Size > v.DeletedByteCount {
+						usage.DataSize += v.Size - v.DeletedByteCount
+					}
+				}
+			}
+		}
+		usage.VolumeCount = uint64(len(volumeIds))
+	}
+
+	t.ecShardMapLock.RLock()
+	for _, ecVolumeLocation := range t.ecShardMap {
+		if ecVolumeLocation.Collection == collectionName {
+			usage.EcVolumeCount++
+		}
+	}
+	t.ecShardMapLock.RUnlock()
+
+	return
+}
+
 func (t *Topology) DeleteCollection(collectionName string) {
 	t.collectionMap.Delete(collectionName)
 }
@@ -239,6 +314,11 @@ func (t *Topology) UnRegisterVolumeLayout(v storage.VolumeInfo, dn *DataNode) {
 	}
 }
 
+// AvailableSpaceFor keeps new volumes away from the read only volume servers.
+func (t *Topology) AvailableSpaceFor(option *VolumeGrowOption) int64 {
+	return t.NodeImpl.AvailableSpaceFor(option) - readOnlyAvailableSpaceFor(t, option)
+}
+
 func (t *Topology) GetOrCreateDataCenter(dcName string) *DataCenter {
 	t.Lock()
 	defer t.Unlock()
@@ -0,0 +1,144 @@
+package topology
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage"
+)
+
+// BrowseInfo is one level of the topology in the master UI, from the data centers down to the volumes of a data node.
+type BrowseInfo struct {
+	DataCenter  NodeId           `json:"DataCenter,omitempty"`
+	Rack        NodeId           `json:"Rack,omitempty"`
+	DataNode    NodeId           `json:"DataNode,omitempty"`
+	Children    []BrowseNode     `json:"Children,omitempty"`
+	Disks       []BrowseDisk     `json:"Disks,omitempty"`
+	DiskHealths []DiskHealthInfo `json:"DiskHealths,omitempty"`
+}
+
+// BrowseNode is the usage of the volume slots of a data center, a rack, or a data node.
+type BrowseNode struct {
+	Id          NodeId  `json:"Id"`
+	Url         string  `json:"Url,omitempty"`
+	Volumes     int64   `json:"Volumes"`
+	EcShards    int64   `json:"EcShards"`
+	Max         int64   `json:"Max"`
+	UsedPercent float64 `json:"UsedPercent"`
+}
+
+type BrowseDisk struct {
+	DiskType    string         `json:"DiskType"`
+	Volumes     int64          `json:"Volumes"`
+	Max         int64          `json:"Max"`
+	UsedPercent float64        `json:"UsedPercent"`
+	VolumeInfos []BrowseVolume `json:"VolumeInfos"`
+}
+
+// BrowseVolume is the volume with its size and garbage in percent of the volume size limit.
+type BrowseVolume struct {
+	storage.VolumeInfo
+	UsedPercent    float64 `json:"UsedPercent"`
+	GarbagePercent float64 `json:"GarbagePercent"`
+}
+
+// Browse lists the children of the data center, the rack, or the data node, whichever is the deepest one given.
+// The data node level lists its disks with their volumes.
+func (t *Topology) Browse(dcName, rackName, dataNodeId string) (info BrowseInfo, err error) {
+	info.DataCenter, info.Rack, info.DataNode = NodeId(dcName), NodeId(rackName), NodeId(dataNodeId)
+
+	if dcName == "" {
+		info.Children = browseChildren(t)
+		return
+	}
+	dc, found := findChild(t, dcName)
+	if !found {
+		return info, fmt.Errorf("data center %s not found", dcName)
+	}
+	if rackName == "" {
+		info.Children = browseChildren(dc)
+		return
+	}
+	rack, found := findChild(dc, rackName)
+	if !found {
+		return info, fmt.Errorf("rack %s not found in data center %s", rackName, dcName)
+	}
+	if dataNodeId == "" {
+		info.Children = browseChildren(rack)
+		return
+	}
+	dn, found := findChild(rack, dataNodeId)
+	if !found {
+		return info, fmt.Errorf("data node %s not found in rack %s", dataNodeId, rackName)
+	}
+	info.DiskHealths = dn.(*DataNode).diskHealthInfos()
+	for _, c := range dn.Children() {
+		info.Disks = append(info.Disks, t.browseDisk(c.(*Disk)))
+	}
+	slices.SortFunc(info.Disks, func(a, b BrowseDisk) bool {
+		return a.DiskType < b.DiskType
+	})
+	return
+}
+
+func findChild(n Node, id string) (Node, bool) {
+	for _, c := range n.Children() {
+		if c.Id() == NodeId(id) {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+func browseChildren(n Node) (children []BrowseNode) {
+	for _, c := range n.Children() {
+		child := BrowseNode{Id: c.Id()}
+		if dn, ok := c.(*DataNode); ok {
+			child.Url = dn.Url()
+		}
+		child.Volumes, child.EcShards, child.Max = sumDiskUsages(c.GetDiskUsages())
+		child.UsedPercent = usedPercent(child.Volumes, child.Max)
+		children = append(children, child)
+	}
+	slices.SortFunc(children, func(a, b BrowseNode) bool {
+		return a.Id < b.Id
+	})
+	return
+}
+
+func (t *Topology) browseDisk(d *Disk) (disk BrowseDisk) {
+	disk.DiskType = string(d.Id())
+	disk.Volumes, _, disk.Max = sumDiskUsages(d.GetDiskUsages())
+	disk.UsedPercent = usedPercent(disk.Volumes, disk.Max)
+	for _, v := range d.GetVolumes() {
+		volume := BrowseVolume{VolumeInfo: v}
+		if t.volumeSizeLimit > 0 {
+			volume.UsedPercent = float64(v.Size) * 100 / float64(t.volumeSizeLimit)
+			volume.GarbagePercent = float64(v.DeletedByteCount) * 100 / float64(t.volumeSizeLimit)
+		}
+		disk.VolumeInfos = append(disk.VolumeInfos, volume)
+	}
+	slices.SortFunc(disk.VolumeInfos, func(a, b BrowseVolume) bool {
+		return a.Id < b.Id
+	})
+	return
+}
+
+func sumDiskUsages(du *DiskUsages) (volumeCount, ecShardCount, maxVolumeCount int64) {
+	du.RLock()
+	defer du.RUnlock()
+	for _, usage := range du.usages {
+		volumeCount += usage.volumeCount
+		ecShardCount += usage.ecShardCount
+		maxVolumeCount += usage.maxVolumeCount
+	}
+	return
+}
+
+func usedPercent(used, max int64) float64 {
+	if max <= 0 {
+		return 0
+	}
+	return float64(used) * 100 / float64(max)
+}
@@ -0,0 +1,46 @@
+package topology
+
+import (
+	"testing"
+)
+
+func TestBrowse(t *testing.T) {
+	topo := setup(topologyLayout)
+
+	info, err := topo.Browse("", "", "")
+	if err != nil {
+		t.Fatalf("browse data centers: %v", err)
+	}
+	if len(info.Children) != 3 || info.Children[0].Id != "dc1" || info.Children[2].Id != "dc3" {
+		t.Fatalf("unexpected data centers %+v", info.Children)
+	}
+	if dc1 := info.Children[0]; dc1.Volumes != 12 || dc1.Max != 26 {
+		t.Errorf("unexpected dc1 usage %+v", dc1)
+	}
+
+	info, err = topo.Browse("dc1", "rack1", "")
+	if err != nil {
+		t.Fatalf("browse rack: %v", err)
+	}
+	if len(info.Children) != 2 || info.Children[0].Id != "server111" {
+		t.Fatalf("unexpected data nodes %+v", info.Children)
+	}
+	if server111 := info.Children[0]; server111.UsedPercent != 100 {
+		t.Errorf("unexpected server111 usage %+v", server111)
+	}
+
+	info, err = topo.Browse("dc1", "rack1", "server112")
+	if err != nil {
+		t.Fatalf("browse data node: %v", err)
+	}
+	if len(info.Disks) != 1 || len(info.Disks[0].VolumeInfos) != 3 {
+		t.Fatalf("unexpected disks %+v", info.Disks)
+	}
+	if v := info.Disks[0].VolumeInfos[0]; v.Id != 4 || v.UsedPercent <= 0 {
+		t.Errorf("unexpected volume %+v", v)
+	}
+
+	if _, err = topo.Browse("dc1", "rack3", ""); err == nil {
+		t.Errorf("expected error for missing rack")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
 	"github.com/seaweedfs/seaweedfs/weed/storage/types"
 
+	"math/rand"
 	"testing"
 )
 
@@ -208,3 +209,48 @@ func TestAddRemoveVolume(t *testing.T) {
 	}
 
 }
+
+func TestReadOnlyDataNodeHasNoAvailableSpace(t *testing.T) {
+
+	topo := NewTopology("weedfs", sequence.NewMemorySequencer(), 32*1024, 5, false)
+
+	dc := topo.GetOrCreateDataCenter("dc1")
+	rack := dc.GetOrCreateRack("rack1")
+	maxVolumeCounts := make(map[string]uint32)
+	maxVolumeCounts[""] = 25
+	dn := rack.GetOrCreateDataNode("127.0.0.1", 34534, 0, "127.0.0.1", maxVolumeCounts)
+
+	option := &VolumeGrowOption{DiskType: types.HardDriveType}
+	if dn.AvailableSpaceFor(option) != 25 {
+		t.Errorf("expected 25 free slots, got %d", dn.AvailableSpaceFor(option))
+	}
+
+	dn.SetReadOnly(true)
+	var node Node = dn
+	if node.AvailableSpaceFor(option) != 0 {
+		t.Errorf("read only data node should have no free slots, got %d", node.AvailableSpaceFor(option))
+	}
+	if !dn.ToDataNodeInfo().IsReadonly {
+		t.Errorf("read only data node should be reported as read only")
+	}
+
+	for _, parent := range []Node{rack, dc, topo} {
+		if parent.AvailableSpaceFor(option) != 0 {
+			t.Errorf("%s should not count the read only data node, got %d", parent.Id(), parent.AvailableSpaceFor(option))
+		}
+	}
+	dn2 := rack.GetOrCreateDataNode("127.0.0.1", 34535, 0, "127.0.0.1", maxVolumeCounts)
+	if rack.AvailableSpaceFor(option) != 25 || topo.AvailableSpaceFor(option) != 25 {
+		t.Errorf("expected only the writable data node, got %d %d", rack.AvailableSpaceFor(option), topo.AvailableSpaceFor(option))
+	}
+	for i := 0; i < 10; i++ {
+		if picked, err := rack.ReserveOneVolume(rand.Int63n(rack.AvailableSpaceFor(option)), option); err != nil || picked != dn2 {
+			t.Errorf("expected the writable data node, got %v %v", picked, err)
+		}
+	}
+
+	dn.SetReadOnly(false)
+	if dn.AvailableSpaceFor(option) != 25 {
+		t.Errorf("expected 25 free slots again, got %d", dn.AvailableSpaceFor(option))
+	}
+}
@@ -2,7 +2,10 @@ package topology
 
 import (
 	"context"
+	"errors"
 	"io"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -11,13 +14,46 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/rpc"
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/volume_server_pb"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 )
 
+var ErrVacuumInProgress = errors.New("another vacuum is in progress")
+
+// VacuumProgressFunc receives the progress of vacuuming each replica, from the concurrently vacuumed volumes
+type VacuumProgressFunc func(progress *master_pb.VacuumVolumeProgress)
+
+// vacuumCandidate is a volume with enough garbage to vacuum, and its replicas to vacuum
+type vacuumCandidate struct {
+	collection   string
+	volumeLayout *VolumeLayout
+	vid          needle.VolumeId
+	locationList *VolumeLocationList
+	garbageBytes uint64
+}
+
+// SetVacuumLimits also vacuums the volumes with at least garbageBytesThreshold bytes of garbage, whatever the garbage ratio,
+// and vacuums up to concurrency volumes at the same time.
+func (t *Topology) SetVacuumLimits(garbageBytesThreshold uint64, concurrency int) {
+	t.vacuumGarbageBytesThreshold = garbageBytesThreshold
+	t.vacuumConcurrency = concurrency
+}
+
+func (t *Topology) isVacuumNeeded(garbageRatio float64, garbageBytes uint64, garbageThreshold float64) bool {
+	if garbageRatio >= garbageThreshold {
+		return true
+	}
+	return t.vacuumGarbageBytesThreshold > 0 && garbageBytes >= t.vacuumGarbageBytesThreshold
+}
+
 func (t *Topology) batchVacuumVolumeCheck(grpcDialOption grpc.DialOption, vid needle.VolumeId,
-	locationlist *VolumeLocationList, garbageThreshold float64) (*VolumeLocationList, bool) {
-	ch := make(chan int, locationlist.Length())
+	locationlist *VolumeLocationList, garbageThreshold float64) (*VolumeLocationList, uint64, bool) {
+	type checkResult struct {
+		index        int
+		garbageBytes uint64
+	}
+	ch := make(chan checkResult, locationlist.Length())
 	errCount := int32(0)
 	for index, dn := range locationlist.list {
 		go func(index int, url rpc.ServerAddress, vid needle.VolumeId) {
@@ -27,13 +63,13 @@ func (t *Topology) batchVacuumVolumeCheck(grpcDialOption grpc.DialOption, vid ne
 				})
 				if err != nil {
 					atomic.AddInt32(&errCount, 1)
-					ch <- -1
+					ch <- checkResult{index: -1}
 					return err
 				}
-				if resp.GarbageRatio >= garbageThreshold {
-					ch <- index
+				if t.isVacuumNeeded(resp.GarbageRatio, resp.GarbageBytes, garbageThreshold) {
+					ch <- checkResult{index: index, garbageBytes: resp.GarbageBytes}
 				} else {
-					ch <- -1
+					ch <- checkResult{index: -1}
 				}
 				return nil
 			})
@@ -43,86 +79,84 @@ func (t *Topology) batchVacuumVolumeCheck(grpcDialOption grpc.DialOption, vid ne
 		}(index, dn.ServerAddress(), vid)
 	}
 	vacuumLocationList := NewVolumeLocationList()
+	var garbageBytes uint64
 
 	waitTimeout := time.NewTimer(time.Minute * time.Duration(t.volumeSizeLimit/1024/1024/1000+1))
 	defer waitTimeout.Stop()
 
 	for range locationlist.list {
 		select {
-		case index := <-ch:
-			if index != -1 {
-				vacuumLocationList.list = append(vacuumLocationList.list, locationlist.list[index])
+		case result := <-ch:
+			if result.index != -1 {
+				vacuumLocationList.list = append(vacuumLocationList.list, locationlist.list[result.index])
+				if result.garbageBytes > garbageBytes {
+					garbageBytes = result.garbageBytes
+				}
 			}
 		case <-waitTimeout.C:
-			return vacuumLocationList, false
+			return vacuumLocationList, garbageBytes, false
 		}
 	}
-	return vacuumLocationList, errCount == 0 && len(vacuumLocationList.list) > 0
+	return vacuumLocationList, garbageBytes, errCount == 0 && len(vacuumLocationList.list) > 0
 }
 
 func (t *Topology) batchVacuumVolumeCompact(grpcDialOption grpc.DialOption, vl *VolumeLayout, vid needle.VolumeId,
-	locationlist *VolumeLocationList, preallocate int64) bool {
+	locationlist *VolumeLocationList, preallocate int64, progressFn VacuumProgressFunc) bool {
 	vl.accessLock.Lock()
 	vl.removeFromWritable(vid)
 	vl.accessLock.Unlock()
 
-	ch := make(chan bool, locationlist.Length())
+	// compact the replicas one after another, so the other replicas keep serving reads at full speed
 	for index, dn := range locationlist.list {
-		go func(index int, url rpc.ServerAddress, vid needle.VolumeId) {
-			glog.V(0).Infoln(index, "Start vacuuming", vid, "on", url)
-			err := operation.WithVolumeServerClient(true, url, grpcDialOption, func(volumeServerClient volume_server_pb.VolumeServerClient) error {
-				stream, err := volumeServerClient.VacuumVolumeCompact(context.Background(), &volume_server_pb.VacuumVolumeCompactRequest{
-					VolumeId:    uint32(vid),
-					Preallocate: preallocate,
-				})
-				if err != nil {
-					return err
-				}
-
-				for {
-					resp, recvErr := stream.Recv()
-					if recvErr != nil {
-						if recvErr == io.EOF {
-							break
-						} else {
-							return recvErr
-						}
-					}
-					glog.V(0).Infof("%d vacuum %d on %s processed %d bytes, loadAvg %.02f%%",
-						index, vid, url, resp.ProcessedBytes, resp.LoadAvg_1M*100)
-				}
-				return nil
-			})
-			if err != nil {
-				glog.Errorf("Error when vacuuming %d on %s: %v", vid, url, err)
-				ch <- false
-			} else {
-				glog.V(0).Infof("Complete vacuuming %d on %s", vid, url)
-				ch <- true
-			}
-		}(index, dn.ServerAddress(), vid)
+		url := dn.ServerAddress()
+		glog.V(0).Infoln(index, "Start vacuuming", vid, "on", url)
+		progressFn(&master_pb.VacuumVolumeProgress{Server: string(url), Stage: "compact"})
+		if err := t.vacuumVolumeCompactOnServer(grpcDialOption, index, url, vid, preallocate, progressFn); err != nil {
+			glog.Errorf("Error when vacuuming %d on %s: %v", vid, url, err)
+			progressFn(&master_pb.VacuumVolumeProgress{Server: string(url), Stage: "compact", Error: err.Error()})
+			return false
+		}
+		glog.V(0).Infof("Complete vacuuming %d on %s", vid, url)
 	}
-	isVacuumSuccess := true
+	return true
+}
 
-	waitTimeout := time.NewTimer(3 * time.Minute * time.Duration(t.volumeSizeLimit/1024/1024/1000+1))
-	defer waitTimeout.Stop()
+func (t *Topology) vacuumVolumeCompactOnServer(grpcDialOption grpc.DialOption, index int, url rpc.ServerAddress, vid needle.VolumeId, preallocate int64, progressFn VacuumProgressFunc) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute*time.Duration(t.volumeSizeLimit/1024/1024/1000+1))
+	defer cancel()
 
-	for range locationlist.list {
-		select {
-		case canCommit := <-ch:
-			isVacuumSuccess = isVacuumSuccess && canCommit
-		case <-waitTimeout.C:
-			return false
+	return operation.WithVolumeServerClient(true, url, grpcDialOption, func(volumeServerClient volume_server_pb.VolumeServerClient) error {
+		stream, err := volumeServerClient.VacuumVolumeCompact(ctx, &volume_server_pb.VacuumVolumeCompactRequest{
+			VolumeId:    uint32(vid),
+			Preallocate: preallocate,
+		})
+		if err != nil {
+			return err
 		}
-	}
-	return isVacuumSuccess
+
+		for {
+			resp, recvErr := stream.Recv()
+			if recvErr != nil {
+				if recvErr == io.EOF {
+					break
+				} else {
+					return recvErr
+				}
+			}
+			glog.V(0).Infof("%d vacuum %d on %s processed %d bytes, loadAvg %.02f%%",
+				index, vid, url, resp.ProcessedBytes, resp.LoadAvg_1M*100)
+			progressFn(&master_pb.VacuumVolumeProgress{Server: string(url), Stage: "compact", ProcessedBytes: resp.ProcessedBytes})
+		}
+		return nil
+	})
 }
 
-func (t *Topology) batchVacuumVolumeCommit(grpcDialOption grpc.DialOption, vl *VolumeLayout, vid needle.VolumeId, locationlist *VolumeLocationList) bool {
+func (t *Topology) batchVacuumVolumeCommit(grpcDialOption grpc.DialOption, vl *VolumeLayout, vid needle.VolumeId, locationlist *VolumeLocationList, progressFn VacuumProgressFunc) bool {
 	isCommitSuccess := true
 	isReadOnly := false
 	for _, dn := range locationlist.list {
 		glog.V(0).Infoln("Start Committing vacuum", vid, "on", dn.Url())
+		progress := &master_pb.VacuumVolumeProgress{Server: string(dn.ServerAddress()), Stage: "commit"}
 		err := operation.WithVolumeServerClient(false, dn.ServerAddress(), grpcDialOption, func(volumeServerClient volume_server_pb.VolumeServerClient) error {
 			resp, err := volumeServerClient.VacuumVolumeCommit(context.Background(), &volume_server_pb.VacuumVolumeCommitRequest{
 				VolumeId: uint32(vid),
@@ -130,14 +164,19 @@ func (t *Topology) batchVacuumVolumeCommit(grpcDialOption grpc.DialOption, vl *V
 			if resp != nil && resp.IsReadOnly {
 				isReadOnly = true
 			}
+			if resp != nil {
+				progress.ReclaimedBytes = resp.ReclaimedBytes
+			}
 			return err
 		})
 		if err != nil {
 			glog.Errorf("Error when committing vacuum %d on %s: %v", vid, dn.Url(), err)
 			isCommitSuccess = false
+			progress.Error = err.Error()
 		} else {
 			glog.V(0).Infof("Complete Committing vacuum %d on %s", vid, dn.Url())
 		}
+		progressFn(progress)
 	}
 	if isCommitSuccess {
 		for _, dn := range locationlist.list {
@@ -147,7 +186,7 @@ func (t *Topology) batchVacuumVolumeCommit(grpcDialOption grpc.DialOption, vl *V
 	return isCommitSuccess
 }
 
-func (t *Topology) batchVacuumVolumeCleanup(grpcDialOption grpc.DialOption, vl *VolumeLayout, vid needle.VolumeId, locationlist *VolumeLocationList) {
+func (t *Topology) batchVacuumVolumeCleanup(grpcDialOption grpc.DialOption, vl *VolumeLayout, vid needle.VolumeId, locationlist *VolumeLocationList, progressFn VacuumProgressFunc) {
 	for _, dn := range locationlist.list {
 		glog.V(0).Infoln("Start cleaning up", vid, "on", dn.Url())
 		err := operation.WithVolumeServerClient(false, dn.ServerAddress(), grpcDialOption, func(volumeServerClient volume_server_pb.VolumeServerClient) error {
@@ -156,20 +195,29 @@ func (t *Topology) batchVacuumVolumeCleanup(grpcDialOption grpc.DialOption, vl *
 			})
 			return err
 		})
+		progress := &master_pb.VacuumVolumeProgress{Server: string(dn.ServerAddress()), Stage: "cleanup"}
 		if err != nil {
 			glog.Errorf("Error when cleaning up vacuum %d on %s: %v", vid, dn.Url(), err)
+			progress.Error = err.Error()
 		} else {
 			glog.V(0).Infof("Complete cleaning up vacuum %d on %s", vid, dn.Url())
 		}
+		progressFn(progress)
 	}
 }
 
 func (t *Topology) Vacuum(grpcDialOption grpc.DialOption, garbageThreshold float64, volumeId uint32, collection string, preallocate int64) {
+	t.VacuumWithProgress(grpcDialOption, garbageThreshold, volumeId, collection, preallocate, nil)
+}
+
+// VacuumWithProgress vacuums the volumes like Vacuum, and reports the progress of each replica to progressFn if not nil.
+// The replicas of a volume are compacted one after another.
+func (t *Topology) VacuumWithProgress(grpcDialOption grpc.DialOption, garbageThreshold float64, volumeId uint32, collection string, preallocate int64, progressFn VacuumProgressFunc) error {
 
 	// if there is vacuum going on, return immediately
 	swapped := atomic.CompareAndSwapInt64(&t.vacuumLockCounter, 0, 1)
 	if !swapped {
-		return
+		return ErrVacuumInProgress
 	}
 	defer atomic.StoreInt64(&t.vacuumLockCounter, 0)
 
@@ -177,6 +225,7 @@ func (t *Topology) Vacuum(grpcDialOption grpc.DialOption, garbageThreshold float
 
 	glog.V(1).Infof("Start vacuum on demand with threshold: %f collection: %s volumeId: %d",
 		garbageThreshold, collection, volumeId)
+	var candidates []*vacuumCandidate
 	for _, col := range t.collectionMap.Items() {
 		c := col.(*Collection)
 		if collection != "" && collection != c.Name {
@@ -185,23 +234,29 @@ func (t *Topology) Vacuum(grpcDialOption grpc.DialOption, garbageThreshold float
 		for _, vl := range c.storageType2VolumeLayout.Items() {
 			if vl != nil {
 				volumeLayout := vl.(*VolumeLayout)
-				if volumeId > 0 {
-					if volumeLayout.Lookup(needle.VolumeId(volumeId)) != nil {
-						t.vacuumOneVolumeLayout(grpcDialOption, volumeLayout, c, garbageThreshold, preallocate)
-					}
-				} else {
-					t.vacuumOneVolumeLayout(grpcDialOption, volumeLayout, c, garbageThreshold, preallocate)
-				}
+				candidates = append(candidates, t.collectVacuumCandidates(grpcDialOption, volumeLayout, c, needle.VolumeId(volumeId), garbageThreshold)...)
 			}
 		}
 	}
+
+	// reclaim the most bytes first, a large volume with a moderate garbage ratio can hold more garbage than a small one
+	sortVacuumCandidates(candidates)
+	runVacuumCandidates(candidates, t.vacuumConcurrency, func(candidate *vacuumCandidate) {
+		glog.V(1).Infof("vacuum collection:%s volume:%d with %d garbage bytes", candidate.collection, candidate.vid, candidate.garbageBytes)
+		t.vacuumOneVolume(grpcDialOption, candidate, preallocate, progressFn)
+	})
+	return nil
 }
 
-func (t *Topology) vacuumOneVolumeLayout(grpcDialOption grpc.DialOption, volumeLayout *VolumeLayout, c *Collection, garbageThreshold float64, preallocate int64) {
+// collectVacuumCandidates checks the volumes of the layout, or only volumeId if it is not 0
+func (t *Topology) collectVacuumCandidates(grpcDialOption grpc.DialOption, volumeLayout *VolumeLayout, c *Collection, volumeId needle.VolumeId, garbageThreshold float64) (candidates []*vacuumCandidate) {
 
 	volumeLayout.accessLock.RLock()
 	tmpMap := make(map[needle.VolumeId]*VolumeLocationList)
 	for vid, locationList := range volumeLayout.vid2location {
+		if volumeId != 0 && vid != volumeId {
+			continue
+		}
 		tmpMap[vid] = locationList.Copy()
 	}
 	volumeLayout.accessLock.RUnlock()
@@ -217,13 +272,64 @@ func (t *Topology) vacuumOneVolumeLayout(grpcDialOption grpc.DialOption, volumeL
 		}
 
 		glog.V(2).Infof("check vacuum on collection:%s volume:%d", c.Name, vid)
-		if vacuumLocationList, needVacuum := t.batchVacuumVolumeCheck(
+		if vacuumLocationList, garbageBytes, needVacuum := t.batchVacuumVolumeCheck(
 			grpcDialOption, vid, locationList, garbageThreshold); needVacuum {
-			if t.batchVacuumVolumeCompact(grpcDialOption, volumeLayout, vid, vacuumLocationList, preallocate) {
-				t.batchVacuumVolumeCommit(grpcDialOption, volumeLayout, vid, vacuumLocationList)
-			} else {
-				t.batchVacuumVolumeCleanup(grpcDialOption, volumeLayout, vid, vacuumLocationList)
-			}
+			candidates = append(candidates, &vacuumCandidate{
+				collection:   c.Name,
+				volumeLayout: volumeLayout,
+				vid:          vid,
+				locationList: vacuumLocationList,
+				garbageBytes: garbageBytes,
+			})
+		}
+	}
+	return
+}
+
+func sortVacuumCandidates(candidates []*vacuumCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].garbageBytes != candidates[j].garbageBytes {
+			return candidates[i].garbageBytes > candidates[j].garbageBytes
 		}
+		return candidates[i].vid < candidates[j].vid
+	})
+}
+
+// runVacuumCandidates vacuums up to concurrency volumes at the same time, in the order of the candidates.
+// Each volume is only one candidate, so the replicas of a volume are never vacuumed by two workers.
+func runVacuumCandidates(candidates []*vacuumCandidate, concurrency int, vacuumFn func(candidate *vacuumCandidate)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	tokens := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, candidate := range candidates {
+		tokens <- struct{}{}
+		wg.Add(1)
+		go func(candidate *vacuumCandidate) {
+			defer func() {
+				<-tokens
+				wg.Done()
+			}()
+			vacuumFn(candidate)
+		}(candidate)
+	}
+	wg.Wait()
+}
+
+func (t *Topology) vacuumOneVolume(grpcDialOption grpc.DialOption, candidate *vacuumCandidate, preallocate int64, progressFn VacuumProgressFunc) {
+	reportFn := func(progress *master_pb.VacuumVolumeProgress) {
+		if progressFn == nil {
+			return
+		}
+		progress.VolumeId = uint32(candidate.vid)
+		progress.Collection = candidate.collection
+		progress.GarbageBytes = candidate.garbageBytes
+		progressFn(progress)
+	}
+	if t.batchVacuumVolumeCompact(grpcDialOption, candidate.volumeLayout, candidate.vid, candidate.locationList, preallocate, reportFn) {
+		t.batchVacuumVolumeCommit(grpcDialOption, candidate.volumeLayout, candidate.vid, candidate.locationList, reportFn)
+	} else {
+		t.batchVacuumVolumeCleanup(grpcDialOption, candidate.volumeLayout, candidate.vid, candidate.locationList, reportFn)
 	}
 }
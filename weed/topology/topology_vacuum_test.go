@@ -0,0 +1,71 @@
+package topology
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIsVacuumNeeded(t *testing.T) {
+	topo := &Topology{}
+	if !topo.isVacuumNeeded(0.5, 100, 0.3) {
+		t.Errorf("garbage ratio above the threshold should vacuum")
+	}
+	if topo.isVacuumNeeded(0.1, 10<<30, 0.3) {
+		t.Errorf("garbage bytes should not vacuum without a bytes threshold")
+	}
+	topo.SetVacuumLimits(1<<30, 2)
+	if !topo.isVacuumNeeded(0.1, 10<<30, 0.3) {
+		t.Errorf("garbage bytes above the bytes threshold should vacuum")
+	}
+	if topo.isVacuumNeeded(0.1, 1<<20, 0.3) {
+		t.Errorf("garbage below both thresholds should not vacuum")
+	}
+}
+
+func TestSortVacuumCandidates(t *testing.T) {
+	candidates := []*vacuumCandidate{
+		{vid: 1, garbageBytes: 10},
+		{vid: 2, garbageBytes: 3000},
+		{vid: 3, garbageBytes: 200},
+		{vid: 4, garbageBytes: 3000},
+	}
+	sortVacuumCandidates(candidates)
+	var vids []int
+	for _, c := range candidates {
+		vids = append(vids, int(c.vid))
+	}
+	expected := []int{2, 4, 3, 1}
+	for i := range expected {
+		if vids[i] != expected[i] {
+			t.Fatalf("expected order %v, got %v", expected, vids)
+		}
+	}
+}
+
+func TestRunVacuumCandidatesConcurrency(t *testing.T) {
+	var candidates []*vacuumCandidate
+	for i := 1; i <= 10; i++ {
+		candidates = append(candidates, &vacuumCandidate{vid: 0})
+	}
+	var running, maxRunning, done int32
+	var lock sync.Mutex
+	runVacuumCandidates(candidates, 3, func(candidate *vacuumCandidate) {
+		n := atomic.AddInt32(&running, 1)
+		lock.Lock()
+		if n > maxRunning {
+			maxRunning = n
+		}
+		lock.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&done, 1)
+	})
+	if done != 10 {
+		t.Errorf("expected 10 vacuumed volumes, got %d", done)
+	}
+	if maxRunning > 3 {
+		t.Errorf("expected at most 3 concurrent vacuums, got %d", maxRunning)
+	}
+}
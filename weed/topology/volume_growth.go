@@ -41,6 +41,7 @@ type VolumeGrowOption struct {
 	Rack               string                        `json:"rack,omitempty"`
 	DataNode           string                        `json:"dataNode,omitempty"`
 	MemoryMapMaxSizeMb uint32                        `json:"memoryMapMaxSizeMb,omitempty"`
+	ChecksumAlgorithm  string                        `json:"checksum,omitempty"`
 }
 
 type VolumeGrowth struct {
@@ -0,0 +1,172 @@
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+)
+
+// VolumeIdRange is an inclusive range of volume ids.
+type VolumeIdRange struct {
+	Start needle.VolumeId
+	Stop  needle.VolumeId
+}
+
+// VolumeIdRanges restricts the volume ids this cluster assigns, so federated clusters
+// replicating to each other never create volumes with the same id. Empty means no restriction.
+type VolumeIdRanges []VolumeIdRange
+
+// ParseVolumeIdRanges parses comma separated ranges, e.g. "1-100000,500000-600000".
+func ParseVolumeIdRanges(s string) (ranges VolumeIdRanges, err error) {
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		startStop := strings.SplitN(part, "-", 2)
+		if len(startStop) != 2 {
+			return nil, fmt.Errorf("volume id range %q should be <start>-<stop>", part)
+		}
+		start, startErr := strconv.ParseUint(strings.TrimSpace(startStop[0]), 10, 32)
+		stop, stopErr := strconv.ParseUint(strings.TrimSpace(startStop[1]), 10, 32)
+		if startErr != nil || stopErr != nil {
+			return nil, fmt.Errorf("volume id range %q should be <start>-<stop>", part)
+		}
+		if start == 0 || start > stop {
+			return nil, fmt.Errorf("volume id range %q should start from 1 and not be empty", part)
+		}
+		ranges = append(ranges, VolumeIdRange{Start: needle.VolumeId(start), Stop: needle.VolumeId(stop)})
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].Start <= ranges[i-1].Stop {
+			return nil, fmt.Errorf("volume id ranges %s and %s overlap", ranges[i-1], ranges[i])
+		}
+	}
+	return ranges, nil
+}
+
+func (r VolumeIdRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Start, r.Stop)
+}
+
+func (ranges VolumeIdRanges) String() string {
+	var parts []string
+	for _, r := range ranges {
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+func (ranges VolumeIdRanges) Contains(vid needle.VolumeId) bool {
+	if len(ranges) == 0 {
+		return true
+	}
+	for _, r := range ranges {
+		if r.Start <= vid && vid <= r.Stop {
+			return true
+		}
+	}
+	return false
+}
+
+// NextAfter returns the smallest volume id in the ranges after the volume id.
+func (ranges VolumeIdRanges) NextAfter(vid needle.VolumeId) (needle.VolumeId, bool) {
+	if len(ranges) == 0 {
+		return vid.Next(), true
+	}
+	for _, r := range ranges {
+		if vid < r.Start {
+			return r.Start, true
+		}
+		if vid < r.Stop {
+			return vid.Next(), true
+		}
+	}
+	return 0, false
+}
+
+func (t *Topology) GetVolumeIdRanges() VolumeIdRanges {
+	t.volumeIdRangesLock.RLock()
+	defer t.volumeIdRangesLock.RUnlock()
+	return t.volumeIdRanges
+}
+
+// LoadVolumeIdRanges uses the ranges from master.toml, unless they have been changed in the cluster.
+func (t *Topology) LoadVolumeIdRanges(ranges VolumeIdRanges) {
+	t.volumeIdRangesLock.Lock()
+	defer t.volumeIdRangesLock.Unlock()
+	if !t.isVolumeIdRangesSet {
+		t.volumeIdRanges = ranges
+	}
+}
+
+// ApplyVolumeIdRanges uses the ranges replicated by raft.
+func (t *Topology) ApplyVolumeIdRanges(ranges VolumeIdRanges) {
+	t.volumeIdRangesLock.Lock()
+	defer t.volumeIdRangesLock.Unlock()
+	t.volumeIdRanges = ranges
+	t.isVolumeIdRangesSet = true
+}
+
+// PersistedVolumeIdRanges returns the ranges to keep in the raft state, or nil if they come from master.toml.
+func (t *Topology) PersistedVolumeIdRanges() *string {
+	t.volumeIdRangesLock.RLock()
+	defer t.volumeIdRangesLock.RUnlock()
+	if !t.isVolumeIdRangesSet {
+		return nil
+	}
+	ranges := t.volumeIdRanges.String()
+	return &ranges
+}
+
+// ChangeVolumeIdRanges sets the ranges for all masters.
+func (t *Topology) ChangeVolumeIdRanges(ranges VolumeIdRanges) error {
+	t.RaftAccessLock.RLock()
+	defer t.RaftAccessLock.RUnlock()
+
+	if t.Raft == nil {
+		t.ApplyVolumeIdRanges(ranges)
+		return nil
+	}
+	rangesString := ranges.String()
+	b, err := json.Marshal(&MaxVolumeIdCommand{
+		MaxVolumeId:    t.GetMaxVolumeId(),
+		VolumeIdRanges: &rangesString,
+	})
+	if err != nil {
+		return fmt.Errorf("failed marshal MaxVolumeIdCommand: %+v", err)
+	}
+	if future := t.Raft.Apply(b, time.Second); future.Error() != nil {
+		return future.Error()
+	}
+	return nil
+}
+
+// ListOutOfRangeVolumeIds lists the volume ids in the cluster outside of the volume id ranges.
+func (t *Topology) ListOutOfRangeVolumeIds() (vids []needle.VolumeId) {
+	ranges := t.GetVolumeIdRanges()
+	if len(ranges) == 0 {
+		return nil
+	}
+	found := make(map[needle.VolumeId]bool)
+	for _, location := range t.ToVolumeLocations() {
+		for _, vid := range location.NewVids {
+			if !ranges.Contains(needle.VolumeId(vid)) && !found[needle.VolumeId(vid)] {
+				found[needle.VolumeId(vid)] = true
+				vids = append(vids, needle.VolumeId(vid))
+			}
+		}
+	}
+	sort.Slice(vids, func(i, j int) bool {
+		return vids[i] < vids[j]
+	})
+	return
+}
@@ -0,0 +1,81 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/sequence"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+)
+
+func TestParseVolumeIdRanges(t *testing.T) {
+	ranges, err := ParseVolumeIdRanges(" 500-600, 1-100 ")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if ranges.String() != "1-100,500-600" {
+		t.Errorf("unexpected ranges %s", ranges)
+	}
+
+	ranges, err = ParseVolumeIdRanges("")
+	if err != nil || len(ranges) != 0 {
+		t.Errorf("empty ranges: %v %v", ranges, err)
+	}
+
+	for _, bad := range []string{"1-100,50-200", "0-10", "10-1", "abc", "1-x"} {
+		if _, err = ParseVolumeIdRanges(bad); err == nil {
+			t.Errorf("expect error for %q", bad)
+		}
+	}
+}
+
+func TestVolumeIdRangesNextAfter(t *testing.T) {
+	ranges, _ := ParseVolumeIdRanges("10-11,20-20")
+
+	tests := []struct {
+		vid   needle.VolumeId
+		next  needle.VolumeId
+		found bool
+	}{
+		{0, 10, true},
+		{10, 11, true},
+		{11, 20, true},
+		{15, 20, true},
+		{20, 0, false},
+		{30, 0, false},
+	}
+	for _, tt := range tests {
+		next, found := ranges.NextAfter(tt.vid)
+		if next != tt.next || found != tt.found {
+			t.Errorf("next after %d: got %d %v, want %d %v", tt.vid, next, found, tt.next, tt.found)
+		}
+	}
+
+	if next, found := VolumeIdRanges(nil).NextAfter(7); next != 8 || !found {
+		t.Errorf("unrestricted next after 7: got %d %v", next, found)
+	}
+}
+
+func TestNextVolumeIdWithRanges(t *testing.T) {
+	topo := NewTopology("weedfs", sequence.NewMemorySequencer(), 32*1024, 5, false)
+	ranges, _ := ParseVolumeIdRanges("100-101")
+	topo.LoadVolumeIdRanges(ranges)
+
+	for _, expected := range []needle.VolumeId{100, 101} {
+		vid, err := topo.NextVolumeId()
+		if err != nil || vid != expected {
+			t.Fatalf("next volume id: got %d %v, want %d", vid, err, expected)
+		}
+		topo.UpAdjustMaxVolumeId(vid)
+	}
+	if _, err := topo.NextVolumeId(); err == nil {
+		t.Errorf("expect the ranges to be used up")
+	}
+
+	// ranges changed in the cluster are not overridden by master.toml
+	changed, _ := ParseVolumeIdRanges("200-300")
+	topo.ApplyVolumeIdRanges(changed)
+	topo.LoadVolumeIdRanges(ranges)
+	if vid, err := topo.NextVolumeId(); err != nil || vid != 200 {
+		t.Errorf("next volume id: got %d %v, want 200", vid, err)
+	}
+}
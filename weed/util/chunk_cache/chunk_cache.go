@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 )
 
@@ -15,30 +16,71 @@ type ChunkCache interface {
 	SetChunk(fileId string, data []byte)
 }
 
+// AdmissionChecker is implemented by the caches admitting only some chunks,
+// so a reader can stream a chunk through instead of fetching the whole chunk to cache it.
+type AdmissionChecker interface {
+	IsAdmitted(fileId string) bool
+}
+
+const (
+	tierMemory = "memory"
+	tierSmall  = "small"
+	tierMedium = "medium"
+	tierLarge  = "large"
+)
+
+// TieredChunkCacheOption configures the tiers of the chunk cache.
+// Chunks up to SmallChunkSizeLimit are kept in memory and in the small disk tier,
+// chunks up to MediumChunkSizeLimit in the medium disk tier, and larger chunks in the large disk tier.
+type TieredChunkCacheOption struct {
+	MemoryEntries        int64
+	Dir                  string
+	DiskSize             int64
+	SmallChunkSizeLimit  int64
+	MediumChunkSizeLimit int64
+	// a chunk is cached only after being read this many times recently, 0 or 1 caches every chunk
+	MinReadsToAdmit int
+}
+
 // a global cache for recently accessed file chunks
 type TieredChunkCache struct {
 	memCache   *ChunkCacheInMemory
 	diskCaches []*OnDiskCacheLayer
 	sync.RWMutex
-	onDiskCacheSizeLimit0 uint64
-	onDiskCacheSizeLimit1 uint64
-	onDiskCacheSizeLimit2 uint64
+	smallChunkSizeLimit  uint64
+	mediumChunkSizeLimit uint64
+	minReadsToAdmit      int
+	readFrequency        *readFrequency
 }
 
 var _ ChunkCache = &TieredChunkCache{}
 
 func NewTieredChunkCache(maxEntries int64, dir string, diskSizeInUnit int64, unitSize int64) *TieredChunkCache {
+	return NewTieredChunkCacheWithOption(&TieredChunkCacheOption{
+		MemoryEntries:        maxEntries,
+		Dir:                  dir,
+		DiskSize:             diskSizeInUnit * unitSize,
+		SmallChunkSizeLimit:  unitSize,
+		MediumChunkSizeLimit: 4 * unitSize,
+	})
+}
+
+func NewTieredChunkCacheWithOption(option *TieredChunkCacheOption) *TieredChunkCache {
 
 	c := &TieredChunkCache{
-		memCache: NewChunkCacheInMemory(maxEntries),
+		memCache:             NewChunkCacheInMemory(option.MemoryEntries),
+		smallChunkSizeLimit:  uint64(option.SmallChunkSizeLimit),
+		mediumChunkSizeLimit: uint64(option.MediumChunkSizeLimit),
+		minReadsToAdmit:      option.MinReadsToAdmit,
+	}
+	if c.minReadsToAdmit > 1 {
+		c.readFrequency = newReadFrequency(readFrequencyCapacity)
 	}
+	diskSize := option.DiskSize
 	c.diskCaches = make([]*OnDiskCacheLayer, 3)
-	c.onDiskCacheSizeLimit0 = uint64(unitSize)
-	c.onDiskCacheSizeLimit1 = 4 * c.onDiskCacheSizeLimit0
-	c.onDiskCacheSizeLimit2 = 2 * c.onDiskCacheSizeLimit1
-	c.diskCaches[0] = NewOnDiskCacheLayer(dir, "c0_2", diskSizeInUnit*unitSize/8, 2)
-	c.diskCaches[1] = NewOnDiskCacheLayer(dir, "c1_3", diskSizeInUnit*unitSize/4+diskSizeInUnit*unitSize/8, 3)
-	c.diskCaches[2] = NewOnDiskCacheLayer(dir, "c2_2", diskSizeInUnit*unitSize/2, 2)
+	c.diskCaches[0] = NewOnDiskCacheLayer(option.Dir, "c0_2", diskSize/8, 2)
+	c.diskCaches[1] = NewOnDiskCacheLayer(option.Dir, "c1_3", diskSize/4+diskSize/8, 3)
+	c.diskCaches[2] = NewOnDiskCacheLayer(option.Dir, "c2_2", diskSize/2, 2)
 
 	return c
 }
@@ -51,13 +93,18 @@ func (c *TieredChunkCache) ReadChunkAt(data []byte, fileId string, offset uint64
 	c.RLock()
 	defer c.RUnlock()
 
+	if c.readFrequency != nil {
+		c.readFrequency.recordRead(fileId)
+	}
+
 	minSize := offset + uint64(len(data))
-	if minSize <= c.onDiskCacheSizeLimit0 {
+	if minSize <= c.smallChunkSizeLimit {
 		n, err = c.memCache.readChunkAt(data, fileId, offset)
 		if err != nil {
 			glog.Errorf("failed to read from memcache: %s", err)
 		}
 		if n >= int(minSize) {
+			recordCacheRequest(tierMemory, "hit")
 			return n, nil
 		}
 	}
@@ -68,33 +115,53 @@ func (c *TieredChunkCache) ReadChunkAt(data []byte, fileId string, offset uint64
 		return n, nil
 	}
 
-	if minSize <= c.onDiskCacheSizeLimit0 {
+	if minSize <= c.smallChunkSizeLimit {
 		n, err = c.diskCaches[0].readChunkAt(data, fid.Key, offset)
 		if n >= int(minSize) {
+			recordCacheRequest(tierSmall, "hit")
 			return
 		}
 	}
-	if minSize <= c.onDiskCacheSizeLimit1 {
+	if minSize <= c.mediumChunkSizeLimit {
 		n, err = c.diskCaches[1].readChunkAt(data, fid.Key, offset)
 		if n >= int(minSize) {
+			recordCacheRequest(tierMedium, "hit")
 			return
 		}
 	}
 	{
 		n, err = c.diskCaches[2].readChunkAt(data, fid.Key, offset)
 		if n >= int(minSize) {
+			recordCacheRequest(tierLarge, "hit")
 			return
 		}
 	}
 
+	recordCacheRequest(c.tierOf(minSize), "miss")
 	return 0, nil
 
 }
 
+// IsAdmitted tells whether the chunk would be cached by SetChunk
+func (c *TieredChunkCache) IsAdmitted(fileId string) bool {
+	if c == nil {
+		return false
+	}
+	return c.readFrequency == nil || c.readFrequency.count(fileId) >= c.minReadsToAdmit
+}
+
 func (c *TieredChunkCache) SetChunk(fileId string, data []byte) {
 	if c == nil {
 		return
 	}
+
+	tier := c.tierOf(uint64(len(data)))
+	if !c.IsAdmitted(fileId) {
+		recordCacheRequest(tier, "reject")
+		return
+	}
+	recordCacheRequest(tier, "admit")
+
 	c.Lock()
 	defer c.Unlock()
 
@@ -105,7 +172,7 @@ func (c *TieredChunkCache) SetChunk(fileId string, data []byte) {
 
 func (c *TieredChunkCache) doSetChunk(fileId string, data []byte) {
 
-	if len(data) <= int(c.onDiskCacheSizeLimit0) {
+	if len(data) <= int(c.smallChunkSizeLimit) {
 		c.memCache.SetChunk(fileId, data)
 	}
 
@@ -115,9 +182,9 @@ func (c *TieredChunkCache) doSetChunk(fileId string, data []byte) {
 		return
 	}
 
-	if len(data) <= int(c.onDiskCacheSizeLimit0) {
+	if len(data) <= int(c.smallChunkSizeLimit) {
 		c.diskCaches[0].setChunk(fid.Key, data)
-	} else if len(data) <= int(c.onDiskCacheSizeLimit1) {
+	} else if len(data) <= int(c.mediumChunkSizeLimit) {
 		c.diskCaches[1].setChunk(fid.Key, data)
 	} else {
 		c.diskCaches[2].setChunk(fid.Key, data)
@@ -125,6 +192,20 @@ func (c *TieredChunkCache) doSetChunk(fileId string, data []byte) {
 
 }
 
+func (c *TieredChunkCache) tierOf(size uint64) string {
+	if size <= c.smallChunkSizeLimit {
+		return tierSmall
+	}
+	if size <= c.mediumChunkSizeLimit {
+		return tierMedium
+	}
+	return tierLarge
+}
+
+func recordCacheRequest(tier, requestType string) {
+	stats.ChunkCacheRequestCounter.WithLabelValues(tier, requestType).Inc()
+}
+
 func (c *TieredChunkCache) Shutdown() {
 	if c == nil {
 		return
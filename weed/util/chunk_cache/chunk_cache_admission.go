@@ -0,0 +1,48 @@
+package chunk_cache
+
+import "sync"
+
+const readFrequencyCapacity = 1 << 20
+
+// readFrequency counts the recent reads of each chunk, so only chunks read a few times are admitted,
+// and a one-off scan over many files does not push the hot chunks out of the cache.
+// When too many chunks are tracked, all counts are halved and the cold ones are forgotten.
+type readFrequency struct {
+	counts   map[string]int
+	capacity int
+	sync.Mutex
+}
+
+func newReadFrequency(capacity int) *readFrequency {
+	return &readFrequency{
+		counts:   make(map[string]int),
+		capacity: capacity,
+	}
+}
+
+func (r *readFrequency) recordRead(fileId string) {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, found := r.counts[fileId]; !found && len(r.counts) >= r.capacity {
+		r.age()
+	}
+	r.counts[fileId]++
+}
+
+func (r *readFrequency) count(fileId string) int {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.counts[fileId]
+}
+
+func (r *readFrequency) age() {
+	for fileId, count := range r.counts {
+		if count <= 1 {
+			delete(r.counts, fileId)
+		} else {
+			r.counts[fileId] = count / 2
+		}
+	}
+}
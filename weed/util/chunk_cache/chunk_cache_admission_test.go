@@ -0,0 +1,54 @@
+package chunk_cache
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAdmissionByReadFrequency(t *testing.T) {
+	cache := NewTieredChunkCacheWithOption(&TieredChunkCacheOption{
+		MemoryEntries:        2,
+		Dir:                  t.TempDir(),
+		DiskSize:             32 * 1024,
+		SmallChunkSizeLimit:  1024,
+		MediumChunkSizeLimit: 4096,
+		MinReadsToAdmit:      2,
+	})
+	defer cache.Shutdown()
+
+	fileId := "1,01aabbccdd"
+	data := bytes.Repeat([]byte("x"), 2048)
+	buf := make([]byte, len(data))
+
+	// read once, not hot enough to be cached
+	if n, _ := cache.ReadChunkAt(buf, fileId, 0); n != 0 {
+		t.Fatalf("unexpected cache hit")
+	}
+	if cache.IsAdmitted(fileId) {
+		t.Fatalf("chunk read once should not be admitted")
+	}
+	cache.SetChunk(fileId, data)
+	if n, _ := cache.ReadChunkAt(buf, fileId, 0); n != 0 {
+		t.Fatalf("chunk read once should not be admitted")
+	}
+
+	// read twice now
+	if !cache.IsAdmitted(fileId) {
+		t.Fatalf("chunk read twice should be admitted")
+	}
+	cache.SetChunk(fileId, data)
+	if n, _ := cache.ReadChunkAt(buf, fileId, 0); n != len(data) || !bytes.Equal(buf, data) {
+		t.Fatalf("chunk read twice should be cached, read %d bytes", n)
+	}
+}
+
+func TestReadFrequencyAging(t *testing.T) {
+	r := newReadFrequency(2)
+	r.recordRead("a")
+	r.recordRead("a")
+	r.recordRead("b")
+	r.recordRead("c")
+	if r.count("a") != 1 || r.count("b") != 0 || r.count("c") != 1 {
+		t.Errorf("unexpected counts after aging: a=%d b=%d c=%d", r.count("a"), r.count("b"), r.count("c"))
+	}
+}
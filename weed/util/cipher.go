@@ -4,6 +4,7 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"io"
 
@@ -58,3 +59,36 @@ func Decrypt(ciphertext []byte, key CipherKey) ([]byte, error) {
 	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
 	return gcm.Open(nil, nonce, ciphertext, nil)
 }
+
+// The encrypted data is the nonce, the encrypted content of the same size as the content, and the GCM tag.
+const (
+	CipherNonceSize = 12
+	CipherTagSize   = 16
+)
+
+// DecryptRange decrypts the part of the content at offset, from the same part of the encrypted content after the nonce.
+// AES-GCM encrypts the content in the AES-CTR mode, so a part is decrypted alone without fetching the whole data,
+// but without checking the GCM tag, which covers the whole content.
+func DecryptRange(encryptedPart []byte, nonce []byte, key CipherKey, offset int64) ([]byte, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != CipherNonceSize {
+		return nil, errors.New("unexpected nonce size")
+	}
+
+	// GCM encrypts the first block of the content with the counter 2 after the nonce
+	counter := make([]byte, aes.BlockSize)
+	copy(counter, nonce)
+	binary.BigEndian.PutUint32(counter[CipherNonceSize:], uint32(2+offset/aes.BlockSize))
+	stream := cipher.NewCTR(c, counter)
+	if skip := offset % aes.BlockSize; skip > 0 {
+		skipped := make([]byte, skip)
+		stream.XORKeyStream(skipped, skipped)
+	}
+
+	decrypted := make([]byte, len(encryptedPart))
+	stream.XORKeyStream(decrypted, encryptedPart)
+	return decrypted, nil
+}
@@ -1,8 +1,13 @@
 package util
 
 import (
+	"bytes"
+	"crypto/rand"
 	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestSameAsJavaImplementation(t *testing.T) {
@@ -15,3 +20,103 @@ func TestSameAsJavaImplementation(t *testing.T) {
 	}
 	println(string(plaintext))
 }
+
+func TestDecryptRange(t *testing.T) {
+	content := make([]byte, 10000)
+	rand.Read(content)
+	key := GenCipherKey()
+	encrypted, err := Encrypt(content, key)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if len(encrypted) != CipherNonceSize+len(content)+CipherTagSize {
+		t.Fatalf("unexpected encrypted size %d", len(encrypted))
+	}
+	nonce := encrypted[:CipherNonceSize]
+	for _, r := range [][2]int{{0, 10000}, {0, 1}, {15, 2}, {16, 16}, {17, 1000}, {4095, 5905}, {9999, 1}} {
+		offset, size := r[0], r[1]
+		part := encrypted[CipherNonceSize+offset : CipherNonceSize+offset+size]
+		decrypted, err := DecryptRange(part, nonce, key, int64(offset))
+		if err != nil {
+			t.Fatalf("decrypt [%d,%d): %v", offset, offset+size, err)
+		}
+		if !bytes.Equal(decrypted, content[offset:offset+size]) {
+			t.Errorf("decrypt [%d,%d): unexpected content", offset, offset+size)
+		}
+	}
+}
+
+func TestReadEncryptedUrlRange(t *testing.T) {
+	content := make([]byte, 200*1024)
+	rand.Read(content)
+	key := GenCipherKey()
+	encrypted, _ := Encrypt(content, key)
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Header.Get("Range"))
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(encrypted))
+	}))
+	defer server.Close()
+	defer func() {
+		EncryptedRangeReads = false
+	}()
+
+	read := func(offset, size int) (data []byte, err error) {
+		requests = requests[:0]
+		_, err = ReadUrlAsStream(server.URL, key, false, false, int64(offset), size, func(part []byte) {
+			data = append(data, part...)
+		})
+		return
+	}
+
+	for _, enabled := range []bool{false, true} {
+		EncryptedRangeReads = enabled
+		for _, r := range [][2]int{{100, 50}, {150 * 1024, 1000}, {len(content) - 10, 10}} {
+			offset, size := r[0], r[1]
+			data, err := read(offset, size)
+			if err != nil {
+				t.Fatalf("read [%d,%d): %v", offset, offset+size, err)
+			}
+			if !bytes.Equal(data, content[offset:offset+size]) {
+				t.Errorf("read [%d,%d): unexpected content", offset, offset+size)
+			}
+			// the whole chunk is fetched to authenticate it, unless the range reads are enabled
+			for _, rangeHeader := range requests {
+				if fetchedWhole := rangeHeader == ""; fetchedWhole == enabled {
+					t.Errorf("range reads %v, read [%d,%d): fetched the whole chunk %v", enabled, offset, offset+size, fetchedWhole)
+				}
+			}
+		}
+		// the tag is not read as content
+		if _, err := read(len(content)-10, 20); err == nil {
+			t.Errorf("range reads %v: expected error reading past the plaintext", enabled)
+		}
+	}
+
+	// a changed chunk fails the authentication of the whole chunk
+	encrypted[CipherNonceSize+100] ^= 1
+	EncryptedRangeReads = false
+	if _, err := read(100, 50); err == nil {
+		t.Errorf("expected error reading a changed chunk")
+	}
+}
+
+func TestDecompressRange(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 1000)
+	compressed, _ := GzipData(content)
+	data, err := DecompressRange(compressed, 100, 1000)
+	if err != nil || !bytes.Equal(data, content[100:1100]) {
+		t.Errorf("decompress range: %v", err)
+	}
+	if _, err = DecompressRange(compressed, 15000, 2000); err == nil {
+		t.Errorf("expected error reading past the content")
+	}
+	// the content not compressed is read as is
+	data, err = DecompressRange(content, 100, 1000)
+	if err != nil || !bytes.Equal(data, content[100:1100]) {
+		t.Errorf("read range of content not compressed: %v", err)
+	}
+	if _, err = DecompressRange(content, 15000, 2000); err == nil {
+		t.Errorf("expected error reading past the content not compressed")
+	}
+}
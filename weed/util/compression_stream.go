@@ -1,6 +1,7 @@
 package util
 
 import (
+	"bytes"
 	"compress/gzip"
 	"fmt"
 	"io"
@@ -51,3 +52,35 @@ func GunzipStream(w io.Writer, r io.Reader) (int64, error) {
 	}()
 	return io.Copy(w, gr)
 }
+
+// DecompressRange decompresses the part of the content at offset of the size,
+// only as far as the end of the part, and without keeping the content before it.
+// The content not compressed with gzip is read as is, as the whole chunk reads keep the content DecompressData fails on.
+func DecompressRange(input []byte, offset int64, size int) ([]byte, error) {
+	if !IsGzippedContent(input) {
+		if int64(len(input)) < offset+int64(size) {
+			return nil, fmt.Errorf("content size %d [%d, %d)", len(input), offset, offset+int64(size))
+		}
+		return input[offset : offset+int64(size)], nil
+	}
+	gr, ok := gzipReaderPool.Get().(*gzip.Reader)
+	if !ok {
+		return nil, fmt.Errorf("gzip: new reader error")
+	}
+	if err := gr.Reset(bytes.NewReader(input)); err != nil {
+		return nil, err
+	}
+	defer func() {
+		gr.Close()
+		gzipReaderPool.Put(gr)
+	}()
+
+	if _, err := io.CopyN(io.Discard, gr, offset); err != nil {
+		return nil, err
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(gr, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
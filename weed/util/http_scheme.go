@@ -0,0 +1,39 @@
+package util
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+var (
+	httpsClientTLS  *tls.Config
+	httpsTransports []*http.Transport
+)
+
+// AddHttpsTransport registers the transport of an internal http client, which may reach the filers serving https.
+func AddHttpsTransport(transport *http.Transport) {
+	transport.TLSClientConfig = httpsClientTLS
+	httpsTransports = append(httpsTransports, transport)
+}
+
+// UseHttpsClient makes the internal http clients reach the filers by https with the tls config.
+// It is called on startup, before any http request.
+func UseHttpsClient(tlsConfig *tls.Config) {
+	httpsClientTLS = tlsConfig
+	for _, transport := range httpsTransports {
+		transport.TLSClientConfig = tlsConfig
+	}
+}
+
+// HttpsClientTLS is the tls config to reach the filers serving https, nil for plain http.
+func HttpsClientTLS() *tls.Config {
+	return httpsClientTLS
+}
+
+// HttpScheme is the scheme of the filer urls built by the internal clients.
+func HttpScheme() string {
+	if httpsClientTLS != nil {
+		return "https"
+	}
+	return "http"
+}
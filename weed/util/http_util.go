@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
@@ -17,6 +18,9 @@ import (
 var (
 	client    *http.Client
 	Transport *http.Transport
+	// EncryptedRangeReads lets the range reads of the encrypted chunks fetch and decrypt only the part.
+	// The part is not authenticated, since the GCM tag covers the whole chunk, so it is off by default.
+	EncryptedRangeReads bool
 )
 
 func init() {
@@ -27,6 +31,7 @@ func init() {
 	client = &http.Client{
 		Transport: Transport,
 	}
+	AddHttpsTransport(Transport)
 }
 
 func Post(url string, values url.Values) ([]byte, error) {
@@ -345,6 +350,9 @@ func ReadUrlAsStream(fileUrl string, cipherKey []byte, isContentGzipped bool, is
 }
 
 func readEncryptedUrl(fileUrl string, cipherKey []byte, isContentCompressed bool, isFullChunk bool, offset int64, size int, fn func(data []byte)) (bool, error) {
+	if EncryptedRangeReads && !isFullChunk && !isContentCompressed {
+		return readEncryptedUrlRange(fileUrl, cipherKey, offset, size, fn)
+	}
 	encryptedData, retryable, err := Get(fileUrl)
 	if err != nil {
 		return retryable, fmt.Errorf("fetch %s: %v", fileUrl, err)
@@ -353,6 +361,14 @@ func readEncryptedUrl(fileUrl string, cipherKey []byte, isContentCompressed bool
 	if err != nil {
 		return false, fmt.Errorf("decrypt %s: %v", fileUrl, err)
 	}
+	if isContentCompressed && !isFullChunk {
+		// the compressed content is read from its start, but only decompressed to the end of the part
+		if decryptedData, err = DecompressRange(decryptedData, offset, size); err != nil {
+			return false, fmt.Errorf("unzip decrypted %s [%d, %d): %v", fileUrl, offset, int(offset)+size, err)
+		}
+		fn(decryptedData)
+		return false, nil
+	}
 	if isContentCompressed {
 		decryptedData, err = DecompressData(decryptedData)
 		if err != nil {
@@ -370,6 +386,78 @@ func readEncryptedUrl(fileUrl string, cipherKey []byte, isContentCompressed bool
 	return false, nil
 }
 
+// the nonce and a part of the encrypted content at most this far apart are fetched in one request
+const encryptedRangeGap = 64 * 1024
+
+// readEncryptedUrlRange fetches and decrypts only the part of the encrypted content, with the nonce before the content.
+// The part must be in the plaintext, which is the stored chunk without the nonce and the tag.
+func readEncryptedUrlRange(fileUrl string, cipherKey []byte, offset int64, size int, fn func(data []byte)) (bool, error) {
+	start := CipherNonceSize + offset
+	fetchStart := start
+	if offset <= encryptedRangeGap {
+		fetchStart = 0
+	}
+	data, storedSize, retryable, err := getRange(fileUrl, fetchStart, start+int64(size))
+	if err != nil {
+		return retryable, fmt.Errorf("fetch %s: %v", fileUrl, err)
+	}
+	if plaintextSize := storedSize - CipherNonceSize - CipherTagSize; offset+int64(size) > plaintextSize {
+		return false, fmt.Errorf("read encrypted %s plaintext size %d [%d, %d)", fileUrl, plaintextSize, offset, int(offset)+size)
+	}
+	var nonce []byte
+	if fetchStart == 0 {
+		nonce, data = data[:CipherNonceSize], data[start:]
+	} else if nonce, _, retryable, err = getRange(fileUrl, 0, CipherNonceSize); err != nil {
+		return retryable, fmt.Errorf("fetch nonce %s: %v", fileUrl, err)
+	}
+	decryptedData, err := DecryptRange(data, nonce, CipherKey(cipherKey), offset)
+	if err != nil {
+		return false, fmt.Errorf("decrypt %s [%d, %d): %v", fileUrl, offset, int(offset)+size, err)
+	}
+	fn(decryptedData)
+	return false, nil
+}
+
+// getRange fetches the bytes [start, stop) of the url, and the size of the whole content.
+func getRange(fileUrl string, start, stop int64) (data []byte, totalSize int64, retryable bool, err error) {
+	req, err := http.NewRequest("GET", fileUrl, nil)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, stop-1))
+
+	r, err := client.Do(req)
+	if err != nil {
+		return nil, 0, true, err
+	}
+	defer CloseResponse(r)
+	if r.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		return nil, 0, false, fmt.Errorf("%s: %s", fileUrl, r.Status)
+	}
+	if r.StatusCode >= 400 {
+		retryable = r.StatusCode == http.StatusNotFound || r.StatusCode >= 500
+		return nil, 0, retryable, fmt.Errorf("%s: %s", fileUrl, r.Status)
+	}
+	if r.StatusCode == http.StatusPartialContent {
+		// Content-Range: bytes <start>-<end>/<total size>
+		contentRange := r.Header.Get("Content-Range")
+		if totalSize, err = strconv.ParseInt(contentRange[strings.LastIndex(contentRange, "/")+1:], 10, 64); err != nil {
+			return nil, 0, false, fmt.Errorf("%s: unexpected content range %q", fileUrl, contentRange)
+		}
+	} else {
+		// the range is ignored, and the whole content returned
+		totalSize = r.ContentLength
+		if _, err = io.CopyN(io.Discard, r.Body, start); err != nil {
+			return nil, 0, true, err
+		}
+	}
+	data = make([]byte, stop-start)
+	if _, err = io.ReadFull(r.Body, data); err != nil {
+		return nil, 0, true, err
+	}
+	return data, totalSize, false, nil
+}
+
 func ReadUrlAsReaderCloser(fileUrl string, jwt string, rangeHeader string) (*http.Response, io.ReadCloser, error) {
 
 	req, err := http.NewRequest("GET", fileUrl, nil)
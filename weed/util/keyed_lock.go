@@ -0,0 +1,47 @@
+package util
+
+import (
+	"sync"
+)
+
+// KeyedLock serializes operations on the same key with one mutex per key,
+// so a lock held for a long time does not block the other keys.
+// The mutex of a key is removed when no one holds or waits for it.
+type KeyedLock struct {
+	locks map[string]*keyedMutex
+	sync.Mutex
+}
+
+type keyedMutex struct {
+	sync.Mutex
+	refCount int
+}
+
+func NewKeyedLock() *KeyedLock {
+	return &KeyedLock{
+		locks: make(map[string]*keyedMutex),
+	}
+}
+
+// Lock locks the mutex for the key, and returns the function to unlock it.
+func (l *KeyedLock) Lock(key string) (unlock func()) {
+	l.Mutex.Lock()
+	lock, found := l.locks[key]
+	if !found {
+		lock = &keyedMutex{}
+		l.locks[key] = lock
+	}
+	lock.refCount++
+	l.Mutex.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		l.Mutex.Lock()
+		lock.refCount--
+		if lock.refCount == 0 {
+			delete(l.locks, key)
+		}
+		l.Mutex.Unlock()
+	}
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedLock(t *testing.T) {
+	l := NewKeyedLock()
+
+	unlockA := l.Lock("a")
+	// another key is not blocked by a held key
+	unlockB := l.Lock("b")
+	unlockB()
+
+	var wg sync.WaitGroup
+	isLocked := make(chan bool, 1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		unlock := l.Lock("a")
+		isLocked <- true
+		unlock()
+	}()
+	select {
+	case <-isLocked:
+		t.Fatal("the same key is locked twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlockA()
+	wg.Wait()
+
+	assert.Equal(t, 0, len(l.locks))
+}
@@ -0,0 +1,38 @@
+package util
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+const stripedLockCount = 256
+
+// StripedLock serializes operations on the same key with a bounded number of mutexes.
+// Different keys may share one mutex, so never acquire two keys at the same time.
+type StripedLock struct {
+	locks [stripedLockCount]sync.RWMutex
+}
+
+func NewStripedLock() *StripedLock {
+	return &StripedLock{}
+}
+
+// Lock locks the mutex for the key, and returns the function to unlock it.
+func (l *StripedLock) Lock(key string) (unlock func()) {
+	lock := l.lockOf(key)
+	lock.Lock()
+	return lock.Unlock
+}
+
+// RLock shares the mutex for the key with the other readers, and returns the function to unlock it.
+func (l *StripedLock) RLock(key string) (unlock func()) {
+	lock := l.lockOf(key)
+	lock.RLock()
+	return lock.RUnlock
+}
+
+func (l *StripedLock) lockOf(key string) *sync.RWMutex {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &l.locks[h.Sum32()%stripedLockCount]
+}
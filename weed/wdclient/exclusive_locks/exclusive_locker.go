@@ -2,10 +2,13 @@ package exclusive_locks
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/wdclient"
 )
@@ -16,6 +19,8 @@ const (
 	InitLockInterval  = 1 * time.Second
 )
 
+// ExclusiveLocker leases a named lock from the master.
+// The lease expires on the master unless renewed with RenewLock, and the clients waiting for it are served in order.
 type ExclusiveLocker struct {
 	token        int64
 	lockTsNs     int64
@@ -23,12 +28,15 @@ type ExclusiveLocker struct {
 	masterClient *wdclient.MasterClient
 	lockName     string
 	message      string
+	clientId     string
+	renewLock    sync.Mutex
 }
 
 func NewExclusiveLocker(masterClient *wdclient.MasterClient, lockName string) *ExclusiveLocker {
 	return &ExclusiveLocker{
 		masterClient: masterClient,
 		lockName:     lockName,
+		clientId:     fmt.Sprintf("%x", rand.Int63()),
 	}
 }
 
@@ -44,72 +52,76 @@ func (l *ExclusiveLocker) GetToken() (token int64, lockTsNs int64) {
 	return atomic.LoadInt64(&l.token), atomic.LoadInt64(&l.lockTsNs)
 }
 
-func (l *ExclusiveLocker) RequestLock(clientName string) {
+// RequestLock blocks until the lock is leased, and writes the position in the queue while waiting.
+// The caller should renew the lease with RenewLock every RenewInterval.
+func (l *ExclusiveLocker) RequestLock(clientName string, writer io.Writer) {
 	if l.isLocked {
 		return
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// retry to get the lease
+	// retry to get the lease, keeping the place in the queue
+	var lastPosition int32
 	for {
-		if err := l.masterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
-			resp, err := client.LeaseAdminToken(ctx, &master_pb.LeaseAdminTokenRequest{
-				PreviousToken:    atomic.LoadInt64(&l.token),
-				PreviousLockTime: atomic.LoadInt64(&l.lockTsNs),
-				LockName:         l.lockName,
-				ClientName:       clientName,
-			})
-			if err == nil {
-				atomic.StoreInt64(&l.token, resp.Token)
-				atomic.StoreInt64(&l.lockTsNs, resp.LockTsNs)
-			}
-			return err
-		}); err != nil {
-			println("lock:", err.Error())
-			time.Sleep(InitLockInterval)
-		} else {
+		resp, err := l.leaseToken(clientName)
+		if err != nil {
+			fmt.Fprintf(writer, "lock: %v\n", err)
+		} else if resp.Token != 0 {
 			break
+		} else if resp.QueuePosition != lastPosition {
+			lastPosition = resp.QueuePosition
+			owner := resp.LockOwner
+			if resp.LockMessage != "" {
+				owner = fmt.Sprintf("%s (%s)", resp.LockOwner, resp.LockMessage)
+			}
+			fmt.Fprintf(writer, "lock: locked by %s, waiting at position %d in the queue\n", owner, resp.QueuePosition)
 		}
+		time.Sleep(InitLockInterval)
 	}
 
 	l.isLocked = true
+}
 
-	// start a goroutine to renew the lease
-	go func() {
-		ctx2, cancel2 := context.WithCancel(context.Background())
-		defer cancel2()
-
-		for l.isLocked {
-			if err := l.masterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
-				resp, err := client.LeaseAdminToken(ctx2, &master_pb.LeaseAdminTokenRequest{
-					PreviousToken:    atomic.LoadInt64(&l.token),
-					PreviousLockTime: atomic.LoadInt64(&l.lockTsNs),
-					LockName:         l.lockName,
-					ClientName:       clientName,
-					Message:          l.message,
-				})
-				if err == nil {
-					atomic.StoreInt64(&l.token, resp.Token)
-					atomic.StoreInt64(&l.lockTsNs, resp.LockTsNs)
-					// println("ts", l.lockTsNs, "token", l.token)
-				}
-				return err
-			}); err != nil {
-				glog.Errorf("failed to renew lock: %v", err)
-				l.isLocked = false
-				return
-			} else {
-				time.Sleep(RenewInterval)
-			}
+// RenewLock extends the lease. The lock is lost if the lease can not be renewed, e.g. already expired and taken by another client.
+func (l *ExclusiveLocker) RenewLock(clientName string) error {
+	l.renewLock.Lock()
+	defer l.renewLock.Unlock()
+	if !l.isLocked {
+		return fmt.Errorf("lock %s is not held", l.lockName)
+	}
+	resp, err := l.leaseToken(clientName)
+	if err == nil && resp.Token == 0 {
+		err = fmt.Errorf("lock %s expired and is taken by %s", l.lockName, resp.LockOwner)
+	}
+	if err != nil {
+		l.isLocked = false
+		return err
+	}
+	return nil
+}
 
+func (l *ExclusiveLocker) leaseToken(clientName string) (resp *master_pb.LeaseAdminTokenResponse, err error) {
+	err = l.masterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
+		var leaseErr error
+		resp, leaseErr = client.LeaseAdminToken(context.Background(), &master_pb.LeaseAdminTokenRequest{
+			PreviousToken:    atomic.LoadInt64(&l.token),
+			PreviousLockTime: atomic.LoadInt64(&l.lockTsNs),
+			LockName:         l.lockName,
+			ClientName:       clientName,
+			Message:          l.message,
+			ClientId:         l.clientId,
+		})
+		if leaseErr == nil && resp.Token != 0 {
+			atomic.StoreInt64(&l.token, resp.Token)
+			atomic.StoreInt64(&l.lockTsNs, resp.LockTsNs)
 		}
-	}()
-
+		return leaseErr
+	})
+	return
 }
 
 func (l *ExclusiveLocker) ReleaseLock() {
+	l.renewLock.Lock()
+	defer l.renewLock.Unlock()
 	l.isLocked = false
 
 	ctx, cancel := context.WithCancel(context.Background())
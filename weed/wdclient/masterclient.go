@@ -17,6 +17,9 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
 )
 
+// the interval to resolve the masters given as dns names again
+const masterResolveInterval = time.Minute
+
 type MasterClient struct {
 	FilerGroup        string
 	clientType        string
@@ -24,13 +27,18 @@ type MasterClient struct {
 	rack              string
 	currentMaster     rpc.ServerAddress
 	currentMasterLock sync.RWMutex
-	masters           map[string]rpc.ServerAddress
-	grpcDialOption    grpc.DialOption
+	// the configured masters, which may be dns names
+	seedMasters    map[string]rpc.ServerAddress
+	masters        map[string]rpc.ServerAddress
+	mastersLock    sync.RWMutex
+	grpcDialOption grpc.DialOption
 
 	*vidMap
 	vidMapCacheSize  int
 	OnPeerUpdate     func(update *master_pb.ClusterNodeUpdate, startFrom time.Time)
 	OnPeerUpdateLock sync.RWMutex
+
+	volumeLocationListeners volumeLocationListeners
 }
 
 func NewMasterClient(grpcDialOption grpc.DialOption, filerGroup string, clientType string, clientHost rpc.ServerAddress, clientDataCenter string, rack string, masters map[string]rpc.ServerAddress) *MasterClient {
@@ -39,7 +47,8 @@ func NewMasterClient(grpcDialOption grpc.DialOption, filerGroup string, clientTy
 		clientType:      clientType,
 		clientHost:      clientHost,
 		rack:            rack,
-		masters:         masters,
+		seedMasters:     masters,
+		masters:         rpc.ResolveServerAddressMap(masters),
 		grpcDialOption:  grpcDialOption,
 		vidMap:          newVidMap(clientDataCenter),
 		vidMapCacheSize: 5,
@@ -110,9 +119,40 @@ func (mc *MasterClient) GetMaster() rpc.ServerAddress {
 
 func (mc *MasterClient) GetMasters() map[string]rpc.ServerAddress {
 	mc.WaitUntilConnected()
+	return mc.getMasters()
+}
+
+func (mc *MasterClient) getMasters() map[string]rpc.ServerAddress {
+	mc.mastersLock.RLock()
+	defer mc.mastersLock.RUnlock()
 	return mc.masters
 }
 
+// resolveMasters resolves the masters given as dns names again, keeping the last resolved masters on failures.
+func (mc *MasterClient) resolveMasters() {
+	if !rpc.HasDnsName(mc.seedMasters) {
+		return
+	}
+	masters := rpc.ResolveServerAddressMap(mc.seedMasters)
+	if len(masters) == 0 {
+		return
+	}
+	mc.mastersLock.Lock()
+	mc.masters = masters
+	mc.mastersLock.Unlock()
+}
+
+func (mc *MasterClient) loopResolveMasters(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		mc.resolveMasters()
+	}
+}
+
 func (mc *MasterClient) WaitUntilConnected() {
 	for {
 		if mc.getCurrentMaster() != "" {
@@ -123,15 +163,29 @@ func (mc *MasterClient) WaitUntilConnected() {
 }
 
 func (mc *MasterClient) KeepConnectedToMaster() {
-	glog.V(1).Infof("%s.%s masterClient bootstraps with masters %v", mc.FilerGroup, mc.clientType, mc.masters)
+	mc.KeepConnectedToMasterWithContext(context.Background())
+}
+
+// KeepConnectedToMasterWithContext keeps connected to the master leader until the context is done.
+func (mc *MasterClient) KeepConnectedToMasterWithContext(ctx context.Context) {
+	glog.V(1).Infof("%s.%s masterClient bootstraps with masters %v", mc.FilerGroup, mc.clientType, mc.seedMasters)
+	if rpc.HasDnsName(mc.seedMasters) {
+		go mc.loopResolveMasters(ctx, masterResolveInterval)
+	}
+	backoff := &connectBackoff{min: time.Second, max: 30 * time.Second}
 	for {
-		mc.tryAllMasters()
-		time.Sleep(time.Second)
+		mc.resolveMasters()
+		connected := mc.tryAllMasters(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff.next(connected)):
+		}
 	}
 }
 
 func (mc *MasterClient) FindLeaderFromOtherPeers(myMasterAddress rpc.ServerAddress) (leader string) {
-	for _, master := range mc.masters {
+	for _, master := range mc.getMasters() {
 		if master == myMasterAddress {
 			continue
 		}
@@ -156,22 +210,30 @@ func (mc *MasterClient) FindLeaderFromOtherPeers(myMasterAddress rpc.ServerAddre
 	return
 }
 
-func (mc *MasterClient) tryAllMasters() {
+// tryAllMasters returns whether it was connected to any master
+func (mc *MasterClient) tryAllMasters(ctx context.Context) (connected bool) {
 	var nextHintedLeader rpc.ServerAddress
-	for _, master := range mc.masters {
-		nextHintedLeader = mc.tryConnectToMaster(master)
-		for nextHintedLeader != "" {
-			nextHintedLeader = mc.tryConnectToMaster(nextHintedLeader)
+	for _, master := range mc.getMasters() {
+		if ctx.Err() != nil {
+			return
+		}
+		var isConnected bool
+		nextHintedLeader, isConnected = mc.tryConnectToMaster(ctx, master)
+		connected = connected || isConnected
+		for nextHintedLeader != "" && ctx.Err() == nil {
+			nextHintedLeader, isConnected = mc.tryConnectToMaster(ctx, nextHintedLeader)
+			connected = connected || isConnected
 		}
 		mc.setCurrentMaster("")
 	}
+	return
 }
 
-func (mc *MasterClient) tryConnectToMaster(master rpc.ServerAddress) (nextHintedLeader rpc.ServerAddress) {
+func (mc *MasterClient) tryConnectToMaster(parentCtx context.Context, master rpc.ServerAddress) (nextHintedLeader rpc.ServerAddress, connected bool) {
 	glog.V(1).Infof("%s.%s masterClient Connecting to master %v", mc.FilerGroup, mc.clientType, master)
 	stats.MasterClientConnectCounter.WithLabelValues("total").Inc()
 	gprcErr := rpc.WithMasterClient(true, master, mc.grpcDialOption, false, func(client master_pb.SeaweedClient) error {
-		ctx, cancel := context.WithCancel(context.Background())
+		ctx, cancel := context.WithCancel(parentCtx)
 		defer cancel()
 
 		stream, err := client.KeepConnected(ctx)
@@ -211,11 +273,14 @@ func (mc *MasterClient) tryConnectToMaster(master rpc.ServerAddress) (nextHinted
 				return nil
 			}
 			mc.resetVidMap()
+			mc.notifyVolumeLocationListeners(&VolumeLocationEvent{IsReset: true})
 			mc.updateVidMap(resp)
 		} else {
 			mc.resetVidMap()
+			mc.notifyVolumeLocationListeners(&VolumeLocationEvent{IsReset: true})
 		}
 		mc.setCurrentMaster(master)
+		connected = true
 
 		for {
 			resp, err := stream.Recv()
@@ -289,6 +354,13 @@ func (mc *MasterClient) updateVidMap(resp *master_pb.KeepConnectedResponse) {
 		glog.V(2).Infof("%s.%s: %s masterClient removes ec volume %d", mc.FilerGroup, mc.clientType, loc.Url, deletedEcVid)
 		mc.deleteEcLocation(deletedEcVid, loc)
 	}
+	mc.notifyVolumeLocationListeners(&VolumeLocationEvent{
+		Location:      loc,
+		NewVids:       resp.VolumeLocation.NewVids,
+		DeletedVids:   resp.VolumeLocation.DeletedVids,
+		NewEcVids:     resp.VolumeLocation.NewEcVids,
+		DeletedEcVids: resp.VolumeLocation.DeletedEcVids,
+	})
 	glog.V(1).Infof("updateVidMap(%s) %s.%s: %s volume add: %d, del: %d, add ec: %d del ec: %d",
 		resp.VolumeLocation.DataCenter, mc.FilerGroup, mc.clientType, loc.Url,
 		len(resp.VolumeLocation.NewVids), len(resp.VolumeLocation.DeletedVids),
@@ -0,0 +1,86 @@
+package wdclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc"
+)
+
+// VolumeLocationEvent is a change of the volume locations reported by the master leader.
+// An event with IsReset set is sent when connected to a master leader, before the current
+// locations are reported again, so the cached locations should be dropped.
+type VolumeLocationEvent struct {
+	IsReset       bool
+	Location      Location
+	NewVids       []uint32
+	DeletedVids   []uint32
+	NewEcVids     []uint32
+	DeletedEcVids []uint32
+}
+
+type volumeLocationListeners struct {
+	listeners map[int64]func(event *VolumeLocationEvent)
+	nextId    int64
+	sync.RWMutex
+}
+
+// SubscribeVolumeLocations calls fn with every volume location change, until unsubscribe is called.
+// fn is called in the loop receiving from the master, so it should not block.
+func (mc *MasterClient) SubscribeVolumeLocations(fn func(event *VolumeLocationEvent)) (unsubscribe func()) {
+	mc.volumeLocationListeners.Lock()
+	defer mc.volumeLocationListeners.Unlock()
+	if mc.volumeLocationListeners.listeners == nil {
+		mc.volumeLocationListeners.listeners = make(map[int64]func(event *VolumeLocationEvent))
+	}
+	id := mc.volumeLocationListeners.nextId
+	mc.volumeLocationListeners.nextId++
+	mc.volumeLocationListeners.listeners[id] = fn
+	return func() {
+		mc.volumeLocationListeners.Lock()
+		delete(mc.volumeLocationListeners.listeners, id)
+		mc.volumeLocationListeners.Unlock()
+	}
+}
+
+func (mc *MasterClient) notifyVolumeLocationListeners(event *VolumeLocationEvent) {
+	mc.volumeLocationListeners.RLock()
+	defer mc.volumeLocationListeners.RUnlock()
+	for _, fn := range mc.volumeLocationListeners.listeners {
+		fn(event)
+	}
+}
+
+// WatchVolumeLocations is for applications reading and writing volume servers directly.
+// It connects to the master leader, follows leader changes and reconnects with backoff,
+// and calls fn with the volume location changes until the context is done.
+// The returned MasterClient also caches the locations, e.g. for LookupFileIdWithFallback.
+func WatchVolumeLocations(ctx context.Context, grpcDialOption grpc.DialOption, clientName string, masters []rpc.ServerAddress, fn func(event *VolumeLocationEvent)) *MasterClient {
+	masterMap := make(map[string]rpc.ServerAddress)
+	for _, master := range masters {
+		masterMap[string(master)] = master
+	}
+	mc := NewMasterClient(grpcDialOption, "", clientName, "", "", "", masterMap)
+	mc.SubscribeVolumeLocations(fn)
+	go mc.KeepConnectedToMasterWithContext(ctx)
+	return mc
+}
+
+// connectBackoff grows the wait between rounds of connecting to all masters, while no master is reachable
+type connectBackoff struct {
+	delay time.Duration
+	min   time.Duration
+	max   time.Duration
+}
+
+func (b *connectBackoff) next(connected bool) time.Duration {
+	if connected || b.delay == 0 {
+		b.delay = b.min
+	} else if b.delay *= 2; b.delay > b.max {
+		b.delay = b.max
+	}
+	return b.delay
+}
@@ -0,0 +1,59 @@
+package wdclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/rpc/master_pb"
+)
+
+func TestSubscribeVolumeLocations(t *testing.T) {
+	mc := NewMasterClient(nil, "", "client", "", "", "", nil)
+
+	var events []*VolumeLocationEvent
+	unsubscribe := mc.SubscribeVolumeLocations(func(event *VolumeLocationEvent) {
+		events = append(events, event)
+	})
+
+	mc.updateVidMap(&master_pb.KeepConnectedResponse{
+		VolumeLocation: &master_pb.VolumeLocation{
+			Url:         "127.0.0.1:8080",
+			PublicUrl:   "127.0.0.1:8080",
+			NewVids:     []uint32{1, 2},
+			DeletedVids: []uint32{3},
+		},
+	})
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if events[0].Location.Url != "127.0.0.1:8080" || len(events[0].NewVids) != 2 || len(events[0].DeletedVids) != 1 {
+		t.Errorf("unexpected event %+v", events[0])
+	}
+	if locations, found := mc.GetLocations(1); !found || len(locations) != 1 {
+		t.Errorf("volume 1 should still be cached, got %+v", locations)
+	}
+
+	unsubscribe()
+	mc.updateVidMap(&master_pb.KeepConnectedResponse{
+		VolumeLocation: &master_pb.VolumeLocation{
+			Url:     "127.0.0.1:8081",
+			NewVids: []uint32{4},
+		},
+	})
+	if len(events) != 1 {
+		t.Errorf("expected no events after unsubscribe, got %d", len(events))
+	}
+}
+
+func TestConnectBackoff(t *testing.T) {
+	b := &connectBackoff{min: time.Second, max: 5 * time.Second}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, want := range expected {
+		if got := b.next(false); got != want {
+			t.Errorf("attempt %d: expected %v, got %v", i, want, got)
+		}
+	}
+	if got := b.next(true); got != time.Second {
+		t.Errorf("expected the backoff to start over after connected, got %v", got)
+	}
+}